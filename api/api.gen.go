@@ -13,6 +13,7 @@ import (
 	"net/url"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/go-chi/chi/v5"
@@ -21,94 +22,992 @@ import (
 
 const (
 	BearerAuthScopes = "BearerAuth.Scopes"
+	CookieAuthScopes = "CookieAuth.Scopes"
 )
 
-// ErrorResponse defines model for ErrorResponse.
-type ErrorResponse struct {
-	Error string `json:"error"`
+// Defines values for DataExportStatus.
+const (
+	Pending DataExportStatus = "pending"
+	Ready   DataExportStatus = "ready"
+)
+
+// Defines values for DeviceSessionKind.
+const (
+	Browser DeviceSessionKind = "browser"
+	Token   DeviceSessionKind = "token"
+)
+
+// Defines values for OrganizationRole.
+const (
+	Admin  OrganizationRole = "admin"
+	Member OrganizationRole = "member"
+	Owner  OrganizationRole = "owner"
+)
+
+// AcceptInvitationRequest defines model for AcceptInvitationRequest.
+type AcceptInvitationRequest struct {
+	Code string `json:"code"`
+}
+
+// AccountDeletion defines model for AccountDeletion.
+type AccountDeletion struct {
+	// RestoreBefore until when the account can be restored
+	RestoreBefore time.Time `json:"restoreBefore"`
+}
+
+// BrowserSessionResponse defines model for BrowserSessionResponse.
+type BrowserSessionResponse struct {
+	CsrfToken string    `json:"csrfToken"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// CreateInvitationRequest defines model for CreateInvitationRequest.
+type CreateInvitationRequest struct {
+	Email string `json:"email"`
+
+	// ExpiresAt when the invitation expires, at most and by default the invitation ttl of the server from now
+	ExpiresAt *time.Time       `json:"expiresAt,omitempty"`
+	Role      OrganizationRole `json:"role"`
+}
+
+// CreateOrganizationRequest defines model for CreateOrganizationRequest.
+type CreateOrganizationRequest struct {
+	Name string `json:"name"`
+
+	// Slug unique lowercase name of the organization in URLs
+	Slug string `json:"slug"`
+}
+
+// DataExport defines model for DataExport.
+type DataExport struct {
+	// ExpiresAt until when the archive is kept
+	ExpiresAt   *time.Time       `json:"expiresAt,omitempty"`
+	ReadyAt     *time.Time       `json:"readyAt,omitempty"`
+	RequestedAt time.Time        `json:"requestedAt"`
+	Status      DataExportStatus `json:"status"`
+
+	// Url signed download URL of the archive
+	Url          *string    `json:"url,omitempty"`
+	UrlExpiresAt *time.Time `json:"urlExpiresAt,omitempty"`
+}
+
+// DataExportStatus defines model for DataExport.Status.
+type DataExportStatus string
+
+// DeleteAccountRequest defines model for DeleteAccountRequest.
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+// DeviceSession defines model for DeviceSession.
+type DeviceSession struct {
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Current set on the browser session the request was made with
+	Current    bool      `json:"current"`
+	DeviceName string    `json:"deviceName"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Id         int       `json:"id"`
+
+	// Ip address the session was last seen from
+	Ip string `json:"ip"`
+
+	// Kind browser sessions are held in a cookie, token sessions by an api client
+	Kind       DeviceSessionKind `json:"kind"`
+	LastSeenAt time.Time         `json:"lastSeenAt"`
+	UserAgent  string            `json:"userAgent"`
+}
+
+// DeviceSessionKind browser sessions are held in a cookie, token sessions by an api client
+type DeviceSessionKind string
+
+// FieldError a single problem with one field of the request
+type FieldError struct {
+	// Field dot separated path of the field, e.g. email or path.id
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
-// GetUserResponse defines model for GetUserResponse.
-type GetUserResponse struct {
-	Email string `json:"email"`
-	Name  string `json:"name"`
-}
+// GetUserResponse defines model for GetUserResponse.
+type GetUserResponse struct {
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+// Invitation defines model for Invitation.
+type Invitation struct {
+	AcceptedAt *time.Time `json:"acceptedAt,omitempty"`
+
+	// AcceptedBy a user who sent or accepted an invitation, missing once the account is erased
+	AcceptedBy *InvitationUser `json:"acceptedBy,omitempty"`
+
+	// Code the secret the invitation is accepted with, only returned on creation
+	Code      *string   `json:"code,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Id        int       `json:"id"`
+
+	// InvitedBy a user who sent or accepted an invitation, missing once the account is erased
+	InvitedBy *InvitationUser  `json:"invitedBy,omitempty"`
+	RevokedAt *time.Time       `json:"revokedAt,omitempty"`
+	Role      OrganizationRole `json:"role"`
+}
+
+// InvitationPreview defines model for InvitationPreview.
+type InvitationPreview struct {
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	// Inviter name of the user who sent the invitation
+	Inviter      *string          `json:"inviter,omitempty"`
+	Organization string           `json:"organization"`
+	Role         OrganizationRole `json:"role"`
+}
+
+// InvitationUser a user who sent or accepted an invitation, missing once the account is erased
+type InvitationUser struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// LoginUserRequest defines model for LoginUserRequest.
+type LoginUserRequest struct {
+	// DeviceName name of the device shown in its sessions, derived from the User-Agent when missing
+	DeviceName *string `json:"deviceName,omitempty"`
+	Email      string  `json:"email"`
+	Password   string  `json:"password"`
+}
+
+// LoginUserResponse defines model for LoginUserResponse.
+type LoginUserResponse struct {
+	RefreshToken string `json:"refreshToken"`
+	Token        string `json:"token"`
+}
+
+// Organization defines model for Organization.
+type Organization struct {
+	CreatedAt time.Time        `json:"createdAt"`
+	Id        int              `json:"id"`
+	Name      string           `json:"name"`
+	Role      OrganizationRole `json:"role"`
+	Slug      string           `json:"slug"`
+}
+
+// OrganizationMember defines model for OrganizationMember.
+type OrganizationMember struct {
+	Email    string           `json:"email"`
+	JoinedAt time.Time        `json:"joinedAt"`
+	Name     string           `json:"name"`
+	Role     OrganizationRole `json:"role"`
+	UserId   int              `json:"userId"`
+}
+
+// OrganizationRole defines model for OrganizationRole.
+type OrganizationRole string
+
+// OrganizationToken defines model for OrganizationToken.
+type OrganizationToken struct {
+	Token string `json:"token"`
+}
+
+// Problem RFC 7807 problem details returned by every failing request
+type Problem struct {
+	// Detail explanation specific to this occurrence of the problem
+	Detail *string       `json:"detail,omitempty"`
+	Errors *[]FieldError `json:"errors,omitempty"`
+
+	// Instance path of the request that failed
+	Instance *string `json:"instance,omitempty"`
+	Status   int     `json:"status"`
+
+	// Title short summary of the problem type in the language negotiated by Accept-Language
+	Title string `json:"title"`
+
+	// TraceId identifier of the request in the server logs
+	TraceId *string `json:"traceId,omitempty"`
+
+	// Type stable machine readable code of the problem, e.g. /problems/user-not-found
+	Type string `json:"type"`
+}
+
+// RefreshTokenRequest defines model for RefreshTokenRequest.
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// RegisterUserRequest defines model for RegisterUserRequest.
+type RegisterUserRequest struct {
+	Email string `json:"email"`
+
+	// Invite code of an invitation to the email, required when registration is invite only
+	Invite *string `json:"invite,omitempty"`
+
+	// Locale preferred locale of the user, e.g. pl; defaults to the one negotiated from Accept-Language
+	Locale   *string `json:"locale,omitempty"`
+	Name     string  `json:"name"`
+	Password string  `json:"password"`
+}
+
+// RestoreAccountRequest defines model for RestoreAccountRequest.
+type RestoreAccountRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// UpdateMemberRequest defines model for UpdateMemberRequest.
+type UpdateMemberRequest struct {
+	Role OrganizationRole `json:"role"`
+}
+
+// GetExportsUserIdParams defines parameters for GetExportsUserId.
+type GetExportsUserIdParams struct {
+	// Expires unix time the URL expires at
+	Expires   int64  `form:"expires" json:"expires"`
+	Signature string `form:"signature" json:"signature"`
+}
+
+// PostInvitationsAcceptJSONRequestBody defines body for PostInvitationsAccept for application/json ContentType.
+type PostInvitationsAcceptJSONRequestBody = AcceptInvitationRequest
+
+// PostLoginJSONRequestBody defines body for PostLogin for application/json ContentType.
+type PostLoginJSONRequestBody = LoginUserRequest
+
+// PostMeDeletionJSONRequestBody defines body for PostMeDeletion for application/json ContentType.
+type PostMeDeletionJSONRequestBody = DeleteAccountRequest
+
+// PostOrganizationsJSONRequestBody defines body for PostOrganizations for application/json ContentType.
+type PostOrganizationsJSONRequestBody = CreateOrganizationRequest
+
+// PostOrganizationsIdInvitationsJSONRequestBody defines body for PostOrganizationsIdInvitations for application/json ContentType.
+type PostOrganizationsIdInvitationsJSONRequestBody = CreateInvitationRequest
+
+// PatchOrganizationsIdMembersUserIdJSONRequestBody defines body for PatchOrganizationsIdMembersUserId for application/json ContentType.
+type PatchOrganizationsIdMembersUserIdJSONRequestBody = UpdateMemberRequest
+
+// PostRefreshJSONRequestBody defines body for PostRefresh for application/json ContentType.
+type PostRefreshJSONRequestBody = RefreshTokenRequest
+
+// PostRegisterJSONRequestBody defines body for PostRegister for application/json ContentType.
+type PostRegisterJSONRequestBody = RegisterUserRequest
+
+// PostRestoreJSONRequestBody defines body for PostRestore for application/json ContentType.
+type PostRestoreJSONRequestBody = RestoreAccountRequest
+
+// PostSessionJSONRequestBody defines body for PostSession for application/json ContentType.
+type PostSessionJSONRequestBody = LoginUserRequest
+
+// ServerInterface represents all server handlers.
+type ServerInterface interface {
+	// download a data export archive
+	// (GET /exports/{userId})
+	GetExportsUserId(w http.ResponseWriter, r *http.Request, userId int, params GetExportsUserIdParams)
+	// accept an invitation to an organization
+	// (POST /invitations/accept)
+	PostInvitationsAccept(w http.ResponseWriter, r *http.Request)
+	// show a pending invitation
+	// (GET /invitations/{code})
+	GetInvitationsCode(w http.ResponseWriter, r *http.Request, code string)
+	// login services
+	// (POST /login)
+	PostLogin(w http.ResponseWriter, r *http.Request)
+	// delete the account of the user
+	// (POST /me/deletion)
+	PostMeDeletion(w http.ResponseWriter, r *http.Request)
+	// export everything held about the user
+	// (GET /me/export)
+	GetMeExport(w http.ResponseWriter, r *http.Request)
+	// list the devices the user is logged in on
+	// (GET /me/sessions)
+	GetMeSessions(w http.ResponseWriter, r *http.Request)
+	// log a device out
+	// (DELETE /me/sessions/{id})
+	DeleteMeSessionsId(w http.ResponseWriter, r *http.Request, id int)
+	// list the organizations of the user
+	// (GET /organizations)
+	GetOrganizations(w http.ResponseWriter, r *http.Request)
+	// create an organization
+	// (POST /organizations)
+	PostOrganizations(w http.ResponseWriter, r *http.Request)
+	// list the invitations of an organization
+	// (GET /organizations/{id}/invitations)
+	GetOrganizationsIdInvitations(w http.ResponseWriter, r *http.Request, id int)
+	// invite someone to an organization by email
+	// (POST /organizations/{id}/invitations)
+	PostOrganizationsIdInvitations(w http.ResponseWriter, r *http.Request, id int)
+	// revoke an invitation
+	// (DELETE /organizations/{id}/invitations/{invitationId})
+	DeleteOrganizationsIdInvitationsInvitationId(w http.ResponseWriter, r *http.Request, id int, invitationId int)
+	// list the members of an organization
+	// (GET /organizations/{id}/members)
+	GetOrganizationsIdMembers(w http.ResponseWriter, r *http.Request, id int)
+	// remove a member from an organization
+	// (DELETE /organizations/{id}/members/{userId})
+	DeleteOrganizationsIdMembersUserId(w http.ResponseWriter, r *http.Request, id int, userId int)
+	// change the role of a member
+	// (PATCH /organizations/{id}/members/{userId})
+	PatchOrganizationsIdMembersUserId(w http.ResponseWriter, r *http.Request, id int, userId int)
+	// get an access token scoped to an organization
+	// (POST /organizations/{id}/token)
+	PostOrganizationsIdToken(w http.ResponseWriter, r *http.Request, id int)
+	// exchange a refresh token for new tokens
+	// (POST /refresh)
+	PostRefresh(w http.ResponseWriter, r *http.Request)
+	// register services
+	// (POST /register)
+	PostRegister(w http.ResponseWriter, r *http.Request)
+	// restore a deleted account
+	// (POST /restore)
+	PostRestore(w http.ResponseWriter, r *http.Request)
+	// log a browser out
+	// (DELETE /session)
+	DeleteSession(w http.ResponseWriter, r *http.Request)
+	// log a browser in
+	// (POST /session)
+	PostSession(w http.ResponseWriter, r *http.Request)
+	// get services by id
+	// (GET /user/{id})
+	GetUserId(w http.ResponseWriter, r *http.Request, id int)
+}
+
+// Unimplemented server implementation that returns http.StatusNotImplemented for each endpoint.
+
+type Unimplemented struct{}
+
+// download a data export archive
+// (GET /exports/{userId})
+func (_ Unimplemented) GetExportsUserId(w http.ResponseWriter, r *http.Request, userId int, params GetExportsUserIdParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// accept an invitation to an organization
+// (POST /invitations/accept)
+func (_ Unimplemented) PostInvitationsAccept(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// show a pending invitation
+// (GET /invitations/{code})
+func (_ Unimplemented) GetInvitationsCode(w http.ResponseWriter, r *http.Request, code string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// login services
+// (POST /login)
+func (_ Unimplemented) PostLogin(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// delete the account of the user
+// (POST /me/deletion)
+func (_ Unimplemented) PostMeDeletion(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// export everything held about the user
+// (GET /me/export)
+func (_ Unimplemented) GetMeExport(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// list the devices the user is logged in on
+// (GET /me/sessions)
+func (_ Unimplemented) GetMeSessions(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// log a device out
+// (DELETE /me/sessions/{id})
+func (_ Unimplemented) DeleteMeSessionsId(w http.ResponseWriter, r *http.Request, id int) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// list the organizations of the user
+// (GET /organizations)
+func (_ Unimplemented) GetOrganizations(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// create an organization
+// (POST /organizations)
+func (_ Unimplemented) PostOrganizations(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// list the invitations of an organization
+// (GET /organizations/{id}/invitations)
+func (_ Unimplemented) GetOrganizationsIdInvitations(w http.ResponseWriter, r *http.Request, id int) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// invite someone to an organization by email
+// (POST /organizations/{id}/invitations)
+func (_ Unimplemented) PostOrganizationsIdInvitations(w http.ResponseWriter, r *http.Request, id int) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// revoke an invitation
+// (DELETE /organizations/{id}/invitations/{invitationId})
+func (_ Unimplemented) DeleteOrganizationsIdInvitationsInvitationId(w http.ResponseWriter, r *http.Request, id int, invitationId int) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// list the members of an organization
+// (GET /organizations/{id}/members)
+func (_ Unimplemented) GetOrganizationsIdMembers(w http.ResponseWriter, r *http.Request, id int) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// remove a member from an organization
+// (DELETE /organizations/{id}/members/{userId})
+func (_ Unimplemented) DeleteOrganizationsIdMembersUserId(w http.ResponseWriter, r *http.Request, id int, userId int) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// change the role of a member
+// (PATCH /organizations/{id}/members/{userId})
+func (_ Unimplemented) PatchOrganizationsIdMembersUserId(w http.ResponseWriter, r *http.Request, id int, userId int) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// get an access token scoped to an organization
+// (POST /organizations/{id}/token)
+func (_ Unimplemented) PostOrganizationsIdToken(w http.ResponseWriter, r *http.Request, id int) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// exchange a refresh token for new tokens
+// (POST /refresh)
+func (_ Unimplemented) PostRefresh(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// register services
+// (POST /register)
+func (_ Unimplemented) PostRegister(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// restore a deleted account
+// (POST /restore)
+func (_ Unimplemented) PostRestore(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// log a browser out
+// (DELETE /session)
+func (_ Unimplemented) DeleteSession(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// log a browser in
+// (POST /session)
+func (_ Unimplemented) PostSession(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// get services by id
+// (GET /user/{id})
+func (_ Unimplemented) GetUserId(w http.ResponseWriter, r *http.Request, id int) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// ServerInterfaceWrapper converts contexts to parameters.
+type ServerInterfaceWrapper struct {
+	Handler            ServerInterface
+	HandlerMiddlewares []MiddlewareFunc
+	ErrorHandlerFunc   func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+type MiddlewareFunc func(http.Handler) http.Handler
+
+// GetExportsUserId operation middleware
+func (siw *ServerInterfaceWrapper) GetExportsUserId(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "userId" -------------
+	var userId int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "userId", runtime.ParamLocationPath, chi.URLParam(r, "userId"), &userId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "userId", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetExportsUserIdParams
+
+	// ------------- Required query parameter "expires" -------------
+
+	if paramValue := r.URL.Query().Get("expires"); paramValue != "" {
+
+	} else {
+		siw.ErrorHandlerFunc(w, r, &RequiredParamError{ParamName: "expires"})
+		return
+	}
+
+	err = runtime.BindQueryParameter("form", true, true, "expires", r.URL.Query(), &params.Expires)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "expires", Err: err})
+		return
+	}
+
+	// ------------- Required query parameter "signature" -------------
+
+	if paramValue := r.URL.Query().Get("signature"); paramValue != "" {
+
+	} else {
+		siw.ErrorHandlerFunc(w, r, &RequiredParamError{ParamName: "signature"})
+		return
+	}
+
+	err = runtime.BindQueryParameter("form", true, true, "signature", r.URL.Query(), &params.Signature)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "signature", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetExportsUserId(w, r, userId, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// PostInvitationsAccept operation middleware
+func (siw *ServerInterfaceWrapper) PostInvitationsAccept(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostInvitationsAccept(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetInvitationsCode operation middleware
+func (siw *ServerInterfaceWrapper) GetInvitationsCode(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "code" -------------
+	var code string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "code", runtime.ParamLocationPath, chi.URLParam(r, "code"), &code)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "code", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetInvitationsCode(w, r, code)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// PostLogin operation middleware
+func (siw *ServerInterfaceWrapper) PostLogin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostLogin(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// PostMeDeletion operation middleware
+func (siw *ServerInterfaceWrapper) PostMeDeletion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostMeDeletion(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetMeExport operation middleware
+func (siw *ServerInterfaceWrapper) GetMeExport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetMeExport(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetMeSessions operation middleware
+func (siw *ServerInterfaceWrapper) GetMeSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetMeSessions(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// DeleteMeSessionsId operation middleware
+func (siw *ServerInterfaceWrapper) DeleteMeSessionsId(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, chi.URLParam(r, "id"), &id)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteMeSessionsId(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetOrganizations operation middleware
+func (siw *ServerInterfaceWrapper) GetOrganizations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetOrganizations(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// PostOrganizations operation middleware
+func (siw *ServerInterfaceWrapper) PostOrganizations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostOrganizations(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetOrganizationsIdInvitations operation middleware
+func (siw *ServerInterfaceWrapper) GetOrganizationsIdInvitations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, chi.URLParam(r, "id"), &id)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetOrganizationsIdInvitations(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// PostOrganizationsIdInvitations operation middleware
+func (siw *ServerInterfaceWrapper) PostOrganizationsIdInvitations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, chi.URLParam(r, "id"), &id)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostOrganizationsIdInvitations(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// DeleteOrganizationsIdInvitationsInvitationId operation middleware
+func (siw *ServerInterfaceWrapper) DeleteOrganizationsIdInvitationsInvitationId(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, chi.URLParam(r, "id"), &id)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "invitationId" -------------
+	var invitationId int
 
-// LoginUserRequest defines model for LoginUserRequest.
-type LoginUserRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
-}
+	err = runtime.BindStyledParameterWithLocation("simple", false, "invitationId", runtime.ParamLocationPath, chi.URLParam(r, "invitationId"), &invitationId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "invitationId", Err: err})
+		return
+	}
 
-// LoginUserResponse defines model for LoginUserResponse.
-type LoginUserResponse struct {
-	RefreshToken string `json:"refreshToken"`
-	Token        string `json:"token"`
-}
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
 
-// RegisterUserRequest defines model for RegisterUserRequest.
-type RegisterUserRequest struct {
-	Email    string `json:"email"`
-	Name     string `json:"name"`
-	Password string `json:"password"`
-}
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
 
-// PostLoginJSONRequestBody defines body for PostLogin for application/json ContentType.
-type PostLoginJSONRequestBody = LoginUserRequest
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteOrganizationsIdInvitationsInvitationId(w, r, id, invitationId)
+	}))
 
-// PostRegisterJSONRequestBody defines body for PostRegister for application/json ContentType.
-type PostRegisterJSONRequestBody = RegisterUserRequest
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
 
-// ServerInterface represents all server handlers.
-type ServerInterface interface {
-	// login services
-	// (POST /login)
-	PostLogin(w http.ResponseWriter, r *http.Request)
-	// register services
-	// (POST /register)
-	PostRegister(w http.ResponseWriter, r *http.Request)
-	// get services by id
-	// (GET /user/{id})
-	GetUserId(w http.ResponseWriter, r *http.Request, id int)
+	handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
-// Unimplemented server implementation that returns http.StatusNotImplemented for each endpoint.
+// GetOrganizationsIdMembers operation middleware
+func (siw *ServerInterfaceWrapper) GetOrganizationsIdMembers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-type Unimplemented struct{}
+	var err error
 
-// login services
-// (POST /login)
-func (_ Unimplemented) PostLogin(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusNotImplemented)
-}
+	// ------------- Path parameter "id" -------------
+	var id int
 
-// register services
-// (POST /register)
-func (_ Unimplemented) PostRegister(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusNotImplemented)
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, chi.URLParam(r, "id"), &id)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetOrganizationsIdMembers(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
-// get services by id
-// (GET /user/{id})
-func (_ Unimplemented) GetUserId(w http.ResponseWriter, r *http.Request, id int) {
-	w.WriteHeader(http.StatusNotImplemented)
+// DeleteOrganizationsIdMembersUserId operation middleware
+func (siw *ServerInterfaceWrapper) DeleteOrganizationsIdMembersUserId(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, chi.URLParam(r, "id"), &id)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "userId" -------------
+	var userId int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "userId", runtime.ParamLocationPath, chi.URLParam(r, "userId"), &userId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "userId", Err: err})
+		return
+	}
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteOrganizationsIdMembersUserId(w, r, id, userId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
-// ServerInterfaceWrapper converts contexts to parameters.
-type ServerInterfaceWrapper struct {
-	Handler            ServerInterface
-	HandlerMiddlewares []MiddlewareFunc
-	ErrorHandlerFunc   func(w http.ResponseWriter, r *http.Request, err error)
+// PatchOrganizationsIdMembersUserId operation middleware
+func (siw *ServerInterfaceWrapper) PatchOrganizationsIdMembersUserId(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, chi.URLParam(r, "id"), &id)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "userId" -------------
+	var userId int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "userId", runtime.ParamLocationPath, chi.URLParam(r, "userId"), &userId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "userId", Err: err})
+		return
+	}
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PatchOrganizationsIdMembersUserId(w, r, id, userId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
-type MiddlewareFunc func(http.Handler) http.Handler
+// PostOrganizationsIdToken operation middleware
+func (siw *ServerInterfaceWrapper) PostOrganizationsIdToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-// PostLogin operation middleware
-func (siw *ServerInterfaceWrapper) PostLogin(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, chi.URLParam(r, "id"), &id)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostOrganizationsIdToken(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// PostRefresh operation middleware
+func (siw *ServerInterfaceWrapper) PostRefresh(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.PostLogin(w, r)
+		siw.Handler.PostRefresh(w, r)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -133,6 +1032,53 @@ func (siw *ServerInterfaceWrapper) PostRegister(w http.ResponseWriter, r *http.R
 	handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
+// PostRestore operation middleware
+func (siw *ServerInterfaceWrapper) PostRestore(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostRestore(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// DeleteSession operation middleware
+func (siw *ServerInterfaceWrapper) DeleteSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteSession(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// PostSession operation middleware
+func (siw *ServerInterfaceWrapper) PostSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostSession(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
 // GetUserId operation middleware
 func (siw *ServerInterfaceWrapper) GetUserId(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -150,6 +1096,8 @@ func (siw *ServerInterfaceWrapper) GetUserId(w http.ResponseWriter, r *http.Requ
 
 	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
 
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.GetUserId(w, r, id)
 	}))
@@ -274,12 +1222,72 @@ func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handl
 		ErrorHandlerFunc:   options.ErrorHandlerFunc,
 	}
 
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/exports/{userId}", wrapper.GetExportsUserId)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/invitations/accept", wrapper.PostInvitationsAccept)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/invitations/{code}", wrapper.GetInvitationsCode)
+	})
 	r.Group(func(r chi.Router) {
 		r.Post(options.BaseURL+"/login", wrapper.PostLogin)
 	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/me/deletion", wrapper.PostMeDeletion)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/me/export", wrapper.GetMeExport)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/me/sessions", wrapper.GetMeSessions)
+	})
+	r.Group(func(r chi.Router) {
+		r.Delete(options.BaseURL+"/me/sessions/{id}", wrapper.DeleteMeSessionsId)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/organizations", wrapper.GetOrganizations)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/organizations", wrapper.PostOrganizations)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/organizations/{id}/invitations", wrapper.GetOrganizationsIdInvitations)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/organizations/{id}/invitations", wrapper.PostOrganizationsIdInvitations)
+	})
+	r.Group(func(r chi.Router) {
+		r.Delete(options.BaseURL+"/organizations/{id}/invitations/{invitationId}", wrapper.DeleteOrganizationsIdInvitationsInvitationId)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/organizations/{id}/members", wrapper.GetOrganizationsIdMembers)
+	})
+	r.Group(func(r chi.Router) {
+		r.Delete(options.BaseURL+"/organizations/{id}/members/{userId}", wrapper.DeleteOrganizationsIdMembersUserId)
+	})
+	r.Group(func(r chi.Router) {
+		r.Patch(options.BaseURL+"/organizations/{id}/members/{userId}", wrapper.PatchOrganizationsIdMembersUserId)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/organizations/{id}/token", wrapper.PostOrganizationsIdToken)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/refresh", wrapper.PostRefresh)
+	})
 	r.Group(func(r chi.Router) {
 		r.Post(options.BaseURL+"/register", wrapper.PostRegister)
 	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/restore", wrapper.PostRestore)
+	})
+	r.Group(func(r chi.Router) {
+		r.Delete(options.BaseURL+"/session", wrapper.DeleteSession)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/session", wrapper.PostSession)
+	})
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/user/{id}", wrapper.GetUserId)
 	})
@@ -290,17 +1298,86 @@ func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handl
 // Base64 encoded, gzipped, json marshaled Swagger object
 var swaggerSpec = []string{
 
-	"H4sIAAAAAAAC/+SWwW7bMAyGX8XgdjTidO0uvq3AMBTYYWi3U5CDYjG2OltSKbqdEfjdB8l20qROlhZL",
-	"dtjNsETx98+PlFeQmcoajZodpCtwWYGVCI+fiQzdorNGO/QvLBmLxArDMvpl/8CNRUjBMSmdQ9vGQPhQ",
-	"K0IJ6azfNo+HbWZxjxlDG8MX5B8OD2WohCpHMsSgRYVHpA7x/e4xBV9NrnSn4aFGx6+RYIVzT4bk8TLW",
-	"EX+Qss8OwiWhK76bn6hHJfGelR09W+cMUWOabjFXjpHe6NCeIr3FunDUQQfbGBxmNSlu7jzCnbhrFIT0",
-	"qeZizbYPWoTXsD6kYLbQ+jOUXpogS3HpV+4yEpwVEMMjklNGQwrTyXRy4b/DWNTCKkjhcjKdXAaBXITE",
-	"SenrGdwynWveM8HK6BsJKXwzjkPJoftgdHxtZOM3ZkYz6hAjrC1VFqKSe2f0pkH903vCJaTwLtl0cNK3",
-	"b/KC7LbtvO3gCho/TKenyNfjG/JJdBkpy51xwZPIIT2qDJ138OovStgeVyPpLZlFiVXEJuqq08bw8ZwC",
-	"lGYkLcpgAVLUjcaAbl1VgpoRj9oYEur78DBOQ7eeiKixYTAK1cWrUm4PE7VnJuw2+wtrB8OijFAwyrPD",
-	"tRYgSkIhmwh/KcedjKt/IEMbjpam1vLslN8cQflA9A7otUNKVkq2XkSOI6D3/ww3MgxbEhUykoN0ttpn",
-	"hPJbVeh/wcVwl6QQ3m/uGqYa42cW9MT5ls3Ry5+fcHru/gkdKuyT4iKSgsX/yNaBCdpf/gGF59f+bO5L",
-	"t0EvR15TFy0az4cfY78DAAD//won++oICwAA",
+	"H4sIAAAAAAAC/+xd65PcNnL/V1DMfbhcuA89fD5vPqQkn5xsTj65dk/lVCQlhSF7ZuAlARrA7Gismv/9",
+	"qhsgCZLgPKR9yvPFXnFAstHox68fAD8lmSorJUFak5x9Skw2h5LTny+yDCp7Lq+F5VYoeQG/LsBY/KnS",
+	"qgJtBdDATOWA/y/5x9cgZ3aenP35eZqUQtb/fJImdlVBcpYYq4WcJet1mmj4dSE05MnZO/eID80oNfkF",
+	"MpusUyRCLaT9KxSANAxfrsFYpeElTJUmKnIwmRaVG50spBUFW85BMjsHxt3jWMYlmwDzN+dJmkyVLrlN",
+	"zpKcWziyooRkG9HdV8eof6nV0oC+BGOIgaZS0kCEg0ZP/6GugObXe2mawMdKaDAviPWfQWf79PBZMXq/",
+	"18At7LDkUHJRdMhxV9JQCJ5+802ftDT5eDRTR5un2F3BZu1EQxbzw1PGLSuVsYzLnE1WLIcpXxS2P9za",
+	"gqkpXTWgr0GzqVYlk2q548KniVYFrRvIRYk85XkpkJ8llBPQyYf+Ld2JvtEzLsVvjqf4pP4S1dyj14yv",
+	"TOc5Y2sjedlXR1qJjfqYJqZYzGL6I35dACvUEnTGDTB8es1MFZDDhGRvL16bJN1gB56mScWtBY2P/r93",
+	"/Oi306PvPvzR/3H04U/1pX/9jz9sFWsiOHXTjbHsr9zyVx8rpWPyOy5vfYuhs7m4BiYMu4LK7i4wwPPV",
+	"7irrpgbGQr7PTcZyuzChYFYgcyeARMFQMtdpstDFcN5GzCTkLFdLWSie42LW6+x5kMQf9eoLzZOfQ5cD",
+	"0QVFJwDeI4yKf8WNWSqd91Tg26f7eaTmMXFKrkUG3qxHzDkp614LmS20BhkRRwOWKSeKE+dNmHHvpWue",
+	"Z2zJDSt5Dmwp7Lx9wUSpArjEN+RE89+9cfhyJ5MmIg+eJKSFGWi6Xg2nwfNcgzHeBjv6keaCG8sMgCST",
+	"HHvLlZD58Hk9XhjGNbA5FDnaIc4ypa4EpMyi22sHTVaMS8YrwbJCILvTRm/8A5ECvCeqN0jsJYDch0kL",
+	"A/rFzC/tZpETeeKn21mt8BnE3DQQsA5R4Sq2IhUT4B8EFPkrrZWOLBUzQs4KYJVWkwJKEimmJLAp3lUb",
+	"BS95SdoTfho0fGqucJ0rrpFwVnE7rx9EN6QMjmfHjPwgU5oGHBNDBhwtwRg+g+38dJS0N8QY8Z9g3xrQ",
+	"49CsQToDOiQvdyCi9uyjbqpFW8OXc4Lg+1mS+p6XK7znDxqmyVnyLyctzD/xGP+kfTOyAO+tkXx36ZzO",
+	"ZhoGwEoYVr+OpCRlShYrpsEuNDoTJRmJKj4nZvT2t5Pjy3GTBgxn+Hkc1HCtrvabUg0tN71oK4AkVemg",
+	"yK6V2Iz723n8pOFawHIfPfgcxhODI7YnRJdo9dhyrpgB2Ze82ENDNBol9EYYXfO487aG5buy+a2JzZ73",
+	"5qx0q15cBtNPWSkMWmmmZAadyFYYBpobimm7Kzgm7bvZMRKwUSP2Ws2EdIZ0BJZ14cf4qrtxzMzVkmIK",
+	"YU3jv1OWgxbXkLv4DYfjO4/INzrI7vmyNRANTckNxbA3BDxrIjYC0IDfY45Lw1SDmY+nFezIL4MsR/Cc",
+	"ITpqaXrT078vxsR7CuznKngb+u6gAWHQGTG02/jyo0sX7GFdf1FC7se2G2cPGqXz6Fr0OOQHNuzp+aNm",
+	"Ktu4dNFLtqilJGS+LenSe0wj+l1e7yj344L+k8PFQzt28cP37Nu/nH7bIOccLBeFaQHRZMXgGvSKTbko",
+	"0H6PoWh35/AV8LEquHToy1SQianImFXMzoVhKnOYP2usqSckiqS0VpreJSyUZptgBBHDunka15qvnEM3",
+	"lsssYttDpF8Hq3bOLXEA8s1JjaHuW2GLyFvMXGnLzKIsuV71Js/wKehK8FrB5WzBZ8AkzJQVFIxMVszl",
+	"uo9e+19jVFnNMziPRDYiB2nFVIDuz1PIMOdYqJmJPpguDGZk+aQAVvJsLiQ+kud0ASF6b4I+djrx/zQn",
+	"qIdHUtmjqVrIfGsChn6tWduwPyb5F4EvGPX0fcezj/Pr3BunYCaMBb0Ra9y0Z3dwdbhG9Vp0sJnTRnCh",
+	"bMrq2Tl4ool83URP7skUNW1MnMYStoXKeEwXKg1T0PhKNyLE015UquLf61y5qelVsqMUhLCGahGQ+Oyb",
+	"Dc5n1xzw+/eXfzz+0/v3l9GM766g6i/pPlH4Fmx14co62/KMjxk+vq0QQTgwMq7HNxEujRQ00MpDttDC",
+	"ri7xUe6NL4Fr0C8Wdt6UIvGmCV1upWNubUU1EUry1cOF00i8VC/0WeIjh/ZeXom/wSpZr0mtpwrv9D4l",
+	"ucw0t9k8SZNr0MYp0+nx6fETfJmqQPJKJGfJs+PT42fEYTsnsk+Aqgzm5JMDQGu8OINIMvcf6A1cnh3T",
+	"6yEsOCnBPydlwjIJkBsmFVN2DpplGsjL8MIcv8fp4EIR19EjYRbLVTrM2xqCYaatBAvaJGfvPHeQ4pY3",
+	"DVpr18vqBaS+BuxNtygXZSh2AfaL1Io+MitKF5DiBH04zDhlLnHMrwvQq5YGP2AjEY1+CWn//DyJUxJ7",
+	"OHKa24WGjY/vq9MHHOyCKlrdp6enCRW5pfVZXF5VhciI+ye/uWR3hNqJkJyo6b9gnfb4FpRYUsbZf1++",
+	"+TubCkzAgmYEA1AAn58+30CH9/3/9otRskvQJvWtkewIUbiEzknxQuRMab+geYp/96pjUrFCyRloVydb",
+	"p8k3p6d3STHKg5a8qPEWOLCKpsbhQspC++oWZzm3nDmNq6dBg09aV25OXPqFbKMyEYX+kV+BabNVnLnQ",
+	"xKXM8bJWrQMO8zfLucjmrFwYi30IvjoCeQc8hI77mL2QY5lXJTOIWYWflAnaN4xz5m2d7aXKVxvWZ791",
+	"GWsWWa/XfeVb76Vg+5HRyUGMSHWnau1iUqdfdyytTqnqWGGCq0FkPLlLMpo8om7UPPA1jqBn90TQUis5",
+	"Y/9z9P3lxQ9HFBIQ2O7XQe/BOErFzCKbhwo5VbpRVjKPwrbWEiudvjIQJnUflpH0eIzgQojE3n1ABxuC",
+	"rXcf1h9Cq+rmMwyDuOwo29C8fsIIahwu/Yx5gsZyAiuEvBrGW1NRFMbH3WF0JckKHzMEXfgeJtyoVr4j",
+	"SGsEXQVm9HuVw074KnMDN6CrffrlPtyi1RyWf0ZMZ8v1+1G6YNXn3K+myuFx6lujPljrYJz5zp0Ok1Fh",
+	"Csz1hxBk6OWpHHBLnn1Q2rljlz4sdUTYTDwiHosMzD248ybXiCgYF+PuPadDoG0ZMBcGYwcH1nPqncqp",
+	"UdL1reJvDqeeuAv3EWUQzVJZ5jKVSMDT7+6SAKsUK7lc1TjMpMyAC2AvwOrV0YupRbbKnF1wC69FKSyb",
+	"A89BmyRN6r/OPiXB8FgXV6ZkTrm2JReI+adKo8OyeuWSQoOAtA1v1w/TbPV1Dm1VCSd52KodDZpcH5/p",
+	"lK2bOnadt0IJzpScCl16sQVkIC8oLAqrws7Dj3R2dyWcuebOTuN2yjgtsYvIBKlOtdAzzIEqzWZK5WPB",
+	"1Y/Q9KXfju2NdjzuZH+f3mRk1+nAj0hLzXtvZNKaf46xuKozzTPKZwj1EIKtJriope0QeT2SyKvX6sIL",
+	"6nKuJe9rCajcdLoGss0FNaYWmu72aPjkDYZhnP3v+U9Nrq4tHmKSMQ26a7QqgJpsyKKT0a1fD9fIkG4p",
+	"abIQRVPknPDsaqbRix+zt2RlnSl164MjHLV4zSPdFC9LHJepEowz1ZzFGtGdfjjrHbace9vf7dH345pe",
+	"LEyq1prvTBJ64IUo0J8wCUumJIwEfj+C30Jwiwg32KiwOTsc8FM1/EGZv0l7vxc1E0CLQYLwIE3o12AM",
+	"vN5Q34id43ypz51P1MIObUKtzYFViAj1ZT3qC8V6p9aR7m6JQfdIJJpD6WrNEs6RdnlpyEDaYuU3DAht",
+	"DlJ3W1JXCGODhsygyCEMhriI74RkSg4k7+STcCVQ58WGfukVRULtRpCU4Lxv/fBbNpT2eziYsaoybKn0",
+	"lZCzY2pJMMaNav2/MGbhAbuwzFi+6rgMO4eV9wcxO+9gdqsVsQJqP56jkYx6AiO5P/EFddVhpu95cjZG",
+	"AMj8XssXASMOEPrxQGhSZEyh1pWMgJyvwnqpGePedDG1cB0nJ2ExYqODfNMZeBc+slu43O4iO1Pp2Oam",
+	"Bq2mKTaWkEU6eMlb9pLd9egEa+lIGsxt6Tb9KlnbO+ASuoa8I7VAj+WhhuJ686mo8Q3oO+WjntxZib/D",
+	"S9+Zf6jvP3oX+d1du0jc6UElY469yF+JvXL6EC/Mh1cciA9r9aNZple0n6Ed6exXs4dNuMM58N9Nw5Kw",
+	"aZsDqq+mTcUWx/tSLuOFuBrLzXTM3nke1Oi3wfeOjbgbDH8LmOG8U43fhhiCtXQhtYQlGqYgjL4XA9lf",
+	"i4ONJPPjwmBhmMlU5RoDuXTNwKqHFe+p+Skko2kIrVGoVLZBol8d2AtUybcj9ZdkK+Lr7OWlnVB166fv",
+	"Ba1bRBumqmn4szebPmkurGkbYbTfImP8lvxFnY33CfFuR1SwVz8DMr20hR+fOOdmjkNcJfWYuf0CzaYV",
+	"t7gmddjUlwvyUlBaxij/906Y9QEa79tC0J/ZIvvkFpq9NnuJh4OdDy4hSNqUfEXGdaa5PxVBqwIa+3tw",
+	"Gw/ObXhzaVQJSkKkL5Z2BaMT2AWHn3xq/3G+Oc/emHmKqmp03d7eT62TZUBL7obiwgjN3DkMAXYcmns7",
+	"V4beQgVkl21HOSUZPfa7CDuvNtSng6s9Af+6fDwzP+4rzgNe3LvfSKNtwKJL4m0WBYKl9Ty9R+N9gPJR",
+	"u93qVgcDPkrz7dkcTkK4o+javUm6Detdre5rMet+ITtYftSAe6y8c83hPPdg+/eRyYgckbJDRqMJQFBz",
+	"gOtC0OESijZ1HfIah7zGAaDul9fwChXNaWw2bJ2t72N4tM4fFMCxDVG2ADOtkaSGUl1D/S+EmMMswwq7",
+	"9SiHQYeH0vUGTtKzd0aSnqKxTfN3jx8juyNEc+Kml7r0Njbz74QvHQF+kQ7Y8gFiS9KeVlYeMa5sTew9",
+	"1P669Xi1KHKMUwuYWsqpYuMll87yfD1w1llez3h3/k8ss01HpAxMwxtnnbM5lzMExSuflupnCIaWfWDK",
+	"rwAq02S2ovljpOFgyu82fR07M2in1HXEkeDCelk55Jcfpi/xmvzIE8wHT3IPPSZdyXHZ53oRRsKI5kzO",
+	"eOH0goqUVDjlQf9356RoOlRSGFe+bPJP9bEc4TuP2bmtm187Xedpux3IlUb99yjalrxASTun8+xW5KzP",
+	"0H3cGZ1dEzlutjG98CYEW/YPCZpDguaQoNliUWdgB6YvXJ1IqsbbtXGTiqC7u+VGmPrDHwsDZ85+ezvB",
+	"Mq61gGC7ZEoQPvyaizCs5JoS/ab9okt4pnv3dNwxm+nPmL2lNubYCbYP8UATZDMti/ld4mMbk05PVory",
+	"mbtik+l8TCgoej7A8zLgYx0d96aGGCVcb6e/rolrXIEvQdJXIc5zKCtlQWaro7/Byh9OgoaBThdhhk+h",
+	"WPX0ufn0jja2XuZ6//UVrBz6qQq+wr5cr9b0c/M2e3ThBxyzn2t45Fsd2qaD4ZlGveORG9ONNRu/1aED",
+	"1X7ecmxy2pwCVUM21OJx4+LZelvWZXg69S20d+3yXY/hxwsip/AO5Lg+zeUem78aEuq9nfBRGHsPYGiD",
+	"0JHzq88j89KHBmgmruE+kFHDs+YoJcJFUm1TwOYYtWAm9xApu8/eOCvCrSets/6GJsS7tgoJN7yEgQ2k",
+	"1WKVVjMNxnnQp0/v2n/1iaK+CD8n8mBTmlEuplPQIG3H3R5OwXoAp2Cd7+DVa0fdHoSFB7yLxk16i+98",
+	"ujtmbdSlv5W58jvt61O0mrZvfw5LPwkxATx0mVmVkkr4DyaFxy25/PrC+C9QoGLwGcf/4iItuc7NuLd0",
+	"BN+Ws4yds/+5KeWaQc0nnR/sKVN3HTM3R/95Do3ISeMKfCvqvaRLneV3WN7pFeShuHZ042AoH9NxgV4x",
+	"Ge8LJI2rTw/p9o/EWjjqc2T2PiWjjhQwz+K+F5sVwBtT8eSO1TI85Oah7cN9uImz7Yc+1LuH6dSHsZ1Y",
+	"l5Zra4LRtaQ0nxCW7L+srd4g2Hciw4Q0FjiVcN2mKSFnPmY/Zm4XrLHc+sJm8HGv9tvz/h2cNNuAz6A1",
+	"W7CaD/WnbgdV89GnJpP2fnF6+ixzBNHf8P94U/O9Y38YW7iW3vyM+fhQnb6+w4NfuuX1k9yUcKsXx6Bk",
+	"xIyFAdu14Jdgj5w4jn0y1z0RnUGwSCbZ9PWR9eHo4uDo4sOJxAeIsd+JxIFJF74UgivanEk21n2/W99Q",
+	"k2d5bHXZ/mfON+WQKLWSc8vv9VixltGHuuzjOFdsmIT8muqvzewmKxLK9Xr9zwEA0SXfhDyLAAA=",
 }
 
 // GetSwagger returns the content of the embedded swagger specification file
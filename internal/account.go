@@ -6,18 +6,23 @@ import (
 	"log/slog"
 	"net/http"
 	"scratch/api"
+	"scratch/internal/authorization/middlewares"
+	"scratch/internal/problem"
 	userManager "scratch/internal/services"
 )
 
 var _ api.ServerInterface = (*accountHandler)(nil)
 
 type accountHandler struct {
-	am  userManager.AccountManager
-	log slog.Logger
+	am userManager.AccountManager
+	// om serves the organization operations, which are not found without it.
+	om      userManager.OrganizationManager
+	cookies middlewares.CookieConfig
+	log     slog.Logger
 }
 
-func NewAccountHandler(am userManager.AccountManager, log slog.Logger) *accountHandler {
-	return &accountHandler{am: am, log: log}
+func NewAccountHandler(am userManager.AccountManager, cookies middlewares.CookieConfig, log slog.Logger) *accountHandler {
+	return &accountHandler{am: am, cookies: cookies, log: log}
 }
 
 func (ah *accountHandler) PostRegister(w http.ResponseWriter, r *http.Request) {
@@ -25,20 +30,14 @@ func (ah *accountHandler) PostRegister(w http.ResponseWriter, r *http.Request) {
 	var request api.RegisterUserRequest
 	err := json.NewDecoder(r.Body).Decode(&request)
 	if err != nil {
-		ah.writeJSON(w, http.StatusBadRequest, "can not create user account")
+		problem.Write(w, r, problem.New(problem.InvalidRequest, "detail.invalid-json"))
 		return
 	}
 
 	id, err := ah.am.CreateUser(ctx, request)
 	if err != nil {
-		switch {
-		case errors.Is(err, userManager.UserExistErr):
-			ah.writeJSON(w, http.StatusConflict, api.ErrorResponse{Error: "user with that email already exists"})
-			return
-		default:
-			ah.writeJSON(w, http.StatusInternalServerError, api.ErrorResponse{Error: "Internal server error"})
-			return
-		}
+		problem.WriteError(w, r, ah.log, err)
+		return
 	}
 	responseBody := struct {
 		Id int `json:"id"`
@@ -47,7 +46,6 @@ func (ah *accountHandler) PostRegister(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ah.writeJSON(w, http.StatusCreated, responseBody)
-	return
 }
 
 func (ah *accountHandler) GetUserId(w http.ResponseWriter, r *http.Request, id int) {
@@ -61,31 +59,173 @@ func (ah *accountHandler) PostLogin(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&body)
 	if err != nil {
-		ah.writeJSON(w, http.StatusBadRequest, api.ErrorResponse{Error: "decode request error"})
+		problem.Write(w, r, problem.New(problem.InvalidRequest, "detail.invalid-json"))
 		return
 	}
 	response, err := ah.am.Login(r.Context(), body)
 	if err != nil {
-		switch {
-		case errors.Is(err, userManager.UserNotFoundErr):
-			ah.writeJSON(w, http.StatusNotFound, api.ErrorResponse{Error: "user not found"})
-			return
-		case errors.Is(err, userManager.IncorrectPasswordErr):
-			ah.writeJSON(w, http.StatusBadRequest, api.ErrorResponse{Error: "incorrect credentials"})
-		default:
-			ah.writeJSON(w, http.StatusInternalServerError, api.ErrorResponse{Error: "internal server error"})
+		problem.WriteError(w, r, ah.log, err)
+		return
+	}
+	ah.writeJSON(w, http.StatusOK, response)
+}
+
+func (ah *accountHandler) PostSession(w http.ResponseWriter, r *http.Request) {
+	var body api.PostSessionJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		problem.Write(w, r, problem.New(problem.InvalidRequest, "detail.invalid-json"))
+		return
+	}
+	session, err := ah.am.LoginBrowser(r.Context(), body, ah.cookies.TTL)
+	if err != nil {
+		problem.WriteError(w, r, ah.log, err)
+		return
+	}
+
+	middlewares.SetSessionCookies(w, ah.cookies, session.Token, session.CSRFToken, session.ExpiresAt)
+	w.Header().Set("Cache-Control", "no-store")
+	ah.writeJSON(w, http.StatusOK, api.BrowserSessionResponse{CsrfToken: session.CSRFToken, ExpiresAt: session.ExpiresAt})
+}
+
+func (ah *accountHandler) DeleteSession(w http.ResponseWriter, r *http.Request) {
+	// the authenticator only lets requests with a live session cookie through
+	if cookie, err := r.Cookie(ah.cookies.Name); err == nil {
+		if err := ah.am.Logout(r.Context(), cookie.Value); err != nil {
+			problem.WriteError(w, r, ah.log, err)
 			return
 		}
 	}
+	middlewares.ClearSessionCookies(w, ah.cookies)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ah *accountHandler) PostRefresh(w http.ResponseWriter, r *http.Request) {
+	var body api.PostRefreshJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		problem.Write(w, r, problem.New(problem.InvalidRequest, "detail.invalid-json"))
+		return
+	}
+	response, err := ah.am.Refresh(r.Context(), body.RefreshToken)
+	if err != nil {
+		problem.WriteError(w, r, ah.log, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
 	ah.writeJSON(w, http.StatusOK, response)
 }
 
+func (ah *accountHandler) GetMeSessions(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middlewares.UserIDFrom(r.Context())
+	var current string
+	if cookie, err := r.Cookie(ah.cookies.Name); err == nil && r.Header.Get("Authorization") == "" {
+		current = cookie.Value
+	}
+
+	sessions, err := ah.am.Sessions(r.Context(), userID, current)
+	if err != nil {
+		problem.WriteError(w, r, ah.log, err)
+		return
+	}
+	response := make([]api.DeviceSession, len(sessions))
+	for i, s := range sessions {
+		kind := api.Token
+		if s.Browser {
+			kind = api.Browser
+		}
+		response[i] = api.DeviceSession{
+			Id:         s.ID,
+			Kind:       kind,
+			DeviceName: s.DeviceName,
+			UserAgent:  s.UserAgent,
+			Ip:         s.IP,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+			ExpiresAt:  s.ExpiresAt,
+			Current:    s.Current,
+		}
+	}
+	ah.writeJSON(w, http.StatusOK, response)
+}
+
+func (ah *accountHandler) DeleteMeSessionsId(w http.ResponseWriter, r *http.Request, id int) {
+	userID, _ := middlewares.UserIDFrom(r.Context())
+	if err := ah.am.RevokeSession(r.Context(), userID, id); err != nil {
+		// the session of the id is missing, not the one of the request
+		if errors.Is(err, userManager.SessionNotFoundErr) {
+			problem.Write(w, r, problem.New(problem.SessionNotFound, ""))
+			return
+		}
+		problem.WriteError(w, r, ah.log, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ah *accountHandler) PostMeDeletion(w http.ResponseWriter, r *http.Request) {
+	var body api.PostMeDeletionJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		problem.Write(w, r, problem.New(problem.InvalidRequest, "detail.invalid-json"))
+		return
+	}
+	userID, _ := middlewares.UserIDFrom(r.Context())
+	restoreBefore, err := ah.am.DeleteAccount(r.Context(), userID, body.Password)
+	if err != nil {
+		problem.WriteError(w, r, ah.log, err)
+		return
+	}
+	// the sessions are gone, the cookies of a browser with them
+	middlewares.ClearSessionCookies(w, ah.cookies)
+	ah.writeJSON(w, http.StatusAccepted, api.AccountDeletion{RestoreBefore: restoreBefore})
+}
+
+func (ah *accountHandler) PostRestore(w http.ResponseWriter, r *http.Request) {
+	var body api.PostRestoreJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		problem.Write(w, r, problem.New(problem.InvalidRequest, "detail.invalid-json"))
+		return
+	}
+	if err := ah.am.RestoreAccount(r.Context(), body); err != nil {
+		problem.WriteError(w, r, ah.log, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ah *accountHandler) GetMeExport(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middlewares.UserIDFrom(r.Context())
+	export, err := ah.am.DataExport(r.Context(), userID)
+	if err != nil {
+		problem.WriteError(w, r, ah.log, err)
+		return
+	}
+	code := http.StatusOK
+	if export.Status == api.Pending {
+		code = http.StatusAccepted
+	}
+	ah.writeJSON(w, code, export)
+}
+
+func (ah *accountHandler) GetExportsUserId(w http.ResponseWriter, r *http.Request, userId int, params api.GetExportsUserIdParams) {
+	archive, err := ah.am.DataExportArchive(r.Context(), userId, params)
+	if err != nil {
+		problem.WriteError(w, r, ah.log, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="chatto-export.zip"`)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(archive); err != nil {
+		ah.log.Warn("write data export", "err", err)
+	}
+}
+
 func (ah *accountHandler) writeJSON(w http.ResponseWriter, code int, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
 
 	err := json.NewEncoder(w).Encode(data)
 	if err != nil {
-		ah.log.Warn("encode response: %w", err)
+		ah.log.Warn("encode response", "err", err)
 	}
 }
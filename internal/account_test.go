@@ -13,6 +13,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -71,7 +72,7 @@ func Test_accountHandler_PostRegister(t *testing.T) {
 			tokenMaker := session.NewJsonWebToken(session.Config{
 				TokenSecret: []byte("real secret"),
 			})
-			ctrl := services.NewAccountService(storage.New(db), tokenMaker, slog.Logger{})
+			ctrl := services.NewAccountService(storage.New(dbpool), storage.NewTxManager(dbpool, storage.TxOptions{Isolation: pgx.Serializable, MaxRetries: 3}), tokenMaker, nil, slog.Logger{})
 
 			_, err := ctrl.CreateUser(context.Background(), api.RegisterUserRequest{
 				Email:    "norbi1@wp.pl",
@@ -81,13 +82,15 @@ func Test_accountHandler_PostRegister(t *testing.T) {
 			assert.NoError(t, err)
 		},
 		verifyResponse: func(t *testing.T, r *http.Response) {
-			wanted := api.ErrorResponse{Error: "user with that email already exists"}
 			t.Helper()
 			assert.Equal(t, http.StatusConflict, r.StatusCode)
-			var res api.ErrorResponse
+			assert.Equal(t, "application/problem+json", r.Header.Get("Content-Type"))
+			var res api.Problem
 			err := json.NewDecoder(r.Body).Decode(&res)
 			assert.NoError(t, err)
-			assert.Equal(t, wanted, res)
+			assert.Equal(t, "/problems/user-exists", res.Type)
+			assert.Equal(t, http.StatusConflict, res.Status)
+			assert.NotNil(t, res.TraceId)
 		},
 	}))
 }
@@ -110,7 +113,7 @@ func Test_accountHandler_PostLogin(t *testing.T) {
 				tokenMaker := session.NewJsonWebToken(session.Config{
 					TokenSecret: []byte("real secret"),
 				})
-				ctrl := services.NewAccountService(storage.New(db), tokenMaker, slog.Logger{})
+				ctrl := services.NewAccountService(storage.New(dbpool), storage.NewTxManager(dbpool, storage.TxOptions{Isolation: pgx.Serializable, MaxRetries: 3}), tokenMaker, nil, slog.Logger{})
 
 				_, err := ctrl.CreateUser(context.Background(), api.RegisterUserRequest{
 					Email:    "norbi22@wp.pl",
@@ -136,11 +139,11 @@ func Test_accountHandler_PostLogin(t *testing.T) {
 				t.Helper()
 			},
 			verifyResponse: func(t *testing.T, r *http.Response) {
-				var resBody api.ErrorResponse
+				var resBody api.Problem
 				err := json.NewDecoder(r.Body).Decode(&resBody)
 				assert.NoError(t, err)
 
-				assert.NotEmpty(t, resBody)
+				assert.Equal(t, "/problems/user-not-found", resBody.Type)
 			},
 		},
 		{
@@ -152,7 +155,7 @@ func Test_accountHandler_PostLogin(t *testing.T) {
 				tokenMaker := session.NewJsonWebToken(session.Config{
 					TokenSecret: []byte("real secret"),
 				})
-				ctrl := services.NewAccountService(storage.New(db), tokenMaker, slog.Logger{})
+				ctrl := services.NewAccountService(storage.New(dbpool), storage.NewTxManager(dbpool, storage.TxOptions{Isolation: pgx.Serializable, MaxRetries: 3}), tokenMaker, nil, slog.Logger{})
 
 				_, err := ctrl.CreateUser(context.Background(), api.RegisterUserRequest{
 					Email:    "norbi@wp.pl",
@@ -162,11 +165,14 @@ func Test_accountHandler_PostLogin(t *testing.T) {
 				assert.NoError(t, err)
 			},
 			verifyResponse: func(t *testing.T, r *http.Response) {
-				var resBody api.ErrorResponse
-				err := json.NewDecoder(r.Body).Decode(&resBody)
+				var resBody api.Problem
+				decoder := json.NewDecoder(r.Body)
+				err := decoder.Decode(&resBody)
 				assert.NoError(t, err)
 
-				assert.NotEmpty(t, resBody)
+				assert.Equal(t, "/problems/invalid-credentials", resBody.Type)
+				// a single problem, not the problem followed by a second response
+				assert.False(t, decoder.More())
 			},
 		},
 	}
@@ -191,3 +197,69 @@ func Test_accountHandler_PostLogin(t *testing.T) {
 		})
 	}
 }
+
+func Test_accountHandler_Sessions(t *testing.T) {
+	srv := initService(t)
+	client := srv.Client()
+
+	tokenMaker := session.NewJsonWebToken(session.Config{TokenSecret: []byte("real secret")})
+	_, err := services.NewAccountService(storage.New(dbpool), storage.NewTxManager(dbpool, storage.TxOptions{Isolation: pgx.Serializable, MaxRetries: 3}), tokenMaker, nil, slog.Logger{}).
+		CreateUser(context.Background(), api.RegisterUserRequest{Email: "devices@wp.pl", Name: "konu33", Password: "Test123!"})
+	assert.NoError(t, err)
+
+	send := func(method, path, token, body string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequestWithContext(context.Background(), method, srv.URL+path, strings.NewReader(body))
+		assert.NoError(t, err)
+		req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:131.0) Gecko/20100101 Firefox/131.0")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		res, err := client.Do(req)
+		assert.NoError(t, err)
+		return res
+	}
+	decode := func(res *http.Response, v any) {
+		t.Helper()
+		defer res.Body.Close()
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(v))
+	}
+
+	res := send(http.MethodPost, "/login", "", `{"email":"devices@wp.pl", "password":"Test123!", "deviceName":"Joe's laptop"}`)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	var login api.LoginUserResponse
+	decode(res, &login)
+
+	res = send(http.MethodPost, "/refresh", "", fmt.Sprintf(`{"refreshToken":%q}`, login.RefreshToken))
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	var refreshed api.LoginUserResponse
+	decode(res, &refreshed)
+	assert.NotEqual(t, login.RefreshToken, refreshed.RefreshToken)
+
+	// the refresh token is single use
+	res = send(http.MethodPost, "/refresh", "", fmt.Sprintf(`{"refreshToken":%q}`, login.RefreshToken))
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	res.Body.Close()
+
+	res = send(http.MethodGet, "/me/sessions", refreshed.Token, "")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	var sessions []api.DeviceSession
+	decode(res, &sessions)
+	if assert.Len(t, sessions, 1) {
+		assert.Equal(t, "Joe's laptop", sessions[0].DeviceName)
+		assert.Equal(t, api.Token, sessions[0].Kind)
+		assert.Equal(t, "127.0.0.1", sessions[0].Ip)
+	}
+
+	res = send(http.MethodDelete, fmt.Sprintf("/me/sessions/%d", sessions[0].Id), refreshed.Token, "")
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	res.Body.Close()
+
+	res = send(http.MethodDelete, fmt.Sprintf("/me/sessions/%d", sessions[0].Id), refreshed.Token, "")
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	res.Body.Close()
+
+	res = send(http.MethodPost, "/refresh", "", fmt.Sprintf(`{"refreshToken":%q}`, refreshed.RefreshToken))
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	res.Body.Close()
+}
@@ -1,37 +1,162 @@
 package middlewares
 
 import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
 	"net/http"
+	"scratch/api"
 	"scratch/internal/authorization/session"
+	"scratch/internal/problem"
+	"scratch/internal/services"
+	"strconv"
 	"strings"
 )
 
-// TODO: testy gdzie wrzucone fakowy struct niby ze validator ale nie
+// CSRFHeader carries the csrf token of a browser session on state changing requests.
+const CSRFHeader = "X-CSRF-Token"
 
-func AuthMiddleware(next http.Handler) http.Handler {
+// SessionLookup finds the browser session of a session cookie.
+type SessionLookup interface {
+	BrowserSession(ctx context.Context, token string) (services.BrowserSession, error)
+}
+
+// Authenticator authenticates the operations whose security requirements the
+// generated server put in the request context. API clients send a bearer
+// token, browsers the session cookie; both are accepted where the spec lists
+// both schemes, the bearer token taking precedence. A bearer token scoped to
+// an organization puts it in the context as well, see TenantIDFrom.
+type Authenticator struct {
+	tokens   session.TokenIdentifier
+	sessions SessionLookup
+	cookies  CookieConfig
+	log      slog.Logger
+}
+
+func NewAuthenticator(tokens session.TokenIdentifier, sessions SessionLookup, cookies CookieConfig, log slog.Logger) *Authenticator {
+	return &Authenticator{tokens: tokens, sessions: sessions, cookies: cookies, log: log}
+}
+
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		h := r.Header.Get("Authorization")
-		if h == "" {
-			w.WriteHeader(403)
+		ctx := r.Context()
+		bearer := ctx.Value(api.BearerAuthScopes) != nil
+		cookie := ctx.Value(api.CookieAuthScopes) != nil
+		if !bearer && !cookie {
 			next.ServeHTTP(w, r)
 			return
 		}
-		token := strings.Split(h, " ")
 
-		v := session.NewJsonWebToken(session.Config{
-			TokenSecret: []byte("YELLOW SUBMARINE, BLACK WIZARDRY"),
-		})
+		if authorization := r.Header.Get("Authorization"); bearer && authorization != "" {
+			userID, tenantID, ok := a.bearer(authorization)
+			if !ok {
+				unauthorized(w, r)
+				return
+			}
+			ctx = WithUserID(ctx, userID)
+			if tenantID != 0 {
+				ctx = WithTenantID(ctx, tenantID)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
 
-		if len(token) > 1 && token[1] != "" {
-			err := v.ValidateToken(token[1])
+		if c, err := r.Cookie(a.cookies.Name); cookie && err == nil {
+			s, err := a.sessions.BrowserSession(ctx, c.Value)
+			if errors.Is(err, services.SessionNotFoundErr) {
+				ClearSessionCookies(w, a.cookies)
+				unauthorized(w, r)
+				return
+			}
 			if err != nil {
-				w.WriteHeader(403)
-				next.ServeHTTP(w, r)
+				problem.WriteError(w, r, a.log, err)
 				return
 			}
-			next.ServeHTTP(w, r)
+			// browsers attach the cookie to requests of other sites as well,
+			// only pages of ours can read the csrf token to send it back
+			if !safeMethod(r.Method) && !validCSRFToken(r.Header.Get(CSRFHeader), s.CSRFToken) {
+				problem.Write(w, r, problem.New(problem.CSRFFailed, ""))
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithUserID(ctx, s.UserID)))
+			return
 		}
-		w.WriteHeader(403)
-		next.ServeHTTP(w, r)
+
+		unauthorized(w, r)
 	})
 }
+
+// bearer returns the user of the token, and its organization, 0 when the
+// token is not scoped to one.
+func (a *Authenticator) bearer(authorization string) (int, int, bool) {
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return 0, 0, false
+	}
+	token := strings.TrimPrefix(authorization, "Bearer ")
+	id, err := a.tokens.UserID(token)
+	if err != nil {
+		return 0, 0, false
+	}
+	userID, err := strconv.Atoi(id)
+	if err != nil {
+		return 0, 0, false
+	}
+	tenant, err := a.tokens.TenantID(token)
+	if err != nil {
+		return 0, 0, false
+	}
+	if tenant == "" {
+		return userID, 0, true
+	}
+	tenantID, err := strconv.Atoi(tenant)
+	if err != nil {
+		return 0, 0, false
+	}
+	return userID, tenantID, true
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	problem.Write(w, r, problem.New(problem.Unauthorized, ""))
+}
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func validCSRFToken(got, want string) bool {
+	return got != "" && subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
+
+type userIDKey struct{}
+
+// WithUserID returns ctx carrying the id of the authenticated user.
+func WithUserID(ctx context.Context, id int) context.Context {
+	return context.WithValue(ctx, userIDKey{}, id)
+}
+
+// UserIDFrom returns the id stored by WithUserID.
+func UserIDFrom(ctx context.Context) (int, bool) {
+	id, ok := ctx.Value(userIDKey{}).(int)
+	return id, ok
+}
+
+type tenantIDKey struct{}
+
+// WithTenantID returns ctx carrying the organization the bearer token of the
+// request is scoped to.
+func WithTenantID(ctx context.Context, id int) context.Context {
+	return context.WithValue(ctx, tenantIDKey{}, id)
+}
+
+// TenantIDFrom returns the id stored by WithTenantID, false when the request
+// is not scoped to an organization.
+func TenantIDFrom(ctx context.Context) (int, bool) {
+	id, ok := ctx.Value(tenantIDKey{}).(int)
+	return id, ok
+}
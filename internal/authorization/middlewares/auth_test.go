@@ -1,63 +1,348 @@
 package middlewares
 
 import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
-	"net/url"
+	"scratch/api"
+	"scratch/internal/authorization/session"
+	"scratch/internal/services"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-// TODO: handle more test cases
-func TestAuthMiddleware(t *testing.T) {
+// tokens maps a token to the user it was issued for, followed by @ and the
+// organization when it is scoped to one.
+type tokens map[string]string
+
+func (t tokens) UserID(token string) (string, error) {
+	if id, ok := t[token]; ok {
+		userID, _, _ := strings.Cut(id, "@")
+		return userID, nil
+	}
+	return "", errors.New("invalid token")
+}
+
+func (t tokens) TenantID(token string) (string, error) {
+	if id, ok := t[token]; ok {
+		_, tenantID, _ := strings.Cut(id, "@")
+		return tenantID, nil
+	}
+	return "", errors.New("invalid token")
+}
+
+type sessions map[string]services.BrowserSession
+
+func (s sessions) BrowserSession(ctx context.Context, token string) (services.BrowserSession, error) {
+	if token == "broken" {
+		return services.BrowserSession{}, errors.New("connection refused")
+	}
+	if session, ok := s[token]; ok {
+		return session, nil
+	}
+	return services.BrowserSession{}, services.SessionNotFoundErr
+}
+
+// secured mimics the generated server, which stores the security schemes of
+// the operation in the context before running the middlewares.
+func secured(schemes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			for _, scheme := range schemes {
+				ctx = context.WithValue(ctx, scheme, []string{})
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func TestAuthenticator_Middleware(t *testing.T) {
+	cookies := CookieConfig{Name: "session", Secure: true, SameSite: http.SameSiteLaxMode, TTL: time.Hour}
+	authenticator := NewAuthenticator(
+		tokens{"valid": "7", "tenant": "7@3", "bad tenant": "7@acme"},
+		sessions{"cookie": {UserID: 9, CSRFToken: "csrf"}},
+		cookies,
+		*slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+
 	tests := []struct {
 		name           string
+		schemes        []string
+		method         string
+		prepareRequest func(r *http.Request)
 		statusCode     int
-		prepareRequest func(string) *http.Request
+		userID         string
+		tenantID       string
 	}{
 		{
-			name:       "success - not authorized",
-			statusCode: 403,
-			prepareRequest: func(u string) *http.Request {
-				serverURL, err := url.Parse(u)
-				require.NoError(t, err)
-				r := &http.Request{
-					Method: http.MethodGet,
-					URL:    serverURL,
-				}
-				return r
+			name:           "success - operation without security",
+			method:         http.MethodPost,
+			prepareRequest: func(r *http.Request) {},
+			statusCode:     http.StatusOK,
+		},
+		{
+			name:    "success - bearer token",
+			schemes: []string{api.BearerAuthScopes, api.CookieAuthScopes},
+			method:  http.MethodGet,
+			prepareRequest: func(r *http.Request) {
+				r.Header.Set("Authorization", "Bearer valid")
 			},
+			statusCode: http.StatusOK,
+			userID:     "7",
 		},
 		{
-			name:       "403 - invalid token length",
-			statusCode: 403,
-			prepareRequest: func(s string) *http.Request {
-				serverURL, err := url.Parse(s)
-				require.NoError(t, err)
-				r := &http.Request{
-					Method: http.MethodGet,
-					URL:    serverURL,
-				}
-				r.Header = make(http.Header)
+			name:    "success - bearer token scoped to an organization",
+			schemes: []string{api.BearerAuthScopes},
+			method:  http.MethodGet,
+			prepareRequest: func(r *http.Request) {
+				r.Header.Set("Authorization", "Bearer tenant")
+			},
+			statusCode: http.StatusOK,
+			userID:     "7",
+			tenantID:   "3",
+		},
+		{
+			name:    "success - session cookie of a safe request needs no csrf token",
+			schemes: []string{api.BearerAuthScopes, api.CookieAuthScopes},
+			method:  http.MethodGet,
+			prepareRequest: func(r *http.Request) {
+				r.AddCookie(&http.Cookie{Name: "session", Value: "cookie"})
+			},
+			statusCode: http.StatusOK,
+			userID:     "9",
+		},
+		{
+			name:    "success - session cookie with csrf token",
+			schemes: []string{api.CookieAuthScopes},
+			method:  http.MethodDelete,
+			prepareRequest: func(r *http.Request) {
+				r.AddCookie(&http.Cookie{Name: "session", Value: "cookie"})
+				r.Header.Set(CSRFHeader, "csrf")
+			},
+			statusCode: http.StatusOK,
+			userID:     "9",
+		},
+		{
+			name:           "fail - no credentials",
+			schemes:        []string{api.BearerAuthScopes},
+			method:         http.MethodGet,
+			prepareRequest: func(r *http.Request) {},
+			statusCode:     http.StatusUnauthorized,
+		},
+		{
+			name:    "fail - invalid token length",
+			schemes: []string{api.BearerAuthScopes},
+			method:  http.MethodGet,
+			prepareRequest: func(r *http.Request) {
 				r.Header.Set("Authorization", "invalid")
-				return r
 			},
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:    "fail - invalid bearer token is not rescued by a cookie",
+			schemes: []string{api.BearerAuthScopes, api.CookieAuthScopes},
+			method:  http.MethodGet,
+			prepareRequest: func(r *http.Request) {
+				r.Header.Set("Authorization", "Bearer forged")
+				r.AddCookie(&http.Cookie{Name: "session", Value: "cookie"})
+			},
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:    "fail - bearer token where only cookies are accepted",
+			schemes: []string{api.CookieAuthScopes},
+			method:  http.MethodDelete,
+			prepareRequest: func(r *http.Request) {
+				r.Header.Set("Authorization", "Bearer valid")
+			},
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:    "fail - organization is not an id",
+			schemes: []string{api.BearerAuthScopes},
+			method:  http.MethodGet,
+			prepareRequest: func(r *http.Request) {
+				r.Header.Set("Authorization", "Bearer bad tenant")
+			},
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:    "fail - expired session",
+			schemes: []string{api.CookieAuthScopes},
+			method:  http.MethodGet,
+			prepareRequest: func(r *http.Request) {
+				r.AddCookie(&http.Cookie{Name: "session", Value: "expired"})
+			},
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:    "fail - missing csrf token",
+			schemes: []string{api.CookieAuthScopes},
+			method:  http.MethodDelete,
+			prepareRequest: func(r *http.Request) {
+				r.AddCookie(&http.Cookie{Name: "session", Value: "cookie"})
+			},
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:    "fail - wrong csrf token",
+			schemes: []string{api.CookieAuthScopes},
+			method:  http.MethodPost,
+			prepareRequest: func(r *http.Request) {
+				r.AddCookie(&http.Cookie{Name: "session", Value: "cookie"})
+				r.Header.Set(CSRFHeader, "guess")
+			},
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:    "fail - session store unavailable",
+			schemes: []string{api.CookieAuthScopes},
+			method:  http.MethodGet,
+			prepareRequest: func(r *http.Request) {
+				r.AddCookie(&http.Cookie{Name: "session", Value: "broken"})
+			},
+			statusCode: http.StatusInternalServerError,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-
-			s := httptest.NewServer(AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				w.WriteHeader(200)
+			var userID, tenantID string
+			handler := secured(tt.schemes...)(authenticator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if id, ok := UserIDFrom(r.Context()); ok {
+					userID = strconv.Itoa(id)
+				}
+				if id, ok := TenantIDFrom(r.Context()); ok {
+					tenantID = strconv.Itoa(id)
+				}
 			})))
 
-			r := tt.prepareRequest(s.URL)
+			r := httptest.NewRequest(tt.method, "/", nil)
+			tt.prepareRequest(r)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, r)
+
+			require.Equal(t, tt.statusCode, rec.Code)
+			assert.Equal(t, tt.userID, userID)
+			assert.Equal(t, tt.tenantID, tenantID)
+		})
+	}
+}
+
+func TestAuthenticator_Middleware_RefreshToken(t *testing.T) {
+	tokenManager := session.NewJsonWebToken(session.Config{TokenSecret: []byte("secret")})
+	authenticator := NewAuthenticator(tokenManager, sessions{}, CookieConfig{Name: "session"}, *slog.New(slog.NewTextHandler(io.Discard, nil)))
+	handler := secured(api.BearerAuthScopes)(authenticator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	tokens, err := tokenManager.GenerateTokens("7")
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+tokens.Token)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+tokens.RefreshToken)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	require.Equal(t, http.StatusUnauthorized, rec.Code, "a refresh token is no bearer token")
+}
+
+type roles map[int]string
+
+func (r roles) HasRole(ctx context.Context, userID int, role string) (bool, error) {
+	if userID == 13 {
+		return false, errors.New("connection refused")
+	}
+	return r[userID] == role, nil
+}
+
+func TestAuthenticator_RequireRole(t *testing.T) {
+	authenticator := NewAuthenticator(
+		tokens{"admin": "7", "user": "8", "broken": "13"},
+		sessions{"admin": {UserID: 7, CSRFToken: "csrf"}},
+		CookieConfig{Name: "session"},
+		*slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+	handler := authenticator.RequireRole(roles{7: "admin"}, "admin")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
 
-			client := http.Client{}
-			res, err := client.Do(r)
-			require.Equal(t, res.StatusCode, tt.statusCode)
-			require.NoError(t, err)
+	tests := []struct {
+		name       string
+		prepare    func(r *http.Request)
+		wantStatus int
+	}{
+		{
+			name:       "success - bearer token of an admin",
+			prepare:    func(r *http.Request) { r.Header.Set("Authorization", "Bearer admin") },
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "success - session of an admin",
+			prepare:    func(r *http.Request) { r.AddCookie(&http.Cookie{Name: "session", Value: "admin"}) },
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "fail - not authenticated",
+			prepare:    func(r *http.Request) {},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "fail - role not granted",
+			prepare:    func(r *http.Request) { r.Header.Set("Authorization", "Bearer user") },
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "fail - roles unavailable",
+			prepare:    func(r *http.Request) { r.Header.Set("Authorization", "Bearer broken") },
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/dev/mail/", nil)
+			tt.prepare(req)
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
 		})
 	}
 }
+
+func TestSessionCookies(t *testing.T) {
+	cookies := CookieConfig{Name: "session", Secure: true, SameSite: http.SameSiteStrictMode}
+	expires := time.Now().Add(time.Hour)
+
+	rec := httptest.NewRecorder()
+	SetSessionCookies(rec, cookies, "token", "csrf", expires)
+	set := rec.Result().Cookies()
+	require.Len(t, set, 2)
+
+	assert.Equal(t, "session", set[0].Name)
+	assert.Equal(t, "token", set[0].Value)
+	assert.True(t, set[0].HttpOnly)
+	assert.True(t, set[0].Secure)
+	assert.Equal(t, http.SameSiteStrictMode, set[0].SameSite)
+	assert.Equal(t, "session_csrf", set[1].Name)
+	assert.False(t, set[1].HttpOnly, "pages read the csrf token from its cookie")
+
+	rec = httptest.NewRecorder()
+	ClearSessionCookies(rec, cookies)
+	for _, cookie := range rec.Result().Cookies() {
+		assert.Empty(t, cookie.Value)
+		assert.Negative(t, cookie.MaxAge)
+	}
+}
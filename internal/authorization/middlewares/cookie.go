@@ -0,0 +1,71 @@
+package middlewares
+
+import (
+	"net/http"
+	"time"
+)
+
+// CookieConfig describes the cookies of browser sessions.
+type CookieConfig struct {
+	// Name of the HttpOnly session cookie, the csrf cookie is Name+"_csrf"
+	// and the one of the login and signup forms Name+"_login_csrf".
+	Name   string
+	Domain string
+	// Secure restricts the cookies to https, browsers treat localhost as
+	// secure so it only needs disabling for plain http on other hosts.
+	Secure   bool
+	SameSite http.SameSite
+	// TTL is how long a browser session lasts.
+	TTL time.Duration
+}
+
+func (c CookieConfig) csrfName() string {
+	return c.Name + "_csrf"
+}
+
+func (c CookieConfig) loginCSRFName() string {
+	return c.Name + "_login_csrf"
+}
+
+// SetLoginCSRFCookie hands a browser without a session the csrf token its
+// login and signup forms echo. It lasts as long as the browser does.
+func SetLoginCSRFCookie(w http.ResponseWriter, c CookieConfig, token string) {
+	http.SetCookie(w, c.cookie(c.loginCSRFName(), token, time.Time{}, true))
+}
+
+// LoginCSRFToken is the token of SetLoginCSRFCookie, empty when r has none.
+func LoginCSRFToken(r *http.Request, c CookieConfig) string {
+	cookie, err := r.Cookie(c.loginCSRFName())
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// SetSessionCookies hands the browser its session. The csrf cookie is
+// readable by scripts, so pages can copy it into the X-CSRF-Token header.
+func SetSessionCookies(w http.ResponseWriter, c CookieConfig, token, csrfToken string, expires time.Time) {
+	http.SetCookie(w, c.cookie(c.Name, token, expires, true))
+	http.SetCookie(w, c.cookie(c.csrfName(), csrfToken, expires, false))
+}
+
+// ClearSessionCookies makes the browser forget its session.
+func ClearSessionCookies(w http.ResponseWriter, c CookieConfig) {
+	for _, cookie := range []*http.Cookie{c.cookie(c.Name, "", time.Time{}, true), c.cookie(c.csrfName(), "", time.Time{}, false)} {
+		cookie.MaxAge = -1
+		http.SetCookie(w, cookie)
+	}
+}
+
+func (c CookieConfig) cookie(name, value string, expires time.Time, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   c.Domain,
+		Expires:  expires,
+		Secure:   c.Secure,
+		HttpOnly: httpOnly,
+		SameSite: c.SameSite,
+	}
+}
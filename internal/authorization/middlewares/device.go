@@ -0,0 +1,21 @@
+package middlewares
+
+import (
+	"net/http"
+	"scratch/internal/ratelimit"
+	"scratch/internal/services"
+)
+
+// Device puts the user agent and ip of the client in the request context,
+// where the services read them to tell the sessions of a user apart.
+func Device(trustForwardedFor bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := services.WithDevice(r.Context(), services.Device{
+				UserAgent: r.UserAgent(),
+				IP:        ratelimit.ClientIP(r, trustForwardedFor),
+			})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"scratch/internal/services"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDevice(t *testing.T) {
+	tests := []struct {
+		name              string
+		trustForwardedFor bool
+		want              services.Device
+	}{
+		{
+			name: "success - remote address",
+			want: services.Device{UserAgent: "curl/8.5.0", IP: "192.0.2.1"},
+		},
+		{
+			name:              "success - forwarded by a trusted proxy",
+			trustForwardedFor: true,
+			want:              services.Device{UserAgent: "curl/8.5.0", IP: "198.51.100.7"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got services.Device
+			handler := Device(tt.trustForwardedFor)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = services.DeviceFrom(r.Context())
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/me/sessions", nil)
+			r.RemoteAddr = "192.0.2.1:51234"
+			r.Header.Set("User-Agent", "curl/8.5.0")
+			// the client made up the left entry, the proxy added the right one
+			r.Header.Set("X-Forwarded-For", "10.1.2.3, 198.51.100.7")
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -33,6 +33,21 @@ func (m *MockIdentityGenerator) EXPECT() *MockIdentityGeneratorMockRecorder {
 	return m.recorder
 }
 
+// GenerateTenantToken mocks base method.
+func (m *MockIdentityGenerator) GenerateTenantToken(userID, tenantID string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateTenantToken", userID, tenantID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateTenantToken indicates an expected call of GenerateTenantToken.
+func (mr *MockIdentityGeneratorMockRecorder) GenerateTenantToken(userID, tenantID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateTenantToken", reflect.TypeOf((*MockIdentityGenerator)(nil).GenerateTenantToken), userID, tenantID)
+}
+
 // GenerateTokens mocks base method.
 func (m *MockIdentityGenerator) GenerateTokens(userID string) (UserSession, error) {
 	m.ctrl.T.Helper()
@@ -61,3 +76,169 @@ func (mr *MockIdentityGeneratorMockRecorder) ValidateToken(t interface{}) *gomoc
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateToken", reflect.TypeOf((*MockIdentityGenerator)(nil).ValidateToken), t)
 }
+
+// MockUserIdentifier is a mock of UserIdentifier interface.
+type MockUserIdentifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserIdentifierMockRecorder
+}
+
+// MockUserIdentifierMockRecorder is the mock recorder for MockUserIdentifier.
+type MockUserIdentifierMockRecorder struct {
+	mock *MockUserIdentifier
+}
+
+// NewMockUserIdentifier creates a new mock instance.
+func NewMockUserIdentifier(ctrl *gomock.Controller) *MockUserIdentifier {
+	mock := &MockUserIdentifier{ctrl: ctrl}
+	mock.recorder = &MockUserIdentifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserIdentifier) EXPECT() *MockUserIdentifierMockRecorder {
+	return m.recorder
+}
+
+// UserID mocks base method.
+func (m *MockUserIdentifier) UserID(t string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserID", t)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserID indicates an expected call of UserID.
+func (mr *MockUserIdentifierMockRecorder) UserID(t interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserID", reflect.TypeOf((*MockUserIdentifier)(nil).UserID), t)
+}
+
+// MockTenantIdentifier is a mock of TenantIdentifier interface.
+type MockTenantIdentifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockTenantIdentifierMockRecorder
+}
+
+// MockTenantIdentifierMockRecorder is the mock recorder for MockTenantIdentifier.
+type MockTenantIdentifierMockRecorder struct {
+	mock *MockTenantIdentifier
+}
+
+// NewMockTenantIdentifier creates a new mock instance.
+func NewMockTenantIdentifier(ctrl *gomock.Controller) *MockTenantIdentifier {
+	mock := &MockTenantIdentifier{ctrl: ctrl}
+	mock.recorder = &MockTenantIdentifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTenantIdentifier) EXPECT() *MockTenantIdentifierMockRecorder {
+	return m.recorder
+}
+
+// TenantID mocks base method.
+func (m *MockTenantIdentifier) TenantID(t string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TenantID", t)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TenantID indicates an expected call of TenantID.
+func (mr *MockTenantIdentifierMockRecorder) TenantID(t interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TenantID", reflect.TypeOf((*MockTenantIdentifier)(nil).TenantID), t)
+}
+
+// MockTokenIdentifier is a mock of TokenIdentifier interface.
+type MockTokenIdentifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockTokenIdentifierMockRecorder
+}
+
+// MockTokenIdentifierMockRecorder is the mock recorder for MockTokenIdentifier.
+type MockTokenIdentifierMockRecorder struct {
+	mock *MockTokenIdentifier
+}
+
+// NewMockTokenIdentifier creates a new mock instance.
+func NewMockTokenIdentifier(ctrl *gomock.Controller) *MockTokenIdentifier {
+	mock := &MockTokenIdentifier{ctrl: ctrl}
+	mock.recorder = &MockTokenIdentifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTokenIdentifier) EXPECT() *MockTokenIdentifierMockRecorder {
+	return m.recorder
+}
+
+// TenantID mocks base method.
+func (m *MockTokenIdentifier) TenantID(t string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TenantID", t)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TenantID indicates an expected call of TenantID.
+func (mr *MockTokenIdentifierMockRecorder) TenantID(t interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TenantID", reflect.TypeOf((*MockTokenIdentifier)(nil).TenantID), t)
+}
+
+// UserID mocks base method.
+func (m *MockTokenIdentifier) UserID(t string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserID", t)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserID indicates an expected call of UserID.
+func (mr *MockTokenIdentifierMockRecorder) UserID(t interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserID", reflect.TypeOf((*MockTokenIdentifier)(nil).UserID), t)
+}
+
+// MockKeySource is a mock of KeySource interface.
+type MockKeySource struct {
+	ctrl     *gomock.Controller
+	recorder *MockKeySourceMockRecorder
+}
+
+// MockKeySourceMockRecorder is the mock recorder for MockKeySource.
+type MockKeySourceMockRecorder struct {
+	mock *MockKeySource
+}
+
+// NewMockKeySource creates a new mock instance.
+func NewMockKeySource(ctrl *gomock.Controller) *MockKeySource {
+	mock := &MockKeySource{ctrl: ctrl}
+	mock.recorder = &MockKeySourceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockKeySource) EXPECT() *MockKeySourceMockRecorder {
+	return m.recorder
+}
+
+// Keys mocks base method.
+func (m *MockKeySource) Keys() [][]byte {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Keys")
+	ret0, _ := ret[0].([][]byte)
+	return ret0
+}
+
+// Keys indicates an expected call of Keys.
+func (mr *MockKeySourceMockRecorder) Keys() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Keys", reflect.TypeOf((*MockKeySource)(nil).Keys))
+}
@@ -1,6 +1,8 @@
 package session
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
@@ -12,6 +14,22 @@ import (
 
 //go:generate mockgen -package=session -destination=session.gen.go -source=$GOFILE
 
+// RefreshTokenTTL is how long a refresh token, and the session it belongs
+// to, lasts.
+const RefreshTokenTTL = 24 * time.Hour
+
+// AccessTokenTTL is how long an access token lasts. A bearer request is
+// authenticated by the token alone, so a disabled user or a revoked session
+// keeps access until the tokens issued before expire; refreshing checks both.
+const AccessTokenTTL = 15 * time.Minute
+
+// Token types, kept in the typ claim so that a refresh token can not
+// authenticate a request.
+const (
+	accessType  = "access"
+	refreshType = "refresh"
+)
+
 type UserSession struct {
 	Token        string
 	RefreshToken string
@@ -19,11 +37,53 @@ type UserSession struct {
 
 type IdentityGenerator interface {
 	GenerateTokens(userID string) (UserSession, error)
+	// GenerateTenantToken returns an access token of the user scoped to the
+	// organization tenantID. It comes without a refresh token, a client asks
+	// for a new one with the token of the user. The token only narrows what
+	// the user may reach, every operation on the organization checks the
+	// membership again, so it is worth nothing once the user is removed.
+	GenerateTenantToken(userID, tenantID string) (string, error)
 	ValidateToken(t string) error
 }
 
+// UserIdentifier reads the id of the user a token was issued for.
+type UserIdentifier interface {
+	UserID(t string) (string, error)
+}
+
+// TenantIdentifier reads the organization a token is scoped to, empty for a
+// token of the user alone.
+type TenantIdentifier interface {
+	TenantID(t string) (string, error)
+}
+
+// TokenIdentifier reads who a token was issued for.
+type TokenIdentifier interface {
+	UserIdentifier
+	TenantIdentifier
+}
+
+// KeySource supplies token keys at runtime, so they can be rotated without a
+// restart. The first key signs new tokens, all of them are accepted on validation.
+type KeySource interface {
+	Keys() [][]byte
+}
+
 type Config struct {
 	TokenSecret []byte
+	// KeySource takes precedence over TokenSecret when set.
+	KeySource KeySource
+}
+
+func (c Config) keys() [][]byte {
+	if c.KeySource != nil {
+		return c.KeySource.Keys()
+	}
+	return [][]byte{c.TokenSecret}
+}
+
+func (c Config) signingKey() []byte {
+	return c.keys()[0]
 }
 
 type jwtTokenManager struct {
@@ -43,11 +103,12 @@ func NewPasetoTokenManager(config Config) *pasetoTokenManager {
 }
 
 func (p pasetoTokenManager) GenerateTokens(userID string) (UserSession, error) {
-	if len(p.config.TokenSecret) != chacha20poly1305.KeySize {
+	key := p.config.signingKey()
+	if len(key) != chacha20poly1305.KeySize {
 		return UserSession{}, nil
 	}
 	now := time.Now()
-	exp := now.Add(24 * time.Hour)
+	exp := now.Add(AccessTokenTTL)
 	nbt := now
 
 	jsonToken := paseto.JSONToken{
@@ -59,10 +120,11 @@ func (p pasetoTokenManager) GenerateTokens(userID string) (UserSession, error) {
 		Expiration: exp,
 		NotBefore:  nbt,
 	}
+	jsonToken.Set("typ", accessType)
 
 	// Encrypt data
 	v2 := paseto.NewV2()
-	token, err := v2.Encrypt(p.config.TokenSecret, jsonToken, nil)
+	token, err := v2.Encrypt(key, jsonToken, nil)
 	if err != nil {
 		return UserSession{}, fmt.Errorf("encrypt token: %w", err)
 	}
@@ -73,11 +135,12 @@ func (p pasetoTokenManager) GenerateTokens(userID string) (UserSession, error) {
 		Jti:        "123",
 		Subject:    "test_subject",
 		IssuedAt:   now,
-		Expiration: time.Now().Add(24 * time.Hour),
-		NotBefore:  time.Now().Add(24 * time.Hour),
+		Expiration: time.Now().Add(RefreshTokenTTL),
+		NotBefore:  time.Now().Add(RefreshTokenTTL),
 	}
+	jsonRefreshToken.Set("typ", refreshType)
 
-	refreshToken, err := v2.Encrypt(p.config.TokenSecret, jsonRefreshToken, nil)
+	refreshToken, err := v2.Encrypt(key, jsonRefreshToken, nil)
 	if err != nil {
 		return UserSession{}, fmt.Errorf("encrypt token: %w", err)
 	}
@@ -89,11 +152,38 @@ func (p pasetoTokenManager) GenerateTokens(userID string) (UserSession, error) {
 
 }
 
+func (p pasetoTokenManager) GenerateTenantToken(userID, tenantID string) (string, error) {
+	key := p.config.signingKey()
+	if len(key) != chacha20poly1305.KeySize {
+		return "", fmt.Errorf("paseto key is %d bytes instead of %d", len(key), chacha20poly1305.KeySize)
+	}
+	now := time.Now()
+	jsonToken := paseto.JSONToken{
+		Subject:    userID,
+		IssuedAt:   now,
+		Expiration: now.Add(AccessTokenTTL),
+		NotBefore:  now,
+	}
+	jsonToken.Set("typ", accessType)
+	jsonToken.Set("org", tenantID)
+
+	token, err := paseto.NewV2().Encrypt(key, jsonToken, nil)
+	if err != nil {
+		return "", fmt.Errorf("encrypt token: %w", err)
+	}
+	return token, nil
+}
+
 func (p pasetoTokenManager) ValidateToken(token string) error {
 	v2 := paseto.NewV2()
 	var newJsonToken paseto.JSONToken
 
-	err := v2.Decrypt(token, p.config.TokenSecret, &newJsonToken, nil)
+	var err error
+	for _, key := range p.config.keys() {
+		if err = v2.Decrypt(token, key, &newJsonToken, nil); err == nil {
+			break
+		}
+	}
 	if err != nil {
 		return fmt.Errorf("decrypt token: %w", err)
 	}
@@ -108,22 +198,31 @@ func (p pasetoTokenManager) ValidateToken(token string) error {
 func (j jwtTokenManager) GenerateTokens(userID string) (UserSession, error) {
 	tokenClaims := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"id":  userID,
-		"exp": time.Now().Add(1 * time.Hour).Unix(),
+		"typ": accessType,
+		"exp": time.Now().Add(AccessTokenTTL).Unix(),
 		"iat": time.Now().Unix(),
 	})
 
-	token, err := tokenClaims.SignedString(j.config.TokenSecret)
+	token, err := tokenClaims.SignedString(j.config.signingKey())
 	if err != nil {
 		return UserSession{}, fmt.Errorf("problem to sign token: %w", err)
 	}
 
+	// the jti keeps refresh tokens issued within the same second apart, the
+	// sessions are found by them
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return UserSession{}, fmt.Errorf("generate token id: %w", err)
+	}
 	refreshTokenClaims := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"id":  userID,
-		"exp": time.Now().Add(24 * time.Hour).Unix(),
+		"jti": hex.EncodeToString(jti),
+		"typ": refreshType,
+		"exp": time.Now().Add(RefreshTokenTTL).Unix(),
 		"iat": time.Now().Unix(),
 	})
 
-	refreshToken, err := refreshTokenClaims.SignedString(j.config.TokenSecret)
+	refreshToken, err := refreshTokenClaims.SignedString(j.config.signingKey())
 	if err != nil {
 		return UserSession{}, fmt.Errorf("problem to sign token: %w", err)
 	}
@@ -134,21 +233,85 @@ func (j jwtTokenManager) GenerateTokens(userID string) (UserSession, error) {
 	}, nil
 }
 
+func (j jwtTokenManager) GenerateTenantToken(userID, tenantID string) (string, error) {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"id":  userID,
+		"org": tenantID,
+		"typ": accessType,
+		"exp": time.Now().Add(AccessTokenTTL).Unix(),
+		"iat": time.Now().Unix(),
+	}).SignedString(j.config.signingKey())
+	if err != nil {
+		return "", fmt.Errorf("problem to sign token: %w", err)
+	}
+	return token, nil
+}
+
 func (j jwtTokenManager) ValidateToken(t string) error {
-	token, err := jwt.Parse(t, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
+	_, err := j.parse(t)
+	return err
+}
 
-		return j.config.TokenSecret, nil
-	})
+// UserID returns the id of the user the access token was issued for.
+func (j jwtTokenManager) UserID(t string) (string, error) {
+	claims, err := j.parseAccess(t)
+	if err != nil {
+		return "", err
+	}
+	id, ok := claims["id"].(string)
+	if !ok || id == "" {
+		return "", errors.New("token has no user id")
+	}
+	return id, nil
+}
+
+// TenantID returns the organization the access token is scoped to, empty when
+// it is not.
+func (j jwtTokenManager) TenantID(t string) (string, error) {
+	claims, err := j.parseAccess(t)
+	if err != nil {
+		return "", err
+	}
+	org, _ := claims["org"].(string)
+	return org, nil
+}
+
+// parseAccess is parse that fails for tokens other than access tokens.
+func (j jwtTokenManager) parseAccess(t string) (jwt.MapClaims, error) {
+	claims, err := j.parse(t)
+	if err != nil {
+		return nil, err
+	}
+	if typ, _ := claims["typ"].(string); typ != accessType {
+		return nil, fmt.Errorf("token of type %q is not an access token", typ)
+	}
+	return claims, nil
+}
+
+func (j jwtTokenManager) parse(t string) (jwt.MapClaims, error) {
+	var (
+		token *jwt.Token
+		err   error
+	)
+	for _, key := range j.config.keys() {
+		token, err = jwt.Parse(t, func(token *jwt.Token) (interface{}, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+
+			return key, nil
+		})
+		if !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+			break
+		}
+	}
 	if err != nil {
-		return fmt.Errorf("validate token err: %w", err)
+		return nil, fmt.Errorf("validate token err: %w", err)
 	}
 
-	if _, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return nil
+	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+		return claims, nil
 	}
 
-	return errors.New("token is not valid - expired")
+	return nil, errors.New("token is not valid - expired")
 }
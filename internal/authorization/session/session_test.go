@@ -38,10 +38,11 @@ func Test_jsonWebToken_GenerateTokens(t *testing.T) {
 				claims, ok := token.Claims.(jwt.MapClaims)
 				require.Equal(t, true, ok)
 				require.Equal(t, claims["id"], userID)
+				require.Equal(t, "access", claims["typ"])
 				ts, err := claims.GetExpirationTime()
 				require.NoError(t, err)
 				require.Equal(t, true, ok)
-				require.WithinDuration(t, ts.UTC(), time.Now().Add(time.Hour*1), time.Minute*1)
+				require.WithinDuration(t, ts.UTC(), time.Now().Add(AccessTokenTTL), time.Minute*1)
 			},
 			wantErr: assert.NoError,
 		},
@@ -85,3 +86,80 @@ func Test_jsonWebToken_ValidateToken(t *testing.T) {
 
 	t.Run("success", scenario(testArgs{userID: userID}))
 }
+
+type rotatingKeys struct {
+	keys [][]byte
+}
+
+func (r *rotatingKeys) Keys() [][]byte {
+	return r.keys
+}
+
+func Test_jwtTokenManager_KeyRotation(t *testing.T) {
+	keys := &rotatingKeys{keys: [][]byte{[]byte("old-secret")}}
+	j := NewJsonWebToken(Config{KeySource: keys})
+
+	beforeRotation, err := j.GenerateTokens("1")
+	require.NoError(t, err)
+
+	keys.keys = [][]byte{[]byte("new-secret"), []byte("old-secret")}
+
+	afterRotation, err := j.GenerateTokens("1")
+	require.NoError(t, err)
+
+	require.NoError(t, j.ValidateToken(beforeRotation.Token), "token signed with previous key")
+	require.NoError(t, j.ValidateToken(afterRotation.Token))
+
+	keys.keys = [][]byte{[]byte("new-secret")}
+	require.Error(t, j.ValidateToken(beforeRotation.Token), "previous key retired")
+}
+
+func Test_jwtTokenManager_UserID(t *testing.T) {
+	j := NewJsonWebToken(Config{TokenSecret: []byte("secret")})
+
+	tokens, err := j.GenerateTokens("42")
+	require.NoError(t, err)
+
+	id, err := j.UserID(tokens.Token)
+	require.NoError(t, err)
+	require.Equal(t, "42", id)
+
+	_, err = j.UserID(tokens.RefreshToken)
+	require.EqualError(t, err, `token of type "refresh" is not an access token`)
+
+	_, err = NewJsonWebToken(Config{TokenSecret: []byte("other")}).UserID(tokens.Token)
+	require.Error(t, err)
+}
+
+func Test_jwtTokenManager_TenantID(t *testing.T) {
+	j := NewJsonWebToken(Config{TokenSecret: []byte("secret")})
+
+	token, err := j.GenerateTenantToken("42", "7")
+	require.NoError(t, err)
+	id, err := j.UserID(token)
+	require.NoError(t, err)
+	require.Equal(t, "42", id)
+	tenant, err := j.TenantID(token)
+	require.NoError(t, err)
+	require.Equal(t, "7", tenant)
+
+	tokens, err := j.GenerateTokens("42")
+	require.NoError(t, err)
+	tenant, err = j.TenantID(tokens.Token)
+	require.NoError(t, err)
+	require.Empty(t, tenant, "a token of the user alone")
+
+	_, err = NewJsonWebToken(Config{TokenSecret: []byte("other")}).TenantID(token)
+	require.Error(t, err)
+}
+
+func Test_pasetoTokenManager_GenerateTenantToken(t *testing.T) {
+	token, err := NewPasetoTokenManager(Config{TokenSecret: []byte("short")}).GenerateTenantToken("42", "7")
+	assert.EqualError(t, err, "paseto key is 5 bytes instead of 32")
+	assert.Empty(t, token)
+
+	p := NewPasetoTokenManager(Config{TokenSecret: []byte("0123456789abcdef0123456789abcdef")})
+	token, err = p.GenerateTenantToken("42", "7")
+	require.NoError(t, err)
+	assert.NoError(t, p.ValidateToken(token))
+}
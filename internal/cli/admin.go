@@ -0,0 +1,319 @@
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"scratch/api"
+	"scratch/internal/config"
+	"scratch/internal/i18n"
+	"scratch/internal/secrets"
+	"scratch/internal/services"
+	"scratch/internal/storage/migrations"
+	"scratch/internal/storage/sqlite"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func migrate(ctx context.Context, args []string, env Env) error {
+	const migrateUsage = "usage: chatto migrate up|down|status|to [flags] VERSION"
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	c := newCommand("migrate "+args[0], env)
+	var dryRun, asJSON *bool
+	switch args[0] {
+	case "up", "down", "to":
+		dryRun = c.flags.Bool("dry-run", false, "print the migrations that would run without running them")
+	case "status":
+		asJSON = c.flags.Bool("json", false, "print the status as JSON")
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%v", args[0], migrateUsage)
+	}
+
+	cfg, rest, err := c.parse(args[1:], env)
+	if err != nil {
+		return err
+	}
+	var version int64
+	if args[0] == "to" {
+		if len(rest) != 1 {
+			return errors.New(migrateUsage)
+		}
+		version, err = strconv.ParseInt(rest[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("parse version %q: %w", rest[0], err)
+		}
+	}
+
+	return withMigrations(ctx, cfg, func(dialect migrations.Dialect, database *sql.DB) error {
+		dialect = dialect.WithLockTimeout(cfg.Migrations.LockTimeout)
+		if args[0] == "status" && *asJSON {
+			report, err := dialect.Report(ctx, database)
+			if err != nil {
+				return err
+			}
+			encoder := json.NewEncoder(env.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(report)
+		}
+		if dryRun != nil && *dryRun {
+			report, err := dialect.Report(ctx, database)
+			if err != nil {
+				return err
+			}
+			switch args[0] {
+			case "up":
+				version = migrations.MaxVersion
+			case "down":
+				version = report.Previous()
+			}
+			return report.Plan(version).Write(env.Stdout)
+		}
+
+		switch args[0] {
+		case "up":
+			return dialect.Up(ctx, database)
+		case "down":
+			return dialect.Down(ctx, database)
+		case "to":
+			return dialect.To(ctx, database, version)
+		default:
+			return dialect.Status(ctx, database, env.Stdout)
+		}
+	})
+}
+
+func user(ctx context.Context, args []string, env Env) error {
+	const userUsage = "usage: chatto user create|disable|set-password|set-locale|grant-role|erase|export --email EMAIL [flags]"
+	if len(args) == 0 {
+		return errors.New(userUsage)
+	}
+
+	c := newCommand("user "+args[0], env)
+	email := c.flags.String("email", "", "email of the user")
+	var (
+		name          *string
+		role          *string
+		locale        *string
+		password      *string
+		passwordStdin *bool
+	)
+	switch args[0] {
+	case "create":
+		name = c.flags.String("name", "", "display name of the new user")
+		locale = c.flags.String("locale", "", fmt.Sprintf("preferred locale, one of %v", i18n.Default().Locales()))
+		password, passwordStdin = passwordFlags(c)
+	case "set-password":
+		password, passwordStdin = passwordFlags(c)
+	case "set-locale":
+		locale = c.flags.String("locale", "", fmt.Sprintf("preferred locale, one of %v, empty to negotiate it", i18n.Default().Locales()))
+	case "grant-role":
+		role = c.flags.String("role", "", fmt.Sprintf("role to grant, one of %v", services.Roles))
+	case "disable", "erase", "export":
+	default:
+		return fmt.Errorf("unknown user command %q\n%v", args[0], userUsage)
+	}
+
+	cfg, _, err := c.parse(args[1:], env)
+	if err != nil {
+		return err
+	}
+	if *email == "" {
+		return errors.New("--email is required")
+	}
+
+	var pwd string
+	if password != nil {
+		pwd, err = readPassword(env, *password, *passwordStdin)
+		if err != nil {
+			return err
+		}
+	}
+
+	return withAccounts(ctx, cfg, env, func(accounts *services.AccountService) error {
+		switch args[0] {
+		case "create":
+			if *name == "" {
+				return errors.New("--name is required")
+			}
+			id, err := accounts.CreateUser(ctx, api.RegisterUserRequest{Email: *email, Name: *name, Password: pwd, Locale: locale})
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(env.Stdout, "created user %v with id %d\n", *email, id)
+			return err
+		case "disable":
+			if err := accounts.DisableUser(ctx, *email); err != nil {
+				return err
+			}
+			_, err := fmt.Fprintf(env.Stdout, "disabled user %v\n", *email)
+			return err
+		case "set-password":
+			if err := accounts.SetPassword(ctx, *email, pwd); err != nil {
+				return err
+			}
+			_, err := fmt.Fprintf(env.Stdout, "password changed for %v\n", *email)
+			return err
+		case "set-locale":
+			if err := accounts.SetLocale(ctx, *email, *locale); err != nil {
+				return err
+			}
+			_, err := fmt.Fprintf(env.Stdout, "locale of %v set to %q\n", *email, *locale)
+			return err
+		case "erase":
+			if err := accounts.EraseUser(ctx, *email); err != nil {
+				return err
+			}
+			_, err := fmt.Fprintf(env.Stdout, "erased user %v\n", *email)
+			return err
+		case "export":
+			archive, err := accounts.ExportUser(ctx, *email)
+			if err != nil {
+				return err
+			}
+			_, err = env.Stdout.Write(archive)
+			return err
+		default:
+			if err := accounts.GrantRole(ctx, *email, *role); err != nil {
+				return err
+			}
+			_, err := fmt.Fprintf(env.Stdout, "granted %v to %v\n", *role, *email)
+			return err
+		}
+	})
+}
+
+func sessions(ctx context.Context, args []string, env Env) error {
+	if len(args) == 0 || args[0] != "purge" {
+		return errors.New("usage: chatto sessions purge [--user EMAIL]")
+	}
+
+	c := newCommand("sessions purge", env)
+	email := c.flags.String("user", "", "only purge the sessions of this user")
+	cfg, _, err := c.parse(args[1:], env)
+	if err != nil {
+		return err
+	}
+
+	return withAccounts(ctx, cfg, env, func(accounts *services.AccountService) error {
+		n, err := accounts.PurgeSessions(ctx, *email)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(env.Stdout, "purged %d sessions\n", n)
+		return err
+	})
+}
+
+// keys rotates the JWT signing key in the configured secrets store. The old
+// key is kept as the previous one, so servers picking up the new key on their
+// next reload, or started afterwards, still accept tokens signed with it.
+func keys(ctx context.Context, args []string, env Env) error {
+	if len(args) == 0 || args[0] != "rotate" {
+		return errors.New("usage: chatto keys rotate")
+	}
+
+	cfg, _, err := newCommand("keys rotate", env).parse(args[1:], env)
+	if err != nil {
+		return err
+	}
+	provider, err := newSecretsProvider(cfg.Secrets, env.LookupEnv)
+	if err != nil {
+		return err
+	}
+	writer, ok := provider.(secrets.Writer)
+	if !ok {
+		return errors.New("keys are read only, set secrets.provider to file or encrypted to rotate them")
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return fmt.Errorf("generate key: %w", err)
+	}
+	// the previous key is stored first, a server reloading in between sees
+	// the old key twice
+	old, err := provider.Get(ctx, secrets.JWTSecret)
+	if err != nil && !errors.Is(err, secrets.ErrNotFound) {
+		return fmt.Errorf("load key: %w", err)
+	}
+	if err == nil {
+		if err := writer.Set(ctx, secrets.Previous(secrets.JWTSecret), old); err != nil {
+			return fmt.Errorf("store previous key: %w", err)
+		}
+	}
+	if err := writer.Set(ctx, secrets.JWTSecret, []byte(base64.StdEncoding.EncodeToString(raw))); err != nil {
+		return fmt.Errorf("store key: %w", err)
+	}
+
+	_, err = fmt.Fprintf(env.Stdout, "rotated %v, servers pick it up within %v or on SIGHUP\n",
+		secrets.JWTSecret, cfg.Secrets.ReloadInterval)
+	return err
+}
+
+func passwordFlags(c *command) (*string, *bool) {
+	password := c.flags.String("password", "", "new password, prefer --password-stdin or the interactive prompt")
+	passwordStdin := c.flags.Bool("password-stdin", false, "read the password from the first line of stdin")
+	return password, passwordStdin
+}
+
+func withDatabase(ctx context.Context, cfg config.Config, f func(database *pgxpool.Pool) error) error {
+	if cfg.Store != "postgres" {
+		return fmt.Errorf("the command needs store postgres, it does not run on the %v store", cfg.Store)
+	}
+	database, err := openDatabase(ctx, cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	return f(database)
+}
+
+// withMigrations calls f with the migrations of the configured store and a
+// database/sql handle on it. The sqlite file is opened as is, so migrate down
+// is not undone by the migrations run when the store opens.
+func withMigrations(ctx context.Context, cfg config.Config, f func(dialect migrations.Dialect, database *sql.DB) error) error {
+	if cfg.Store == "sqlite" {
+		database, err := sqlite.Open(cfg.SQLite.Path)
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		return f(migrations.SQLite, database)
+	}
+	return withDatabase(ctx, cfg, func(pool *pgxpool.Pool) error {
+		database := migrations.OpenDB(pool)
+		defer database.Close()
+
+		return f(migrations.Postgres, database)
+	})
+}
+
+// withStore calls f with the configured store, which has to outlive the
+// command, so anything but the memory store.
+func withStore(ctx context.Context, cfg config.Config, f func(s store) error) error {
+	if cfg.Store == "memory" {
+		return errors.New("the command needs store postgres or sqlite, the memory store lives in the serve process only")
+	}
+	s, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	return f(s)
+}
+
+func withAccounts(ctx context.Context, cfg config.Config, env Env, f func(accounts *services.AccountService) error) error {
+	return withStore(ctx, cfg, func(s store) error {
+		return f(newAccountService(s, cfg.Account, cfg.Database.RowLevelSecurity, nil, nil, *cfg.Log.NewLogger(env.Stderr)))
+	})
+}
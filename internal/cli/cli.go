@@ -0,0 +1,410 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"scratch/internal/authorization/session"
+	"scratch/internal/config"
+	"scratch/internal/mail"
+	"scratch/internal/privacy"
+	"scratch/internal/secrets"
+	"scratch/internal/services"
+	storage "scratch/internal/storage/database"
+	"scratch/internal/storage/database/memory"
+	"scratch/internal/storage/migrations"
+	"scratch/internal/storage/sqlite"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const usage = `usage: chatto <command> [flags]
+
+commands:
+  serve                                 run the http server (default)
+  migrate up|down|status|to VERSION     manage database migrations
+  user create|disable|set-password|set-locale|grant-role
+                                        manage user accounts
+  sessions purge [--user EMAIL]         log users out
+  worker                                run background jobs
+  jobs dead|retry ID                    list or retry the jobs that ran out of attempts
+  keys rotate                           generate a new token signing key
+  config print [--redacted]             print the effective configuration
+  secrets keygen|encrypt|decrypt        manage the encrypted secrets file
+  i18n check|extract LOCALE             check or export the message catalogs
+
+every command accepts the configuration flags, run "chatto <command> -h" to list them`
+
+var ErrUsage = errors.New(usage)
+
+// Env is the process environment commands run in, replaceable in tests.
+type Env struct {
+	Stdin     io.Reader
+	Stdout    io.Writer
+	Stderr    io.Writer
+	LookupEnv func(string) (string, bool)
+}
+
+func StdEnv() Env {
+	return Env{Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr, LookupEnv: os.LookupEnv}
+}
+
+// Run executes the command given by args.
+func Run(ctx context.Context, args []string, env Env) error {
+	if len(args) == 0 || (strings.HasPrefix(args[0], "-") && args[0] != "-h" && args[0] != "--help") {
+		return serve(ctx, args, env)
+	}
+
+	switch args[0] {
+	case "serve":
+		return serve(ctx, args[1:], env)
+	case "migrate":
+		return migrate(ctx, args[1:], env)
+	case "user":
+		return user(ctx, args[1:], env)
+	case "sessions":
+		return sessions(ctx, args[1:], env)
+	case "worker":
+		return worker(ctx, args[1:], env)
+	case "jobs":
+		return jobsCommand(ctx, args[1:], env)
+	case "keys":
+		return keys(ctx, args[1:], env)
+	case "config":
+		return configCommand(args[1:], env)
+	case "secrets":
+		return secrets.RunCommand(args[1:], env.Stdin, env.Stdout, env.LookupEnv)
+	case "i18n":
+		return i18nCommand(args[1:], env)
+	case "help", "-h", "--help":
+		_, err := fmt.Fprintln(env.Stdout, usage)
+		return err
+	default:
+		return fmt.Errorf("unknown command %q\n%w", args[0], ErrUsage)
+	}
+}
+
+// command is a parsed subcommand sharing the configuration flags.
+type command struct {
+	flags  *flag.FlagSet
+	loader *config.Loader
+}
+
+func newCommand(name string, env Env) *command {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(env.Stderr)
+	return &command{flags: flags, loader: config.NewLoader(flags)}
+}
+
+// parse parses args and loads the config, returning the positional arguments.
+func (c *command) parse(args []string, env Env) (config.Config, []string, error) {
+	if err := c.flags.Parse(args); err != nil {
+		return config.Config{}, nil, err
+	}
+	cfg, err := c.loader.Load(env.LookupEnv)
+	if err != nil {
+		return config.Config{}, nil, fmt.Errorf("load config: %w", err)
+	}
+	return cfg, c.flags.Args(), nil
+}
+
+func configCommand(args []string, env Env) error {
+	if len(args) == 0 || args[0] != "print" {
+		return fmt.Errorf("usage: chatto config print [--redacted]")
+	}
+
+	c := newCommand("config print", env)
+	redacted := c.flags.Bool("redacted", false, "hide passwords and secrets")
+	cfg, _, err := c.parse(args[1:], env)
+	if err != nil {
+		return err
+	}
+	if *redacted {
+		cfg = cfg.Redacted()
+	}
+	return config.Print(env.Stdout, cfg)
+}
+
+// queryExecModes maps the database.query_exec_mode values to pgx.
+var queryExecModes = map[string]pgx.QueryExecMode{
+	"cache_statement": pgx.QueryExecModeCacheStatement,
+	"cache_describe":  pgx.QueryExecModeCacheDescribe,
+	"describe_exec":   pgx.QueryExecModeDescribeExec,
+	"exec":            pgx.QueryExecModeExec,
+	"simple_protocol": pgx.QueryExecModeSimpleProtocol,
+}
+
+func openDatabase(ctx context.Context, cfg config.DatabaseConfig) (*pgxpool.Pool, error) {
+	database, err := openPool(ctx, cfg, cfg.DSN())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := database.Ping(ctx); err != nil {
+		database.Close()
+		return nil, fmt.Errorf("ping database: %w", err)
+	}
+	return database, nil
+}
+
+// openReplicas opens a pool on every replica. They are not pinged, an
+// unreachable replica only serves no reads until it is back.
+func openReplicas(ctx context.Context, cfg config.DatabaseConfig) ([]*pgxpool.Pool, error) {
+	var replicas []*pgxpool.Pool
+	for i, dsn := range cfg.Replicas {
+		replica, err := openPool(ctx, cfg, dsn)
+		if err != nil {
+			for _, r := range replicas {
+				r.Close()
+			}
+			return nil, fmt.Errorf("replica %d: %w", i+1, err)
+		}
+		replicas = append(replicas, replica)
+	}
+	return replicas, nil
+}
+
+// openPool opens a pool on dsn with the pool settings of cfg.
+func openPool(ctx context.Context, cfg config.DatabaseConfig, dsn string) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("parse database config: %w", err)
+	}
+	poolConfig.MaxConns = int32(cfg.MaxConns)
+	poolConfig.MinConns = int32(cfg.MinConns)
+	poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	poolConfig.HealthCheckPeriod = cfg.HealthCheckPeriod
+	poolConfig.ConnConfig.DefaultQueryExecMode = queryExecModes[cfg.QueryExecMode]
+	poolConfig.ConnConfig.StatementCacheCapacity = cfg.StatementCacheCapacity
+	poolConfig.ConnConfig.DescriptionCacheCapacity = cfg.StatementCacheCapacity
+	if !cfg.RowLevelSecurity {
+		// the tenant policies show nothing to a connection without a tenant,
+		// without row level security every connection sees every tenant
+		poolConfig.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+			_, err := conn.Exec(ctx, "SELECT set_config('app.tenant_id', '*', false)")
+			return err
+		}
+	}
+
+	database, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("open connection to database: %w", err)
+	}
+	return database, nil
+}
+
+// store is where the application keeps its data: the postgres pool or, when
+// pool is nil, the local store, in memory or in an SQLite file.
+type store struct {
+	pool *pgxpool.Pool
+	// replicas serve reads through router, once the caller routes them
+	replicas []*pgxpool.Pool
+	router   *storage.Router
+	local    localStore
+	// sqlite is the database of the local store when it is an SQLite file
+	sqlite *sql.DB
+}
+
+type localStore interface {
+	storage.Querier
+	storage.Transactor
+}
+
+// openStore opens the configured store, which the caller closes. The schema of
+// the SQLite file is prepared when opened, postgres is prepared by serve.
+func openStore(ctx context.Context, cfg config.Config) (store, error) {
+	switch cfg.Store {
+	case "memory":
+		return store{local: memory.New()}, nil
+	case "sqlite":
+		database, err := sqlite.Open(cfg.SQLite.Path)
+		if err != nil {
+			return store{}, err
+		}
+		if err := prepareSchema(ctx, cfg.Migrations, migrations.SQLite, database); err != nil {
+			database.Close()
+			return store{}, fmt.Errorf("setup migrations: %w", err)
+		}
+		return store{local: sqlite.New(database), sqlite: database}, nil
+	}
+	database, err := openDatabase(ctx, cfg.Database)
+	if err != nil {
+		return store{}, err
+	}
+	replicas, err := openReplicas(ctx, cfg.Database)
+	if err != nil {
+		database.Close()
+		return store{}, err
+	}
+	return store{pool: database, replicas: replicas}, nil
+}
+
+// routeReads sends the reads of queries to the replicas, while the returned
+// router, which the caller runs, finds them healthy.
+func (s *store) routeReads(cfg config.DatabaseConfig, logger slog.Logger) *storage.Router {
+	replicas := make([]storage.DBTX, len(s.replicas))
+	for i, replica := range s.replicas {
+		replicas[i] = replica
+	}
+	s.router = storage.NewRouter(s.pool, replicas, storage.RouterOptions{
+		MaxLag:        cfg.ReplicaMaxLag,
+		CheckInterval: cfg.ReplicaCheckInterval,
+	}, logger)
+	return s.router
+}
+
+func (s store) Close() {
+	if s.pool != nil {
+		s.pool.Close()
+	}
+	for _, replica := range s.replicas {
+		replica.Close()
+	}
+	if s.sqlite != nil {
+		s.sqlite.Close()
+	}
+}
+
+func (s store) queries() storage.Querier {
+	if s.pool == nil {
+		return s.local
+	}
+	if s.router != nil {
+		return storage.New(s.router)
+	}
+	return storage.New(s.pool)
+}
+
+// transactor returns a Transactor with options, which the local stores do not
+// need since their transactions are serialized and never conflict.
+func (s store) transactor(options storage.TxOptions) storage.Transactor {
+	if s.pool == nil {
+		return s.local
+	}
+	return storage.NewTxManager(s.pool, options)
+}
+
+func (s store) sentMail() mail.SentStore {
+	if s.pool == nil {
+		return mail.NewMemorySentStore()
+	}
+	return mail.NewPostgresSentStore(s.pool)
+}
+
+// prepareSchema applies the pending migrations with migrations.auto, and
+// otherwise refuses a schema that has any.
+func prepareSchema(ctx context.Context, cfg config.MigrationsConfig, dialect migrations.Dialect, database *sql.DB) error {
+	dialect = dialect.WithLockTimeout(cfg.LockTimeout)
+	if cfg.Auto {
+		return dialect.Up(ctx, database)
+	}
+	report, err := dialect.Report(ctx, database)
+	if err != nil {
+		return err
+	}
+	if pending := report.Pending(); len(pending) > 0 {
+		return fmt.Errorf("%d pending migrations, run chatto migrate up or set migrations.auto", len(pending))
+	}
+	return nil
+}
+
+// newAccountService returns the service of accounts, whose transactions set
+// the tenant with rowLevelSecurity too: the ones reaching into organizations
+// ask for all of them.
+func newAccountService(s store, cfg config.AccountConfig, rowLevelSecurity bool, tokenKeys session.KeySource, mailer mail.Sender, logger slog.Logger) *services.AccountService {
+	tokenMaker := session.NewJsonWebToken(session.Config{KeySource: tokenKeys})
+	// registrations check the email and insert the user in one serializable
+	// transaction
+	tx := s.transactor(storage.TxOptions{Isolation: pgx.Serializable, MaxRetries: 3, RowLevelSecurity: rowLevelSecurity})
+	// export download URLs are signed with the token keys
+	var exportSigner *privacy.URLSigner
+	if tokenKeys != nil {
+		exportSigner = privacy.NewURLSigner(tokenKeys)
+	}
+	return services.NewAccountService(s.queries(), tx, tokenMaker, mailer, logger).
+		WithDeletionGracePeriod(cfg.DeletionGracePeriod).
+		WithDataExports(exportSigner, cfg.DataExportTTL, cfg.DataExportURLTTL)
+}
+
+// newOrganizationService returns the service of organizations, whose
+// transactions set the tenant for the row level security policies with
+// rowLevelSecurity.
+func newOrganizationService(s store, cfg config.AccountConfig, rowLevelSecurity bool, tokenKeys session.KeySource, mailer mail.Sender, logger slog.Logger) *services.OrganizationService {
+	tokenMaker := session.NewJsonWebToken(session.Config{KeySource: tokenKeys})
+	// the last owner check reads the owners before changing one, which only
+	// a serializable transaction keeps from racing another change
+	tx := s.transactor(storage.TxOptions{Isolation: pgx.Serializable, MaxRetries: 3, RowLevelSecurity: rowLevelSecurity})
+	return services.NewOrganizationService(s.queries(), tx, tokenMaker, mailer, logger).
+		WithInvitationTTL(cfg.InvitationTTL)
+}
+
+// newMailSender returns the configured transport, sending every message ID
+// once, and the memory sender when the transport is memory.
+func newMailSender(cfg config.MailConfig, sent mail.SentStore, logger slog.Logger) (mail.Sender, *mail.MemorySender) {
+	var (
+		sender mail.Sender
+		outbox *mail.MemorySender
+	)
+	switch cfg.Transport {
+	case "smtp":
+		sender = mail.NewSMTPSender(mail.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.From,
+		})
+	case "file":
+		sender = mail.NewFileSender(cfg.Dir, cfg.From)
+	case "memory":
+		outbox = mail.NewMemorySender()
+		sender = outbox
+	default:
+		sender = mail.NewLogSender(logger)
+	}
+	return mail.Once(sender, sent), outbox
+}
+
+// newSecretsProvider returns the configured provider, or nil when secrets come from the config.
+func newSecretsProvider(cfg config.SecretsConfig, lookupEnv func(string) (string, bool)) (secrets.Provider, error) {
+	switch cfg.Provider {
+	case "env":
+		return secrets.NewEnvProvider(cfg.EnvPrefix, lookupEnv), nil
+	case "file":
+		return secrets.NewFileProvider(cfg.Dir), nil
+	case "encrypted":
+		key, err := secrets.ParseKey(cfg.Key)
+		if err != nil {
+			return nil, fmt.Errorf("secrets key: %w", err)
+		}
+		return secrets.NewEncryptedFileProvider(cfg.File, key), nil
+	}
+	return nil, nil
+}
+
+// loadTokenKeys returns the JWT signing keys. With a secrets provider configured
+// the keys are tracked by the returned Reloader, so they can be rotated at runtime.
+func loadTokenKeys(ctx context.Context, cfg config.Config, env Env, logger slog.Logger) (session.KeySource, *secrets.Reloader, error) {
+	provider, err := newSecretsProvider(cfg.Secrets, env.LookupEnv)
+	if err != nil {
+		return nil, nil, err
+	}
+	if provider == nil {
+		return secrets.NewValue([]byte(cfg.Auth.JWTSecret)), nil, nil
+	}
+
+	reloader := secrets.NewReloader(provider, cfg.Secrets.ReloadInterval, logger)
+	jwtSecret, err := reloader.Track(ctx, secrets.JWTSecret)
+	if err != nil {
+		return nil, nil, err
+	}
+	return jwtSecret, reloader, nil
+}
@@ -0,0 +1,277 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"path/filepath"
+	"scratch/internal/authorization/session"
+	"scratch/internal/secrets"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "YELLOWXSUBMARINEBBBLACKXWIZARDRY"
+
+func testEnv(vars map[string]string, stdin string) (Env, *bytes.Buffer) {
+	var stdout bytes.Buffer
+	return Env{
+		Stdin:  strings.NewReader(stdin),
+		Stdout: &stdout,
+		Stderr: &bytes.Buffer{},
+		LookupEnv: func(key string) (string, bool) {
+			v, ok := vars[key]
+			return v, ok
+		},
+	}, &stdout
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		env        map[string]string
+		wantOutput string
+		wantErr    string
+	}{
+		{
+			name:       "help",
+			args:       []string{"help"},
+			wantOutput: "usage: chatto <command> [flags]",
+		},
+		{
+			name:    "unknown command",
+			args:    []string{"frobnicate"},
+			wantErr: `unknown command "frobnicate"`,
+		},
+		{
+			name:       "config print - redacted",
+			args:       []string{"config", "print", "--redacted", "--server-addr", ":9999"},
+			env:        map[string]string{"CHATTO_AUTH_JWT_SECRET": testSecret},
+			wantOutput: "addr: :9999",
+		},
+		{
+			name:    "config print - invalid config",
+			args:    []string{"config", "print"},
+			wantErr: "auth.jwt_secret is required",
+		},
+		{
+			name:    "migrate - missing subcommand",
+			args:    []string{"migrate"},
+			wantErr: "usage: chatto migrate",
+		},
+		{
+			name:    "jobs - missing subcommand",
+			args:    []string{"jobs"},
+			wantErr: "usage: chatto jobs dead|retry ID",
+		},
+		{
+			name:    "user create - missing email",
+			args:    []string{"user", "create", "--name", "joe"},
+			env:     map[string]string{"CHATTO_AUTH_JWT_SECRET": testSecret},
+			wantErr: "--email is required",
+		},
+		{
+			name:    "user - unknown subcommand",
+			args:    []string{"user", "delete"},
+			wantErr: `unknown user command "delete"`,
+		},
+		{
+			name:    "jobs dead - memory store",
+			args:    []string{"jobs", "dead", "--store", "memory"},
+			env:     map[string]string{"CHATTO_AUTH_JWT_SECRET": testSecret},
+			wantErr: "the command needs store postgres",
+		},
+		{
+			name:       "i18n check",
+			args:       []string{"i18n", "check"},
+			wantOutput: "are complete",
+		},
+		{
+			name:       "i18n extract",
+			args:       []string{"i18n", "extract", "de"},
+			wantOutput: `"problem.user-not-found": ""`,
+		},
+		{
+			name:    "keys rotate - read only keys",
+			args:    []string{"keys", "rotate"},
+			env:     map[string]string{"CHATTO_AUTH_JWT_SECRET": testSecret},
+			wantErr: "keys are read only",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, stdout := testEnv(tt.env, "")
+
+			err := Run(context.Background(), tt.args, env)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, stdout.String(), tt.wantOutput)
+			assert.NotContains(t, stdout.String(), testSecret)
+		})
+	}
+}
+
+func TestRun_KeysRotate(t *testing.T) {
+	key, err := secrets.GenerateKey()
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	store := secrets.NewEncryptedFileProvider(path, key)
+	require.NoError(t, store.Set(context.Background(), secrets.JWTSecret, []byte(testSecret)))
+
+	env, stdout := testEnv(map[string]string{
+		"CHATTO_SECRETS_PROVIDER": "encrypted",
+		"CHATTO_SECRETS_FILE":     path,
+		"CHATTO_SECRETS_KEY":      key.String(),
+	}, "")
+	logger := *slog.New(slog.NewTextHandler(io.Discard, nil))
+	keys, err := secrets.NewReloader(store, 0, logger).Track(context.Background(), secrets.JWTSecret)
+	require.NoError(t, err)
+	tokens, err := session.NewJsonWebToken(session.Config{KeySource: keys}).GenerateTokens("1")
+	require.NoError(t, err)
+
+	require.NoError(t, Run(context.Background(), []string{"keys", "rotate"}, env))
+	assert.Contains(t, stdout.String(), "rotated jwt_secret")
+
+	rotated, err := store.Get(context.Background(), secrets.JWTSecret)
+	require.NoError(t, err)
+	assert.NotEqual(t, testSecret, string(rotated))
+	assert.GreaterOrEqual(t, len(rotated), 32)
+
+	// a server started after the rotation
+	keys, err = secrets.NewReloader(store, 0, logger).Track(context.Background(), secrets.JWTSecret)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{rotated, []byte(testSecret)}, keys.Keys())
+	assert.NoError(t, session.NewJsonWebToken(session.Config{KeySource: keys}).ValidateToken(tokens.Token), "token signed with the old key")
+}
+
+func TestServe_MemoryStore(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	env, _ := testEnv(map[string]string{"CHATTO_AUTH_JWT_SECRET": testSecret}, "")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Run(ctx, []string{"serve", "--store", "memory", "--server-addr", addr, "--mail-preview", "true"}, env)
+	}()
+	defer func() {
+		cancel()
+		require.NoError(t, <-done)
+	}()
+
+	url := fmt.Sprintf("http://%v", addr)
+	require.Eventually(t, func() bool {
+		res, err := http.Get(url + "/readyz")
+		if err != nil {
+			return false
+		}
+		res.Body.Close()
+		return res.StatusCode == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+
+	register := func() int {
+		res, err := http.Post(url+"/register", "application/json",
+			strings.NewReader(`{"email":"norbi@example.com","name":"Norbi","password":"Test123!"}`))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		return res.StatusCode
+	}
+	assert.Equal(t, http.StatusCreated, register())
+	assert.Equal(t, http.StatusConflict, register(), "the user is kept in memory")
+
+	res, err := http.Get(url + "/dev/mail/")
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode, "the mail preview is for admins")
+
+	// the pages are limited like the api, the default allows 10 logins a minute
+	login := func() int {
+		res, err := http.Post(url+"/web/login", "application/x-www-form-urlencoded",
+			strings.NewReader("email=norbi%40example.com&password=guess"))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		return res.StatusCode
+	}
+	for i := 0; i < 10; i++ {
+		assert.NotEqual(t, http.StatusTooManyRequests, login())
+	}
+	assert.Equal(t, http.StatusTooManyRequests, login())
+}
+
+func TestRun_SQLiteStore(t *testing.T) {
+	env := map[string]string{
+		"CHATTO_AUTH_JWT_SECRET": testSecret,
+		"CHATTO_STORE":           "sqlite",
+		"CHATTO_SQLITE_PATH":     filepath.Join(t.TempDir(), "chatto.db"),
+	}
+	run := func(args ...string) (string, error) {
+		env, stdout := testEnv(env, "")
+		err := Run(context.Background(), args, env)
+		return stdout.String(), err
+	}
+
+	_, err := run("migrate", "up")
+	require.NoError(t, err)
+	output, err := run("migrate", "status")
+	require.NoError(t, err)
+	assert.NotContains(t, output, "pending")
+
+	output, err = run("user", "create", "--email", "norbi@example.com", "--name", "Norbi", "--password", "Test123!")
+	require.NoError(t, err)
+	assert.Contains(t, output, "created user norbi@example.com with id 1")
+
+	_, err = run("user", "create", "--email", "NORBI@example.com", "--name", "Norbi", "--password", "Test123!")
+	assert.Error(t, err, "the user is kept in the file")
+
+	_, err = run("worker")
+	assert.ErrorContains(t, err, "the command needs store postgres")
+}
+
+func TestRun_ManualMigrations(t *testing.T) {
+	env := map[string]string{
+		"CHATTO_AUTH_JWT_SECRET": testSecret,
+		"CHATTO_STORE":           "sqlite",
+		"CHATTO_SQLITE_PATH":     filepath.Join(t.TempDir(), "chatto.db"),
+		"CHATTO_MIGRATIONS_AUTO": "false",
+	}
+	run := func(args ...string) (string, error) {
+		env, stdout := testEnv(env, "")
+		err := Run(context.Background(), args, env)
+		return stdout.String(), err
+	}
+
+	_, err := run("user", "disable", "--email", "norbi@example.com")
+	assert.ErrorContains(t, err, "pending migrations, run chatto migrate up")
+
+	output, err := run("migrate", "up", "--dry-run")
+	require.NoError(t, err)
+	assert.Contains(t, output, "would apply 20261019200000 20261019200000_init.sql")
+	output, err = run("migrate", "status", "--json")
+	require.NoError(t, err)
+	assert.Contains(t, output, `"current": 0`)
+
+	_, err = run("migrate", "up")
+	require.NoError(t, err)
+	output, err = run("migrate", "down", "--dry-run")
+	require.NoError(t, err)
+	assert.Contains(t, output, "would roll back")
+	assert.Equal(t, 1, strings.Count(output, "\n"))
+
+	_, err = run("user", "disable", "--email", "norbi@example.com")
+	assert.ErrorContains(t, err, "not found")
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"scratch/internal/i18n"
+	"sort"
+	"strings"
+)
+
+// i18nCommand checks the embedded catalogs for missing translations, or
+// prints the catalog of a locale with every key for translators to fill in.
+func i18nCommand(args []string, env Env) error {
+	const i18nUsage = "usage: chatto i18n check|extract LOCALE"
+	if len(args) == 0 {
+		return errors.New(i18nUsage)
+	}
+
+	bundle := i18n.Default()
+	switch args[0] {
+	case "check":
+		missing := bundle.Missing()
+		if len(missing) == 0 {
+			_, err := fmt.Fprintf(env.Stdout, "catalogs %v are complete\n", bundle.Locales())
+			return err
+		}
+		locales := make([]string, 0, len(missing))
+		for locale := range missing {
+			locales = append(locales, locale)
+		}
+		sort.Strings(locales)
+		var problems []string
+		for _, locale := range locales {
+			problems = append(problems, fmt.Sprintf("%v: %v", locale, strings.Join(missing[locale], ", ")))
+		}
+		return fmt.Errorf("catalogs are missing keys:\n  - %v", strings.Join(problems, "\n  - "))
+	case "extract":
+		if len(args) != 2 {
+			return errors.New(i18nUsage)
+		}
+		enc := json.NewEncoder(env.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(bundle.Extract(args[1]))
+	default:
+		return fmt.Errorf("unknown i18n command %q\n%v", args[0], i18nUsage)
+	}
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_readPassword(t *testing.T) {
+	tests := []struct {
+		name      string
+		flagValue string
+		fromStdin bool
+		stdin     string
+		want      string
+		wantErr   string
+	}{
+		{name: "from flag", flagValue: "Test123!", want: "Test123!"},
+		{name: "from stdin", fromStdin: true, stdin: "Test123!\nignored\n", want: "Test123!"},
+		{name: "from stdin without newline", fromStdin: true, stdin: "Test123!", want: "Test123!"},
+		{name: "empty stdin", fromStdin: true, stdin: "", wantErr: "read password from stdin"},
+		{name: "not a terminal", wantErr: "use --password-stdin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, _ := testEnv(nil, tt.stdin)
+
+			got, err := readPassword(env, tt.flagValue, tt.fromStdin)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
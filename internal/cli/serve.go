@@ -0,0 +1,341 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"scratch/api"
+	"scratch/internal"
+	authorization "scratch/internal/authorization/middlewares"
+	"scratch/internal/authorization/session"
+	"scratch/internal/config"
+	"scratch/internal/events"
+	"scratch/internal/health"
+	"scratch/internal/i18n"
+	"scratch/internal/idempotency"
+	"scratch/internal/mail"
+	"scratch/internal/problem"
+	"scratch/internal/ratelimit"
+	"scratch/internal/secrets"
+	"scratch/internal/server"
+	"scratch/internal/services"
+	storage "scratch/internal/storage/database"
+	"scratch/internal/storage/migrations"
+	"scratch/internal/validation"
+	"scratch/internal/web"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func setupAppHandler(s store, tokenKeys session.KeySource, probes *health.Probes, rateLimits ratelimit.Store, idempotencyKeys idempotency.Store, mailer mail.Sender, outbox *mail.MemorySender, cfg config.Config, logger slog.Logger) (http.Handler, error) {
+	spec, err := api.GetSwagger()
+	if err != nil {
+		return nil, fmt.Errorf("load api spec: %w", err)
+	}
+	validator := validation.New(spec, validation.Options{
+		ValidateResponses:     cfg.Server.ResponseValidation != "off",
+		FailOnInvalidResponse: cfg.Server.ResponseValidation == "fail",
+	}, logger)
+
+	var middlewares []api.MiddlewareFunc
+	if idempotencyKeys != nil {
+		// only valid requests are deduplicated, so a fixed retry is not rejected
+		// as a reuse of the key
+		deduplicator := idempotency.New(idempotencyKeys, idempotency.OperationsFromSpec(spec), idempotency.Options{
+			TTL:         cfg.Idempotency.TTL,
+			LockTimeout: cfg.Idempotency.LockTimeout,
+		}, logger)
+		middlewares = append(middlewares, deduplicator.Middleware)
+	}
+	middlewares = append(middlewares, validator.Middleware)
+
+	// the admin commands create users without an invitation, only the
+	// registrations served are invite only
+	accountService := newAccountService(s, cfg.Account, cfg.Database.RowLevelSecurity, tokenKeys, mailer, logger).
+		WithInviteOnly(cfg.Account.Registration == "invite")
+	cookies := newCookieConfig(cfg.Session)
+	authenticator := authorization.NewAuthenticator(session.NewJsonWebToken(session.Config{KeySource: tokenKeys}), accountService, cookies, logger)
+	middlewares = append(middlewares, authenticator.Middleware)
+	// the pages are throttled and logged like the api, without its validation
+	var pageMiddlewares []func(http.Handler) http.Handler
+	if rateLimits != nil {
+		limiter, err := newRateLimiter(cfg.RateLimit, rateLimits, session.NewJsonWebToken(session.Config{KeySource: tokenKeys}), logger)
+		if err != nil {
+			return nil, err
+		}
+		// the limiter wraps the validator, so invalid requests count as well
+		middlewares = append(middlewares, limiter.Middleware)
+		pageMiddlewares = append(pageMiddlewares, limiter.Middleware)
+	}
+	middlewares = append(middlewares, middleware.Logger)
+	// unlike the api middlewares, the first of these wraps the others
+	pageMiddlewares = append([]func(http.Handler) http.Handler{middleware.Logger}, pageMiddlewares...)
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(trackWrites)
+	r.Use(i18n.Default().Middleware)
+	r.Use(authorization.Device(cfg.RateLimit.TrustForwardedFor))
+
+	probes.Routes(r)
+
+	if cfg.Mail.Preview {
+		// the outbox holds the links of password resets and invitations
+		r.With(authenticator.RequireRole(accountService, services.RoleAdmin)).
+			Mount("/dev/mail", mail.Preview(mail.Default(), outbox))
+	}
+
+	if cfg.Server.WebUI {
+		pages, err := web.New(accountService, cookies, spec, logger)
+		if err != nil {
+			return nil, fmt.Errorf("web ui: %w", err)
+		}
+		r.Route(web.Prefix, func(r chi.Router) {
+			// inline middlewares run once a page is routed, when the limiter
+			// finds the pattern of its rules, e.g. "POST /web/login"
+			pages.Routes(r.With(pageMiddlewares...))
+		})
+	}
+
+	organizations := newOrganizationService(s, cfg.Account, cfg.Database.RowLevelSecurity, tokenKeys, mailer, logger)
+	if cfg.Server.WebUI && cfg.Server.PublicURL != "" {
+		organizations = organizations.WithInviteLink(strings.TrimSuffix(cfg.Server.PublicURL, "/") + web.Prefix + "/signup?invite=")
+	}
+	ah := internal.NewAccountHandler(accountService, cookies, logger).WithOrganizations(organizations)
+
+	server := api.HandlerWithOptions(ah, api.ChiServerOptions{
+		BaseRouter:       r,
+		Middlewares:      middlewares,
+		ErrorHandlerFunc: problem.ErrorHandlerFunc,
+	})
+
+	return server, nil
+}
+
+// serve starts the application and blocks until ctx is done, after which the
+// http server is drained and the database connection closed. With the memory
+// or sqlite store it runs without a database server.
+func serve(ctx context.Context, args []string, env Env) error {
+	cfg, _, err := newCommand("serve", env).parse(args, env)
+	if err != nil {
+		return err
+	}
+	logger := *cfg.Log.NewLogger(env.Stdout)
+
+	tokenKeys, reloader, err := loadTokenKeys(ctx, cfg, env, logger)
+	if err != nil {
+		return fmt.Errorf("load token keys: %w", err)
+	}
+	if reloader != nil {
+		go reloader.Run(ctx)
+		go reloadOnHangup(ctx, reloader)
+	}
+
+	s, err := openStore(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("init database: %w", err)
+	}
+	defer s.Close()
+
+	probes := health.NewProbes(2 * time.Second)
+	if s.pool != nil {
+		// goose, and the readiness check of its version, use database/sql
+		migrationDB := migrations.OpenDB(s.pool)
+		defer migrationDB.Close()
+		if err := prepareSchema(ctx, cfg.Migrations, migrations.Postgres, migrationDB); err != nil {
+			return fmt.Errorf("setup migrations: %w", err)
+		}
+
+		probes.AddReadinessCheck("database", health.DatabaseCheck(s.pool))
+		probes.AddReadinessCheck("migrations", health.MigrationCheck(migrationDB, migrations.Postgres.Dir))
+
+		if len(s.replicas) > 0 {
+			router := s.routeReads(cfg.Database, logger)
+			go router.Run(ctx)
+		}
+	} else if s.sqlite != nil {
+		probes.AddReadinessCheck("migrations", health.MigrationCheck(s.sqlite, migrations.SQLite.Dir))
+	} else {
+		logger.Warn("running on the memory store, all data is lost on exit")
+	}
+
+	rateLimits := newRateLimitStore(cfg.RateLimit, s.pool)
+	if rateLimits != nil {
+		go ratelimit.Cleanup(ctx, rateLimits, time.Minute, logger)
+	}
+
+	idempotencyKeys := newIdempotencyStore(cfg.Idempotency, s.pool)
+	if idempotencyKeys != nil {
+		go idempotency.Cleanup(ctx, idempotencyKeys, time.Hour, logger)
+	}
+
+	if broker := newEventBroker(cfg.Events, s.pool); broker != nil {
+		relay := events.NewRelay(s.transactor(storage.TxOptions{}), broker, events.RelayOptions{
+			Interval:    cfg.Events.RelayInterval,
+			BatchSize:   cfg.Events.BatchSize,
+			MaxBackoff:  cfg.Events.MaxBackoff,
+			MaxAttempts: cfg.Events.MaxAttempts,
+			LockTimeout: cfg.Events.LockTimeout,
+			Retention:   cfg.Events.Retention,
+		}, logger)
+		go relay.Run(ctx)
+	}
+
+	sent := s.sentMail()
+	mailer, outbox := newMailSender(cfg.Mail, sent, logger)
+	go mail.Cleanup(ctx, sent, time.Hour, cfg.Mail.SentRetention, logger)
+
+	if cfg.Jobs.Worker {
+		worker, err := newWorker(cfg, s, mailer, logger)
+		if err != nil {
+			return err
+		}
+		// running jobs finish before the database is closed
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			worker.Run(ctx)
+		}()
+		defer func() { <-stopped }()
+	}
+
+	handler, err := setupAppHandler(s, tokenKeys, probes, rateLimits, idempotencyKeys, mailer, outbox, cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	srv := server.New(server.Config{
+		Addr:              cfg.Server.Addr,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		ShutdownTimeout:   cfg.Server.ShutdownTimeout,
+	}, handler, logger)
+	srv.OnDrain(probes.Drain)
+
+	if err := srv.Run(ctx); err != nil {
+		logger.Error("application stopped", "err", err)
+		return err
+	}
+	return nil
+}
+
+// newRateLimitStore returns the configured store, or nil when rate limiting is off.
+// Without a database the counters are kept in memory.
+func newRateLimitStore(cfg config.RateLimitConfig, database *pgxpool.Pool) ratelimit.Store {
+	switch {
+	case cfg.Store == "memory", cfg.Store == "postgres" && database == nil:
+		return ratelimit.NewMemoryStore()
+	case cfg.Store == "postgres":
+		return ratelimit.NewPostgresStore(database)
+	}
+	return nil
+}
+
+// newEventBroker returns the configured broker, or nil when this instance
+// does not relay events. Without a database the postgres broker is replaced
+// by the memory one.
+func newEventBroker(cfg config.EventsConfig, database *pgxpool.Pool) events.Broker {
+	switch {
+	case cfg.Broker == "memory", cfg.Broker == "postgres" && database == nil:
+		return events.NewMemoryBroker()
+	case cfg.Broker == "postgres":
+		return events.NewPostgresBroker(database, cfg.PostgresChannel)
+	case cfg.Broker == "kafka":
+		return events.NewKafkaBroker(cfg.KafkaProxyURL, cfg.KafkaTopic, &http.Client{Timeout: 10 * time.Second})
+	}
+	return nil
+}
+
+func newCookieConfig(cfg config.SessionConfig) authorization.CookieConfig {
+	sameSite := http.SameSiteLaxMode
+	switch cfg.CookieSameSite {
+	case "strict":
+		sameSite = http.SameSiteStrictMode
+	case "none":
+		sameSite = http.SameSiteNoneMode
+	}
+	return authorization.CookieConfig{
+		Name:     cfg.CookieName,
+		Domain:   cfg.CookieDomain,
+		Secure:   cfg.CookieSecure,
+		SameSite: sameSite,
+		TTL:      cfg.TTL,
+	}
+}
+
+// newIdempotencyStore returns the configured store, or nil when Idempotency-Key is ignored.
+// Without a database the keys are kept in memory.
+func newIdempotencyStore(cfg config.IdempotencyConfig, database *pgxpool.Pool) idempotency.Store {
+	switch {
+	case cfg.Store == "memory", cfg.Store == "postgres" && database == nil:
+		return idempotency.NewMemoryStore()
+	case cfg.Store == "postgres":
+		return idempotency.NewPostgresStore(database)
+	}
+	return nil
+}
+
+func newRateLimiter(cfg config.RateLimitConfig, store ratelimit.Store, users session.UserIdentifier, logger slog.Logger) (*ratelimit.Limiter, error) {
+	rules := make([]ratelimit.Rule, len(cfg.Rules))
+	for i, rule := range cfg.Rules {
+		rules[i] = ratelimit.Rule{
+			Operation: rule.Operation,
+			Key:       rule.Key,
+			Limit:     ratelimit.Limit{Requests: rule.Requests, Window: rule.Window},
+		}
+	}
+
+	options := ratelimit.Options{
+		TrustForwardedFor: cfg.TrustForwardedFor,
+		ExemptAPIKeys:     cfg.ExemptAPIKeys,
+		Users:             users,
+	}
+	for _, cidr := range cfg.ExemptNetworks {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("rate limit exempt network: %w", err)
+		}
+		options.ExemptNetworks = append(options.ExemptNetworks, network)
+	}
+
+	limiter, err := ratelimit.New(store, rules, options, logger)
+	if err != nil {
+		return nil, fmt.Errorf("rate limiter: %w", err)
+	}
+	return limiter, nil
+}
+
+// reloadOnHangup reloads secrets whenever the process receives SIGHUP.
+func reloadOnHangup(ctx context.Context, reloader *secrets.Reloader) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			reloader.Reload(ctx)
+		}
+	}
+}
+
+// trackWrites has the reads of a request go to the primary once it wrote, so
+// it sees its own writes however far behind the replicas are.
+func trackWrites(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(storage.TrackWrites(r.Context())))
+	})
+}
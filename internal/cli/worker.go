@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"scratch/internal/config"
+	"scratch/internal/jobs"
+	"scratch/internal/mail"
+	"scratch/internal/services"
+	storage "scratch/internal/storage/database"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// worker runs background jobs until ctx is done, next to or instead of the
+// worker in the server.
+func worker(ctx context.Context, args []string, env Env) error {
+	cfg, _, err := newCommand("worker", env).parse(args, env)
+	if err != nil {
+		return err
+	}
+	logger := *cfg.Log.NewLogger(env.Stdout)
+
+	return withDatabase(ctx, cfg, func(database *pgxpool.Pool) error {
+		// the memory outbox is only useful to the preview of the server
+		mailer, _ := newMailSender(cfg.Mail, mail.NewPostgresSentStore(database), logger)
+		w, err := newWorker(cfg, store{pool: database}, mailer, logger)
+		if err != nil {
+			return err
+		}
+		logger.Info("worker started", "concurrency", cfg.Jobs.Concurrency)
+		w.Run(ctx)
+		logger.Info("worker stopped")
+		return nil
+	})
+}
+
+// newWorker returns a worker running the jobs of the application.
+func newWorker(cfg config.Config, s store, mailer mail.Sender, logger slog.Logger) (*jobs.Worker, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "worker"
+	}
+	w := jobs.NewWorker(s.queries(), jobs.WorkerOptions{
+		ID:           fmt.Sprintf("%v-%d", host, os.Getpid()),
+		Concurrency:  cfg.Jobs.Concurrency,
+		PollInterval: cfg.Jobs.PollInterval,
+		LockTimeout:  cfg.Jobs.LockTimeout,
+		MaxBackoff:   cfg.Jobs.MaxBackoff,
+		Retention:    cfg.Jobs.Retention,
+	}, logger)
+
+	// jobs never issue tokens
+	accounts := newAccountService(s, cfg.Account, cfg.Database.RowLevelSecurity, nil, mailer, logger)
+	jobs.Handle(w, accounts.SendWelcomeEmail)
+	jobs.Handle(w, accounts.SendNewDeviceEmail)
+	jobs.Handle(w, accounts.CleanupSessions)
+	jobs.Handle(w, accounts.PurgeUsers)
+	jobs.Handle(w, accounts.BuildDataExport)
+	jobs.Handle(w, accounts.CleanupDataExports)
+	organizations := newOrganizationService(s, cfg.Account, cfg.Database.RowLevelSecurity, nil, mailer, logger)
+	jobs.Handle(w, organizations.SendInvitationEmail)
+
+	if cfg.Jobs.SessionCleanup != "" {
+		if err := w.Schedule(cfg.Jobs.SessionCleanup, services.SessionCleanup{}); err != nil {
+			return nil, fmt.Errorf("schedule session cleanup: %w", err)
+		}
+	}
+	if cfg.Jobs.UserPurge != "" {
+		if err := w.Schedule(cfg.Jobs.UserPurge, services.UserPurge{}); err != nil {
+			return nil, fmt.Errorf("schedule user purge: %w", err)
+		}
+	}
+	if cfg.Jobs.DataExportCleanup != "" {
+		if err := w.Schedule(cfg.Jobs.DataExportCleanup, services.DataExportCleanup{}); err != nil {
+			return nil, fmt.Errorf("schedule data export cleanup: %w", err)
+		}
+	}
+	return w, nil
+}
+
+func jobsCommand(ctx context.Context, args []string, env Env) error {
+	const jobsUsage = "usage: chatto jobs dead|retry ID"
+	if len(args) == 0 {
+		return errors.New(jobsUsage)
+	}
+
+	cfg, rest, err := newCommand("jobs "+args[0], env).parse(args[1:], env)
+	if err != nil {
+		return err
+	}
+	return withDatabase(ctx, cfg, func(database *pgxpool.Pool) error {
+		queries := storage.New(database)
+		switch args[0] {
+		case "dead":
+			dead, err := queries.ListDeadJobs(ctx)
+			if err != nil {
+				return fmt.Errorf("list dead jobs: %w", err)
+			}
+			for _, job := range dead {
+				_, err := fmt.Fprintf(env.Stdout, "%d\t%v\t%v\tattempts=%d\t%s\t%v\n",
+					job.ID, job.Kind, job.FinishedAt.Time.Format(time.RFC3339), job.Attempts, job.Payload, job.LastError.String)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		case "retry":
+			if len(rest) != 1 {
+				return errors.New(jobsUsage)
+			}
+			id, err := strconv.ParseInt(rest[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("parse job id %q: %w", rest[0], err)
+			}
+			n, err := queries.ReviveJob(ctx, storage.ReviveJobParams{Now: time.Now(), ID: id})
+			if err != nil {
+				return fmt.Errorf("retry job: %w", err)
+			}
+			if n == 0 {
+				return fmt.Errorf("no dead job %d", id)
+			}
+			_, err = fmt.Fprintf(env.Stdout, "job %d is pending again\n", id)
+			return err
+		default:
+			return fmt.Errorf("unknown jobs command %q\n%v", args[0], jobsUsage)
+		}
+	})
+}
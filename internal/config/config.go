@@ -0,0 +1,1152 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"scratch/internal/jobs"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is prepended to every environment variable read by Load, so that
+// settings like CHATTO_DATABASE_USER do not collide with the shell's USER.
+const EnvPrefix = "CHATTO_"
+
+const redacted = "[redacted]"
+
+const minSecretLength = 32
+
+var ErrInvalidConfig = errors.New("invalid configuration")
+
+type Config struct {
+	// Store keeps the data of the application: postgres, sqlite, which keeps
+	// it in a single file, or memory, which loses everything on exit. With
+	// sqlite and memory, the rate limits, idempotency keys, sent mail and
+	// events configured to live in postgres are kept in memory.
+	Store       string            `yaml:"store" toml:"store"`
+	Server      ServerConfig      `yaml:"server" toml:"server"`
+	Database    DatabaseConfig    `yaml:"database" toml:"database"`
+	SQLite      SQLiteConfig      `yaml:"sqlite" toml:"sqlite"`
+	Migrations  MigrationsConfig  `yaml:"migrations" toml:"migrations"`
+	Auth        AuthConfig        `yaml:"auth" toml:"auth"`
+	Account     AccountConfig     `yaml:"account" toml:"account"`
+	Secrets     SecretsConfig     `yaml:"secrets" toml:"secrets"`
+	Session     SessionConfig     `yaml:"session" toml:"session"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit" toml:"rate_limit"`
+	Idempotency IdempotencyConfig `yaml:"idempotency" toml:"idempotency"`
+	Mail        MailConfig        `yaml:"mail" toml:"mail"`
+	Events      EventsConfig      `yaml:"events" toml:"events"`
+	Jobs        JobsConfig        `yaml:"jobs" toml:"jobs"`
+	Log         LogConfig         `yaml:"log" toml:"log"`
+}
+
+type ServerConfig struct {
+	Addr              string        `yaml:"addr" toml:"addr"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	// ResponseValidation checks responses against the OpenAPI spec: off, log or fail.
+	ResponseValidation string `yaml:"response_validation" toml:"response_validation"`
+	// WebUI serves the browser pages under /web next to the api.
+	WebUI bool `yaml:"web_ui" toml:"web_ui"`
+	// PublicURL is the address users reach the server at, such as
+	// https://chat.example.com. The links in emails start with it, without it
+	// emails carry codes only.
+	PublicURL string `yaml:"public_url" toml:"public_url"`
+}
+
+// SQLiteConfig describes the database file of the sqlite store.
+type SQLiteConfig struct {
+	// Path is the database file, created and migrated on start.
+	Path string `yaml:"path" toml:"path"`
+}
+
+// MigrationsConfig controls how the schema of the postgres and sqlite stores
+// is migrated.
+type MigrationsConfig struct {
+	// Auto applies the pending migrations on start. Without it they are
+	// applied by chatto migrate up, and the server refuses to start before.
+	Auto bool `yaml:"auto" toml:"auto"`
+	// LockTimeout is how long an instance waits for another one migrating
+	// the same postgres database.
+	LockTimeout time.Duration `yaml:"lock_timeout" toml:"lock_timeout"`
+}
+
+// DatabaseConfig describes the postgres connection. When URL is set it takes
+// precedence over the individual fields.
+type DatabaseConfig struct {
+	URL      string `yaml:"url" toml:"url"`
+	Host     string `yaml:"host" toml:"host"`
+	Port     int    `yaml:"port" toml:"port"`
+	User     string `yaml:"user" toml:"user"`
+	Password string `yaml:"password" toml:"password"`
+	Name     string `yaml:"name" toml:"name"`
+	SSLMode  string `yaml:"ssl_mode" toml:"ssl_mode"`
+
+	MaxConns          int           `yaml:"max_conns" toml:"max_conns"`
+	MinConns          int           `yaml:"min_conns" toml:"min_conns"`
+	MaxConnLifetime   time.Duration `yaml:"max_conn_lifetime" toml:"max_conn_lifetime"`
+	MaxConnIdleTime   time.Duration `yaml:"max_conn_idle_time" toml:"max_conn_idle_time"`
+	HealthCheckPeriod time.Duration `yaml:"health_check_period" toml:"health_check_period"`
+	// QueryExecMode is how queries are sent: cache_statement prepares each
+	// once per connection, exec and simple_protocol suit transaction pooling
+	// proxies like PgBouncer.
+	QueryExecMode string `yaml:"query_exec_mode" toml:"query_exec_mode"`
+	// StatementCacheCapacity is how many prepared statements, or their
+	// descriptions, each connection keeps.
+	StatementCacheCapacity int `yaml:"statement_cache_capacity" toml:"statement_cache_capacity"`
+
+	// Replicas are the postgres:// URLs of read replicas, which serve the
+	// lookups of users with the pool settings of the primary. Their role needs
+	// pg_read_all_stats to see whether they stream from the primary.
+	Replicas []string `yaml:"replicas" toml:"replicas"`
+	// ReplicaMaxLag is how far behind the primary a replica may be and still
+	// serve reads.
+	ReplicaMaxLag time.Duration `yaml:"replica_max_lag" toml:"replica_max_lag"`
+	// ReplicaCheckInterval is how often the health and lag of the replicas
+	// are checked.
+	ReplicaCheckInterval time.Duration `yaml:"replica_check_interval" toml:"replica_check_interval"`
+
+	// RowLevelSecurity scopes the transactions of organization requests to
+	// the organization with app.tenant_id, which the policies of the tenant
+	// tables check, and hides the tenant tables from any other transaction
+	// not asking for every tenant explicitly. It has no effect when
+	// connecting as a superuser or a role with BYPASSRLS.
+	RowLevelSecurity bool `yaml:"row_level_security" toml:"row_level_security"`
+}
+
+type AuthConfig struct {
+	JWTSecret    string `yaml:"jwt_secret" toml:"jwt_secret"`
+	PasetoSecret string `yaml:"paseto_secret" toml:"paseto_secret"`
+}
+
+type AccountConfig struct {
+	// DeletionGracePeriod is how long a deleted account can be restored,
+	// after it the account is purged by the jobs.user_purge job.
+	DeletionGracePeriod time.Duration `yaml:"deletion_grace_period" toml:"deletion_grace_period"`
+	// DataExportTTL is how long the data export archive of a user is kept,
+	// after it the jobs.data_export_cleanup job deletes it.
+	DataExportTTL time.Duration `yaml:"data_export_ttl" toml:"data_export_ttl"`
+	// DataExportURLTTL is how long a signed download URL of an archive is
+	// valid.
+	DataExportURLTTL time.Duration `yaml:"data_export_url_ttl" toml:"data_export_url_ttl"`
+	// InvitationTTL is how long an invitation to an organization can be
+	// accepted, and the longest expiry an inviter may choose.
+	InvitationTTL time.Duration `yaml:"invitation_ttl" toml:"invitation_ttl"`
+	// Registration is open, where anyone registers, or invite, where
+	// registering takes the code of a pending invitation.
+	Registration string `yaml:"registration" toml:"registration"`
+}
+
+// SecretsConfig selects where token keys come from. With an empty Provider
+// they are taken from AuthConfig.
+type SecretsConfig struct {
+	Provider       string        `yaml:"provider" toml:"provider"`
+	EnvPrefix      string        `yaml:"env_prefix" toml:"env_prefix"`
+	Dir            string        `yaml:"dir" toml:"dir"`
+	File           string        `yaml:"file" toml:"file"`
+	Key            string        `yaml:"key" toml:"key"`
+	ReloadInterval time.Duration `yaml:"reload_interval" toml:"reload_interval"`
+}
+
+// SessionConfig describes the cookies of browser sessions.
+type SessionConfig struct {
+	CookieName   string `yaml:"cookie_name" toml:"cookie_name"`
+	CookieDomain string `yaml:"cookie_domain" toml:"cookie_domain"`
+	// CookieSecure sends the cookies over https only.
+	CookieSecure bool `yaml:"cookie_secure" toml:"cookie_secure"`
+	// CookieSameSite is lax, strict or none.
+	CookieSameSite string        `yaml:"cookie_same_site" toml:"cookie_same_site"`
+	TTL            time.Duration `yaml:"ttl" toml:"ttl"`
+}
+
+// RateLimitConfig throttles api operations. Rules are only read from the
+// config file, the other settings from every source.
+type RateLimitConfig struct {
+	// Store keeps the counters: off, memory or postgres. Only postgres holds
+	// the limits across several instances.
+	Store string `yaml:"store" toml:"store"`
+	// TrustForwardedFor takes the client ip from the right-most
+	// X-Forwarded-For entry, for the limits and the sessions of users; enable
+	// it only behind a single proxy that appends the ip it sees to the header.
+	TrustForwardedFor bool `yaml:"trust_forwarded_for" toml:"trust_forwarded_for"`
+	// ExemptNetworks and ExemptAPIKeys are trusted clients never limited.
+	ExemptNetworks []string        `yaml:"exempt_networks" toml:"exempt_networks"`
+	ExemptAPIKeys  []string        `yaml:"exempt_api_keys" toml:"exempt_api_keys"`
+	Rules          []RateLimitRule `yaml:"rules" toml:"rules"`
+}
+
+// RateLimitRule allows Requests per Window to one operation for every key.
+type RateLimitRule struct {
+	// Operation is the method and path of the api spec, e.g. "POST /login",
+	// or of a page of the web ui, e.g. "POST /web/login".
+	Operation string `yaml:"operation" toml:"operation"`
+	// Key tells clients apart: ip, user or api_key.
+	Key      string        `yaml:"key" toml:"key"`
+	Requests int           `yaml:"requests" toml:"requests"`
+	Window   time.Duration `yaml:"window" toml:"window"`
+}
+
+// IdempotencyConfig deduplicates retries of operations sent with an
+// Idempotency-Key header.
+type IdempotencyConfig struct {
+	// Store keeps the keys and responses: off, memory or postgres.
+	Store string `yaml:"store" toml:"store"`
+	// TTL is how long a key, and the response replayed for it, is kept.
+	TTL time.Duration `yaml:"ttl" toml:"ttl"`
+	// LockTimeout is after how long the key of an unfinished request may be
+	// taken over by a retry.
+	LockTimeout time.Duration `yaml:"lock_timeout" toml:"lock_timeout"`
+}
+
+// MailConfig selects how emails to users are delivered.
+type MailConfig struct {
+	// Transport is log, which only logs the emails, smtp, file, which writes
+	// them as .eml files to Dir, or memory, which keeps them for the preview.
+	Transport    string `yaml:"transport" toml:"transport"`
+	From         string `yaml:"from" toml:"from"`
+	SMTPHost     string `yaml:"smtp_host" toml:"smtp_host"`
+	SMTPPort     int    `yaml:"smtp_port" toml:"smtp_port"`
+	SMTPUsername string `yaml:"smtp_username" toml:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password" toml:"smtp_password"`
+	Dir          string `yaml:"dir" toml:"dir"`
+	// Preview serves the rendered templates and the memory outbox under
+	// /dev/mail to admins. It must not be enabled in production.
+	Preview bool `yaml:"preview" toml:"preview"`
+	// SentRetention is how long the ids of sent emails are kept to not send
+	// them twice.
+	SentRetention time.Duration `yaml:"sent_retention" toml:"sent_retention"`
+}
+
+// EventsConfig selects where the domain events recorded in the outbox are
+// relayed to.
+type EventsConfig struct {
+	// Broker is off, when another instance relays the events, memory, postgres
+	// or kafka.
+	Broker string `yaml:"broker" toml:"broker"`
+	// RelayInterval is how often the outbox is checked for new events.
+	RelayInterval time.Duration `yaml:"relay_interval" toml:"relay_interval"`
+	BatchSize     int           `yaml:"batch_size" toml:"batch_size"`
+	// MaxBackoff caps the delay before an event the broker refused is retried.
+	MaxBackoff time.Duration `yaml:"max_backoff" toml:"max_backoff"`
+	// MaxAttempts is how often an event is offered to the broker before it
+	// is dead and no longer holds back the later events of its aggregate.
+	MaxAttempts int `yaml:"max_attempts" toml:"max_attempts"`
+	// LockTimeout bounds the publishing of a batch, after it the events are
+	// claimed again.
+	LockTimeout time.Duration `yaml:"lock_timeout" toml:"lock_timeout"`
+	// Retention is how long published events are kept, zero keeps them forever.
+	Retention time.Duration `yaml:"retention" toml:"retention"`
+	// PostgresChannel is the channel events are notified on.
+	PostgresChannel string `yaml:"postgres_channel" toml:"postgres_channel"`
+	// KafkaProxyURL is the Kafka REST proxy events are produced through.
+	KafkaProxyURL string `yaml:"kafka_proxy_url" toml:"kafka_proxy_url"`
+	KafkaTopic    string `yaml:"kafka_topic" toml:"kafka_topic"`
+}
+
+// JobsConfig sets up the worker running background jobs.
+type JobsConfig struct {
+	// Worker runs the jobs in the server, turn it off when separate
+	// "chatto worker" processes run them.
+	Worker      bool `yaml:"worker" toml:"worker"`
+	Concurrency int  `yaml:"concurrency" toml:"concurrency"`
+	// PollInterval is how often the queue is checked for due jobs.
+	PollInterval time.Duration `yaml:"poll_interval" toml:"poll_interval"`
+	// LockTimeout bounds the run of a job, after it the job is retried.
+	LockTimeout time.Duration `yaml:"lock_timeout" toml:"lock_timeout"`
+	// MaxBackoff caps the delay between the attempts of a failing job.
+	MaxBackoff time.Duration `yaml:"max_backoff" toml:"max_backoff"`
+	// Retention is how long done jobs are kept, zero keeps them forever.
+	Retention time.Duration `yaml:"retention" toml:"retention"`
+	// SessionCleanup is the cron schedule expired sessions are deleted on,
+	// empty never deletes them.
+	SessionCleanup string `yaml:"session_cleanup" toml:"session_cleanup"`
+	// UserPurge is the cron schedule the accounts deleted longer than the
+	// grace period ago are purged on, empty never purges them.
+	UserPurge string `yaml:"user_purge" toml:"user_purge"`
+	// DataExportCleanup is the cron schedule expired data export archives
+	// are deleted on, empty never deletes them.
+	DataExportCleanup string `yaml:"data_export_cleanup" toml:"data_export_cleanup"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level" toml:"level"`
+	Format string `yaml:"format" toml:"format"`
+}
+
+func Default() Config {
+	return Config{
+		Store: "postgres",
+		Server: ServerConfig{
+			Addr:               "localhost:8080",
+			ReadTimeout:        10 * time.Second,
+			ReadHeaderTimeout:  5 * time.Second,
+			WriteTimeout:       15 * time.Second,
+			IdleTimeout:        60 * time.Second,
+			ShutdownTimeout:    20 * time.Second,
+			ResponseValidation: "off",
+			WebUI:              true,
+		},
+		SQLite: SQLiteConfig{
+			Path: "chatto.db",
+		},
+		Migrations: MigrationsConfig{
+			Auto:        true,
+			LockTimeout: time.Minute,
+		},
+		Database: DatabaseConfig{
+			Host:    "localhost",
+			Port:    5432,
+			User:    "postgres",
+			Name:    "scratch",
+			SSLMode: "disable",
+
+			MaxConns:               10,
+			MaxConnLifetime:        time.Hour,
+			MaxConnIdleTime:        30 * time.Minute,
+			HealthCheckPeriod:      time.Minute,
+			QueryExecMode:          "cache_statement",
+			StatementCacheCapacity: 512,
+			ReplicaMaxLag:          time.Second,
+			ReplicaCheckInterval:   5 * time.Second,
+		},
+		Account: AccountConfig{
+			DeletionGracePeriod: 30 * 24 * time.Hour,
+			DataExportTTL:       7 * 24 * time.Hour,
+			DataExportURLTTL:    15 * time.Minute,
+			InvitationTTL:       7 * 24 * time.Hour,
+			Registration:        "open",
+		},
+		Secrets: SecretsConfig{
+			EnvPrefix:      "CHATTO_SECRET_",
+			Dir:            "/run/secrets",
+			ReloadInterval: time.Minute,
+		},
+		Session: SessionConfig{
+			CookieName:     "session",
+			CookieSecure:   true,
+			CookieSameSite: "lax",
+			TTL:            7 * 24 * time.Hour,
+		},
+		RateLimit: RateLimitConfig{
+			Store: "memory",
+			Rules: []RateLimitRule{
+				{Operation: "POST /login", Key: "ip", Requests: 10, Window: time.Minute},
+				{Operation: "POST /session", Key: "ip", Requests: 10, Window: time.Minute},
+				{Operation: "POST /refresh", Key: "ip", Requests: 30, Window: time.Minute},
+				{Operation: "POST /restore", Key: "ip", Requests: 10, Window: time.Minute},
+				{Operation: "POST /register", Key: "ip", Requests: 5, Window: 10 * time.Minute},
+				{Operation: "GET /invitations/{code}", Key: "ip", Requests: 30, Window: time.Minute},
+				{Operation: "POST /web/login", Key: "ip", Requests: 10, Window: time.Minute},
+				{Operation: "POST /web/signup", Key: "ip", Requests: 5, Window: 10 * time.Minute},
+				{Operation: "GET /web/signup", Key: "ip", Requests: 30, Window: time.Minute},
+				{Operation: "POST /web/password", Key: "ip", Requests: 10, Window: time.Minute},
+			},
+		},
+		Idempotency: IdempotencyConfig{
+			Store:       "postgres",
+			TTL:         24 * time.Hour,
+			LockTimeout: time.Minute,
+		},
+		Mail: MailConfig{
+			Transport:     "log",
+			From:          "Scratch <no-reply@localhost>",
+			SMTPPort:      587,
+			Dir:           "mail-outbox",
+			SentRetention: 30 * 24 * time.Hour,
+		},
+		Events: EventsConfig{
+			Broker:          "memory",
+			RelayInterval:   time.Second,
+			BatchSize:       100,
+			MaxBackoff:      10 * time.Minute,
+			MaxAttempts:     20,
+			LockTimeout:     time.Minute,
+			Retention:       7 * 24 * time.Hour,
+			PostgresChannel: "scratch_events",
+			KafkaTopic:      "scratch.events",
+		},
+		Jobs: JobsConfig{
+			Worker:            true,
+			Concurrency:       4,
+			PollInterval:      time.Second,
+			LockTimeout:       5 * time.Minute,
+			MaxBackoff:        time.Hour,
+			Retention:         7 * 24 * time.Hour,
+			SessionCleanup:    "@hourly",
+			UserPurge:         "@daily",
+			DataExportCleanup: "@hourly",
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+	}
+}
+
+// DSN returns the connection string accepted by the postgres drivers.
+func (d DatabaseConfig) DSN() string {
+	if d.URL != "" {
+		return d.URL
+	}
+	return fmt.Sprintf("host=%v port=%v user=%v password=%v dbname=%v sslmode=%v",
+		d.Host, d.Port, d.User, d.Password, d.Name, d.SSLMode)
+}
+
+// Redacted returns a copy of the config that is safe to print or log.
+func (c Config) Redacted() Config {
+	c.Database.Password = redact(c.Database.Password)
+	c.Auth.JWTSecret = redact(c.Auth.JWTSecret)
+	c.Auth.PasetoSecret = redact(c.Auth.PasetoSecret)
+	c.Secrets.Key = redact(c.Secrets.Key)
+	c.Mail.SMTPPassword = redact(c.Mail.SMTPPassword)
+	if len(c.RateLimit.ExemptAPIKeys) > 0 {
+		c.RateLimit.ExemptAPIKeys = []string{redacted}
+	}
+	if c.Database.URL != "" {
+		c.Database.URL = redactURL(c.Database.URL)
+	}
+	if len(c.Database.Replicas) > 0 {
+		replicas := make([]string, len(c.Database.Replicas))
+		for i, replica := range c.Database.Replicas {
+			replicas[i] = redactURL(replica)
+		}
+		c.Database.Replicas = replicas
+	}
+	return c
+}
+
+// redactURL hides the password in u, or all of u when it does not parse.
+func redactURL(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return redacted
+	}
+	return parsed.Redacted()
+}
+
+// Validate reports every problem with the config at once.
+func (c Config) Validate() error {
+	var problems []string
+
+	if c.Server.Addr == "" {
+		problems = append(problems, "server.addr is required")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		problems = append(problems, "server.shutdown_timeout must be positive")
+	}
+
+	switch c.Store {
+	case "postgres":
+		problems = append(problems, c.Database.validate()...)
+	case "sqlite":
+		if c.SQLite.Path == "" {
+			problems = append(problems, missing("sqlite.path"))
+		}
+	case "memory":
+	default:
+		problems = append(problems, fmt.Sprintf("store %q is not one of postgres, sqlite, memory", c.Store))
+	}
+
+	if c.Migrations.LockTimeout <= 0 {
+		problems = append(problems, "migrations.lock_timeout must be positive")
+	}
+
+	switch c.Secrets.Provider {
+	case "", "env", "file":
+	case "encrypted":
+		if c.Secrets.File == "" {
+			problems = append(problems, missing("secrets.file"))
+		}
+		if c.Secrets.Key == "" {
+			problems = append(problems, missing("secrets.key"))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("secrets.provider %q is not one of env, file, encrypted", c.Secrets.Provider))
+	}
+
+	switch {
+	case c.Secrets.Provider != "":
+		// token keys are loaded and checked by the secrets provider
+	case c.Auth.JWTSecret == "":
+		problems = append(problems, missing("auth.jwt_secret"))
+	case len(c.Auth.JWTSecret) < minSecretLength:
+		problems = append(problems, fmt.Sprintf("auth.jwt_secret must be at least %d bytes long", minSecretLength))
+	}
+	if c.Auth.PasetoSecret != "" && len(c.Auth.PasetoSecret) != minSecretLength {
+		problems = append(problems, fmt.Sprintf("auth.paseto_secret must be exactly %d bytes long", minSecretLength))
+	}
+
+	switch c.Server.ResponseValidation {
+	case "off", "log", "fail":
+	default:
+		problems = append(problems, fmt.Sprintf("server.response_validation %q is not one of off, log, fail", c.Server.ResponseValidation))
+	}
+	problems = append(problems, c.Session.validate()...)
+	problems = append(problems, c.RateLimit.validate()...)
+	problems = append(problems, c.Idempotency.validate()...)
+	problems = append(problems, c.Mail.validate()...)
+	problems = append(problems, c.Events.validate()...)
+	problems = append(problems, c.Jobs.validate()...)
+	if c.Account.DeletionGracePeriod < 0 {
+		problems = append(problems, "account.deletion_grace_period must not be negative")
+	}
+	if c.Account.DataExportTTL <= 0 {
+		problems = append(problems, "account.data_export_ttl must be positive")
+	}
+	if c.Account.DataExportURLTTL <= 0 {
+		problems = append(problems, "account.data_export_url_ttl must be positive")
+	}
+	if c.Account.InvitationTTL <= 0 {
+		problems = append(problems, "account.invitation_ttl must be positive")
+	}
+	switch c.Account.Registration {
+	case "open", "invite":
+	default:
+		problems = append(problems, fmt.Sprintf("account.registration %q is not one of open, invite", c.Account.Registration))
+	}
+	if c.Server.PublicURL != "" {
+		if u, err := url.Parse(c.Server.PublicURL); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			problems = append(problems, fmt.Sprintf("server.public_url %q is not an absolute http url", c.Server.PublicURL))
+		}
+	}
+
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		problems = append(problems, fmt.Sprintf("log.level %q is not one of debug, info, warn, error", c.Log.Level))
+	}
+	switch c.Log.Format {
+	case "json", "text":
+	default:
+		problems = append(problems, fmt.Sprintf("log.format %q is not one of json, text", c.Log.Format))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w:\n  - %v", ErrInvalidConfig, strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// Loader binds the config flags to a FlagSet, so commands can mix them with
+// their own flags.
+type Loader struct {
+	fs     *flag.FlagSet
+	path   string
+	values map[string]*string
+}
+
+func NewLoader(fs *flag.FlagSet) *Loader {
+	l := &Loader{fs: fs, values: make(map[string]*string, len(fields))}
+	fs.StringVar(&l.path, "config", "", "path to a YAML or TOML config file")
+	for _, f := range fields {
+		l.values[f.name] = fs.String(f.flagName(), "", f.usage)
+	}
+	return l
+}
+
+// Load builds the config from, in increasing priority: defaults, the config file
+// given by --config or CHATTO_CONFIG, environment variables and command line flags.
+// DATABASE_URL is honoured as well as CHATTO_DATABASE_URL. The FlagSet must be
+// parsed before calling Load.
+func (l *Loader) Load(lookupEnv func(string) (string, bool)) (Config, error) {
+	cfg := Default()
+
+	path := l.path
+	if path == "" {
+		path, _ = lookupEnv(EnvPrefix + "CONFIG")
+	}
+	if path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if v, ok := lookupEnv("DATABASE_URL"); ok && v != "" {
+		cfg.Database.URL = v
+	}
+	for _, f := range fields {
+		v, ok := lookupEnv(f.envName())
+		if !ok {
+			continue
+		}
+		if err := f.set(&cfg, v); err != nil {
+			return Config{}, fmt.Errorf("%v: %w", f.envName(), err)
+		}
+	}
+
+	var flagErr error
+	l.fs.Visit(func(fl *flag.Flag) {
+		for _, f := range fields {
+			if f.flagName() != fl.Name || flagErr != nil {
+				continue
+			}
+			if err := f.set(&cfg, *l.values[f.name]); err != nil {
+				flagErr = fmt.Errorf("--%v: %w", fl.Name, err)
+			}
+		}
+	})
+	if flagErr != nil {
+		return Config{}, flagErr
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// Load parses args with only the config flags and loads the config.
+func Load(args []string, lookupEnv func(string) (string, bool)) (Config, error) {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	l := NewLoader(fs)
+	if err := fs.Parse(args); err != nil {
+		return Config{}, fmt.Errorf("parse flags: %w", err)
+	}
+	return l.Load(lookupEnv)
+}
+
+// Print writes cfg as YAML to w.
+func Print(w io.Writer, cfg Config) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg); err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	return enc.Close()
+}
+
+func (s SessionConfig) validate() []string {
+	var problems []string
+	if s.CookieName == "" {
+		problems = append(problems, missing("session.cookie_name"))
+	}
+	switch s.CookieSameSite {
+	case "lax", "strict":
+	case "none":
+		if !s.CookieSecure {
+			problems = append(problems, "session.cookie_same_site none requires session.cookie_secure")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("session.cookie_same_site %q is not one of lax, strict, none", s.CookieSameSite))
+	}
+	if s.TTL <= 0 {
+		problems = append(problems, "session.ttl must be positive")
+	}
+	return problems
+}
+
+func (r RateLimitConfig) validate() []string {
+	var problems []string
+	switch r.Store {
+	case "off", "memory", "postgres":
+	default:
+		problems = append(problems, fmt.Sprintf("rate_limit.store %q is not one of off, memory, postgres", r.Store))
+	}
+	for _, network := range r.ExemptNetworks {
+		if _, _, err := net.ParseCIDR(network); err != nil {
+			problems = append(problems, fmt.Sprintf("rate_limit.exempt_networks: %q is not a CIDR", network))
+		}
+	}
+	for i, rule := range r.Rules {
+		method, path, ok := strings.Cut(rule.Operation, " ")
+		if !ok || method == "" || !strings.HasPrefix(path, "/") {
+			problems = append(problems, fmt.Sprintf("rate_limit.rules[%d].operation %q is not like \"POST /login\"", i, rule.Operation))
+		}
+		switch rule.Key {
+		case "ip", "user", "api_key":
+		default:
+			problems = append(problems, fmt.Sprintf("rate_limit.rules[%d].key %q is not one of ip, user, api_key", i, rule.Key))
+		}
+		if rule.Requests <= 0 || rule.Window <= 0 {
+			problems = append(problems, fmt.Sprintf("rate_limit.rules[%d] must allow a positive number of requests per positive window", i))
+		}
+	}
+	return problems
+}
+
+func (i IdempotencyConfig) validate() []string {
+	var problems []string
+	switch i.Store {
+	case "off", "memory", "postgres":
+	default:
+		problems = append(problems, fmt.Sprintf("idempotency.store %q is not one of off, memory, postgres", i.Store))
+	}
+	if i.TTL <= 0 {
+		problems = append(problems, "idempotency.ttl must be positive")
+	}
+	if i.LockTimeout <= 0 {
+		problems = append(problems, "idempotency.lock_timeout must be positive")
+	}
+	return problems
+}
+
+func (d DatabaseConfig) validate() []string {
+	var problems []string
+	if d.URL == "" {
+		if d.Host == "" {
+			problems = append(problems, missing("database.host"))
+		}
+		if d.Port <= 0 || d.Port > 65535 {
+			problems = append(problems, fmt.Sprintf("database.port %d is out of range", d.Port))
+		}
+		if d.User == "" {
+			problems = append(problems, missing("database.user"))
+		}
+		if d.Name == "" {
+			problems = append(problems, missing("database.name"))
+		}
+	} else if !isPostgresURL(d.URL) {
+		problems = append(problems, "database.url must be a postgres:// URL")
+	}
+	for i, replica := range d.Replicas {
+		if !isPostgresURL(replica) {
+			problems = append(problems, fmt.Sprintf("database.replicas[%d] must be a postgres:// URL", i))
+		}
+	}
+	if len(d.Replicas) > 0 && (d.ReplicaMaxLag <= 0 || d.ReplicaCheckInterval <= 0) {
+		problems = append(problems, "database.replica_max_lag and replica_check_interval must be positive")
+	}
+	return append(problems, d.validatePool()...)
+}
+
+func isPostgresURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql")
+}
+
+func (d DatabaseConfig) validatePool() []string {
+	var problems []string
+	if d.MaxConns <= 0 {
+		problems = append(problems, "database.max_conns must be positive")
+	}
+	if d.MinConns < 0 || d.MinConns > d.MaxConns {
+		problems = append(problems, fmt.Sprintf("database.min_conns %d is not between 0 and database.max_conns", d.MinConns))
+	}
+	if d.MaxConnLifetime <= 0 || d.MaxConnIdleTime <= 0 || d.HealthCheckPeriod <= 0 {
+		problems = append(problems, "database.max_conn_lifetime, max_conn_idle_time and health_check_period must be positive")
+	}
+	switch d.QueryExecMode {
+	case "cache_statement", "cache_describe":
+		if d.StatementCacheCapacity <= 0 {
+			problems = append(problems, fmt.Sprintf("database.statement_cache_capacity must be positive with query_exec_mode %v", d.QueryExecMode))
+		}
+	case "describe_exec", "exec", "simple_protocol":
+	default:
+		problems = append(problems, fmt.Sprintf("database.query_exec_mode %q is not one of cache_statement, cache_describe, describe_exec, exec, simple_protocol", d.QueryExecMode))
+	}
+	return problems
+}
+
+func (m MailConfig) validate() []string {
+	var problems []string
+	if _, err := mail.ParseAddress(m.From); err != nil {
+		problems = append(problems, fmt.Sprintf("mail.from %q is not an email address", m.From))
+	}
+	switch m.Transport {
+	case "log", "memory":
+	case "file":
+		if m.Dir == "" {
+			problems = append(problems, missing("mail.dir"))
+		}
+	case "smtp":
+		if m.SMTPHost == "" {
+			problems = append(problems, missing("mail.smtp_host"))
+		}
+		if m.SMTPPort <= 0 || m.SMTPPort > 65535 {
+			problems = append(problems, fmt.Sprintf("mail.smtp_port %d is out of range", m.SMTPPort))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("mail.transport %q is not one of log, smtp, file, memory", m.Transport))
+	}
+	if m.SentRetention <= 0 {
+		problems = append(problems, "mail.sent_retention must be positive")
+	}
+	return problems
+}
+
+func (e EventsConfig) validate() []string {
+	var problems []string
+	switch e.Broker {
+	case "off", "memory":
+	case "postgres":
+		if e.PostgresChannel == "" {
+			problems = append(problems, missing("events.postgres_channel"))
+		}
+	case "kafka":
+		if u, err := url.Parse(e.KafkaProxyURL); err != nil || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("events.kafka_proxy_url %q is not an absolute url", e.KafkaProxyURL))
+		}
+		if e.KafkaTopic == "" {
+			problems = append(problems, missing("events.kafka_topic"))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("events.broker %q is not one of off, memory, postgres, kafka", e.Broker))
+	}
+	if e.RelayInterval <= 0 {
+		problems = append(problems, "events.relay_interval must be positive")
+	}
+	if e.BatchSize <= 0 {
+		problems = append(problems, "events.batch_size must be positive")
+	}
+	if e.MaxBackoff < e.RelayInterval {
+		problems = append(problems, "events.max_backoff must not be shorter than events.relay_interval")
+	}
+	if e.MaxAttempts <= 0 {
+		problems = append(problems, "events.max_attempts must be positive")
+	}
+	if e.LockTimeout <= 0 {
+		problems = append(problems, "events.lock_timeout must be positive")
+	}
+	if e.Retention < 0 {
+		problems = append(problems, "events.retention must not be negative")
+	}
+	return problems
+}
+
+func (j JobsConfig) validate() []string {
+	var problems []string
+	if j.Concurrency <= 0 {
+		problems = append(problems, "jobs.concurrency must be positive")
+	}
+	if j.PollInterval <= 0 {
+		problems = append(problems, "jobs.poll_interval must be positive")
+	}
+	if j.LockTimeout <= 0 {
+		problems = append(problems, "jobs.lock_timeout must be positive")
+	}
+	if j.MaxBackoff <= 0 {
+		problems = append(problems, "jobs.max_backoff must be positive")
+	}
+	if j.Retention < 0 {
+		problems = append(problems, "jobs.retention must not be negative")
+	}
+	if j.SessionCleanup != "" {
+		if _, err := jobs.ParseSchedule(j.SessionCleanup); err != nil {
+			problems = append(problems, fmt.Sprintf("jobs.session_cleanup: %v", err))
+		}
+	}
+	if j.UserPurge != "" {
+		if _, err := jobs.ParseSchedule(j.UserPurge); err != nil {
+			problems = append(problems, fmt.Sprintf("jobs.user_purge: %v", err))
+		}
+	}
+	if j.DataExportCleanup != "" {
+		if _, err := jobs.ParseSchedule(j.DataExportCleanup); err != nil {
+			problems = append(problems, fmt.Sprintf("jobs.data_export_cleanup: %v", err))
+		}
+	}
+	return problems
+}
+
+// NewLogger builds the application logger writing to w.
+func (l LogConfig) NewLogger(w io.Writer) *slog.Logger {
+	var level slog.Level
+	_ = level.UnmarshalText([]byte(l.Level))
+
+	options := &slog.HandlerOptions{Level: level}
+	if l.Format == "text" {
+		return slog.New(slog.NewTextHandler(w, options))
+	}
+	return slog.New(slog.NewJSONHandler(w, options))
+}
+
+func loadFile(path string, cfg *Config) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, cfg)
+	case ".toml":
+		err = toml.Unmarshal(content, cfg)
+	default:
+		return fmt.Errorf("config file %v: unsupported extension %q", path, ext)
+	}
+	if err != nil {
+		return fmt.Errorf("decode config file %v: %w", path, err)
+	}
+	return nil
+}
+
+func missing(name string) string {
+	f, _ := fieldByName(name)
+	return fmt.Sprintf("%v is required (set %v, --%v or %v in the config file)",
+		name, f.envName(), f.flagName(), name)
+}
+
+func redact(s string) string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// field binds a dotted config key to its environment variable and flag.
+type field struct {
+	name  string
+	usage string
+	set   func(c *Config, v string) error
+}
+
+func (f field) envName() string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(f.name, ".", "_"))
+}
+
+func (f field) flagName() string {
+	return strings.ReplaceAll(strings.ReplaceAll(f.name, ".", "-"), "_", "-")
+}
+
+func fieldByName(name string) (field, bool) {
+	for _, f := range fields {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+func stringField(name, usage string, target func(c *Config) *string) field {
+	return field{name: name, usage: usage, set: func(c *Config, v string) error {
+		*target(c) = v
+		return nil
+	}}
+}
+
+func intField(name, usage string, target func(c *Config) *int) field {
+	return field{name: name, usage: usage, set: func(c *Config, v string) error {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("parse %q as integer: %w", v, err)
+		}
+		*target(c) = i
+		return nil
+	}}
+}
+
+func durationField(name, usage string, target func(c *Config) *time.Duration) field {
+	return field{name: name, usage: usage, set: func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("parse %q as duration: %w", v, err)
+		}
+		*target(c) = d
+		return nil
+	}}
+}
+
+func boolField(name, usage string, target func(c *Config) *bool) field {
+	return field{name: name, usage: usage, set: func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("parse %q as boolean: %w", v, err)
+		}
+		*target(c) = b
+		return nil
+	}}
+}
+
+// listField reads a comma separated list.
+func listField(name, usage string, target func(c *Config) *[]string) field {
+	return field{name: name, usage: usage, set: func(c *Config, v string) error {
+		var list []string
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		*target(c) = list
+		return nil
+	}}
+}
+
+var fields = []field{
+	stringField("store", "where the application keeps its data: postgres, sqlite or memory",
+		func(c *Config) *string { return &c.Store }),
+	stringField("sqlite.path", "database file of the sqlite store",
+		func(c *Config) *string { return &c.SQLite.Path }),
+	boolField("migrations.auto", "apply pending migrations on start, otherwise run chatto migrate up first",
+		func(c *Config) *bool { return &c.Migrations.Auto }),
+	durationField("migrations.lock_timeout", "how long to wait for another instance migrating the database",
+		func(c *Config) *time.Duration { return &c.Migrations.LockTimeout }),
+	stringField("server.addr", "address the http server listens on",
+		func(c *Config) *string { return &c.Server.Addr }),
+	durationField("server.read_timeout", "maximum duration for reading a request",
+		func(c *Config) *time.Duration { return &c.Server.ReadTimeout }),
+	durationField("server.read_header_timeout", "maximum duration for reading request headers",
+		func(c *Config) *time.Duration { return &c.Server.ReadHeaderTimeout }),
+	durationField("server.write_timeout", "maximum duration for writing a response",
+		func(c *Config) *time.Duration { return &c.Server.WriteTimeout }),
+	durationField("server.idle_timeout", "how long keep-alive connections stay open",
+		func(c *Config) *time.Duration { return &c.Server.IdleTimeout }),
+	durationField("server.shutdown_timeout", "how long in-flight requests are drained on shutdown",
+		func(c *Config) *time.Duration { return &c.Server.ShutdownTimeout }),
+	stringField("server.response_validation", "check responses against the api spec: off, log or fail",
+		func(c *Config) *string { return &c.Server.ResponseValidation }),
+	boolField("server.web_ui", "serve the browser pages under /web",
+		func(c *Config) *bool { return &c.Server.WebUI }),
+	stringField("server.public_url", "address users reach the server at, the links in emails start with it",
+		func(c *Config) *string { return &c.Server.PublicURL }),
+	stringField("database.url", "postgres connection URL, overrides the other database settings",
+		func(c *Config) *string { return &c.Database.URL }),
+	stringField("database.host", "postgres host",
+		func(c *Config) *string { return &c.Database.Host }),
+	intField("database.port", "postgres port",
+		func(c *Config) *int { return &c.Database.Port }),
+	stringField("database.user", "postgres user",
+		func(c *Config) *string { return &c.Database.User }),
+	stringField("database.password", "postgres password",
+		func(c *Config) *string { return &c.Database.Password }),
+	stringField("database.name", "postgres database name",
+		func(c *Config) *string { return &c.Database.Name }),
+	stringField("database.ssl_mode", "postgres sslmode",
+		func(c *Config) *string { return &c.Database.SSLMode }),
+	intField("database.max_conns", "largest number of connections in the pool",
+		func(c *Config) *int { return &c.Database.MaxConns }),
+	intField("database.min_conns", "number of connections the pool keeps open when idle",
+		func(c *Config) *int { return &c.Database.MinConns }),
+	durationField("database.max_conn_lifetime", "after how long a connection is closed and replaced",
+		func(c *Config) *time.Duration { return &c.Database.MaxConnLifetime }),
+	durationField("database.max_conn_idle_time", "after how long an unused connection is closed",
+		func(c *Config) *time.Duration { return &c.Database.MaxConnIdleTime }),
+	durationField("database.health_check_period", "how often idle connections are checked",
+		func(c *Config) *time.Duration { return &c.Database.HealthCheckPeriod }),
+	stringField("database.query_exec_mode", "how queries are sent: cache_statement, cache_describe, describe_exec, exec or simple_protocol",
+		func(c *Config) *string { return &c.Database.QueryExecMode }),
+	intField("database.statement_cache_capacity", "number of prepared statements cached per connection",
+		func(c *Config) *int { return &c.Database.StatementCacheCapacity }),
+	listField("database.replicas", "comma separated postgres:// URLs of read replicas",
+		func(c *Config) *[]string { return &c.Database.Replicas }),
+	durationField("database.replica_max_lag", "how far behind the primary a replica may be and still serve reads",
+		func(c *Config) *time.Duration { return &c.Database.ReplicaMaxLag }),
+	durationField("database.replica_check_interval", "how often the health and lag of the replicas are checked",
+		func(c *Config) *time.Duration { return &c.Database.ReplicaCheckInterval }),
+	boolField("database.row_level_security", "scope the transactions of organization requests with app.tenant_id for the row level security policies",
+		func(c *Config) *bool { return &c.Database.RowLevelSecurity }),
+	stringField("auth.jwt_secret", "secret used to sign JWT tokens",
+		func(c *Config) *string { return &c.Auth.JWTSecret }),
+	stringField("auth.paseto_secret", "32 byte key used to encrypt PASETO tokens",
+		func(c *Config) *string { return &c.Auth.PasetoSecret }),
+	durationField("account.deletion_grace_period", "how long a deleted account can be restored before it is purged",
+		func(c *Config) *time.Duration { return &c.Account.DeletionGracePeriod }),
+	durationField("account.data_export_ttl", "how long a data export archive is kept",
+		func(c *Config) *time.Duration { return &c.Account.DataExportTTL }),
+	durationField("account.data_export_url_ttl", "how long the signed download URL of a data export is valid",
+		func(c *Config) *time.Duration { return &c.Account.DataExportURLTTL }),
+	durationField("account.invitation_ttl", "how long an invitation to an organization can be accepted",
+		func(c *Config) *time.Duration { return &c.Account.InvitationTTL }),
+	stringField("account.registration", "who may register: open to anyone, or invite with the code of an invitation",
+		func(c *Config) *string { return &c.Account.Registration }),
+	stringField("secrets.provider", "where token keys are loaded from: env, file or encrypted; empty uses auth.*",
+		func(c *Config) *string { return &c.Secrets.Provider }),
+	stringField("secrets.env_prefix", "environment variable prefix of the env secrets provider",
+		func(c *Config) *string { return &c.Secrets.EnvPrefix }),
+	stringField("secrets.dir", "directory of the file secrets provider, one file per secret",
+		func(c *Config) *string { return &c.Secrets.Dir }),
+	stringField("secrets.file", "path of the encrypted secrets file",
+		func(c *Config) *string { return &c.Secrets.File }),
+	stringField("secrets.key", "base64 key of the encrypted secrets file",
+		func(c *Config) *string { return &c.Secrets.Key }),
+	durationField("secrets.reload_interval", "how often secrets are reloaded, 0 disables reloading",
+		func(c *Config) *time.Duration { return &c.Secrets.ReloadInterval }),
+	stringField("session.cookie_name", "name of the browser session cookie",
+		func(c *Config) *string { return &c.Session.CookieName }),
+	stringField("session.cookie_domain", "domain of the browser session cookies, empty for the host of the request",
+		func(c *Config) *string { return &c.Session.CookieDomain }),
+	boolField("session.cookie_secure", "send the browser session cookies over https only",
+		func(c *Config) *bool { return &c.Session.CookieSecure }),
+	stringField("session.cookie_same_site", "SameSite of the browser session cookies: lax, strict or none",
+		func(c *Config) *string { return &c.Session.CookieSameSite }),
+	durationField("session.ttl", "how long a browser session lasts",
+		func(c *Config) *time.Duration { return &c.Session.TTL }),
+	stringField("rate_limit.store", "where rate limit counters are kept: off, memory or postgres",
+		func(c *Config) *string { return &c.RateLimit.Store }),
+	boolField("rate_limit.trust_forwarded_for", "take the client ip from X-Forwarded-For, only behind a trusted proxy",
+		func(c *Config) *bool { return &c.RateLimit.TrustForwardedFor }),
+	listField("rate_limit.exempt_networks", "comma separated CIDRs of trusted clients that are never limited",
+		func(c *Config) *[]string { return &c.RateLimit.ExemptNetworks }),
+	listField("rate_limit.exempt_api_keys", "comma separated API keys of trusted clients that are never limited",
+		func(c *Config) *[]string { return &c.RateLimit.ExemptAPIKeys }),
+	stringField("idempotency.store", "where idempotency keys and responses are kept: off, memory or postgres",
+		func(c *Config) *string { return &c.Idempotency.Store }),
+	durationField("idempotency.ttl", "how long responses are replayed for retries with the same Idempotency-Key",
+		func(c *Config) *time.Duration { return &c.Idempotency.TTL }),
+	durationField("idempotency.lock_timeout", "after how long the key of an unfinished request may be taken over",
+		func(c *Config) *time.Duration { return &c.Idempotency.LockTimeout }),
+	stringField("mail.transport", "how emails are delivered: log, smtp, file or memory",
+		func(c *Config) *string { return &c.Mail.Transport }),
+	stringField("mail.from", "sender of the emails, e.g. Scratch <no-reply@example.com>",
+		func(c *Config) *string { return &c.Mail.From }),
+	stringField("mail.smtp_host", "host of the SMTP server",
+		func(c *Config) *string { return &c.Mail.SMTPHost }),
+	intField("mail.smtp_port", "port of the SMTP server",
+		func(c *Config) *int { return &c.Mail.SMTPPort }),
+	stringField("mail.smtp_username", "SMTP username, empty skips authentication",
+		func(c *Config) *string { return &c.Mail.SMTPUsername }),
+	stringField("mail.smtp_password", "SMTP password",
+		func(c *Config) *string { return &c.Mail.SMTPPassword }),
+	stringField("mail.dir", "directory the file transport writes .eml files to",
+		func(c *Config) *string { return &c.Mail.Dir }),
+	boolField("mail.preview", "serve a preview of the emails under /dev/mail to admins, for development only",
+		func(c *Config) *bool { return &c.Mail.Preview }),
+	durationField("mail.sent_retention", "how long the ids of sent emails are kept to not send them twice",
+		func(c *Config) *time.Duration { return &c.Mail.SentRetention }),
+	stringField("events.broker", "where domain events are relayed: off, memory, postgres or kafka",
+		func(c *Config) *string { return &c.Events.Broker }),
+	durationField("events.relay_interval", "how often the outbox is checked for new events",
+		func(c *Config) *time.Duration { return &c.Events.RelayInterval }),
+	intField("events.batch_size", "number of events relayed in one transaction",
+		func(c *Config) *int { return &c.Events.BatchSize }),
+	durationField("events.max_backoff", "longest delay before an event the broker refused is retried",
+		func(c *Config) *time.Duration { return &c.Events.MaxBackoff }),
+	intField("events.max_attempts", "number of times an event is offered to the broker before it is given up",
+		func(c *Config) *int { return &c.Events.MaxAttempts }),
+	durationField("events.lock_timeout", "longest publishing of a batch of events, after which they are relayed again",
+		func(c *Config) *time.Duration { return &c.Events.LockTimeout }),
+	durationField("events.retention", "how long published events are kept, 0 keeps them forever",
+		func(c *Config) *time.Duration { return &c.Events.Retention }),
+	stringField("events.postgres_channel", "channel the postgres broker notifies events on",
+		func(c *Config) *string { return &c.Events.PostgresChannel }),
+	stringField("events.kafka_proxy_url", "url of the Kafka REST proxy the kafka broker produces through",
+		func(c *Config) *string { return &c.Events.KafkaProxyURL }),
+	stringField("events.kafka_topic", "Kafka topic events are produced to",
+		func(c *Config) *string { return &c.Events.KafkaTopic }),
+	boolField("jobs.worker", "run background jobs in the server",
+		func(c *Config) *bool { return &c.Jobs.Worker }),
+	intField("jobs.concurrency", "number of background jobs run at the same time",
+		func(c *Config) *int { return &c.Jobs.Concurrency }),
+	durationField("jobs.poll_interval", "how often the queue is checked for due jobs",
+		func(c *Config) *time.Duration { return &c.Jobs.PollInterval }),
+	durationField("jobs.lock_timeout", "longest run of a job, after which it is retried",
+		func(c *Config) *time.Duration { return &c.Jobs.LockTimeout }),
+	durationField("jobs.max_backoff", "longest delay between the attempts of a failing job",
+		func(c *Config) *time.Duration { return &c.Jobs.MaxBackoff }),
+	durationField("jobs.retention", "how long done jobs are kept, 0 keeps them forever",
+		func(c *Config) *time.Duration { return &c.Jobs.Retention }),
+	stringField("jobs.session_cleanup", "cron schedule of deleting expired sessions, empty to never delete them",
+		func(c *Config) *string { return &c.Jobs.SessionCleanup }),
+	stringField("jobs.user_purge", "cron schedule of purging the accounts past their deletion grace period, empty to never purge them",
+		func(c *Config) *string { return &c.Jobs.UserPurge }),
+	stringField("jobs.data_export_cleanup", "cron schedule of deleting expired data export archives, empty to never delete them",
+		func(c *Config) *string { return &c.Jobs.DataExportCleanup }),
+	stringField("log.level", "log level: debug, info, warn or error",
+		func(c *Config) *string { return &c.Log.Level }),
+	stringField("log.format", "log format: json or text",
+		func(c *Config) *string { return &c.Log.Format }),
+}
@@ -0,0 +1,51 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Broker delivers events to their consumers.
+type Broker interface {
+	// Publish returns nil once the event is accepted, an error makes the
+	// relay retry it later.
+	Publish(ctx context.Context, e Event) error
+}
+
+// Handler consumes an event delivered in the process.
+type Handler func(ctx context.Context, e Event) error
+
+// MemoryBroker delivers events to handlers subscribed in the same process.
+type MemoryBroker struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe registers h for events of eventType, or for every event when
+// eventType is empty.
+func (m *MemoryBroker) Subscribe(eventType string, h Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.handlers[eventType] = append(m.handlers[eventType], h)
+}
+
+// Publish calls the handlers of the event in order. A failing handler fails
+// the publication, so the handlers before it see the event again on retry.
+func (m *MemoryBroker) Publish(ctx context.Context, e Event) error {
+	m.mu.RLock()
+	handlers := append(append([]Handler(nil), m.handlers[""]...), m.handlers[e.Type]...)
+	m.mu.RUnlock()
+
+	for _, h := range handlers {
+		if err := h(ctx, e); err != nil {
+			return fmt.Errorf("handle %v %d: %w", e.Type, e.ID, err)
+		}
+	}
+	return nil
+}
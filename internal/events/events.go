@@ -0,0 +1,111 @@
+// Package events publishes domain events through a transactional outbox.
+//
+// Services Record an event with the queries of the transaction that makes
+// the change it describes, so the event exists exactly when the change was
+// committed. A Relay later publishes the recorded events to a Broker. Delivery
+// is at least once: consumers must deduplicate by Event.ID. The events of one
+// aggregate are published in the order they were recorded.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	db "scratch/internal/storage/database"
+	"strconv"
+	"time"
+)
+
+// Event is a recorded domain event as it is handed to a broker.
+type Event struct {
+	// ID is unique and increasing, redeliveries keep it.
+	ID            int64           `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregateType"`
+	AggregateID   string          `json:"aggregateId"`
+	Payload       json.RawMessage `json:"payload"`
+	OccurredAt    time.Time       `json:"occurredAt"`
+}
+
+// Payload is the body of a domain event.
+type Payload interface {
+	// EventType names the event, e.g. user.registered.
+	EventType() string
+	// Aggregate identifies the entity the event belongs to, events of the
+	// same aggregate are delivered in order.
+	Aggregate() (typ, id string)
+}
+
+// Record writes the event to the outbox with q, which should be bound to the
+// transaction of the change the event describes.
+func Record(ctx context.Context, q db.Querier, p Payload, now time.Time) error {
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("marshal %v: %w", p.EventType(), err)
+	}
+	typ, id := p.Aggregate()
+	err = q.InsertOutboxEvent(ctx, db.InsertOutboxEventParams{
+		AggregateType: typ,
+		AggregateID:   id,
+		EventType:     p.EventType(),
+		Payload:       payload,
+		OccurredAt:    now,
+	})
+	if err != nil {
+		return fmt.Errorf("record %v: %w", p.EventType(), err)
+	}
+	return nil
+}
+
+const (
+	UserRegisteredType  = "user.registered"
+	UserLoggedInType    = "user.logged_in"
+	PasswordChangedType = "user.password_changed"
+	UserDeletedType     = "user.deleted"
+)
+
+// userAggregate is the aggregate of the user events.
+func userAggregate(id int) (string, string) {
+	return "user", strconv.Itoa(id)
+}
+
+// UserRegistered is recorded when an account is created.
+type UserRegistered struct {
+	UserID int    `json:"userId"`
+	Email  string `json:"email"`
+	Name   string `json:"name"`
+	Locale string `json:"locale,omitempty"`
+}
+
+func (UserRegistered) EventType() string             { return UserRegisteredType }
+func (e UserRegistered) Aggregate() (string, string) { return userAggregate(e.UserID) }
+
+// UserLoggedIn is recorded for every new session.
+type UserLoggedIn struct {
+	UserID int `json:"userId"`
+	// Browser tells cookie sessions apart from token sessions.
+	Browser    bool   `json:"browser"`
+	DeviceName string `json:"deviceName"`
+	IP         string `json:"ip"`
+}
+
+func (UserLoggedIn) EventType() string             { return UserLoggedInType }
+func (e UserLoggedIn) Aggregate() (string, string) { return userAggregate(e.UserID) }
+
+// PasswordChanged is recorded when the user or an admin sets a new password.
+type PasswordChanged struct {
+	UserID int `json:"userId"`
+}
+
+func (PasswordChanged) EventType() string             { return PasswordChangedType }
+func (e PasswordChanged) Aggregate() (string, string) { return userAggregate(e.UserID) }
+
+// UserDeleted is recorded when an account is erased, once the grace period of
+// its deletion is over or right away on request. Consumers should erase what
+// they hold about the user.
+type UserDeleted struct {
+	UserID int `json:"userId"`
+}
+
+func (UserDeleted) EventType() string             { return UserDeletedType }
+func (e UserDeleted) Aggregate() (string, string) { return userAggregate(e.UserID) }
@@ -0,0 +1,97 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	db "scratch/internal/storage/database"
+	mockdb "scratch/internal/storage/database/mock"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecord(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		payload Payload
+		want    db.InsertOutboxEventParams
+		err     error
+	}{
+		{
+			name:    "success - user registered",
+			payload: UserRegistered{UserID: 7, Email: "joedoe@gmail.com", Name: "Joe"},
+			want: db.InsertOutboxEventParams{
+				AggregateType: "user",
+				AggregateID:   "7",
+				EventType:     "user.registered",
+				Payload:       json.RawMessage(`{"userId":7,"email":"joedoe@gmail.com","name":"Joe"}`),
+				OccurredAt:    now,
+			},
+		},
+		{
+			name:    "success - password changed",
+			payload: PasswordChanged{UserID: 7},
+			want: db.InsertOutboxEventParams{
+				AggregateType: "user",
+				AggregateID:   "7",
+				EventType:     "user.password_changed",
+				Payload:       json.RawMessage(`{"userId":7}`),
+				OccurredAt:    now,
+			},
+		},
+		{
+			name:    "fail - insert",
+			payload: UserDeleted{UserID: 7},
+			err:     errors.New("connection reset"),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			queries := mockdb.NewMockQuerier(ctrl)
+			if tt.err != nil {
+				queries.EXPECT().InsertOutboxEvent(gomock.Any(), gomock.Any()).Return(tt.err)
+			} else {
+				queries.EXPECT().InsertOutboxEvent(gomock.Any(), tt.want).Return(nil)
+			}
+
+			err := Record(context.Background(), queries, tt.payload, now)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				assert.Contains(t, err.Error(), "record user.deleted")
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestMemoryBroker_Publish(t *testing.T) {
+	broker := NewMemoryBroker()
+	var got []string
+	broker.Subscribe("", func(ctx context.Context, e Event) error {
+		got = append(got, "all:"+e.Type)
+		return nil
+	})
+	broker.Subscribe(UserRegisteredType, func(ctx context.Context, e Event) error {
+		got = append(got, "registered:"+e.AggregateID)
+		return nil
+	})
+	failing := errors.New("handler down")
+	broker.Subscribe(UserDeletedType, func(ctx context.Context, e Event) error {
+		return failing
+	})
+
+	assert.NoError(t, broker.Publish(context.Background(), Event{ID: 1, Type: UserRegisteredType, AggregateID: "7"}))
+	assert.NoError(t, broker.Publish(context.Background(), Event{ID: 2, Type: UserLoggedInType, AggregateID: "7"}))
+	assert.ErrorIs(t, broker.Publish(context.Background(), Event{ID: 3, Type: UserDeletedType, AggregateID: "7"}), failing)
+
+	assert.Equal(t, []string{"all:user.registered", "registered:7", "all:user.logged_in", "all:user.deleted"}, got)
+}
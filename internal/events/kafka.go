@@ -0,0 +1,87 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// KafkaBroker produces events to a Kafka topic through a REST proxy speaking
+// the Confluent v2 API. Records are keyed by aggregate, which keeps the events
+// of an aggregate in one partition and so in order.
+type KafkaBroker struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewKafkaBroker produces to topic through the REST proxy at proxyURL, with
+// http.DefaultClient when client is nil.
+func NewKafkaBroker(proxyURL, topic string, client *http.Client) *KafkaBroker {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &KafkaBroker{
+		endpoint: strings.TrimSuffix(proxyURL, "/") + "/topics/" + url.PathEscape(topic),
+		client:   client,
+	}
+}
+
+type kafkaRecord struct {
+	Key   string `json:"key"`
+	Value Event  `json:"value"`
+}
+
+type kafkaProduceRequest struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+func (k *KafkaBroker) Publish(ctx context.Context, e Event) error {
+	body, err := json.Marshal(kafkaProduceRequest{Records: []kafkaRecord{{
+		Key:   e.AggregateType + ":" + e.AggregateID,
+		Value: e,
+	}}})
+	if err != nil {
+		return fmt.Errorf("marshal record: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("new produce request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	res, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("produce: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("produce: %v: %s", res.Status, bytes.TrimSpace(msg))
+	}
+	// the proxy answers 200 with an error per record it could not produce
+	var produced kafkaProduceResponse
+	if err := json.NewDecoder(res.Body).Decode(&produced); err != nil {
+		return fmt.Errorf("decode produce response: %w", err)
+	}
+	for _, o := range produced.Offsets {
+		if o.ErrorCode != nil {
+			return fmt.Errorf("produce: %v (code %d)", o.Error, *o.ErrorCode)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKafkaBroker_Publish(t *testing.T) {
+	event := Event{
+		ID:            42,
+		Type:          UserRegisteredType,
+		AggregateType: "user",
+		AggregateID:   "7",
+		Payload:       json.RawMessage(`{"userId":7}`),
+		OccurredAt:    time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		name     string
+		status   int
+		response string
+		wantErr  string
+	}{
+		{
+			name:     "success",
+			status:   http.StatusOK,
+			response: `{"offsets":[{"partition":2,"offset":100}]}`,
+		},
+		{
+			name:     "fail - record not produced",
+			status:   http.StatusOK,
+			response: `{"offsets":[{"partition":null,"offset":null,"error_code":50003,"error":"broker not available"}]}`,
+			wantErr:  "produce: broker not available (code 50003)",
+		},
+		{
+			name:     "fail - unknown topic",
+			status:   http.StatusNotFound,
+			response: `{"error_code":40401,"message":"Topic not found."}`,
+			wantErr:  `produce: 404 Not Found: {"error_code":40401,"message":"Topic not found."}`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "/topics/scratch.events", r.URL.Path)
+				assert.Equal(t, "application/vnd.kafka.json.v2+json", r.Header.Get("Content-Type"))
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				assert.JSONEq(t, `{"records":[{"key":"user:7","value":{
+					"id":42,"type":"user.registered","aggregateType":"user","aggregateId":"7",
+					"payload":{"userId":7},"occurredAt":"2026-10-19T12:00:00Z"}}]}`, string(body))
+
+				w.Header().Set("Content-Type", "application/vnd.kafka.v2+json")
+				w.WriteHeader(tt.status)
+				_, _ = io.WriteString(w, tt.response)
+			}))
+			defer proxy.Close()
+
+			err := NewKafkaBroker(proxy.URL+"/", "scratch.events", nil).Publish(context.Background(), event)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
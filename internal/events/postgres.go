@@ -0,0 +1,90 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxNotifyPayload is the largest payload postgres accepts in a NOTIFY.
+const maxNotifyPayload = 8000
+
+var PayloadTooLargeErr = errors.New("event payload too large to notify")
+
+// PostgresBroker sends events as NOTIFY on a channel. Only the sessions
+// listening at the time receive them, it suits consumers that can catch up
+// from the database on their own.
+type PostgresBroker struct {
+	db      *pgxpool.Pool
+	channel string
+}
+
+func NewPostgresBroker(db *pgxpool.Pool, channel string) *PostgresBroker {
+	return &PostgresBroker{db: db, channel: channel}
+}
+
+func (p *PostgresBroker) Publish(ctx context.Context, e Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	if len(payload) >= maxNotifyPayload {
+		return fmt.Errorf("%w: %d bytes", PayloadTooLargeErr, len(payload))
+	}
+	if _, err := p.db.Exec(ctx, "SELECT pg_notify($1, $2)", p.channel, string(payload)); err != nil {
+		return fmt.Errorf("notify %v: %w", p.channel, err)
+	}
+	return nil
+}
+
+// Listen calls h for every event notified on channel until ctx is done. It
+// holds a connection of pool, which is reacquired when it drops; events
+// notified meanwhile are missed.
+func Listen(ctx context.Context, pool *pgxpool.Pool, channel string, h Handler, log slog.Logger) error {
+	for {
+		err := listen(ctx, pool, channel, h, log)
+		if ctx.Err() != nil {
+			return nil
+		}
+		log.Warn("event listener", "channel", channel, "err", err)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func listen(ctx context.Context, pool *pgxpool.Pool, channel string, h Handler, log slog.Logger) error {
+	acquired, err := pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	// a listening connection must not go back to the pool
+	conn := acquired.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return fmt.Errorf("listen %v: %w", channel, err)
+	}
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("wait for notification: %w", err)
+		}
+		var e Event
+		if err := json.Unmarshal([]byte(n.Payload), &e); err != nil {
+			log.Warn("decode notified event", "channel", channel, "err", err)
+			continue
+		}
+		if err := h(ctx, e); err != nil {
+			log.Warn("handle notified event", "type", e.Type, "id", e.ID, "err", err)
+		}
+	}
+}
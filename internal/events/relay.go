@@ -0,0 +1,174 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	db "scratch/internal/storage/database"
+	"sort"
+	"time"
+)
+
+// cleanupInterval is how often published events past the retention are deleted.
+const cleanupInterval = time.Hour
+
+type RelayOptions struct {
+	// Interval is how long the relay waits for new events once the outbox is drained.
+	Interval time.Duration
+	// BatchSize is the number of events claimed at once.
+	BatchSize int
+	// MaxBackoff caps the doubling delay before a failed event is retried.
+	MaxBackoff time.Duration
+	// MaxAttempts is how often an event is offered to the broker before it
+	// is dead and no longer holds back the later events of its aggregate.
+	MaxAttempts int
+	// LockTimeout bounds the publishing of a batch, after it the events are
+	// claimed again.
+	LockTimeout time.Duration
+	// Retention is how long published events are kept, zero keeps them forever.
+	Retention time.Duration
+}
+
+// Relay publishes the events recorded in the outbox. Several relays may run
+// against the same database, an event is published by one of them at a time.
+type Relay struct {
+	tx      db.Transactor
+	broker  Broker
+	options RelayOptions
+	log     slog.Logger
+	now     func() time.Time
+}
+
+func NewRelay(tx db.Transactor, broker Broker, options RelayOptions, log slog.Logger) *Relay {
+	return &Relay{tx: tx, broker: broker, options: options, log: log, now: time.Now}
+}
+
+// Run relays events until ctx is done.
+func (r *Relay) Run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	var cleaned time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		n, err := r.RelayBatch(ctx)
+		if err != nil {
+			r.log.Warn("relay events", "err", err)
+		}
+		if r.options.Retention > 0 && r.now().Sub(cleaned) >= cleanupInterval {
+			if _, err := r.Cleanup(ctx); err != nil {
+				r.log.Warn("delete published events", "err", err)
+			}
+			cleaned = r.now()
+		}
+
+		// a full batch means more events are waiting
+		wait := r.options.Interval
+		if err == nil && n == r.options.BatchSize {
+			wait = 0
+		}
+		timer.Reset(wait)
+	}
+}
+
+// RelayBatch publishes the due events and returns how many it handled. The
+// events are claimed for the lock timeout and published outside of the
+// claiming transaction, so a slow broker holds no row locks. An event the
+// broker refuses is retried after a backoff, and holds back the later events
+// of its aggregate until then or until it is dead after the max attempts.
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	var rows []db.ClaimOutboxEventsRow
+	err := r.tx.InTx(ctx, func(q db.Querier) error {
+		var err error
+		now := r.now()
+		rows, err = q.ClaimOutboxEvents(ctx, db.ClaimOutboxEventsParams{
+			LockedUntil: now.Add(r.options.LockTimeout),
+			Now:         now,
+			BatchSize:   int32(r.options.BatchSize),
+		})
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("claim events: %w", err)
+	}
+	// UPDATE ... RETURNING does not keep the order of the subquery
+	sort.Slice(rows, func(i, j int) bool { return rows[i].ID < rows[j].ID })
+
+	for i, row := range rows {
+		e := Event{
+			ID:            row.ID,
+			Type:          row.EventType,
+			AggregateType: row.AggregateType,
+			AggregateID:   row.AggregateID,
+			Payload:       row.Payload,
+			OccurredAt:    row.OccurredAt,
+		}
+		published := r.broker.Publish(ctx, e)
+		if err := r.tx.InTx(ctx, func(q db.Querier) error {
+			return r.record(ctx, q, row, published)
+		}); err != nil {
+			return i, err
+		}
+	}
+	return len(rows), nil
+}
+
+// record stores the outcome of offering the event to the broker.
+func (r *Relay) record(ctx context.Context, q db.Querier, row db.ClaimOutboxEventsRow, published error) error {
+	attempts := row.Attempts + 1
+	switch {
+	case published == nil:
+		if err := q.MarkOutboxEventPublished(ctx, db.MarkOutboxEventPublishedParams{Now: r.now(), ID: row.ID}); err != nil {
+			return fmt.Errorf("mark event %d published: %w", row.ID, err)
+		}
+	case int(attempts) >= r.options.MaxAttempts:
+		r.log.Error("event dead", "type", row.EventType, "id", row.ID, "attempts", attempts, "err", published)
+		err := q.KillOutboxEvent(ctx, db.KillOutboxEventParams{Now: r.now(), LastError: published.Error(), ID: row.ID})
+		if err != nil {
+			return fmt.Errorf("mark event %d dead: %w", row.ID, err)
+		}
+	default:
+		delay := r.backoff(row.Attempts)
+		r.log.Warn("publish event", "type", row.EventType, "id", row.ID, "attempts", attempts, "retry_in", delay, "err", published)
+		err := q.MarkOutboxEventFailed(ctx, db.MarkOutboxEventFailedParams{
+			NextAttemptAt: r.now().Add(delay),
+			LastError:     published.Error(),
+			ID:            row.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("mark event %d failed: %w", row.ID, err)
+		}
+	}
+	return nil
+}
+
+// Cleanup deletes the events published longer than the retention ago.
+func (r *Relay) Cleanup(ctx context.Context) (int64, error) {
+	var n int64
+	err := r.tx.InTx(ctx, func(q db.Querier) error {
+		var err error
+		n, err = q.DeletePublishedOutboxEvents(ctx, r.now().Add(-r.options.Retention))
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("delete published events: %w", err)
+	}
+	return n, nil
+}
+
+// backoff is the delay before the next attempt after attempts failed ones.
+func (r *Relay) backoff(attempts int32) time.Duration {
+	delay := r.options.Interval
+	for i := int32(0); i < attempts && delay < r.options.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.options.MaxBackoff {
+		delay = r.options.MaxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,186 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	db "scratch/internal/storage/database"
+	mockdb "scratch/internal/storage/database/mock"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+// queriesTx runs the unit of work with the queries, without a transaction.
+type queriesTx struct {
+	q db.Querier
+}
+
+func (t queriesTx) InTx(ctx context.Context, fn func(q db.Querier) error) error {
+	return fn(t.q)
+}
+
+// brokerFunc adapts a function to a Broker.
+type brokerFunc func(ctx context.Context, e Event) error
+
+func (f brokerFunc) Publish(ctx context.Context, e Event) error {
+	return f(ctx, e)
+}
+
+func TestRelay_RelayBatch(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	options := RelayOptions{Interval: time.Second, BatchSize: 10, MaxBackoff: time.Minute, MaxAttempts: 5, LockTimeout: time.Minute}
+	rows := []db.ClaimOutboxEventsRow{
+		{ID: 2, AggregateType: "user", AggregateID: "8", EventType: UserLoggedInType, Payload: []byte(`{}`), Attempts: 3},
+		{ID: 1, AggregateType: "user", AggregateID: "7", EventType: UserRegisteredType, Payload: []byte(`{}`)},
+	}
+	refused := errors.New("broker down")
+
+	tests := []struct {
+		name        string
+		publish     func(e Event) error
+		prepareMock func(queries *mockdb.MockQuerier)
+		want        int
+		wantErr     string
+	}{
+		{
+			name:    "success - events are published in order",
+			publish: func(e Event) error { return nil },
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().ClaimOutboxEvents(gomock.Any(), db.ClaimOutboxEventsParams{
+					LockedUntil: now.Add(time.Minute),
+					Now:         now,
+					BatchSize:   10,
+				}).Return(rows, nil)
+				gomock.InOrder(
+					queries.EXPECT().MarkOutboxEventPublished(gomock.Any(), db.MarkOutboxEventPublishedParams{Now: now, ID: 1}).Return(nil),
+					queries.EXPECT().MarkOutboxEventPublished(gomock.Any(), db.MarkOutboxEventPublishedParams{Now: now, ID: 2}).Return(nil),
+				)
+			},
+			want: 2,
+		},
+		{
+			name: "success - refused event is retried after a backoff",
+			publish: func(e Event) error {
+				if e.ID == 2 {
+					return refused
+				}
+				return nil
+			},
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().ClaimOutboxEvents(gomock.Any(), gomock.Any()).Return(rows, nil)
+				queries.EXPECT().MarkOutboxEventPublished(gomock.Any(), db.MarkOutboxEventPublishedParams{Now: now, ID: 1}).Return(nil)
+				queries.EXPECT().MarkOutboxEventFailed(gomock.Any(), db.MarkOutboxEventFailedParams{
+					NextAttemptAt: now.Add(8 * time.Second),
+					LastError:     "broker down",
+					ID:            2,
+				}).Return(nil)
+			},
+			want: 2,
+		},
+		{
+			name: "success - event refused max attempts times is dead",
+			publish: func(e Event) error {
+				if e.ID == 2 {
+					return refused
+				}
+				return nil
+			},
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				dying := []db.ClaimOutboxEventsRow{rows[0], rows[1]}
+				for i := range dying {
+					if dying[i].ID == 2 {
+						dying[i].Attempts = 4
+					}
+				}
+				queries.EXPECT().ClaimOutboxEvents(gomock.Any(), gomock.Any()).Return(dying, nil)
+				queries.EXPECT().MarkOutboxEventPublished(gomock.Any(), db.MarkOutboxEventPublishedParams{Now: now, ID: 1}).Return(nil)
+				queries.EXPECT().KillOutboxEvent(gomock.Any(), db.KillOutboxEventParams{
+					Now:       now,
+					LastError: "broker down",
+					ID:        2,
+				}).Return(nil)
+			},
+			want: 2,
+		},
+		{
+			name:    "fail - claim events",
+			publish: func(e Event) error { return nil },
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().ClaimOutboxEvents(gomock.Any(), gomock.Any()).Return(nil, errors.New("connection reset"))
+			},
+			wantErr: "claim events: connection reset",
+		},
+		{
+			name:    "fail - mark event published",
+			publish: func(e Event) error { return nil },
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().ClaimOutboxEvents(gomock.Any(), gomock.Any()).Return(rows, nil)
+				queries.EXPECT().MarkOutboxEventPublished(gomock.Any(), gomock.Any()).Return(errors.New("connection reset"))
+			},
+			wantErr: "mark event 1 published: connection reset",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			queries := mockdb.NewMockQuerier(ctrl)
+			tt.prepareMock(queries)
+			var published []int64
+			broker := brokerFunc(func(ctx context.Context, e Event) error {
+				published = append(published, e.ID)
+				return tt.publish(e)
+			})
+			relay := NewRelay(queriesTx{queries}, broker, options, *slog.New(slog.NewTextHandler(io.Discard, nil)))
+			relay.now = func() time.Time { return now }
+
+			got, err := relay.RelayBatch(context.Background())
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, []int64{1, 2}, published)
+		})
+	}
+}
+
+func TestRelay_backoff(t *testing.T) {
+	relay := NewRelay(nil, nil, RelayOptions{Interval: time.Second, MaxBackoff: time.Minute}, slog.Logger{})
+	tests := []struct {
+		attempts int32
+		want     time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{5, 32 * time.Second},
+		{6, time.Minute},
+		{1000, time.Minute},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, relay.backoff(tt.attempts), "after %d attempts", tt.attempts)
+	}
+}
+
+func TestRelay_Cleanup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	queries := mockdb.NewMockQuerier(ctrl)
+	queries.EXPECT().DeletePublishedOutboxEvents(gomock.Any(), now.Add(-24*time.Hour)).Return(int64(3), nil)
+
+	relay := NewRelay(queriesTx{queries}, nil, RelayOptions{Retention: 24 * time.Hour}, slog.Logger{})
+	relay.now = func() time.Time { return now }
+
+	n, err := relay.Cleanup(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+}
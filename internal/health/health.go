@@ -0,0 +1,142 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pressly/goose/v3"
+)
+
+const (
+	statusOK       = "ok"
+	statusFailing  = "failing"
+	statusDraining = "draining"
+)
+
+// Check reports whether a single dependency of the application is usable.
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Probes serves liveness and readiness endpoints for orchestrators like k8s.
+// Liveness only says that the process is able to answer, readiness runs every
+// registered check and fails as soon as the application starts draining.
+type Probes struct {
+	mu       sync.RWMutex
+	checks   []namedCheck
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+type probeResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func NewProbes(timeout time.Duration) *Probes {
+	return &Probes{timeout: timeout}
+}
+
+// AddReadinessCheck registers check under name, checks run in registration order.
+func (p *Probes) AddReadinessCheck(name string, check Check) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.checks = append(p.checks, namedCheck{name: name, check: check})
+}
+
+// Drain marks the application as not ready, so load balancers stop routing
+// new traffic while in-flight requests are finished.
+func (p *Probes) Drain() {
+	p.draining.Store(true)
+}
+
+// Routes mounts /healthz and /readyz on r.
+func (p *Probes) Routes(r chi.Router) {
+	r.Get("/healthz", p.Liveness)
+	r.Get("/readyz", p.Readiness)
+}
+
+func (p *Probes) Liveness(w http.ResponseWriter, r *http.Request) {
+	writeProbe(w, http.StatusOK, probeResponse{Status: statusOK})
+}
+
+func (p *Probes) Readiness(w http.ResponseWriter, r *http.Request) {
+	if p.draining.Load() {
+		writeProbe(w, http.StatusServiceUnavailable, probeResponse{Status: statusDraining})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), p.timeout)
+	defer cancel()
+
+	p.mu.RLock()
+	checks := make([]namedCheck, len(p.checks))
+	copy(checks, p.checks)
+	p.mu.RUnlock()
+
+	code := http.StatusOK
+	response := probeResponse{Status: statusOK, Checks: make(map[string]string, len(checks))}
+	for _, c := range checks {
+		if err := c.check(ctx); err != nil {
+			code = http.StatusServiceUnavailable
+			response.Status = statusFailing
+			response.Checks[c.name] = err.Error()
+			continue
+		}
+		response.Checks[c.name] = statusOK
+	}
+
+	writeProbe(w, code, response)
+}
+
+// DatabaseCheck pings the database.
+func DatabaseCheck(database *pgxpool.Pool) Check {
+	return func(ctx context.Context) error {
+		if err := database.Ping(ctx); err != nil {
+			return fmt.Errorf("ping database: %w", err)
+		}
+		return nil
+	}
+}
+
+// MigrationCheck verifies that the database schema is at the newest migration
+// found in dir. It relies on the goose base FS and dialect being already set.
+func MigrationCheck(database *sql.DB, dir string) Check {
+	return func(ctx context.Context) error {
+		migrations, err := goose.CollectMigrations(dir, 0, goose.MaxVersion)
+		if err != nil {
+			return fmt.Errorf("collect migrations: %w", err)
+		}
+		latest, err := migrations.Last()
+		if err != nil {
+			return fmt.Errorf("find latest migration: %w", err)
+		}
+
+		current, err := goose.GetDBVersionContext(ctx, database)
+		if err != nil {
+			return fmt.Errorf("get database version: %w", err)
+		}
+		if current < latest.Version {
+			return fmt.Errorf("database at version %d, expected %d", current, latest.Version)
+		}
+		return nil
+	}
+}
+
+func writeProbe(w http.ResponseWriter, code int, response probeResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(response)
+}
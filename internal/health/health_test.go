@@ -0,0 +1,93 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbes(t *testing.T) {
+	tests := []struct {
+		name          string
+		path          string
+		prepareProbes func(p *Probes)
+		wantCode      int
+		wantBody      probeResponse
+	}{
+		{
+			name:          "liveness - always ok",
+			path:          "/healthz",
+			prepareProbes: func(p *Probes) {},
+			wantCode:      http.StatusOK,
+			wantBody:      probeResponse{Status: statusOK},
+		},
+		{
+			name: "readiness - all checks pass",
+			path: "/readyz",
+			prepareProbes: func(p *Probes) {
+				p.AddReadinessCheck("database", func(ctx context.Context) error { return nil })
+			},
+			wantCode: http.StatusOK,
+			wantBody: probeResponse{Status: statusOK, Checks: map[string]string{"database": statusOK}},
+		},
+		{
+			name: "readiness - failing check",
+			path: "/readyz",
+			prepareProbes: func(p *Probes) {
+				p.AddReadinessCheck("database", func(ctx context.Context) error { return nil })
+				p.AddReadinessCheck("migrations", func(ctx context.Context) error {
+					return errors.New("database at version 1, expected 2")
+				})
+			},
+			wantCode: http.StatusServiceUnavailable,
+			wantBody: probeResponse{Status: statusFailing, Checks: map[string]string{
+				"database":   statusOK,
+				"migrations": "database at version 1, expected 2",
+			}},
+		},
+		{
+			name: "readiness - draining",
+			path: "/readyz",
+			prepareProbes: func(p *Probes) {
+				p.AddReadinessCheck("database", func(ctx context.Context) error { return nil })
+				p.Drain()
+			},
+			wantCode: http.StatusServiceUnavailable,
+			wantBody: probeResponse{Status: statusDraining},
+		},
+		{
+			name: "liveness - ok while draining",
+			path: "/healthz",
+			prepareProbes: func(p *Probes) {
+				p.Drain()
+			},
+			wantCode: http.StatusOK,
+			wantBody: probeResponse{Status: statusOK},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewProbes(time.Second)
+			tt.prepareProbes(p)
+
+			r := chi.NewRouter()
+			p.Routes(r)
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			var got probeResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+			assert.Equal(t, tt.wantBody, got)
+		})
+	}
+}
@@ -0,0 +1,223 @@
+// Package i18n holds the message catalogs of user facing texts and picks the
+// locale of a request from Accept-Language or the user's preference.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLocale is used when nothing the client asked for is supported. Its
+// catalog is the reference every other catalog is checked against.
+const DefaultLocale = "en"
+
+//go:embed locales/*.json
+var locales embed.FS
+
+var defaultBundle = mustLoad()
+
+func mustLoad() *Bundle {
+	sub, err := fs.Sub(locales, "locales")
+	if err != nil {
+		panic(err)
+	}
+	b, err := Load(sub, DefaultLocale)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// Default returns the bundle of the embedded catalogs.
+func Default() *Bundle {
+	return defaultBundle
+}
+
+// Bundle is a set of catalogs, one per locale, each mapping a message key to
+// its text. Texts may contain {name} placeholders filled by T.
+type Bundle struct {
+	fallback string
+	locales  []string
+	catalogs map[string]map[string]string
+	matcher  language.Matcher
+}
+
+// Load reads every <locale>.json file in the root of fsys.
+func Load(fsys fs.FS, fallback string) (*Bundle, error) {
+	files, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, fmt.Errorf("list catalogs: %w", err)
+	}
+
+	b := &Bundle{fallback: fallback, catalogs: make(map[string]map[string]string)}
+	for _, file := range files {
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("read catalog: %w", err)
+		}
+		var catalog map[string]string
+		if err := json.Unmarshal(content, &catalog); err != nil {
+			return nil, fmt.Errorf("decode catalog %v: %w", file, err)
+		}
+		locale := strings.TrimSuffix(path.Base(file), ".json")
+		if _, err := language.Parse(locale); err != nil {
+			return nil, fmt.Errorf("catalog %v: %w", file, err)
+		}
+		b.catalogs[locale] = catalog
+	}
+	if _, ok := b.catalogs[fallback]; !ok {
+		return nil, fmt.Errorf("no catalog for the fallback locale %v", fallback)
+	}
+
+	// the fallback goes first, the matcher returns it when nothing matches
+	b.locales = append(b.locales, fallback)
+	for locale := range b.catalogs {
+		if locale != fallback {
+			b.locales = append(b.locales, locale)
+		}
+	}
+	sort.Strings(b.locales[1:])
+
+	tags := make([]language.Tag, len(b.locales))
+	for i, locale := range b.locales {
+		tags[i] = language.MustParse(locale)
+	}
+	b.matcher = language.NewMatcher(tags)
+	return b, nil
+}
+
+// Locales lists the supported locales, the fallback first.
+func (b *Bundle) Locales() []string {
+	return append([]string(nil), b.locales...)
+}
+
+// Supports reports whether locale, or its base language, has a catalog.
+func (b *Bundle) Supports(locale string) bool {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return false
+	}
+	base, _ := tag.Base()
+	_, ok := b.catalogs[base.String()]
+	return ok
+}
+
+// Match picks the supported locale best matching the preferences, in order of
+// priority. Each preference is a locale or an Accept-Language header; empty
+// and malformed ones are skipped.
+func (b *Bundle) Match(preferences ...string) string {
+	for _, preference := range preferences {
+		if preference == "" {
+			continue
+		}
+		tags, _, err := language.ParseAcceptLanguage(preference)
+		if err != nil || len(tags) == 0 {
+			continue
+		}
+		_, index, confidence := b.matcher.Match(tags...)
+		if confidence != language.No {
+			return b.locales[index]
+		}
+	}
+	return b.fallback
+}
+
+// T returns the text of key in locale, falling back to the default catalog and
+// then to the key itself. args are name, value pairs filling {name} placeholders.
+func (b *Bundle) T(locale, key string, args ...any) string {
+	text, ok := b.catalogs[locale][key]
+	if !ok {
+		text, ok = b.catalogs[b.fallback][key]
+	}
+	if !ok {
+		return key
+	}
+
+	for i := 0; i+1 < len(args); i += 2 {
+		text = strings.ReplaceAll(text, fmt.Sprintf("{%v}", args[i]), fmt.Sprint(args[i+1]))
+	}
+	return text
+}
+
+// Funcs returns template functions rendering texts in locale, so email and
+// page templates can use {{t "email.welcome.subject"}}.
+func (b *Bundle) Funcs(locale string) map[string]any {
+	return map[string]any{
+		"t": func(key string, args ...any) string {
+			return b.T(locale, key, args...)
+		},
+		"locale": func() string {
+			return locale
+		},
+	}
+}
+
+// Missing lists, per locale, the keys of the fallback catalog it does not
+// translate. Unknown keys, present only in a translation, are listed as well
+// under the fallback locale, since they are most likely typos.
+func (b *Bundle) Missing() map[string][]string {
+	missing := make(map[string][]string)
+	reference := b.catalogs[b.fallback]
+	for locale, catalog := range b.catalogs {
+		if locale == b.fallback {
+			continue
+		}
+		for key := range reference {
+			if _, ok := catalog[key]; !ok {
+				missing[locale] = append(missing[locale], key)
+			}
+		}
+		for key := range catalog {
+			if _, ok := reference[key]; !ok {
+				missing[b.fallback] = append(missing[b.fallback], key)
+			}
+		}
+	}
+	for locale := range missing {
+		sort.Strings(missing[locale])
+	}
+	return missing
+}
+
+// Extract returns the catalog of locale with every key of the fallback
+// catalog, untranslated ones left empty, ready to hand to a translator.
+func (b *Bundle) Extract(locale string) map[string]string {
+	catalog := make(map[string]string, len(b.catalogs[b.fallback]))
+	for key := range b.catalogs[b.fallback] {
+		catalog[key] = b.catalogs[locale][key]
+	}
+	return catalog
+}
+
+type localeKey struct{}
+
+// WithLocale returns ctx carrying the locale texts should be rendered in.
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// LocaleFrom returns the locale stored by WithLocale, or an empty string.
+func LocaleFrom(ctx context.Context) string {
+	locale, _ := ctx.Value(localeKey{}).(string)
+	return locale
+}
+
+// Middleware negotiates the locale of the request from Accept-Language and
+// stores it in the request context.
+func (b *Bundle) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale := b.Match(r.Header.Get("Accept-Language"))
+		w.Header().Set("Content-Language", locale)
+		w.Header().Add("Vary", "Accept-Language")
+		next.ServeHTTP(w, r.WithContext(WithLocale(r.Context(), locale)))
+	})
+}
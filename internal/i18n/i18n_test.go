@@ -0,0 +1,116 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var placeholder = regexp.MustCompile(`\{[a-z_]+\}`)
+
+// TestCatalogs guards the embedded catalogs: every locale translates every key
+// and keeps the placeholders of the reference text.
+func TestCatalogs(t *testing.T) {
+	b := Default()
+	assert.Empty(t, b.Missing(), "run `chatto i18n extract LOCALE` to list the keys to translate")
+
+	reference := b.catalogs[DefaultLocale]
+	for locale, catalog := range b.catalogs {
+		for key, text := range catalog {
+			assert.NotEmpty(t, text, "%v: %v is empty", locale, key)
+			assert.ElementsMatch(t, placeholder.FindAllString(reference[key], -1), placeholder.FindAllString(text, -1),
+				"%v: %v has different placeholders than %v", locale, key, DefaultLocale)
+		}
+	}
+}
+
+func testBundle(t *testing.T) *Bundle {
+	t.Helper()
+	b, err := Load(fstest.MapFS{
+		"en.json": {Data: []byte(`{"hello": "Hello {name}", "bye": "Bye"}`)},
+		"pl.json": {Data: []byte(`{"hello": "Cześć {name}", "typo": "Literówka"}`)},
+	}, "en")
+	require.NoError(t, err)
+	return b
+}
+
+func TestLoad(t *testing.T) {
+	_, err := Load(fstest.MapFS{"pl.json": {Data: []byte(`{}`)}}, "en")
+	assert.ErrorContains(t, err, "no catalog for the fallback locale en")
+
+	_, err = Load(fstest.MapFS{"en.json": {Data: []byte(`["not", "an", "object"]`)}}, "en")
+	assert.ErrorContains(t, err, "decode catalog en.json")
+}
+
+func TestBundle_Missing(t *testing.T) {
+	assert.Equal(t, map[string][]string{
+		"pl": {"bye"},
+		"en": {"typo"},
+	}, testBundle(t).Missing())
+}
+
+func TestBundle_Extract(t *testing.T) {
+	assert.Equal(t, map[string]string{"hello": "Cześć {name}", "bye": ""}, testBundle(t).Extract("pl"))
+}
+
+func TestBundle_Match(t *testing.T) {
+	tests := []struct {
+		name        string
+		preferences []string
+		want        string
+	}{
+		{"nothing", nil, "en"},
+		{"exact", []string{"pl"}, "pl"},
+		{"region", []string{"pl-PL"}, "pl"},
+		{"accept language by quality", []string{"de;q=0.9, pl;q=0.8, en;q=0.5"}, "pl"},
+		{"unsupported", []string{"de"}, "en"},
+		{"user preference wins", []string{"en", "pl"}, "en"},
+		{"empty preference is skipped", []string{"", "pl"}, "pl"},
+		{"malformed preference is skipped", []string{"not a locale!", "pl"}, "pl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, testBundle(t).Match(tt.preferences...))
+		})
+	}
+}
+
+func TestBundle_T(t *testing.T) {
+	b := testBundle(t)
+	assert.Equal(t, "Cześć Ola", b.T("pl", "hello", "name", "Ola"))
+	assert.Equal(t, "Bye", b.T("pl", "bye"), "falls back to the default catalog")
+	assert.Equal(t, "unknown", b.T("pl", "unknown"), "falls back to the key")
+	assert.True(t, b.Supports("pl-PL"))
+	assert.False(t, b.Supports("de"))
+}
+
+func TestBundle_Funcs(t *testing.T) {
+	tmpl := template.Must(template.New("email").Funcs(testBundle(t).Funcs("pl")).
+		Parse(`{{t "hello" "name" .}} ({{locale}})`))
+
+	var out strings.Builder
+	require.NoError(t, tmpl.Execute(&out, "Ola"))
+	assert.Equal(t, "Cześć Ola (pl)", out.String())
+}
+
+func TestBundle_Middleware(t *testing.T) {
+	var got string
+	handler := testBundle(t).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = LocaleFrom(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Language", "pl-PL,pl;q=0.9,en;q=0.8")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, "pl", got)
+	assert.Equal(t, "pl", w.Header().Get("Content-Language"))
+}
@@ -0,0 +1,60 @@
+// Package idempotency makes retries of api operations safe: the first request
+// carrying an Idempotency-Key runs the operation, later ones with the same key
+// get its stored response replayed instead of running it again.
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrKeyReused is returned when a key is sent again with a different request.
+	ErrKeyReused = errors.New("idempotency key was used for a different request")
+	// ErrInProgress is returned while the first request of a key is still running.
+	ErrInProgress = errors.New("request with the idempotency key is in progress")
+	// ErrLockLost is returned when a request that ran too long completes a key
+	// taken over by a retry meanwhile.
+	ErrLockLost = errors.New("idempotency key was taken over by another request")
+)
+
+// Claim asks for the key of an operation on behalf of a request.
+type Claim struct {
+	Operation string
+	Key       string
+	// Fingerprint identifies the request, a key is bound to its first request.
+	Fingerprint string
+	// Now is when the request locked the key, it identifies the lock when
+	// the key is completed or released.
+	Now time.Time
+	// ExpiresAt is when the key, and the response stored for it, is forgotten.
+	ExpiresAt time.Time
+	// StaleBefore is the time locks older than which belong to crashed
+	// requests and may be taken over.
+	StaleBefore time.Time
+}
+
+// Response is what the operation answered to the first request of a key.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Store keeps the keys.
+type Store interface {
+	// Begin locks the key for the request of claim. It returns the stored
+	// response when the key was already completed, nil when the request
+	// should run, ErrKeyReused or ErrInProgress otherwise.
+	Begin(ctx context.Context, claim Claim) (*Response, error)
+	// Complete stores the response of the request of claim, or returns
+	// ErrLockLost when the request no longer holds the key.
+	Complete(ctx context.Context, claim Claim, response Response) error
+	// Release forgets the key of claim, so the request can be retried from
+	// scratch. A key the request no longer holds is left alone.
+	Release(ctx context.Context, claim Claim) error
+	// DeleteExpired forgets keys that expired before now.
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
@@ -0,0 +1,237 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"scratch/internal/problem"
+	"sort"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// Header carries the key chosen by the client, unique per logical request.
+	Header = "Idempotency-Key"
+	// ReplayedHeader is set on responses replayed from the store.
+	ReplayedHeader = "Idempotent-Replayed"
+	// Extension marks the operations of the api spec that honour Header.
+	Extension = "x-idempotent"
+
+	maxKeyLength = 255
+	// maxStoredBody caps the responses kept, larger ones are not replayed.
+	maxStoredBody = 1 << 20
+)
+
+type Options struct {
+	// TTL is how long a key and its response are remembered.
+	TTL time.Duration
+	// LockTimeout is how long a request may hold its key before it is
+	// considered crashed and a retry takes the key over.
+	LockTimeout time.Duration
+}
+
+// Middleware deduplicates the requests of the operations it is enabled for.
+// Like the validation middleware it runs inside the chi route of the operation.
+type Middleware struct {
+	store      Store
+	operations map[string]bool
+	options    Options
+	now        func() time.Time
+	log        slog.Logger
+}
+
+// New enables store backed deduplication for operations, e.g. "POST /register".
+func New(store Store, operations []string, options Options, log slog.Logger) *Middleware {
+	m := &Middleware{store: store, operations: make(map[string]bool), options: options, now: time.Now, log: log}
+	for _, operation := range operations {
+		m.operations[operation] = true
+	}
+	return m
+}
+
+// OperationsFromSpec lists the operations of spec marked with Extension.
+func OperationsFromSpec(spec *openapi3.T) []string {
+	var operations []string
+	for path, item := range spec.Paths {
+		for method, operation := range item.Operations() {
+			if enabled, _ := operation.Extensions[Extension].(bool); enabled {
+				operations = append(operations, method+" "+path)
+			}
+		}
+	}
+	sort.Strings(operations)
+	return operations
+}
+
+func (m *Middleware) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		operation := operation(r)
+		key := r.Header.Get(Header)
+		if key == "" || !m.operations[operation] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxKeyLength {
+			problem.Write(w, r, problem.New(problem.InvalidRequest, "detail.invalid-idempotency-key"))
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			problem.Write(w, r, problem.New(problem.InvalidRequest, ""))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// postgres keeps microseconds, the lock must compare equal once stored
+		now := m.now().Truncate(time.Microsecond)
+		claim := Claim{
+			Operation:   operation,
+			Key:         key,
+			Fingerprint: fingerprint(r, body),
+			Now:         now,
+			ExpiresAt:   now.Add(m.options.TTL),
+			StaleBefore: now.Add(-m.options.LockTimeout),
+		}
+		stored, err := m.store.Begin(r.Context(), claim)
+		switch {
+		case errors.Is(err, ErrKeyReused):
+			problem.Write(w, r, problem.New(problem.IdempotencyKeyReused, ""))
+			return
+		case errors.Is(err, ErrInProgress):
+			w.Header().Set("Retry-After", "1")
+			problem.Write(w, r, problem.New(problem.IdempotencyKeyInProgress, ""))
+			return
+		case err != nil:
+			// running the request anyway could run it twice
+			problem.WriteError(w, r, m.log, fmt.Errorf("begin idempotent request: %w", err))
+			return
+		case stored != nil:
+			replay(w, *stored)
+			return
+		}
+
+		m.serve(w, r, next, claim)
+	})
+}
+
+// serve runs the request holding the lock of claim and stores its response.
+// Server errors and panics release the key instead, so they can be retried.
+func (m *Middleware) serve(w http.ResponseWriter, r *http.Request, next http.Handler, claim Claim) {
+	recorder := &recorder{ResponseWriter: w, before: w.Header().Clone()}
+	// the key is stored or released even when the client went away, else
+	// its retries would wait for the lock timeout
+	ctx := context.WithoutCancel(r.Context())
+	completed := false
+	defer func() {
+		if completed {
+			return
+		}
+		if err := m.store.Release(ctx, claim); err != nil {
+			m.log.Warn("release idempotency key", "operation", claim.Operation, "err", err)
+		}
+	}()
+
+	next.ServeHTTP(recorder, r)
+
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+	if recorder.status >= http.StatusInternalServerError || recorder.overflow {
+		return
+	}
+	response := Response{StatusCode: recorder.status, Header: recorder.added(), Body: recorder.body.Bytes()}
+	if err := m.store.Complete(ctx, claim, response); err != nil {
+		m.log.Warn("complete idempotency key", "operation", claim.Operation, "err", err)
+		return
+	}
+	completed = true
+}
+
+func replay(w http.ResponseWriter, response Response) {
+	for name, values := range response.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(response.StatusCode)
+	_, _ = w.Write(response.Body)
+}
+
+// fingerprint binds a key to the request body and to the credentials it was
+// sent with, so another client can not replay somebody else's response.
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	_, _ = io.WriteString(h, r.Method+"\n"+r.URL.Path+"\n"+r.Header.Get("Authorization")+"\n")
+	_, _ = h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func operation(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return ""
+	}
+	return r.Method + " " + rctx.RoutePattern()
+}
+
+// recorder passes the response through while keeping a copy of it.
+type recorder struct {
+	http.ResponseWriter
+	// before is the header set by outer middlewares, it is not stored since
+	// they set it again on the replay.
+	before   http.Header
+	status   int
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	if r.body.Len()+len(b) > maxStoredBody {
+		r.overflow = true
+	} else {
+		r.body.Write(b)
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// added returns the header fields set or changed by the operation.
+func (r *recorder) added() http.Header {
+	added := make(http.Header)
+	for name, values := range r.Header() {
+		if !equal(r.before[name], values) {
+			added[name] = values
+		}
+	}
+	return added
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,344 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"scratch/api"
+	"scratch/internal/problem"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingStore struct{ *MemoryStore }
+
+func (failingStore) Begin(ctx context.Context, claim Claim) (*Response, error) {
+	return nil, errors.New("connection refused")
+}
+
+// contextStore fails like the database does once ctx is done.
+type contextStore struct{ *MemoryStore }
+
+func (s contextStore) Complete(ctx context.Context, claim Claim, response Response) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.MemoryStore.Complete(ctx, claim, response)
+}
+
+// counter is an operation that creates a new resource on every call.
+type counter struct {
+	calls   atomic.Int32
+	status  int
+	started chan struct{}
+	proceed chan struct{}
+}
+
+func (c *counter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := c.calls.Add(1)
+	body, _ := io.ReadAll(r.Body)
+	if c.started != nil {
+		c.started <- struct{}{}
+		<-c.proceed
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/user/"+string(body))
+	w.WriteHeader(c.status)
+	_ = json.NewEncoder(w).Encode(map[string]int32{"id": n})
+}
+
+func newRouter(t *testing.T, store Store, handler http.Handler) http.Handler {
+	t.Helper()
+	m := New(store, []string{"POST /register"}, Options{TTL: time.Hour, LockTimeout: time.Minute},
+		*slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	r := chi.NewRouter()
+	// an outer middleware, its header must not be stored with the response
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("RateLimit-Remaining", "3")
+			next.ServeHTTP(w, r)
+		})
+	})
+	r.With(m.Middleware).Post("/register", handler.ServeHTTP)
+	r.With(m.Middleware).Post("/login", handler.ServeHTTP)
+	return r
+}
+
+func send(handler http.Handler, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(Header, key)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func problemType(t *testing.T, rec *httptest.ResponseRecorder) problem.Type {
+	t.Helper()
+	var p api.Problem
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&p))
+	return problem.Type(p.Type)
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		verify func(t *testing.T, router http.Handler, handler *counter)
+	}{
+		{
+			name:   "success - retry replays the stored response",
+			status: http.StatusCreated,
+			verify: func(t *testing.T, router http.Handler, handler *counter) {
+				first := send(router, "/register", "key-1", "alice")
+				second := send(router, "/register", "key-1", "alice")
+
+				assert.Equal(t, int32(1), handler.calls.Load())
+				assert.Equal(t, http.StatusCreated, second.Code)
+				assert.Equal(t, first.Body.String(), second.Body.String())
+				assert.Equal(t, "/user/alice", second.Header().Get("Location"))
+				assert.Equal(t, "true", second.Header().Get(ReplayedHeader))
+				assert.Empty(t, first.Header().Get(ReplayedHeader))
+			},
+		},
+		{
+			name:   "success - different keys run the operation again",
+			status: http.StatusCreated,
+			verify: func(t *testing.T, router http.Handler, handler *counter) {
+				send(router, "/register", "key-1", "alice")
+				rec := send(router, "/register", "key-2", "alice")
+
+				assert.Equal(t, int32(2), handler.calls.Load())
+				assert.Empty(t, rec.Header().Get(ReplayedHeader))
+			},
+		},
+		{
+			name:   "success - requests without a key or of other operations are not deduplicated",
+			status: http.StatusOK,
+			verify: func(t *testing.T, router http.Handler, handler *counter) {
+				send(router, "/register", "", "alice")
+				send(router, "/register", "", "alice")
+				send(router, "/login", "key-1", "alice")
+				send(router, "/login", "key-1", "alice")
+
+				assert.Equal(t, int32(4), handler.calls.Load())
+			},
+		},
+		{
+			name:   "success - server errors are not stored",
+			status: http.StatusInternalServerError,
+			verify: func(t *testing.T, router http.Handler, handler *counter) {
+				send(router, "/register", "key-1", "alice")
+				rec := send(router, "/register", "key-1", "alice")
+
+				assert.Equal(t, int32(2), handler.calls.Load())
+				assert.Empty(t, rec.Header().Get(ReplayedHeader))
+			},
+		},
+		{
+			name:   "fail - key reused with a different body",
+			status: http.StatusCreated,
+			verify: func(t *testing.T, router http.Handler, handler *counter) {
+				send(router, "/register", "key-1", "alice")
+				rec := send(router, "/register", "key-1", "bob")
+
+				assert.Equal(t, int32(1), handler.calls.Load())
+				assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+				assert.Equal(t, problem.IdempotencyKeyReused, problemType(t, rec))
+			},
+		},
+		{
+			name:   "fail - key too long",
+			status: http.StatusCreated,
+			verify: func(t *testing.T, router http.Handler, handler *counter) {
+				rec := send(router, "/register", strings.Repeat("k", maxKeyLength+1), "alice")
+
+				assert.Equal(t, int32(0), handler.calls.Load())
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &counter{status: tt.status}
+			tt.verify(t, newRouter(t, NewMemoryStore(), handler), handler)
+		})
+	}
+}
+
+func TestMiddleware_ConcurrentDuplicate(t *testing.T) {
+	handler := &counter{status: http.StatusCreated, started: make(chan struct{}), proceed: make(chan struct{})}
+	router := newRouter(t, NewMemoryStore(), handler)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- send(router, "/register", "key-1", "alice")
+	}()
+	<-handler.started
+
+	rec := send(router, "/register", "key-1", "alice")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+	assert.Equal(t, problem.IdempotencyKeyInProgress, problemType(t, rec))
+
+	close(handler.proceed)
+	assert.Equal(t, http.StatusCreated, (<-done).Code)
+
+	rec = send(router, "/register", "key-1", "alice")
+	assert.Equal(t, "true", rec.Header().Get(ReplayedHeader))
+	assert.Equal(t, int32(1), handler.calls.Load())
+}
+
+func TestMiddleware_Panic(t *testing.T) {
+	store := NewMemoryStore()
+	router := newRouter(t, store, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	assert.Panics(t, func() { send(router, "/register", "key-1", "alice") })
+
+	// the key was released, a retry runs the operation again
+	stored, err := store.Begin(context.Background(), Claim{Operation: "POST /register", Key: "key-1", Fingerprint: "other", Now: time.Now()})
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+}
+
+func TestMiddleware_TakenOver(t *testing.T) {
+	store := NewMemoryStore()
+	var router http.Handler
+	router = newRouter(t, store, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the request runs past the lock timeout and a retry takes the key over
+		now := time.Now().Add(2 * time.Minute)
+		_, err := store.Begin(context.Background(), Claim{
+			Operation: "POST /register", Key: "key-1", Fingerprint: fingerprint(r, []byte("alice")),
+			Now: now, ExpiresAt: now.Add(time.Hour), StaleBefore: now.Add(-time.Minute),
+		})
+		require.NoError(t, err)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	assert.Equal(t, http.StatusCreated, send(router, "/register", "key-1", "alice").Code)
+
+	// the first request neither completed nor released the key of the retry
+	rec := send(router, "/register", "key-1", "alice")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, problem.IdempotencyKeyInProgress, problemType(t, rec))
+}
+
+func TestMiddleware_ClientGone(t *testing.T) {
+	handler := &counter{status: http.StatusCreated}
+	ctx, cancel := context.WithCancel(context.Background())
+	router := newRouter(t, contextStore{NewMemoryStore()}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+		cancel()
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader("alice")).WithContext(ctx)
+	req.Header.Set(Header, "key-1")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	rec := send(router, "/register", "key-1", "alice")
+	assert.Equal(t, "true", rec.Header().Get(ReplayedHeader))
+	assert.Equal(t, int32(1), handler.calls.Load())
+}
+
+func TestMiddleware_StoreUnavailable(t *testing.T) {
+	handler := &counter{status: http.StatusCreated}
+	rec := send(newRouter(t, failingStore{NewMemoryStore()}, handler), "/register", "key-1", "alice")
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, int32(0), handler.calls.Load())
+}
+
+func TestMemoryStore_Begin(t *testing.T) {
+	now := time.Now()
+	claim := Claim{Operation: "POST /register", Key: "key-1", Fingerprint: "a", Now: now, ExpiresAt: now.Add(time.Hour), StaleBefore: now.Add(-time.Minute)}
+
+	tests := []struct {
+		name    string
+		prepare func(s *MemoryStore)
+		claim   func(c Claim) Claim
+		want    *Response
+		wantErr error
+	}{
+		{
+			name:    "success - new key",
+			prepare: func(s *MemoryStore) {},
+			claim:   func(c Claim) Claim { return c },
+		},
+		{
+			name: "success - completed key",
+			prepare: func(s *MemoryStore) {
+				_, _ = s.Begin(context.Background(), claim)
+				_ = s.Complete(context.Background(), claim, Response{StatusCode: http.StatusCreated})
+			},
+			claim: func(c Claim) Claim { return c },
+			want:  &Response{StatusCode: http.StatusCreated},
+		},
+		{
+			name:    "success - stale lock is taken over",
+			prepare: func(s *MemoryStore) { _, _ = s.Begin(context.Background(), claim) },
+			claim: func(c Claim) Claim {
+				c.Now = c.Now.Add(2 * time.Minute)
+				c.StaleBefore = c.Now.Add(-time.Minute)
+				return c
+			},
+		},
+		{
+			name: "success - expired key is reused",
+			prepare: func(s *MemoryStore) {
+				_, _ = s.Begin(context.Background(), claim)
+				_ = s.Complete(context.Background(), claim, Response{StatusCode: http.StatusCreated})
+			},
+			claim: func(c Claim) Claim {
+				c.Now = c.Now.Add(2 * time.Hour)
+				c.Fingerprint = "b"
+				return c
+			},
+		},
+		{
+			name:    "fail - lock held",
+			prepare: func(s *MemoryStore) { _, _ = s.Begin(context.Background(), claim) },
+			claim:   func(c Claim) Claim { return c },
+			wantErr: ErrInProgress,
+		},
+		{
+			name:    "fail - different fingerprint",
+			prepare: func(s *MemoryStore) { _, _ = s.Begin(context.Background(), claim) },
+			claim: func(c Claim) Claim {
+				c.Fingerprint = "b"
+				return c
+			},
+			wantErr: ErrKeyReused,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			tt.prepare(store)
+
+			got, err := store.Begin(context.Background(), tt.claim(claim))
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestOperationsFromSpec(t *testing.T) {
+	spec, err := api.GetSwagger()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"POST /register"}, OperationsFromSpec(spec))
+}
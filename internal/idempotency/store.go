@@ -0,0 +1,230 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	db "scratch/internal/storage/database"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type entry struct {
+	fingerprint string
+	lockedAt    time.Time
+	expiresAt   time.Time
+	response    *Response
+}
+
+// MemoryStore keeps keys in the process, retries must reach the same instance.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*entry)}
+}
+
+func (m *MemoryStore) Begin(ctx context.Context, claim Claim) (*Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := claim.Operation + "|" + claim.Key
+	e, ok := m.entries[id]
+	switch {
+	case !ok || e.expiresAt.Before(claim.Now):
+	case e.fingerprint != claim.Fingerprint:
+		return nil, ErrKeyReused
+	case e.response != nil:
+		return e.response, nil
+	case e.lockedAt.After(claim.StaleBefore):
+		return nil, ErrInProgress
+	}
+
+	m.entries[id] = &entry{fingerprint: claim.Fingerprint, lockedAt: claim.Now, expiresAt: claim.ExpiresAt}
+	return nil, nil
+}
+
+func (m *MemoryStore) Complete(ctx context.Context, claim Claim, response Response) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[claim.Operation+"|"+claim.Key]
+	if !ok || !e.lockedBy(claim) {
+		return ErrLockLost
+	}
+	e.response = &response
+	return nil
+}
+
+func (m *MemoryStore) Release(ctx context.Context, claim Claim) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := claim.Operation + "|" + claim.Key
+	if e, ok := m.entries[id]; ok && e.lockedBy(claim) {
+		delete(m.entries, id)
+	}
+	return nil
+}
+
+// lockedBy reports whether the request of claim still holds the key.
+func (e *entry) lockedBy(claim Claim) bool {
+	return e.response == nil && e.lockedAt.Equal(claim.Now)
+}
+
+func (m *MemoryStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	for id, e := range m.entries {
+		if e.expiresAt.Before(now) {
+			delete(m.entries, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// beginAttempts bounds how often Begin retries when the row of a key changes
+// under it, e.g. when it is released by its request or purged.
+const beginAttempts = 3
+
+// PostgresStore keeps keys in scratch.idempotency_key, so retries may reach
+// any instance of the application.
+type PostgresStore struct {
+	queries *db.Queries
+}
+
+func NewPostgresStore(database *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{queries: db.New(database)}
+}
+
+func (p *PostgresStore) Begin(ctx context.Context, claim Claim) (*Response, error) {
+	for attempt := 0; attempt < beginAttempts; attempt++ {
+		// the primary key serializes concurrent duplicates, only one of them
+		// inserts the row and runs the request
+		n, err := p.queries.InsertIdempotencyKey(ctx, db.InsertIdempotencyKeyParams{
+			Key:         claim.Key,
+			Operation:   claim.Operation,
+			Fingerprint: claim.Fingerprint,
+			LockedAt:    claim.Now,
+			ExpiresAt:   claim.ExpiresAt,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("insert idempotency key: %w", err)
+		}
+		if n == 1 {
+			return nil, nil
+		}
+
+		row, err := p.queries.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{Key: claim.Key, Operation: claim.Operation})
+		if errors.Is(err, db.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get idempotency key: %w", err)
+		}
+
+		expired := row.ExpiresAt.Before(claim.Now)
+		switch {
+		case expired:
+		case row.Fingerprint != claim.Fingerprint:
+			return nil, ErrKeyReused
+		case row.StatusCode.Valid:
+			return decodeResponse(row)
+		case row.LockedAt.After(claim.StaleBefore):
+			return nil, ErrInProgress
+		}
+
+		n, err = p.queries.TakeOverIdempotencyKey(ctx, db.TakeOverIdempotencyKeyParams{
+			Key:         claim.Key,
+			Operation:   claim.Operation,
+			Fingerprint: claim.Fingerprint,
+			Now:         claim.Now,
+			ExpiresAt:   claim.ExpiresAt,
+			StaleBefore: claim.StaleBefore,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("take over idempotency key: %w", err)
+		}
+		if n == 1 {
+			return nil, nil
+		}
+	}
+	// somebody else keeps winning the key, most likely a concurrent duplicate
+	return nil, ErrInProgress
+}
+
+func (p *PostgresStore) Complete(ctx context.Context, claim Claim, response Response) error {
+	header, err := json.Marshal(response.Header)
+	if err != nil {
+		return fmt.Errorf("encode response header: %w", err)
+	}
+	n, err := p.queries.CompleteIdempotencyKey(ctx, db.CompleteIdempotencyKeyParams{
+		Key:            claim.Key,
+		Operation:      claim.Operation,
+		LockedAt:       claim.Now,
+		StatusCode:     sql.NullInt32{Int32: int32(response.StatusCode), Valid: true},
+		ResponseHeader: header,
+		ResponseBody:   response.Body,
+	})
+	if err != nil {
+		return fmt.Errorf("complete idempotency key: %w", err)
+	}
+	if n == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+func (p *PostgresStore) Release(ctx context.Context, claim Claim) error {
+	err := p.queries.DeleteIdempotencyKey(ctx, db.DeleteIdempotencyKeyParams{Key: claim.Key, Operation: claim.Operation, LockedAt: claim.Now})
+	if err != nil {
+		return fmt.Errorf("delete idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	n, err := p.queries.DeleteExpiredIdempotencyKeys(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("delete expired idempotency keys: %w", err)
+	}
+	return n, nil
+}
+
+func decodeResponse(row db.ScratchIdempotencyKey) (*Response, error) {
+	var header http.Header
+	if len(row.ResponseHeader) > 0 {
+		if err := json.Unmarshal(row.ResponseHeader, &header); err != nil {
+			return nil, fmt.Errorf("decode response header: %w", err)
+		}
+	}
+	return &Response{StatusCode: int(row.StatusCode.Int32), Header: header, Body: row.ResponseBody}, nil
+}
+
+// Cleanup deletes expired keys every interval until ctx is done.
+func Cleanup(ctx context.Context, store Store, interval time.Duration, log slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := store.DeleteExpired(ctx, now); err != nil {
+				log.Warn("delete expired idempotency keys", "err", err)
+			}
+		}
+	}
+}
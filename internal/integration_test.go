@@ -3,7 +3,6 @@ package internal
 import (
 	"context"
 	"database/sql"
-	"embed"
 	"fmt"
 	"log"
 	"log/slog"
@@ -11,17 +10,25 @@ import (
 	"net/http/httptest"
 	"os"
 	"scratch/api"
+	"scratch/internal/authorization/middlewares"
 	"scratch/internal/authorization/session"
+	"scratch/internal/i18n"
+	"scratch/internal/idempotency"
+	"scratch/internal/problem"
 	"scratch/internal/services"
 	storage "scratch/internal/storage/database"
+	"scratch/internal/storage/migrations"
+	"scratch/internal/validation"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/joho/godotenv"
 	"github.com/ory/dockertest/v3"
-	"github.com/pressly/goose/v3"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -30,12 +37,11 @@ var (
 	user      = "postgres"
 	password  = "postgres"
 	dbname    = "integration_tests"
-	db        *sql.DB
+	// db runs the migrations, everything else uses dbpool
+	db     *sql.DB
+	dbpool *pgxpool.Pool
 )
 
-//go:embed storage/migrations/*
-var embedMigrations embed.FS
-
 func connectPostgres(pool *dockertest.Pool) (*dockertest.Resource, error) {
 	resource, err := pool.Run("postgres", "13.8",
 		[]string{"POSTGRES_DB=integration_tests", "POSTGRES_PASSWORD=postgres"})
@@ -56,6 +62,11 @@ func connectPostgres(pool *dockertest.Pool) (*dockertest.Resource, error) {
 	}); err != nil {
 		return nil, fmt.Errorf("retry connection: %w", err)
 	}
+
+	dbpool, err = pgxpool.New(context.Background(), psqlInfo)
+	if err != nil {
+		return nil, fmt.Errorf("open pool: %w", err)
+	}
 	return resource, nil
 }
 
@@ -76,10 +87,13 @@ func TestMain(m *testing.M) {
 		log.Fatal(fmt.Sprintf("connect postgres: %v", err))
 	}
 
-	runUpMigrations()
+	if err := runUpMigrations(); err != nil {
+		log.Fatalf("run up migrations: %v", err)
+	}
 
 	code := m.Run()
 
+	dbpool.Close()
 	runDownMigrations()
 	if err = pool.Purge(resource); err != nil {
 		log.Fatalf("Could not purge resource: %s", err)
@@ -91,6 +105,9 @@ func TestMain(m *testing.M) {
 func testHandler(t *testing.T) http.Handler {
 	t.Helper()
 	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(i18n.Default().Middleware)
+	r.Use(middlewares.Device(false))
 
 	err := godotenv.Load(".env.test")
 	if err != nil {
@@ -99,15 +116,26 @@ func testHandler(t *testing.T) http.Handler {
 
 	s := session.NewJsonWebToken(session.Config{TokenSecret: []byte(os.Getenv("JWT_SECRET"))})
 
-	accountService := services.NewAccountService(storage.New(db), s, slog.Logger{})
+	logger := *slog.New(slog.NewTextHandler(os.Stderr, nil))
 
-	ah := NewAccountHandler(accountService, slog.Logger{})
+	accountService := services.NewAccountService(storage.New(dbpool), storage.NewTxManager(dbpool, storage.TxOptions{Isolation: pgx.Serializable, MaxRetries: 3}), s, nil, logger)
+
+	cookies := middlewares.CookieConfig{Name: "session", SameSite: http.SameSiteLaxMode, TTL: time.Hour}
+	ah := NewAccountHandler(accountService, cookies, logger)
+
+	spec, err := api.GetSwagger()
+	assert.NoError(t, err)
+	validator := validation.New(spec, validation.Options{ValidateResponses: true, FailOnInvalidResponse: true}, logger)
 
 	server := api.HandlerWithOptions(ah, api.ChiServerOptions{
 		BaseRouter: r,
 		Middlewares: []api.MiddlewareFunc{
+			idempotency.New(idempotency.NewPostgresStore(dbpool), idempotency.OperationsFromSpec(spec), idempotency.Options{TTL: time.Hour, LockTimeout: time.Minute}, logger).Middleware,
+			validator.Middleware,
+			middlewares.NewAuthenticator(s, accountService, cookies, logger).Middleware,
 			middleware.Logger,
 		},
+		ErrorHandlerFunc: problem.ErrorHandlerFunc,
 	})
 
 	return server
@@ -130,32 +158,9 @@ func initService(t *testing.T) *httptest.Server {
 }
 
 func runUpMigrations() error {
-	goose.SetBaseFS(embedMigrations)
-
-	if err := goose.SetDialect("pgx"); err != nil {
-		return fmt.Errorf("goose - set dialect: %w", err)
-	}
-
-	if err := goose.UpContext(context.Background(), db, "storage/migrations"); err != nil {
-		return fmt.Errorf("run up migrations: %w", err)
-	}
-
-	//message, err := storage.New(db).MigrationMessage(context.Background())
-
-	return nil
+	return migrations.Postgres.Up(context.Background(), db)
 }
 
 func runDownMigrations() error {
-
-	goose.SetBaseFS(embedMigrations)
-
-	if err := goose.SetDialect("postgres"); err != nil {
-		return fmt.Errorf("goose - set dialect: %w", err)
-	}
-
-	if err := goose.DownTo(db, "storage/migrations", 0); err != nil {
-		return fmt.Errorf("run up migrations: %w", err)
-	}
-
-	return nil
+	return migrations.Postgres.To(context.Background(), db, 0)
 }
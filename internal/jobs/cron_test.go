@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	// a wednesday
+	from := time.Date(2026, 10, 21, 10, 17, 30, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 10, 21, 10, 18, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 10, 21, 11, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 10, 21, 10, 30, 0, 0, time.UTC)},
+		{"5,20 9-17 * * *", time.Date(2026, 10, 21, 10, 20, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 10, 22, 0, 0, 0, 0, time.UTC)},
+		{"30 3 * * 7", time.Date(2026, 10, 25, 3, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// a restricted day of month or day of week matches either
+		{"0 12 1 * 5", time.Date(2026, 10, 23, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := ParseSchedule(tt.spec)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, s.Next(from))
+		})
+	}
+}
+
+func TestParseSchedule_Invalid(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr string
+	}{
+		{"* * * *", `invalid cron schedule "* * * *": want 5 fields, got 4`},
+		{"60 * * * *", `invalid cron schedule "60 * * * *": minute: "60" is out of range 0-59`},
+		{"* * 0 * *", `invalid cron schedule "* * 0 * *": day of month: "0" is out of range 1-31`},
+		{"*/0 * * * *", `invalid cron schedule "*/0 * * * *": minute: step "0" is not a positive number`},
+		{"* 5-1 * * *", `invalid cron schedule "* 5-1 * * *": hour: "5-1" is out of range 0-23`},
+		{"@often", `invalid cron schedule "@often": want 5 fields, got 1`},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := ParseSchedule(tt.spec)
+			assert.ErrorIs(t, err, InvalidScheduleErr)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+// Package jobs runs background work from a queue kept in postgres.
+//
+// Jobs are enqueued with the queries of a transaction, so they exist exactly
+// when the work that asked for them was committed. Workers claim due jobs
+// with FOR UPDATE SKIP LOCKED and run them at least once: a failed job is
+// retried with a growing delay until it runs out of attempts and is kept as
+// dead for inspection, a job whose worker disappeared is claimed again after
+// the lock timeout.
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	db "scratch/internal/storage/database"
+	"time"
+)
+
+// DefaultMaxAttempts is how often a job runs before it is dead, unless
+// enqueued with other options.
+const DefaultMaxAttempts = 5
+
+// Args is the payload of a kind of job, marshalled to JSON.
+type Args interface {
+	// Kind names the job and selects its handler.
+	Kind() string
+}
+
+type Options struct {
+	// UniqueKey drops the job when one of the same kind with the same key
+	// was already enqueued and not yet cleaned up.
+	UniqueKey string
+	// RunAt delays the job, zero runs it as soon as possible.
+	RunAt time.Time
+	// MaxAttempts overrides DefaultMaxAttempts.
+	MaxAttempts int
+}
+
+// Enqueue adds a job run with args, it returns false when the job was dropped
+// for its unique key.
+func Enqueue(ctx context.Context, q db.Querier, args Args, options Options) (bool, error) {
+	payload, err := json.Marshal(args)
+	if err != nil {
+		return false, fmt.Errorf("marshal %v job: %w", args.Kind(), err)
+	}
+	now := time.Now()
+	runAt := options.RunAt
+	if runAt.IsZero() {
+		runAt = now
+	}
+	maxAttempts := options.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+
+	n, err := q.EnqueueJob(ctx, db.EnqueueJobParams{
+		Kind:        args.Kind(),
+		Payload:     payload,
+		UniqueKey:   sql.NullString{String: options.UniqueKey, Valid: options.UniqueKey != ""},
+		MaxAttempts: int32(maxAttempts),
+		RunAt:       runAt,
+		Now:         now,
+	})
+	if err != nil {
+		return false, fmt.Errorf("enqueue %v job: %w", args.Kind(), err)
+	}
+	return n > 0, nil
+}
+
+type permanentError struct {
+	err error
+}
+
+func (p permanentError) Error() string { return p.err.Error() }
+func (p permanentError) Unwrap() error { return p.err }
+
+// Permanent marks the error of a handler as one retries can not fix, the job
+// is dead right away.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// IsPermanent tells whether err was marked by Permanent.
+func IsPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
@@ -0,0 +1,90 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	db "scratch/internal/storage/database"
+	mockdb "scratch/internal/storage/database/mock"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+type greet struct {
+	Name string `json:"name"`
+}
+
+func (greet) Kind() string { return "greet" }
+
+func TestEnqueue(t *testing.T) {
+	runAt := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		options Options
+		rows    int64
+		err     error
+		verify  func(t *testing.T, arg db.EnqueueJobParams)
+		want    bool
+		wantErr string
+	}{
+		{
+			name: "success - defaults",
+			rows: 1,
+			verify: func(t *testing.T, arg db.EnqueueJobParams) {
+				assert.Equal(t, "greet", arg.Kind)
+				assert.JSONEq(t, `{"name":"joe"}`, string(arg.Payload))
+				assert.Equal(t, sql.NullString{}, arg.UniqueKey)
+				assert.Equal(t, int32(DefaultMaxAttempts), arg.MaxAttempts)
+				assert.Equal(t, arg.Now, arg.RunAt)
+			},
+			want: true,
+		},
+		{
+			name:    "success - scheduled and unique",
+			options: Options{UniqueKey: "joe", RunAt: runAt, MaxAttempts: 2},
+			rows:    1,
+			verify: func(t *testing.T, arg db.EnqueueJobParams) {
+				assert.Equal(t, sql.NullString{String: "joe", Valid: true}, arg.UniqueKey)
+				assert.Equal(t, int32(2), arg.MaxAttempts)
+				assert.Equal(t, runAt, arg.RunAt)
+			},
+			want: true,
+		},
+		{
+			name:    "success - dropped for its unique key",
+			options: Options{UniqueKey: "joe"},
+			verify:  func(t *testing.T, arg db.EnqueueJobParams) {},
+		},
+		{
+			name:    "fail - insert",
+			err:     errors.New("connection reset"),
+			verify:  func(t *testing.T, arg db.EnqueueJobParams) {},
+			wantErr: "enqueue greet job: connection reset",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			queries := mockdb.NewMockQuerier(ctrl)
+			queries.EXPECT().EnqueueJob(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, arg db.EnqueueJobParams) (int64, error) {
+					tt.verify(t, arg)
+					return tt.rows, tt.err
+				})
+
+			got, err := Enqueue(context.Background(), queries, greet{Name: "joe"}, tt.options)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
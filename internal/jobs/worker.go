@@ -0,0 +1,270 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	db "scratch/internal/storage/database"
+	"sync"
+	"time"
+)
+
+const (
+	// retryDelay is the delay before the first retry, it doubles with every
+	// further attempt.
+	retryDelay = 10 * time.Second
+	// finishTimeout bounds recording the outcome of a job, which outlives
+	// the context of the job.
+	finishTimeout = 5 * time.Second
+	// cleanupInterval is how often done jobs past the retention are deleted.
+	cleanupInterval = time.Hour
+)
+
+type WorkerOptions struct {
+	// ID tells the workers apart in the locks of their jobs.
+	ID string
+	// Concurrency is the number of jobs run at the same time.
+	Concurrency int
+	// PollInterval is how long the worker waits for new jobs once none are due.
+	PollInterval time.Duration
+	// LockTimeout bounds the run of a job, after it the job is claimed again.
+	LockTimeout time.Duration
+	// MaxBackoff caps the doubling delay between the attempts of a job.
+	MaxBackoff time.Duration
+	// Retention is how long done jobs are kept, zero keeps them forever.
+	Retention time.Duration
+}
+
+type handler func(ctx context.Context, payload json.RawMessage) error
+
+type scheduled struct {
+	schedule Schedule
+	args     Args
+	next     time.Time
+}
+
+// Worker claims due jobs and runs them with the handler of their kind.
+type Worker struct {
+	db        db.Querier
+	options   WorkerOptions
+	log       slog.Logger
+	handlers  map[string]handler
+	schedules []*scheduled
+	now       func() time.Time
+}
+
+func NewWorker(q db.Querier, options WorkerOptions, log slog.Logger) *Worker {
+	return &Worker{db: q, options: options, log: log, handlers: make(map[string]handler), now: time.Now}
+}
+
+// Handle registers fn to run the jobs of the kind of T.
+func Handle[T Args](w *Worker, fn func(ctx context.Context, args T) error) {
+	var zero T
+	w.handlers[zero.Kind()] = func(ctx context.Context, payload json.RawMessage) error {
+		var args T
+		if err := json.Unmarshal(payload, &args); err != nil {
+			return Permanent(fmt.Errorf("unmarshal payload: %w", err))
+		}
+		return fn(ctx, args)
+	}
+}
+
+// Schedule enqueues a job with args whenever the cron spec fires. Every
+// worker with the schedule enqueues it, the unique key of the time it fired
+// runs it once.
+func (w *Worker) Schedule(spec string, args Args) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+	w.schedules = append(w.schedules, &scheduled{schedule: schedule, args: args})
+	return nil
+}
+
+// Run works on jobs until ctx is done, and then waits for the running ones.
+func (w *Worker) Run(ctx context.Context) {
+	slots := make(chan struct{}, w.options.Concurrency)
+	var running sync.WaitGroup
+	defer running.Wait()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	var cleaned time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		w.enqueueScheduled(ctx)
+
+		// only this loop takes slots, so they stay free until the jobs start
+		free := cap(slots) - len(slots)
+		var claimed int
+		if free > 0 {
+			jobs, err := w.claim(ctx, free)
+			if err != nil {
+				w.log.Warn("claim jobs", "err", err)
+			}
+			for _, job := range jobs {
+				slots <- struct{}{}
+				running.Add(1)
+				go func(job db.ClaimJobsRow) {
+					defer running.Done()
+					defer func() { <-slots }()
+					w.run(ctx, job)
+				}(job)
+			}
+			claimed = len(jobs)
+		}
+
+		if w.options.Retention > 0 && w.now().Sub(cleaned) >= cleanupInterval {
+			if _, err := w.Cleanup(ctx); err != nil {
+				w.log.Warn("delete done jobs", "err", err)
+			}
+			cleaned = w.now()
+		}
+
+		// more jobs may be due when every free slot got one
+		wait := w.options.PollInterval
+		if free > 0 && claimed == free {
+			wait = 0
+		}
+		timer.Reset(wait)
+	}
+}
+
+// RunOnce runs the jobs due now, up to the concurrency, and returns how many.
+func (w *Worker) RunOnce(ctx context.Context) (int, error) {
+	w.enqueueScheduled(ctx)
+	jobs, err := w.claim(ctx, w.options.Concurrency)
+	if err != nil {
+		return 0, err
+	}
+	var running sync.WaitGroup
+	for _, job := range jobs {
+		running.Add(1)
+		go func(job db.ClaimJobsRow) {
+			defer running.Done()
+			w.run(ctx, job)
+		}(job)
+	}
+	running.Wait()
+	return len(jobs), nil
+}
+
+// Cleanup deletes the jobs done longer than the retention ago.
+func (w *Worker) Cleanup(ctx context.Context) (int64, error) {
+	n, err := w.db.DeleteFinishedJobs(ctx, w.now().Add(-w.options.Retention))
+	if err != nil {
+		return 0, fmt.Errorf("delete finished jobs: %w", err)
+	}
+	return n, nil
+}
+
+func (w *Worker) claim(ctx context.Context, n int) ([]db.ClaimJobsRow, error) {
+	now := w.now()
+	staleBefore := now.Add(-w.options.LockTimeout)
+	// a job whose worker died on its last attempt is not claimed again
+	killed, err := w.db.KillStaleJobs(ctx, db.KillStaleJobsParams{
+		Now:         now,
+		LastError:   "lock timed out on the last attempt",
+		StaleBefore: staleBefore,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("kill stale jobs: %w", err)
+	}
+	if killed > 0 {
+		w.log.Error("stale jobs dead", "count", killed)
+	}
+	jobs, err := w.db.ClaimJobs(ctx, db.ClaimJobsParams{
+		Now:         now,
+		Worker:      w.options.ID,
+		StaleBefore: staleBefore,
+		BatchSize:   int32(n),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("claim jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+func (w *Worker) enqueueScheduled(ctx context.Context) {
+	now := w.now()
+	for _, s := range w.schedules {
+		if s.next.IsZero() {
+			s.next = s.schedule.Next(now)
+		}
+		if now.Before(s.next) {
+			continue
+		}
+		_, err := Enqueue(ctx, w.db, s.args, Options{
+			UniqueKey: "cron:" + s.next.UTC().Format(time.RFC3339),
+			RunAt:     s.next,
+		})
+		if err != nil {
+			w.log.Warn("enqueue scheduled job", "kind", s.args.Kind(), "err", err)
+			continue
+		}
+		s.next = s.schedule.Next(now)
+	}
+}
+
+// run runs the job and records the outcome: done, retried later or dead.
+func (w *Worker) run(ctx context.Context, job db.ClaimJobsRow) {
+	started := w.now()
+	err := w.call(ctx, job)
+
+	ctx, cancel := context.WithTimeout(context.Background(), finishTimeout)
+	defer cancel()
+	now := w.now()
+	log := w.log.With("job_id", job.ID, "kind", job.Kind, "attempt", job.Attempts)
+
+	switch {
+	case err == nil:
+		_, err = w.db.CompleteJob(ctx, db.CompleteJobParams{Now: now, ID: job.ID, Attempts: job.Attempts})
+		log.Debug("job done", "duration", now.Sub(started))
+	case IsPermanent(err) || job.Attempts >= job.MaxAttempts:
+		log.Error("job dead", "err", err)
+		_, err = w.db.KillJob(ctx, db.KillJobParams{Now: now, LastError: err.Error(), ID: job.ID, Attempts: job.Attempts})
+	default:
+		delay := w.backoff(job.Attempts)
+		log.Warn("job failed", "retry_in", delay, "err", err)
+		_, err = w.db.RetryJob(ctx, db.RetryJobParams{RunAt: now.Add(delay), LastError: err.Error(), ID: job.ID, Attempts: job.Attempts})
+	}
+	// the job runs again once its lock times out
+	if err != nil {
+		log.Warn("record job outcome", "err", err)
+	}
+}
+
+// call runs the handler of the job within the lock timeout.
+func (w *Worker) call(ctx context.Context, job db.ClaimJobsRow) (err error) {
+	h, ok := w.handlers[job.Kind]
+	if !ok {
+		return fmt.Errorf("no handler for %v jobs", job.Kind)
+	}
+	ctx, cancel := context.WithTimeout(ctx, w.options.LockTimeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return h(ctx, job.Payload)
+}
+
+// backoff is the delay before the next attempt after attempts failed ones.
+func (w *Worker) backoff(attempts int32) time.Duration {
+	delay := retryDelay
+	for i := int32(1); i < attempts && delay < w.options.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > w.options.MaxBackoff {
+		delay = w.options.MaxBackoff
+	}
+	return delay
+}
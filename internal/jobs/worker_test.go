@@ -0,0 +1,204 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	db "scratch/internal/storage/database"
+	mockdb "scratch/internal/storage/database/mock"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestWorker(queries db.Querier, now time.Time) *Worker {
+	w := NewWorker(queries, WorkerOptions{
+		ID:          "test",
+		Concurrency: 2,
+		LockTimeout: time.Minute,
+		MaxBackoff:  time.Hour,
+		Retention:   24 * time.Hour,
+	}, *slog.New(slog.NewTextHandler(io.Discard, nil)))
+	w.now = func() time.Time { return now }
+	return w
+}
+
+func TestWorker_RunOnce(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	job := db.ClaimJobsRow{ID: 7, Kind: "greet", Payload: []byte(`{"name":"joe"}`), Attempts: 2, MaxAttempts: 3}
+
+	tests := []struct {
+		name        string
+		job         db.ClaimJobsRow
+		handle      func(ctx context.Context, args greet) error
+		prepareMock func(queries *mockdb.MockQuerier)
+	}{
+		{
+			name: "success - done",
+			job:  job,
+			handle: func(ctx context.Context, args greet) error {
+				assert.Equal(t, "joe", args.Name)
+				return nil
+			},
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().CompleteJob(gomock.Any(), db.CompleteJobParams{Now: now, ID: 7, Attempts: 2}).Return(int64(1), nil)
+			},
+		},
+		{
+			name:   "failure - retried after a backoff",
+			job:    job,
+			handle: func(ctx context.Context, args greet) error { return errors.New("smtp down") },
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().RetryJob(gomock.Any(), db.RetryJobParams{
+					RunAt:     now.Add(20 * time.Second),
+					LastError: "smtp down",
+					ID:        7,
+					Attempts:  2,
+				}).Return(int64(1), nil)
+			},
+		},
+		{
+			name:   "failure - dead after the last attempt",
+			job:    db.ClaimJobsRow{ID: 7, Kind: "greet", Payload: []byte(`{}`), Attempts: 3, MaxAttempts: 3},
+			handle: func(ctx context.Context, args greet) error { return errors.New("smtp down") },
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().KillJob(gomock.Any(), db.KillJobParams{Now: now, LastError: "smtp down", ID: 7, Attempts: 3}).Return(int64(1), nil)
+			},
+		},
+		{
+			name:   "failure - permanent error is not retried",
+			job:    job,
+			handle: func(ctx context.Context, args greet) error { return Permanent(errors.New("no such user")) },
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().KillJob(gomock.Any(), db.KillJobParams{Now: now, LastError: "no such user", ID: 7, Attempts: 2}).Return(int64(1), nil)
+			},
+		},
+		{
+			name:   "failure - malformed payload",
+			job:    db.ClaimJobsRow{ID: 7, Kind: "greet", Payload: []byte(`[]`), Attempts: 1, MaxAttempts: 3},
+			handle: func(ctx context.Context, args greet) error { return nil },
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().KillJob(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, arg db.KillJobParams) (int64, error) {
+						assert.Contains(t, arg.LastError, "unmarshal payload")
+						return 1, nil
+					})
+			},
+		},
+		{
+			name:   "failure - panic",
+			job:    job,
+			handle: func(ctx context.Context, args greet) error { panic("boom") },
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().RetryJob(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, arg db.RetryJobParams) (int64, error) {
+						assert.Equal(t, "panic: boom", arg.LastError)
+						return 1, nil
+					})
+			},
+		},
+		{
+			name:   "failure - no handler",
+			job:    db.ClaimJobsRow{ID: 7, Kind: "unknown", Payload: []byte(`{}`), Attempts: 1, MaxAttempts: 3},
+			handle: func(ctx context.Context, args greet) error { return nil },
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().RetryJob(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, arg db.RetryJobParams) (int64, error) {
+						assert.Equal(t, "no handler for unknown jobs", arg.LastError)
+						assert.Equal(t, now.Add(10*time.Second), arg.RunAt)
+						return 1, nil
+					})
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			queries := mockdb.NewMockQuerier(ctrl)
+			queries.EXPECT().KillStaleJobs(gomock.Any(), db.KillStaleJobsParams{
+				Now:         now,
+				LastError:   "lock timed out on the last attempt",
+				StaleBefore: now.Add(-time.Minute),
+			}).Return(int64(0), nil)
+			queries.EXPECT().ClaimJobs(gomock.Any(), db.ClaimJobsParams{
+				Now:         now,
+				Worker:      "test",
+				StaleBefore: now.Add(-time.Minute),
+				BatchSize:   2,
+			}).Return([]db.ClaimJobsRow{tt.job}, nil)
+			tt.prepareMock(queries)
+
+			w := newTestWorker(queries, now)
+			Handle(w, tt.handle)
+
+			n, err := w.RunOnce(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, 1, n)
+		})
+	}
+}
+
+func TestWorker_RunOnce_KillStaleFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	queries := mockdb.NewMockQuerier(ctrl)
+	queries.EXPECT().KillStaleJobs(gomock.Any(), gomock.Any()).Return(int64(0), errors.New("connection refused"))
+
+	_, err := newTestWorker(queries, time.Now()).RunOnce(context.Background())
+	assert.ErrorContains(t, err, "kill stale jobs")
+}
+
+func TestWorker_Schedule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC)
+	queries := mockdb.NewMockQuerier(ctrl)
+	queries.EXPECT().KillStaleJobs(gomock.Any(), gomock.Any()).Return(int64(0), nil).Times(2)
+	queries.EXPECT().ClaimJobs(gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+	queries.EXPECT().EnqueueJob(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, arg db.EnqueueJobParams) (int64, error) {
+			assert.Equal(t, "greet", arg.Kind)
+			assert.Equal(t, "cron:2026-10-19T13:00:00Z", arg.UniqueKey.String, "workers enqueue the same run once")
+			assert.Equal(t, time.Date(2026, 10, 19, 13, 0, 0, 0, time.UTC), arg.RunAt)
+			return 1, nil
+		})
+
+	w := newTestWorker(queries, now)
+	w.now = func() time.Time { return now }
+	assert.Error(t, w.Schedule("@sometimes", greet{}))
+	require.NoError(t, w.Schedule("@hourly", greet{}))
+
+	// not due yet
+	_, err := w.RunOnce(context.Background())
+	require.NoError(t, err)
+
+	now = now.Add(30 * time.Minute)
+	_, err = w.RunOnce(context.Background())
+	require.NoError(t, err)
+}
+
+func TestWorker_backoff(t *testing.T) {
+	w := NewWorker(nil, WorkerOptions{MaxBackoff: time.Minute}, slog.Logger{})
+	tests := []struct {
+		attempts int32
+		want     time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{4, time.Minute},
+		{1000, time.Minute},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, w.backoff(tt.attempts), "after %d attempts", tt.attempts)
+	}
+}
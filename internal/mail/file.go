@@ -0,0 +1,71 @@
+package mail
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileSender drops every message as an .eml file into a directory, where
+// a mail client or a test can open it.
+type FileSender struct {
+	dir  string
+	from string
+}
+
+func NewFileSender(dir, from string) *FileSender {
+	return &FileSender{dir: dir, from: from}
+}
+
+// Send writes the message to a file named after its ID, a message whose file
+// exists is not written again.
+func (f *FileSender) Send(ctx context.Context, m Message) error {
+	now := time.Now()
+	body, err := m.Bytes(f.from, now)
+	if err != nil {
+		return err
+	}
+	name, err := fileName(m, now)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(f.dir, 0o750); err != nil {
+		return fmt.Errorf("create mail directory: %w", err)
+	}
+
+	// a reader never sees a partial file: it is written aside and linked in
+	tmp, err := os.CreateTemp(f.dir, ".mail-*")
+	if err != nil {
+		return fmt.Errorf("create mail file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write mail file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write mail file: %w", err)
+	}
+	if err := os.Link(tmp.Name(), filepath.Join(f.dir, name)); err != nil && !errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("link mail file: %w", err)
+	}
+	return nil
+}
+
+func fileName(m Message, now time.Time) (string, error) {
+	if m.ID != "" {
+		return url.PathEscape(m.ID) + ".eml", nil
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("generate mail file name: %w", err)
+	}
+	return now.UTC().Format("20060102T150405.000000000") + "-" + hex.EncodeToString(suffix) + ".eml", nil
+}
@@ -0,0 +1,34 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSender_Send(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	sender := NewFileSender(dir, "no-reply@scratch.dev")
+	ctx := context.Background()
+
+	require.NoError(t, sender.Send(ctx, Message{ID: "welcome/1", To: "joedoe@gmail.com", Subject: "Hello", Text: "first"}))
+	require.NoError(t, sender.Send(ctx, Message{ID: "welcome/1", To: "joedoe@gmail.com", Subject: "Hello", Text: "second"}))
+	require.NoError(t, sender.Send(ctx, Message{To: "joedoe@gmail.com", Subject: "No id"}))
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 2, "no temporary files are left behind")
+
+	body, err := os.ReadFile(filepath.Join(dir, "welcome%2F1.eml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "Message-ID: <welcome/1@scratch.dev>")
+	assert.Contains(t, string(body), "first", "a message is written once")
+	assert.NotContains(t, string(body), "second")
+
+	err = sender.Send(ctx, Message{ID: "x", To: "joedoe@gmail.com\r\nBcc: mallory@gmail.com"})
+	assert.ErrorIs(t, err, InvalidHeaderErr)
+}
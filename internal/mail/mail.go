@@ -0,0 +1,226 @@
+// Package mail sends the emails of the application to users.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var InvalidHeaderErr = errors.New("line break in mail header")
+
+// Message is an email to one recipient, in plain text and optionally html.
+type Message struct {
+	// ID identifies the message across retries, senders wrapped by Once send
+	// a message with the same ID once. Empty IDs are never deduplicated.
+	ID      string
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender delivers messages.
+type Sender interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// LogSender only logs the messages, for development.
+type LogSender struct {
+	log slog.Logger
+}
+
+func NewLogSender(log slog.Logger) *LogSender {
+	return &LogSender{log: log}
+}
+
+func (l *LogSender) Send(ctx context.Context, m Message) error {
+	l.log.Info("mail", "id", m.ID, "to", m.To, "subject", m.Subject, "text", m.Text)
+	return nil
+}
+
+// SMTPConfig describes the SMTP server messages are relayed through.
+type SMTPConfig struct {
+	Host string
+	Port int
+	// Username and Password authenticate with PLAIN auth when set, which
+	// net/smtp only allows over TLS or to localhost.
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPSender relays messages through an SMTP server.
+type SMTPSender struct {
+	config SMTPConfig
+}
+
+func NewSMTPSender(config SMTPConfig) *SMTPSender {
+	return &SMTPSender{config: config}
+}
+
+func (s *SMTPSender) Send(ctx context.Context, m Message) error {
+	body, err := m.Bytes(s.config.From, time.Now())
+	if err != nil {
+		return err
+	}
+	// the envelope takes the bare address of a "Name <address>" sender
+	from, err := netmail.ParseAddress(s.config.From)
+	if err != nil {
+		return fmt.Errorf("parse sender: %w", err)
+	}
+
+	// smtp.SendMail knows no deadline, a stuck server would hold the job
+	// sending the message forever
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("dial smtp server: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return fmt.Errorf("set deadline: %w", err)
+		}
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	if err := s.send(conn, from.Address, m.To, body); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("send mail: %w", ctx.Err())
+		}
+		return fmt.Errorf("send mail: %w", err)
+	}
+	return nil
+}
+
+// send is smtp.SendMail over conn.
+func (s *SMTPSender) send(conn net.Conn, from, to string, body []byte) error {
+	c, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.config.Host}); err != nil {
+			return err
+		}
+	}
+	if s.config.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("smtp server does not support AUTH")
+		}
+		if err := c.Auth(smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// Bytes renders m as an RFC 5322 message sent from from at date.
+func (m Message) Bytes(from string, date time.Time) ([]byte, error) {
+	for _, header := range []string{from, m.ID, m.To, m.Subject} {
+		if strings.ContainsAny(header, "\r\n") {
+			return nil, InvalidHeaderErr
+		}
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %v\r\n", from)
+	fmt.Fprintf(&b, "To: %v\r\n", m.To)
+	fmt.Fprintf(&b, "Subject: %v\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "Date: %v\r\n", date.Format(time.RFC1123Z))
+	if m.ID != "" {
+		fmt.Fprintf(&b, "Message-ID: %v\r\n", messageID(m.ID, from))
+	}
+	b.WriteString("MIME-Version: 1.0\r\n")
+	if m.HTML == "" {
+		b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+		b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+		b.WriteString("\r\n")
+		b.WriteString(crlf(m.Text))
+		return b.Bytes(), nil
+	}
+
+	parts := multipart.NewWriter(&b)
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%v\r\n", parts.Boundary())
+	b.WriteString("\r\n")
+	// the plain text goes first, clients show the last part they understand
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create %v part: %w", part.contentType, err)
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, fmt.Errorf("encode %v part: %w", part.contentType, err)
+		}
+		if err := qp.Close(); err != nil {
+			return nil, fmt.Errorf("encode %v part: %w", part.contentType, err)
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, fmt.Errorf("close multipart: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+func crlf(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\n", "\r\n")
+}
+
+// messageID turns the ID of a message into a Message-ID header in the domain
+// of the sender.
+func messageID(id, from string) string {
+	domain := "localhost"
+	if address, err := netmail.ParseAddress(from); err == nil {
+		if i := strings.LastIndexByte(address.Address, '@'); i >= 0 {
+			domain = address.Address[i+1:]
+		}
+	}
+	local := strings.Map(func(r rune) rune {
+		if r < 128 && (unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("!#$%&'*+-/=?^_`{|}~.", r)) {
+			return r
+		}
+		return '-'
+	}, id)
+	return "<" + local + "@" + domain + ">"
+}
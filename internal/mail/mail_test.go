@@ -0,0 +1,96 @@
+package mail
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessage_Bytes(t *testing.T) {
+	date := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		message Message
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "success - ascii subject",
+			message: Message{To: "joedoe@gmail.com", Subject: "Hello", Text: "first\nsecond"},
+			want: "From: no-reply@scratch.dev\r\n" +
+				"To: joedoe@gmail.com\r\n" +
+				"Subject: Hello\r\n" +
+				"Date: Mon, 19 Oct 2026 12:00:00 +0000\r\n" +
+				"MIME-Version: 1.0\r\n" +
+				"Content-Type: text/plain; charset=utf-8\r\n" +
+				"Content-Transfer-Encoding: 8bit\r\n" +
+				"\r\n" +
+				"first\r\nsecond",
+		},
+		{
+			name:    "success - encoded subject",
+			message: Message{To: "joedoe@gmail.com", Subject: "Logowanie z nowego urządzenia"},
+			want:    "Subject: =?utf-8?q?Logowanie_z_nowego_urz=C4=85dzenia?=\r\n",
+		},
+		{
+			name:    "success - message id in the domain of the sender",
+			message: Message{ID: "welcome/12", To: "joedoe@gmail.com", Subject: "Hello"},
+			want:    "Message-ID: <welcome/12@scratch.dev>\r\n",
+		},
+		{
+			name:    "success - html alternative",
+			message: Message{To: "joedoe@gmail.com", Subject: "Hello", Text: "Hi Joe", HTML: "<p>Hi Joe</p>"},
+			want: "Content-Type: text/html; charset=utf-8\r\n" +
+				"\r\n" +
+				"<p>Hi Joe</p>\r\n",
+		},
+		{
+			name:    "fail - header injection",
+			message: Message{To: "joedoe@gmail.com\r\nBcc: mallory@gmail.com", Subject: "Hello"},
+			wantErr: InvalidHeaderErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.message.Bytes("no-reply@scratch.dev", date)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, string(got), tt.want)
+		})
+	}
+}
+
+func TestSMTPSender_Send_Deadline(t *testing.T) {
+	// a server accepting connections without ever greeting
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	portNumber, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	sender := NewSMTPSender(SMTPConfig{Host: host, Port: portNumber, From: "noreply@example.com"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = sender.Send(ctx, Message{To: "norbi@example.com", Subject: "Welcome", Text: "Hello"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
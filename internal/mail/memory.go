@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"context"
+	"sync"
+)
+
+// MemorySender keeps the messages in the process, for tests and the preview.
+type MemorySender struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+// Send keeps the message, unless one with the same ID is kept already.
+func (s *MemorySender) Send(ctx context.Context, m Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if m.ID != "" {
+		for _, sent := range s.messages {
+			if sent.ID == m.ID {
+				return nil
+			}
+		}
+	}
+	s.messages = append(s.messages, m)
+	return nil
+}
+
+// Messages returns the kept messages, the oldest first.
+func (s *MemorySender) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Message(nil), s.messages...)
+}
@@ -0,0 +1,141 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	db "scratch/internal/storage/database"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SentStore remembers the IDs of sent messages.
+type SentStore interface {
+	// Claim records id as sent at now, it returns false when id already was.
+	Claim(ctx context.Context, id string, now time.Time) (bool, error)
+	// Release forgets id, so the message can be sent again.
+	Release(ctx context.Context, id string) error
+	// DeleteBefore forgets the ids sent before before.
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type onceSender struct {
+	sender Sender
+	store  SentStore
+}
+
+// Once sends every message ID with sender once. The ID is claimed before the
+// message is sent and released when sending fails, so a crash in between
+// loses the message rather than sending it twice.
+func Once(sender Sender, store SentStore) Sender {
+	return &onceSender{sender: sender, store: store}
+}
+
+func (o *onceSender) Send(ctx context.Context, m Message) error {
+	if m.ID == "" {
+		return o.sender.Send(ctx, m)
+	}
+	claimed, err := o.store.Claim(ctx, m.ID, time.Now())
+	if err != nil {
+		return fmt.Errorf("claim message %v: %w", m.ID, err)
+	}
+	if !claimed {
+		return nil
+	}
+	if err := o.sender.Send(ctx, m); err != nil {
+		if releaseErr := o.store.Release(ctx, m.ID); releaseErr != nil {
+			return fmt.Errorf("%w (release message %v: %v)", err, m.ID, releaseErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// MemorySentStore remembers the ids in the process.
+type MemorySentStore struct {
+	mu   sync.Mutex
+	sent map[string]time.Time
+}
+
+func NewMemorySentStore() *MemorySentStore {
+	return &MemorySentStore{sent: make(map[string]time.Time)}
+}
+
+func (s *MemorySentStore) Claim(ctx context.Context, id string, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sent[id]; ok {
+		return false, nil
+	}
+	s.sent[id] = now
+	return true, nil
+}
+
+func (s *MemorySentStore) Release(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sent, id)
+	return nil
+}
+
+func (s *MemorySentStore) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int64
+	for id, sentAt := range s.sent {
+		if sentAt.Before(before) {
+			delete(s.sent, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// PostgresSentStore remembers the ids in scratch.mail_sent, shared by every
+// instance.
+type PostgresSentStore struct {
+	queries db.Querier
+}
+
+func NewPostgresSentStore(database *pgxpool.Pool) *PostgresSentStore {
+	return &PostgresSentStore{queries: db.New(database)}
+}
+
+func (p *PostgresSentStore) Claim(ctx context.Context, id string, now time.Time) (bool, error) {
+	n, err := p.queries.ClaimMailMessage(ctx, db.ClaimMailMessageParams{MessageID: id, Now: now})
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (p *PostgresSentStore) Release(ctx context.Context, id string) error {
+	return p.queries.ReleaseMailMessage(ctx, id)
+}
+
+func (p *PostgresSentStore) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	return p.queries.DeleteSentMailBefore(ctx, before)
+}
+
+// Cleanup forgets the ids sent longer than retention ago every interval,
+// until ctx is done.
+func Cleanup(ctx context.Context, store SentStore, interval, retention time.Duration, log slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := store.DeleteBefore(ctx, now.Add(-retention)); err != nil {
+				log.Warn("delete sent mail ids", "err", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingSender struct{ err error }
+
+func (f failingSender) Send(ctx context.Context, m Message) error { return f.err }
+
+func TestOnce(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemorySentStore()
+
+	failure := errors.New("connection refused")
+	err := Once(failingSender{err: failure}, store).Send(ctx, Message{ID: "welcome/1"})
+	assert.ErrorIs(t, err, failure)
+
+	outbox := NewMemorySender()
+	sender := Once(outbox, store)
+	require.NoError(t, sender.Send(ctx, Message{ID: "welcome/1", Subject: "first"}), "a failed message is sent again")
+	require.NoError(t, sender.Send(ctx, Message{ID: "welcome/1", Subject: "second"}))
+	require.NoError(t, sender.Send(ctx, Message{Subject: "no id"}))
+	require.NoError(t, sender.Send(ctx, Message{Subject: "no id"}))
+
+	var subjects []string
+	for _, m := range outbox.Messages() {
+		subjects = append(subjects, m.Subject)
+	}
+	assert.Equal(t, []string{"first", "no id", "no id"}, subjects)
+}
+
+func TestMemorySentStore_DeleteBefore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemorySentStore()
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	for i, id := range []string{"old", "new"} {
+		claimed, err := store.Claim(ctx, id, now.Add(time.Duration(i)*time.Hour))
+		require.NoError(t, err)
+		assert.True(t, claimed)
+	}
+
+	n, err := store.DeleteBefore(ctx, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	claimed, err := store.Claim(ctx, "old", now)
+	require.NoError(t, err)
+	assert.True(t, claimed, "a forgotten id is claimed again")
+	claimed, err = store.Claim(ctx, "new", now)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+}
+
+func TestMemorySender_Send(t *testing.T) {
+	outbox := NewMemorySender()
+	require.NoError(t, outbox.Send(context.Background(), Message{ID: "a", Subject: "first"}))
+	require.NoError(t, outbox.Send(context.Background(), Message{ID: "a", Subject: "second"}))
+
+	messages := outbox.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "first", messages[0].Subject)
+}
@@ -0,0 +1,103 @@
+package mail
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"net/http"
+	"scratch/internal/i18n"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var previewIndex = htmltemplate.Must(htmltemplate.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Mail preview</title></head>
+<body style="font-family:system-ui,sans-serif;">
+<h1>Templates</h1>
+<ul>
+{{range $name := .Templates}}<li>{{$name}}:
+  {{range $.Locales}}<a href="templates/{{$name}}?locale={{.}}">{{.}}</a> (<a href="templates/{{$name}}?locale={{.}}&amp;format=text">text</a>) {{end}}
+</li>
+{{end}}</ul>
+{{with .Outbox}}<h1>Outbox</h1>
+<ol>
+{{range $i, $m := .}}<li><a href="outbox/{{$i}}">{{$m.Subject}}</a> to {{$m.To}}{{with $m.ID}} ({{.}}){{end}}</li>
+{{end}}</ol>
+{{end}}
+</body>
+</html>
+`))
+
+type preview struct {
+	templates *Templates
+	outbox    *MemorySender
+}
+
+// Preview serves the templates rendered with sample data, and the messages
+// kept by outbox unless it is nil. It is meant for development only.
+func Preview(templates *Templates, outbox *MemorySender) http.Handler {
+	p := &preview{templates: templates, outbox: outbox}
+	r := chi.NewRouter()
+	r.Get("/", p.index)
+	r.Get("/templates/{name}", p.template)
+	r.Get("/outbox/{index}", p.message)
+	return r
+}
+
+func (p *preview) index(w http.ResponseWriter, r *http.Request) {
+	data := struct {
+		Templates []string
+		Locales   []string
+		Outbox    []Message
+	}{Templates: p.templates.Names(), Locales: i18n.Default().Locales()}
+	if p.outbox != nil {
+		data.Outbox = p.outbox.Messages()
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := previewIndex.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (p *preview) template(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	data, ok := samples[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	m, err := p.templates.Render(name, r.URL.Query().Get("locale"), data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeMessage(w, r, m)
+}
+
+func (p *preview) message(w http.ResponseWriter, r *http.Request) {
+	i, err := strconv.Atoi(chi.URLParam(r, "index"))
+	if p.outbox == nil || err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	messages := p.outbox.Messages()
+	if i < 0 || i >= len(messages) {
+		http.NotFound(w, r)
+		return
+	}
+	writeMessage(w, r, messages[i])
+}
+
+// writeMessage writes the html of m, or its text when asked for with
+// format=text or when it has no html.
+func writeMessage(w http.ResponseWriter, r *http.Request, m Message) {
+	w.Header().Set("Cache-Control", "no-store")
+	if m.HTML == "" || r.URL.Query().Get("format") == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintf(w, "Subject: %v\n\n%v", m.Subject, m.Text)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(m.HTML))
+}
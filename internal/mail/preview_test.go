@@ -0,0 +1,81 @@
+package mail
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreview(t *testing.T) {
+	outbox := NewMemorySender()
+	require.NoError(t, outbox.Send(context.Background(), Message{To: "joedoe@gmail.com", Subject: "Sent", Text: "plain", HTML: "<p>rich</p>"}))
+	handler := Preview(Default(), outbox)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantType   string
+		wantBody   string
+	}{
+		{
+			name:       "success - index lists templates and outbox",
+			path:       "/",
+			wantStatus: http.StatusOK,
+			wantType:   "text/html; charset=utf-8",
+			wantBody:   `<a href="templates/welcome?locale=pl">pl</a>`,
+		},
+		{
+			name:       "success - html template",
+			path:       "/templates/new-device?locale=en",
+			wantStatus: http.StatusOK,
+			wantType:   "text/html; charset=utf-8",
+			wantBody:   "192.0.2.1",
+		},
+		{
+			name:       "success - text template",
+			path:       "/templates/welcome?locale=pl&format=text",
+			wantStatus: http.StatusOK,
+			wantType:   "text/plain; charset=utf-8",
+			wantBody:   "Subject: Witaj w Scratch",
+		},
+		{
+			name:       "success - sent message",
+			path:       "/outbox/0",
+			wantStatus: http.StatusOK,
+			wantType:   "text/html; charset=utf-8",
+			wantBody:   "<p>rich</p>",
+		},
+		{
+			name:       "fail - unknown template",
+			path:       "/templates/invoice",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "fail - message out of range",
+			path:       "/outbox/1",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			res := rec.Result()
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tt.wantType, res.Header.Get("Content-Type"))
+			assert.Contains(t, string(body), tt.wantBody)
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"scratch/internal/i18n"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+)
+
+var UnknownTemplateErr = errors.New("unknown mail template")
+
+//go:embed templates/*
+var templateFiles embed.FS
+
+// Welcome is the data of the welcome template.
+type Welcome struct {
+	Name string
+}
+
+// NewDevice is the data of the new-device template.
+type NewDevice struct {
+	Name   string
+	Device string
+	IP     string
+	Time   string
+}
+
+// Invitation is the data of the invitation template, Name is the address
+// invited. Link registers with the invitation, it is left out when empty.
+type Invitation struct {
+	Name         string
+	Inviter      string
+	Organization string
+	Role         string
+	Code         string
+	Link         string
+	Expires      string
+}
+
+// samples render every template in the preview.
+var samples = map[string]any{
+	"welcome":    Welcome{Name: "Joe"},
+	"new-device": NewDevice{Name: "Joe", Device: "Firefox on Linux", IP: "192.0.2.1", Time: "2026-10-19 12:00 UTC"},
+	"invitation": Invitation{
+		Name: "joe@example.com", Inviter: "Ann", Organization: "Acme", Role: "member",
+		Code:    "4Xv0dQ2yJ7kLmN9pRsT1uVwXyZ3aBcDeFgHiJkLmNoP",
+		Link:    "https://chat.example.com/web/signup?invite=4Xv0dQ2yJ7kLmN9pRsT1uVwXyZ3aBcDeFgHiJkLmNoP",
+		Expires: "2026-10-26 12:00 UTC",
+	},
+}
+
+// Templates renders messages from a text template, and optionally an html
+// one, of the same name. Each defines the "subject" and the "content" the
+// "layout" of its kind wraps.
+type Templates struct {
+	text map[string]*texttemplate.Template
+	html map[string]*htmltemplate.Template
+}
+
+var defaultTemplates = mustParse(templateFiles)
+
+// Default returns the templates embedded in the binary.
+func Default() *Templates {
+	return defaultTemplates
+}
+
+func mustParse(fsys fs.FS) *Templates {
+	t, err := ParseTemplates(fsys)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// ParseTemplates parses templates/*.txt and templates/*.html of fsys.
+func ParseTemplates(fsys fs.FS) (*Templates, error) {
+	t := &Templates{text: make(map[string]*texttemplate.Template), html: make(map[string]*htmltemplate.Template)}
+	// the texts are rendered in the locale of each message, these functions
+	// only let the templates parse
+	funcs := i18n.Default().Funcs(i18n.DefaultLocale)
+
+	files, err := fs.Glob(fsys, "templates/*.txt")
+	if err != nil {
+		return nil, fmt.Errorf("list templates: %w", err)
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(path.Base(file), ".txt")
+		if name == "layout" {
+			continue
+		}
+		text, err := texttemplate.New(name).Option("missingkey=error").Funcs(funcs).
+			ParseFS(fsys, "templates/layout.txt", file)
+		if err != nil {
+			return nil, fmt.Errorf("parse template %v: %w", file, err)
+		}
+		t.text[name] = text
+
+		htmlFile := "templates/" + name + ".html"
+		if _, err := fs.Stat(fsys, htmlFile); err != nil {
+			continue
+		}
+		html, err := htmltemplate.New(name).Option("missingkey=error").Funcs(funcs).
+			ParseFS(fsys, "templates/layout.html", htmlFile)
+		if err != nil {
+			return nil, fmt.Errorf("parse template %v: %w", htmlFile, err)
+		}
+		t.html[name] = html
+	}
+	return t, nil
+}
+
+// Names lists the templates in alphabetical order.
+func (t *Templates) Names() []string {
+	names := make([]string, 0, len(t.text))
+	for name := range t.text {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render returns the message of template name in the supported locale best
+// matching locale, without recipient.
+func (t *Templates) Render(name, locale string, data any) (Message, error) {
+	text, ok := t.text[name]
+	if !ok {
+		return Message{}, fmt.Errorf("%w: %v", UnknownTemplateErr, name)
+	}
+	funcs := i18n.Default().Funcs(i18n.Default().Match(locale))
+
+	text, err := text.Clone()
+	if err != nil {
+		return Message{}, fmt.Errorf("clone template %v: %w", name, err)
+	}
+	text.Funcs(funcs)
+	var subject, body bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("render subject of %v: %w", name, err)
+	}
+	if err := text.ExecuteTemplate(&body, "layout", data); err != nil {
+		return Message{}, fmt.Errorf("render template %v: %w", name, err)
+	}
+	m := Message{Subject: strings.TrimSpace(subject.String()), Text: body.String()}
+
+	if html, ok := t.html[name]; ok {
+		html, err := html.Clone()
+		if err != nil {
+			return Message{}, fmt.Errorf("clone html template %v: %w", name, err)
+		}
+		var body bytes.Buffer
+		if err := html.Funcs(funcs).ExecuteTemplate(&body, "layout", data); err != nil {
+			return Message{}, fmt.Errorf("render html template %v: %w", name, err)
+		}
+		m.HTML = body.String()
+	}
+	return m, nil
+}
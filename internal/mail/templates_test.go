@@ -0,0 +1,122 @@
+package mail
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplates_Render(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		locale   string
+		data     any
+		verify   func(t *testing.T, m Message)
+		wantErr  error
+	}{
+		{
+			name:     "success - welcome in english",
+			template: "welcome",
+			locale:   "en",
+			data:     Welcome{Name: "Joe"},
+			verify: func(t *testing.T, m Message) {
+				assert.Equal(t, "Welcome to Scratch", m.Subject)
+				assert.Contains(t, m.Text, "Hi Joe,")
+				assert.Contains(t, m.HTML, "<html lang=\"en\">")
+				assert.Contains(t, m.HTML, "Hi Joe,")
+			},
+		},
+		{
+			name:     "success - new device in polish escapes html",
+			template: "new-device",
+			locale:   "pl-PL",
+			data:     NewDevice{Name: "<b>Joe</b>", Device: "curl", IP: "192.0.2.1", Time: "2026-10-19 12:00 UTC"},
+			verify: func(t *testing.T, m Message) {
+				assert.Equal(t, "Nowe logowanie na Twoje konto", m.Subject)
+				assert.Contains(t, m.Text, "Cześć <b>Joe</b>,")
+				assert.Contains(t, m.Text, "192.0.2.1")
+				assert.Contains(t, m.HTML, "&lt;b&gt;Joe&lt;/b&gt;")
+			},
+		},
+		{
+			name:     "success - invitation replaces the footer",
+			template: "invitation",
+			locale:   "en",
+			data:     Invitation{Name: "joe@example.com", Inviter: "Ann", Organization: "Acme", Role: "admin", Code: "code", Expires: "2026-10-26 12:00 UTC"},
+			verify: func(t *testing.T, m Message) {
+				assert.Equal(t, "Join Acme on Scratch", m.Subject)
+				assert.Contains(t, m.Text, "Ann invited you to join Acme on Scratch as admin.")
+				assert.Contains(t, m.Text, "\n  code\n")
+				assert.NotContains(t, m.Text, "Create your account", "no link")
+				assert.Contains(t, m.Text, "someone invited this address")
+				assert.NotContains(t, m.Text, "because you have an account")
+				assert.NotContains(t, m.HTML, "because you have an account")
+			},
+		},
+		{
+			name:     "success - invitation with a link",
+			template: "invitation",
+			locale:   "pl",
+			data: Invitation{
+				Name: "joe@example.com", Inviter: "Ann", Organization: "Acme", Role: "member", Code: "code",
+				Link: "https://chat.example.com/web/signup?invite=code", Expires: "2026-10-26 12:00 UTC",
+			},
+			verify: func(t *testing.T, m Message) {
+				assert.Contains(t, m.Text, "Załóż je z zaproszenia:\n\n  https://chat.example.com/web/signup?invite=code\n")
+				assert.Contains(t, m.HTML, `<a href="https://chat.example.com/web/signup?invite=code">`)
+				assert.Contains(t, m.Text, "\n  code\n")
+			},
+		},
+		{
+			name:     "fail - unknown template",
+			template: "invoice",
+			locale:   "en",
+			wantErr:  UnknownTemplateErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Default().Render(tt.template, tt.locale, tt.data)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			tt.verify(t, m)
+		})
+	}
+}
+
+func TestTemplates_Samples(t *testing.T) {
+	assert.ElementsMatch(t, Default().Names(), keys(samples), "every template has a sample for the preview")
+	for _, name := range Default().Names() {
+		_, err := Default().Render(name, "en", samples[name])
+		assert.NoError(t, err, name)
+	}
+}
+
+func TestParseTemplates_TextOnly(t *testing.T) {
+	templates, err := ParseTemplates(fstest.MapFS{
+		"templates/layout.txt":   {Data: []byte(`{{define "layout"}}{{template "content" .}}{{end}}`)},
+		"templates/reminder.txt": {Data: []byte(`{{define "subject"}}Reminder{{end}}{{define "content"}}{{.Missing}}{{end}}`)},
+	})
+	require.NoError(t, err)
+
+	_, err = templates.Render("reminder", "en", map[string]string{})
+	assert.Error(t, err, "missing data is an error")
+
+	m, err := templates.Render("reminder", "en", map[string]string{"Missing": "found"})
+	require.NoError(t, err)
+	assert.Equal(t, Message{Subject: "Reminder", Text: "found"}, m)
+}
+
+func keys(m map[string]any) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
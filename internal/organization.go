@@ -0,0 +1,198 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"scratch/api"
+	"scratch/internal/authorization/middlewares"
+	"scratch/internal/problem"
+	userManager "scratch/internal/services"
+)
+
+// WithOrganizations returns a copy of the handler serving the organization
+// operations with om.
+func (ah *accountHandler) WithOrganizations(om userManager.OrganizationManager) *accountHandler {
+	c := *ah
+	c.om = om
+	return &c
+}
+
+func (ah *accountHandler) PostOrganizations(w http.ResponseWriter, r *http.Request) {
+	if !ah.organizations(w, r) {
+		return
+	}
+	var body api.PostOrganizationsJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		problem.Write(w, r, problem.New(problem.InvalidRequest, "detail.invalid-json"))
+		return
+	}
+	userID, _ := middlewares.UserIDFrom(r.Context())
+	organization, err := ah.om.CreateOrganization(r.Context(), userID, body)
+	if err != nil {
+		problem.WriteError(w, r, ah.log, err)
+		return
+	}
+	ah.writeJSON(w, http.StatusCreated, organization)
+}
+
+func (ah *accountHandler) GetOrganizations(w http.ResponseWriter, r *http.Request) {
+	if !ah.organizations(w, r) {
+		return
+	}
+	userID, _ := middlewares.UserIDFrom(r.Context())
+	organizations, err := ah.om.Organizations(r.Context(), userID)
+	if err != nil {
+		problem.WriteError(w, r, ah.log, err)
+		return
+	}
+	ah.writeJSON(w, http.StatusOK, organizations)
+}
+
+func (ah *accountHandler) GetOrganizationsIdMembers(w http.ResponseWriter, r *http.Request, id int) {
+	if !ah.tenant(w, r, id) {
+		return
+	}
+	userID, _ := middlewares.UserIDFrom(r.Context())
+	members, err := ah.om.Members(r.Context(), userID, id)
+	if err != nil {
+		problem.WriteError(w, r, ah.log, err)
+		return
+	}
+	ah.writeJSON(w, http.StatusOK, members)
+}
+
+func (ah *accountHandler) PatchOrganizationsIdMembersUserId(w http.ResponseWriter, r *http.Request, id int, userId int) {
+	if !ah.tenant(w, r, id) {
+		return
+	}
+	var body api.PatchOrganizationsIdMembersUserIdJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		problem.Write(w, r, problem.New(problem.InvalidRequest, "detail.invalid-json"))
+		return
+	}
+	userID, _ := middlewares.UserIDFrom(r.Context())
+	if err := ah.om.UpdateMemberRole(r.Context(), userID, id, userId, body.Role); err != nil {
+		problem.WriteError(w, r, ah.log, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ah *accountHandler) DeleteOrganizationsIdMembersUserId(w http.ResponseWriter, r *http.Request, id int, userId int) {
+	if !ah.tenant(w, r, id) {
+		return
+	}
+	userID, _ := middlewares.UserIDFrom(r.Context())
+	if err := ah.om.RemoveMember(r.Context(), userID, id, userId); err != nil {
+		problem.WriteError(w, r, ah.log, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ah *accountHandler) PostOrganizationsIdInvitations(w http.ResponseWriter, r *http.Request, id int) {
+	if !ah.tenant(w, r, id) {
+		return
+	}
+	var body api.PostOrganizationsIdInvitationsJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		problem.Write(w, r, problem.New(problem.InvalidRequest, "detail.invalid-json"))
+		return
+	}
+	userID, _ := middlewares.UserIDFrom(r.Context())
+	invitation, err := ah.om.Invite(r.Context(), userID, id, body)
+	if err != nil {
+		problem.WriteError(w, r, ah.log, err)
+		return
+	}
+	ah.writeJSON(w, http.StatusCreated, invitation)
+}
+
+func (ah *accountHandler) GetOrganizationsIdInvitations(w http.ResponseWriter, r *http.Request, id int) {
+	if !ah.tenant(w, r, id) {
+		return
+	}
+	userID, _ := middlewares.UserIDFrom(r.Context())
+	invitations, err := ah.om.Invitations(r.Context(), userID, id)
+	if err != nil {
+		problem.WriteError(w, r, ah.log, err)
+		return
+	}
+	ah.writeJSON(w, http.StatusOK, invitations)
+}
+
+func (ah *accountHandler) DeleteOrganizationsIdInvitationsInvitationId(w http.ResponseWriter, r *http.Request, id int, invitationId int) {
+	if !ah.tenant(w, r, id) {
+		return
+	}
+	userID, _ := middlewares.UserIDFrom(r.Context())
+	if err := ah.om.RevokeInvitation(r.Context(), userID, id, invitationId); err != nil {
+		problem.WriteError(w, r, ah.log, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ah *accountHandler) PostOrganizationsIdToken(w http.ResponseWriter, r *http.Request, id int) {
+	if !ah.tenant(w, r, id) {
+		return
+	}
+	userID, _ := middlewares.UserIDFrom(r.Context())
+	token, err := ah.om.OrganizationToken(r.Context(), userID, id)
+	if err != nil {
+		problem.WriteError(w, r, ah.log, err)
+		return
+	}
+	ah.writeJSON(w, http.StatusOK, token)
+}
+
+func (ah *accountHandler) PostInvitationsAccept(w http.ResponseWriter, r *http.Request) {
+	if !ah.organizations(w, r) {
+		return
+	}
+	var body api.PostInvitationsAcceptJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		problem.Write(w, r, problem.New(problem.InvalidRequest, "detail.invalid-json"))
+		return
+	}
+	userID, _ := middlewares.UserIDFrom(r.Context())
+	organization, err := ah.om.AcceptInvitation(r.Context(), userID, body.Code)
+	if err != nil {
+		problem.WriteError(w, r, ah.log, err)
+		return
+	}
+	ah.writeJSON(w, http.StatusOK, organization)
+}
+
+func (ah *accountHandler) GetInvitationsCode(w http.ResponseWriter, r *http.Request, code string) {
+	invitation, err := ah.am.Invitation(r.Context(), code)
+	if err != nil {
+		problem.WriteError(w, r, ah.log, err)
+		return
+	}
+	ah.writeJSON(w, http.StatusOK, invitation)
+}
+
+// organizations answers not found when the handler serves no organizations.
+func (ah *accountHandler) organizations(w http.ResponseWriter, r *http.Request) bool {
+	if ah.om == nil {
+		http.NotFound(w, r)
+		return false
+	}
+	return true
+}
+
+// tenant rejects a request for an organization other than the one its token
+// is scoped to. The token does not stand for the membership, the services
+// check it on every operation, so a removed member is turned away even with a
+// token issued before.
+func (ah *accountHandler) tenant(w http.ResponseWriter, r *http.Request, organizationID int) bool {
+	if !ah.organizations(w, r) {
+		return false
+	}
+	if tenantID, ok := middlewares.TenantIDFrom(r.Context()); ok && tenantID != organizationID {
+		problem.Write(w, r, problem.New(problem.OrganizationForbidden, ""))
+		return false
+	}
+	return true
+}
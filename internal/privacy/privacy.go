@@ -0,0 +1,320 @@
+// Package privacy answers the data subject requests of users: it exports
+// everything held about a user and erases it.
+//
+// Every table of the schema is declared in Tables, with how the rows of a user
+// are exported and erased, or why it needs neither. A test fails for a table
+// that is not declared, so a new table cannot be forgotten.
+package privacy
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	db "scratch/internal/storage/database"
+)
+
+// Table declares what a table of the schema holds about a user.
+type Table struct {
+	// Name is the table without its schema.
+	Name string
+	// Export returns the rows of the user, written to Name.json of the archive.
+	Export func(ctx context.Context, q db.Querier, userID int32) (any, error)
+	// Erase deletes or anonymizes the rows of the user.
+	Erase func(ctx context.Context, q db.Querier, userID int32) error
+	// Exempt says why Export or Erase is missing.
+	Exempt string
+}
+
+// Tables are all tables of the schema. Erase runs in their order, the user
+// goes last since the other rows reference it.
+var Tables = []Table{
+	{
+		Name: "session",
+		Export: func(ctx context.Context, q db.Querier, userID int32) (any, error) {
+			rows, err := q.ListUserSessions(ctx, userID)
+			if err != nil {
+				return nil, err
+			}
+			sessions := []userSession{}
+			for _, row := range rows {
+				sessions = append(sessions, userSession{
+					ID:         row.ID,
+					CreatedAt:  row.CreatedAt,
+					LastSeenAt: row.LastSeenAt,
+					ExpiresAt:  timePtr(row.ExpiresAt.Time, row.ExpiresAt.Valid),
+					UserAgent:  row.UserAgent,
+					IP:         row.Ip,
+					DeviceName: row.DeviceName,
+				})
+			}
+			return sessions, nil
+		},
+		Erase: func(ctx context.Context, q db.Querier, userID int32) error {
+			_, err := q.DeleteUserSessions(ctx, userID)
+			return err
+		},
+	},
+	{
+		Name: "user_role",
+		Export: func(ctx context.Context, q db.Querier, userID int32) (any, error) {
+			rows, err := q.ListUserRoleGrants(ctx, userID)
+			if err != nil {
+				return nil, err
+			}
+			roles := []role{}
+			for _, row := range rows {
+				roles = append(roles, role{Role: row.Role, GrantedAt: row.GrantedAt})
+			}
+			return roles, nil
+		},
+		Erase: func(ctx context.Context, q db.Querier, userID int32) error {
+			_, err := q.DeleteUserRoles(ctx, userID)
+			return err
+		},
+	},
+	{
+		Name: "user_device",
+		Export: func(ctx context.Context, q db.Querier, userID int32) (any, error) {
+			rows, err := q.ListUserDevices(ctx, userID)
+			if err != nil {
+				return nil, err
+			}
+			devices := []device{}
+			for _, row := range rows {
+				devices = append(devices, device{
+					Fingerprint: row.Fingerprint,
+					Name:        row.Name,
+					FirstSeenAt: row.FirstSeenAt,
+					LastSeenAt:  row.LastSeenAt,
+				})
+			}
+			return devices, nil
+		},
+		Erase: func(ctx context.Context, q db.Querier, userID int32) error {
+			_, err := q.DeleteUserDevices(ctx, userID)
+			return err
+		},
+	},
+	{
+		// the events of the user aggregate, the audit trail of the account
+		Name: "outbox",
+		Export: func(ctx context.Context, q db.Querier, userID int32) (any, error) {
+			rows, err := q.ListUserEvents(ctx, strconv.Itoa(int(userID)))
+			if err != nil {
+				return nil, err
+			}
+			events := []event{}
+			for _, row := range rows {
+				events = append(events, event{
+					ID:         row.ID,
+					Type:       row.EventType,
+					Payload:    row.Payload,
+					OccurredAt: row.OccurredAt,
+				})
+			}
+			return events, nil
+		},
+		Erase: func(ctx context.Context, q db.Querier, userID int32) error {
+			_, err := q.DeleteUserEvents(ctx, strconv.Itoa(int(userID)))
+			return err
+		},
+	},
+	{
+		Name: "data_export",
+		Erase: func(ctx context.Context, q db.Querier, userID int32) error {
+			_, err := q.DeleteDataExport(ctx, userID)
+			return err
+		},
+		Exempt: "the archive copies the other tables",
+	},
+	{
+		Name: "organization_member",
+		Export: func(ctx context.Context, q db.Querier, userID int32) (any, error) {
+			rows, err := q.ListUserOrganizations(ctx, userID)
+			if err != nil {
+				return nil, err
+			}
+			memberships := []membership{}
+			for _, row := range rows {
+				memberships = append(memberships, membership{
+					OrganizationID: row.ID,
+					Organization:   row.Name,
+					Role:           row.Role,
+					JoinedAt:       row.JoinedAt,
+				})
+			}
+			return memberships, nil
+		},
+		Erase: func(ctx context.Context, q db.Querier, userID int32) error {
+			_, err := q.DeleteUserMemberships(ctx, userID)
+			return err
+		},
+	},
+	{
+		// the invitations sent to the email of the user, the code is never
+		// exported
+		Name: "invitation",
+		Export: func(ctx context.Context, q db.Querier, userID int32) (any, error) {
+			rows, err := q.ListUserInvitations(ctx, userID)
+			if err != nil {
+				return nil, err
+			}
+			invitations := []invitation{}
+			for _, row := range rows {
+				invitations = append(invitations, invitation{
+					ID:           row.ID,
+					Organization: row.Organization,
+					Role:         row.Role,
+					CreatedAt:    row.CreatedAt,
+					ExpiresAt:    row.ExpiresAt,
+					AcceptedAt:   timePtr(row.AcceptedAt.Time, row.AcceptedAt.Valid),
+					RevokedAt:    timePtr(row.RevokedAt.Time, row.RevokedAt.Valid),
+				})
+			}
+			return invitations, nil
+		},
+		// invitations go before the user, they are found by its email. The
+		// invitations the user sent or accepted lose their user with it.
+		Erase: func(ctx context.Context, q db.Querier, userID int32) error {
+			_, err := q.DeleteUserInvitations(ctx, userID)
+			return err
+		},
+	},
+	{
+		Name: "user",
+		Export: func(ctx context.Context, q db.Querier, userID int32) (any, error) {
+			user, err := q.GetUserByID(ctx, userID)
+			if err != nil {
+				return nil, err
+			}
+			return profile{
+				ID:         user.ID,
+				Name:       user.Name,
+				Email:      user.Email,
+				Locale:     user.Locale.String,
+				DisabledAt: timePtr(user.DisabledAt.Time, user.DisabledAt.Valid),
+			}, nil
+		},
+		Erase: func(ctx context.Context, q db.Querier, userID int32) error {
+			_, err := q.EraseUser(ctx, userID)
+			return err
+		},
+	},
+	{Name: "initial_migration", Exempt: "holds the message of the first migration"},
+	{Name: "goose_db_version", Exempt: "holds the applied migrations"},
+	{Name: "rate_limit", Exempt: "keys name an IP or a user id, the rows expire with their window"},
+	{Name: "idempotency_key", Exempt: "the stored responses expire after idempotency.ttl"},
+	{Name: "job", Exempt: "payloads name users by id only, finished jobs are deleted after jobs.retention"},
+	{Name: "mail_sent", Exempt: "holds message ids only"},
+	{Name: "organization", Exempt: "holds the names of organizations, not of people"},
+}
+
+// Export returns a ZIP archive of everything held about the user, with a JSON
+// file for every table that has an Export.
+func Export(ctx context.Context, q db.Querier, userID int32) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, table := range Tables {
+		if table.Export == nil {
+			continue
+		}
+		rows, err := table.Export(ctx, q, userID)
+		if err != nil {
+			return nil, fmt.Errorf("export %v: %w", table.Name, err)
+		}
+		f, err := archive.Create(table.Name + ".json")
+		if err != nil {
+			return nil, fmt.Errorf("export %v: %w", table.Name, err)
+		}
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(rows); err != nil {
+			return nil, fmt.Errorf("export %v: %w", table.Name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("close archive: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Erase deletes the rows of the user from every table that has an Erase, q
+// should be bound to a transaction so a user is erased entirely or not at all.
+func Erase(ctx context.Context, q db.Querier, userID int32) error {
+	for _, table := range Tables {
+		if table.Erase == nil {
+			continue
+		}
+		if err := table.Erase(ctx, q, userID); err != nil {
+			return fmt.Errorf("erase %v: %w", table.Name, err)
+		}
+	}
+	return nil
+}
+
+// profile is the user row without its password hash.
+type profile struct {
+	ID         int32      `json:"id"`
+	Name       string     `json:"name"`
+	Email      string     `json:"email"`
+	Locale     string     `json:"locale,omitempty"`
+	DisabledAt *time.Time `json:"disabledAt,omitempty"`
+}
+
+type userSession struct {
+	ID         int32      `json:"id"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastSeenAt time.Time  `json:"lastSeenAt"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	UserAgent  string     `json:"userAgent"`
+	IP         string     `json:"ip"`
+	DeviceName string     `json:"deviceName"`
+}
+
+type role struct {
+	Role      string    `json:"role"`
+	GrantedAt time.Time `json:"grantedAt"`
+}
+
+type device struct {
+	Fingerprint string    `json:"fingerprint"`
+	Name        string    `json:"name"`
+	FirstSeenAt time.Time `json:"firstSeenAt"`
+	LastSeenAt  time.Time `json:"lastSeenAt"`
+}
+
+type event struct {
+	ID         int64           `json:"id"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	OccurredAt time.Time       `json:"occurredAt"`
+}
+
+type membership struct {
+	OrganizationID int32     `json:"organizationId"`
+	Organization   string    `json:"organization"`
+	Role           string    `json:"role"`
+	JoinedAt       time.Time `json:"joinedAt"`
+}
+
+type invitation struct {
+	ID           int32      `json:"id"`
+	Organization string     `json:"organization"`
+	Role         string     `json:"role"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	ExpiresAt    time.Time  `json:"expiresAt"`
+	AcceptedAt   *time.Time `json:"acceptedAt,omitempty"`
+	RevokedAt    *time.Time `json:"revokedAt,omitempty"`
+}
+
+func timePtr(t time.Time, valid bool) *time.Time {
+	if !valid {
+		return nil
+	}
+	return &t
+}
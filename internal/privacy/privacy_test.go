@@ -0,0 +1,144 @@
+package privacy
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	db "scratch/internal/storage/database"
+	"scratch/internal/storage/database/memory"
+	"scratch/internal/storage/migrations"
+	"scratch/internal/storage/sqlite"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTables checks that every table of the schema is declared, the sqlite
+// schema follows the postgres one.
+func TestTables(t *testing.T) {
+	ctx := context.Background()
+	database, err := sqlite.Open(filepath.Join(t.TempDir(), "chatto.db"))
+	require.NoError(t, err)
+	defer database.Close()
+	require.NoError(t, migrations.SQLite.Up(ctx, database))
+
+	rows, err := database.QueryContext(ctx, `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'`)
+	require.NoError(t, err)
+	defer rows.Close()
+	var tables []string
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		tables = append(tables, name)
+	}
+	require.NoError(t, rows.Err())
+
+	var declared []string
+	for _, table := range Tables {
+		declared = append(declared, table.Name)
+		if table.Export == nil || table.Erase == nil {
+			assert.NotEmpty(t, table.Exempt, "table %v needs an Export and an Erase, or says why not", table.Name)
+		}
+	}
+	sort.Strings(tables)
+	sort.Strings(declared)
+	assert.Equal(t, tables, declared)
+}
+
+func TestExportAndErase(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	store := memory.New()
+
+	user, err := store.CreateUser(ctx, db.CreateUserParams{Name: "Norbi", Email: "norbi@example.com", Password: "hash"})
+	require.NoError(t, err)
+	other, err := store.CreateUser(ctx, db.CreateUserParams{Name: "Other", Email: "other@example.com", Password: "hash"})
+	require.NoError(t, err)
+	org, err := store.CreateOrganization(ctx, db.CreateOrganizationParams{Slug: "acme", Name: "Acme", Now: now})
+	require.NoError(t, err)
+	for _, u := range []db.ScratchUser{user, other} {
+		_, err = store.AddOrganizationMember(ctx, db.AddOrganizationMemberParams{OrganizationID: org.ID, UserID: u.ID, Role: "owner", Now: now})
+		require.NoError(t, err)
+		_, err = store.CreateInvitation(ctx, db.CreateInvitationParams{
+			OrganizationID: org.ID, Email: u.Email, Role: "member", CodeHash: u.Email, Now: now, ExpiresAt: now.Add(time.Hour),
+		})
+		require.NoError(t, err)
+		_, err = store.CreateSession(ctx, db.CreateSessionParams{
+			UserID: u.ID, RefreshTokenHash: u.Email, Now: now, ExpiresAt: now.Add(time.Hour), Ip: "10.0.0.1",
+		})
+		require.NoError(t, err)
+		require.NoError(t, store.GrantUserRole(ctx, db.GrantUserRoleParams{UserID: u.ID, Role: "admin"}))
+		_, err := store.RememberDevice(ctx, db.RememberDeviceParams{UserID: u.ID, Fingerprint: "a", Name: "curl", Now: now})
+		require.NoError(t, err)
+		require.NoError(t, store.InsertOutboxEvent(ctx, db.InsertOutboxEventParams{
+			AggregateType: "user", AggregateID: strconv.Itoa(int(u.ID)), EventType: "user.registered",
+			Payload: json.RawMessage(`{"email":"` + u.Email + `"}`), OccurredAt: now,
+		}))
+	}
+	_, err = store.RequestDataExport(ctx, db.RequestDataExportParams{UserID: user.ID, Now: now})
+	require.NoError(t, err)
+
+	archive, err := Export(ctx, store, user.ID)
+	require.NoError(t, err)
+	files := unzip(t, archive)
+	assert.Equal(t, []string{
+		"invitation.json", "organization_member.json", "outbox.json", "session.json", "user.json", "user_device.json", "user_role.json",
+	}, sortedKeys(files))
+	assert.JSONEq(t, `{"id":1,"name":"Norbi","email":"norbi@example.com"}`, files["user.json"], "without the password")
+	assert.Contains(t, files["session.json"], `"ip": "10.0.0.1"`)
+	assert.Contains(t, files["outbox.json"], `"type": "user.registered"`)
+	assert.NotContains(t, files["outbox.json"], "other@example.com")
+	assert.Contains(t, files["organization_member.json"], `"organization": "Acme"`)
+	assert.NotContains(t, files["invitation.json"], "norbi@example.com", "without the code")
+
+	require.NoError(t, store.InTx(ctx, func(q db.Querier) error { return Erase(ctx, q, user.ID) }))
+
+	_, err = store.GetUserByID(ctx, user.ID)
+	assert.ErrorIs(t, err, db.ErrNoRows)
+	_, err = store.GetDataExport(ctx, user.ID)
+	assert.ErrorIs(t, err, db.ErrNoRows)
+	for _, table := range Tables {
+		if table.Export == nil || table.Name == "user" {
+			continue
+		}
+		rows, err := table.Export(ctx, store, user.ID)
+		require.NoError(t, err)
+		assert.Empty(t, rows, table.Name)
+		rows, err = table.Export(ctx, store, other.ID)
+		require.NoError(t, err)
+		assert.NotEmpty(t, rows, "%v of the other user", table.Name)
+	}
+}
+
+func unzip(t *testing.T, archive []byte) map[string]string {
+	t.Helper()
+	r, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+	files := make(map[string]string)
+	for _, f := range r.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
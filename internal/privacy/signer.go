@@ -0,0 +1,52 @@
+package privacy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"time"
+
+	"scratch/internal/authorization/session"
+)
+
+// URLSigner signs the download URLs of export archives, which carry no other
+// credentials. It signs with the first of the token keys and verifies with all
+// of them, so a key rotation keeps issued URLs working until they expire.
+type URLSigner struct {
+	keys session.KeySource
+}
+
+func NewURLSigner(keys session.KeySource) *URLSigner {
+	return &URLSigner{keys: keys}
+}
+
+// URL returns the download URL of the archive of the user, valid until
+// expires.
+func (s *URLSigner) URL(userID int, expires time.Time) string {
+	query := url.Values{}
+	query.Set("expires", fmt.Sprint(expires.Unix()))
+	query.Set("signature", s.sign(s.keys.Keys()[0], userID, expires.Unix()))
+	return fmt.Sprintf("/exports/%d?%v", userID, query.Encode())
+}
+
+// Verify reports whether signature was made by URL for the user and expiry,
+// and the URL has not expired at now.
+func (s *URLSigner) Verify(userID int, expires int64, signature string, now time.Time) bool {
+	if now.Unix() >= expires {
+		return false
+	}
+	for _, key := range s.keys.Keys() {
+		if hmac.Equal([]byte(signature), []byte(s.sign(key, userID, expires))) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *URLSigner) sign(key []byte, userID int, expires int64) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "data-export:%d:%d", userID, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,49 @@
+package privacy
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type keys [][]byte
+
+func (k keys) Keys() [][]byte { return k }
+
+func TestURLSigner(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	expires := now.Add(15 * time.Minute)
+
+	signed, err := url.Parse(NewURLSigner(keys{[]byte("old")}).URL(3, expires))
+	require.NoError(t, err)
+	assert.Equal(t, "/exports/3", signed.Path)
+	assert.Equal(t, strconv.FormatInt(expires.Unix(), 10), signed.Query().Get("expires"))
+	signature := signed.Query().Get("signature")
+
+	tests := []struct {
+		name      string
+		keys      keys
+		userID    int
+		expires   int64
+		signature string
+		now       time.Time
+		want      bool
+	}{
+		{name: "valid", keys: keys{[]byte("old")}, userID: 3, expires: expires.Unix(), signature: signature, now: now, want: true},
+		{name: "rotated key", keys: keys{[]byte("new"), []byte("old")}, userID: 3, expires: expires.Unix(), signature: signature, now: now, want: true},
+		{name: "expired", keys: keys{[]byte("old")}, userID: 3, expires: expires.Unix(), signature: signature, now: expires, want: false},
+		{name: "other user", keys: keys{[]byte("old")}, userID: 4, expires: expires.Unix(), signature: signature, now: now, want: false},
+		{name: "extended", keys: keys{[]byte("old")}, userID: 3, expires: expires.Add(time.Hour).Unix(), signature: signature, now: now, want: false},
+		{name: "unknown key", keys: keys{[]byte("new")}, userID: 3, expires: expires.Unix(), signature: signature, now: now, want: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NewURLSigner(tt.keys).Verify(tt.userID, tt.expires, tt.signature, tt.now))
+		})
+	}
+}
@@ -0,0 +1,246 @@
+// Package problem renders API errors as RFC 7807 application/problem+json.
+package problem
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"scratch/api"
+	"scratch/internal/i18n"
+	"scratch/internal/services"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const ContentType = "application/problem+json"
+
+// Type is the stable, machine readable code of a problem. Clients should
+// switch on it instead of on the title, which is localized.
+type Type string
+
+const (
+	InvalidRequest     Type = "/problems/invalid-request"
+	ValidationFailed   Type = "/problems/validation-failed"
+	InvalidCredentials Type = "/problems/invalid-credentials"
+	Unauthorized       Type = "/problems/unauthorized"
+	CSRFFailed         Type = "/problems/csrf-failed"
+	SessionNotFound    Type = "/problems/session-not-found"
+	UserDisabled       Type = "/problems/user-disabled"
+	UserDeleted        Type = "/problems/user-deleted"
+	UserNotFound       Type = "/problems/user-not-found"
+	UserExists         Type = "/problems/user-exists"
+	DataExportNotFound Type = "/problems/data-export-not-found"
+	UnsupportedLocale  Type = "/problems/unsupported-locale"
+	UnknownRole        Type = "/problems/unknown-role"
+	RateLimited        Type = "/problems/rate-limited"
+	// OrganizationNotFound hides the organizations the user is not a member
+	// of, OrganizationForbidden is for members lacking the role.
+	OrganizationNotFound  Type = "/problems/organization-not-found"
+	OrganizationForbidden Type = "/problems/organization-forbidden"
+	OrganizationSlugTaken Type = "/problems/organization-slug-taken"
+	MemberNotFound        Type = "/problems/member-not-found"
+	LastOwner             Type = "/problems/last-owner"
+	InvitationNotFound    Type = "/problems/invitation-not-found"
+	InvitationExpiry      Type = "/problems/invitation-expiry"
+	// InvitationRequired rejects registrations without an invite code when
+	// registration is invite only.
+	InvitationRequired Type = "/problems/invitation-required"
+	// IdempotencyKeyReused and IdempotencyKeyInProgress reject retries that
+	// do not match, or overlap, the first request of an Idempotency-Key.
+	IdempotencyKeyReused     Type = "/problems/idempotency-key-reused"
+	IdempotencyKeyInProgress Type = "/problems/idempotency-key-in-progress"
+	InvalidResponse          Type = "/problems/invalid-response"
+	Internal                 Type = "/problems/internal"
+)
+
+var statuses = map[Type]int{
+	InvalidRequest:           http.StatusBadRequest,
+	ValidationFailed:         http.StatusBadRequest,
+	InvalidCredentials:       http.StatusBadRequest,
+	Unauthorized:             http.StatusUnauthorized,
+	CSRFFailed:               http.StatusForbidden,
+	SessionNotFound:          http.StatusNotFound,
+	UserDisabled:             http.StatusForbidden,
+	UserDeleted:              http.StatusForbidden,
+	UserNotFound:             http.StatusNotFound,
+	UserExists:               http.StatusConflict,
+	DataExportNotFound:       http.StatusNotFound,
+	UnsupportedLocale:        http.StatusBadRequest,
+	UnknownRole:              http.StatusBadRequest,
+	OrganizationNotFound:     http.StatusNotFound,
+	OrganizationForbidden:    http.StatusForbidden,
+	OrganizationSlugTaken:    http.StatusConflict,
+	MemberNotFound:           http.StatusNotFound,
+	LastOwner:                http.StatusConflict,
+	InvitationNotFound:       http.StatusNotFound,
+	InvitationExpiry:         http.StatusBadRequest,
+	InvitationRequired:       http.StatusForbidden,
+	RateLimited:              http.StatusTooManyRequests,
+	IdempotencyKeyReused:     http.StatusUnprocessableEntity,
+	IdempotencyKeyInProgress: http.StatusConflict,
+	InvalidResponse:          http.StatusInternalServerError,
+	Internal:                 http.StatusInternalServerError,
+}
+
+// domainErrors maps the errors returned by services to problem types.
+var domainErrors = []struct {
+	err error
+	typ Type
+}{
+	{services.UserNotFoundErr, UserNotFound},
+	{services.UserExistErr, UserExists},
+	{services.IncorrectPasswordErr, InvalidCredentials},
+	{services.UserDisabledErr, UserDisabled},
+	{services.UserDeletedErr, UserDeleted},
+	{services.UnsupportedLocaleErr, UnsupportedLocale},
+	{services.SessionNotFoundErr, Unauthorized},
+	{services.DataExportNotFoundErr, DataExportNotFound},
+	{services.UnknownRoleErr, UnknownRole},
+	{services.OrganizationNotFoundErr, OrganizationNotFound},
+	{services.OrganizationForbiddenErr, OrganizationForbidden},
+	{services.OrganizationSlugTakenErr, OrganizationSlugTaken},
+	{services.MemberNotFoundErr, MemberNotFound},
+	{services.LastOwnerErr, LastOwner},
+	{services.InvitationNotFoundErr, InvitationNotFound},
+	{services.InvitationExpiryErr, InvitationExpiry},
+	{services.InvitationRequiredErr, InvitationRequired},
+}
+
+// Problem is a single occurrence of a problem, rendered by Write.
+type Problem struct {
+	Type Type
+	// Detail is a message key of the i18n catalogs, or plain text when no such key exists.
+	Detail string
+	Errors []api.FieldError
+}
+
+func New(typ Type, detail string) Problem {
+	return Problem{Type: typ, Detail: detail}
+}
+
+// Title returns the localized title of typ.
+func Title(typ Type, locale string) string {
+	return i18n.Default().T(locale, "problem."+strings.TrimPrefix(string(typ), "/problems/"))
+}
+
+// Validation reports invalid fields of the request.
+func Validation(errors []api.FieldError) Problem {
+	return Problem{Type: ValidationFailed, Errors: errors}
+}
+
+// FromError maps a domain error to its problem. Unknown errors become
+// Internal without a detail, so nothing internal leaks to the client.
+func FromError(err error) Problem {
+	for _, d := range domainErrors {
+		if errors.Is(err, d.err) {
+			return Problem{Type: d.typ}
+		}
+	}
+	return Problem{Type: Internal}
+}
+
+func (p Problem) Status() int {
+	if status, ok := statuses[p.Type]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Response builds the body of the problem for the request r, in the locale
+// set by the i18n middleware or negotiated from Accept-Language.
+func (p Problem) Response(r *http.Request) api.Problem {
+	locale := i18n.LocaleFrom(r.Context())
+	if locale == "" {
+		locale = i18n.Default().Match(r.Header.Get("Accept-Language"))
+	}
+
+	response := api.Problem{
+		Type:   string(p.Type),
+		Title:  Title(p.Type, locale),
+		Status: p.Status(),
+	}
+	if p.Detail != "" {
+		detail := i18n.Default().T(locale, p.Detail)
+		response.Detail = &detail
+	}
+	if len(p.Errors) > 0 {
+		response.Errors = &p.Errors
+	}
+	instance := r.URL.Path
+	response.Instance = &instance
+	traceID := TraceID(r)
+	response.TraceId = &traceID
+	return response
+}
+
+// Write sends p as the response to r.
+func Write(w http.ResponseWriter, r *http.Request, p Problem) {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status())
+	_ = json.NewEncoder(w).Encode(p.Response(r))
+}
+
+// WriteError maps err with FromError and sends it. Internal errors are logged
+// with the trace id so they can be found from the client's report.
+func WriteError(w http.ResponseWriter, r *http.Request, log slog.Logger, err error) {
+	p := FromError(err)
+	if p.Type == Internal {
+		log.Error("request failed", "method", r.Method, "path", r.URL.Path, "trace_id", TraceID(r), "err", err)
+	}
+	Write(w, r, p)
+}
+
+// ErrorHandlerFunc renders the parameter errors of the generated wrapper,
+// meant for api.ChiServerOptions.ErrorHandlerFunc.
+func ErrorHandlerFunc(w http.ResponseWriter, r *http.Request, err error) {
+	var (
+		invalidFormat *api.InvalidParamFormatError
+		required      *api.RequiredParamError
+		unmarshaling  *api.UnmarshalingParamError
+		tooMany       *api.TooManyValuesForParamError
+		requiredHdr   *api.RequiredHeaderError
+		cookie        *api.UnescapedCookieParamError
+	)
+	var field, message string
+	switch {
+	case errors.As(err, &invalidFormat):
+		field, message = invalidFormat.ParamName, invalidFormat.Err.Error()
+	case errors.As(err, &required):
+		field, message = required.ParamName, "parameter is required"
+	case errors.As(err, &unmarshaling):
+		field, message = unmarshaling.ParamName, unmarshaling.Err.Error()
+	case errors.As(err, &tooMany):
+		field, message = tooMany.ParamName, "parameter given more than once"
+	case errors.As(err, &requiredHdr):
+		field, message = requiredHdr.ParamName, "header is required"
+	case errors.As(err, &cookie):
+		field, message = cookie.ParamName, cookie.Err.Error()
+	default:
+		Write(w, r, New(InvalidRequest, err.Error()))
+		return
+	}
+	Write(w, r, Validation([]api.FieldError{{Field: field, Message: message}}))
+}
+
+// TraceID identifies the request: the trace id of a W3C traceparent header
+// when the caller sent one, otherwise the id set by middleware.RequestID.
+func TraceID(r *http.Request) string {
+	// traceparent is version-traceid-parentid-flags
+	if parts := strings.Split(r.Header.Get("traceparent"), "-"); len(parts) == 4 && len(parts[1]) == 32 {
+		return parts[1]
+	}
+	if id := middleware.GetReqID(r.Context()); id != "" {
+		return id
+	}
+	return newTraceID()
+}
+
+func newTraceID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
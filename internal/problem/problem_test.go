@@ -0,0 +1,109 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"scratch/api"
+	"scratch/internal/i18n"
+	"scratch/internal/services"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantType   Type
+		wantStatus int
+	}{
+		{"user not found", services.UserNotFoundErr, UserNotFound, http.StatusNotFound},
+		{"wrapped user exists", fmt.Errorf("create user: %w", services.UserExistErr), UserExists, http.StatusConflict},
+		{"incorrect password", services.IncorrectPasswordErr, InvalidCredentials, http.StatusBadRequest},
+		{"disabled user", services.UserDisabledErr, UserDisabled, http.StatusForbidden},
+		{"expired session", services.SessionNotFoundErr, Unauthorized, http.StatusUnauthorized},
+		{"not a member", services.OrganizationNotFoundErr, OrganizationNotFound, http.StatusNotFound},
+		{"last owner", services.LastOwnerErr, LastOwner, http.StatusConflict},
+		{"unknown error", errors.New("connection refused"), Internal, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := FromError(tt.err)
+			assert.Equal(t, tt.wantType, p.Type)
+			assert.Equal(t, tt.wantStatus, p.Status())
+			assert.Empty(t, p.Detail)
+		})
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/login", nil)
+	r.Header.Set("Accept-Language", "de;q=0.9, pl-PL, en;q=0.5")
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+
+	WriteError(w, r, *slog.New(slog.NewTextHandler(io.Discard, nil)), services.IncorrectPasswordErr)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, ContentType, w.Header().Get("Content-Type"))
+
+	var res api.Problem
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&res))
+	instance := "/login"
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	assert.Equal(t, api.Problem{
+		Type:     string(InvalidCredentials),
+		Title:    "Nieprawidłowy email lub hasło",
+		Status:   http.StatusBadRequest,
+		Instance: &instance,
+		TraceId:  &traceID,
+	}, res)
+}
+
+func TestErrorHandlerFunc(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/user/abc", nil)
+	w := httptest.NewRecorder()
+
+	ErrorHandlerFunc(w, r, &api.InvalidParamFormatError{ParamName: "id", Err: errors.New("not a number")})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var res api.Problem
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&res))
+	assert.Equal(t, string(ValidationFailed), res.Type)
+	assert.Equal(t, "The request contains invalid fields", res.Title)
+	require.NotNil(t, res.Errors)
+	assert.Equal(t, []api.FieldError{{Field: "id", Message: "not a number"}}, *res.Errors)
+}
+
+func TestTraceID(t *testing.T) {
+	t.Run("request id", func(t *testing.T) {
+		var got string
+		handler := middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = TraceID(r)
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.NotEmpty(t, got)
+	})
+
+	t.Run("generated", func(t *testing.T) {
+		id := TraceID(httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Len(t, id, 32)
+	})
+}
+
+func TestTitle(t *testing.T) {
+	// every problem type needs a title in the catalogs, which i18n checks are complete
+	for typ := range statuses {
+		key := "problem." + strings.TrimPrefix(string(typ), "/problems/")
+		assert.NotEqual(t, key, Title(typ, i18n.DefaultLocale), "no title for %v", typ)
+	}
+}
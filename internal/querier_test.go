@@ -0,0 +1,271 @@
+package internal
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"scratch/internal/privacy"
+	storage "scratch/internal/storage/database"
+	"scratch/internal/storage/database/dbtest"
+	"scratch/internal/storage/migrations"
+	"scratch/internal/storage/migrations/migrationtest"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestQuerier runs the conformance suite of the in-memory store against
+// postgres, which keeps the two in agreement.
+func TestQuerier(t *testing.T) {
+	dbtest.TestQuerier(t, func(t *testing.T) (storage.Querier, storage.Transactor) {
+		_, err := dbpool.Exec(context.Background(), `TRUNCATE scratch.user, scratch.session, scratch.user_role,
+			scratch.user_device, scratch.idempotency_key, scratch.job, scratch.mail_sent, scratch.outbox,
+			scratch.rate_limit, scratch.data_export, scratch.organization, scratch.organization_member,
+			scratch.invitation RESTART IDENTITY CASCADE`)
+		require.NoError(t, err)
+		return storage.New(dbpool), storage.NewTxManager(dbpool, storage.TxOptions{})
+	})
+}
+
+// TestPrivacyTables checks that every table of the postgres schema declares
+// how it is exported and erased.
+func TestPrivacyTables(t *testing.T) {
+	rows, err := dbpool.Query(context.Background(), `SELECT table_name FROM information_schema.tables
+		WHERE table_schema = 'scratch' OR table_name = 'goose_db_version'`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	declared := make(map[string]bool)
+	for _, table := range privacy.Tables {
+		declared[table.Name] = true
+	}
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		assert.True(t, declared[name], "table %v is not declared in privacy.Tables", name)
+	}
+	require.NoError(t, rows.Err())
+}
+
+// TestMigrations_Reversible applies and rolls back every migration in a
+// database of its own.
+func TestMigrations_Reversible(t *testing.T) {
+	ctx := context.Background()
+	_, err := dbpool.Exec(ctx, "CREATE DATABASE reversible")
+	require.NoError(t, err)
+	defer dbpool.Exec(ctx, "DROP DATABASE reversible")
+
+	config := dbpool.Config().ConnConfig.Copy()
+	config.Database = "reversible"
+	database := stdlib.OpenDB(*config)
+	defer database.Close()
+
+	migrationtest.TestReversible(t, migrations.Postgres, database, migrationtest.PostgresSchema)
+	assertNoSchema(t, database, "scratch")
+}
+
+// TestMigrations_Upgrade migrates a database the first release migrated to
+// the latest version.
+func TestMigrations_Upgrade(t *testing.T) {
+	ctx := context.Background()
+	_, err := dbpool.Exec(ctx, "CREATE DATABASE upgrade")
+	require.NoError(t, err)
+	defer dbpool.Exec(ctx, "DROP DATABASE upgrade")
+
+	config := dbpool.Config().ConnConfig.Copy()
+	config.Database = "upgrade"
+	database := stdlib.OpenDB(*config)
+	defer database.Close()
+
+	latest := migrationtest.PostgresSchema(t, db)
+	migrationtest.TestUpgrade(t, migrations.Postgres, migrationtest.PostgresBaseline, database, latest, migrationtest.PostgresSchema)
+	assertNoSchema(t, database, "scratch")
+}
+
+// TestMigrations_DuplicateEmails checks that the unique email index names
+// the emails differing only in case instead of failing on the first.
+func TestMigrations_DuplicateEmails(t *testing.T) {
+	ctx := context.Background()
+	_, err := dbpool.Exec(ctx, "CREATE DATABASE duplicates")
+	require.NoError(t, err)
+	defer dbpool.Exec(ctx, "DROP DATABASE duplicates")
+
+	config := dbpool.Config().ConnConfig.Copy()
+	config.Database = "duplicates"
+	database := stdlib.OpenDB(*config)
+	defer database.Close()
+
+	require.NoError(t, migrations.Postgres.To(ctx, database, 20261019180000))
+	_, err = database.Exec(`INSERT INTO scratch.user (name, email, password) VALUES
+		('Ann', 'ann@example.com', 'secret'), ('Ann', 'Ann@Example.com', 'secret'), ('Bob', 'bob@example.com', 'secret')`)
+	require.NoError(t, err)
+
+	err = migrations.Postgres.Up(ctx, database)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "users share an email differing only in case: ann@example.com")
+}
+
+// TestMigrations_UserEmailLiveDown checks that rolling back the index of the
+// emails of live users names the emails of deleted users registered again
+// instead of failing on the first.
+func TestMigrations_UserEmailLiveDown(t *testing.T) {
+	ctx := context.Background()
+	_, err := dbpool.Exec(ctx, "CREATE DATABASE email_live")
+	require.NoError(t, err)
+	defer dbpool.Exec(ctx, "DROP DATABASE email_live")
+
+	config := dbpool.Config().ConnConfig.Copy()
+	config.Database = "email_live"
+	database := stdlib.OpenDB(*config)
+	defer database.Close()
+
+	require.NoError(t, migrations.Postgres.To(ctx, database, 20261020020000))
+	_, err = database.Exec(`INSERT INTO scratch.user (name, email, password, deleted_at) VALUES
+		('Ann', 'ann@example.com', 'secret', now()), ('Ann', 'Ann@example.com', 'secret', NULL)`)
+	require.NoError(t, err)
+
+	err = migrations.Postgres.To(ctx, database, 20261020010000)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "deleted users share an email with other users: ann@example.com")
+}
+
+// TestMigrations_RefreshTokenHash checks that the refresh tokens of sessions
+// started before they were hashed are hashed, and the hashes left alone.
+func TestMigrations_RefreshTokenHash(t *testing.T) {
+	ctx := context.Background()
+	_, err := dbpool.Exec(ctx, "CREATE DATABASE refresh_tokens")
+	require.NoError(t, err)
+	defer dbpool.Exec(ctx, "DROP DATABASE refresh_tokens")
+
+	config := dbpool.Config().ConnConfig.Copy()
+	config.Database = "refresh_tokens"
+	database := stdlib.OpenDB(*config)
+	defer database.Close()
+
+	hashed := hex.EncodeToString(sha256.New().Sum(nil))
+	require.NoError(t, migrations.Postgres.To(ctx, database, 20261020020000))
+	_, err = database.Exec(`INSERT INTO scratch.user (id, name, email, password) VALUES (1, 'Ann', 'ann@example.com', 'secret')`)
+	require.NoError(t, err)
+	_, err = database.Exec(`INSERT INTO scratch.session (user_id, refresh_token, expires_at) VALUES
+		(1, 'eyJhbGciOiJIUzI1NiJ9.legacy.token', now() + interval '1 hour'), (1, $1, now() + interval '1 hour')`, hashed)
+	require.NoError(t, err)
+
+	require.NoError(t, migrations.Postgres.Up(ctx, database))
+	rows, err := database.Query(`SELECT refresh_token_hash FROM scratch.session ORDER BY id`)
+	require.NoError(t, err)
+	defer rows.Close()
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		require.NoError(t, rows.Scan(&hash))
+		hashes = append(hashes, hash)
+	}
+	require.NoError(t, rows.Err())
+	legacy := sha256.Sum256([]byte("eyJhbGciOiJIUzI1NiJ9.legacy.token"))
+	assert.Equal(t, []string{hex.EncodeToString(legacy[:]), hashed}, hashes)
+}
+
+// assertNoSchema checks that the migrations rolled back left no schema
+// behind, which the schema description ignores while empty.
+func assertNoSchema(t *testing.T, database *sql.DB, name string) {
+	t.Helper()
+	var exists bool
+	require.NoError(t, database.QueryRow("SELECT EXISTS (SELECT FROM pg_namespace WHERE nspname = $1)", name).Scan(&exists))
+	assert.False(t, exists, "schema %v is left behind", name)
+}
+
+// TestRowLevelSecurity checks that the transactions of one organization see
+// the members and invitations of no other, as a role the policies restrict.
+func TestRowLevelSecurity(t *testing.T) {
+	ctx := context.Background()
+	for _, statement := range []string{
+		"CREATE ROLE tenant_test LOGIN PASSWORD 'tenant_test'",
+		"GRANT USAGE ON SCHEMA scratch TO tenant_test",
+		"GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA scratch TO tenant_test",
+		"GRANT USAGE ON ALL SEQUENCES IN SCHEMA scratch TO tenant_test",
+	} {
+		_, err := dbpool.Exec(ctx, statement)
+		require.NoError(t, err, statement)
+	}
+	defer dbpool.Exec(ctx, "DROP OWNED BY tenant_test; DROP ROLE tenant_test")
+
+	// the superuser of the tests is never restricted
+	admin := storage.New(dbpool)
+	user, err := admin.CreateUser(ctx, storage.CreateUserParams{Name: "Ann", Email: "ann.rls@example.com", Password: "x"})
+	require.NoError(t, err)
+	defer dbpool.Exec(ctx, "DELETE FROM scratch.user WHERE id = $1", user.ID)
+	now := time.Now()
+	var organizations [2]storage.ScratchOrganization
+	for i, slug := range []string{"rls-a", "rls-b"} {
+		organizations[i], err = admin.CreateOrganization(ctx, storage.CreateOrganizationParams{Slug: slug, Name: slug, Now: now})
+		require.NoError(t, err)
+		defer dbpool.Exec(ctx, "DELETE FROM scratch.organization WHERE id = $1", organizations[i].ID)
+		_, err = admin.AddOrganizationMember(ctx, storage.AddOrganizationMemberParams{
+			OrganizationID: organizations[i].ID, UserID: user.ID, Role: "owner", Now: now,
+		})
+		require.NoError(t, err)
+		_, err = admin.CreateInvitation(ctx, storage.CreateInvitationParams{
+			OrganizationID: organizations[i].ID, Email: "joe.rls@example.com", Role: "member",
+			CodeHash: slug, Now: now, ExpiresAt: now.Add(time.Hour),
+		})
+		require.NoError(t, err)
+	}
+	a, b := organizations[0].ID, organizations[1].ID
+
+	config := dbpool.Config().Copy()
+	config.ConnConfig.User = "tenant_test"
+	config.ConnConfig.Password = "tenant_test"
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	require.NoError(t, err)
+	defer pool.Close()
+	tx := storage.NewTxManager(pool, storage.TxOptions{RowLevelSecurity: true})
+
+	err = tx.InTx(storage.WithTenant(ctx, a), func(q storage.Querier) error {
+		members, err := q.ListOrganizationMembers(ctx, a)
+		require.NoError(t, err)
+		assert.Len(t, members, 1)
+		members, err = q.ListOrganizationMembers(ctx, b)
+		require.NoError(t, err)
+		assert.Empty(t, members, "members of another organization")
+
+		invitations, err := q.ListOrganizationInvitations(ctx, b)
+		require.NoError(t, err)
+		assert.Empty(t, invitations, "invitations of another organization")
+		_, err = q.GetInvitationByCode(ctx, "rls-b")
+		assert.ErrorIs(t, err, storage.ErrNoRows)
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = tx.InTx(storage.WithTenant(ctx, a), func(q storage.Querier) error {
+		_, err := q.AddOrganizationMember(ctx, storage.AddOrganizationMemberParams{OrganizationID: b, UserID: user.ID, Role: "member", Now: now})
+		return err
+	})
+	assert.Error(t, err, "joins another organization")
+
+	// a transaction that forgets its tenant sees nothing
+	err = tx.InTx(ctx, func(q storage.Querier) error {
+		members, err := q.ListOrganizationMembers(ctx, a)
+		require.NoError(t, err)
+		assert.Empty(t, members)
+		return nil
+	})
+	require.NoError(t, err)
+	members, err := storage.New(pool).ListOrganizationMembers(ctx, a)
+	require.NoError(t, err)
+	assert.Empty(t, members, "outside of a transaction")
+
+	err = tx.InTx(storage.WithAllTenants(ctx), func(q storage.Querier) error {
+		organizations, err := q.ListUserOrganizations(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Len(t, organizations, 2)
+		return nil
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,222 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"scratch/internal/authorization/session"
+	"scratch/internal/problem"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// APIKeyHeader carries the key of api clients.
+const APIKeyHeader = "X-API-Key"
+
+// Keys a Rule can tell clients apart by.
+const (
+	KeyIP     = "ip"
+	KeyUser   = "user"
+	KeyAPIKey = "api_key"
+)
+
+// Rule limits one operation of the api spec, e.g. "POST /login", per Key.
+type Rule struct {
+	Operation string
+	Key       string
+	Limit     Limit
+}
+
+type Options struct {
+	// TrustForwardedFor takes the client ip from the right-most
+	// X-Forwarded-For entry, see ClientIP.
+	TrustForwardedFor bool
+	// ExemptNetworks and ExemptAPIKeys identify trusted clients that are never limited.
+	ExemptNetworks []*net.IPNet
+	ExemptAPIKeys  []string
+	// Users resolves the user key from the bearer token, without it user rules are skipped.
+	Users session.UserIdentifier
+}
+
+// Limiter enforces the rules of the operation a request is routed to. Like
+// the validation middleware it runs inside the chi route of the operation.
+type Limiter struct {
+	store   Store
+	rules   map[string][]Rule
+	options Options
+	now     func() time.Time
+	log     slog.Logger
+}
+
+func New(store Store, rules []Rule, options Options, log slog.Logger) (*Limiter, error) {
+	l := &Limiter{store: store, rules: make(map[string][]Rule), options: options, now: time.Now, log: log}
+	for _, rule := range rules {
+		if err := rule.Limit.validate(); err != nil {
+			return nil, fmt.Errorf("rule %v: %w", rule.Operation, err)
+		}
+		switch rule.Key {
+		case KeyIP, KeyUser, KeyAPIKey:
+		default:
+			return nil, fmt.Errorf("rule %v: unknown key %q", rule.Operation, rule.Key)
+		}
+		l.rules[rule.Operation] = append(l.rules[rule.Operation], rule)
+	}
+	return l, nil
+}
+
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rules := l.rules[operation(r)]
+		if len(rules) == 0 || l.exempt(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var buckets []Bucket
+		for _, rule := range rules {
+			if key := l.key(r, rule.Key); key != "" {
+				buckets = append(buckets, Bucket{Key: rule.Operation + "|" + key, Limit: rule.Limit})
+			}
+		}
+		if len(buckets) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		var (
+			limiting *Result
+			denied   bool
+		)
+		// the rules are taken from together, a request denied by one of them
+		// does not use up the others
+		results, err := l.store.Take(r.Context(), buckets, l.now())
+		if err != nil {
+			// an unavailable store must not take the api down with it
+			l.log.Error("take rate limit", "operation", rules[0].Operation, "err", err)
+		}
+		for i := range results {
+			if !results[i].Allowed {
+				denied = true
+			}
+			if limiting == nil || moreLimiting(results[i], *limiting) {
+				limiting = &results[i]
+			}
+		}
+
+		if limiting != nil {
+			setHeaders(w.Header(), *limiting)
+		}
+		if denied {
+			w.Header().Set("Retry-After", strconv.Itoa(seconds(limiting.RetryAfter)))
+			problem.Write(w, r, problem.New(problem.RateLimited, ""))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func moreLimiting(a, b Result) bool {
+	if a.Allowed != b.Allowed {
+		return !a.Allowed
+	}
+	if !a.Allowed {
+		return a.RetryAfter > b.RetryAfter
+	}
+	return a.Remaining < b.Remaining
+}
+
+// setHeaders writes the RateLimit header fields of the IETF httpapi draft.
+func setHeaders(h http.Header, result Result) {
+	h.Set("RateLimit-Limit", strconv.Itoa(result.Limit.Requests))
+	h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(seconds(result.ResetAfter)))
+	h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", result.Limit.Requests, seconds(result.Limit.Window)))
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+func operation(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return ""
+	}
+	return r.Method + " " + rctx.RoutePattern()
+}
+
+func (l *Limiter) exempt(r *http.Request) bool {
+	if apiKey := r.Header.Get(APIKeyHeader); apiKey != "" {
+		for _, exempt := range l.options.ExemptAPIKeys {
+			if subtle.ConstantTimeCompare([]byte(apiKey), []byte(exempt)) == 1 {
+				return true
+			}
+		}
+	}
+	if len(l.options.ExemptNetworks) > 0 {
+		ip := net.ParseIP(ClientIP(r, l.options.TrustForwardedFor))
+		for _, network := range l.options.ExemptNetworks {
+			if ip != nil && network.Contains(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// key identifies the client of r, an empty key means the rule does not apply.
+func (l *Limiter) key(r *http.Request, kind string) string {
+	switch kind {
+	case KeyIP:
+		return "ip:" + ClientIP(r, l.options.TrustForwardedFor)
+	case KeyAPIKey:
+		apiKey := r.Header.Get(APIKeyHeader)
+		if apiKey == "" {
+			return ""
+		}
+		// keys are secrets, only their hash is stored
+		sum := sha256.Sum256([]byte(apiKey))
+		return "api_key:" + hex.EncodeToString(sum[:16])
+	case KeyUser:
+		authorization := r.Header.Get("Authorization")
+		if !strings.HasPrefix(authorization, "Bearer ") || l.options.Users == nil {
+			return ""
+		}
+		id, err := l.options.Users.UserID(strings.TrimPrefix(authorization, "Bearer "))
+		if err != nil {
+			return ""
+		}
+		return "user:" + id
+	}
+	return ""
+}
+
+// ClientIP returns the ip of the client. When trustForwardedFor is set and
+// the header is present it is the right-most X-Forwarded-For entry, the one
+// added by the proxy in front of us; the entries left of it come from the
+// client and anyone can put an ip there.
+func ClientIP(r *http.Request, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+			forwarded := values[len(values)-1]
+			if i := strings.LastIndex(forwarded, ","); i >= 0 {
+				forwarded = forwarded[i+1:]
+			}
+			if ip := strings.TrimSpace(forwarded); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
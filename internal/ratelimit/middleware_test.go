@@ -0,0 +1,214 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type users map[string]string
+
+func (u users) UserID(token string) (string, error) {
+	if id, ok := u[token]; ok {
+		return id, nil
+	}
+	return "", errors.New("invalid token")
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, buckets []Bucket, now time.Time) ([]Result, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (failingStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+func newRouter(t *testing.T, store Store, rules []Rule, options Options) http.Handler {
+	t.Helper()
+	limiter, err := New(store, rules, options, *slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.With(limiter.Middleware).Post("/login", func(w http.ResponseWriter, r *http.Request) {})
+	r.With(limiter.Middleware).Get("/user/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	return r
+}
+
+func TestLimiter_Middleware(t *testing.T) {
+	_, trusted, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+
+	rules := []Rule{
+		{Operation: "POST /login", Key: KeyIP, Limit: Limit{Requests: 2, Window: time.Minute}},
+		{Operation: "GET /user/{id}", Key: KeyUser, Limit: Limit{Requests: 1, Window: time.Minute}},
+		{Operation: "GET /user/{id}", Key: KeyAPIKey, Limit: Limit{Requests: 1, Window: time.Minute}},
+		{Operation: "GET /user/{id}", Key: KeyIP, Limit: Limit{Requests: 3, Window: time.Minute}},
+	}
+	options := Options{
+		ExemptNetworks: []*net.IPNet{trusted},
+		ExemptAPIKeys:  []string{"trusted-key"},
+		Users:          users{"token-1": "1", "token-2": "2"},
+	}
+
+	type request struct {
+		method string
+		path   string
+		header map[string]string
+		remote string
+	}
+	login := func(remote string) request {
+		return request{method: http.MethodPost, path: "/login", remote: remote}
+	}
+	getUser := func(header map[string]string) request {
+		return request{method: http.MethodGet, path: "/user/1", remote: "192.0.2.1:1234", header: header}
+	}
+
+	tests := []struct {
+		name     string
+		store    Store
+		options  Options
+		requests []request
+		// wantCodes has the status of every request
+		wantCodes []int
+	}{
+		{
+			name:      "per ip",
+			requests:  []request{login("192.0.2.1:1"), login("192.0.2.1:2"), login("192.0.2.1:3"), login("192.0.2.2:1")},
+			wantCodes: []int{200, 200, 429, 200},
+		},
+		{
+			name:      "forwarded for ignored unless trusted",
+			requests:  []request{login("192.0.2.1:1"), login("192.0.2.1:1"), {method: http.MethodPost, path: "/login", remote: "192.0.2.1:1", header: map[string]string{"X-Forwarded-For": "198.51.100.7"}}},
+			wantCodes: []int{200, 200, 429},
+		},
+		{
+			name:      "forwarded for trusted",
+			options:   Options{TrustForwardedFor: true},
+			requests:  []request{login("192.0.2.1:1"), login("192.0.2.1:1"), {method: http.MethodPost, path: "/login", remote: "192.0.2.1:1", header: map[string]string{"X-Forwarded-For": "198.51.100.7"}}},
+			wantCodes: []int{200, 200, 200},
+		},
+		{
+			name:    "forwarded for forged by the client",
+			options: Options{TrustForwardedFor: true},
+			requests: []request{
+				{method: http.MethodPost, path: "/login", remote: "192.0.2.1:1", header: map[string]string{"X-Forwarded-For": "10.1.2.3, 198.51.100.7"}},
+				{method: http.MethodPost, path: "/login", remote: "192.0.2.1:1", header: map[string]string{"X-Forwarded-For": "10.1.2.3, 198.51.100.7"}},
+				{method: http.MethodPost, path: "/login", remote: "192.0.2.1:1", header: map[string]string{"X-Forwarded-For": "10.1.2.3, 198.51.100.7"}},
+			},
+			wantCodes: []int{200, 200, 429},
+		},
+		{
+			name:      "exempt network",
+			requests:  []request{login("10.1.2.3:1"), login("10.1.2.3:1"), login("10.1.2.3:1")},
+			wantCodes: []int{200, 200, 200},
+		},
+		{
+			name: "per user",
+			requests: []request{
+				getUser(map[string]string{"Authorization": "Bearer token-1"}),
+				getUser(map[string]string{"Authorization": "Bearer token-1"}),
+				getUser(map[string]string{"Authorization": "Bearer token-2"}),
+				getUser(nil),
+			},
+			wantCodes: []int{200, 429, 200, 200},
+		},
+		{
+			name: "per api key and exempt api key",
+			requests: []request{
+				getUser(map[string]string{APIKeyHeader: "key"}),
+				getUser(map[string]string{APIKeyHeader: "key"}),
+				getUser(map[string]string{APIKeyHeader: "trusted-key"}),
+				getUser(map[string]string{APIKeyHeader: "trusted-key"}),
+			},
+			wantCodes: []int{200, 429, 200, 200},
+		},
+		{
+			name: "a request denied by one rule does not use up the others",
+			requests: []request{
+				getUser(map[string]string{"Authorization": "Bearer token-1"}),
+				getUser(map[string]string{"Authorization": "Bearer token-1"}),
+				getUser(map[string]string{"Authorization": "Bearer token-1"}),
+				getUser(map[string]string{"Authorization": "Bearer token-2"}),
+				getUser(map[string]string{"Authorization": "Bearer token-2"}),
+			},
+			wantCodes: []int{200, 429, 429, 200, 429},
+		},
+		{
+			name:      "store failure lets requests through",
+			store:     failingStore{},
+			requests:  []request{login("192.0.2.1:1"), login("192.0.2.1:1"), login("192.0.2.1:1")},
+			wantCodes: []int{200, 200, 200},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := tt.store
+			if store == nil {
+				store = NewMemoryStore()
+			}
+			opts := options
+			opts.TrustForwardedFor = tt.options.TrustForwardedFor
+			router := newRouter(t, store, rules, opts)
+
+			var codes []int
+			for _, req := range tt.requests {
+				r := httptest.NewRequest(req.method, req.path, nil)
+				r.RemoteAddr = req.remote
+				for k, v := range req.header {
+					r.Header.Set(k, v)
+				}
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, r)
+				codes = append(codes, w.Code)
+			}
+			assert.Equal(t, tt.wantCodes, codes)
+		})
+	}
+}
+
+func TestLimiter_Headers(t *testing.T) {
+	router := newRouter(t, NewMemoryStore(), []Rule{
+		{Operation: "POST /login", Key: KeyIP, Limit: Limit{Requests: 1, Window: time.Minute}},
+	}, Options{})
+
+	send := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/login", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	allowed := send()
+	assert.Equal(t, http.StatusOK, allowed.Code)
+	assert.Equal(t, "1", allowed.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", allowed.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", allowed.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "1;w=60", allowed.Header().Get("RateLimit-Policy"))
+	assert.Empty(t, allowed.Header().Get("Retry-After"))
+
+	denied := send()
+	assert.Equal(t, http.StatusTooManyRequests, denied.Code)
+	assert.Equal(t, "application/problem+json", denied.Header().Get("Content-Type"))
+	assert.Equal(t, "60", denied.Header().Get("Retry-After"))
+	assert.Contains(t, denied.Body.String(), `"type":"/problems/rate-limited"`)
+}
+
+func TestNew_InvalidRule(t *testing.T) {
+	_, err := New(NewMemoryStore(), []Rule{{Operation: "POST /login", Key: "cookie", Limit: Limit{Requests: 1, Window: time.Second}}}, Options{}, slog.Logger{})
+	assert.ErrorContains(t, err, `unknown key "cookie"`)
+
+	_, err = New(NewMemoryStore(), []Rule{{Operation: "POST /login", Key: KeyIP}}, Options{}, slog.Logger{})
+	assert.ErrorIs(t, err, ErrInvalidLimit)
+}
@@ -0,0 +1,114 @@
+// Package ratelimit throttles api operations with a token bucket per client.
+//
+// Buckets follow the generic cell rate algorithm: instead of counting tokens
+// a bucket stores the theoretical arrival time (TAT) of the next request, so a
+// single timestamp per key is enough and both stores update it atomically.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrInvalidLimit = errors.New("rate limit must allow a positive number of requests per positive window")
+
+// Limit allows Requests per Window, all of which may come in a single burst.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+func (l Limit) validate() error {
+	if l.Requests <= 0 || l.Window <= 0 {
+		return ErrInvalidLimit
+	}
+	return nil
+}
+
+// interval is the time it takes to earn back one request.
+func (l Limit) interval() time.Duration {
+	return l.Window / time.Duration(l.Requests)
+}
+
+// Bucket is the bucket of Key, refilled at the rate of Limit.
+type Bucket struct {
+	Key   string
+	Limit Limit
+}
+
+// Result is the state of a bucket after taking one request from it.
+type Result struct {
+	Allowed   bool
+	Limit     Limit
+	Remaining int
+	// ResetAfter is how long until the bucket is full again.
+	ResetAfter time.Duration
+	// RetryAfter is how long to wait before a denied request can succeed.
+	RetryAfter time.Duration
+}
+
+// Store keeps the buckets.
+type Store interface {
+	// Take spends one request of every bucket at now, or of none of them
+	// when one denies it, and returns their results in order.
+	Take(ctx context.Context, buckets []Bucket, now time.Time) ([]Result, error)
+	// DeleteExpired forgets buckets that are full again at now.
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// take applies one request at now to a bucket whose theoretical arrival time
+// is tat and returns the new tat with the result.
+func take(tat, now time.Time, limit Limit) (time.Time, Result) {
+	interval := limit.interval()
+	if tat.Before(now) {
+		tat = now
+	}
+	newTAT := tat.Add(interval)
+	allowAt := newTAT.Add(-limit.Window)
+
+	if now.Before(allowAt) {
+		return tat, Result{
+			Limit:      limit,
+			ResetAfter: tat.Sub(now),
+			RetryAfter: allowAt.Sub(now),
+		}
+	}
+	return newTAT, Result{
+		Allowed:    true,
+		Limit:      limit,
+		Remaining:  int(now.Sub(allowAt) / interval),
+		ResetAfter: newTAT.Sub(now),
+	}
+}
+
+// takeAll applies one request at now to every bucket, whose theoretical
+// arrival times are in tats, and updates tats only when every bucket allows
+// the request. Buckets of the same key are applied one after the other.
+func takeAll(tats map[string]time.Time, buckets []Bucket, now time.Time) ([]Result, bool) {
+	next := make(map[string]time.Time, len(buckets))
+	for _, b := range buckets {
+		next[b.Key] = tats[b.Key]
+	}
+	results := make([]Result, len(buckets))
+	allowed := true
+	for i, b := range buckets {
+		next[b.Key], results[i] = take(next[b.Key], now, b.Limit)
+		allowed = allowed && results[i].Allowed
+	}
+	if allowed {
+		for key, tat := range next {
+			tats[key] = tat
+		}
+	}
+	return results, allowed
+}
+
+func validate(buckets []Bucket) error {
+	for _, b := range buckets {
+		if err := b.Limit.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_Take(t *testing.T) {
+	limit := Limit{Requests: 3, Window: 3 * time.Second}
+	start := time.Date(2024, 2, 14, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		at   time.Duration
+		want Result
+	}{
+		{"first request", 0, Result{Allowed: true, Limit: limit, Remaining: 2, ResetAfter: time.Second}},
+		{"burst", 0, Result{Allowed: true, Limit: limit, Remaining: 1, ResetAfter: 2 * time.Second}},
+		{"bucket empty", 0, Result{Allowed: true, Limit: limit, Remaining: 0, ResetAfter: 3 * time.Second}},
+		{"denied", 0, Result{Limit: limit, ResetAfter: 3 * time.Second, RetryAfter: time.Second}},
+		{"still denied", 500 * time.Millisecond, Result{Limit: limit, ResetAfter: 2500 * time.Millisecond, RetryAfter: 500 * time.Millisecond}},
+		{"one request earned back", time.Second, Result{Allowed: true, Limit: limit, Remaining: 0, ResetAfter: 3 * time.Second}},
+		{"full again", 10 * time.Second, Result{Allowed: true, Limit: limit, Remaining: 2, ResetAfter: time.Second}},
+	}
+
+	// the cases share one bucket and run in order
+	store := NewMemoryStore()
+	for _, tt := range tests {
+		got, err := store.Take(context.Background(), []Bucket{{Key: "key", Limit: limit}}, start.Add(tt.at))
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, []Result{tt.want}, got, tt.name)
+	}
+}
+
+func TestMemoryStore_KeysAreIndependent(t *testing.T) {
+	limit := Limit{Requests: 1, Window: time.Minute}
+	now := time.Now()
+	store := NewMemoryStore()
+
+	first, err := store.Take(context.Background(), []Bucket{{Key: "a", Limit: limit}}, now)
+	require.NoError(t, err)
+	other, err := store.Take(context.Background(), []Bucket{{Key: "b", Limit: limit}}, now)
+	require.NoError(t, err)
+	again, err := store.Take(context.Background(), []Bucket{{Key: "a", Limit: limit}}, now)
+	require.NoError(t, err)
+
+	assert.True(t, first[0].Allowed)
+	assert.True(t, other[0].Allowed)
+	assert.False(t, again[0].Allowed)
+}
+
+func TestMemoryStore_TakeAllOrNothing(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	user := Bucket{Key: "user", Limit: Limit{Requests: 1, Window: time.Minute}}
+	ip := Bucket{Key: "ip", Limit: Limit{Requests: 2, Window: time.Minute}}
+
+	got, err := store.Take(context.Background(), []Bucket{user, ip}, now)
+	require.NoError(t, err)
+	assert.True(t, got[0].Allowed)
+	assert.True(t, got[1].Allowed)
+
+	// the user bucket is empty, the ip bucket keeps its last request
+	for i := 0; i < 3; i++ {
+		got, err = store.Take(context.Background(), []Bucket{user, ip}, now)
+		require.NoError(t, err)
+		assert.False(t, got[0].Allowed)
+		assert.True(t, got[1].Allowed)
+	}
+	got, err = store.Take(context.Background(), []Bucket{ip}, now)
+	require.NoError(t, err)
+	assert.True(t, got[0].Allowed)
+}
+
+func TestMemoryStore_DeleteExpired(t *testing.T) {
+	limit := Limit{Requests: 2, Window: time.Minute}
+	now := time.Now()
+	store := NewMemoryStore()
+
+	_, err := store.Take(context.Background(), []Bucket{{Key: "old", Limit: limit}}, now.Add(-time.Hour))
+	require.NoError(t, err)
+	_, err = store.Take(context.Background(), []Bucket{{Key: "recent", Limit: limit}}, now)
+	require.NoError(t, err)
+
+	n, err := store.DeleteExpired(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	assert.Len(t, store.tats, 1)
+}
+
+func TestMemoryStore_InvalidLimit(t *testing.T) {
+	_, err := NewMemoryStore().Take(context.Background(), []Bucket{{Key: "key"}}, time.Now())
+	assert.ErrorIs(t, err, ErrInvalidLimit)
+}
@@ -0,0 +1,135 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	db "scratch/internal/storage/database"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// MemoryStore keeps buckets in the process, limits are per instance.
+type MemoryStore struct {
+	mu   sync.Mutex
+	tats map[string]time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tats: make(map[string]time.Time)}
+}
+
+func (m *MemoryStore) Take(ctx context.Context, buckets []Bucket, now time.Time) ([]Result, error) {
+	if err := validate(buckets); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	results, _ := takeAll(m.tats, buckets, now)
+	return results, nil
+}
+
+func (m *MemoryStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	for key, tat := range m.tats {
+		if tat.Before(now) {
+			delete(m.tats, key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// PostgresStore keeps buckets in scratch.rate_limit, so every instance of the
+// application shares the same limits.
+type PostgresStore struct {
+	database *pgxpool.Pool
+	queries  *db.Queries
+}
+
+func NewPostgresStore(database *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{database: database, queries: db.New(database)}
+}
+
+func (p *PostgresStore) Take(ctx context.Context, buckets []Bucket, now time.Time) ([]Result, error) {
+	if err := validate(buckets); err != nil {
+		return nil, err
+	}
+
+	tx, err := p.database.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	queries := p.queries.WithTx(tx)
+
+	// concurrent requests lock the rows of their buckets in the same order,
+	// so they can not deadlock
+	keys := make([]string, 0, len(buckets))
+	for _, b := range buckets {
+		keys = append(keys, b.Key)
+	}
+	sort.Strings(keys)
+	tats := make(map[string]time.Time, len(keys))
+	for _, key := range keys {
+		if _, ok := tats[key]; ok {
+			continue
+		}
+		// the row is created first so that concurrent requests of a new key
+		// serialize on its lock instead of racing to insert it
+		if err := queries.EnsureRateLimit(ctx, db.EnsureRateLimitParams{Key: key, Tat: now}); err != nil {
+			return nil, fmt.Errorf("create rate limit: %w", err)
+		}
+		tat, err := queries.LockRateLimit(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("lock rate limit: %w", err)
+		}
+		tats[key] = tat
+	}
+
+	results, allowed := takeAll(tats, buckets, now)
+	if allowed {
+		for _, key := range keys {
+			if err := queries.UpdateRateLimit(ctx, db.UpdateRateLimitParams{Key: key, Tat: tats[key]}); err != nil {
+				return nil, fmt.Errorf("update rate limit: %w", err)
+			}
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit rate limit: %w", err)
+	}
+	return results, nil
+}
+
+func (p *PostgresStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	n, err := p.queries.DeleteExpiredRateLimits(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("delete expired rate limits: %w", err)
+	}
+	return n, nil
+}
+
+// Cleanup deletes expired buckets every interval until ctx is done.
+func Cleanup(ctx context.Context, store Store, interval time.Duration, log slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := store.DeleteExpired(ctx, now); err != nil {
+				log.Warn("delete expired rate limits", "err", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package secrets
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const keyEnv = "CHATTO_SECRETS_KEY"
+
+const commandUsage = `usage: secrets <command> [flags]
+
+commands:
+  keygen   print a new random key
+  encrypt  encrypt a JSON object of secrets
+  decrypt  print the decrypted JSON object of secrets
+
+the key is read from --key-file or the CHATTO_SECRETS_KEY environment variable`
+
+// RunCommand implements the `secrets` command line tool used to manage the
+// encrypted secrets file.
+func RunCommand(args []string, stdin io.Reader, stdout io.Writer, lookupEnv func(string) (string, bool)) error {
+	if len(args) == 0 {
+		return errors.New(commandUsage)
+	}
+
+	switch args[0] {
+	case "keygen":
+		key, err := GenerateKey()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(stdout, key.String())
+		return err
+	case "encrypt", "decrypt":
+	default:
+		return fmt.Errorf("unknown command %q\n%v", args[0], commandUsage)
+	}
+
+	fs := flag.NewFlagSet("secrets "+args[0], flag.ContinueOnError)
+	keyFile := fs.String("key-file", "", "file holding the base64 encoded key")
+	in := fs.String("in", "-", "input file, - for stdin")
+	out := fs.String("out", "-", "output file, - for stdout")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	key, err := readKey(*keyFile, lookupEnv)
+	if err != nil {
+		return err
+	}
+
+	var input []byte
+	if *in == "-" {
+		input, err = io.ReadAll(stdin)
+	} else {
+		input, err = os.ReadFile(*in)
+	}
+	if err != nil {
+		return fmt.Errorf("read input: %w", err)
+	}
+
+	var output []byte
+	if args[0] == "encrypt" {
+		output, err = Encrypt(key, input)
+	} else {
+		output, err = Decrypt(key, input)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *out == "-" {
+		_, err = stdout.Write(output)
+		return err
+	}
+	if err := os.WriteFile(*out, output, 0o600); err != nil {
+		return fmt.Errorf("write output: %w", err)
+	}
+	return nil
+}
+
+// readKey loads the encryption key from keyFile, falling back to CHATTO_SECRETS_KEY.
+func readKey(keyFile string, lookupEnv func(string) (string, bool)) (Key, error) {
+	if keyFile != "" {
+		content, err := os.ReadFile(keyFile)
+		if err != nil {
+			return Key{}, fmt.Errorf("read key file: %w", err)
+		}
+		return ParseKey(string(content))
+	}
+	if v, ok := lookupEnv(keyEnv); ok && v != "" {
+		return ParseKey(v)
+	}
+	return Key{}, fmt.Errorf("no key: pass --key-file or set %v", keyEnv)
+}
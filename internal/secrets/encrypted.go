@@ -0,0 +1,159 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+const (
+	encryptedHeader = "chatto-secrets:v1"
+	KeySize         = 32
+	nonceSize       = 24
+)
+
+var ErrDecrypt = errors.New("decrypt secrets: wrong key or corrupted file")
+
+// Key is the symmetric NaCl secretbox key protecting an encrypted secrets file.
+type Key [KeySize]byte
+
+func GenerateKey() (Key, error) {
+	var k Key
+	if _, err := io.ReadFull(rand.Reader, k[:]); err != nil {
+		return Key{}, fmt.Errorf("generate key: %w", err)
+	}
+	return k, nil
+}
+
+// ParseKey decodes a base64 encoded key.
+func ParseKey(s string) (Key, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return Key{}, fmt.Errorf("decode key: %w", err)
+	}
+	if len(raw) != KeySize {
+		return Key{}, fmt.Errorf("key must be %d bytes, got %d", KeySize, len(raw))
+	}
+	var k Key
+	copy(k[:], raw)
+	return k, nil
+}
+
+func (k Key) String() string {
+	return base64.StdEncoding.EncodeToString(k[:])
+}
+
+// Encrypt seals a JSON object of secret name to value.
+func Encrypt(key Key, plaintext []byte) ([]byte, error) {
+	var values map[string]string
+	if err := json.Unmarshal(plaintext, &values); err != nil {
+		return nil, fmt.Errorf("secrets must be a JSON object of strings: %w", err)
+	}
+
+	var nonce [nonceSize]byte
+	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	k := [KeySize]byte(key)
+	sealed := secretbox.Seal(nonce[:], plaintext, &nonce, &k)
+
+	var out bytes.Buffer
+	out.WriteString(encryptedHeader + "\n")
+	out.WriteString(base64.StdEncoding.EncodeToString(sealed))
+	out.WriteString("\n")
+	return out.Bytes(), nil
+}
+
+// Decrypt opens a file produced by Encrypt and returns the JSON document.
+func Decrypt(key Key, content []byte) ([]byte, error) {
+	header, body, ok := strings.Cut(string(content), "\n")
+	if !ok || header != encryptedHeader {
+		return nil, fmt.Errorf("not a chatto secrets file")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(body))
+	if err != nil {
+		return nil, fmt.Errorf("decode secrets file: %w", err)
+	}
+	if len(sealed) < nonceSize+secretbox.Overhead {
+		return nil, ErrDecrypt
+	}
+
+	var nonce [nonceSize]byte
+	copy(nonce[:], sealed[:nonceSize])
+	k := [KeySize]byte(key)
+	plaintext, ok := secretbox.Open(nil, sealed[nonceSize:], &nonce, &k)
+	if !ok {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// EncryptedFileProvider reads secrets from a local file encrypted with Encrypt.
+// The file is read on every Get, so replacing it is picked up by the Reloader.
+type EncryptedFileProvider struct {
+	path string
+	key  Key
+}
+
+func NewEncryptedFileProvider(path string, key Key) *EncryptedFileProvider {
+	return &EncryptedFileProvider{path: path, key: key}
+}
+
+func (e *EncryptedFileProvider) Get(ctx context.Context, name string) ([]byte, error) {
+	values, err := e.read()
+	if err != nil {
+		return nil, err
+	}
+	v, ok := values[name]
+	if !ok {
+		return nil, fmt.Errorf("%v in %v: %w", name, e.path, ErrNotFound)
+	}
+	return []byte(v), nil
+}
+
+// Set re-encrypts the file with name set to value, creating the file if needed.
+func (e *EncryptedFileProvider) Set(ctx context.Context, name string, value []byte) error {
+	values, err := e.read()
+	if errors.Is(err, os.ErrNotExist) {
+		values = make(map[string]string)
+	} else if err != nil {
+		return err
+	}
+	values[name] = string(value)
+
+	plaintext, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("encode secrets: %w", err)
+	}
+	sealed, err := Encrypt(e.key, plaintext)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(e.path, sealed)
+}
+
+func (e *EncryptedFileProvider) read() (map[string]string, error) {
+	content, err := os.ReadFile(e.path)
+	if err != nil {
+		return nil, fmt.Errorf("read secrets file: %w", err)
+	}
+	plaintext, err := Decrypt(e.key, content)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]string
+	if err := json.Unmarshal(plaintext, &values); err != nil {
+		return nil, fmt.Errorf("decode secrets: %w", err)
+	}
+	return values, nil
+}
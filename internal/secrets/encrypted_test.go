@@ -0,0 +1,94 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	key, err := GenerateKey()
+	require.NoError(t, err)
+	otherKey, err := GenerateKey()
+	require.NoError(t, err)
+
+	plaintext := []byte(`{"jwt_secret":"YELLOWXSUBMARINEBBBLACKXWIZARDRY"}`)
+	sealed, err := Encrypt(key, plaintext)
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), "YELLOW")
+
+	opened, err := Decrypt(key, sealed)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, opened)
+
+	_, err = Decrypt(otherKey, sealed)
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = Encrypt(key, []byte("not json"))
+	assert.Error(t, err)
+}
+
+func TestEncryptedFileProvider_Get(t *testing.T) {
+	key, err := GenerateKey()
+	require.NoError(t, err)
+	sealed, err := Encrypt(key, []byte(`{"jwt_secret":"encrypted-value"}`))
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	require.NoError(t, os.WriteFile(path, sealed, 0o600))
+
+	p := NewEncryptedFileProvider(path, key)
+
+	got, err := p.Get(context.Background(), JWTSecret)
+	require.NoError(t, err)
+	assert.Equal(t, "encrypted-value", string(got))
+
+	_, err = p.Get(context.Background(), PasetoSecret)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestRunCommand(t *testing.T) {
+	var keyOut bytes.Buffer
+	require.NoError(t, RunCommand([]string{"keygen"}, nil, &keyOut, nil))
+	key := strings.TrimSpace(keyOut.String())
+	_, err := ParseKey(key)
+	require.NoError(t, err)
+
+	env := func(k string) (string, bool) { return key, k == keyEnv }
+	encPath := filepath.Join(t.TempDir(), "secrets.enc")
+
+	err = RunCommand([]string{"encrypt", "--out", encPath}, strings.NewReader(`{"jwt_secret":"abc"}`), nil, env)
+	require.NoError(t, err)
+
+	var decrypted bytes.Buffer
+	err = RunCommand([]string{"decrypt", "--in", encPath}, nil, &decrypted, env)
+	require.NoError(t, err)
+	assert.Equal(t, `{"jwt_secret":"abc"}`, decrypted.String())
+
+	err = RunCommand([]string{"decrypt", "--in", encPath}, nil, &decrypted, func(string) (string, bool) { return "", false })
+	assert.ErrorContains(t, err, "no key")
+}
+
+func TestEncryptedFileProvider_Set(t *testing.T) {
+	key, err := GenerateKey()
+	require.NoError(t, err)
+	p := NewEncryptedFileProvider(filepath.Join(t.TempDir(), "secrets.enc"), key)
+
+	require.NoError(t, p.Set(context.Background(), JWTSecret, []byte("first")))
+	require.NoError(t, p.Set(context.Background(), PasetoSecret, []byte("second")))
+	require.NoError(t, p.Set(context.Background(), JWTSecret, []byte("rotated")))
+
+	got, err := p.Get(context.Background(), JWTSecret)
+	require.NoError(t, err)
+	assert.Equal(t, "rotated", string(got))
+
+	got, err = p.Get(context.Background(), PasetoSecret)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(got))
+}
@@ -0,0 +1,146 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Value holds the current and the previous content of a secret, so tokens
+// signed just before a rotation still validate. The previous content is the
+// one stored under the Previous name when there is one, the content replaced
+// by the last reload otherwise. It is safe for concurrent use.
+type Value struct {
+	mu       sync.RWMutex
+	current  []byte
+	previous []byte
+}
+
+func NewValue(current []byte) *Value {
+	return &Value{current: current}
+}
+
+// Keys returns the current secret followed by the previous one, if any.
+func (v *Value) Keys() [][]byte {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.previous == nil {
+		return [][]byte{v.current}
+	}
+	return [][]byte{v.current, v.previous}
+}
+
+// set rotates the value to current and reports whether it changed. A nil
+// previous keeps the previous content, or makes it the replaced one when
+// current is new.
+func (v *Value) set(current, previous []byte) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	rotated := !bytes.Equal(v.current, current)
+	if previous == nil {
+		previous = v.previous
+		if rotated {
+			previous = v.current
+		}
+	}
+	if bytes.Equal(previous, current) {
+		previous = nil
+	}
+	changed := rotated || !bytes.Equal(v.previous, previous)
+	v.current, v.previous = current, previous
+	return changed
+}
+
+// Reloader keeps tracked secrets in sync with a Provider, either periodically
+// from Run or on demand from Reload (e.g. on SIGHUP).
+type Reloader struct {
+	provider Provider
+	interval time.Duration
+	log      slog.Logger
+
+	mu     sync.Mutex
+	values map[string]*Value
+}
+
+func NewReloader(provider Provider, interval time.Duration, log slog.Logger) *Reloader {
+	return &Reloader{provider: provider, interval: interval, log: log, values: make(map[string]*Value)}
+}
+
+// Track loads the secret and keeps it updated for as long as the Reloader runs.
+func (r *Reloader) Track(ctx context.Context, name string) (*Value, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if v, ok := r.values[name]; ok {
+		return v, nil
+	}
+	secret, err := r.provider.Get(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("load secret %v: %w", name, err)
+	}
+	previous, err := r.previous(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	v := NewValue(secret)
+	v.set(secret, previous)
+	r.values[name] = v
+	return v, nil
+}
+
+// previous loads the Previous secret of name, nil when there is none.
+func (r *Reloader) previous(ctx context.Context, name string) ([]byte, error) {
+	previous, err := r.provider.Get(ctx, Previous(name))
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load secret %v: %w", Previous(name), err)
+	}
+	return previous, nil
+}
+
+// Reload fetches every tracked secret again. A secret that fails to load keeps
+// its last known value.
+func (r *Reloader) Reload(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for name, v := range r.values {
+		secret, err := r.provider.Get(ctx, name)
+		if err != nil {
+			r.log.Warn("reload secret", "name", name, "err", err)
+			continue
+		}
+		previous, err := r.previous(ctx, name)
+		if err != nil {
+			r.log.Warn("reload secret", "name", Previous(name), "err", err)
+			continue
+		}
+		if v.set(secret, previous) {
+			r.log.Info("secret rotated", "name", name)
+		}
+	}
+}
+
+// Run reloads secrets every interval until ctx is done.
+func (r *Reloader) Run(ctx context.Context) {
+	if r.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Reload(ctx)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package secrets
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mapProvider map[string]string
+
+func (m mapProvider) Get(ctx context.Context, name string) ([]byte, error) {
+	v, ok := m[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return []byte(v), nil
+}
+
+func TestReloader(t *testing.T) {
+	provider := mapProvider{JWTSecret: "first"}
+	r := NewReloader(provider, 0, *slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	v, err := r.Track(context.Background(), JWTSecret)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("first")}, v.Keys())
+
+	provider[JWTSecret] = "second"
+	r.Reload(context.Background())
+	assert.Equal(t, [][]byte{[]byte("second"), []byte("first")}, v.Keys())
+
+	delete(provider, JWTSecret)
+	r.Reload(context.Background())
+	assert.Equal(t, [][]byte{[]byte("second"), []byte("first")}, v.Keys(), "keeps last known value")
+
+	_, err = r.Track(context.Background(), PasetoSecret)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestReloader_StoredPrevious(t *testing.T) {
+	provider := mapProvider{JWTSecret: "second", Previous(JWTSecret): "first"}
+	r := NewReloader(provider, 0, *slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	v, err := r.Track(context.Background(), JWTSecret)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("second"), []byte("first")}, v.Keys(), "previous key loaded at start")
+
+	provider[Previous(JWTSecret)] = "second"
+	r.Reload(context.Background())
+	assert.Equal(t, [][]byte{[]byte("second")}, v.Keys(), "halfway through a rotation")
+
+	provider[JWTSecret] = "third"
+	r.Reload(context.Background())
+	assert.Equal(t, [][]byte{[]byte("third"), []byte("second")}, v.Keys())
+}
@@ -0,0 +1,123 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Names of the secrets used by the application.
+const (
+	JWTSecret        = "jwt_secret"
+	PasetoSecret     = "paseto_secret"
+	DatabasePassword = "database_password"
+)
+
+var ErrNotFound = errors.New("secret not found")
+
+// Previous names the secret a rotation keeps the replaced value of name in, so
+// that it is still accepted after a restart.
+func Previous(name string) string {
+	return name + "_previous"
+}
+
+// Provider returns the current value of a named secret.
+type Provider interface {
+	Get(ctx context.Context, name string) ([]byte, error)
+}
+
+// Writer stores a new value of a secret, used to rotate keys.
+type Writer interface {
+	Set(ctx context.Context, name string, value []byte) error
+}
+
+// EnvProvider reads secrets from environment variables named prefix + NAME.
+type EnvProvider struct {
+	prefix    string
+	lookupEnv func(string) (string, bool)
+}
+
+func NewEnvProvider(prefix string, lookupEnv func(string) (string, bool)) *EnvProvider {
+	return &EnvProvider{prefix: prefix, lookupEnv: lookupEnv}
+}
+
+func (e *EnvProvider) Get(ctx context.Context, name string) ([]byte, error) {
+	key := e.prefix + strings.ToUpper(name)
+	v, ok := e.lookupEnv(key)
+	if !ok || v == "" {
+		return nil, fmt.Errorf("env %v: %w", key, ErrNotFound)
+	}
+	return []byte(v), nil
+}
+
+// FileProvider reads secrets from files in a directory, one secret per file,
+// which is how Docker (/run/secrets) and Kubernetes volume mounts expose them.
+type FileProvider struct {
+	dir string
+}
+
+func NewFileProvider(dir string) *FileProvider {
+	return &FileProvider{dir: dir}
+}
+
+func (f *FileProvider) Get(ctx context.Context, name string) ([]byte, error) {
+	if name != filepath.Base(name) {
+		return nil, fmt.Errorf("invalid secret name %q", name)
+	}
+	content, err := os.ReadFile(filepath.Join(f.dir, name))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("file %v: %w", name, ErrNotFound)
+		}
+		return nil, fmt.Errorf("read secret file: %w", err)
+	}
+	// editors and `echo` usually leave a trailing newline which is never part of the secret
+	return []byte(strings.TrimRight(string(content), "\r\n")), nil
+}
+
+func (f *FileProvider) Set(ctx context.Context, name string, value []byte) error {
+	if name != filepath.Base(name) {
+		return fmt.Errorf("invalid secret name %q", name)
+	}
+	return writeFileAtomic(filepath.Join(f.dir, name), value)
+}
+
+// writeFileAtomic replaces path in a single rename, so readers never see a partial secret.
+func writeFileAtomic(path string, content []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temporary file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replace %v: %w", path, err)
+	}
+	return nil
+}
+
+// Chain asks every provider in order and returns the first secret found.
+type Chain []Provider
+
+func (c Chain) Get(ctx context.Context, name string) ([]byte, error) {
+	for _, p := range c {
+		v, err := p.Get(ctx, name)
+		if err == nil {
+			return v, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("%v: %w", name, ErrNotFound)
+}
@@ -0,0 +1,56 @@
+package secrets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviders_Get(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, JWTSecret), []byte("from-file\n"), 0o600))
+
+	env := NewEnvProvider("CHATTO_SECRET_", func(key string) (string, bool) {
+		if key == "CHATTO_SECRET_PASETO_SECRET" {
+			return "from-env", true
+		}
+		return "", false
+	})
+	files := NewFileProvider(dir)
+
+	tests := []struct {
+		name       string
+		provider   Provider
+		secret     string
+		want       string
+		wantErr    error
+		wantAnyErr bool
+	}{
+		{name: "env - found", provider: env, secret: PasetoSecret, want: "from-env"},
+		{name: "env - missing", provider: env, secret: JWTSecret, wantErr: ErrNotFound},
+		{name: "file - found and trimmed", provider: files, secret: JWTSecret, want: "from-file"},
+		{name: "file - missing", provider: files, secret: PasetoSecret, wantErr: ErrNotFound},
+		{name: "file - path traversal", provider: files, secret: "../jwt_secret", wantAnyErr: true},
+		{name: "chain - first provider wins", provider: Chain{files, env}, secret: JWTSecret, want: "from-file"},
+		{name: "chain - falls through", provider: Chain{files, env}, secret: PasetoSecret, want: "from-env"},
+		{name: "chain - missing everywhere", provider: Chain{files, env}, secret: DatabasePassword, wantErr: ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.provider.Get(context.Background(), tt.secret)
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.wantAnyErr:
+				assert.Error(t, err)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, string(got))
+			}
+		})
+	}
+}
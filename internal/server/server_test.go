@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Serve(t *testing.T) {
+	t.Run("drains in-flight requests on shutdown", func(t *testing.T) {
+		started := make(chan struct{})
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			time.Sleep(200 * time.Millisecond)
+			w.WriteHeader(http.StatusOK)
+		})
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		config := DefaultConfig()
+		config.ShutdownTimeout = 5 * time.Second
+		s := New(config, handler, *slog.New(slog.NewTextHandler(io.Discard, nil)))
+		drained := false
+		s.OnDrain(func() { drained = true })
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		served := make(chan error, 1)
+		go func() { served <- s.Serve(ctx, ln) }()
+
+		responded := make(chan int, 1)
+		go func() {
+			res, err := http.Get(fmt.Sprintf("http://%v/", ln.Addr()))
+			if err != nil {
+				responded <- 0
+				return
+			}
+			res.Body.Close()
+			responded <- res.StatusCode
+		}()
+
+		<-started
+		cancel()
+
+		assert.Equal(t, http.StatusOK, <-responded)
+		assert.NoError(t, <-served)
+		assert.True(t, drained)
+	})
+
+	t.Run("returns listener errors", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		require.NoError(t, ln.Close())
+
+		s := New(DefaultConfig(), http.NotFoundHandler(), *slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+		err = s.Serve(context.Background(), ln)
+		assert.Error(t, err)
+	})
+}
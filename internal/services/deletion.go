@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"scratch/api"
+	"scratch/internal/events"
+	"scratch/internal/privacy"
+	db "scratch/internal/storage/database"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultDeletionGracePeriod is how long a deleted account can be restored,
+// unless WithDeletionGracePeriod says otherwise.
+const DefaultDeletionGracePeriod = 30 * 24 * time.Hour
+
+// UserPurge hard deletes the accounts deleted longer than the grace period ago.
+type UserPurge struct{}
+
+func (UserPurge) Kind() string { return "user_purge" }
+
+// WithDeletionGracePeriod returns a copy of the service keeping deleted
+// accounts restorable for grace.
+func (a *AccountService) WithDeletionGracePeriod(grace time.Duration) *AccountService {
+	c := *a
+	c.deletionGrace = grace
+	return &c
+}
+
+// DeleteAccount deletes the account of the user once the password is
+// confirmed, and ends all of its sessions. Access tokens already issued stay
+// valid until they expire. The account can be restored until the returned
+// time, after which it is purged.
+func (a *AccountService) DeleteAccount(ctx context.Context, userID int, password string) (time.Time, error) {
+	user, err := a.getUserByID(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return time.Time{}, IncorrectPasswordErr
+	}
+
+	now := time.Now()
+	err = a.inTx(ctx, func(q db.Querier) error {
+		n, err := q.DeleteUser(ctx, db.DeleteUserParams{ID: user.ID, Now: now})
+		if err != nil {
+			return fmt.Errorf("delete user: %w", err)
+		}
+		// a concurrent request deleted it first
+		if n == 0 {
+			return UserNotFoundErr
+		}
+		if _, err := q.DeleteUserSessions(ctx, user.ID); err != nil {
+			return fmt.Errorf("delete user sessions: %w", err)
+		}
+		// a pending download should not outlive the account
+		if _, err := q.DeleteDataExport(ctx, user.ID); err != nil {
+			return fmt.Errorf("delete data export: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return now.Add(a.deletionGrace), nil
+}
+
+// RestoreAccount undoes the deletion of the account the credentials belong
+// to, while it is within the grace period. The user logs in again afterwards.
+func (a *AccountService) RestoreAccount(ctx context.Context, model api.RestoreAccountRequest) error {
+	user, err := a.db.GetDeletedUserByEmail(ctx, model.Email)
+	if err != nil {
+		if errors.Is(err, db.ErrNoRows) {
+			return UserNotFoundErr
+		}
+		return fmt.Errorf("get deleted user: %w", err)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(model.Password)); err != nil {
+		return IncorrectPasswordErr
+	}
+
+	n, err := a.db.RestoreUser(ctx, db.RestoreUserParams{ID: user.ID, DeletedAfter: time.Now().Add(-a.deletionGrace)})
+	if err != nil {
+		// the email was registered again after the deletion
+		if db.IsUniqueViolation(err) {
+			return UserExistErr
+		}
+		return fmt.Errorf("restore user: %w", err)
+	}
+	// the grace period is over, the purge has not caught up yet
+	if n == 0 {
+		return UserNotFoundErr
+	}
+	return nil
+}
+
+// PurgeUsers runs a UserPurge job, erasing the accounts deleted longer than
+// the grace period ago.
+func (a *AccountService) PurgeUsers(ctx context.Context, job UserPurge) error {
+	now := time.Now()
+	var ids []int32
+	// erasing reaches into the organizations of the users
+	ctx = db.WithAllTenants(ctx)
+	err := a.inTx(ctx, func(q db.Querier) error {
+		var err error
+		ids, err = q.ListUsersDeletedBefore(ctx, now.Add(-a.deletionGrace))
+		if err != nil {
+			return fmt.Errorf("list deleted users: %w", err)
+		}
+		for _, id := range ids {
+			if err := eraseUser(ctx, q, id, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	a.logger.Info("purged deleted users", "count", len(ids))
+	return nil
+}
+
+// EraseUser erases the account with the email right away, deleted or not, for
+// a request of the user to erase its personal data.
+func (a *AccountService) EraseUser(ctx context.Context, email string) error {
+	user, err := a.db.GetUserByEmail(ctx, email)
+	if errors.Is(err, db.ErrNoRows) {
+		user, err = a.db.GetDeletedUserByEmail(ctx, email)
+	}
+	if err != nil {
+		if errors.Is(err, db.ErrNoRows) {
+			return UserNotFoundErr
+		}
+		return fmt.Errorf("get user by email: %w", err)
+	}
+	ctx = db.WithAllTenants(ctx)
+	return a.inTx(ctx, func(q db.Querier) error {
+		return eraseUser(ctx, q, user.ID, time.Now())
+	})
+}
+
+// eraseUser erases the user from every table and records a UserDeleted event,
+// the only one of the user left.
+func eraseUser(ctx context.Context, q db.Querier, id int32, now time.Time) error {
+	if err := privacy.Erase(ctx, q, id); err != nil {
+		return err
+	}
+	return events.Record(ctx, q, events.UserDeleted{UserID: int(id)}, now)
+}
+
+// deletedLogin is the error of a login with an email no live account has:
+// UserDeletedErr when the credentials are those of a deleted account, so the
+// user learns it can be restored, and UserNotFoundErr otherwise.
+func (a *AccountService) deletedLogin(ctx context.Context, model api.LoginUserRequest) error {
+	user, err := a.db.GetDeletedUserByEmail(ctx, model.Email)
+	if err != nil {
+		if errors.Is(err, db.ErrNoRows) {
+			return fmt.Errorf("login user: %w", UserNotFoundErr)
+		}
+		return fmt.Errorf("login: %w", err)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(model.Password)); err != nil {
+		return fmt.Errorf("login user: %w", UserNotFoundErr)
+	}
+	return UserDeletedErr
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"scratch/api"
+	db "scratch/internal/storage/database"
+	mockdb "scratch/internal/storage/database/mock"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAccountService_DeleteAccount(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("Test123!"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := db.ScratchUser{ID: 3, Email: "joedoe@gmail.com", Password: string(hash)}
+
+	tests := []struct {
+		name        string
+		password    string
+		prepareMock func(queries *mockdb.MockQuerier)
+		wantErr     error
+	}{
+		{
+			name:     "success",
+			password: "Test123!",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetUserByID(gomock.Any(), int32(3)).Return(user, nil)
+				queries.EXPECT().DeleteUser(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, arg db.DeleteUserParams) (int64, error) {
+						assert.Equal(t, int32(3), arg.ID)
+						assert.WithinDuration(t, time.Now(), arg.Now, time.Minute)
+						return 1, nil
+					})
+				queries.EXPECT().DeleteUserSessions(gomock.Any(), int32(3)).Return(int64(2), nil)
+				queries.EXPECT().DeleteDataExport(gomock.Any(), int32(3)).Return(int64(0), nil)
+			},
+		},
+		{
+			name:     "fail - incorrect password",
+			password: "wrong",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetUserByID(gomock.Any(), int32(3)).Return(user, nil)
+			},
+			wantErr: IncorrectPasswordErr,
+		},
+		{
+			name:     "fail - deleted concurrently",
+			password: "Test123!",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetUserByID(gomock.Any(), int32(3)).Return(user, nil)
+				queries.EXPECT().DeleteUser(gomock.Any(), gomock.Any()).Return(int64(0), nil)
+			},
+			wantErr: UserNotFoundErr,
+		},
+		{
+			name:     "fail - user not found",
+			password: "Test123!",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetUserByID(gomock.Any(), int32(3)).Return(db.ScratchUser{}, db.ErrNoRows)
+			},
+			wantErr: UserNotFoundErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockQuerier(ctrl)
+			tt.prepareMock(mockQueries)
+			s := NewAccountService(mockQueries, nil, nil, nil, slog.Logger{}).WithDeletionGracePeriod(time.Hour)
+
+			restoreBefore, err := s.DeleteAccount(context.Background(), 3, tt.password)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.WithinDuration(t, time.Now().Add(time.Hour), restoreBefore, time.Minute)
+		})
+	}
+}
+
+func TestAccountService_RestoreAccount(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("Test123!"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := db.ScratchUser{ID: 3, Email: "joedoe@gmail.com", Password: string(hash)}
+
+	tests := []struct {
+		name        string
+		password    string
+		prepareMock func(queries *mockdb.MockQuerier)
+		wantErr     error
+	}{
+		{
+			name:     "success",
+			password: "Test123!",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetDeletedUserByEmail(gomock.Any(), "joedoe@gmail.com").Return(user, nil)
+				queries.EXPECT().RestoreUser(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, arg db.RestoreUserParams) (int64, error) {
+						assert.Equal(t, int32(3), arg.ID)
+						assert.WithinDuration(t, time.Now().Add(-time.Hour), arg.DeletedAfter, time.Minute)
+						return 1, nil
+					})
+			},
+		},
+		{
+			name:     "fail - incorrect password",
+			password: "wrong",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetDeletedUserByEmail(gomock.Any(), "joedoe@gmail.com").Return(user, nil)
+			},
+			wantErr: IncorrectPasswordErr,
+		},
+		{
+			name:     "fail - grace period over",
+			password: "Test123!",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetDeletedUserByEmail(gomock.Any(), "joedoe@gmail.com").Return(user, nil)
+				queries.EXPECT().RestoreUser(gomock.Any(), gomock.Any()).Return(int64(0), nil)
+			},
+			wantErr: UserNotFoundErr,
+		},
+		{
+			name:     "fail - email registered again",
+			password: "Test123!",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetDeletedUserByEmail(gomock.Any(), "joedoe@gmail.com").Return(user, nil)
+				queries.EXPECT().RestoreUser(gomock.Any(), gomock.Any()).
+					Return(int64(0), &pgconn.PgError{Code: db.UniqueViolation, ConstraintName: "user_email_key"})
+			},
+			wantErr: UserExistErr,
+		},
+		{
+			name:     "fail - no deleted user",
+			password: "Test123!",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetDeletedUserByEmail(gomock.Any(), "joedoe@gmail.com").Return(db.ScratchUser{}, db.ErrNoRows)
+			},
+			wantErr: UserNotFoundErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockQuerier(ctrl)
+			tt.prepareMock(mockQueries)
+			s := NewAccountService(mockQueries, nil, nil, nil, slog.Logger{}).WithDeletionGracePeriod(time.Hour)
+
+			err := s.RestoreAccount(context.Background(), api.RestoreAccountRequest{Email: "joedoe@gmail.com", Password: tt.password})
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestAccountService_Login_DeletedUser(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("Test123!"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+	mockQueries.EXPECT().GetUserByEmail(gomock.Any(), "joedoe@gmail.com").Return(db.ScratchUser{}, db.ErrNoRows).Times(2)
+	mockQueries.EXPECT().GetDeletedUserByEmail(gomock.Any(), "joedoe@gmail.com").
+		Return(db.ScratchUser{ID: 3, Email: "joedoe@gmail.com", Password: string(hash)}, nil).Times(2)
+	s := NewAccountService(mockQueries, nil, nil, nil, slog.Logger{})
+
+	_, err = s.Login(context.Background(), api.LoginUserRequest{Email: "joedoe@gmail.com", Password: "Test123!"})
+	assert.ErrorIs(t, err, UserDeletedErr)
+
+	// a wrong password does not tell the account exists
+	_, err = s.Login(context.Background(), api.LoginUserRequest{Email: "joedoe@gmail.com", Password: "wrong"})
+	assert.ErrorIs(t, err, UserNotFoundErr)
+}
+
+func TestAccountService_PurgeUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	queries := mockdb.NewMockQuerier(ctrl)
+	queries.EXPECT().ListUsersDeletedBefore(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, deletedBefore time.Time) ([]int32, error) {
+			assert.WithinDuration(t, time.Now().Add(-time.Hour), deletedBefore, time.Minute)
+			return []int32{3, 5}, nil
+		})
+	var purged []string
+	for _, id := range []int32{3, 5} {
+		expectErase(queries, id)
+		queries.EXPECT().InsertOutboxEvent(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, arg db.InsertOutboxEventParams) error {
+				assert.Equal(t, "user.deleted", arg.EventType)
+				purged = append(purged, arg.AggregateID)
+				return nil
+			})
+	}
+
+	s := NewAccountService(queries, nil, nil, nil, *slog.New(slog.NewTextHandler(io.Discard, nil))).WithDeletionGracePeriod(time.Hour)
+	require.NoError(t, s.PurgeUsers(context.Background(), UserPurge{}))
+	assert.Equal(t, []string{"3", "5"}, purged)
+}
+
+func TestAccountService_EraseUser(t *testing.T) {
+	tests := []struct {
+		name        string
+		prepareMock func(queries *mockdb.MockQuerier)
+		wantErr     error
+	}{
+		{
+			name: "success",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetUserByEmail(gomock.Any(), "joedoe@gmail.com").Return(db.ScratchUser{ID: 3}, nil)
+				expectErase(queries, 3)
+				queries.EXPECT().InsertOutboxEvent(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "success - deleted user",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetUserByEmail(gomock.Any(), "joedoe@gmail.com").Return(db.ScratchUser{}, db.ErrNoRows)
+				queries.EXPECT().GetDeletedUserByEmail(gomock.Any(), "joedoe@gmail.com").Return(db.ScratchUser{ID: 3}, nil)
+				expectErase(queries, 3)
+				queries.EXPECT().InsertOutboxEvent(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "fail - user not found",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetUserByEmail(gomock.Any(), "joedoe@gmail.com").Return(db.ScratchUser{}, db.ErrNoRows)
+				queries.EXPECT().GetDeletedUserByEmail(gomock.Any(), "joedoe@gmail.com").Return(db.ScratchUser{}, db.ErrNoRows)
+			},
+			wantErr: UserNotFoundErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockQuerier(ctrl)
+			tt.prepareMock(mockQueries)
+			s := NewAccountService(mockQueries, nil, nil, nil, slog.Logger{})
+
+			assert.ErrorIs(t, s.EraseUser(context.Background(), "joedoe@gmail.com"), tt.wantErr)
+		})
+	}
+}
+
+// expectErase expects the user to be erased from every table.
+func expectErase(queries *mockdb.MockQuerier, id int32) {
+	aggregateID := strconv.Itoa(int(id))
+	queries.EXPECT().DeleteUserSessions(gomock.Any(), id).Return(int64(1), nil)
+	queries.EXPECT().DeleteUserRoles(gomock.Any(), id).Return(int64(0), nil)
+	queries.EXPECT().DeleteUserDevices(gomock.Any(), id).Return(int64(1), nil)
+	queries.EXPECT().DeleteUserEvents(gomock.Any(), aggregateID).Return(int64(2), nil)
+	queries.EXPECT().DeleteDataExport(gomock.Any(), id).Return(int64(0), nil)
+	queries.EXPECT().DeleteUserMemberships(gomock.Any(), id).Return(int64(1), nil)
+	queries.EXPECT().DeleteUserInvitations(gomock.Any(), id).Return(int64(0), nil)
+	queries.EXPECT().EraseUser(gomock.Any(), id).Return(int64(1), nil)
+}
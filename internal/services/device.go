@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"scratch/internal/events"
+	"scratch/internal/i18n"
+	"scratch/internal/jobs"
+	"scratch/internal/mail"
+	db "scratch/internal/storage/database"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Device describes the client a request comes from.
+type Device struct {
+	UserAgent string
+	IP        string
+	// Name is given by the client, or derived from UserAgent.
+	Name string
+}
+
+type deviceKey struct{}
+
+// WithDevice returns ctx carrying the device of the request.
+func WithDevice(ctx context.Context, d Device) context.Context {
+	return context.WithValue(ctx, deviceKey{}, d)
+}
+
+// DeviceFrom returns the device stored by WithDevice.
+func DeviceFrom(ctx context.Context) Device {
+	d, _ := ctx.Value(deviceKey{}).(Device)
+	return d
+}
+
+// loggedIn is the event of user logging in from device.
+func loggedIn(user db.ScratchUser, device Device, browser bool) events.UserLoggedIn {
+	return events.UserLoggedIn{UserID: int(user.ID), Browser: browser, DeviceName: device.Name, IP: device.IP}
+}
+
+// deviceOf returns the device of the request, named name when the client
+// gave one.
+func deviceOf(ctx context.Context, name *string) Device {
+	d := DeviceFrom(ctx)
+	if name != nil && strings.TrimSpace(*name) != "" {
+		d.Name = strings.TrimSpace(*name)
+	}
+	if d.Name == "" {
+		d.Name = DeviceName(d.UserAgent)
+	}
+	if len(d.UserAgent) > 512 {
+		d.UserAgent = d.UserAgent[:512]
+	}
+	return d
+}
+
+// DeviceName makes a readable name like "Firefox on Linux" of a user agent.
+func DeviceName(userAgent string) string {
+	browser := firstMatch(userAgent, [][2]string{
+		{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"}, {"Chrome/", "Chrome"},
+		{"Safari/", "Safari"}, {"curl/", "curl"},
+	})
+	system := firstMatch(userAgent, [][2]string{
+		{"Windows", "Windows"}, {"Android", "Android"}, {"iPhone", "iOS"}, {"iPad", "iPadOS"},
+		{"Mac OS X", "macOS"}, {"CrOS", "ChromeOS"}, {"Linux", "Linux"},
+	})
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	case userAgent == "":
+		return "unknown"
+	case len(userAgent) > 255:
+		return userAgent[:255]
+	}
+	return userAgent
+}
+
+func firstMatch(s string, patterns [][2]string) string {
+	for _, p := range patterns {
+		if strings.Contains(s, p[0]) {
+			return p[1]
+		}
+	}
+	return ""
+}
+
+// rememberDevice records with q that user logged in from d, starting session
+// sessionID, and enqueues an email telling them when d is new. The first
+// device of a user is not worth an email.
+func (a *AccountService) rememberDevice(ctx context.Context, q db.Querier, user db.ScratchUser, d Device, sessionID int32, now time.Time) error {
+	inserted, err := q.RememberDevice(ctx, db.RememberDeviceParams{
+		UserID:      user.ID,
+		Fingerprint: hashToken(d.UserAgent + "\n" + d.Name),
+		Name:        d.Name,
+		Now:         now,
+	})
+	if err != nil {
+		return fmt.Errorf("remember device: %w", err)
+	}
+	if !inserted {
+		return nil
+	}
+	devices, err := q.CountUserDevices(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("count devices: %w", err)
+	}
+	if devices < 2 {
+		return nil
+	}
+	_, err = jobs.Enqueue(ctx, q, NewDeviceEmail{
+		UserID:    int(user.ID),
+		SessionID: int(sessionID),
+		Device:    d.Name,
+		IP:        d.IP,
+		Time:      now,
+		Locale:    i18n.LocaleFrom(ctx),
+	}, jobs.Options{UniqueKey: strconv.Itoa(int(sessionID))})
+	return err
+}
+
+func newDeviceMessage(user db.ScratchUser, job NewDeviceEmail) (mail.Message, error) {
+	locale := user.Locale.String
+	if locale == "" {
+		locale = job.Locale
+	}
+	message, err := mail.Default().Render("new-device", locale, mail.NewDevice{
+		Name:   user.Name,
+		Device: job.Device,
+		IP:     job.IP,
+		Time:   job.Time.UTC().Format("2006-01-02 15:04 MST"),
+	})
+	if err != nil {
+		return mail.Message{}, fmt.Errorf("render new device mail: %w", err)
+	}
+	message.ID = fmt.Sprintf("new-device/%d", job.SessionID)
+	message.To = user.Email
+	return message, nil
+}
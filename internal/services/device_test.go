@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"scratch/internal/i18n"
+	"scratch/internal/mail"
+	db "scratch/internal/storage/database"
+	mockdb "scratch/internal/storage/database/mock"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceName(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      string
+	}{
+		{"Mozilla/5.0 (X11; Linux x86_64; rv:131.0) Gecko/20100101 Firefox/131.0", "Firefox on Linux"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36 Edg/129.0.0.0", "Edge on Windows"},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.6 Mobile/15E148 Safari/604.1", "Safari on iOS"},
+		{"Mozilla/5.0 (Linux; Android 14) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Mobile Safari/537.36", "Chrome on Android"},
+		{"curl/8.5.0", "curl"},
+		{"scratch-cli/1.0", "scratch-cli/1.0"},
+		{"", "unknown"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, DeviceName(tt.userAgent))
+		})
+	}
+}
+
+// sender hands the sent messages over to the test.
+type sender chan mail.Message
+
+func (s sender) Send(ctx context.Context, m mail.Message) error {
+	s <- m
+	return nil
+}
+
+func TestAccountService_rememberDevice(t *testing.T) {
+	user := db.ScratchUser{ID: 3, Name: "Joe", Email: "joedoe@gmail.com", Locale: sql.NullString{String: "pl", Valid: true}}
+	device := Device{UserAgent: "curl/8.5.0", IP: "192.0.2.1", Name: "curl"}
+	tests := []struct {
+		name        string
+		prepareMock func(queries *mockdb.MockQuerier)
+		wantErr     error
+	}{
+		{
+			name: "success - new device is told about",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().RememberDevice(gomock.Any(), gomock.Any()).Return(true, nil)
+				queries.EXPECT().CountUserDevices(gomock.Any(), int32(3)).Return(int64(2), nil)
+				queries.EXPECT().EnqueueJob(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, arg db.EnqueueJobParams) (int64, error) {
+						assert.Equal(t, "new_device_email", arg.Kind)
+						assert.Equal(t, sql.NullString{String: "12", Valid: true}, arg.UniqueKey, "one mail per session")
+						assert.JSONEq(t, `{"userId":3,"sessionId":12,"device":"curl","ip":"192.0.2.1","time":"2026-10-19T12:00:00Z","locale":"en"}`, string(arg.Payload))
+						return 1, nil
+					})
+			},
+		},
+		{
+			name: "success - known device",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().RememberDevice(gomock.Any(), gomock.Any()).Return(false, nil)
+			},
+		},
+		{
+			name: "success - first device of the user",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().RememberDevice(gomock.Any(), gomock.Any()).Return(true, nil)
+				queries.EXPECT().CountUserDevices(gomock.Any(), int32(3)).Return(int64(1), nil)
+			},
+		},
+		{
+			name: "fail - the login is not committed without its mail",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().RememberDevice(gomock.Any(), gomock.Any()).Return(true, nil)
+				queries.EXPECT().CountUserDevices(gomock.Any(), int32(3)).Return(int64(2), nil)
+				queries.EXPECT().EnqueueJob(gomock.Any(), gomock.Any()).Return(int64(0), sql.ErrConnDone)
+			},
+			wantErr: sql.ErrConnDone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockQuerier(ctrl)
+			tt.prepareMock(mockQueries)
+			s := NewAccountService(mockQueries, nil, nil, nil, slog.Logger{})
+
+			ctx := i18n.WithLocale(context.Background(), "en")
+			err := s.rememberDevice(ctx, mockQueries, user, device, 12, time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"scratch/api"
+	"scratch/internal/jobs"
+	"scratch/internal/privacy"
+	db "scratch/internal/storage/database"
+	"time"
+)
+
+// DefaultDataExportTTL is how long a data export archive is kept and
+// DefaultDataExportURLTTL how long its download URL is valid, unless
+// WithDataExports says otherwise.
+const (
+	DefaultDataExportTTL    = 7 * 24 * time.Hour
+	DefaultDataExportURLTTL = 15 * time.Minute
+)
+
+// DataExportNotFoundErr is returned for a download URL that is invalid or
+// expired, or whose archive is no longer kept.
+var DataExportNotFoundErr = errors.New("data export not found")
+
+// DataExportJob builds the data export archive of a user.
+type DataExportJob struct {
+	UserID int `json:"userId"`
+}
+
+func (DataExportJob) Kind() string { return "data_export" }
+
+// DataExportCleanup deletes the data export archives older than their TTL.
+type DataExportCleanup struct{}
+
+func (DataExportCleanup) Kind() string { return "data_export_cleanup" }
+
+// WithDataExports returns a copy of the service keeping data export archives
+// for ttl, with download URLs signed by signer and valid for urlTTL. Without a
+// signer the service builds archives but hands out no URLs.
+func (a *AccountService) WithDataExports(signer *privacy.URLSigner, ttl, urlTTL time.Duration) *AccountService {
+	c := *a
+	c.exportSigner = signer
+	c.exportTTL = ttl
+	c.exportURLTTL = urlTTL
+	return &c
+}
+
+// DataExport returns the data export of the user, requesting one when there is
+// none or the last one expired. A ready export comes with a signed download
+// URL.
+func (a *AccountService) DataExport(ctx context.Context, userID int) (api.DataExport, error) {
+	now := time.Now()
+	export, err := a.db.GetDataExport(ctx, int32(userID))
+	if errors.Is(err, db.ErrNoRows) {
+		return a.requestDataExport(ctx, userID, now)
+	}
+	if err != nil {
+		return api.DataExport{}, fmt.Errorf("get data export: %w", err)
+	}
+	if !export.ReadyAt.Valid {
+		return api.DataExport{Status: api.Pending, RequestedAt: export.RequestedAt}, nil
+	}
+
+	readyAt := export.ReadyAt.Time
+	expiresAt := readyAt.Add(a.exportTTL)
+	if !expiresAt.After(now) {
+		return a.requestDataExport(ctx, userID, now)
+	}
+	if a.exportSigner == nil {
+		return api.DataExport{}, errors.New("data export urls can not be signed")
+	}
+	// the URL is signed to the second and does not outlive the archive
+	urlExpiresAt := now.Add(a.exportURLTTL).Truncate(time.Second)
+	if urlExpiresAt.After(expiresAt) {
+		urlExpiresAt = expiresAt.Truncate(time.Second)
+	}
+	url := a.exportSigner.URL(userID, urlExpiresAt)
+	return api.DataExport{
+		Status:       api.Ready,
+		RequestedAt:  export.RequestedAt,
+		ReadyAt:      &readyAt,
+		ExpiresAt:    &expiresAt,
+		Url:          &url,
+		UrlExpiresAt: &urlExpiresAt,
+	}, nil
+}
+
+// requestDataExport starts building a new archive of the user.
+func (a *AccountService) requestDataExport(ctx context.Context, userID int, now time.Time) (api.DataExport, error) {
+	err := a.inTx(ctx, func(q db.Querier) error {
+		n, err := q.RequestDataExport(ctx, db.RequestDataExportParams{UserID: int32(userID), Now: now})
+		if err != nil {
+			if db.SQLState(err) == db.ForeignKeyViolation {
+				return UserNotFoundErr
+			}
+			return fmt.Errorf("request data export: %w", err)
+		}
+		// a concurrent request was first
+		if n == 0 {
+			return nil
+		}
+		_, err = jobs.Enqueue(ctx, q, DataExportJob{UserID: userID}, jobs.Options{})
+		return err
+	})
+	if err != nil {
+		return api.DataExport{}, err
+	}
+	return api.DataExport{Status: api.Pending, RequestedAt: now}, nil
+}
+
+// DataExportArchive returns the archive a URL of DataExport points to, once
+// its signature is verified.
+func (a *AccountService) DataExportArchive(ctx context.Context, userID int, params api.GetExportsUserIdParams) ([]byte, error) {
+	now := time.Now()
+	if a.exportSigner == nil || !a.exportSigner.Verify(userID, params.Expires, params.Signature, now) {
+		return nil, DataExportNotFoundErr
+	}
+	archive, err := a.db.GetDataExportArchive(ctx, db.GetDataExportArchiveParams{
+		UserID:     int32(userID),
+		ReadyAfter: now.Add(-a.exportTTL),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrNoRows) {
+			return nil, DataExportNotFoundErr
+		}
+		return nil, fmt.Errorf("get data export archive: %w", err)
+	}
+	return archive, nil
+}
+
+// ExportUser returns the data export archive of the user with the email right
+// away, for support answering a request of the user.
+func (a *AccountService) ExportUser(ctx context.Context, email string) ([]byte, error) {
+	user, err := a.getUser(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	var archive []byte
+	// the memberships and invitations of the user are of any organization
+	ctx = db.WithAllTenants(ctx)
+	err = a.inTx(ctx, func(q db.Querier) error {
+		archive, err = privacy.Export(ctx, q, user.ID)
+		return err
+	})
+	return archive, err
+}
+
+// BuildDataExport runs a DataExportJob.
+func (a *AccountService) BuildDataExport(ctx context.Context, job DataExportJob) error {
+	// the archive is a consistent snapshot of the user, in every organization
+	ctx = db.WithAllTenants(ctx)
+	err := a.inTx(ctx, func(q db.Querier) error {
+		archive, err := privacy.Export(ctx, q, int32(job.UserID))
+		if err != nil {
+			return err
+		}
+		_, err = q.SaveDataExport(ctx, db.SaveDataExportParams{UserID: int32(job.UserID), Archive: archive, Now: time.Now()})
+		if err != nil {
+			return fmt.Errorf("save data export: %w", err)
+		}
+		return nil
+	})
+	// the user was deleted in the meantime
+	if errors.Is(err, db.ErrNoRows) {
+		return jobs.Permanent(fmt.Errorf("%w: %d", UserNotFoundErr, job.UserID))
+	}
+	return err
+}
+
+// CleanupDataExports runs a DataExportCleanup job.
+func (a *AccountService) CleanupDataExports(ctx context.Context, job DataExportCleanup) error {
+	n, err := a.db.DeleteExpiredDataExports(ctx, time.Now().Add(-a.exportTTL))
+	if err != nil {
+		return fmt.Errorf("delete expired data exports: %w", err)
+	}
+	a.logger.Info("deleted expired data exports", "count", n)
+	return nil
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"net/url"
+	"scratch/api"
+	"scratch/internal/jobs"
+	"scratch/internal/privacy"
+	db "scratch/internal/storage/database"
+	mockdb "scratch/internal/storage/database/mock"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type exportKeys [][]byte
+
+func (k exportKeys) Keys() [][]byte { return k }
+
+func TestAccountService_DataExport(t *testing.T) {
+	requestedAt := time.Now().Add(-time.Hour).UTC()
+
+	tests := []struct {
+		name        string
+		prepareMock func(queries *mockdb.MockQuerier)
+		want        api.DataExportStatus
+		wantURL     bool
+		wantErr     error
+	}{
+		{
+			name: "success - requested",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetDataExport(gomock.Any(), int32(3)).Return(db.GetDataExportRow{}, db.ErrNoRows)
+				queries.EXPECT().RequestDataExport(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, arg db.RequestDataExportParams) (int64, error) {
+						assert.Equal(t, int32(3), arg.UserID)
+						return 1, nil
+					})
+				queries.EXPECT().EnqueueJob(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, arg db.EnqueueJobParams) (int64, error) {
+						assert.Equal(t, "data_export", arg.Kind)
+						assert.JSONEq(t, `{"userId":3}`, string(arg.Payload))
+						return 1, nil
+					})
+			},
+			want: api.Pending,
+		},
+		{
+			name: "success - requested concurrently",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetDataExport(gomock.Any(), int32(3)).Return(db.GetDataExportRow{}, db.ErrNoRows)
+				queries.EXPECT().RequestDataExport(gomock.Any(), gomock.Any()).Return(int64(0), nil)
+			},
+			want: api.Pending,
+		},
+		{
+			name: "success - pending",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetDataExport(gomock.Any(), int32(3)).
+					Return(db.GetDataExportRow{UserID: 3, RequestedAt: requestedAt}, nil)
+			},
+			want: api.Pending,
+		},
+		{
+			name: "success - ready",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetDataExport(gomock.Any(), int32(3)).Return(db.GetDataExportRow{
+					UserID:      3,
+					RequestedAt: requestedAt,
+					ReadyAt:     sql.NullTime{Time: requestedAt.Add(time.Minute), Valid: true},
+				}, nil)
+			},
+			want:    api.Ready,
+			wantURL: true,
+		},
+		{
+			name: "success - expired export is requested again",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetDataExport(gomock.Any(), int32(3)).Return(db.GetDataExportRow{
+					UserID:      3,
+					RequestedAt: requestedAt.Add(-48 * time.Hour),
+					ReadyAt:     sql.NullTime{Time: requestedAt.Add(-48 * time.Hour), Valid: true},
+				}, nil)
+				queries.EXPECT().RequestDataExport(gomock.Any(), gomock.Any()).Return(int64(1), nil)
+				queries.EXPECT().EnqueueJob(gomock.Any(), gomock.Any()).Return(int64(1), nil)
+			},
+			want: api.Pending,
+		},
+		{
+			name: "fail - user not found",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetDataExport(gomock.Any(), int32(3)).Return(db.GetDataExportRow{}, db.ErrNoRows)
+				queries.EXPECT().RequestDataExport(gomock.Any(), gomock.Any()).
+					Return(int64(0), &pgconn.PgError{Code: db.ForeignKeyViolation})
+			},
+			wantErr: UserNotFoundErr,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockQuerier(ctrl)
+			tt.prepareMock(mockQueries)
+			s := NewAccountService(mockQueries, nil, nil, nil, slog.Logger{}).
+				WithDataExports(privacy.NewURLSigner(exportKeys{[]byte("secret")}), 24*time.Hour, 15*time.Minute)
+
+			export, err := s.DataExport(context.Background(), 3)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, export.Status)
+			if !tt.wantURL {
+				assert.Nil(t, export.Url)
+				return
+			}
+			require.NotNil(t, export.Url)
+			require.NotNil(t, export.UrlExpiresAt)
+			assert.WithinDuration(t, time.Now().Add(15*time.Minute), *export.UrlExpiresAt, time.Minute)
+			signed, err := url.Parse(*export.Url)
+			require.NoError(t, err)
+			assert.Equal(t, "/exports/3", signed.Path)
+			assert.Equal(t, strconv.FormatInt(export.UrlExpiresAt.Unix(), 10), signed.Query().Get("expires"))
+		})
+	}
+}
+
+func TestAccountService_DataExportArchive(t *testing.T) {
+	signer := privacy.NewURLSigner(exportKeys{[]byte("secret")})
+	signed, err := url.Parse(signer.URL(3, time.Now().Add(time.Minute)))
+	require.NoError(t, err)
+	expires, err := strconv.ParseInt(signed.Query().Get("expires"), 10, 64)
+	require.NoError(t, err)
+	params := api.GetExportsUserIdParams{Expires: expires, Signature: signed.Query().Get("signature")}
+
+	tests := []struct {
+		name        string
+		userID      int
+		params      api.GetExportsUserIdParams
+		prepareMock func(queries *mockdb.MockQuerier)
+		wantErr     error
+	}{
+		{
+			name:   "success",
+			userID: 3,
+			params: params,
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetDataExportArchive(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, arg db.GetDataExportArchiveParams) ([]byte, error) {
+						assert.Equal(t, int32(3), arg.UserID)
+						assert.WithinDuration(t, time.Now().Add(-24*time.Hour), arg.ReadyAfter, time.Minute)
+						return []byte("archive"), nil
+					})
+			},
+		},
+		{
+			name:        "fail - signed for another user",
+			userID:      4,
+			params:      params,
+			prepareMock: func(queries *mockdb.MockQuerier) {},
+			wantErr:     DataExportNotFoundErr,
+		},
+		{
+			name:        "fail - bad signature",
+			userID:      3,
+			params:      api.GetExportsUserIdParams{Expires: expires, Signature: "bad"},
+			prepareMock: func(queries *mockdb.MockQuerier) {},
+			wantErr:     DataExportNotFoundErr,
+		},
+		{
+			name:   "fail - archive expired",
+			userID: 3,
+			params: params,
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetDataExportArchive(gomock.Any(), gomock.Any()).Return(nil, db.ErrNoRows)
+			},
+			wantErr: DataExportNotFoundErr,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockQuerier(ctrl)
+			tt.prepareMock(mockQueries)
+			s := NewAccountService(mockQueries, nil, nil, nil, slog.Logger{}).
+				WithDataExports(signer, 24*time.Hour, 15*time.Minute)
+
+			archive, err := s.DataExportArchive(context.Background(), tt.userID, tt.params)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []byte("archive"), archive)
+		})
+	}
+}
+
+func TestAccountService_BuildDataExport(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		queries := mockdb.NewMockQuerier(ctrl)
+		queries.EXPECT().ListUserSessions(gomock.Any(), int32(3)).Return(nil, nil)
+		queries.EXPECT().ListUserRoleGrants(gomock.Any(), int32(3)).Return(nil, nil)
+		queries.EXPECT().ListUserDevices(gomock.Any(), int32(3)).Return(nil, nil)
+		queries.EXPECT().ListUserEvents(gomock.Any(), "3").Return(nil, nil)
+		queries.EXPECT().ListUserOrganizations(gomock.Any(), int32(3)).Return(nil, nil)
+		queries.EXPECT().ListUserInvitations(gomock.Any(), int32(3)).Return(nil, nil)
+		queries.EXPECT().GetUserByID(gomock.Any(), int32(3)).Return(db.ScratchUser{ID: 3, Email: "joedoe@gmail.com"}, nil)
+		queries.EXPECT().SaveDataExport(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, arg db.SaveDataExportParams) (int64, error) {
+				assert.Equal(t, int32(3), arg.UserID)
+				assert.NotEmpty(t, arg.Archive)
+				return 1, nil
+			})
+
+		s := NewAccountService(queries, nil, nil, nil, slog.Logger{})
+		require.NoError(t, s.BuildDataExport(context.Background(), DataExportJob{UserID: 3}))
+	})
+
+	t.Run("fail - user deleted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		queries := mockdb.NewMockQuerier(ctrl)
+		queries.EXPECT().ListUserSessions(gomock.Any(), int32(3)).Return(nil, nil)
+		queries.EXPECT().ListUserRoleGrants(gomock.Any(), int32(3)).Return(nil, nil)
+		queries.EXPECT().ListUserDevices(gomock.Any(), int32(3)).Return(nil, nil)
+		queries.EXPECT().ListUserEvents(gomock.Any(), "3").Return(nil, nil)
+		queries.EXPECT().ListUserOrganizations(gomock.Any(), int32(3)).Return(nil, nil)
+		queries.EXPECT().ListUserInvitations(gomock.Any(), int32(3)).Return(nil, nil)
+		queries.EXPECT().GetUserByID(gomock.Any(), int32(3)).Return(db.ScratchUser{}, db.ErrNoRows)
+
+		s := NewAccountService(queries, nil, nil, nil, slog.Logger{})
+		err := s.BuildDataExport(context.Background(), DataExportJob{UserID: 3})
+		assert.ErrorIs(t, err, UserNotFoundErr)
+		assert.True(t, jobs.IsPermanent(err))
+	})
+}
+
+func TestAccountService_CleanupDataExports(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	queries := mockdb.NewMockQuerier(ctrl)
+	queries.EXPECT().DeleteExpiredDataExports(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, before time.Time) (int64, error) {
+			assert.WithinDuration(t, time.Now().Add(-24*time.Hour), before, time.Minute)
+			return 2, nil
+		})
+
+	s := NewAccountService(queries, nil, nil, nil, *slog.New(slog.NewTextHandler(io.Discard, nil))).
+		WithDataExports(nil, 24*time.Hour, time.Minute)
+	require.NoError(t, s.CleanupDataExports(context.Background(), DataExportCleanup{}))
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"scratch/internal/jobs"
+	"scratch/internal/mail"
+	db "scratch/internal/storage/database"
+	"time"
+)
+
+// WelcomeEmail greets a new user, it is enqueued together with the account.
+type WelcomeEmail struct {
+	UserID int `json:"userId"`
+}
+
+func (WelcomeEmail) Kind() string { return "welcome_email" }
+
+// NewDeviceEmail tells a user about a login from a device they had not used
+// before, it is enqueued together with the session. Locale is the one of the
+// login request, for users without a locale of their own.
+type NewDeviceEmail struct {
+	UserID    int       `json:"userId"`
+	SessionID int       `json:"sessionId"`
+	Device    string    `json:"device"`
+	IP        string    `json:"ip"`
+	Time      time.Time `json:"time"`
+	Locale    string    `json:"locale"`
+}
+
+func (NewDeviceEmail) Kind() string { return "new_device_email" }
+
+// SessionCleanup deletes the expired sessions of every user.
+type SessionCleanup struct{}
+
+func (SessionCleanup) Kind() string { return "session_cleanup" }
+
+// SendWelcomeEmail runs a WelcomeEmail job.
+func (a *AccountService) SendWelcomeEmail(ctx context.Context, job WelcomeEmail) error {
+	if a.mailer == nil {
+		return nil
+	}
+	user, err := a.getUserByID(ctx, job.UserID)
+	if err != nil {
+		if errors.Is(err, UserNotFoundErr) {
+			return jobs.Permanent(err)
+		}
+		return err
+	}
+	message, err := welcomeMessage(user)
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+	if err := a.mailer.Send(ctx, message); err != nil {
+		return fmt.Errorf("send welcome mail: %w", err)
+	}
+	return nil
+}
+
+// SendNewDeviceEmail runs a NewDeviceEmail job.
+func (a *AccountService) SendNewDeviceEmail(ctx context.Context, job NewDeviceEmail) error {
+	if a.mailer == nil {
+		return nil
+	}
+	user, err := a.getUserByID(ctx, job.UserID)
+	if err != nil {
+		if errors.Is(err, UserNotFoundErr) {
+			return jobs.Permanent(err)
+		}
+		return err
+	}
+	message, err := newDeviceMessage(user, job)
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+	if err := a.mailer.Send(ctx, message); err != nil {
+		return fmt.Errorf("send new device mail: %w", err)
+	}
+	return nil
+}
+
+// CleanupSessions runs a SessionCleanup job.
+func (a *AccountService) CleanupSessions(ctx context.Context, job SessionCleanup) error {
+	n, err := a.db.DeleteExpiredSessions(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("delete expired sessions: %w", err)
+	}
+	a.logger.Info("deleted expired sessions", "count", n)
+	return nil
+}
+
+func welcomeMessage(user db.ScratchUser) (mail.Message, error) {
+	message, err := mail.Default().Render("welcome", user.Locale.String, mail.Welcome{Name: user.Name})
+	if err != nil {
+		return mail.Message{}, fmt.Errorf("render welcome mail: %w", err)
+	}
+	message.ID = fmt.Sprintf("welcome/%d", user.ID)
+	message.To = user.Email
+	return message, nil
+}
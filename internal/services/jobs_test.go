@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"scratch/internal/jobs"
+	db "scratch/internal/storage/database"
+	mockdb "scratch/internal/storage/database/mock"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountService_SendWelcomeEmail(t *testing.T) {
+	user := db.ScratchUser{ID: 3, Name: "Joe", Email: "joedoe@gmail.com", Locale: sql.NullString{String: "pl", Valid: true}}
+	tests := []struct {
+		name          string
+		prepareMock   func(queries *mockdb.MockQuerier)
+		wantMail      bool
+		wantErr       error
+		wantPermanent bool
+	}{
+		{
+			name: "success - mail in the locale of the user",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetUserByID(gomock.Any(), int32(3)).Return(user, nil)
+			},
+			wantMail: true,
+		},
+		{
+			name: "fail - user deleted meanwhile",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetUserByID(gomock.Any(), int32(3)).Return(db.ScratchUser{}, db.ErrNoRows)
+			},
+			wantErr:       UserNotFoundErr,
+			wantPermanent: true,
+		},
+		{
+			name: "fail - database is retried",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetUserByID(gomock.Any(), int32(3)).Return(db.ScratchUser{}, sql.ErrConnDone)
+			},
+			wantErr: sql.ErrConnDone,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			queries := mockdb.NewMockQuerier(ctrl)
+			tt.prepareMock(queries)
+			sent := make(sender, 1)
+			s := NewAccountService(queries, nil, nil, sent, slog.Logger{})
+
+			err := s.SendWelcomeEmail(context.Background(), WelcomeEmail{UserID: 3})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Equal(t, tt.wantPermanent, jobs.IsPermanent(err))
+				assert.Empty(t, sent)
+				return
+			}
+			require.NoError(t, err)
+			m := <-sent
+			assert.Equal(t, "joedoe@gmail.com", m.To)
+			assert.Equal(t, "welcome/3", m.ID)
+			assert.Equal(t, "Witaj w Scratch", m.Subject)
+			assert.Contains(t, m.Text, "Cześć Joe")
+		})
+	}
+}
+
+func TestAccountService_SendNewDeviceEmail(t *testing.T) {
+	job := NewDeviceEmail{UserID: 3, SessionID: 12, Device: "curl", IP: "192.0.2.1", Time: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC), Locale: "en"}
+	tests := []struct {
+		name        string
+		user        db.ScratchUser
+		wantSubject string
+	}{
+		{
+			name:        "success - mail in the locale of the user",
+			user:        db.ScratchUser{ID: 3, Name: "Joe", Email: "joedoe@gmail.com", Locale: sql.NullString{String: "pl", Valid: true}},
+			wantSubject: "Nowe logowanie na Twoje konto",
+		},
+		{
+			name:        "success - mail in the locale of the login",
+			user:        db.ScratchUser{ID: 3, Name: "Joe", Email: "joedoe@gmail.com"},
+			wantSubject: "New login to your account",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			queries := mockdb.NewMockQuerier(ctrl)
+			queries.EXPECT().GetUserByID(gomock.Any(), int32(3)).Return(tt.user, nil)
+			sent := make(sender, 1)
+			s := NewAccountService(queries, nil, nil, sent, slog.Logger{})
+
+			require.NoError(t, s.SendNewDeviceEmail(context.Background(), job))
+			m := <-sent
+			assert.Equal(t, "new-device/12", m.ID, "sent once per session")
+			assert.Equal(t, "joedoe@gmail.com", m.To)
+			assert.Equal(t, tt.wantSubject, m.Subject)
+			assert.Contains(t, m.Text, "curl")
+			assert.Contains(t, m.Text, "192.0.2.1")
+			assert.Contains(t, m.Text, "2026-10-19 12:00 UTC")
+		})
+	}
+}
+
+func TestAccountService_CleanupSessions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	queries := mockdb.NewMockQuerier(ctrl)
+	queries.EXPECT().DeleteExpiredSessions(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, now time.Time) (int64, error) {
+			assert.WithinDuration(t, time.Now(), now, time.Minute)
+			return 4, nil
+		})
+
+	s := NewAccountService(queries, nil, nil, nil, *slog.New(slog.NewTextHandler(io.Discard, nil)))
+	assert.NoError(t, s.CleanupSessions(context.Background(), SessionCleanup{}))
+}
@@ -0,0 +1,691 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"scratch/api"
+	"scratch/internal/authorization/session"
+	"scratch/internal/i18n"
+	"scratch/internal/jobs"
+	"scratch/internal/mail"
+	db "scratch/internal/storage/database"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	OrganizationNotFoundErr  = errors.New("organization not found")
+	OrganizationForbiddenErr = errors.New("not allowed in the organization")
+	OrganizationSlugTakenErr = errors.New("organization slug is taken")
+	MemberNotFoundErr        = errors.New("organization member not found")
+	InvitationNotFoundErr    = errors.New("invitation not found or expired")
+	InvitationExpiryErr      = errors.New("invitation expiry out of range")
+	InvitationRequiredErr    = errors.New("registration requires an invitation")
+	LastOwnerErr             = errors.New("organization needs an owner")
+)
+
+// DefaultInvitationTTL is how long an invitation can be accepted, unless
+// WithInvitationTTL says otherwise.
+const DefaultInvitationTTL = 7 * 24 * time.Hour
+
+// The roles of organization members: owners manage the organization and
+// every role, admins invite and manage admins and members, and members invite
+// members.
+const (
+	OrganizationOwner  = string(api.Owner)
+	OrganizationAdmin  = string(api.Admin)
+	OrganizationMember = string(api.Member)
+)
+
+type OrganizationManager interface {
+	CreateOrganization(ctx context.Context, userID int, model api.CreateOrganizationRequest) (api.Organization, error)
+	Organizations(ctx context.Context, userID int) ([]api.Organization, error)
+	Members(ctx context.Context, userID, organizationID int) ([]api.OrganizationMember, error)
+	UpdateMemberRole(ctx context.Context, userID, organizationID, memberID int, role api.OrganizationRole) error
+	RemoveMember(ctx context.Context, userID, organizationID, memberID int) error
+	Invite(ctx context.Context, userID, organizationID int, model api.CreateInvitationRequest) (api.Invitation, error)
+	Invitations(ctx context.Context, userID, organizationID int) ([]api.Invitation, error)
+	RevokeInvitation(ctx context.Context, userID, organizationID, invitationID int) error
+	AcceptInvitation(ctx context.Context, userID int, code string) (api.Organization, error)
+	OrganizationToken(ctx context.Context, userID, organizationID int) (api.OrganizationToken, error)
+}
+
+// OrganizationService manages organizations, their members and invitations.
+// The work on an organization runs in transactions scoped to it with
+// db.WithTenant, which row level security enforces when enabled.
+type OrganizationService struct {
+	db db.Querier
+	// tx runs the changes spanning several statements, nil runs them on db
+	// without a transaction.
+	tx         db.Transactor
+	tokenMaker session.IdentityGenerator
+	// mailer sends the invitations, nil sends nothing.
+	mailer        mail.Sender
+	logger        slog.Logger
+	invitationTTL time.Duration
+	// inviteLink is the address of the registration page the code of an
+	// invitation is appended to, empty mails the code only.
+	inviteLink string
+}
+
+func NewOrganizationService(db db.Querier, tx db.Transactor, tokenGenerator session.IdentityGenerator, mailer mail.Sender, logger slog.Logger) *OrganizationService {
+	return &OrganizationService{
+		db:            db,
+		tx:            tx,
+		tokenMaker:    tokenGenerator,
+		mailer:        mailer,
+		logger:        logger,
+		invitationTTL: DefaultInvitationTTL,
+	}
+}
+
+// WithInvitationTTL returns a copy of the service whose invitations can be
+// accepted for ttl, and no longer.
+func (o *OrganizationService) WithInvitationTTL(ttl time.Duration) *OrganizationService {
+	c := *o
+	c.invitationTTL = ttl
+	return &c
+}
+
+// WithInviteLink returns a copy of the service mailing invitations with a
+// link to register with them, link followed by the code.
+func (o *OrganizationService) WithInviteLink(link string) *OrganizationService {
+	c := *o
+	c.inviteLink = link
+	return &c
+}
+
+func (o *OrganizationService) inTx(ctx context.Context, fn func(q db.Querier) error) error {
+	if o.tx == nil {
+		return fn(o.db)
+	}
+	return o.tx.InTx(ctx, fn)
+}
+
+// CreateOrganization creates an organization owned by the user.
+func (o *OrganizationService) CreateOrganization(ctx context.Context, userID int, model api.CreateOrganizationRequest) (api.Organization, error) {
+	now := time.Now()
+	var organization db.ScratchOrganization
+	// the owner joins an organization without an id to scope the transaction
+	// to yet
+	ctx = db.WithAllTenants(ctx)
+	err := o.inTx(ctx, func(q db.Querier) error {
+		var err error
+		organization, err = q.CreateOrganization(ctx, db.CreateOrganizationParams{Slug: model.Slug, Name: model.Name, Now: now})
+		if db.IsUniqueViolation(err) {
+			return OrganizationSlugTakenErr
+		}
+		if err != nil {
+			return fmt.Errorf("create organization: %w", err)
+		}
+		_, err = q.AddOrganizationMember(ctx, db.AddOrganizationMemberParams{
+			OrganizationID: organization.ID,
+			UserID:         int32(userID),
+			Role:           OrganizationOwner,
+			Now:            now,
+		})
+		if db.SQLState(err) == db.ForeignKeyViolation {
+			return UserNotFoundErr
+		}
+		if err != nil {
+			return fmt.Errorf("add owner: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return api.Organization{}, err
+	}
+	return api.Organization{
+		Id:        int(organization.ID),
+		Slug:      organization.Slug,
+		Name:      organization.Name,
+		Role:      api.Owner,
+		CreatedAt: organization.CreatedAt,
+	}, nil
+}
+
+// Organizations lists the organizations the user is a member of.
+func (o *OrganizationService) Organizations(ctx context.Context, userID int) ([]api.Organization, error) {
+	var rows []db.ListUserOrganizationsRow
+	// the memberships of the user are of every organization it is in
+	ctx = db.WithAllTenants(ctx)
+	err := o.inTx(ctx, func(q db.Querier) error {
+		var err error
+		rows, err = q.ListUserOrganizations(ctx, int32(userID))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list organizations: %w", err)
+	}
+	organizations := make([]api.Organization, len(rows))
+	for i, row := range rows {
+		organizations[i] = api.Organization{
+			Id:        int(row.ID),
+			Slug:      row.Slug,
+			Name:      row.Name,
+			Role:      api.OrganizationRole(row.Role),
+			CreatedAt: row.CreatedAt,
+		}
+	}
+	return organizations, nil
+}
+
+// Members lists the members of an organization to one of them.
+func (o *OrganizationService) Members(ctx context.Context, userID, organizationID int) ([]api.OrganizationMember, error) {
+	var rows []db.ListOrganizationMembersRow
+	err := o.inTenantTx(ctx, organizationID, func(ctx context.Context, q db.Querier) error {
+		if _, err := membership(ctx, q, organizationID, userID); err != nil {
+			return err
+		}
+		var err error
+		rows, err = q.ListOrganizationMembers(ctx, int32(organizationID))
+		if err != nil {
+			return fmt.Errorf("list members: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	members := make([]api.OrganizationMember, len(rows))
+	for i, row := range rows {
+		members[i] = api.OrganizationMember{
+			UserId:   int(row.UserID),
+			Name:     row.Name,
+			Email:    row.Email,
+			Role:     api.OrganizationRole(row.Role),
+			JoinedAt: row.JoinedAt,
+		}
+	}
+	return members, nil
+}
+
+// UpdateMemberRole changes the role of a member. Only owners touch the role
+// of an owner or make one, and the last owner keeps the role.
+func (o *OrganizationService) UpdateMemberRole(ctx context.Context, userID, organizationID, memberID int, role api.OrganizationRole) error {
+	if !isOrganizationRole(string(role)) {
+		return fmt.Errorf("%w: %v", UnknownRoleErr, role)
+	}
+	return o.inTenantTx(ctx, organizationID, func(ctx context.Context, q db.Querier) error {
+		actor, err := membership(ctx, q, organizationID, userID)
+		if err != nil {
+			return err
+		}
+		target, err := member(ctx, q, organizationID, memberID)
+		if err != nil {
+			return err
+		}
+		if !mayManage(actor.Role, target.Role) || !mayManage(actor.Role, string(role)) {
+			return OrganizationForbiddenErr
+		}
+		if target.Role == OrganizationOwner && string(role) != OrganizationOwner {
+			if err := keepOwner(ctx, q, organizationID); err != nil {
+				return err
+			}
+		}
+		if _, err := q.UpdateOrganizationMemberRole(ctx, db.UpdateOrganizationMemberRoleParams{
+			Role:           string(role),
+			OrganizationID: int32(organizationID),
+			UserID:         int32(memberID),
+		}); err != nil {
+			return fmt.Errorf("update member role: %w", err)
+		}
+		return nil
+	})
+}
+
+// RemoveMember removes a member from the organization. Members leave on their
+// own, otherwise the rules of UpdateMemberRole apply.
+func (o *OrganizationService) RemoveMember(ctx context.Context, userID, organizationID, memberID int) error {
+	return o.inTenantTx(ctx, organizationID, func(ctx context.Context, q db.Querier) error {
+		actor, err := membership(ctx, q, organizationID, userID)
+		if err != nil {
+			return err
+		}
+		target, err := member(ctx, q, organizationID, memberID)
+		if err != nil {
+			return err
+		}
+		if userID != memberID && !mayManage(actor.Role, target.Role) {
+			return OrganizationForbiddenErr
+		}
+		if target.Role == OrganizationOwner {
+			if err := keepOwner(ctx, q, organizationID); err != nil {
+				return err
+			}
+		}
+		if _, err := q.RemoveOrganizationMember(ctx, db.RemoveOrganizationMemberParams{
+			OrganizationID: int32(organizationID),
+			UserID:         int32(memberID),
+		}); err != nil {
+			return fmt.Errorf("remove member: %w", err)
+		}
+		return nil
+	})
+}
+
+// Invite creates an invitation to the organization and enqueues the mail of
+// its code to the address. Only the hash of the code is stored with the
+// invitation, the code is returned once.
+// The invitation expires when the model says, but no later than the
+// invitation ttl of the service.
+func (o *OrganizationService) Invite(ctx context.Context, userID, organizationID int, model api.CreateInvitationRequest) (api.Invitation, error) {
+	if model.Role != api.Admin && model.Role != api.Member {
+		return api.Invitation{}, fmt.Errorf("%w: %v", UnknownRoleErr, model.Role)
+	}
+	now := time.Now()
+	expiresAt := now.Add(o.invitationTTL)
+	if model.ExpiresAt != nil {
+		if !model.ExpiresAt.After(now) || model.ExpiresAt.After(expiresAt) {
+			return api.Invitation{}, fmt.Errorf("%w: %v", InvitationExpiryErr, model.ExpiresAt)
+		}
+		expiresAt = *model.ExpiresAt
+	}
+	code, err := randomToken()
+	if err != nil {
+		return api.Invitation{}, fmt.Errorf("generate invitation code: %w", err)
+	}
+	var (
+		invitation db.ScratchInvitation
+		inviter    db.ScratchUser
+	)
+	err = o.inTenantTx(ctx, organizationID, func(ctx context.Context, q db.Querier) error {
+		actor, err := membership(ctx, q, organizationID, userID)
+		if err != nil {
+			return err
+		}
+		if !mayInvite(actor.Role, string(model.Role)) {
+			return OrganizationForbiddenErr
+		}
+		if inviter, err = q.GetUserByID(ctx, int32(userID)); err != nil {
+			return fmt.Errorf("get inviter: %w", err)
+		}
+		invitation, err = q.CreateInvitation(ctx, db.CreateInvitationParams{
+			OrganizationID: int32(organizationID),
+			Email:          model.Email,
+			Role:           string(model.Role),
+			CodeHash:       hashToken(code),
+			InvitedBy:      sql.NullInt32{Int32: int32(userID), Valid: true},
+			Now:            now,
+			ExpiresAt:      expiresAt,
+		})
+		if err != nil {
+			return fmt.Errorf("create invitation: %w", err)
+		}
+		_, err = jobs.Enqueue(ctx, q, InvitationEmail{
+			InvitationID:   int(invitation.ID),
+			OrganizationID: organizationID,
+			Code:           code,
+			Link:           o.link(code),
+			Locale:         i18n.LocaleFrom(ctx),
+		}, jobs.Options{UniqueKey: strconv.Itoa(int(invitation.ID))})
+		return err
+	})
+	if err != nil {
+		return api.Invitation{}, err
+	}
+	return api.Invitation{
+		Id:        int(invitation.ID),
+		Email:     invitation.Email,
+		Role:      api.OrganizationRole(invitation.Role),
+		Code:      &code,
+		InvitedBy: &api.InvitationUser{Id: int(inviter.ID), Name: inviter.Name},
+		CreatedAt: invitation.CreatedAt,
+		ExpiresAt: invitation.ExpiresAt,
+	}, nil
+}
+
+// Invitations lists the invitations of an organization to one of its members,
+// with who sent and who accepted them.
+func (o *OrganizationService) Invitations(ctx context.Context, userID, organizationID int) ([]api.Invitation, error) {
+	var rows []db.ListOrganizationInvitationsRow
+	err := o.inTenantTx(ctx, organizationID, func(ctx context.Context, q db.Querier) error {
+		if _, err := membership(ctx, q, organizationID, userID); err != nil {
+			return err
+		}
+		var err error
+		rows, err = q.ListOrganizationInvitations(ctx, int32(organizationID))
+		if err != nil {
+			return fmt.Errorf("list invitations: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	invitations := make([]api.Invitation, len(rows))
+	for i, row := range rows {
+		invitations[i] = api.Invitation{
+			Id:         int(row.ID),
+			Email:      row.Email,
+			Role:       api.OrganizationRole(row.Role),
+			InvitedBy:  invitationUser(row.InvitedBy, row.InviterName),
+			AcceptedBy: invitationUser(row.AcceptedBy, row.AccepterName),
+			CreatedAt:  row.CreatedAt,
+			ExpiresAt:  row.ExpiresAt,
+			AcceptedAt: nullTimePtr(row.AcceptedAt),
+			RevokedAt:  nullTimePtr(row.RevokedAt),
+		}
+	}
+	return invitations, nil
+}
+
+// RevokeInvitation stops the code of a pending invitation from working.
+// Inviters revoke their own invitations, owners and admins those of the roles
+// they manage.
+func (o *OrganizationService) RevokeInvitation(ctx context.Context, userID, organizationID, invitationID int) error {
+	now := time.Now()
+	return o.inTenantTx(ctx, organizationID, func(ctx context.Context, q db.Querier) error {
+		actor, err := membership(ctx, q, organizationID, userID)
+		if err != nil {
+			return err
+		}
+		invitation, err := q.GetInvitation(ctx, db.GetInvitationParams{ID: int32(invitationID), OrganizationID: int32(organizationID)})
+		if errors.Is(err, db.ErrNoRows) {
+			return InvitationNotFoundErr
+		}
+		if err != nil {
+			return fmt.Errorf("get invitation: %w", err)
+		}
+		invitedBy := invitation.InvitedBy.Valid && int(invitation.InvitedBy.Int32) == userID
+		if !invitedBy && !mayManage(actor.Role, invitation.Role) {
+			return OrganizationForbiddenErr
+		}
+		revoked, err := q.RevokeInvitation(ctx, db.RevokeInvitationParams{
+			ID:             invitation.ID,
+			OrganizationID: invitation.OrganizationID,
+			Now:            now,
+		})
+		if err != nil {
+			return fmt.Errorf("revoke invitation: %w", err)
+		}
+		if revoked == 0 {
+			return InvitationNotFoundErr
+		}
+		return nil
+	})
+}
+
+// InvitationEmail mails the code of an invitation to the address invited, it
+// is enqueued together with the invitation. The invitation only keeps the hash
+// of the code, so the job carries the code and the link made of it. Locale is
+// the one of the inviting request, the locale of the inviter when empty.
+type InvitationEmail struct {
+	InvitationID   int    `json:"invitationId"`
+	OrganizationID int    `json:"organizationId"`
+	Code           string `json:"code"`
+	Link           string `json:"link,omitempty"`
+	Locale         string `json:"locale,omitempty"`
+}
+
+func (InvitationEmail) Kind() string { return "invitation_email" }
+
+// SendInvitationEmail runs an InvitationEmail job. An invitation accepted,
+// revoked or expired meanwhile is not mailed.
+func (o *OrganizationService) SendInvitationEmail(ctx context.Context, job InvitationEmail) error {
+	if o.mailer == nil {
+		return nil
+	}
+	var (
+		invitation   db.ScratchInvitation
+		organization db.ScratchOrganization
+		inviter      db.ScratchUser
+	)
+	err := o.inTenantTx(ctx, job.OrganizationID, func(ctx context.Context, q db.Querier) error {
+		var err error
+		if invitation, err = pendingInvitation(ctx, q, job.Code, time.Now()); err != nil {
+			return err
+		}
+		if organization, err = q.GetOrganization(ctx, invitation.OrganizationID); err != nil {
+			return fmt.Errorf("get organization: %w", err)
+		}
+		if !invitation.InvitedBy.Valid {
+			return nil
+		}
+		inviter, err = q.GetUserByID(ctx, invitation.InvitedBy.Int32)
+		if err != nil && !errors.Is(err, db.ErrNoRows) {
+			return fmt.Errorf("get inviter: %w", err)
+		}
+		return nil
+	})
+	if errors.Is(err, InvitationNotFoundErr) {
+		return jobs.Permanent(err)
+	}
+	if err != nil {
+		return err
+	}
+	message, err := invitationMessage(invitation, organization, inviter, job)
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+	if err := o.mailer.Send(ctx, message); err != nil {
+		return fmt.Errorf("send invitation mail: %w", err)
+	}
+	return nil
+}
+
+func invitationMessage(invitation db.ScratchInvitation, organization db.ScratchOrganization, inviter db.ScratchUser, job InvitationEmail) (mail.Message, error) {
+	locale := job.Locale
+	if locale == "" {
+		locale = inviter.Locale.String
+	}
+	message, err := mail.Default().Render("invitation", locale, mail.Invitation{
+		Name:         invitation.Email,
+		Inviter:      inviter.Name,
+		Organization: organization.Name,
+		Role:         invitation.Role,
+		Code:         job.Code,
+		Link:         job.Link,
+		Expires:      invitation.ExpiresAt.UTC().Format("2006-01-02 15:04 MST"),
+	})
+	if err != nil {
+		return mail.Message{}, fmt.Errorf("render invitation mail: %w", err)
+	}
+	message.ID = fmt.Sprintf("invitation/%d", invitation.ID)
+	message.To = invitation.Email
+	return message, nil
+}
+
+func (o *OrganizationService) link(code string) string {
+	if o.inviteLink == "" {
+		return ""
+	}
+	return o.inviteLink + code
+}
+
+// AcceptInvitation makes the user a member of the organization of the
+// invitation with the code, which must be addressed to the email of the user.
+// A user who is a member already keeps the role. The invitation is found by
+// its code before its organization is known, so the transaction sees every
+// tenant.
+func (o *OrganizationService) AcceptInvitation(ctx context.Context, userID int, code string) (api.Organization, error) {
+	now := time.Now()
+	var (
+		organization db.ScratchOrganization
+		joined       db.ScratchOrganizationMember
+	)
+	ctx = db.WithAllTenants(ctx)
+	err := o.inTx(ctx, func(q db.Querier) error {
+		invitation, err := pendingInvitation(ctx, q, code, now)
+		if err != nil {
+			return err
+		}
+		user, err := q.GetUserByID(ctx, int32(userID))
+		if errors.Is(err, db.ErrNoRows) {
+			return UserNotFoundErr
+		}
+		if err != nil {
+			return fmt.Errorf("get user: %w", err)
+		}
+		// the code may have leaked, it only works for the address it was sent to
+		if !strings.EqualFold(user.Email, invitation.Email) {
+			return InvitationNotFoundErr
+		}
+		if err := acceptInvitation(ctx, q, invitation, user.ID, now); err != nil {
+			return err
+		}
+		if joined, err = membership(ctx, q, int(invitation.OrganizationID), userID); err != nil {
+			return err
+		}
+		if organization, err = q.GetOrganization(ctx, invitation.OrganizationID); err != nil {
+			return fmt.Errorf("get organization: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return api.Organization{}, err
+	}
+	return api.Organization{
+		Id:        int(organization.ID),
+		Slug:      organization.Slug,
+		Name:      organization.Name,
+		Role:      api.OrganizationRole(joined.Role),
+		CreatedAt: organization.CreatedAt,
+	}, nil
+}
+
+// OrganizationToken issues an access token of the user scoped to an
+// organization it is a member of.
+func (o *OrganizationService) OrganizationToken(ctx context.Context, userID, organizationID int) (api.OrganizationToken, error) {
+	err := o.inTenantTx(ctx, organizationID, func(ctx context.Context, q db.Querier) error {
+		_, err := membership(ctx, q, organizationID, userID)
+		return err
+	})
+	if err != nil {
+		return api.OrganizationToken{}, err
+	}
+	token, err := o.tokenMaker.GenerateTenantToken(strconv.Itoa(userID), strconv.Itoa(organizationID))
+	if err != nil {
+		return api.OrganizationToken{}, fmt.Errorf("generate token: %w", err)
+	}
+	return api.OrganizationToken{Token: token}, nil
+}
+
+// pendingInvitation returns the invitation with the code while it can be
+// accepted.
+func pendingInvitation(ctx context.Context, q db.Querier, code string, now time.Time) (db.ScratchInvitation, error) {
+	invitation, err := q.GetInvitationByCode(ctx, hashToken(code))
+	if errors.Is(err, db.ErrNoRows) {
+		return db.ScratchInvitation{}, InvitationNotFoundErr
+	}
+	if err != nil {
+		return db.ScratchInvitation{}, fmt.Errorf("get invitation: %w", err)
+	}
+	if invitation.AcceptedAt.Valid || invitation.RevokedAt.Valid || !invitation.ExpiresAt.After(now) {
+		return db.ScratchInvitation{}, InvitationNotFoundErr
+	}
+	return invitation, nil
+}
+
+// acceptInvitation uses up the invitation and makes the user a member with
+// its role, a concurrent accept of the same invitation finds it used.
+func acceptInvitation(ctx context.Context, q db.Querier, invitation db.ScratchInvitation, userID int32, now time.Time) error {
+	accepted, err := q.AcceptInvitation(ctx, db.AcceptInvitationParams{
+		ID:         invitation.ID,
+		AcceptedBy: sql.NullInt32{Int32: userID, Valid: true},
+		Now:        now,
+	})
+	if err != nil {
+		return fmt.Errorf("accept invitation: %w", err)
+	}
+	if accepted == 0 {
+		return InvitationNotFoundErr
+	}
+	if _, err := q.AddOrganizationMember(ctx, db.AddOrganizationMemberParams{
+		OrganizationID: invitation.OrganizationID,
+		UserID:         userID,
+		Role:           invitation.Role,
+		Now:            now,
+	}); err != nil {
+		return fmt.Errorf("add member: %w", err)
+	}
+	return nil
+}
+
+// inTenantTx runs fn in a transaction scoped to the organization.
+func (o *OrganizationService) inTenantTx(ctx context.Context, organizationID int, fn func(ctx context.Context, q db.Querier) error) error {
+	ctx = db.WithTenant(ctx, int32(organizationID))
+	return o.inTx(ctx, func(q db.Querier) error { return fn(ctx, q) })
+}
+
+// membership returns the membership of the user, the organization is not
+// found by anyone else.
+func membership(ctx context.Context, q db.Querier, organizationID, userID int) (db.ScratchOrganizationMember, error) {
+	m, err := q.GetOrganizationMember(ctx, db.GetOrganizationMemberParams{
+		OrganizationID: int32(organizationID),
+		UserID:         int32(userID),
+	})
+	if errors.Is(err, db.ErrNoRows) {
+		return db.ScratchOrganizationMember{}, OrganizationNotFoundErr
+	}
+	if err != nil {
+		return db.ScratchOrganizationMember{}, fmt.Errorf("get membership: %w", err)
+	}
+	return m, nil
+}
+
+// member returns the membership of another user in an organization the
+// caller is a member of.
+func member(ctx context.Context, q db.Querier, organizationID, userID int) (db.ScratchOrganizationMember, error) {
+	m, err := membership(ctx, q, organizationID, userID)
+	if errors.Is(err, OrganizationNotFoundErr) {
+		return db.ScratchOrganizationMember{}, MemberNotFoundErr
+	}
+	return m, err
+}
+
+// keepOwner fails when the organization has a single owner left.
+func keepOwner(ctx context.Context, q db.Querier, organizationID int) error {
+	owners, err := q.CountOrganizationOwners(ctx, int32(organizationID))
+	if err != nil {
+		return fmt.Errorf("count owners: %w", err)
+	}
+	if owners < 2 {
+		return LastOwnerErr
+	}
+	return nil
+}
+
+// mayManage reports whether a member with role manages members with, or
+// grants, the role other.
+func mayManage(role, other string) bool {
+	switch role {
+	case OrganizationOwner:
+		return true
+	case OrganizationAdmin:
+		return other != OrganizationOwner
+	}
+	return false
+}
+
+// mayInvite reports whether a member with role invites others with the role
+// invited: every member invites members, and whom it manages.
+func mayInvite(role, invited string) bool {
+	return invited == OrganizationMember && isOrganizationRole(role) || mayManage(role, invited)
+}
+
+func invitationUser(id sql.NullInt32, name sql.NullString) *api.InvitationUser {
+	if !id.Valid {
+		return nil
+	}
+	return &api.InvitationUser{Id: int(id.Int32), Name: name.String}
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func isOrganizationRole(role string) bool {
+	switch role {
+	case OrganizationOwner, OrganizationAdmin, OrganizationMember:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,579 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"scratch/api"
+	"scratch/internal/authorization/session"
+	"scratch/internal/jobs"
+	"scratch/internal/mail"
+	db "scratch/internal/storage/database"
+	mockdb "scratch/internal/storage/database/mock"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrganizationService_CreateOrganization(t *testing.T) {
+	tests := []struct {
+		name        string
+		prepareMock func(queries *mockdb.MockQuerier)
+		wantErr     error
+	}{
+		{
+			name: "success",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().CreateOrganization(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, arg db.CreateOrganizationParams) (db.ScratchOrganization, error) {
+						assert.Equal(t, "acme", arg.Slug)
+						return db.ScratchOrganization{ID: 5, Slug: arg.Slug, Name: arg.Name, CreatedAt: arg.Now}, nil
+					})
+				queries.EXPECT().AddOrganizationMember(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, arg db.AddOrganizationMemberParams) (int64, error) {
+						assert.Equal(t, db.AddOrganizationMemberParams{OrganizationID: 5, UserID: 3, Role: "owner", Now: arg.Now}, arg)
+						return 1, nil
+					})
+			},
+		},
+		{
+			name: "fail - slug taken",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().CreateOrganization(gomock.Any(), gomock.Any()).
+					Return(db.ScratchOrganization{}, &pgconn.PgError{Code: db.UniqueViolation})
+			},
+			wantErr: OrganizationSlugTakenErr,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockQuerier(ctrl)
+			tt.prepareMock(mockQueries)
+			s := NewOrganizationService(mockQueries, nil, nil, nil, slog.Logger{})
+
+			organization, err := s.CreateOrganization(context.Background(), 3, api.CreateOrganizationRequest{Slug: "acme", Name: "Acme"})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 5, organization.Id)
+			assert.Equal(t, api.Owner, organization.Role)
+		})
+	}
+}
+
+func TestOrganizationService_UpdateMemberRole(t *testing.T) {
+	tests := []struct {
+		name        string
+		role        api.OrganizationRole
+		prepareMock func(queries *mockdb.MockQuerier)
+		wantErr     error
+	}{
+		{
+			name: "success - an owner steps down next to another",
+			role: api.Admin,
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				expectMember(queries, 3, "owner")
+				expectMember(queries, 4, "owner")
+				queries.EXPECT().CountOrganizationOwners(gomock.Any(), int32(5)).Return(int64(2), nil)
+				queries.EXPECT().UpdateOrganizationMemberRole(gomock.Any(), db.UpdateOrganizationMemberRoleParams{
+					Role: "admin", OrganizationID: 5, UserID: 4,
+				}).Return(int64(1), nil)
+			},
+		},
+		{
+			name: "success - an admin promotes a member",
+			role: api.Admin,
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				expectMember(queries, 3, "admin")
+				expectMember(queries, 4, "member")
+				queries.EXPECT().UpdateOrganizationMemberRole(gomock.Any(), gomock.Any()).Return(int64(1), nil)
+			},
+		},
+		{
+			name: "fail - an admin makes an owner",
+			role: api.Owner,
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				expectMember(queries, 3, "admin")
+				expectMember(queries, 4, "member")
+			},
+			wantErr: OrganizationForbiddenErr,
+		},
+		{
+			name: "fail - a member changes roles",
+			role: api.Member,
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				expectMember(queries, 3, "member")
+				expectMember(queries, 4, "admin")
+			},
+			wantErr: OrganizationForbiddenErr,
+		},
+		{
+			name: "fail - the last owner",
+			role: api.Member,
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				expectMember(queries, 3, "owner")
+				expectMember(queries, 4, "owner")
+				queries.EXPECT().CountOrganizationOwners(gomock.Any(), int32(5)).Return(int64(1), nil)
+			},
+			wantErr: LastOwnerErr,
+		},
+		{
+			name: "fail - not a member",
+			role: api.Member,
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetOrganizationMember(gomock.Any(), db.GetOrganizationMemberParams{OrganizationID: 5, UserID: 3}).
+					Return(db.ScratchOrganizationMember{}, db.ErrNoRows)
+			},
+			wantErr: OrganizationNotFoundErr,
+		},
+		{
+			name: "fail - no such member",
+			role: api.Member,
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				expectMember(queries, 3, "owner")
+				queries.EXPECT().GetOrganizationMember(gomock.Any(), db.GetOrganizationMemberParams{OrganizationID: 5, UserID: 4}).
+					Return(db.ScratchOrganizationMember{}, db.ErrNoRows)
+			},
+			wantErr: MemberNotFoundErr,
+		},
+		{
+			name:        "fail - unknown role",
+			role:        "boss",
+			prepareMock: func(queries *mockdb.MockQuerier) {},
+			wantErr:     UnknownRoleErr,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockQuerier(ctrl)
+			tt.prepareMock(mockQueries)
+			s := NewOrganizationService(mockQueries, nil, nil, nil, slog.Logger{})
+
+			assert.ErrorIs(t, s.UpdateMemberRole(context.Background(), 3, 5, 4, tt.role), tt.wantErr)
+		})
+	}
+}
+
+func TestOrganizationService_RemoveMember(t *testing.T) {
+	tests := []struct {
+		name        string
+		memberID    int
+		prepareMock func(queries *mockdb.MockQuerier)
+		wantErr     error
+	}{
+		{
+			name:     "success - a member leaves",
+			memberID: 3,
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				expectMember(queries, 3, "member")
+				expectMember(queries, 3, "member")
+				queries.EXPECT().RemoveOrganizationMember(gomock.Any(), db.RemoveOrganizationMemberParams{OrganizationID: 5, UserID: 3}).
+					Return(int64(1), nil)
+			},
+		},
+		{
+			name:     "success - an admin removes a member",
+			memberID: 4,
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				expectMember(queries, 3, "admin")
+				expectMember(queries, 4, "member")
+				queries.EXPECT().RemoveOrganizationMember(gomock.Any(), gomock.Any()).Return(int64(1), nil)
+			},
+		},
+		{
+			name:     "fail - an admin removes an owner",
+			memberID: 4,
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				expectMember(queries, 3, "admin")
+				expectMember(queries, 4, "owner")
+			},
+			wantErr: OrganizationForbiddenErr,
+		},
+		{
+			name:     "fail - the last owner leaves",
+			memberID: 3,
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				expectMember(queries, 3, "owner")
+				expectMember(queries, 3, "owner")
+				queries.EXPECT().CountOrganizationOwners(gomock.Any(), int32(5)).Return(int64(1), nil)
+			},
+			wantErr: LastOwnerErr,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockQuerier(ctrl)
+			tt.prepareMock(mockQueries)
+			s := NewOrganizationService(mockQueries, nil, nil, nil, slog.Logger{})
+
+			assert.ErrorIs(t, s.RemoveMember(context.Background(), 3, 5, tt.memberID), tt.wantErr)
+		})
+	}
+}
+
+func TestOrganizationService_Invite(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		var codeHash string
+		queries := mockdb.NewMockQuerier(ctrl)
+		expectMember(queries, 3, "admin")
+		queries.EXPECT().GetUserByID(gomock.Any(), int32(3)).Return(db.ScratchUser{ID: 3, Name: "Ann"}, nil)
+		queries.EXPECT().CreateInvitation(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, arg db.CreateInvitationParams) (db.ScratchInvitation, error) {
+				assert.Equal(t, "joe@example.com", arg.Email)
+				assert.Equal(t, "member", arg.Role)
+				assert.Equal(t, arg.Now.Add(48*time.Hour), arg.ExpiresAt)
+				assert.Equal(t, sql.NullInt32{Int32: 3, Valid: true}, arg.InvitedBy)
+				codeHash = arg.CodeHash
+				return db.ScratchInvitation{
+					ID: 1, OrganizationID: 5, Email: arg.Email, Role: arg.Role, CodeHash: arg.CodeHash, ExpiresAt: arg.ExpiresAt,
+				}, nil
+			})
+		var job InvitationEmail
+		queries.EXPECT().EnqueueJob(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, arg db.EnqueueJobParams) (int64, error) {
+				assert.Equal(t, "invitation_email", arg.Kind)
+				assert.Equal(t, sql.NullString{String: "1", Valid: true}, arg.UniqueKey, "one mail per invitation")
+				require.NoError(t, json.Unmarshal(arg.Payload, &job))
+				return 1, nil
+			})
+
+		s := NewOrganizationService(queries, nil, nil, nil, slog.Logger{}).
+			WithInvitationTTL(48 * time.Hour).
+			WithInviteLink("https://chat.example.com/web/signup?invite=")
+		invitation, err := s.Invite(context.Background(), 3, 5, api.CreateInvitationRequest{Email: "joe@example.com", Role: api.Member})
+		require.NoError(t, err)
+		require.NotNil(t, invitation.Code)
+		assert.Equal(t, hashToken(*invitation.Code), codeHash, "only the hash is stored")
+		assert.Equal(t, &api.InvitationUser{Id: 3, Name: "Ann"}, invitation.InvitedBy)
+		assert.Equal(t, InvitationEmail{
+			InvitationID:   1,
+			OrganizationID: 5,
+			Code:           *invitation.Code,
+			Link:           "https://chat.example.com/web/signup?invite=" + *invitation.Code,
+		}, job)
+	})
+
+	t.Run("success - members invite members until they choose", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+		queries := mockdb.NewMockQuerier(ctrl)
+		expectMember(queries, 3, "member")
+		queries.EXPECT().GetUserByID(gomock.Any(), int32(3)).Return(db.ScratchUser{ID: 3, Name: "Ann"}, nil)
+		queries.EXPECT().CreateInvitation(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, arg db.CreateInvitationParams) (db.ScratchInvitation, error) {
+				assert.Equal(t, expiresAt, arg.ExpiresAt)
+				return db.ScratchInvitation{ID: 1, Email: arg.Email, Role: arg.Role, ExpiresAt: arg.ExpiresAt}, nil
+			})
+		queries.EXPECT().EnqueueJob(gomock.Any(), gomock.Any()).Return(int64(1), nil)
+
+		s := NewOrganizationService(queries, nil, nil, nil, slog.Logger{})
+		invitation, err := s.Invite(context.Background(), 3, 5, api.CreateInvitationRequest{Email: "joe@example.com", Role: api.Member, ExpiresAt: &expiresAt})
+		require.NoError(t, err)
+		assert.Equal(t, expiresAt, invitation.ExpiresAt)
+	})
+
+	t.Run("fail - members do not invite admins", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		queries := mockdb.NewMockQuerier(ctrl)
+		expectMember(queries, 3, "member")
+
+		s := NewOrganizationService(queries, nil, nil, nil, slog.Logger{})
+		_, err := s.Invite(context.Background(), 3, 5, api.CreateInvitationRequest{Email: "joe@example.com", Role: api.Admin})
+		assert.ErrorIs(t, err, OrganizationForbiddenErr)
+	})
+
+	t.Run("fail - expires after the ttl", func(t *testing.T) {
+		expiresAt := time.Now().Add(49 * time.Hour)
+		s := NewOrganizationService(nil, nil, nil, nil, slog.Logger{}).WithInvitationTTL(48 * time.Hour)
+		_, err := s.Invite(context.Background(), 3, 5, api.CreateInvitationRequest{Email: "joe@example.com", Role: api.Member, ExpiresAt: &expiresAt})
+		assert.ErrorIs(t, err, InvitationExpiryErr)
+	})
+}
+
+func TestOrganizationService_SendInvitationEmail(t *testing.T) {
+	code := "secret-code"
+	job := InvitationEmail{InvitationID: 1, OrganizationID: 5, Code: code, Link: "https://chat.example.com/web/signup?invite=" + code}
+	pending := db.ScratchInvitation{
+		ID: 1, OrganizationID: 5, Email: "joe@example.com", Role: "member", CodeHash: hashToken(code),
+		InvitedBy: sql.NullInt32{Int32: 3, Valid: true}, ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		queries := mockdb.NewMockQuerier(ctrl)
+		queries.EXPECT().GetInvitationByCode(gomock.Any(), hashToken(code)).Return(pending, nil)
+		queries.EXPECT().GetOrganization(gomock.Any(), int32(5)).Return(db.ScratchOrganization{ID: 5, Name: "Acme"}, nil)
+		queries.EXPECT().GetUserByID(gomock.Any(), int32(3)).Return(db.ScratchUser{ID: 3, Name: "Ann"}, nil)
+
+		sender := mail.NewMemorySender()
+		s := NewOrganizationService(queries, nil, nil, sender, slog.Logger{})
+		require.NoError(t, s.SendInvitationEmail(context.Background(), job))
+
+		require.Len(t, sender.Messages(), 1)
+		message := sender.Messages()[0]
+		assert.Equal(t, "invitation/1", message.ID, "sent once per invitation")
+		assert.Equal(t, "joe@example.com", message.To)
+		assert.Contains(t, message.Text, "Ann invited you to join Acme")
+		assert.Contains(t, message.Text, "https://chat.example.com/web/signup?invite="+code)
+	})
+
+	t.Run("fail - revoked meanwhile", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		revoked := pending
+		revoked.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		queries := mockdb.NewMockQuerier(ctrl)
+		queries.EXPECT().GetInvitationByCode(gomock.Any(), hashToken(code)).Return(revoked, nil)
+
+		sender := mail.NewMemorySender()
+		s := NewOrganizationService(queries, nil, nil, sender, slog.Logger{})
+		err := s.SendInvitationEmail(context.Background(), job)
+		assert.ErrorIs(t, err, InvitationNotFoundErr)
+		assert.True(t, jobs.IsPermanent(err))
+		assert.Empty(t, sender.Messages())
+	})
+}
+
+func TestOrganizationService_Invitations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	queries := mockdb.NewMockQuerier(ctrl)
+	expectMember(queries, 3, "member")
+	acceptedAt := time.Now()
+	queries.EXPECT().ListOrganizationInvitations(gomock.Any(), int32(5)).Return([]db.ListOrganizationInvitationsRow{
+		{
+			ID: 2, Email: "joe@example.com", Role: "member",
+			InvitedBy: sql.NullInt32{Int32: 3, Valid: true}, InviterName: sql.NullString{String: "Ann", Valid: true},
+			AcceptedBy: sql.NullInt32{Int32: 4, Valid: true}, AccepterName: sql.NullString{String: "Joe", Valid: true},
+			AcceptedAt: sql.NullTime{Time: acceptedAt, Valid: true},
+		},
+		{ID: 1, Email: "bob@example.com", Role: "admin"},
+	}, nil)
+
+	s := NewOrganizationService(queries, nil, nil, nil, slog.Logger{})
+	invitations, err := s.Invitations(context.Background(), 3, 5)
+	require.NoError(t, err)
+	require.Len(t, invitations, 2)
+	assert.Equal(t, &api.InvitationUser{Id: 3, Name: "Ann"}, invitations[0].InvitedBy)
+	assert.Equal(t, &api.InvitationUser{Id: 4, Name: "Joe"}, invitations[0].AcceptedBy)
+	assert.Equal(t, &acceptedAt, invitations[0].AcceptedAt)
+	assert.Nil(t, invitations[1].InvitedBy, "the inviter is gone")
+	assert.Nil(t, invitations[1].AcceptedAt)
+}
+
+func TestOrganizationService_RevokeInvitation(t *testing.T) {
+	invitation := db.ScratchInvitation{ID: 1, OrganizationID: 5, Role: "member", InvitedBy: sql.NullInt32{Int32: 3, Valid: true}}
+
+	tests := []struct {
+		name        string
+		prepareMock func(queries *mockdb.MockQuerier)
+		wantErr     error
+	}{
+		{
+			name: "success - the inviter revokes",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				expectMember(queries, 3, "member")
+				queries.EXPECT().GetInvitation(gomock.Any(), db.GetInvitationParams{ID: 1, OrganizationID: 5}).Return(invitation, nil)
+				queries.EXPECT().RevokeInvitation(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, arg db.RevokeInvitationParams) (int64, error) {
+						assert.Equal(t, db.RevokeInvitationParams{ID: 1, OrganizationID: 5, Now: arg.Now}, arg)
+						return 1, nil
+					})
+			},
+		},
+		{
+			name: "success - an admin revokes",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				expectMember(queries, 3, "admin")
+				other := invitation
+				other.InvitedBy = sql.NullInt32{Int32: 4, Valid: true}
+				queries.EXPECT().GetInvitation(gomock.Any(), gomock.Any()).Return(other, nil)
+				queries.EXPECT().RevokeInvitation(gomock.Any(), gomock.Any()).Return(int64(1), nil)
+			},
+		},
+		{
+			name: "fail - a member revokes the invitation of another",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				expectMember(queries, 3, "member")
+				other := invitation
+				other.InvitedBy = sql.NullInt32{}
+				queries.EXPECT().GetInvitation(gomock.Any(), gomock.Any()).Return(other, nil)
+			},
+			wantErr: OrganizationForbiddenErr,
+		},
+		{
+			name: "fail - accepted",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				expectMember(queries, 3, "owner")
+				queries.EXPECT().GetInvitation(gomock.Any(), gomock.Any()).Return(invitation, nil)
+				queries.EXPECT().RevokeInvitation(gomock.Any(), gomock.Any()).Return(int64(0), nil)
+			},
+			wantErr: InvitationNotFoundErr,
+		},
+		{
+			name: "fail - of another organization",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				expectMember(queries, 3, "owner")
+				queries.EXPECT().GetInvitation(gomock.Any(), gomock.Any()).Return(db.ScratchInvitation{}, db.ErrNoRows)
+			},
+			wantErr: InvitationNotFoundErr,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockQuerier(ctrl)
+			tt.prepareMock(mockQueries)
+			s := NewOrganizationService(mockQueries, nil, nil, nil, slog.Logger{})
+
+			assert.ErrorIs(t, s.RevokeInvitation(context.Background(), 3, 5, 1), tt.wantErr)
+		})
+	}
+}
+
+func TestOrganizationService_AcceptInvitation(t *testing.T) {
+	invitation := db.ScratchInvitation{
+		ID: 1, OrganizationID: 5, Email: "Joe@Example.com", Role: "admin", CodeHash: hashToken("code"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	tests := []struct {
+		name        string
+		prepareMock func(queries *mockdb.MockQuerier)
+		wantErr     error
+	}{
+		{
+			name: "success",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetInvitationByCode(gomock.Any(), hashToken("code")).Return(invitation, nil)
+				queries.EXPECT().GetUserByID(gomock.Any(), int32(3)).Return(db.ScratchUser{ID: 3, Email: "joe@example.com"}, nil)
+				queries.EXPECT().AcceptInvitation(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, arg db.AcceptInvitationParams) (int64, error) {
+						assert.Equal(t, int32(1), arg.ID)
+						assert.Equal(t, sql.NullInt32{Int32: 3, Valid: true}, arg.AcceptedBy)
+						return 1, nil
+					})
+				queries.EXPECT().AddOrganizationMember(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, arg db.AddOrganizationMemberParams) (int64, error) {
+						assert.Equal(t, db.AddOrganizationMemberParams{OrganizationID: 5, UserID: 3, Role: "admin", Now: arg.Now}, arg)
+						return 1, nil
+					})
+				expectMember(queries, 3, "admin")
+				queries.EXPECT().GetOrganization(gomock.Any(), int32(5)).Return(db.ScratchOrganization{ID: 5, Slug: "acme", Name: "Acme"}, nil)
+			},
+		},
+		{
+			name: "fail - unknown code",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetInvitationByCode(gomock.Any(), gomock.Any()).Return(db.ScratchInvitation{}, db.ErrNoRows)
+			},
+			wantErr: InvitationNotFoundErr,
+		},
+		{
+			name: "fail - addressed to someone else",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetInvitationByCode(gomock.Any(), gomock.Any()).Return(invitation, nil)
+				queries.EXPECT().GetUserByID(gomock.Any(), int32(3)).Return(db.ScratchUser{ID: 3, Email: "ann@example.com"}, nil)
+			},
+			wantErr: InvitationNotFoundErr,
+		},
+		{
+			name: "fail - revoked",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				revoked := invitation
+				revoked.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+				queries.EXPECT().GetInvitationByCode(gomock.Any(), gomock.Any()).Return(revoked, nil)
+			},
+			wantErr: InvitationNotFoundErr,
+		},
+		{
+			name: "fail - accepted concurrently",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetInvitationByCode(gomock.Any(), gomock.Any()).Return(invitation, nil)
+				queries.EXPECT().GetUserByID(gomock.Any(), int32(3)).Return(db.ScratchUser{ID: 3, Email: "joe@example.com"}, nil)
+				queries.EXPECT().AcceptInvitation(gomock.Any(), gomock.Any()).Return(int64(0), nil)
+			},
+			wantErr: InvitationNotFoundErr,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockQuerier(ctrl)
+			tt.prepareMock(mockQueries)
+			s := NewOrganizationService(mockQueries, nil, nil, nil, *slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			organization, err := s.AcceptInvitation(context.Background(), 3, "code")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, api.Organization{Id: 5, Slug: "acme", Name: "Acme", Role: api.Admin}, organization)
+		})
+	}
+}
+
+func TestOrganizationService_OrganizationToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	queries := mockdb.NewMockQuerier(ctrl)
+	tokenMaker := session.NewMockIdentityGenerator(ctrl)
+	expectMember(queries, 3, "member")
+	tokenMaker.EXPECT().GenerateTenantToken("3", "5").Return("token", nil)
+	queries.EXPECT().GetOrganizationMember(gomock.Any(), db.GetOrganizationMemberParams{OrganizationID: 6, UserID: 3}).
+		Return(db.ScratchOrganizationMember{}, db.ErrNoRows)
+
+	s := NewOrganizationService(queries, nil, tokenMaker, nil, slog.Logger{})
+	token, err := s.OrganizationToken(context.Background(), 3, 5)
+	require.NoError(t, err)
+	assert.Equal(t, "token", token.Token)
+
+	_, err = s.OrganizationToken(context.Background(), 3, 6)
+	assert.ErrorIs(t, err, OrganizationNotFoundErr, "not a member")
+}
+
+// expectMember expects the membership of the user in organization 5 to be
+// looked up once.
+func expectMember(queries *mockdb.MockQuerier, userID int32, role string) {
+	queries.EXPECT().GetOrganizationMember(gomock.Any(), db.GetOrganizationMemberParams{OrganizationID: 5, UserID: userID}).
+		Return(db.ScratchOrganizationMember{OrganizationID: 5, UserID: userID, Role: role}, nil)
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"scratch/internal/i18n"
+	db "scratch/internal/storage/database"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Profile is what a user can see and edit about themselves.
+type Profile struct {
+	ID    int
+	Name  string
+	Email string
+	// Locale is empty when it is negotiated from Accept-Language.
+	Locale string
+}
+
+// SessionInfo describes one session of a user, held by a browser in a cookie
+// or by an api client as a refresh token.
+type SessionInfo struct {
+	ID         int
+	Browser    bool
+	DeviceName string
+	UserAgent  string
+	// IP is the address the session was last seen from.
+	IP         string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+	// Current marks the browser session the listing was requested from.
+	Current bool
+}
+
+func (a *AccountService) Profile(ctx context.Context, userID int) (Profile, error) {
+	user, err := a.getUserByID(ctx, userID)
+	if err != nil {
+		return Profile{}, err
+	}
+	return Profile{ID: int(user.ID), Name: user.Name, Email: user.Email, Locale: user.Locale.String}, nil
+}
+
+// UpdateProfile changes the name and the preferred locale of the user, an
+// empty locale goes back to negotiating it from Accept-Language.
+func (a *AccountService) UpdateProfile(ctx context.Context, userID int, name, locale string) error {
+	var value sql.NullString
+	if locale != "" {
+		if !i18n.Default().Supports(locale) {
+			return fmt.Errorf("%w: %v", UnsupportedLocaleErr, locale)
+		}
+		value = sql.NullString{String: i18n.Default().Match(locale), Valid: true}
+	}
+
+	n, err := a.db.UpdateUserProfile(ctx, db.UpdateUserProfileParams{ID: int32(userID), Name: name, Locale: value})
+	if err != nil {
+		return fmt.Errorf("update profile: %w", err)
+	}
+	if n == 0 {
+		return UserNotFoundErr
+	}
+	return nil
+}
+
+// ChangePassword replaces the password of the user once the current one is
+// confirmed, and ends every other session of the user, which may be held by
+// whoever learned the old password. The browser session whose cookie token is
+// keep stays signed in.
+func (a *AccountService) ChangePassword(ctx context.Context, userID int, current, password, keep string) error {
+	user, err := a.getUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(current)); err != nil {
+		return IncorrectPasswordErr
+	}
+	return a.setPassword(ctx, user.Email, password, func(q db.Querier, id int32) error {
+		_, err := q.DeleteOtherUserSessions(ctx, db.DeleteOtherUserSessionsParams{UserID: id, KeepHash: hashToken(keep)})
+		if err != nil {
+			return fmt.Errorf("delete other sessions: %w", err)
+		}
+		return nil
+	})
+}
+
+// Sessions lists the live sessions of the user, the most recently seen
+// first. The browser session whose cookie token is current is marked, an
+// empty current marks none.
+func (a *AccountService) Sessions(ctx context.Context, userID int, current string) ([]SessionInfo, error) {
+	rows, err := a.db.ListSessions(ctx, db.ListSessionsParams{UserID: int32(userID), Now: time.Now()})
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	currentHash := hashToken(current)
+	sessions := make([]SessionInfo, len(rows))
+	for i, row := range rows {
+		sessions[i] = SessionInfo{
+			ID:         int(row.ID),
+			Browser:    row.Browser,
+			DeviceName: row.DeviceName,
+			UserAgent:  row.UserAgent,
+			IP:         row.Ip,
+			CreatedAt:  row.CreatedAt,
+			LastSeenAt: row.LastSeenAt,
+			ExpiresAt:  row.ExpiresAt,
+			Current:    current != "" && row.Browser && row.TokenHash == currentHash,
+		}
+	}
+	return sessions, nil
+}
+
+// RevokeSession ends one session of the user.
+func (a *AccountService) RevokeSession(ctx context.Context, userID, sessionID int) error {
+	n, err := a.db.DeleteUserSession(ctx, db.DeleteUserSessionParams{ID: int32(sessionID), UserID: int32(userID)})
+	if err != nil {
+		return fmt.Errorf("delete session: %w", err)
+	}
+	if n == 0 {
+		return SessionNotFoundErr
+	}
+	return nil
+}
+
+func (a *AccountService) getUserByID(ctx context.Context, id int) (db.ScratchUser, error) {
+	user, err := a.db.GetUserByID(ctx, int32(id))
+	if err != nil {
+		if errors.Is(err, db.ErrNoRows) {
+			return db.ScratchUser{}, UserNotFoundErr
+		}
+		return db.ScratchUser{}, fmt.Errorf("get user by id: %w", err)
+	}
+	return user, nil
+}
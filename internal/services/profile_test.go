@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	db "scratch/internal/storage/database"
+	mockdb "scratch/internal/storage/database/mock"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAccountService_UpdateProfile(t *testing.T) {
+	tests := []struct {
+		name        string
+		locale      string
+		prepareMock func(queries *mockdb.MockQuerier)
+		wantErr     error
+	}{
+		{
+			name:   "success - with a locale",
+			locale: "pl-PL",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().UpdateUserProfile(gomock.Any(), db.UpdateUserProfileParams{
+					ID: 3, Name: "Joe", Locale: sql.NullString{String: "pl", Valid: true},
+				}).Return(int64(1), nil)
+			},
+		},
+		{
+			name: "success - without a locale",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().UpdateUserProfile(gomock.Any(), db.UpdateUserProfileParams{ID: 3, Name: "Joe"}).Return(int64(1), nil)
+			},
+		},
+		{
+			name:        "fail - unsupported locale",
+			locale:      "tlh",
+			prepareMock: func(queries *mockdb.MockQuerier) {},
+			wantErr:     UnsupportedLocaleErr,
+		},
+		{
+			name: "fail - user not found",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().UpdateUserProfile(gomock.Any(), gomock.Any()).Return(int64(0), nil)
+			},
+			wantErr: UserNotFoundErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockQuerier(ctrl)
+			tt.prepareMock(mockQueries)
+			s := NewAccountService(mockQueries, nil, nil, nil, slog.Logger{})
+
+			assert.ErrorIs(t, s.UpdateProfile(context.Background(), 3, "Joe", tt.locale), tt.wantErr)
+		})
+	}
+}
+
+func TestAccountService_ChangePassword(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("Test123!"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := db.ScratchUser{ID: 3, Email: "joedoe@gmail.com", Password: string(hash)}
+	customErr := errors.New("unexpected error")
+
+	tests := []struct {
+		name        string
+		current     string
+		prepareMock func(queries *mockdb.MockQuerier)
+		wantErr     error
+	}{
+		{
+			name:    "success",
+			current: "Test123!",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetUserByID(gomock.Any(), int32(3)).Return(user, nil)
+				queries.EXPECT().UpdateUserPassword(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, arg db.UpdateUserPasswordParams) (int32, error) {
+						assert.Equal(t, "joedoe@gmail.com", arg.Email)
+						assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(arg.Password), []byte("Better123!")))
+						return 3, nil
+					})
+				queries.EXPECT().DeleteOtherUserSessions(gomock.Any(), db.DeleteOtherUserSessionsParams{UserID: 3, KeepHash: hashToken("token")}).
+					Return(int64(2), nil)
+				queries.EXPECT().InsertOutboxEvent(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, arg db.InsertOutboxEventParams) error {
+						assert.Equal(t, "user.password_changed", arg.EventType)
+						assert.Equal(t, "3", arg.AggregateID)
+						return nil
+					})
+			},
+		},
+		{
+			name:    "fail - incorrect current password",
+			current: "wrong",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetUserByID(gomock.Any(), int32(3)).Return(user, nil)
+			},
+			wantErr: IncorrectPasswordErr,
+		},
+		{
+			name:    "fail - delete other sessions",
+			current: "Test123!",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetUserByID(gomock.Any(), int32(3)).Return(user, nil)
+				queries.EXPECT().UpdateUserPassword(gomock.Any(), gomock.Any()).Return(int32(3), nil)
+				queries.EXPECT().DeleteOtherUserSessions(gomock.Any(), gomock.Any()).Return(int64(0), customErr)
+			},
+			wantErr: customErr,
+		},
+		{
+			name:    "fail - user not found",
+			current: "Test123!",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetUserByID(gomock.Any(), int32(3)).Return(db.ScratchUser{}, db.ErrNoRows)
+			},
+			wantErr: UserNotFoundErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockQuerier(ctrl)
+			tt.prepareMock(mockQueries)
+			s := NewAccountService(mockQueries, nil, nil, nil, slog.Logger{})
+
+			assert.ErrorIs(t, s.ChangePassword(context.Background(), 3, tt.current, "Better123!", "token"), tt.wantErr)
+		})
+	}
+}
+
+func TestAccountService_Sessions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+	mockQueries.EXPECT().ListSessions(gomock.Any(), gomock.Any()).Return([]db.ListSessionsRow{
+		{ID: 3, DeviceName: "Joe's phone", Ip: "192.0.2.2"},
+		{ID: 2, Browser: true, TokenHash: hashToken("other")},
+		{ID: 1, Browser: true, TokenHash: hashToken("token"), DeviceName: "Firefox on Linux"},
+	}, nil)
+
+	s := NewAccountService(mockQueries, nil, nil, nil, slog.Logger{})
+	got, err := s.Sessions(context.Background(), 3, "token")
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	assert.Equal(t, SessionInfo{ID: 3, DeviceName: "Joe's phone", IP: "192.0.2.2"}, got[0])
+	assert.False(t, got[1].Current)
+	assert.True(t, got[2].Current)
+	assert.Equal(t, "Firefox on Linux", got[2].DeviceName)
+}
+
+func TestAccountService_RevokeSession(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+	mockQueries.EXPECT().DeleteUserSession(gomock.Any(), db.DeleteUserSessionParams{ID: 7, UserID: 3}).Return(int64(0), nil)
+
+	s := NewAccountService(mockQueries, nil, nil, nil, slog.Logger{})
+	assert.ErrorIs(t, s.RevokeSession(context.Background(), 3, 7), SessionNotFoundErr)
+}
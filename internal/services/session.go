@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"scratch/api"
+	"scratch/internal/events"
+	db "scratch/internal/storage/database"
+	"time"
+)
+
+var SessionNotFoundErr = errors.New("session not found or expired")
+
+// lastSeenInterval limits how often the last seen time of a browser session
+// is written, it would otherwise be written on every request.
+const lastSeenInterval = time.Minute
+
+// BrowserSession is the session of a web browser. It is identified by a
+// cookie holding Token instead of a bearer token, and every state changing
+// request of it has to echo CSRFToken.
+type BrowserSession struct {
+	UserID int
+	// Token is only known right after login, the database keeps its hash.
+	Token     string
+	CSRFToken string
+	ExpiresAt time.Time
+}
+
+// LoginBrowser checks the credentials like Login and starts a browser session lasting ttl.
+func (a *AccountService) LoginBrowser(ctx context.Context, model api.LoginUserRequest, ttl time.Duration) (BrowserSession, error) {
+	user, err := a.authenticate(ctx, model)
+	if err != nil {
+		return BrowserSession{}, err
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return BrowserSession{}, fmt.Errorf("generate session token: %w", err)
+	}
+	csrfToken, err := randomToken()
+	if err != nil {
+		return BrowserSession{}, fmt.Errorf("generate csrf token: %w", err)
+	}
+
+	now := time.Now()
+	device := deviceOf(ctx, model.DeviceName)
+	s := BrowserSession{UserID: int(user.ID), Token: token, CSRFToken: csrfToken, ExpiresAt: now.Add(ttl)}
+	err = a.inTx(ctx, func(q db.Querier) error {
+		sessionID, err := q.CreateBrowserSession(ctx, db.CreateBrowserSessionParams{
+			UserID:     user.ID,
+			TokenHash:  hashToken(token),
+			CsrfToken:  csrfToken,
+			Now:        now,
+			ExpiresAt:  s.ExpiresAt,
+			UserAgent:  device.UserAgent,
+			Ip:         device.IP,
+			DeviceName: device.Name,
+		})
+		if err != nil {
+			return fmt.Errorf("create browser session: %w", err)
+		}
+		if err := a.rememberDevice(ctx, q, user, device, sessionID, now); err != nil {
+			return err
+		}
+		return events.Record(ctx, q, loggedIn(user, device, true), now)
+	})
+	if err != nil {
+		return BrowserSession{}, err
+	}
+	return s, nil
+}
+
+// BrowserSession returns the live session identified by the cookie token,
+// and marks it as seen from the device of ctx. Sessions of disabled users are
+// not live.
+func (a *AccountService) BrowserSession(ctx context.Context, token string) (BrowserSession, error) {
+	now := time.Now()
+	row, err := a.db.GetBrowserSession(ctx, db.GetBrowserSessionParams{TokenHash: hashToken(token), Now: now})
+	if err != nil {
+		if errors.Is(err, db.ErrNoRows) {
+			return BrowserSession{}, SessionNotFoundErr
+		}
+		return BrowserSession{}, fmt.Errorf("get browser session: %w", err)
+	}
+	if now.Sub(row.LastSeenAt) >= lastSeenInterval {
+		// the request goes on when only the bookkeeping fails
+		err := a.db.TouchSession(ctx, db.TouchSessionParams{Now: now, Ip: DeviceFrom(ctx).IP, ID: row.ID})
+		if err != nil {
+			a.logger.Warn("touch session", "session_id", row.ID, "err", err)
+		}
+	}
+	return BrowserSession{UserID: int(row.UserID), Token: token, CSRFToken: row.CsrfToken, ExpiresAt: row.ExpiresAt}, nil
+}
+
+// Logout ends the browser session identified by the cookie token.
+func (a *AccountService) Logout(ctx context.Context, token string) error {
+	if _, err := a.db.DeleteBrowserSession(ctx, hashToken(token)); err != nil {
+		return fmt.Errorf("delete browser session: %w", err)
+	}
+	return nil
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"scratch/api"
+	db "scratch/internal/storage/database"
+	mockdb "scratch/internal/storage/database/mock"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAccountService_LoginBrowser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("Test123!"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	var stored db.CreateBrowserSessionParams
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+	mockQueries.EXPECT().GetUserByEmail(gomock.Any(), "joedoe@gmail.com").
+		Return(db.ScratchUser{ID: 3, Email: "joedoe@gmail.com", Password: string(hash)}, nil)
+	mockQueries.EXPECT().CreateBrowserSession(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, arg db.CreateBrowserSessionParams) (int32, error) {
+			stored = arg
+			return 12, nil
+		})
+	mockQueries.EXPECT().InsertOutboxEvent(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, arg db.InsertOutboxEventParams) error {
+			assert.Equal(t, "user.logged_in", arg.EventType)
+			assert.JSONEq(t, `{"userId":3,"browser":true,"deviceName":"Work laptop","ip":""}`, string(arg.Payload))
+			return nil
+		})
+	mockQueries.EXPECT().RememberDevice(gomock.Any(), gomock.Any()).Return(false, nil)
+
+	s := NewAccountService(mockQueries, nil, nil, nil, slog.Logger{})
+	deviceName := "Work laptop"
+	got, err := s.LoginBrowser(context.Background(), api.LoginUserRequest{Email: "joedoe@gmail.com", Password: "Test123!", DeviceName: &deviceName}, time.Hour)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, got.UserID)
+	assert.NotEmpty(t, got.Token)
+	assert.NotEmpty(t, got.CSRFToken)
+	assert.NotEqual(t, got.Token, got.CSRFToken)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), got.ExpiresAt, time.Minute)
+
+	assert.Equal(t, int32(3), stored.UserID)
+	assert.Equal(t, hashToken(got.Token), stored.TokenHash, "only the hash of the cookie is stored")
+	assert.Equal(t, got.CSRFToken, stored.CsrfToken)
+	assert.Equal(t, "Work laptop", stored.DeviceName)
+}
+
+func TestAccountService_BrowserSession(t *testing.T) {
+	expires := time.Now().Add(time.Hour)
+	tests := []struct {
+		name        string
+		prepareMock func(queries *mockdb.MockQuerier)
+		want        BrowserSession
+		wantErr     error
+	}{
+		{
+			name: "success - live session",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetBrowserSession(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, arg db.GetBrowserSessionParams) (db.GetBrowserSessionRow, error) {
+						assert.Equal(t, hashToken("token"), arg.TokenHash)
+						return db.GetBrowserSessionRow{ID: 5, UserID: 3, CsrfToken: "csrf", ExpiresAt: expires, LastSeenAt: time.Now()}, nil
+					})
+			},
+			want: BrowserSession{UserID: 3, Token: "token", CSRFToken: "csrf", ExpiresAt: expires},
+		},
+		{
+			name: "success - session not seen for a while is touched",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetBrowserSession(gomock.Any(), gomock.Any()).
+					Return(db.GetBrowserSessionRow{ID: 5, UserID: 3, CsrfToken: "csrf", ExpiresAt: expires, LastSeenAt: time.Now().Add(-time.Hour)}, nil)
+				queries.EXPECT().TouchSession(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, arg db.TouchSessionParams) error {
+						assert.Equal(t, int32(5), arg.ID)
+						assert.Equal(t, "192.0.2.1", arg.Ip)
+						return nil
+					})
+			},
+			want: BrowserSession{UserID: 3, Token: "token", CSRFToken: "csrf", ExpiresAt: expires},
+		},
+		{
+			name: "fail - unknown or expired session",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetBrowserSession(gomock.Any(), gomock.Any()).Return(db.GetBrowserSessionRow{}, db.ErrNoRows)
+			},
+			wantErr: SessionNotFoundErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockQuerier(ctrl)
+			tt.prepareMock(mockQueries)
+			s := NewAccountService(mockQueries, nil, nil, nil, slog.Logger{})
+
+			got, err := s.BrowserSession(WithDevice(context.Background(), Device{IP: "192.0.2.1"}), "token")
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAccountService_Logout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+	mockQueries.EXPECT().DeleteBrowserSession(gomock.Any(), hashToken("token")).Return(int64(1), nil)
+
+	s := NewAccountService(mockQueries, nil, nil, nil, slog.Logger{})
+	assert.NoError(t, s.Logout(context.Background(), "token"))
+}
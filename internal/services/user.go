@@ -8,8 +8,15 @@ import (
 	"log/slog"
 	"scratch/api"
 	"scratch/internal/authorization/session"
+	"scratch/internal/events"
+	"scratch/internal/i18n"
+	"scratch/internal/jobs"
+	"scratch/internal/mail"
+	"scratch/internal/privacy"
 	db "scratch/internal/storage/database"
 	"strconv"
+	"strings"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -18,34 +25,99 @@ var (
 	UserNotFoundErr      = errors.New("user not found")
 	UserExistErr         = errors.New("user with that email already exist")
 	IncorrectPasswordErr = errors.New("incorrect credentials")
+	UserDisabledErr      = errors.New("user account is disabled")
+	UserDeletedErr       = errors.New("user account is deleted")
+	UnknownRoleErr       = errors.New("unknown role")
+	EmptyPasswordErr     = errors.New("password can not be empty")
+	UnsupportedLocaleErr = errors.New("unsupported locale")
 )
 
+const RoleAdmin = "admin"
+
+// sessionTTL is how long the session of an api client lasts without a refresh.
+const sessionTTL = session.RefreshTokenTTL
+
+// Roles lists every role that can be granted to a user.
+var Roles = []string{RoleAdmin, "moderator"}
+
 type AccountManager interface {
 	CreateUser(ctx context.Context, model api.RegisterUserRequest) (int, error)
+	Invitation(ctx context.Context, code string) (api.InvitationPreview, error)
 	Login(ctx context.Context, model api.LoginUserRequest) (api.LoginUserResponse, error)
+	Refresh(ctx context.Context, refreshToken string) (api.LoginUserResponse, error)
+	LoginBrowser(ctx context.Context, model api.LoginUserRequest, ttl time.Duration) (BrowserSession, error)
+	Logout(ctx context.Context, token string) error
+	Sessions(ctx context.Context, userID int, current string) ([]SessionInfo, error)
+	RevokeSession(ctx context.Context, userID, sessionID int) error
 	GetUser(ctx context.Context, id int) (api.GetUserResponse, error)
+	DeleteAccount(ctx context.Context, userID int, password string) (time.Time, error)
+	RestoreAccount(ctx context.Context, model api.RestoreAccountRequest) error
+	DataExport(ctx context.Context, userID int) (api.DataExport, error)
+	DataExportArchive(ctx context.Context, userID int, params api.GetExportsUserIdParams) ([]byte, error)
 	CleanUserTable(ctx context.Context) error
 	MigrationMessage(ctx context.Context) (string, error)
 }
 
 // AccountService is responsible for handling account creation, login and sessions.
 type AccountService struct {
-	db         db.Querier
+	db db.Querier
+	// tx runs the changes spanning several statements, nil runs them on db
+	// without a transaction.
+	tx         db.Transactor
 	tokenMaker session.IdentityGenerator
-	logger     slog.Logger
+	// mailer tells users about logins from new devices, nil sends nothing.
+	mailer mail.Sender
+	logger slog.Logger
+	// deletionGrace is how long a deleted account can be restored.
+	deletionGrace time.Duration
+	// exportSigner signs the download URLs of data exports, valid for
+	// exportURLTTL, and the archives are kept for exportTTL.
+	exportSigner *privacy.URLSigner
+	exportTTL    time.Duration
+	exportURLTTL time.Duration
+	// inviteOnly registers only users with the code of an invitation.
+	inviteOnly bool
+}
+
+func NewAccountService(db db.Querier, tx db.Transactor, tokenGenerator session.IdentityGenerator, mailer mail.Sender, logger slog.Logger) *AccountService {
+	return &AccountService{
+		db:            db,
+		tx:            tx,
+		tokenMaker:    tokenGenerator,
+		mailer:        mailer,
+		logger:        logger,
+		deletionGrace: DefaultDeletionGracePeriod,
+		exportTTL:     DefaultDataExportTTL,
+		exportURLTTL:  DefaultDataExportURLTTL,
+	}
+}
+
+// inTx runs fn in a transaction, so the events it records are committed
+// together with its changes and its reads are consistent with its writes.
+func (a *AccountService) inTx(ctx context.Context, fn func(q db.Querier) error) error {
+	if a.tx == nil {
+		return fn(a.db)
+	}
+	return a.tx.InTx(ctx, fn)
 }
 
-func NewAccountService(db db.Querier, tokenGenerator session.IdentityGenerator, logger slog.Logger) *AccountService {
-	return &AccountService{db: db, tokenMaker: tokenGenerator, logger: logger}
+// WithInviteOnly returns a copy of the service that registers users only with
+// the code of an invitation when inviteOnly is set.
+func (a *AccountService) WithInviteOnly(inviteOnly bool) *AccountService {
+	c := *a
+	c.inviteOnly = inviteOnly
+	return &c
 }
 
+// CreateUser registers a user. With the code of a pending invitation to its
+// email, the user joins the organization of the invitation as well.
 func (a *AccountService) CreateUser(ctx context.Context, model api.RegisterUserRequest) (int, error) {
-	isExist, err := a.isUserExist(ctx, model.Email)
-	if err != nil {
-		return 0, fmt.Errorf("find user by email: %w", err)
+	if a.inviteOnly && model.Invite == nil {
+		return 0, InvitationRequiredErr
 	}
-	if isExist {
-		return 0, UserExistErr
+	locale, err := userLocale(ctx, model.Locale)
+	if err != nil {
+		return 0, err
 	}
 
 	pwd, err := a.hashAndSalt([]byte(model.Password))
@@ -53,28 +125,106 @@ func (a *AccountService) CreateUser(ctx context.Context, model api.RegisterUserR
 		return 0, fmt.Errorf("problem to hash password: %w", err)
 	}
 
-	user, err := a.db.CreateUser(ctx, db.CreateUserParams{
-		Name:     model.Name,
-		Email:    model.Email,
-		Password: pwd,
+	now := time.Now()
+	var id int
+	if model.Invite != nil {
+		// the invitation is found by its code before its organization is known
+		ctx = db.WithAllTenants(ctx)
+	}
+	err = a.inTx(ctx, func(q db.Querier) error {
+		var invitation db.ScratchInvitation
+		if model.Invite != nil {
+			if invitation, err = pendingInvitation(ctx, q, *model.Invite, now); err != nil {
+				return err
+			}
+			// the code may have leaked, it only works for the address it was sent to
+			if !strings.EqualFold(invitation.Email, model.Email) {
+				return InvitationNotFoundErr
+			}
+		}
+		isExist, err := isUserExist(ctx, q, model.Email)
+		if err != nil {
+			return fmt.Errorf("find user by email: %w", err)
+		}
+		if isExist {
+			return UserExistErr
+		}
+		user, err := q.CreateUser(ctx, db.CreateUserParams{
+			Name:     model.Name,
+			Email:    model.Email,
+			Password: pwd,
+			Locale:   locale,
+		})
+		if err != nil {
+			// a concurrent registration of the email committed first
+			if db.IsUniqueViolation(err) {
+				return UserExistErr
+			}
+			return fmt.Errorf("create user: %w", err)
+		}
+		id = int(user.ID)
+		if model.Invite != nil {
+			if err := acceptInvitation(ctx, q, invitation, user.ID, now); err != nil {
+				return err
+			}
+		}
+		err = events.Record(ctx, q, events.UserRegistered{
+			UserID: id,
+			Email:  user.Email,
+			Name:   user.Name,
+			Locale: user.Locale.String,
+		}, now)
+		if err != nil {
+			return err
+		}
+		_, err = jobs.Enqueue(ctx, q, WelcomeEmail{UserID: id}, jobs.Options{UniqueKey: strconv.Itoa(id)})
+		return err
 	})
 	if err != nil {
-		return 0, fmt.Errorf("create user: %w", err)
+		return 0, err
 	}
-	return int(user.ID), nil
+	return id, nil
 }
 
-func (a *AccountService) Login(ctx context.Context, model api.LoginUserRequest) (api.LoginUserResponse, error) {
-	user, err := a.db.GetUserByEmail(ctx, model.Email)
+// Invitation shows the pending invitation with the code, to fill in the
+// registration of the invited.
+func (a *AccountService) Invitation(ctx context.Context, code string) (api.InvitationPreview, error) {
+	var invitation db.ScratchInvitation
+	ctx = db.WithAllTenants(ctx)
+	err := a.inTx(ctx, func(q db.Querier) error {
+		var err error
+		invitation, err = pendingInvitation(ctx, q, code, time.Now())
+		return err
+	})
+	if err != nil {
+		return api.InvitationPreview{}, err
+	}
+	organization, err := a.db.GetOrganization(ctx, invitation.OrganizationID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return api.LoginUserResponse{}, fmt.Errorf("login user: %w", UserNotFoundErr)
+		return api.InvitationPreview{}, fmt.Errorf("get organization: %w", err)
+	}
+	preview := api.InvitationPreview{
+		Email:        invitation.Email,
+		Organization: organization.Name,
+		Role:         api.OrganizationRole(invitation.Role),
+		ExpiresAt:    invitation.ExpiresAt,
+	}
+	if invitation.InvitedBy.Valid {
+		inviter, err := a.db.GetUserByID(ctx, invitation.InvitedBy.Int32)
+		if err != nil && !errors.Is(err, db.ErrNoRows) {
+			return api.InvitationPreview{}, fmt.Errorf("get inviter: %w", err)
+		}
+		if err == nil {
+			preview.Inviter = &inviter.Name
 		}
-		return api.LoginUserResponse{}, fmt.Errorf("login: %w", err)
 	}
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(model.Password))
+	return preview, nil
+}
+
+func (a *AccountService) Login(ctx context.Context, model api.LoginUserRequest) (api.LoginUserResponse, error) {
+	user, err := a.authenticate(ctx, model)
 	if err != nil {
-		return api.LoginUserResponse{}, IncorrectPasswordErr
+		return api.LoginUserResponse{}, err
 	}
 
 	session, err := a.tokenMaker.GenerateTokens(strconv.Itoa(int(user.ID)))
@@ -82,17 +232,218 @@ func (a *AccountService) Login(ctx context.Context, model api.LoginUserRequest)
 		return api.LoginUserResponse{}, fmt.Errorf("generate token: %w", err)
 	}
 
+	now := time.Now()
+	device := deviceOf(ctx, model.DeviceName)
+	err = a.inTx(ctx, func(q db.Querier) error {
+		sessionID, err := q.CreateSession(ctx, db.CreateSessionParams{
+			UserID:           user.ID,
+			RefreshTokenHash: hashToken(session.RefreshToken),
+			Now:              now,
+			ExpiresAt:        now.Add(sessionTTL),
+			UserAgent:        device.UserAgent,
+			Ip:               device.IP,
+			DeviceName:       device.Name,
+		})
+		if err != nil {
+			return fmt.Errorf("create session: %w", err)
+		}
+		if err := a.rememberDevice(ctx, q, user, device, sessionID, now); err != nil {
+			return err
+		}
+		return events.Record(ctx, q, loggedIn(user, device, false), now)
+	})
+	if err != nil {
+		return api.LoginUserResponse{}, err
+	}
+
 	return api.LoginUserResponse{
 		Token:        session.Token,
 		RefreshToken: session.RefreshToken,
 	}, nil
 }
 
+// Refresh exchanges a refresh token for new tokens. The old refresh token
+// stops working, and the session is seen from the current device.
+func (a *AccountService) Refresh(ctx context.Context, refreshToken string) (api.LoginUserResponse, error) {
+	if err := a.tokenMaker.ValidateToken(refreshToken); err != nil {
+		return api.LoginUserResponse{}, fmt.Errorf("%w: %v", SessionNotFoundErr, err)
+	}
+	now := time.Now()
+	row, err := a.db.GetSessionByRefreshToken(ctx, db.GetSessionByRefreshTokenParams{RefreshTokenHash: hashToken(refreshToken), Now: now})
+	if err != nil {
+		if errors.Is(err, db.ErrNoRows) {
+			return api.LoginUserResponse{}, SessionNotFoundErr
+		}
+		return api.LoginUserResponse{}, fmt.Errorf("get session: %w", err)
+	}
+
+	session, err := a.tokenMaker.GenerateTokens(strconv.Itoa(int(row.UserID)))
+	if err != nil {
+		return api.LoginUserResponse{}, fmt.Errorf("generate token: %w", err)
+	}
+	device := DeviceFrom(ctx)
+	n, err := a.db.RefreshSession(ctx, db.RefreshSessionParams{
+		NewRefreshTokenHash: hashToken(session.RefreshToken),
+		Now:                 now,
+		ExpiresAt:           now.Add(sessionTTL),
+		UserAgent:           device.UserAgent,
+		Ip:                  device.IP,
+		ID:                  row.ID,
+		RefreshTokenHash:    hashToken(refreshToken),
+	})
+	if err != nil {
+		return api.LoginUserResponse{}, fmt.Errorf("refresh session: %w", err)
+	}
+	// a concurrent refresh with the same token won
+	if n == 0 {
+		return api.LoginUserResponse{}, SessionNotFoundErr
+	}
+
+	return api.LoginUserResponse{
+		Token:        session.Token,
+		RefreshToken: session.RefreshToken,
+	}, nil
+}
+
+// authenticate returns the user the credentials belong to, if it may log in.
+func (a *AccountService) authenticate(ctx context.Context, model api.LoginUserRequest) (db.ScratchUser, error) {
+	user, err := a.db.GetUserByEmail(ctx, model.Email)
+	if err != nil {
+		if errors.Is(err, db.ErrNoRows) {
+			return db.ScratchUser{}, a.deletedLogin(ctx, model)
+		}
+		return db.ScratchUser{}, fmt.Errorf("login: %w", err)
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(model.Password))
+	if err != nil {
+		return db.ScratchUser{}, IncorrectPasswordErr
+	}
+	if user.DisabledAt.Valid {
+		return db.ScratchUser{}, UserDisabledErr
+	}
+	return user, nil
+}
+
 func (a *AccountService) GetUser(ctx context.Context, id int) (api.GetUserResponse, error) {
 	// add midelware here
 	return api.GetUserResponse{}, nil
 }
 
+// DisableUser blocks the user from logging in and ends all of their sessions.
+func (a *AccountService) DisableUser(ctx context.Context, email string) error {
+	user, err := a.getUser(ctx, email)
+	if err != nil {
+		return err
+	}
+	if _, err := a.db.DisableUser(ctx, email); err != nil {
+		return fmt.Errorf("disable user: %w", err)
+	}
+	if _, err := a.db.DeleteUserSessions(ctx, user.ID); err != nil {
+		return fmt.Errorf("delete user sessions: %w", err)
+	}
+	return nil
+}
+
+func (a *AccountService) SetPassword(ctx context.Context, email, password string) error {
+	return a.setPassword(ctx, email, password, func(q db.Querier, id int32) error { return nil })
+}
+
+// setPassword replaces the password of the user, then runs also in the same
+// transaction.
+func (a *AccountService) setPassword(ctx context.Context, email, password string, also func(q db.Querier, id int32) error) error {
+	if password == "" {
+		return EmptyPasswordErr
+	}
+	pwd, err := a.hashAndSalt([]byte(password))
+	if err != nil {
+		return fmt.Errorf("problem to hash password: %w", err)
+	}
+
+	return a.inTx(ctx, func(q db.Querier) error {
+		id, err := q.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{Email: email, Password: pwd})
+		if err != nil {
+			if errors.Is(err, db.ErrNoRows) {
+				return UserNotFoundErr
+			}
+			return fmt.Errorf("update password: %w", err)
+		}
+		if err := also(q, id); err != nil {
+			return err
+		}
+		return events.Record(ctx, q, events.PasswordChanged{UserID: int(id)}, time.Now())
+	})
+}
+
+// SetLocale changes the preferred locale of the user, an empty locale goes
+// back to negotiating it from Accept-Language.
+func (a *AccountService) SetLocale(ctx context.Context, email, locale string) error {
+	var value sql.NullString
+	if locale != "" {
+		if !i18n.Default().Supports(locale) {
+			return fmt.Errorf("%w: %v", UnsupportedLocaleErr, locale)
+		}
+		value = sql.NullString{String: i18n.Default().Match(locale), Valid: true}
+	}
+
+	n, err := a.db.SetUserLocale(ctx, db.SetUserLocaleParams{Email: email, Locale: value})
+	if err != nil {
+		return fmt.Errorf("set locale: %w", err)
+	}
+	if n == 0 {
+		return UserNotFoundErr
+	}
+	return nil
+}
+
+func (a *AccountService) GrantRole(ctx context.Context, email, role string) error {
+	if !isKnownRole(role) {
+		return fmt.Errorf("%w: %v", UnknownRoleErr, role)
+	}
+	user, err := a.getUser(ctx, email)
+	if err != nil {
+		return err
+	}
+	if err := a.db.GrantUserRole(ctx, db.GrantUserRoleParams{UserID: user.ID, Role: role}); err != nil {
+		return fmt.Errorf("grant role: %w", err)
+	}
+	return nil
+}
+
+// HasRole tells whether the user with id was granted role.
+func (a *AccountService) HasRole(ctx context.Context, id int, role string) (bool, error) {
+	roles, err := a.db.ListUserRoles(ctx, int32(id))
+	if err != nil {
+		return false, fmt.Errorf("list user roles: %w", err)
+	}
+	for _, r := range roles {
+		if r == role {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// PurgeSessions deletes the sessions of the user with email, or of everyone when email is empty.
+func (a *AccountService) PurgeSessions(ctx context.Context, email string) (int64, error) {
+	if email == "" {
+		n, err := a.db.DeleteAllSessions(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("delete sessions: %w", err)
+		}
+		return n, nil
+	}
+
+	user, err := a.getUser(ctx, email)
+	if err != nil {
+		return 0, err
+	}
+	n, err := a.db.DeleteUserSessions(ctx, user.ID)
+	if err != nil {
+		return 0, fmt.Errorf("delete user sessions: %w", err)
+	}
+	return n, nil
+}
+
 func (a *AccountService) CleanUserTable(ctx context.Context) error {
 	return a.db.CleanUserTable(ctx)
 }
@@ -111,10 +462,45 @@ func (a *AccountService) hashAndSalt(pwd []byte) (string, error) {
 	return string(hash), nil
 }
 
-func (a *AccountService) isUserExist(ctx context.Context, email string) (bool, error) {
-	_, err := a.db.GetUserByEmail(ctx, email)
+func (a *AccountService) getUser(ctx context.Context, email string) (db.ScratchUser, error) {
+	user, err := a.db.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, db.ErrNoRows) {
+			return db.ScratchUser{}, UserNotFoundErr
+		}
+		return db.ScratchUser{}, fmt.Errorf("get user by email: %w", err)
+	}
+	return user, nil
+}
+
+// userLocale is the locale stored for a new user: the requested one, or the
+// one negotiated for the request when none was given.
+func userLocale(ctx context.Context, requested *string) (sql.NullString, error) {
+	if requested != nil && *requested != "" {
+		if !i18n.Default().Supports(*requested) {
+			return sql.NullString{}, fmt.Errorf("%w: %v", UnsupportedLocaleErr, *requested)
+		}
+		return sql.NullString{String: i18n.Default().Match(*requested), Valid: true}, nil
+	}
+	if locale := i18n.LocaleFrom(ctx); locale != "" {
+		return sql.NullString{String: locale, Valid: true}, nil
+	}
+	return sql.NullString{}, nil
+}
+
+func isKnownRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func isUserExist(ctx context.Context, q db.Querier, email string) (bool, error) {
+	_, err := q.GetUserByEmail(ctx, email)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, db.ErrNoRows) {
 			return false, nil
 		}
 		return false, fmt.Errorf("can not get user by email: %w", err)
@@ -8,11 +8,14 @@ import (
 	"log/slog"
 	"scratch/api"
 	"scratch/internal/authorization/session"
+	"scratch/internal/i18n"
 	db "scratch/internal/storage/database"
 	mockdb "scratch/internal/storage/database/mock"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -43,7 +46,15 @@ func TestAccountService_Login(t *testing.T) {
 					RefreshToken: "refresh-token",
 					Token:        "normal-token",
 				}, nil)
-
+				queries.EXPECT().CreateSession(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, arg db.CreateSessionParams) (int32, error) {
+						assert.Equal(t, hashToken("refresh-token"), arg.RefreshTokenHash, "only the hash of the refresh token is stored")
+						assert.Equal(t, "Firefox on Linux", arg.DeviceName)
+						assert.Equal(t, "192.0.2.1", arg.Ip)
+						return 12, nil
+					})
+				queries.EXPECT().InsertOutboxEvent(gomock.Any(), gomock.Any()).Return(nil)
+				queries.EXPECT().RememberDevice(gomock.Any(), gomock.Any()).Return(false, nil)
 			},
 			want: api.LoginUserResponse{
 				RefreshToken: "refresh-token",
@@ -55,7 +66,9 @@ func TestAccountService_Login(t *testing.T) {
 			name: "fail - there is no user with that email",
 			prepareMock: func(t *testing.T, tokenMaker *session.MockIdentityGenerator, queries *mockdb.MockQuerier) {
 				queries.EXPECT().GetUserByEmail(gomock.Any(), "joedoe@gmail.com").
-					Return(db.ScratchUser{}, sql.ErrNoRows)
+					Return(db.ScratchUser{}, db.ErrNoRows)
+				queries.EXPECT().GetDeletedUserByEmail(gomock.Any(), "joedoe@gmail.com").
+					Return(db.ScratchUser{}, db.ErrNoRows)
 			},
 			want:  api.LoginUserResponse{},
 			error: fmt.Errorf("login user: %w", UserNotFoundErr),
@@ -78,9 +91,13 @@ func TestAccountService_Login(t *testing.T) {
 			mockQueries := mockdb.NewMockQuerier(ctrl)
 			tt.prepareMock(t, mockTokenMaker, mockQueries)
 
-			s := NewAccountService(mockQueries, mockTokenMaker, slog.Logger{})
+			s := NewAccountService(mockQueries, nil, mockTokenMaker, nil, slog.Logger{})
 
-			got, err := s.Login(context.Background(), api.LoginUserRequest{
+			ctx := WithDevice(context.Background(), Device{
+				UserAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:131.0) Gecko/20100101 Firefox/131.0",
+				IP:        "192.0.2.1",
+			})
+			got, err := s.Login(ctx, api.LoginUserRequest{
 				Email:    "joedoe@gmail.com",
 				Password: "Test123!",
 			})
@@ -92,6 +109,75 @@ func TestAccountService_Login(t *testing.T) {
 	}
 }
 
+func TestAccountService_Refresh(t *testing.T) {
+	tests := []struct {
+		name        string
+		prepareMock func(t *testing.T, tokenMaker *session.MockIdentityGenerator, queries *mockdb.MockQuerier)
+		want        api.LoginUserResponse
+		wantErr     error
+	}{
+		{
+			name: "success - tokens are rotated",
+			prepareMock: func(t *testing.T, tokenMaker *session.MockIdentityGenerator, queries *mockdb.MockQuerier) {
+				tokenMaker.EXPECT().ValidateToken("old-refresh-token").Return(nil)
+				queries.EXPECT().GetSessionByRefreshToken(gomock.Any(), gomock.Any()).
+					Return(db.GetSessionByRefreshTokenRow{ID: 5, UserID: 1}, nil)
+				tokenMaker.EXPECT().GenerateTokens("1").Return(session.UserSession{Token: "token", RefreshToken: "refresh-token"}, nil)
+				queries.EXPECT().RefreshSession(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, arg db.RefreshSessionParams) (int64, error) {
+						assert.Equal(t, int32(5), arg.ID)
+						assert.Equal(t, hashToken("old-refresh-token"), arg.RefreshTokenHash)
+						assert.Equal(t, hashToken("refresh-token"), arg.NewRefreshTokenHash)
+						assert.Equal(t, "192.0.2.1", arg.Ip)
+						return 1, nil
+					})
+			},
+			want: api.LoginUserResponse{Token: "token", RefreshToken: "refresh-token"},
+		},
+		{
+			name: "fail - invalid token",
+			prepareMock: func(t *testing.T, tokenMaker *session.MockIdentityGenerator, queries *mockdb.MockQuerier) {
+				tokenMaker.EXPECT().ValidateToken("old-refresh-token").Return(errors.New("token expired"))
+			},
+			wantErr: SessionNotFoundErr,
+		},
+		{
+			name: "fail - revoked or already used token",
+			prepareMock: func(t *testing.T, tokenMaker *session.MockIdentityGenerator, queries *mockdb.MockQuerier) {
+				tokenMaker.EXPECT().ValidateToken("old-refresh-token").Return(nil)
+				queries.EXPECT().GetSessionByRefreshToken(gomock.Any(), gomock.Any()).
+					Return(db.GetSessionByRefreshTokenRow{}, db.ErrNoRows)
+			},
+			wantErr: SessionNotFoundErr,
+		},
+		{
+			name: "fail - concurrent refresh won",
+			prepareMock: func(t *testing.T, tokenMaker *session.MockIdentityGenerator, queries *mockdb.MockQuerier) {
+				tokenMaker.EXPECT().ValidateToken("old-refresh-token").Return(nil)
+				queries.EXPECT().GetSessionByRefreshToken(gomock.Any(), gomock.Any()).
+					Return(db.GetSessionByRefreshTokenRow{ID: 5, UserID: 1}, nil)
+				tokenMaker.EXPECT().GenerateTokens("1").Return(session.UserSession{Token: "token", RefreshToken: "refresh-token"}, nil)
+				queries.EXPECT().RefreshSession(gomock.Any(), gomock.Any()).Return(int64(0), nil)
+			},
+			wantErr: SessionNotFoundErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockTokenMaker := session.NewMockIdentityGenerator(ctrl)
+			mockQueries := mockdb.NewMockQuerier(ctrl)
+			tt.prepareMock(t, mockTokenMaker, mockQueries)
+
+			s := NewAccountService(mockQueries, nil, mockTokenMaker, nil, slog.Logger{})
+			got, err := s.Refresh(WithDevice(context.Background(), Device{IP: "192.0.2.1"}), "old-refresh-token")
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestAccountService_CreateUser(t *testing.T) {
 
 	tests := []struct {
@@ -103,11 +189,24 @@ func TestAccountService_CreateUser(t *testing.T) {
 		{
 			name: "success - create user",
 			prepareMock: func(t *testing.T, queries *mockdb.MockQuerier) {
-				queries.EXPECT().GetUserByEmail(gomock.Any(), "joedoe@gmail.com").Return(db.ScratchUser{}, sql.ErrNoRows)
+				queries.EXPECT().GetUserByEmail(gomock.Any(), "joedoe@gmail.com").Return(db.ScratchUser{}, db.ErrNoRows)
 
 				queries.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(db.ScratchUser{
 					ID: 1,
 				}, nil)
+				queries.EXPECT().InsertOutboxEvent(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, arg db.InsertOutboxEventParams) error {
+						assert.Equal(t, "user", arg.AggregateType)
+						assert.Equal(t, "1", arg.AggregateID)
+						assert.Equal(t, "user.registered", arg.EventType)
+						return nil
+					})
+				queries.EXPECT().EnqueueJob(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, arg db.EnqueueJobParams) (int64, error) {
+						assert.Equal(t, "welcome_email", arg.Kind)
+						assert.JSONEq(t, `{"userId":1}`, string(arg.Payload))
+						return 1, nil
+					})
 			},
 			want:    1,
 			wantErr: nil,
@@ -125,6 +224,26 @@ func TestAccountService_CreateUser(t *testing.T) {
 			want:    0,
 			wantErr: UserExistErr,
 		},
+		{
+			name: "fail - email registered concurrently",
+			prepareMock: func(t *testing.T, queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetUserByEmail(gomock.Any(), "joedoe@gmail.com").Return(db.ScratchUser{}, db.ErrNoRows)
+				queries.EXPECT().CreateUser(gomock.Any(), gomock.Any()).
+					Return(db.ScratchUser{}, &pgconn.PgError{Code: "23505", ConstraintName: "user_email_key"})
+			},
+			want:    0,
+			wantErr: UserExistErr,
+		},
+		{
+			name: "fail - event not recorded",
+			prepareMock: func(t *testing.T, queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetUserByEmail(gomock.Any(), "joedoe@gmail.com").Return(db.ScratchUser{}, db.ErrNoRows)
+				queries.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(db.ScratchUser{ID: 1}, nil)
+				queries.EXPECT().InsertOutboxEvent(gomock.Any(), gomock.Any()).Return(sql.ErrConnDone)
+			},
+			want:    0,
+			wantErr: errors.New("record user.registered: sql: connection is already closed"),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -134,7 +253,7 @@ func TestAccountService_CreateUser(t *testing.T) {
 
 			mockQueries := mockdb.NewMockQuerier(ctrl)
 			tt.prepareMock(t, mockQueries)
-			s := NewAccountService(mockQueries, nil, slog.Logger{})
+			s := NewAccountService(mockQueries, nil, nil, nil, slog.Logger{})
 
 			got, err := s.CreateUser(context.Background(), api.RegisterUserRequest{
 				Email:    "joedoe@gmail.com",
@@ -151,3 +270,339 @@ func TestAccountService_CreateUser(t *testing.T) {
 		})
 	}
 }
+
+func TestAccountService_CreateUser_Invitation(t *testing.T) {
+	invitation := db.ScratchInvitation{
+		ID: 7, OrganizationID: 5, Email: "JoeDoe@gmail.com", Role: "admin", CodeHash: hashToken("code"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	tests := []struct {
+		name        string
+		inviteOnly  bool
+		invite      string
+		prepareMock func(t *testing.T, queries *mockdb.MockQuerier)
+		wantErr     error
+	}{
+		{
+			name:       "success - joins the organization",
+			inviteOnly: true,
+			invite:     "code",
+			prepareMock: func(t *testing.T, queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetInvitationByCode(gomock.Any(), hashToken("code")).Return(invitation, nil)
+				queries.EXPECT().GetUserByEmail(gomock.Any(), "joedoe@gmail.com").Return(db.ScratchUser{}, db.ErrNoRows)
+				queries.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(db.ScratchUser{ID: 1}, nil)
+				queries.EXPECT().AcceptInvitation(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, arg db.AcceptInvitationParams) (int64, error) {
+						assert.Equal(t, int32(7), arg.ID)
+						assert.Equal(t, sql.NullInt32{Int32: 1, Valid: true}, arg.AcceptedBy, "who was invited is tracked")
+						return 1, nil
+					})
+				queries.EXPECT().AddOrganizationMember(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, arg db.AddOrganizationMemberParams) (int64, error) {
+						assert.Equal(t, db.AddOrganizationMemberParams{OrganizationID: 5, UserID: 1, Role: "admin", Now: arg.Now}, arg)
+						return 1, nil
+					})
+				queries.EXPECT().InsertOutboxEvent(gomock.Any(), gomock.Any()).Return(nil)
+				queries.EXPECT().EnqueueJob(gomock.Any(), gomock.Any()).Return(int64(1), nil)
+			},
+		},
+		{
+			name:        "fail - invite only",
+			inviteOnly:  true,
+			prepareMock: func(t *testing.T, queries *mockdb.MockQuerier) {},
+			wantErr:     InvitationRequiredErr,
+		},
+		{
+			name:   "fail - invitation to another email",
+			invite: "code",
+			prepareMock: func(t *testing.T, queries *mockdb.MockQuerier) {
+				other := invitation
+				other.Email = "ann@gmail.com"
+				queries.EXPECT().GetInvitationByCode(gomock.Any(), gomock.Any()).Return(other, nil)
+			},
+			wantErr: InvitationNotFoundErr,
+		},
+		{
+			name:   "fail - revoked invitation",
+			invite: "code",
+			prepareMock: func(t *testing.T, queries *mockdb.MockQuerier) {
+				revoked := invitation
+				revoked.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+				queries.EXPECT().GetInvitationByCode(gomock.Any(), gomock.Any()).Return(revoked, nil)
+			},
+			wantErr: InvitationNotFoundErr,
+		},
+		{
+			name:   "fail - invitation used concurrently",
+			invite: "code",
+			prepareMock: func(t *testing.T, queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetInvitationByCode(gomock.Any(), gomock.Any()).Return(invitation, nil)
+				queries.EXPECT().GetUserByEmail(gomock.Any(), "joedoe@gmail.com").Return(db.ScratchUser{}, db.ErrNoRows)
+				queries.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(db.ScratchUser{ID: 1}, nil)
+				queries.EXPECT().AcceptInvitation(gomock.Any(), gomock.Any()).Return(int64(0), nil)
+			},
+			wantErr: InvitationNotFoundErr,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockQuerier(ctrl)
+			tt.prepareMock(t, mockQueries)
+			s := NewAccountService(mockQueries, nil, nil, nil, slog.Logger{}).WithInviteOnly(tt.inviteOnly)
+
+			request := api.RegisterUserRequest{Email: "joedoe@gmail.com", Name: "Joe", Password: "Test123!"}
+			if tt.invite != "" {
+				request.Invite = &tt.invite
+			}
+			id, err := s.CreateUser(context.Background(), request)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 1, id)
+		})
+	}
+}
+
+func TestAccountService_Invitation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expiresAt := time.Now().Add(time.Hour)
+	queries := mockdb.NewMockQuerier(ctrl)
+	queries.EXPECT().GetInvitationByCode(gomock.Any(), hashToken("code")).Return(db.ScratchInvitation{
+		OrganizationID: 5, Email: "joedoe@gmail.com", Role: "member", InvitedBy: sql.NullInt32{Int32: 3, Valid: true}, ExpiresAt: expiresAt,
+	}, nil)
+	queries.EXPECT().GetOrganization(gomock.Any(), int32(5)).Return(db.ScratchOrganization{ID: 5, Name: "Acme"}, nil)
+	queries.EXPECT().GetUserByID(gomock.Any(), int32(3)).Return(db.ScratchUser{ID: 3, Name: "Ann"}, nil)
+	queries.EXPECT().GetInvitationByCode(gomock.Any(), hashToken("accepted")).Return(db.ScratchInvitation{
+		AcceptedAt: sql.NullTime{Time: time.Now(), Valid: true}, ExpiresAt: expiresAt,
+	}, nil)
+
+	s := NewAccountService(queries, nil, nil, nil, slog.Logger{})
+	preview, err := s.Invitation(context.Background(), "code")
+	assert.NoError(t, err)
+	inviter := "Ann"
+	assert.Equal(t, api.InvitationPreview{Email: "joedoe@gmail.com", Organization: "Acme", Role: api.Member, Inviter: &inviter, ExpiresAt: expiresAt}, preview)
+
+	_, err = s.Invitation(context.Background(), "accepted")
+	assert.ErrorIs(t, err, InvitationNotFoundErr)
+}
+
+func TestAccountService_Login_DisabledUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("Test123!"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	mockQueries := mockdb.NewMockQuerier(ctrl)
+	mockQueries.EXPECT().GetUserByEmail(gomock.Any(), "joedoe@gmail.com").Return(db.ScratchUser{
+		ID:         1,
+		Email:      "joedoe@gmail.com",
+		Password:   string(hash),
+		DisabledAt: sql.NullTime{Time: time.Now(), Valid: true},
+	}, nil)
+
+	s := NewAccountService(mockQueries, nil, session.NewMockIdentityGenerator(ctrl), nil, slog.Logger{})
+
+	_, err = s.Login(context.Background(), api.LoginUserRequest{Email: "joedoe@gmail.com", Password: "Test123!"})
+	assert.ErrorIs(t, err, UserDisabledErr)
+}
+
+func TestAccountService_AdminOperations(t *testing.T) {
+	const email = "joedoe@gmail.com"
+	existingUser := db.ScratchUser{ID: 7, Email: email}
+
+	tests := []struct {
+		name        string
+		prepareMock func(queries *mockdb.MockQuerier)
+		run         func(s *AccountService) error
+		wantErr     error
+	}{
+		{
+			name: "success - disable user ends sessions",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetUserByEmail(gomock.Any(), email).Return(existingUser, nil)
+				queries.EXPECT().DisableUser(gomock.Any(), email).Return(int64(1), nil)
+				queries.EXPECT().DeleteUserSessions(gomock.Any(), int32(7)).Return(int64(2), nil)
+			},
+			run: func(s *AccountService) error {
+				return s.DisableUser(context.Background(), email)
+			},
+		},
+		{
+			name: "fail - disable unknown user",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetUserByEmail(gomock.Any(), email).Return(db.ScratchUser{}, db.ErrNoRows)
+			},
+			run: func(s *AccountService) error {
+				return s.DisableUser(context.Background(), email)
+			},
+			wantErr: UserNotFoundErr,
+		},
+		{
+			name: "success - set password stores a hash",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().UpdateUserPassword(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, arg db.UpdateUserPasswordParams) (int32, error) {
+						assert.Equal(t, email, arg.Email)
+						assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(arg.Password), []byte("NewPass1!")))
+						return 1, nil
+					})
+				queries.EXPECT().InsertOutboxEvent(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			run: func(s *AccountService) error {
+				return s.SetPassword(context.Background(), email, "NewPass1!")
+			},
+		},
+		{
+			name: "fail - set password of unknown user",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().UpdateUserPassword(gomock.Any(), gomock.Any()).Return(int32(0), db.ErrNoRows)
+			},
+			run: func(s *AccountService) error {
+				return s.SetPassword(context.Background(), email, "NewPass1!")
+			},
+			wantErr: UserNotFoundErr,
+		},
+		{
+			name:        "fail - empty password",
+			prepareMock: func(queries *mockdb.MockQuerier) {},
+			run: func(s *AccountService) error {
+				return s.SetPassword(context.Background(), email, "")
+			},
+			wantErr: EmptyPasswordErr,
+		},
+		{
+			name: "success - grant role",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetUserByEmail(gomock.Any(), email).Return(existingUser, nil)
+				queries.EXPECT().GrantUserRole(gomock.Any(), db.GrantUserRoleParams{UserID: 7, Role: RoleAdmin}).Return(nil)
+			},
+			run: func(s *AccountService) error {
+				return s.GrantRole(context.Background(), email, RoleAdmin)
+			},
+		},
+		{
+			name:        "fail - grant unknown role",
+			prepareMock: func(queries *mockdb.MockQuerier) {},
+			run: func(s *AccountService) error {
+				return s.GrantRole(context.Background(), email, "superuser")
+			},
+			wantErr: UnknownRoleErr,
+		},
+		{
+			name: "success - purge all sessions",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().DeleteAllSessions(gomock.Any()).Return(int64(3), nil)
+			},
+			run: func(s *AccountService) error {
+				n, err := s.PurgeSessions(context.Background(), "")
+				assert.Equal(t, int64(3), n)
+				return err
+			},
+		},
+		{
+			name: "success - purge sessions of one user",
+			prepareMock: func(queries *mockdb.MockQuerier) {
+				queries.EXPECT().GetUserByEmail(gomock.Any(), email).Return(existingUser, nil)
+				queries.EXPECT().DeleteUserSessions(gomock.Any(), int32(7)).Return(int64(1), nil)
+			},
+			run: func(s *AccountService) error {
+				n, err := s.PurgeSessions(context.Background(), email)
+				assert.Equal(t, int64(1), n)
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockQuerier(ctrl)
+			tt.prepareMock(mockQueries)
+			s := NewAccountService(mockQueries, nil, nil, nil, slog.Logger{})
+
+			err := tt.run(s)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAccountService_Locale(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	queries := mockdb.NewMockQuerier(ctrl)
+	s := NewAccountService(queries, nil, nil, nil, slog.Logger{})
+
+	t.Run("create user - requested locale", func(t *testing.T) {
+		locale := "pl-PL"
+		queries.EXPECT().GetUserByEmail(gomock.Any(), "joedoe@gmail.com").Return(db.ScratchUser{}, db.ErrNoRows)
+		queries.EXPECT().CreateUser(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, arg db.CreateUserParams) (db.ScratchUser, error) {
+				assert.Equal(t, sql.NullString{String: "pl", Valid: true}, arg.Locale)
+				return db.ScratchUser{ID: 1}, nil
+			})
+		queries.EXPECT().InsertOutboxEvent(gomock.Any(), gomock.Any()).Return(nil)
+		queries.EXPECT().EnqueueJob(gomock.Any(), gomock.Any()).Return(int64(1), nil)
+
+		_, err := s.CreateUser(context.Background(), api.RegisterUserRequest{
+			Email: "joedoe@gmail.com", Name: "joe", Password: "Test123!", Locale: &locale,
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("create user - negotiated locale", func(t *testing.T) {
+		queries.EXPECT().GetUserByEmail(gomock.Any(), "joedoe@gmail.com").Return(db.ScratchUser{}, db.ErrNoRows)
+		queries.EXPECT().CreateUser(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, arg db.CreateUserParams) (db.ScratchUser, error) {
+				assert.Equal(t, sql.NullString{String: "pl", Valid: true}, arg.Locale)
+				return db.ScratchUser{ID: 1}, nil
+			})
+		queries.EXPECT().InsertOutboxEvent(gomock.Any(), gomock.Any()).Return(nil)
+		queries.EXPECT().EnqueueJob(gomock.Any(), gomock.Any()).Return(int64(1), nil)
+
+		ctx := i18n.WithLocale(context.Background(), "pl")
+		_, err := s.CreateUser(ctx, api.RegisterUserRequest{Email: "joedoe@gmail.com", Name: "joe", Password: "Test123!"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("create user - unsupported locale", func(t *testing.T) {
+		locale := "tlh"
+
+		_, err := s.CreateUser(context.Background(), api.RegisterUserRequest{
+			Email: "joedoe@gmail.com", Name: "joe", Password: "Test123!", Locale: &locale,
+		})
+		assert.ErrorIs(t, err, UnsupportedLocaleErr)
+	})
+
+	t.Run("set locale", func(t *testing.T) {
+		queries.EXPECT().SetUserLocale(gomock.Any(), db.SetUserLocaleParams{
+			Email: "joedoe@gmail.com", Locale: sql.NullString{String: "en", Valid: true},
+		}).Return(int64(1), nil)
+		assert.NoError(t, s.SetLocale(context.Background(), "joedoe@gmail.com", "en-GB"))
+	})
+
+	t.Run("set locale - back to negotiation", func(t *testing.T) {
+		queries.EXPECT().SetUserLocale(gomock.Any(), db.SetUserLocaleParams{Email: "joedoe@gmail.com"}).Return(int64(1), nil)
+		assert.NoError(t, s.SetLocale(context.Background(), "joedoe@gmail.com", ""))
+	})
+
+	t.Run("set locale - unknown user", func(t *testing.T) {
+		queries.EXPECT().SetUserLocale(gomock.Any(), gomock.Any()).Return(int64(0), nil)
+		assert.ErrorIs(t, s.SetLocale(context.Background(), "nobody@gmail.com", "pl"), UserNotFoundErr)
+	})
+}
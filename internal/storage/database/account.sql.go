@@ -7,6 +7,8 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
 const cleanUserTable = `-- name: CleanUserTable :exec
@@ -14,82 +16,581 @@ DELETE FROM scratch.user
 `
 
 func (q *Queries) CleanUserTable(ctx context.Context) error {
-	_, err := q.db.ExecContext(ctx, cleanUserTable)
+	_, err := q.db.Exec(ctx, cleanUserTable)
 	return err
 }
 
-const createSession = `-- name: CreateSession :exec
-INSERT INTO scratch.session (user_id, refresh_token, login_date) VALUES ($1, $2, $3)
+const countUserDevices = `-- name: CountUserDevices :one
+SELECT count(*) FROM scratch.user_device WHERE user_id = $1
+`
+
+func (q *Queries) CountUserDevices(ctx context.Context, userID int32) (int64, error) {
+	row := q.db.QueryRow(ctx, countUserDevices, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createBrowserSession = `-- name: CreateBrowserSession :one
+INSERT INTO scratch.session (user_id, token_hash, csrf_token, created_at, last_seen_at, expires_at, user_agent, ip, device_name)
+VALUES ($1, $2::varchar, $3::varchar, $4, $4, $5::timestamptz, $6, $7, $8)
+RETURNING id
+`
+
+type CreateBrowserSessionParams struct {
+	UserID     int32
+	TokenHash  string
+	CsrfToken  string
+	Now        time.Time
+	ExpiresAt  time.Time
+	UserAgent  string
+	Ip         string
+	DeviceName string
+}
+
+func (q *Queries) CreateBrowserSession(ctx context.Context, arg CreateBrowserSessionParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createBrowserSession,
+		arg.UserID,
+		arg.TokenHash,
+		arg.CsrfToken,
+		arg.Now,
+		arg.ExpiresAt,
+		arg.UserAgent,
+		arg.Ip,
+		arg.DeviceName,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createSession = `-- name: CreateSession :one
+INSERT INTO scratch.session (user_id, refresh_token_hash, created_at, last_seen_at, expires_at, user_agent, ip, device_name)
+VALUES ($1, $2::varchar, $3, $3, $4::timestamptz, $5, $6, $7)
+RETURNING id
 `
 
 type CreateSessionParams struct {
-	UserID       int32
-	RefreshToken string
-	LoginDate    string
+	UserID           int32
+	RefreshTokenHash string
+	Now              time.Time
+	ExpiresAt        time.Time
+	UserAgent        string
+	Ip               string
+	DeviceName       string
 }
 
-func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) error {
-	_, err := q.db.ExecContext(ctx, createSession, arg.UserID, arg.RefreshToken, arg.LoginDate)
-	return err
+func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createSession,
+		arg.UserID,
+		arg.RefreshTokenHash,
+		arg.Now,
+		arg.ExpiresAt,
+		arg.UserAgent,
+		arg.Ip,
+		arg.DeviceName,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
 }
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO scratch.user (name, email, password)
-VALUES ($1, $2, $3)
-RETURNING id, name, email, password
+INSERT INTO scratch.user (name, email, password, locale)
+VALUES ($1, $2, $3, $4)
+RETURNING id, name, email, password, disabled_at, locale, deleted_at
 `
 
 type CreateUserParams struct {
 	Name     string
 	Email    string
 	Password string
+	Locale   sql.NullString
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (ScratchUser, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Name, arg.Email, arg.Password)
+	row := q.db.QueryRow(ctx, createUser,
+		arg.Name,
+		arg.Email,
+		arg.Password,
+		arg.Locale,
+	)
 	var i ScratchUser
 	err := row.Scan(
 		&i.ID,
 		&i.Name,
 		&i.Email,
 		&i.Password,
+		&i.DisabledAt,
+		&i.Locale,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
-const getSession = `-- name: GetSession :one
-SELECT id, user_id, refresh_token, login_date FROM scratch.session WHERE refresh_token = $1 AND user_id = $2
+const deleteAllSessions = `-- name: DeleteAllSessions :execrows
+DELETE FROM scratch.session
+`
+
+func (q *Queries) DeleteAllSessions(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteAllSessions)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteBrowserSession = `-- name: DeleteBrowserSession :execrows
+DELETE FROM scratch.session WHERE token_hash = $1::varchar
 `
 
-type GetSessionParams struct {
-	RefreshToken string
-	UserID       int32
+func (q *Queries) DeleteBrowserSession(ctx context.Context, tokenHash string) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteBrowserSession, tokenHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
-func (q *Queries) GetSession(ctx context.Context, arg GetSessionParams) (ScratchSession, error) {
-	row := q.db.QueryRowContext(ctx, getSession, arg.RefreshToken, arg.UserID)
-	var i ScratchSession
+const deleteExpiredSessions = `-- name: DeleteExpiredSessions :execrows
+DELETE FROM scratch.session WHERE expires_at < $1::timestamptz
+`
+
+func (q *Queries) DeleteExpiredSessions(ctx context.Context, now time.Time) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredSessions, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteOtherUserSessions = `-- name: DeleteOtherUserSessions :execrows
+DELETE FROM scratch.session WHERE user_id = $1 AND token_hash IS DISTINCT FROM $2::varchar
+`
+
+type DeleteOtherUserSessionsParams struct {
+	UserID   int32
+	KeepHash string
+}
+
+// Deletes the sessions of the user but the browser session keep_hash, api
+// sessions included.
+func (q *Queries) DeleteOtherUserSessions(ctx context.Context, arg DeleteOtherUserSessionsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteOtherUserSessions, arg.UserID, arg.KeepHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteUser = `-- name: DeleteUser :execrows
+UPDATE scratch.user SET deleted_at = $1::timestamptz WHERE id = $2 AND deleted_at IS NULL
+`
+
+type DeleteUserParams struct {
+	Now time.Time
+	ID  int32
+}
+
+func (q *Queries) DeleteUser(ctx context.Context, arg DeleteUserParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUser, arg.Now, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteUserSession = `-- name: DeleteUserSession :execrows
+DELETE FROM scratch.session WHERE id = $1 AND user_id = $2
+`
+
+type DeleteUserSessionParams struct {
+	ID     int32
+	UserID int32
+}
+
+func (q *Queries) DeleteUserSession(ctx context.Context, arg DeleteUserSessionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUserSession, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteUserSessions = `-- name: DeleteUserSessions :execrows
+DELETE FROM scratch.session WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserSessions(ctx context.Context, userID int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUserSessions, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const disableUser = `-- name: DisableUser :execrows
+UPDATE scratch.user SET disabled_at = COALESCE(disabled_at, now()) WHERE lower(email) = lower($1::text) AND deleted_at IS NULL
+`
+
+func (q *Queries) DisableUser(ctx context.Context, email string) (int64, error) {
+	result, err := q.db.Exec(ctx, disableUser, email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getBrowserSession = `-- name: GetBrowserSession :one
+SELECT s.id, s.user_id, s.csrf_token::varchar AS csrf_token, s.expires_at::timestamptz AS expires_at, s.last_seen_at
+FROM scratch.session s
+JOIN scratch.user u ON u.id = s.user_id
+WHERE s.token_hash = $1::varchar AND s.expires_at > $2::timestamptz
+    AND u.disabled_at IS NULL AND u.deleted_at IS NULL
+`
+
+type GetBrowserSessionParams struct {
+	TokenHash string
+	Now       time.Time
+}
+
+type GetBrowserSessionRow struct {
+	ID         int32
+	UserID     int32
+	CsrfToken  string
+	ExpiresAt  time.Time
+	LastSeenAt time.Time
+}
+
+func (q *Queries) GetBrowserSession(ctx context.Context, arg GetBrowserSessionParams) (GetBrowserSessionRow, error) {
+	row := q.db.QueryRow(ctx, getBrowserSession, arg.TokenHash, arg.Now)
+	var i GetBrowserSessionRow
 	err := row.Scan(
 		&i.ID,
 		&i.UserID,
-		&i.RefreshToken,
-		&i.LoginDate,
+		&i.CsrfToken,
+		&i.ExpiresAt,
+		&i.LastSeenAt,
+	)
+	return i, err
+}
+
+const getDeletedUserByEmail = `-- name: GetDeletedUserByEmail :one
+SELECT id, name, email, password, disabled_at, locale, deleted_at FROM scratch.user WHERE lower(email) = lower($1::text) AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id DESC LIMIT 1
+`
+
+// The email may be registered again after the deletion, the latest deleted
+// account of it is the one to restore.
+func (q *Queries) GetDeletedUserByEmail(ctx context.Context, email string) (ScratchUser, error) {
+	row := q.db.QueryRow(ctx, getDeletedUserByEmail, email)
+	var i ScratchUser
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.Password,
+		&i.DisabledAt,
+		&i.Locale,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
+const getSessionByRefreshToken = `-- name: GetSessionByRefreshToken :one
+SELECT s.id, s.user_id
+FROM scratch.session s
+JOIN scratch.user u ON u.id = s.user_id
+WHERE s.refresh_token_hash = $1::varchar AND s.expires_at > $2::timestamptz
+    AND u.disabled_at IS NULL AND u.deleted_at IS NULL
+`
+
+type GetSessionByRefreshTokenParams struct {
+	RefreshTokenHash string
+	Now              time.Time
+}
+
+type GetSessionByRefreshTokenRow struct {
+	ID     int32
+	UserID int32
+}
+
+func (q *Queries) GetSessionByRefreshToken(ctx context.Context, arg GetSessionByRefreshTokenParams) (GetSessionByRefreshTokenRow, error) {
+	row := q.db.QueryRow(ctx, getSessionByRefreshToken, arg.RefreshTokenHash, arg.Now)
+	var i GetSessionByRefreshTokenRow
+	err := row.Scan(&i.ID, &i.UserID)
+	return i, err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, name, email, password FROM scratch.user WHERE email = $1
+SELECT id, name, email, password, disabled_at, locale, deleted_at FROM scratch.user WHERE lower(email) = lower($1::text) AND deleted_at IS NULL
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (ScratchUser, error) {
-	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
+	row := q.db.QueryRow(ctx, getUserByEmail, email)
 	var i ScratchUser
 	err := row.Scan(
 		&i.ID,
 		&i.Name,
 		&i.Email,
 		&i.Password,
+		&i.DisabledAt,
+		&i.Locale,
+		&i.DeletedAt,
 	)
 	return i, err
 }
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, name, email, password, disabled_at, locale, deleted_at FROM scratch.user WHERE id = $1 AND deleted_at IS NULL
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id int32) (ScratchUser, error) {
+	row := q.db.QueryRow(ctx, getUserByID, id)
+	var i ScratchUser
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.Password,
+		&i.DisabledAt,
+		&i.Locale,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const grantUserRole = `-- name: GrantUserRole :exec
+INSERT INTO scratch.user_role (user_id, role) VALUES ($1, $2) ON CONFLICT DO NOTHING
+`
+
+type GrantUserRoleParams struct {
+	UserID int32
+	Role   string
+}
+
+func (q *Queries) GrantUserRole(ctx context.Context, arg GrantUserRoleParams) error {
+	_, err := q.db.Exec(ctx, grantUserRole, arg.UserID, arg.Role)
+	return err
+}
+
+const listSessions = `-- name: ListSessions :many
+SELECT id, (token_hash IS NOT NULL)::boolean AS browser, COALESCE(token_hash, '')::varchar AS token_hash,
+    created_at, last_seen_at, expires_at::timestamptz AS expires_at, user_agent, ip, device_name
+FROM scratch.session
+WHERE user_id = $1 AND expires_at > $2::timestamptz
+ORDER BY last_seen_at DESC, id DESC
+`
+
+type ListSessionsParams struct {
+	UserID int32
+	Now    time.Time
+}
+
+type ListSessionsRow struct {
+	ID         int32
+	Browser    bool
+	TokenHash  string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+	UserAgent  string
+	Ip         string
+	DeviceName string
+}
+
+func (q *Queries) ListSessions(ctx context.Context, arg ListSessionsParams) ([]ListSessionsRow, error) {
+	rows, err := q.db.Query(ctx, listSessions, arg.UserID, arg.Now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSessionsRow
+	for rows.Next() {
+		var i ListSessionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Browser,
+			&i.TokenHash,
+			&i.CreatedAt,
+			&i.LastSeenAt,
+			&i.ExpiresAt,
+			&i.UserAgent,
+			&i.Ip,
+			&i.DeviceName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserRoles = `-- name: ListUserRoles :many
+SELECT role FROM scratch.user_role WHERE user_id = $1 ORDER BY role
+`
+
+func (q *Queries) ListUserRoles(ctx context.Context, userID int32) ([]string, error) {
+	rows, err := q.db.Query(ctx, listUserRoles, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		items = append(items, role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const refreshSession = `-- name: RefreshSession :execrows
+UPDATE scratch.session
+SET refresh_token_hash = $1::varchar, last_seen_at = $2, expires_at = $3::timestamptz,
+    user_agent = COALESCE(NULLIF($4::varchar, ''), user_agent), ip = COALESCE(NULLIF($5::varchar, ''), ip)
+WHERE id = $6 AND refresh_token_hash = $7::varchar
+`
+
+type RefreshSessionParams struct {
+	NewRefreshTokenHash string
+	Now                 time.Time
+	ExpiresAt           time.Time
+	UserAgent           string
+	Ip                  string
+	ID                  int32
+	RefreshTokenHash    string
+}
+
+func (q *Queries) RefreshSession(ctx context.Context, arg RefreshSessionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, refreshSession,
+		arg.NewRefreshTokenHash,
+		arg.Now,
+		arg.ExpiresAt,
+		arg.UserAgent,
+		arg.Ip,
+		arg.ID,
+		arg.RefreshTokenHash,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const rememberDevice = `-- name: RememberDevice :one
+INSERT INTO scratch.user_device (user_id, fingerprint, name, first_seen_at, last_seen_at)
+VALUES ($1, $2, $3, $4, $4)
+ON CONFLICT (user_id, fingerprint) DO UPDATE SET last_seen_at = EXCLUDED.last_seen_at
+RETURNING (xmax = 0)::boolean AS inserted
+`
+
+type RememberDeviceParams struct {
+	UserID      int32
+	Fingerprint string
+	Name        string
+	Now         time.Time
+}
+
+func (q *Queries) RememberDevice(ctx context.Context, arg RememberDeviceParams) (bool, error) {
+	row := q.db.QueryRow(ctx, rememberDevice,
+		arg.UserID,
+		arg.Fingerprint,
+		arg.Name,
+		arg.Now,
+	)
+	var inserted bool
+	err := row.Scan(&inserted)
+	return inserted, err
+}
+
+const restoreUser = `-- name: RestoreUser :execrows
+UPDATE scratch.user SET deleted_at = NULL WHERE id = $1 AND deleted_at > $2::timestamptz
+`
+
+type RestoreUserParams struct {
+	ID           int32
+	DeletedAfter time.Time
+}
+
+func (q *Queries) RestoreUser(ctx context.Context, arg RestoreUserParams) (int64, error) {
+	result, err := q.db.Exec(ctx, restoreUser, arg.ID, arg.DeletedAfter)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setUserLocale = `-- name: SetUserLocale :execrows
+UPDATE scratch.user SET locale = $1 WHERE lower(email) = lower($2::text) AND deleted_at IS NULL
+`
+
+type SetUserLocaleParams struct {
+	Locale sql.NullString
+	Email  string
+}
+
+func (q *Queries) SetUserLocale(ctx context.Context, arg SetUserLocaleParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setUserLocale, arg.Locale, arg.Email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const touchSession = `-- name: TouchSession :exec
+UPDATE scratch.session SET last_seen_at = $1, ip = COALESCE(NULLIF($2::varchar, ''), ip) WHERE id = $3
+`
+
+type TouchSessionParams struct {
+	Now time.Time
+	Ip  string
+	ID  int32
+}
+
+func (q *Queries) TouchSession(ctx context.Context, arg TouchSessionParams) error {
+	_, err := q.db.Exec(ctx, touchSession, arg.Now, arg.Ip, arg.ID)
+	return err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :one
+UPDATE scratch.user SET password = $1 WHERE lower(email) = lower($2::text) AND deleted_at IS NULL RETURNING id
+`
+
+type UpdateUserPasswordParams struct {
+	Password string
+	Email    string
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (int32, error) {
+	row := q.db.QueryRow(ctx, updateUserPassword, arg.Password, arg.Email)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :execrows
+UPDATE scratch.user SET name = $2, locale = $3 WHERE id = $1 AND deleted_at IS NULL
+`
+
+type UpdateUserProfileParams struct {
+	ID     int32
+	Name   string
+	Locale sql.NullString
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateUserProfile, arg.ID, arg.Name, arg.Locale)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,997 @@
+// Package dbtest checks that an implementation of db.Querier behaves like the
+// queries do on postgres, so the in-memory store can stand in for it.
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	db "scratch/internal/storage/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Open returns an empty store and a Transactor over it, for a single test.
+type Open func(t *testing.T) (db.Querier, db.Transactor)
+
+// now is in microseconds, the precision of timestamptz.
+var now = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+// TestQuerier runs the conformance suite against the stores returned by open.
+func TestQuerier(t *testing.T, open Open) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, q db.Querier, tx db.Transactor)
+	}{
+		{"users", testUsers},
+		{"deleted users", testDeletedUsers},
+		{"sessions", testSessions},
+		{"browser sessions", testBrowserSessions},
+		{"devices and roles", testDevicesAndRoles},
+		{"personal data", testPersonalData},
+		{"data exports", testDataExports},
+		{"organizations", testOrganizations},
+		{"invitations", testInvitations},
+		{"idempotency keys", testIdempotencyKeys},
+		{"jobs", testJobs},
+		{"mail", testMail},
+		{"outbox", testOutbox},
+		{"rate limits", testRateLimits},
+		{"transactions", testTransactions},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			q, tx := open(t)
+			tt.test(t, q, tx)
+		})
+	}
+}
+
+func testUsers(t *testing.T, q db.Querier, _ db.Transactor) {
+	ctx := context.Background()
+
+	user := createUser(t, q, "Norbi@example.com")
+	assert.Equal(t, "Norbi", user.Name)
+	assert.Equal(t, sql.NullString{String: "pl", Valid: true}, user.Locale)
+	assert.False(t, user.DisabledAt.Valid)
+
+	got, err := q.GetUserByEmail(ctx, "norbi@EXAMPLE.com")
+	require.NoError(t, err)
+	assert.Equal(t, user, got)
+
+	_, err = q.CreateUser(ctx, db.CreateUserParams{Name: "Other", Email: "NORBI@example.com", Password: "secret"})
+	assert.True(t, db.IsUniqueViolation(err), "case-insensitive duplicate email: %v", err)
+
+	_, err = q.GetUserByEmail(ctx, "missing@example.com")
+	assert.ErrorIs(t, err, db.ErrNoRows)
+	_, err = q.GetUserByID(ctx, user.ID+100)
+	assert.ErrorIs(t, err, db.ErrNoRows)
+
+	n, err := q.UpdateUserProfile(ctx, db.UpdateUserProfileParams{ID: user.ID, Name: "Norbert"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = q.UpdateUserProfile(ctx, db.UpdateUserProfileParams{ID: user.ID + 100, Name: "Nobody"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	// the email matches in any case like on login
+	n, err = q.SetUserLocale(ctx, db.SetUserLocaleParams{Email: "norbi@example.com", Locale: sql.NullString{String: "en", Valid: true}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	id, err := q.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{Email: "NORBI@example.com", Password: "changed"})
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, id)
+	_, err = q.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{Email: "missing@example.com", Password: "changed"})
+	assert.ErrorIs(t, err, db.ErrNoRows)
+
+	n, err = q.DisableUser(ctx, "norbi@EXAMPLE.com")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	got, err = q.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Norbert", got.Name)
+	assert.Equal(t, sql.NullString{String: "en", Valid: true}, got.Locale)
+	assert.Equal(t, "changed", got.Password)
+	assert.True(t, got.DisabledAt.Valid)
+
+	// disabling again keeps the first time
+	_, err = q.DisableUser(ctx, user.Email)
+	require.NoError(t, err)
+	again, err := q.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.True(t, got.DisabledAt.Time.Equal(again.DisabledAt.Time))
+}
+
+func testDeletedUsers(t *testing.T, q db.Querier, _ db.Transactor) {
+	ctx := context.Background()
+	user := createUser(t, q, "norbi@example.com")
+	_, err := q.CreateSession(ctx, db.CreateSessionParams{UserID: user.ID, RefreshTokenHash: "token", Now: now, ExpiresAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+	require.NoError(t, q.GrantUserRole(ctx, db.GrantUserRoleParams{UserID: user.ID, Role: "admin"}))
+	_, err = q.RememberDevice(ctx, db.RememberDeviceParams{UserID: user.ID, Fingerprint: "a", Name: "curl", Now: now})
+	require.NoError(t, err)
+
+	n, err := q.DeleteUser(ctx, db.DeleteUserParams{ID: user.ID, Now: now})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = q.DeleteUser(ctx, db.DeleteUserParams{ID: user.ID, Now: now.Add(time.Minute)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n, "already deleted")
+
+	_, err = q.GetUserByEmail(ctx, user.Email)
+	assert.ErrorIs(t, err, db.ErrNoRows)
+	_, err = q.GetUserByID(ctx, user.ID)
+	assert.ErrorIs(t, err, db.ErrNoRows)
+	_, err = q.GetSessionByRefreshToken(ctx, db.GetSessionByRefreshTokenParams{RefreshTokenHash: "token", Now: now})
+	assert.ErrorIs(t, err, db.ErrNoRows, "session of a deleted user")
+	n, err = q.UpdateUserProfile(ctx, db.UpdateUserProfileParams{ID: user.ID, Name: "Norbert"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+	n, err = q.SetUserLocale(ctx, db.SetUserLocaleParams{Email: user.Email})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+	n, err = q.DisableUser(ctx, user.Email)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+	_, err = q.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{Email: user.Email, Password: "changed"})
+	assert.ErrorIs(t, err, db.ErrNoRows)
+
+	deleted, err := q.GetDeletedUserByEmail(ctx, "NORBI@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, deleted.ID)
+	assert.True(t, deleted.DeletedAt.Time.Equal(now))
+
+	// the email is free to register again, which blocks restoring the account
+	again, err := q.CreateUser(ctx, db.CreateUserParams{Name: "Again", Email: "norbi@example.com", Password: "secret"})
+	require.NoError(t, err)
+	_, err = q.RestoreUser(ctx, db.RestoreUserParams{ID: user.ID, DeletedAfter: now.Add(-time.Hour)})
+	assert.True(t, db.IsUniqueViolation(err), "the email is taken again: %v", err)
+	_, err = q.DeleteUser(ctx, db.DeleteUserParams{ID: again.ID, Now: now.Add(-time.Minute)})
+	require.NoError(t, err)
+	deleted, err = q.GetDeletedUserByEmail(ctx, "norbi@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, deleted.ID, "the latest deleted account")
+	n, err = q.EraseUser(ctx, again.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	n, err = q.RestoreUser(ctx, db.RestoreUserParams{ID: user.ID, DeletedAfter: now})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n, "past the grace period")
+	n, err = q.RestoreUser(ctx, db.RestoreUserParams{ID: user.ID, DeletedAfter: now.Add(-time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	restored, err := q.GetUserByEmail(ctx, user.Email)
+	require.NoError(t, err)
+	assert.Equal(t, user, restored)
+	_, err = q.GetDeletedUserByEmail(ctx, user.Email)
+	assert.ErrorIs(t, err, db.ErrNoRows)
+
+	other := createUser(t, q, "other@example.com")
+	_, err = q.DeleteUser(ctx, db.DeleteUserParams{ID: user.ID, Now: now})
+	require.NoError(t, err)
+	_, err = q.DeleteUser(ctx, db.DeleteUserParams{ID: other.ID, Now: now.Add(time.Hour)})
+	require.NoError(t, err)
+
+	ids, err := q.ListUsersDeletedBefore(ctx, now)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+	ids, err = q.ListUsersDeletedBefore(ctx, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, []int32{user.ID}, ids)
+	n, err = q.EraseUser(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	_, err = q.GetDeletedUserByEmail(ctx, user.Email)
+	assert.ErrorIs(t, err, db.ErrNoRows)
+	_, err = q.GetDeletedUserByEmail(ctx, other.Email)
+	require.NoError(t, err, "deleted later")
+	sessions, err := q.ListSessions(ctx, db.ListSessionsParams{UserID: user.ID, Now: now})
+	require.NoError(t, err)
+	assert.Empty(t, sessions, "sessions cascade")
+	roles, err := q.ListUserRoles(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, roles)
+	count, err := q.CountUserDevices(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	// the purged email is free again
+	createUser(t, q, user.Email)
+}
+
+func testSessions(t *testing.T, q db.Querier, _ db.Transactor) {
+	ctx := context.Background()
+	user := createUser(t, q, "norbi@example.com")
+
+	id, err := q.CreateSession(ctx, db.CreateSessionParams{
+		UserID: user.ID, RefreshTokenHash: "token", Now: now, ExpiresAt: now.Add(time.Hour),
+		UserAgent: "curl", Ip: "10.0.0.1", DeviceName: "cli",
+	})
+	require.NoError(t, err)
+
+	_, err = q.CreateSession(ctx, db.CreateSessionParams{UserID: user.ID, RefreshTokenHash: "token", Now: now, ExpiresAt: now.Add(time.Hour)})
+	assert.True(t, db.IsUniqueViolation(err), "duplicate refresh token: %v", err)
+
+	_, err = q.CreateSession(ctx, db.CreateSessionParams{UserID: user.ID + 100, RefreshTokenHash: "orphan", Now: now, ExpiresAt: now.Add(time.Hour)})
+	assert.Equal(t, db.ForeignKeyViolation, db.SQLState(err), "session of a missing user: %v", err)
+
+	session, err := q.GetSessionByRefreshToken(ctx, db.GetSessionByRefreshTokenParams{RefreshTokenHash: "token", Now: now})
+	require.NoError(t, err)
+	assert.Equal(t, id, session.ID)
+	assert.Equal(t, user.ID, session.UserID)
+
+	_, err = q.GetSessionByRefreshToken(ctx, db.GetSessionByRefreshTokenParams{RefreshTokenHash: "token", Now: now.Add(time.Hour)})
+	assert.ErrorIs(t, err, db.ErrNoRows, "expired")
+
+	n, err := q.RefreshSession(ctx, db.RefreshSessionParams{
+		ID: session.ID, RefreshTokenHash: "wrong", NewRefreshTokenHash: "rotated", Now: now, ExpiresAt: now.Add(time.Hour),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	later := now.Add(time.Minute)
+	n, err = q.RefreshSession(ctx, db.RefreshSessionParams{
+		ID: session.ID, RefreshTokenHash: "token", NewRefreshTokenHash: "rotated", Now: later, ExpiresAt: later.Add(2 * time.Hour), Ip: "10.0.0.2",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	_, err = q.GetSessionByRefreshToken(ctx, db.GetSessionByRefreshTokenParams{RefreshTokenHash: "token", Now: now})
+	assert.ErrorIs(t, err, db.ErrNoRows, "rotated token")
+
+	sessions, err := q.ListSessions(ctx, db.ListSessionsParams{UserID: user.ID, Now: now.Add(90 * time.Minute)})
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, session.ID, sessions[0].ID)
+	assert.False(t, sessions[0].Browser)
+	assert.Equal(t, "", sessions[0].TokenHash)
+	assert.Equal(t, "curl", sessions[0].UserAgent, "kept when empty")
+	assert.Equal(t, "10.0.0.2", sessions[0].Ip)
+	assert.WithinDuration(t, later, sessions[0].LastSeenAt, 0)
+	assert.WithinDuration(t, later.Add(2*time.Hour), sessions[0].ExpiresAt, 0)
+
+	_, err = q.DisableUser(ctx, user.Email)
+	require.NoError(t, err)
+	_, err = q.GetSessionByRefreshToken(ctx, db.GetSessionByRefreshTokenParams{RefreshTokenHash: "rotated", Now: now})
+	assert.ErrorIs(t, err, db.ErrNoRows, "disabled user")
+
+	n, err = q.DeleteUserSession(ctx, db.DeleteUserSessionParams{ID: session.ID, UserID: user.ID + 100})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n, "session of another user")
+
+	n, err = q.DeleteExpiredSessions(ctx, now.Add(3*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	sessions, err = q.ListSessions(ctx, db.ListSessionsParams{UserID: user.ID, Now: now})
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func testBrowserSessions(t *testing.T, q db.Querier, _ db.Transactor) {
+	ctx := context.Background()
+	user := createUser(t, q, "norbi@example.com")
+
+	for i, hash := range []string{"first", "second"} {
+		_, err := q.CreateBrowserSession(ctx, db.CreateBrowserSessionParams{
+			UserID: user.ID, TokenHash: hash, CsrfToken: "csrf-" + hash,
+			Now: now.Add(time.Duration(i) * time.Minute), ExpiresAt: now.Add(time.Hour), DeviceName: "Firefox",
+		})
+		require.NoError(t, err)
+	}
+	_, err := q.CreateBrowserSession(ctx, db.CreateBrowserSessionParams{UserID: user.ID, TokenHash: "first", Now: now, ExpiresAt: now.Add(time.Hour)})
+	assert.True(t, db.IsUniqueViolation(err), "duplicate token hash: %v", err)
+
+	session, err := q.GetBrowserSession(ctx, db.GetBrowserSessionParams{TokenHash: "first", Now: now})
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, session.UserID)
+	assert.Equal(t, "csrf-first", session.CsrfToken)
+	assert.WithinDuration(t, now.Add(time.Hour), session.ExpiresAt, 0)
+
+	_, err = q.GetBrowserSession(ctx, db.GetBrowserSessionParams{TokenHash: "first", Now: now.Add(2 * time.Hour)})
+	assert.ErrorIs(t, err, db.ErrNoRows, "expired")
+
+	err = q.TouchSession(ctx, db.TouchSessionParams{ID: session.ID, Now: now.Add(5 * time.Minute), Ip: "10.0.0.3"})
+	require.NoError(t, err)
+
+	sessions, err := q.ListSessions(ctx, db.ListSessionsParams{UserID: user.ID, Now: now})
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, session.ID, sessions[0].ID, "most recently seen first")
+	assert.True(t, sessions[0].Browser)
+	assert.Equal(t, "first", sessions[0].TokenHash)
+	assert.Equal(t, "10.0.0.3", sessions[0].Ip)
+	assert.Equal(t, "second", sessions[1].TokenHash)
+
+	_, err = q.CreateSession(ctx, db.CreateSessionParams{UserID: user.ID, RefreshTokenHash: "api", Now: now, ExpiresAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+	n, err := q.DeleteOtherUserSessions(ctx, db.DeleteOtherUserSessionsParams{UserID: user.ID + 100, KeepHash: "first"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n, "sessions of another user")
+	n, err = q.DeleteOtherUserSessions(ctx, db.DeleteOtherUserSessionsParams{UserID: user.ID, KeepHash: "first"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n, "the other browser and the api session")
+	_, err = q.GetBrowserSession(ctx, db.GetBrowserSessionParams{TokenHash: "first", Now: now})
+	require.NoError(t, err, "kept")
+	_, err = q.CreateBrowserSession(ctx, db.CreateBrowserSessionParams{UserID: user.ID, TokenHash: "second", Now: now, ExpiresAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+
+	n, err = q.DeleteBrowserSession(ctx, "first")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = q.DeleteUserSessions(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = q.DeleteAllSessions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+}
+
+func testDevicesAndRoles(t *testing.T, q db.Querier, _ db.Transactor) {
+	ctx := context.Background()
+	user := createUser(t, q, "norbi@example.com")
+
+	inserted, err := q.RememberDevice(ctx, db.RememberDeviceParams{UserID: user.ID, Fingerprint: "a", Name: "Firefox", Now: now})
+	require.NoError(t, err)
+	assert.True(t, inserted)
+	inserted, err = q.RememberDevice(ctx, db.RememberDeviceParams{UserID: user.ID, Fingerprint: "a", Name: "Firefox", Now: now})
+	require.NoError(t, err)
+	assert.False(t, inserted)
+	_, err = q.RememberDevice(ctx, db.RememberDeviceParams{UserID: user.ID, Fingerprint: "b", Name: "curl", Now: now})
+	require.NoError(t, err)
+
+	count, err := q.CountUserDevices(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	_, err = q.RememberDevice(ctx, db.RememberDeviceParams{UserID: user.ID + 100, Fingerprint: "a", Now: now})
+	assert.Equal(t, db.ForeignKeyViolation, db.SQLState(err), "device of a missing user: %v", err)
+
+	roles, err := q.ListUserRoles(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, roles)
+
+	for _, role := range []string{"support", "admin", "admin"} {
+		require.NoError(t, q.GrantUserRole(ctx, db.GrantUserRoleParams{UserID: user.ID, Role: role}))
+	}
+	roles, err = q.ListUserRoles(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admin", "support"}, roles)
+
+	err = q.GrantUserRole(ctx, db.GrantUserRoleParams{UserID: user.ID + 100, Role: "admin"})
+	assert.Equal(t, db.ForeignKeyViolation, db.SQLState(err), "role of a missing user: %v", err)
+
+	_, err = q.CreateSession(ctx, db.CreateSessionParams{UserID: user.ID, RefreshTokenHash: "token", Now: now, ExpiresAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+	require.NoError(t, q.CleanUserTable(ctx), "sessions go with their user")
+	sessions, err := q.ListSessions(ctx, db.ListSessionsParams{UserID: user.ID, Now: now})
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+	roles, err = q.ListUserRoles(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, roles)
+	count, err = q.CountUserDevices(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func testPersonalData(t *testing.T, q db.Querier, _ db.Transactor) {
+	ctx := context.Background()
+	user := createUser(t, q, "norbi@example.com")
+	other := createUser(t, q, "other@example.com")
+	aggregateID := strconv.Itoa(int(user.ID))
+
+	for _, u := range []db.ScratchUser{user, other} {
+		_, err := q.CreateSession(ctx, db.CreateSessionParams{
+			UserID: u.ID, RefreshTokenHash: u.Email, Now: now, ExpiresAt: now.Add(time.Hour),
+			UserAgent: "curl", Ip: "10.0.0.1", DeviceName: "cli",
+		})
+		require.NoError(t, err)
+		_, err = q.RememberDevice(ctx, db.RememberDeviceParams{UserID: u.ID, Fingerprint: "a", Name: "curl", Now: now})
+		require.NoError(t, err)
+		require.NoError(t, q.GrantUserRole(ctx, db.GrantUserRoleParams{UserID: u.ID, Role: "admin"}))
+		require.NoError(t, q.InsertOutboxEvent(ctx, db.InsertOutboxEventParams{
+			AggregateType: "user", AggregateID: strconv.Itoa(int(u.ID)), EventType: "user.registered",
+			Payload: json.RawMessage(`{"email":"` + u.Email + `"}`), OccurredAt: now,
+		}))
+	}
+	require.NoError(t, q.InsertOutboxEvent(ctx, db.InsertOutboxEventParams{
+		AggregateType: "order", AggregateID: aggregateID, EventType: "order.placed",
+		Payload: json.RawMessage(`{}`), OccurredAt: now,
+	}))
+
+	sessions, err := q.ListUserSessions(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "10.0.0.1", sessions[0].Ip)
+	assert.True(t, sessions[0].ExpiresAt.Time.Equal(now.Add(time.Hour)))
+	roles, err := q.ListUserRoleGrants(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, roles, 1)
+	assert.Equal(t, "admin", roles[0].Role)
+	devices, err := q.ListUserDevices(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, devices, 1)
+	assert.Equal(t, "curl", devices[0].Name)
+	events, err := q.ListUserEvents(ctx, aggregateID)
+	require.NoError(t, err)
+	require.Len(t, events, 1, "only the events of the user aggregate")
+	assert.Equal(t, "user.registered", events[0].EventType)
+	assert.JSONEq(t, `{"email":"norbi@example.com"}`, string(events[0].Payload))
+
+	// the user goes last, its rows would cascade
+	for _, erase := range []struct {
+		name string
+		f    func() (int64, error)
+	}{
+		{"sessions", func() (int64, error) { return q.DeleteUserSessions(ctx, user.ID) }},
+		{"roles", func() (int64, error) { return q.DeleteUserRoles(ctx, user.ID) }},
+		{"devices", func() (int64, error) { return q.DeleteUserDevices(ctx, user.ID) }},
+		{"events", func() (int64, error) { return q.DeleteUserEvents(ctx, aggregateID) }},
+		{"user", func() (int64, error) { return q.EraseUser(ctx, user.ID) }},
+	} {
+		n, err := erase.f()
+		require.NoError(t, err, erase.name)
+		assert.Equal(t, int64(1), n, erase.name)
+	}
+	n, err := q.EraseUser(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n, "already erased")
+
+	events, err = q.ListUserEvents(ctx, strconv.Itoa(int(other.ID)))
+	require.NoError(t, err)
+	assert.Len(t, events, 1, "the other user keeps its events")
+	roles, err = q.ListUserRoleGrants(ctx, other.ID)
+	require.NoError(t, err)
+	assert.Len(t, roles, 1)
+	_, err = q.GetUserByID(ctx, other.ID)
+	require.NoError(t, err)
+}
+
+func testDataExports(t *testing.T, q db.Querier, _ db.Transactor) {
+	ctx := context.Background()
+	user := createUser(t, q, "norbi@example.com")
+
+	_, err := q.GetDataExport(ctx, user.ID)
+	assert.ErrorIs(t, err, db.ErrNoRows)
+
+	n, err := q.RequestDataExport(ctx, db.RequestDataExportParams{UserID: user.ID, Now: now})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = q.RequestDataExport(ctx, db.RequestDataExportParams{UserID: user.ID, Now: now.Add(time.Minute)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n, "being built")
+
+	export, err := q.GetDataExport(ctx, user.ID)
+	require.NoError(t, err)
+	assert.True(t, export.RequestedAt.Equal(now))
+	assert.False(t, export.ReadyAt.Valid)
+	_, err = q.GetDataExportArchive(ctx, db.GetDataExportArchiveParams{UserID: user.ID, ReadyAfter: now.Add(-time.Hour)})
+	assert.ErrorIs(t, err, db.ErrNoRows, "not ready")
+
+	n, err = q.SaveDataExport(ctx, db.SaveDataExportParams{UserID: user.ID, Archive: []byte("zip"), Now: now.Add(time.Minute)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = q.SaveDataExport(ctx, db.SaveDataExportParams{UserID: user.ID, Archive: []byte("other"), Now: now.Add(time.Minute)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n, "already saved")
+
+	export, err = q.GetDataExport(ctx, user.ID)
+	require.NoError(t, err)
+	assert.True(t, export.ReadyAt.Time.Equal(now.Add(time.Minute)))
+	archive, err := q.GetDataExportArchive(ctx, db.GetDataExportArchiveParams{UserID: user.ID, ReadyAfter: now})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("zip"), archive)
+	_, err = q.GetDataExportArchive(ctx, db.GetDataExportArchiveParams{UserID: user.ID, ReadyAfter: now.Add(time.Hour)})
+	assert.ErrorIs(t, err, db.ErrNoRows, "expired")
+
+	n, err = q.RequestDataExport(ctx, db.RequestDataExportParams{UserID: user.ID, Now: now.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n, "a ready export is replaced")
+	export, err = q.GetDataExport(ctx, user.ID)
+	require.NoError(t, err)
+	assert.False(t, export.ReadyAt.Valid)
+
+	_, err = q.RequestDataExport(ctx, db.RequestDataExportParams{UserID: user.ID + 100, Now: now})
+	assert.Equal(t, db.ForeignKeyViolation, db.SQLState(err), "export of a missing user: %v", err)
+
+	_, err = q.SaveDataExport(ctx, db.SaveDataExportParams{UserID: user.ID, Archive: []byte("zip"), Now: now.Add(2 * time.Hour)})
+	require.NoError(t, err)
+	n, err = q.DeleteExpiredDataExports(ctx, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+	n, err = q.DeleteExpiredDataExports(ctx, now.Add(3*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	_, err = q.RequestDataExport(ctx, db.RequestDataExportParams{UserID: user.ID, Now: now})
+	require.NoError(t, err)
+	n, err = q.DeleteDataExport(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	_, err = q.GetDataExport(ctx, user.ID)
+	assert.ErrorIs(t, err, db.ErrNoRows)
+}
+
+func testOrganizations(t *testing.T, q db.Querier, _ db.Transactor) {
+	ctx := context.Background()
+	owner := createUser(t, q, "norbi@example.com")
+	member := createUser(t, q, "Member@example.com")
+
+	org, err := q.CreateOrganization(ctx, db.CreateOrganizationParams{Slug: "acme", Name: "Acme", Now: now})
+	require.NoError(t, err)
+	assert.Equal(t, "acme", org.Slug)
+	got, err := q.GetOrganization(ctx, org.ID)
+	require.NoError(t, err)
+	assert.Equal(t, org, got)
+	_, err = q.CreateOrganization(ctx, db.CreateOrganizationParams{Slug: "acme", Name: "Other", Now: now})
+	assert.Equal(t, db.UniqueViolation, db.SQLState(err), "slug taken: %v", err)
+	_, err = q.GetOrganization(ctx, org.ID+100)
+	assert.ErrorIs(t, err, db.ErrNoRows)
+
+	n, err := q.AddOrganizationMember(ctx, db.AddOrganizationMemberParams{OrganizationID: org.ID, UserID: owner.ID, Role: "owner", Now: now})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = q.AddOrganizationMember(ctx, db.AddOrganizationMemberParams{OrganizationID: org.ID, UserID: owner.ID, Role: "member", Now: now})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n, "already a member")
+	_, err = q.AddOrganizationMember(ctx, db.AddOrganizationMemberParams{OrganizationID: org.ID + 100, UserID: owner.ID, Role: "member", Now: now})
+	assert.Equal(t, db.ForeignKeyViolation, db.SQLState(err), "member of a missing organization: %v", err)
+
+	invitation, err := q.CreateInvitation(ctx, db.CreateInvitationParams{
+		OrganizationID: org.ID, Email: "member@example.com", Role: "admin", CodeHash: "hash", Now: now, ExpiresAt: now.Add(time.Hour),
+	})
+	require.NoError(t, err)
+	assert.False(t, invitation.AcceptedAt.Valid)
+	_, err = q.CreateInvitation(ctx, db.CreateInvitationParams{
+		OrganizationID: org.ID, Email: "other@example.com", Role: "admin", CodeHash: "hash", Now: now, ExpiresAt: now.Add(time.Hour),
+	})
+	assert.Equal(t, db.UniqueViolation, db.SQLState(err), "code taken: %v", err)
+	found, err := q.GetInvitationByCode(ctx, "hash")
+	require.NoError(t, err)
+	assert.Equal(t, invitation.ID, found.ID)
+	assert.True(t, found.ExpiresAt.Equal(now.Add(time.Hour)))
+
+	n, err = q.AcceptInvitation(ctx, db.AcceptInvitationParams{ID: invitation.ID, Now: now.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n, "expired")
+	n, err = q.AcceptInvitation(ctx, db.AcceptInvitationParams{ID: invitation.ID, Now: now.Add(time.Minute)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = q.AcceptInvitation(ctx, db.AcceptInvitationParams{ID: invitation.ID, Now: now.Add(time.Minute)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n, "single use")
+	_, err = q.AddOrganizationMember(ctx, db.AddOrganizationMemberParams{OrganizationID: org.ID, UserID: member.ID, Role: "admin", Now: now.Add(time.Minute)})
+	require.NoError(t, err)
+
+	members, err := q.ListOrganizationMembers(ctx, org.ID)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	assert.Equal(t, owner.ID, members[0].UserID)
+	assert.Equal(t, "Member@example.com", members[1].Email)
+	assert.Equal(t, "admin", members[1].Role)
+	organizations, err := q.ListUserOrganizations(ctx, member.ID)
+	require.NoError(t, err)
+	require.Len(t, organizations, 1)
+	assert.Equal(t, "Acme", organizations[0].Name)
+	assert.Equal(t, "admin", organizations[0].Role)
+	invitations, err := q.ListUserInvitations(ctx, member.ID)
+	require.NoError(t, err)
+	require.Len(t, invitations, 1, "matched by email, whatever its case")
+	assert.Equal(t, "Acme", invitations[0].Organization)
+	assert.True(t, invitations[0].AcceptedAt.Valid)
+
+	n, err = q.CountOrganizationOwners(ctx, org.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = q.UpdateOrganizationMemberRole(ctx, db.UpdateOrganizationMemberRoleParams{OrganizationID: org.ID, UserID: member.ID, Role: "owner"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = q.CountOrganizationOwners(ctx, org.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	m, err := q.GetOrganizationMember(ctx, db.GetOrganizationMemberParams{OrganizationID: org.ID, UserID: member.ID})
+	require.NoError(t, err)
+	assert.Equal(t, "owner", m.Role)
+
+	n, err = q.RemoveOrganizationMember(ctx, db.RemoveOrganizationMemberParams{OrganizationID: org.ID, UserID: owner.ID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	_, err = q.GetOrganizationMember(ctx, db.GetOrganizationMemberParams{OrganizationID: org.ID, UserID: owner.ID})
+	assert.ErrorIs(t, err, db.ErrNoRows)
+
+	n, err = q.DeleteUserInvitations(ctx, member.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = q.DeleteUserMemberships(ctx, member.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	organizations, err = q.ListUserOrganizations(ctx, member.ID)
+	require.NoError(t, err)
+	assert.Empty(t, organizations)
+}
+
+func testInvitations(t *testing.T, q db.Querier, _ db.Transactor) {
+	ctx := context.Background()
+	inviter := createUser(t, q, "norbi@example.com")
+	invitee := createUser(t, q, "joe@example.com")
+	org, err := q.CreateOrganization(ctx, db.CreateOrganizationParams{Slug: "acme", Name: "Acme", Now: now})
+	require.NoError(t, err)
+	invitedBy := sql.NullInt32{Int32: inviter.ID, Valid: true}
+
+	accepted, err := q.CreateInvitation(ctx, db.CreateInvitationParams{
+		OrganizationID: org.ID, Email: "joe@example.com", Role: "member", CodeHash: "accepted", InvitedBy: invitedBy, Now: now, ExpiresAt: now.Add(time.Hour),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, invitedBy, accepted.InvitedBy)
+	revoked, err := q.CreateInvitation(ctx, db.CreateInvitationParams{
+		OrganizationID: org.ID, Email: "ann@example.com", Role: "admin", CodeHash: "revoked", InvitedBy: invitedBy, Now: now.Add(time.Second), ExpiresAt: now.Add(time.Hour),
+	})
+	require.NoError(t, err)
+	_, err = q.CreateInvitation(ctx, db.CreateInvitationParams{
+		OrganizationID: org.ID, Email: "ann@example.com", Role: "admin", CodeHash: "stranger", InvitedBy: sql.NullInt32{Int32: invitee.ID + 100, Valid: true}, Now: now, ExpiresAt: now.Add(time.Hour),
+	})
+	assert.Equal(t, db.ForeignKeyViolation, db.SQLState(err), "invited by a missing user: %v", err)
+
+	n, err := q.RevokeInvitation(ctx, db.RevokeInvitationParams{ID: revoked.ID, OrganizationID: org.ID + 100, Now: now})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n, "of another organization")
+	n, err = q.RevokeInvitation(ctx, db.RevokeInvitationParams{ID: revoked.ID, OrganizationID: org.ID, Now: now})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = q.RevokeInvitation(ctx, db.RevokeInvitationParams{ID: revoked.ID, OrganizationID: org.ID, Now: now})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n, "revoked already")
+	n, err = q.AcceptInvitation(ctx, db.AcceptInvitationParams{ID: revoked.ID, AcceptedBy: sql.NullInt32{Int32: invitee.ID, Valid: true}, Now: now})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n, "revoked")
+
+	n, err = q.AcceptInvitation(ctx, db.AcceptInvitationParams{ID: accepted.ID, AcceptedBy: sql.NullInt32{Int32: invitee.ID, Valid: true}, Now: now})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = q.RevokeInvitation(ctx, db.RevokeInvitationParams{ID: accepted.ID, OrganizationID: org.ID, Now: now})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n, "accepted already")
+
+	got, err := q.GetInvitation(ctx, db.GetInvitationParams{ID: revoked.ID, OrganizationID: org.ID})
+	require.NoError(t, err)
+	assert.True(t, got.RevokedAt.Valid)
+	_, err = q.GetInvitation(ctx, db.GetInvitationParams{ID: revoked.ID, OrganizationID: org.ID + 100})
+	assert.ErrorIs(t, err, db.ErrNoRows)
+
+	invitations, err := q.ListOrganizationInvitations(ctx, org.ID)
+	require.NoError(t, err)
+	require.Len(t, invitations, 2)
+	assert.Equal(t, revoked.ID, invitations[0].ID, "newest first")
+	assert.Equal(t, sql.NullString{String: "Norbi", Valid: true}, invitations[1].InviterName)
+	assert.Equal(t, sql.NullInt32{Int32: invitee.ID, Valid: true}, invitations[1].AcceptedBy)
+	assert.Equal(t, sql.NullString{String: "Norbi", Valid: true}, invitations[1].AccepterName)
+	assert.True(t, invitations[1].AcceptedAt.Valid)
+
+	_, err = q.EraseUser(ctx, inviter.ID)
+	require.NoError(t, err)
+	invitations, err = q.ListOrganizationInvitations(ctx, org.ID)
+	require.NoError(t, err)
+	require.Len(t, invitations, 2, "the invitations outlive their inviter")
+	assert.False(t, invitations[1].InvitedBy.Valid)
+	assert.False(t, invitations[1].InviterName.Valid)
+	received, err := q.ListUserInvitations(ctx, invitee.ID)
+	require.NoError(t, err)
+	require.Len(t, received, 1)
+	assert.False(t, received[0].RevokedAt.Valid)
+}
+
+func testIdempotencyKeys(t *testing.T, q db.Querier, _ db.Transactor) {
+	ctx := context.Background()
+	key := db.GetIdempotencyKeyParams{Key: "key", Operation: "postRegister"}
+
+	_, err := q.GetIdempotencyKey(ctx, key)
+	assert.ErrorIs(t, err, db.ErrNoRows)
+
+	insert := db.InsertIdempotencyKeyParams{Key: key.Key, Operation: key.Operation, Fingerprint: "a", LockedAt: now, ExpiresAt: now.Add(time.Hour)}
+	n, err := q.InsertIdempotencyKey(ctx, insert)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = q.InsertIdempotencyKey(ctx, insert)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	takeOver := db.TakeOverIdempotencyKeyParams{
+		Key: key.Key, Operation: key.Operation, Fingerprint: "b",
+		Now: now.Add(time.Second), ExpiresAt: now.Add(2 * time.Hour), StaleBefore: now.Add(-time.Minute),
+	}
+	n, err = q.TakeOverIdempotencyKey(ctx, takeOver)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n, "locked by a live request")
+
+	complete := db.CompleteIdempotencyKeyParams{
+		Key: key.Key, Operation: key.Operation, LockedAt: now.Add(time.Second),
+		StatusCode: sql.NullInt32{Int32: 201, Valid: true}, ResponseHeader: []byte(`{}`), ResponseBody: []byte(`{"id":1}`),
+	}
+	n, err = q.CompleteIdempotencyKey(ctx, complete)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n, "locked by another request")
+	require.NoError(t, q.DeleteIdempotencyKey(ctx, db.DeleteIdempotencyKeyParams{Key: key.Key, Operation: key.Operation, LockedAt: now.Add(time.Second)}))
+	complete.LockedAt = now
+	n, err = q.CompleteIdempotencyKey(ctx, complete)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = q.CompleteIdempotencyKey(ctx, complete)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n, "completed already")
+
+	row, err := q.GetIdempotencyKey(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, "a", row.Fingerprint)
+	assert.Equal(t, sql.NullInt32{Int32: 201, Valid: true}, row.StatusCode)
+	assert.Equal(t, []byte(`{"id":1}`), row.ResponseBody)
+
+	takeOver.Now = now.Add(2 * time.Hour)
+	takeOver.StaleBefore = now.Add(time.Hour)
+	n, err = q.TakeOverIdempotencyKey(ctx, takeOver)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n, "expired")
+
+	row, err = q.GetIdempotencyKey(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, "b", row.Fingerprint)
+	assert.False(t, row.StatusCode.Valid)
+	assert.Nil(t, row.ResponseBody)
+
+	require.NoError(t, q.DeleteIdempotencyKey(ctx, db.DeleteIdempotencyKeyParams{Key: key.Key, Operation: key.Operation, LockedAt: now}))
+	_, err = q.GetIdempotencyKey(ctx, key)
+	require.NoError(t, err, "taken over, the first request releases it no more")
+	require.NoError(t, q.DeleteIdempotencyKey(ctx, db.DeleteIdempotencyKeyParams{Key: key.Key, Operation: key.Operation, LockedAt: takeOver.Now}))
+	_, err = q.GetIdempotencyKey(ctx, key)
+	assert.ErrorIs(t, err, db.ErrNoRows, "released")
+
+	n, err = q.InsertIdempotencyKey(ctx, insert)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = q.DeleteExpiredIdempotencyKeys(ctx, now.Add(3*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+}
+
+func testJobs(t *testing.T, q db.Querier, _ db.Transactor) {
+	ctx := context.Background()
+	payload := json.RawMessage(`{"user_id":1}`)
+	enqueue := func(kind, key string, runAt time.Time) int64 {
+		t.Helper()
+		n, err := q.EnqueueJob(ctx, db.EnqueueJobParams{
+			Kind: kind, Payload: payload, UniqueKey: sql.NullString{String: key, Valid: key != ""},
+			MaxAttempts: 3, RunAt: runAt, Now: now,
+		})
+		require.NoError(t, err)
+		return n
+	}
+
+	assert.Equal(t, int64(1), enqueue("welcome", "1", now.Add(-time.Minute)))
+	assert.Equal(t, int64(0), enqueue("welcome", "1", now), "duplicate unique key")
+	assert.Equal(t, int64(1), enqueue("cleanup", "", now.Add(-2*time.Minute)))
+	assert.Equal(t, int64(1), enqueue("cleanup", "", now), "no unique key")
+	assert.Equal(t, int64(1), enqueue("later", "", now.Add(time.Hour)))
+
+	claimed, err := q.ClaimJobs(ctx, db.ClaimJobsParams{Now: now, Worker: "w1", StaleBefore: now.Add(-time.Hour), BatchSize: 2})
+	require.NoError(t, err)
+	sortJobs(claimed)
+	require.Len(t, claimed, 2, "the earliest due, up to the batch size")
+	assert.Equal(t, "welcome", claimed[0].Kind)
+	assert.Equal(t, "cleanup", claimed[1].Kind)
+	assert.Equal(t, int32(1), claimed[0].Attempts)
+	assert.JSONEq(t, string(payload), string(claimed[0].Payload))
+
+	welcome, cleanup := claimed[0], claimed[1]
+
+	n, err := q.CompleteJob(ctx, db.CompleteJobParams{ID: cleanup.ID, Attempts: 2, Now: now})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n, "stale attempt")
+	n, err = q.CompleteJob(ctx, db.CompleteJobParams{ID: cleanup.ID, Attempts: 1, Now: now})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	n, err = q.RetryJob(ctx, db.RetryJobParams{ID: welcome.ID, Attempts: 1, RunAt: now, LastError: "smtp down"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	claimed, err = q.ClaimJobs(ctx, db.ClaimJobsParams{Now: now, Worker: "w2", StaleBefore: now.Add(-time.Hour), BatchSize: 10})
+	require.NoError(t, err)
+	sortJobs(claimed)
+	require.Len(t, claimed, 2)
+	assert.Equal(t, welcome.ID, claimed[0].ID)
+	assert.Equal(t, int32(2), claimed[0].Attempts)
+
+	// the workers holding them are gone for longer than the lock timeout
+	claimed, err = q.ClaimJobs(ctx, db.ClaimJobsParams{Now: now.Add(time.Minute), Worker: "w3", StaleBefore: now.Add(time.Second), BatchSize: 10})
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+
+	// welcome was on its last attempt, it is not claimed again but killed
+	claimed, err = q.ClaimJobs(ctx, db.ClaimJobsParams{Now: now.Add(2 * time.Minute), Worker: "w4", StaleBefore: now.Add(2 * time.Minute), BatchSize: 10})
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.NotEqual(t, welcome.ID, claimed[0].ID)
+	other := claimed[0]
+	n, err = q.KillStaleJobs(ctx, db.KillStaleJobsParams{Now: now, LastError: "gave up", StaleBefore: now.Add(2 * time.Minute)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n, "the other one is locked by w4")
+
+	n, err = q.KillJob(ctx, db.KillJobParams{ID: other.ID, Attempts: 3, Now: now.Add(time.Minute), LastError: "no such user"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	dead, err := q.ListDeadJobs(ctx)
+	require.NoError(t, err)
+	require.Len(t, dead, 2)
+	assert.Equal(t, other.ID, dead[0].ID)
+	assert.Equal(t, welcome.ID, dead[1].ID)
+	assert.Equal(t, sql.NullString{String: "gave up", Valid: true}, dead[1].LastError)
+
+	n, err = q.ReviveJob(ctx, db.ReviveJobParams{ID: cleanup.ID, Now: now})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n, "not dead")
+	n, err = q.ReviveJob(ctx, db.ReviveJobParams{ID: welcome.ID, Now: now})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	dead, err = q.ListDeadJobs(ctx)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, other.ID, dead[0].ID)
+
+	n, err = q.DeleteFinishedJobs(ctx, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+}
+
+func testMail(t *testing.T, q db.Querier, _ db.Transactor) {
+	ctx := context.Background()
+
+	n, err := q.ClaimMailMessage(ctx, db.ClaimMailMessageParams{MessageID: "welcome/1", Now: now})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = q.ClaimMailMessage(ctx, db.ClaimMailMessageParams{MessageID: "welcome/1", Now: now})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	require.NoError(t, q.ReleaseMailMessage(ctx, "welcome/1"))
+	n, err = q.ClaimMailMessage(ctx, db.ClaimMailMessageParams{MessageID: "welcome/1", Now: now})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n, "released")
+
+	n, err = q.DeleteSentMailBefore(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+	n, err = q.DeleteSentMailBefore(ctx, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+}
+
+func testOutbox(t *testing.T, q db.Querier, _ db.Transactor) {
+	ctx := context.Background()
+	for _, event := range []struct{ id, typ string }{
+		{"1", "user.registered"}, {"2", "user.registered"}, {"1", "user.disabled"},
+	} {
+		err := q.InsertOutboxEvent(ctx, db.InsertOutboxEventParams{
+			AggregateType: "user", AggregateID: event.id, EventType: event.typ,
+			Payload: json.RawMessage(`{}`), OccurredAt: now,
+		})
+		require.NoError(t, err)
+	}
+
+	claim := func(now time.Time, batchSize int32) []db.ClaimOutboxEventsRow {
+		t.Helper()
+		rows, err := q.ClaimOutboxEvents(ctx, db.ClaimOutboxEventsParams{
+			LockedUntil: now.Add(time.Minute), Now: now, BatchSize: batchSize,
+		})
+		require.NoError(t, err)
+		sort.Slice(rows, func(i, j int) bool { return rows[i].ID < rows[j].ID })
+		return rows
+	}
+
+	pending := claim(now, 10)
+	require.Len(t, pending, 2, "only the oldest event of every aggregate")
+	assert.Equal(t, "1", pending[0].AggregateID)
+	assert.Equal(t, "user.registered", pending[0].EventType)
+	assert.Equal(t, "2", pending[1].AggregateID)
+	assert.Empty(t, claim(now, 10), "claimed until the lock timeout")
+
+	first, second := pending[0], pending[1]
+	require.NoError(t, q.MarkOutboxEventPublished(ctx, db.MarkOutboxEventPublishedParams{ID: first.ID, Now: now}))
+	require.NoError(t, q.MarkOutboxEventFailed(ctx, db.MarkOutboxEventFailedParams{ID: second.ID, NextAttemptAt: now, LastError: "broker down"}))
+
+	pending = claim(now, 10)
+	require.Len(t, pending, 2)
+	assert.Equal(t, second.ID, pending[0].ID)
+	assert.Equal(t, int32(1), pending[0].Attempts)
+	assert.Equal(t, "user.disabled", pending[1].EventType)
+
+	require.NoError(t, q.KillOutboxEvent(ctx, db.KillOutboxEventParams{ID: second.ID, Now: now, LastError: "payload too large"}))
+	require.NoError(t, q.MarkOutboxEventFailed(ctx, db.MarkOutboxEventFailedParams{ID: pending[1].ID, NextAttemptAt: now, LastError: "broker down"}))
+	require.NoError(t, q.InsertOutboxEvent(ctx, db.InsertOutboxEventParams{
+		AggregateType: "user", AggregateID: "2", EventType: "user.disabled",
+		Payload: json.RawMessage(`{}`), OccurredAt: now,
+	}))
+	pending = claim(now, 10)
+	require.Len(t, pending, 2, "the dead event does not hold back its aggregate")
+	assert.Equal(t, "1", pending[0].AggregateID)
+	assert.Equal(t, "2", pending[1].AggregateID)
+	assert.Equal(t, "user.disabled", pending[1].EventType)
+
+	require.NoError(t, q.MarkOutboxEventFailed(ctx, db.MarkOutboxEventFailedParams{ID: pending[0].ID, NextAttemptAt: now, LastError: "broker down"}))
+	require.Len(t, claim(now.Add(time.Minute), 1), 1, "up to the batch size")
+
+	n, err := q.DeletePublishedOutboxEvents(ctx, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+}
+
+func testRateLimits(t *testing.T, q db.Querier, _ db.Transactor) {
+	ctx := context.Background()
+
+	_, err := q.LockRateLimit(ctx, "ip:10.0.0.1")
+	assert.ErrorIs(t, err, db.ErrNoRows)
+
+	require.NoError(t, q.EnsureRateLimit(ctx, db.EnsureRateLimitParams{Key: "ip:10.0.0.1", Tat: now}))
+	require.NoError(t, q.EnsureRateLimit(ctx, db.EnsureRateLimitParams{Key: "ip:10.0.0.1", Tat: now.Add(time.Hour)}))
+
+	tat, err := q.LockRateLimit(ctx, "ip:10.0.0.1")
+	require.NoError(t, err)
+	assert.WithinDuration(t, now, tat, 0, "kept the first")
+
+	require.NoError(t, q.UpdateRateLimit(ctx, db.UpdateRateLimitParams{Key: "ip:10.0.0.1", Tat: now.Add(time.Second)}))
+	tat, err = q.LockRateLimit(ctx, "ip:10.0.0.1")
+	require.NoError(t, err)
+	assert.WithinDuration(t, now.Add(time.Second), tat, 0)
+
+	n, err := q.DeleteExpiredRateLimits(ctx, now.Add(2*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+}
+
+func testTransactions(t *testing.T, q db.Querier, tx db.Transactor) {
+	ctx := context.Background()
+	failed := errors.New("failed")
+
+	err := tx.InTx(ctx, func(q db.Querier) error {
+		createUser(t, q, "rolled@example.com")
+		return failed
+	})
+	assert.ErrorIs(t, err, failed)
+	_, err = q.GetUserByEmail(ctx, "rolled@example.com")
+	assert.ErrorIs(t, err, db.ErrNoRows, "rolled back")
+
+	err = tx.InTx(ctx, func(q db.Querier) error {
+		createUser(t, q, "committed@example.com")
+		return nil
+	})
+	require.NoError(t, err)
+	_, err = q.GetUserByEmail(ctx, "committed@example.com")
+	assert.NoError(t, err, "committed")
+}
+
+func createUser(t *testing.T, q db.Querier, email string) db.ScratchUser {
+	t.Helper()
+	user, err := q.CreateUser(context.Background(), db.CreateUserParams{
+		Name: "Norbi", Email: email, Password: "secret", Locale: sql.NullString{String: "pl", Valid: true},
+	})
+	require.NoError(t, err)
+	return user
+}
+
+// sortJobs orders claimed jobs by id, postgres returns them in any order.
+func sortJobs(jobs []db.ClaimJobsRow) {
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+}
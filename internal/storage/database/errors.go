@@ -0,0 +1,57 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrNoRows is returned by the queries of a single row that find none.
+var ErrNoRows = pgx.ErrNoRows
+
+// SQLSTATE codes of the violated constraints, which the stores of every
+// dialect report.
+const (
+	NotNullViolation    = "23502"
+	ForeignKeyViolation = "23503"
+	UniqueViolation     = "23505"
+)
+
+// SQLSTATE codes of the conflicts between concurrent transactions.
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// IsUniqueViolation reports whether err is caused by a violated unique
+// constraint or index.
+func IsUniqueViolation(err error) bool {
+	return SQLState(err) == UniqueViolation
+}
+
+// IsSerializationFailure reports whether err is caused by a conflict with a
+// concurrent transaction, after which the transaction can be retried.
+func IsSerializationFailure(err error) bool {
+	state := SQLState(err)
+	return state == serializationFailure || state == deadlockDetected
+}
+
+// SQLStateError is an error with a SQLSTATE code, like the errors of postgres
+// and those the other dialects translate theirs to.
+type SQLStateError interface {
+	error
+	SQLState() string
+}
+
+var _ SQLStateError = (*pgconn.PgError)(nil)
+
+// SQLState returns the SQLSTATE code of the first SQLStateError in the chain
+// of err, or an empty string.
+func SQLState(err error) string {
+	var stateErr SQLStateError
+	if errors.As(err, &stateErr) {
+		return stateErr.SQLState()
+	}
+	return ""
+}
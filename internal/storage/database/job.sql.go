@@ -0,0 +1,271 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.20.0
+// source: job.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+const claimJobs = `-- name: ClaimJobs :many
+UPDATE scratch.job
+SET state = 'running', attempts = attempts + 1, locked_at = $1::timestamptz, locked_by = $2::varchar
+WHERE id IN (
+    SELECT j.id FROM scratch.job j
+    WHERE (j.state = 'pending' AND j.run_at <= $1::timestamptz)
+       OR (j.state = 'running' AND j.locked_at < $3::timestamptz AND j.attempts < j.max_attempts)
+    ORDER BY j.run_at, j.id
+    LIMIT $4
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, kind, payload, attempts, max_attempts
+`
+
+type ClaimJobsParams struct {
+	Now         time.Time
+	Worker      string
+	StaleBefore time.Time
+	BatchSize   int32
+}
+
+type ClaimJobsRow struct {
+	ID          int64
+	Kind        string
+	Payload     json.RawMessage
+	Attempts    int32
+	MaxAttempts int32
+}
+
+// Claims the due jobs, and the running ones whose worker is gone for longer
+// than the lock timeout and which have attempts left, see KillStaleJobs.
+func (q *Queries) ClaimJobs(ctx context.Context, arg ClaimJobsParams) ([]ClaimJobsRow, error) {
+	rows, err := q.db.Query(ctx, claimJobs,
+		arg.Now,
+		arg.Worker,
+		arg.StaleBefore,
+		arg.BatchSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimJobsRow
+	for rows.Next() {
+		var i ClaimJobsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.Payload,
+			&i.Attempts,
+			&i.MaxAttempts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const completeJob = `-- name: CompleteJob :execrows
+UPDATE scratch.job
+SET state = 'done', finished_at = $1::timestamptz, locked_at = NULL, locked_by = NULL, last_error = NULL
+WHERE id = $2 AND state = 'running' AND attempts = $3
+`
+
+type CompleteJobParams struct {
+	Now      time.Time
+	ID       int64
+	Attempts int32
+}
+
+func (q *Queries) CompleteJob(ctx context.Context, arg CompleteJobParams) (int64, error) {
+	result, err := q.db.Exec(ctx, completeJob, arg.Now, arg.ID, arg.Attempts)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteFinishedJobs = `-- name: DeleteFinishedJobs :execrows
+DELETE FROM scratch.job WHERE state = 'done' AND finished_at < $1::timestamptz
+`
+
+func (q *Queries) DeleteFinishedJobs(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteFinishedJobs, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const enqueueJob = `-- name: EnqueueJob :execrows
+INSERT INTO scratch.job (kind, payload, unique_key, max_attempts, run_at, created_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (kind, unique_key) DO NOTHING
+`
+
+type EnqueueJobParams struct {
+	Kind        string
+	Payload     json.RawMessage
+	UniqueKey   sql.NullString
+	MaxAttempts int32
+	RunAt       time.Time
+	Now         time.Time
+}
+
+func (q *Queries) EnqueueJob(ctx context.Context, arg EnqueueJobParams) (int64, error) {
+	result, err := q.db.Exec(ctx, enqueueJob,
+		arg.Kind,
+		arg.Payload,
+		arg.UniqueKey,
+		arg.MaxAttempts,
+		arg.RunAt,
+		arg.Now,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const killJob = `-- name: KillJob :execrows
+UPDATE scratch.job
+SET state = 'dead', finished_at = $1::timestamptz, locked_at = NULL, locked_by = NULL, last_error = $2::text
+WHERE id = $3 AND state = 'running' AND attempts = $4
+`
+
+type KillJobParams struct {
+	Now       time.Time
+	LastError string
+	ID        int64
+	Attempts  int32
+}
+
+func (q *Queries) KillJob(ctx context.Context, arg KillJobParams) (int64, error) {
+	result, err := q.db.Exec(ctx, killJob,
+		arg.Now,
+		arg.LastError,
+		arg.ID,
+		arg.Attempts,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const killStaleJobs = `-- name: KillStaleJobs :execrows
+UPDATE scratch.job
+SET state = 'dead', finished_at = $1::timestamptz, locked_at = NULL, locked_by = NULL, last_error = $2::text
+WHERE state = 'running' AND locked_at < $3::timestamptz AND attempts >= max_attempts
+`
+
+type KillStaleJobsParams struct {
+	Now         time.Time
+	LastError   string
+	StaleBefore time.Time
+}
+
+// Kills the running jobs whose worker is gone on their last attempt.
+func (q *Queries) KillStaleJobs(ctx context.Context, arg KillStaleJobsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, killStaleJobs, arg.Now, arg.LastError, arg.StaleBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const listDeadJobs = `-- name: ListDeadJobs :many
+SELECT id, kind, payload, attempts, last_error, finished_at FROM scratch.job
+WHERE state = 'dead'
+ORDER BY finished_at DESC, id DESC
+`
+
+type ListDeadJobsRow struct {
+	ID         int64
+	Kind       string
+	Payload    json.RawMessage
+	Attempts   int32
+	LastError  sql.NullString
+	FinishedAt sql.NullTime
+}
+
+func (q *Queries) ListDeadJobs(ctx context.Context) ([]ListDeadJobsRow, error) {
+	rows, err := q.db.Query(ctx, listDeadJobs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDeadJobsRow
+	for rows.Next() {
+		var i ListDeadJobsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.Payload,
+			&i.Attempts,
+			&i.LastError,
+			&i.FinishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const retryJob = `-- name: RetryJob :execrows
+UPDATE scratch.job
+SET state = 'pending', run_at = $1, locked_at = NULL, locked_by = NULL, last_error = $2::text
+WHERE id = $3 AND state = 'running' AND attempts = $4
+`
+
+type RetryJobParams struct {
+	RunAt     time.Time
+	LastError string
+	ID        int64
+	Attempts  int32
+}
+
+func (q *Queries) RetryJob(ctx context.Context, arg RetryJobParams) (int64, error) {
+	result, err := q.db.Exec(ctx, retryJob,
+		arg.RunAt,
+		arg.LastError,
+		arg.ID,
+		arg.Attempts,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const reviveJob = `-- name: ReviveJob :execrows
+UPDATE scratch.job
+SET state = 'pending', attempts = 0, run_at = $1, finished_at = NULL
+WHERE id = $2 AND state = 'dead'
+`
+
+type ReviveJobParams struct {
+	Now time.Time
+	ID  int64
+}
+
+func (q *Queries) ReviveJob(ctx context.Context, arg ReviveJobParams) (int64, error) {
+	result, err := q.db.Exec(ctx, reviveJob, arg.Now, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,523 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+
+	db "scratch/internal/storage/database"
+)
+
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (db.ScratchUser, error) {
+	defer s.lock()()
+
+	if user, ok := s.tables.userByEmail(email); ok {
+		return user, nil
+	}
+	return db.ScratchUser{}, db.ErrNoRows
+}
+
+func (s *Store) GetUserByID(ctx context.Context, id int32) (db.ScratchUser, error) {
+	defer s.lock()()
+
+	if user, ok := s.tables.users[id]; ok && !user.DeletedAt.Valid {
+		return user, nil
+	}
+	return db.ScratchUser{}, db.ErrNoRows
+}
+
+func (s *Store) GetDeletedUserByEmail(ctx context.Context, email string) (db.ScratchUser, error) {
+	defer s.lock()()
+
+	// the latest deleted account of the email, which may be registered again
+	var latest db.ScratchUser
+	email = strings.ToLower(email)
+	for _, user := range s.tables.users {
+		if !user.DeletedAt.Valid || strings.ToLower(user.Email) != email {
+			continue
+		}
+		if latest.ID == 0 || user.DeletedAt.Time.After(latest.DeletedAt.Time) ||
+			user.DeletedAt.Time.Equal(latest.DeletedAt.Time) && user.ID > latest.ID {
+			latest = user
+		}
+	}
+	if latest.ID == 0 {
+		return db.ScratchUser{}, db.ErrNoRows
+	}
+	return latest, nil
+}
+
+func (s *Store) UpdateUserProfile(ctx context.Context, arg db.UpdateUserProfileParams) (int64, error) {
+	defer s.lock()()
+
+	user, ok := s.tables.users[arg.ID]
+	if !ok || user.DeletedAt.Valid {
+		return 0, nil
+	}
+	user.Name = arg.Name
+	user.Locale = arg.Locale
+	s.tables.users[arg.ID] = user
+	return 1, nil
+}
+
+func (s *Store) CreateUser(ctx context.Context, arg db.CreateUserParams) (db.ScratchUser, error) {
+	defer s.lock()()
+
+	s.tables.userID++
+	if _, ok := s.tables.userByEmail(arg.Email); ok {
+		return db.ScratchUser{}, emailViolation()
+	}
+	user := db.ScratchUser{
+		ID:       s.tables.userID,
+		Name:     arg.Name,
+		Email:    arg.Email,
+		Password: arg.Password,
+		Locale:   arg.Locale,
+	}
+	s.tables.users[user.ID] = user
+	return user, nil
+}
+
+func (s *Store) DisableUser(ctx context.Context, email string) (int64, error) {
+	defer s.lock()()
+
+	var n int64
+	for id, user := range s.tables.users {
+		if !strings.EqualFold(user.Email, email) || user.DeletedAt.Valid {
+			continue
+		}
+		if !user.DisabledAt.Valid {
+			user.DisabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+		}
+		s.tables.users[id] = user
+		n++
+	}
+	return n, nil
+}
+
+func (s *Store) UpdateUserPassword(ctx context.Context, arg db.UpdateUserPasswordParams) (int32, error) {
+	defer s.lock()()
+
+	for id, user := range s.tables.users {
+		if strings.EqualFold(user.Email, arg.Email) && !user.DeletedAt.Valid {
+			user.Password = arg.Password
+			s.tables.users[id] = user
+			return id, nil
+		}
+	}
+	return 0, db.ErrNoRows
+}
+
+func (s *Store) SetUserLocale(ctx context.Context, arg db.SetUserLocaleParams) (int64, error) {
+	defer s.lock()()
+
+	var n int64
+	for id, user := range s.tables.users {
+		if strings.EqualFold(user.Email, arg.Email) && !user.DeletedAt.Valid {
+			user.Locale = arg.Locale
+			s.tables.users[id] = user
+			n++
+		}
+	}
+	return n, nil
+}
+
+// CleanUserTable deletes the users with their sessions, roles, devices and
+// data exports.
+func (s *Store) CleanUserTable(ctx context.Context) error {
+	defer s.lock()()
+
+	s.tables.users = make(map[int32]db.ScratchUser)
+	s.tables.sessions = make(map[int32]db.ScratchSession)
+	s.tables.roles = make(map[roleKey]db.ScratchUserRole)
+	s.tables.devices = make(map[deviceKey]db.ScratchUserDevice)
+	s.tables.dataExports = make(map[int32]db.ScratchDataExport)
+	return nil
+}
+
+func (s *Store) DeleteUser(ctx context.Context, arg db.DeleteUserParams) (int64, error) {
+	defer s.lock()()
+
+	user, ok := s.tables.users[arg.ID]
+	if !ok || user.DeletedAt.Valid {
+		return 0, nil
+	}
+	user.DeletedAt = sql.NullTime{Time: arg.Now, Valid: true}
+	s.tables.users[arg.ID] = user
+	return 1, nil
+}
+
+func (s *Store) RestoreUser(ctx context.Context, arg db.RestoreUserParams) (int64, error) {
+	defer s.lock()()
+
+	user, ok := s.tables.users[arg.ID]
+	if !ok || !after(user.DeletedAt, arg.DeletedAfter) {
+		return 0, nil
+	}
+	if _, ok := s.tables.userByEmail(user.Email); ok {
+		return 0, emailViolation()
+	}
+	user.DeletedAt = sql.NullTime{}
+	s.tables.users[arg.ID] = user
+	return 1, nil
+}
+
+func (s *Store) GrantUserRole(ctx context.Context, arg db.GrantUserRoleParams) error {
+	defer s.lock()()
+
+	if err := s.tables.referenceUser("user_role", "fk_user_role_user", arg.UserID); err != nil {
+		return err
+	}
+	key := roleKey{userID: arg.UserID, role: arg.Role}
+	if _, ok := s.tables.roles[key]; !ok {
+		s.tables.roles[key] = db.ScratchUserRole{UserID: arg.UserID, Role: arg.Role, GrantedAt: time.Now()}
+	}
+	return nil
+}
+
+func (s *Store) ListUserRoles(ctx context.Context, userID int32) ([]string, error) {
+	defer s.lock()()
+
+	var roles []string
+	for key := range s.tables.roles {
+		if key.userID == userID {
+			roles = append(roles, key.role)
+		}
+	}
+	sort.Strings(roles)
+	return roles, nil
+}
+
+func (s *Store) CreateSession(ctx context.Context, arg db.CreateSessionParams) (int32, error) {
+	defer s.lock()()
+
+	return s.tables.insertSession(db.ScratchSession{
+		UserID:           arg.UserID,
+		RefreshTokenHash: sql.NullString{String: arg.RefreshTokenHash, Valid: true},
+		ExpiresAt:        sql.NullTime{Time: arg.ExpiresAt, Valid: true},
+		CreatedAt:        arg.Now,
+		LastSeenAt:       arg.Now,
+		UserAgent:        arg.UserAgent,
+		Ip:               arg.Ip,
+		DeviceName:       arg.DeviceName,
+	})
+}
+
+func (s *Store) CreateBrowserSession(ctx context.Context, arg db.CreateBrowserSessionParams) (int32, error) {
+	defer s.lock()()
+
+	return s.tables.insertSession(db.ScratchSession{
+		UserID:     arg.UserID,
+		TokenHash:  sql.NullString{String: arg.TokenHash, Valid: true},
+		CsrfToken:  sql.NullString{String: arg.CsrfToken, Valid: true},
+		ExpiresAt:  sql.NullTime{Time: arg.ExpiresAt, Valid: true},
+		CreatedAt:  arg.Now,
+		LastSeenAt: arg.Now,
+		UserAgent:  arg.UserAgent,
+		Ip:         arg.Ip,
+		DeviceName: arg.DeviceName,
+	})
+}
+
+func (s *Store) GetSessionByRefreshToken(ctx context.Context, arg db.GetSessionByRefreshTokenParams) (db.GetSessionByRefreshTokenRow, error) {
+	defer s.lock()()
+
+	session, ok := s.tables.activeSession(func(session db.ScratchSession) bool {
+		return session.RefreshTokenHash.Valid && session.RefreshTokenHash.String == arg.RefreshTokenHash
+	}, arg.Now)
+	if !ok {
+		return db.GetSessionByRefreshTokenRow{}, db.ErrNoRows
+	}
+	return db.GetSessionByRefreshTokenRow{ID: session.ID, UserID: session.UserID}, nil
+}
+
+func (s *Store) GetBrowserSession(ctx context.Context, arg db.GetBrowserSessionParams) (db.GetBrowserSessionRow, error) {
+	defer s.lock()()
+
+	session, ok := s.tables.activeSession(func(session db.ScratchSession) bool {
+		return session.TokenHash.Valid && session.TokenHash.String == arg.TokenHash
+	}, arg.Now)
+	if !ok {
+		return db.GetBrowserSessionRow{}, db.ErrNoRows
+	}
+	return db.GetBrowserSessionRow{
+		ID:         session.ID,
+		UserID:     session.UserID,
+		CsrfToken:  session.CsrfToken.String,
+		ExpiresAt:  session.ExpiresAt.Time,
+		LastSeenAt: session.LastSeenAt,
+	}, nil
+}
+
+func (s *Store) RefreshSession(ctx context.Context, arg db.RefreshSessionParams) (int64, error) {
+	defer s.lock()()
+
+	session, ok := s.tables.sessions[arg.ID]
+	if !ok || !session.RefreshTokenHash.Valid || session.RefreshTokenHash.String != arg.RefreshTokenHash {
+		return 0, nil
+	}
+	if other, ok := s.tables.sessionBy(func(other db.ScratchSession) bool {
+		return other.RefreshTokenHash.Valid && other.RefreshTokenHash.String == arg.NewRefreshTokenHash
+	}); ok && other.ID != session.ID {
+		return 0, constraintError(db.UniqueViolation, "session", "session_refresh_token_hash_key",
+			`duplicate key value violates unique constraint "session_refresh_token_hash_key"`)
+	}
+	session.RefreshTokenHash.String = arg.NewRefreshTokenHash
+	session.LastSeenAt = arg.Now
+	session.ExpiresAt = sql.NullTime{Time: arg.ExpiresAt, Valid: true}
+	if arg.UserAgent != "" {
+		session.UserAgent = arg.UserAgent
+	}
+	if arg.Ip != "" {
+		session.Ip = arg.Ip
+	}
+	s.tables.sessions[session.ID] = session
+	return 1, nil
+}
+
+func (s *Store) TouchSession(ctx context.Context, arg db.TouchSessionParams) error {
+	defer s.lock()()
+
+	session, ok := s.tables.sessions[arg.ID]
+	if !ok {
+		return nil
+	}
+	session.LastSeenAt = arg.Now
+	if arg.Ip != "" {
+		session.Ip = arg.Ip
+	}
+	s.tables.sessions[session.ID] = session
+	return nil
+}
+
+func (s *Store) ListSessions(ctx context.Context, arg db.ListSessionsParams) ([]db.ListSessionsRow, error) {
+	defer s.lock()()
+
+	var rows []db.ListSessionsRow
+	for _, session := range s.tables.sessions {
+		if session.UserID != arg.UserID || !after(session.ExpiresAt, arg.Now) {
+			continue
+		}
+		rows = append(rows, db.ListSessionsRow{
+			ID:         session.ID,
+			Browser:    session.TokenHash.Valid,
+			TokenHash:  session.TokenHash.String,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt.Time,
+			UserAgent:  session.UserAgent,
+			Ip:         session.Ip,
+			DeviceName: session.DeviceName,
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if !rows[i].LastSeenAt.Equal(rows[j].LastSeenAt) {
+			return rows[i].LastSeenAt.After(rows[j].LastSeenAt)
+		}
+		return rows[i].ID > rows[j].ID
+	})
+	return rows, nil
+}
+
+func (s *Store) DeleteBrowserSession(ctx context.Context, tokenHash string) (int64, error) {
+	defer s.lock()()
+
+	return s.tables.deleteSessions(func(session db.ScratchSession) bool {
+		return session.TokenHash.Valid && session.TokenHash.String == tokenHash
+	}), nil
+}
+
+func (s *Store) DeleteUserSession(ctx context.Context, arg db.DeleteUserSessionParams) (int64, error) {
+	defer s.lock()()
+
+	return s.tables.deleteSessions(func(session db.ScratchSession) bool {
+		return session.ID == arg.ID && session.UserID == arg.UserID
+	}), nil
+}
+
+func (s *Store) DeleteExpiredSessions(ctx context.Context, now time.Time) (int64, error) {
+	defer s.lock()()
+
+	return s.tables.deleteSessions(func(session db.ScratchSession) bool {
+		return before(session.ExpiresAt, now)
+	}), nil
+}
+
+func (s *Store) DeleteAllSessions(ctx context.Context) (int64, error) {
+	defer s.lock()()
+
+	return s.tables.deleteSessions(func(db.ScratchSession) bool { return true }), nil
+}
+
+func (s *Store) DeleteUserSessions(ctx context.Context, userID int32) (int64, error) {
+	defer s.lock()()
+
+	return s.tables.deleteSessions(func(session db.ScratchSession) bool {
+		return session.UserID == userID
+	}), nil
+}
+
+func (s *Store) DeleteOtherUserSessions(ctx context.Context, arg db.DeleteOtherUserSessionsParams) (int64, error) {
+	defer s.lock()()
+
+	return s.tables.deleteSessions(func(session db.ScratchSession) bool {
+		return session.UserID == arg.UserID && (!session.TokenHash.Valid || session.TokenHash.String != arg.KeepHash)
+	}), nil
+}
+
+func (s *Store) RememberDevice(ctx context.Context, arg db.RememberDeviceParams) (bool, error) {
+	defer s.lock()()
+
+	if err := s.tables.referenceUser("user_device", "user_device_user_id_fkey", arg.UserID); err != nil {
+		return false, err
+	}
+	key := deviceKey{userID: arg.UserID, fingerprint: arg.Fingerprint}
+	device, ok := s.tables.devices[key]
+	if ok {
+		device.LastSeenAt = arg.Now
+		s.tables.devices[key] = device
+		return false, nil
+	}
+	s.tables.devices[key] = db.ScratchUserDevice{
+		UserID:      arg.UserID,
+		Fingerprint: arg.Fingerprint,
+		Name:        arg.Name,
+		FirstSeenAt: arg.Now,
+		LastSeenAt:  arg.Now,
+	}
+	return true, nil
+}
+
+func (s *Store) CountUserDevices(ctx context.Context, userID int32) (int64, error) {
+	defer s.lock()()
+
+	var n int64
+	for key := range s.tables.devices {
+		if key.userID == userID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// userByEmail finds the user not deleted by the lower case email, as the
+// user_email_key index does.
+func (t *tables) userByEmail(email string) (db.ScratchUser, bool) {
+	email = strings.ToLower(email)
+	for _, user := range t.users {
+		if !user.DeletedAt.Valid && strings.ToLower(user.Email) == email {
+			return user, true
+		}
+	}
+	return db.ScratchUser{}, false
+}
+
+// emailViolation is the error of two users not deleted sharing an email.
+func emailViolation() error {
+	return constraintError(db.UniqueViolation, "user", "user_email_key",
+		`duplicate key value violates unique constraint "user_email_key"`)
+}
+
+// referenceUser checks the foreign key of a row of table to the user id.
+func (t *tables) referenceUser(table, constraint string, id int32) error {
+	if _, ok := t.users[id]; !ok {
+		return constraintError(db.ForeignKeyViolation, table, constraint,
+			`insert or update on table "`+table+`" violates foreign key constraint "`+constraint+`"`)
+	}
+	return nil
+}
+
+func (t *tables) insertSession(session db.ScratchSession) (int32, error) {
+	t.sessionID++
+	if err := t.referenceUser("session", "fk_session_user", session.UserID); err != nil {
+		return 0, err
+	}
+	if _, ok := t.sessionBy(func(other db.ScratchSession) bool {
+		return session.RefreshTokenHash.Valid && other.RefreshTokenHash == session.RefreshTokenHash
+	}); ok {
+		return 0, constraintError(db.UniqueViolation, "session", "session_refresh_token_hash_key",
+			`duplicate key value violates unique constraint "session_refresh_token_hash_key"`)
+	}
+	if _, ok := t.sessionBy(func(other db.ScratchSession) bool {
+		return session.TokenHash.Valid && other.TokenHash == session.TokenHash
+	}); ok {
+		return 0, constraintError(db.UniqueViolation, "session", "session_token_hash_key",
+			`duplicate key value violates unique constraint "session_token_hash_key"`)
+	}
+	session.ID = t.sessionID
+	t.sessions[session.ID] = session
+	return session.ID, nil
+}
+
+func (t *tables) sessionBy(match func(db.ScratchSession) bool) (db.ScratchSession, bool) {
+	for _, session := range t.sessions {
+		if match(session) {
+			return session, true
+		}
+	}
+	return db.ScratchSession{}, false
+}
+
+// activeSession finds a session that has not expired at now, of a user who is
+// neither disabled nor deleted.
+func (t *tables) activeSession(match func(db.ScratchSession) bool, now time.Time) (db.ScratchSession, bool) {
+	return t.sessionBy(func(session db.ScratchSession) bool {
+		user, ok := t.users[session.UserID]
+		return match(session) && after(session.ExpiresAt, now) && ok && !user.DisabledAt.Valid && !user.DeletedAt.Valid
+	})
+}
+
+// deleteUser deletes the user and the rows referencing it.
+func (t *tables) deleteUser(id int32) {
+	delete(t.users, id)
+	t.deleteSessions(func(session db.ScratchSession) bool { return session.UserID == id })
+	for key := range t.roles {
+		if key.userID == id {
+			delete(t.roles, key)
+		}
+	}
+	for key := range t.devices {
+		if key.userID == id {
+			delete(t.devices, key)
+		}
+	}
+	delete(t.dataExports, id)
+	for key := range t.members {
+		if key.userID == id {
+			delete(t.members, key)
+		}
+	}
+	// the invitations the user sent or accepted stay, without the user
+	for invitationID, invitation := range t.invitations {
+		if invitation.InvitedBy.Valid && invitation.InvitedBy.Int32 == id {
+			invitation.InvitedBy = sql.NullInt32{}
+		}
+		if invitation.AcceptedBy.Valid && invitation.AcceptedBy.Int32 == id {
+			invitation.AcceptedBy = sql.NullInt32{}
+		}
+		t.invitations[invitationID] = invitation
+	}
+}
+
+func (t *tables) deleteSessions(match func(db.ScratchSession) bool) int64 {
+	var n int64
+	for id, session := range t.sessions {
+		if match(session) {
+			delete(t.sessions, id)
+			n++
+		}
+	}
+	return n
+}
+
+// after reports whether a nullable time is after u, which NULL never is.
+func after(t sql.NullTime, u time.Time) bool {
+	return t.Valid && t.Time.After(u)
+}
+
+// before reports whether a nullable time is before u, which NULL never is.
+func before(t sql.NullTime, u time.Time) bool {
+	return t.Valid && t.Time.Before(u)
+}
@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	db "scratch/internal/storage/database"
+)
+
+func (s *Store) InsertIdempotencyKey(ctx context.Context, arg db.InsertIdempotencyKeyParams) (int64, error) {
+	defer s.lock()()
+
+	key := idempotencyKey{key: arg.Key, operation: arg.Operation}
+	if _, ok := s.tables.idempotencyKeys[key]; ok {
+		return 0, nil
+	}
+	s.tables.idempotencyKeys[key] = db.ScratchIdempotencyKey{
+		Key:         arg.Key,
+		Operation:   arg.Operation,
+		Fingerprint: arg.Fingerprint,
+		LockedAt:    arg.LockedAt,
+		ExpiresAt:   arg.ExpiresAt,
+	}
+	return 1, nil
+}
+
+func (s *Store) GetIdempotencyKey(ctx context.Context, arg db.GetIdempotencyKeyParams) (db.ScratchIdempotencyKey, error) {
+	defer s.lock()()
+
+	if row, ok := s.tables.idempotencyKeys[idempotencyKey{key: arg.Key, operation: arg.Operation}]; ok {
+		return row, nil
+	}
+	return db.ScratchIdempotencyKey{}, db.ErrNoRows
+}
+
+func (s *Store) TakeOverIdempotencyKey(ctx context.Context, arg db.TakeOverIdempotencyKeyParams) (int64, error) {
+	defer s.lock()()
+
+	key := idempotencyKey{key: arg.Key, operation: arg.Operation}
+	row, ok := s.tables.idempotencyKeys[key]
+	if !ok {
+		return 0, nil
+	}
+	expired := row.ExpiresAt.Before(arg.Now)
+	stale := !row.StatusCode.Valid && row.LockedAt.Before(arg.StaleBefore)
+	if !expired && !stale {
+		return 0, nil
+	}
+	row.Fingerprint = arg.Fingerprint
+	row.LockedAt = arg.Now
+	row.ExpiresAt = arg.ExpiresAt
+	row.StatusCode = sql.NullInt32{}
+	row.ResponseHeader = nil
+	row.ResponseBody = nil
+	s.tables.idempotencyKeys[key] = row
+	return 1, nil
+}
+
+func (s *Store) CompleteIdempotencyKey(ctx context.Context, arg db.CompleteIdempotencyKeyParams) (int64, error) {
+	defer s.lock()()
+
+	key := idempotencyKey{key: arg.Key, operation: arg.Operation}
+	row, ok := s.tables.idempotencyKeys[key]
+	if !ok || !lockedBy(row, arg.LockedAt) {
+		return 0, nil
+	}
+	row.StatusCode = arg.StatusCode
+	row.ResponseHeader = cloneBytes(arg.ResponseHeader)
+	row.ResponseBody = cloneBytes(arg.ResponseBody)
+	s.tables.idempotencyKeys[key] = row
+	return 1, nil
+}
+
+func (s *Store) DeleteIdempotencyKey(ctx context.Context, arg db.DeleteIdempotencyKeyParams) error {
+	defer s.lock()()
+
+	key := idempotencyKey{key: arg.Key, operation: arg.Operation}
+	if row, ok := s.tables.idempotencyKeys[key]; ok && lockedBy(row, arg.LockedAt) {
+		delete(s.tables.idempotencyKeys, key)
+	}
+	return nil
+}
+
+// lockedBy reports whether the request that locked the key at lockedAt still
+// holds it.
+func lockedBy(row db.ScratchIdempotencyKey, lockedAt time.Time) bool {
+	return !row.StatusCode.Valid && row.LockedAt.Equal(lockedAt)
+}
+
+func (s *Store) DeleteExpiredIdempotencyKeys(ctx context.Context, expiresAt time.Time) (int64, error) {
+	defer s.lock()()
+
+	var n int64
+	for key, row := range s.tables.idempotencyKeys {
+		if row.ExpiresAt.Before(expiresAt) {
+			delete(s.tables.idempotencyKeys, key)
+			n++
+		}
+	}
+	return n, nil
+}
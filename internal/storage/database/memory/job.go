@@ -0,0 +1,205 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	db "scratch/internal/storage/database"
+)
+
+// Job states, as in scratch.job.state.
+const (
+	jobPending = "pending"
+	jobRunning = "running"
+	jobDone    = "done"
+	jobDead    = "dead"
+)
+
+func (s *Store) EnqueueJob(ctx context.Context, arg db.EnqueueJobParams) (int64, error) {
+	defer s.lock()()
+
+	if arg.UniqueKey.Valid {
+		for _, job := range s.tables.jobs {
+			if job.Kind == arg.Kind && job.UniqueKey == arg.UniqueKey {
+				return 0, nil
+			}
+		}
+	}
+	s.tables.jobID++
+	s.tables.jobs[s.tables.jobID] = db.ScratchJob{
+		ID:          s.tables.jobID,
+		Kind:        arg.Kind,
+		Payload:     cloneJSON(arg.Payload),
+		UniqueKey:   arg.UniqueKey,
+		State:       jobPending,
+		MaxAttempts: arg.MaxAttempts,
+		RunAt:       arg.RunAt,
+		CreatedAt:   arg.Now,
+	}
+	return 1, nil
+}
+
+// ClaimJobs claims the due jobs, and the running ones whose worker is gone for
+// longer than the lock timeout, in the order they were due.
+func (s *Store) ClaimJobs(ctx context.Context, arg db.ClaimJobsParams) ([]db.ClaimJobsRow, error) {
+	defer s.lock()()
+
+	var due []db.ScratchJob
+	for _, job := range s.tables.jobs {
+		pending := job.State == jobPending && !job.RunAt.After(arg.Now)
+		stale := job.State == jobRunning && before(job.LockedAt, arg.StaleBefore) && job.Attempts < job.MaxAttempts
+		if pending || stale {
+			due = append(due, job)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].RunAt.Equal(due[j].RunAt) {
+			return due[i].RunAt.Before(due[j].RunAt)
+		}
+		return due[i].ID < due[j].ID
+	})
+	if len(due) > int(arg.BatchSize) {
+		due = due[:arg.BatchSize]
+	}
+
+	var rows []db.ClaimJobsRow
+	for _, job := range due {
+		job.State = jobRunning
+		job.Attempts++
+		job.LockedAt = sql.NullTime{Time: arg.Now, Valid: true}
+		job.LockedBy = sql.NullString{String: arg.Worker, Valid: true}
+		s.tables.jobs[job.ID] = job
+		rows = append(rows, db.ClaimJobsRow{
+			ID:          job.ID,
+			Kind:        job.Kind,
+			Payload:     job.Payload,
+			Attempts:    job.Attempts,
+			MaxAttempts: job.MaxAttempts,
+		})
+	}
+	return rows, nil
+}
+
+func (s *Store) CompleteJob(ctx context.Context, arg db.CompleteJobParams) (int64, error) {
+	defer s.lock()()
+
+	return s.tables.finishJob(arg.ID, arg.Attempts, func(job *db.ScratchJob) {
+		job.State = jobDone
+		job.FinishedAt = sql.NullTime{Time: arg.Now, Valid: true}
+		job.LastError = sql.NullString{}
+	}), nil
+}
+
+func (s *Store) RetryJob(ctx context.Context, arg db.RetryJobParams) (int64, error) {
+	defer s.lock()()
+
+	return s.tables.finishJob(arg.ID, arg.Attempts, func(job *db.ScratchJob) {
+		job.State = jobPending
+		job.RunAt = arg.RunAt
+		job.LastError = sql.NullString{String: arg.LastError, Valid: true}
+	}), nil
+}
+
+func (s *Store) KillJob(ctx context.Context, arg db.KillJobParams) (int64, error) {
+	defer s.lock()()
+
+	return s.tables.finishJob(arg.ID, arg.Attempts, func(job *db.ScratchJob) {
+		job.State = jobDead
+		job.FinishedAt = sql.NullTime{Time: arg.Now, Valid: true}
+		job.LastError = sql.NullString{String: arg.LastError, Valid: true}
+	}), nil
+}
+
+func (s *Store) KillStaleJobs(ctx context.Context, arg db.KillStaleJobsParams) (int64, error) {
+	defer s.lock()()
+
+	var n int64
+	for id, job := range s.tables.jobs {
+		if job.State != jobRunning || !before(job.LockedAt, arg.StaleBefore) || job.Attempts < job.MaxAttempts {
+			continue
+		}
+		job.State = jobDead
+		job.FinishedAt = sql.NullTime{Time: arg.Now, Valid: true}
+		job.LockedAt = sql.NullTime{}
+		job.LockedBy = sql.NullString{}
+		job.LastError = sql.NullString{String: arg.LastError, Valid: true}
+		s.tables.jobs[id] = job
+		n++
+	}
+	return n, nil
+}
+
+func (s *Store) ListDeadJobs(ctx context.Context) ([]db.ListDeadJobsRow, error) {
+	defer s.lock()()
+
+	var rows []db.ListDeadJobsRow
+	for _, job := range s.tables.jobs {
+		if job.State != jobDead {
+			continue
+		}
+		rows = append(rows, db.ListDeadJobsRow{
+			ID:         job.ID,
+			Kind:       job.Kind,
+			Payload:    job.Payload,
+			Attempts:   job.Attempts,
+			LastError:  job.LastError,
+			FinishedAt: job.FinishedAt,
+		})
+	}
+	// NULLs come first in a descending order in postgres
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i].FinishedAt, rows[j].FinishedAt
+		if a.Valid != b.Valid {
+			return !a.Valid
+		}
+		if !a.Time.Equal(b.Time) {
+			return a.Time.After(b.Time)
+		}
+		return rows[i].ID > rows[j].ID
+	})
+	return rows, nil
+}
+
+func (s *Store) ReviveJob(ctx context.Context, arg db.ReviveJobParams) (int64, error) {
+	defer s.lock()()
+
+	job, ok := s.tables.jobs[arg.ID]
+	if !ok || job.State != jobDead {
+		return 0, nil
+	}
+	job.State = jobPending
+	job.Attempts = 0
+	job.RunAt = arg.Now
+	job.FinishedAt = sql.NullTime{}
+	s.tables.jobs[job.ID] = job
+	return 1, nil
+}
+
+func (s *Store) DeleteFinishedJobs(ctx context.Context, before time.Time) (int64, error) {
+	defer s.lock()()
+
+	var n int64
+	for id, job := range s.tables.jobs {
+		if job.State == jobDone && job.FinishedAt.Valid && job.FinishedAt.Time.Before(before) {
+			delete(s.tables.jobs, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// finishJob applies update to a running job, unless it was claimed again
+// since the given attempt, and unlocks it.
+func (t *tables) finishJob(id int64, attempts int32, update func(job *db.ScratchJob)) int64 {
+	job, ok := t.jobs[id]
+	if !ok || job.State != jobRunning || job.Attempts != attempts {
+		return 0
+	}
+	update(&job)
+	job.LockedAt = sql.NullTime{}
+	job.LockedBy = sql.NullString{}
+	t.jobs[id] = job
+	return 1
+}
@@ -0,0 +1,38 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	db "scratch/internal/storage/database"
+)
+
+func (s *Store) ClaimMailMessage(ctx context.Context, arg db.ClaimMailMessageParams) (int64, error) {
+	defer s.lock()()
+
+	if _, ok := s.tables.mailSent[arg.MessageID]; ok {
+		return 0, nil
+	}
+	s.tables.mailSent[arg.MessageID] = arg.Now
+	return 1, nil
+}
+
+func (s *Store) ReleaseMailMessage(ctx context.Context, messageID string) error {
+	defer s.lock()()
+
+	delete(s.tables.mailSent, messageID)
+	return nil
+}
+
+func (s *Store) DeleteSentMailBefore(ctx context.Context, before time.Time) (int64, error) {
+	defer s.lock()()
+
+	var n int64
+	for id, sentAt := range s.tables.mailSent {
+		if sentAt.Before(before) {
+			delete(s.tables.mailSent, id)
+			n++
+		}
+	}
+	return n, nil
+}
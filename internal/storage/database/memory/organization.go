@@ -0,0 +1,356 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+
+	db "scratch/internal/storage/database"
+)
+
+// The store has a single tenant: the row level security of postgres is not
+// emulated, and every organization is visible to every query.
+
+func (s *Store) CreateOrganization(ctx context.Context, arg db.CreateOrganizationParams) (db.ScratchOrganization, error) {
+	defer s.lock()()
+
+	s.tables.organizationID++
+	for _, other := range s.tables.organizations {
+		if other.Slug == arg.Slug {
+			return db.ScratchOrganization{}, constraintError(db.UniqueViolation, "organization", "organization_slug_key",
+				`duplicate key value violates unique constraint "organization_slug_key"`)
+		}
+	}
+	organization := db.ScratchOrganization{
+		ID:        s.tables.organizationID,
+		Slug:      arg.Slug,
+		Name:      arg.Name,
+		CreatedAt: arg.Now,
+	}
+	s.tables.organizations[organization.ID] = organization
+	return organization, nil
+}
+
+func (s *Store) GetOrganization(ctx context.Context, id int32) (db.ScratchOrganization, error) {
+	defer s.lock()()
+
+	organization, ok := s.tables.organizations[id]
+	if !ok {
+		return db.ScratchOrganization{}, db.ErrNoRows
+	}
+	return organization, nil
+}
+
+func (s *Store) ListUserOrganizations(ctx context.Context, userID int32) ([]db.ListUserOrganizationsRow, error) {
+	defer s.lock()()
+
+	var organizations []db.ListUserOrganizationsRow
+	for key, member := range s.tables.members {
+		if key.userID != userID {
+			continue
+		}
+		organization := s.tables.organizations[key.organizationID]
+		organizations = append(organizations, db.ListUserOrganizationsRow{
+			ID:        organization.ID,
+			Slug:      organization.Slug,
+			Name:      organization.Name,
+			CreatedAt: organization.CreatedAt,
+			Role:      member.Role,
+			JoinedAt:  member.JoinedAt,
+		})
+	}
+	sort.Slice(organizations, func(i, j int) bool {
+		if organizations[i].Name != organizations[j].Name {
+			return organizations[i].Name < organizations[j].Name
+		}
+		return organizations[i].ID < organizations[j].ID
+	})
+	return organizations, nil
+}
+
+// AddOrganizationMember adds the user to the organization, unless it is a
+// member already.
+func (s *Store) AddOrganizationMember(ctx context.Context, arg db.AddOrganizationMemberParams) (int64, error) {
+	defer s.lock()()
+
+	if err := s.tables.referenceOrganization("organization_member", "organization_member_organization_id_fkey", arg.OrganizationID); err != nil {
+		return 0, err
+	}
+	if err := s.tables.referenceUser("organization_member", "organization_member_user_id_fkey", arg.UserID); err != nil {
+		return 0, err
+	}
+	key := memberKey{organizationID: arg.OrganizationID, userID: arg.UserID}
+	if _, ok := s.tables.members[key]; ok {
+		return 0, nil
+	}
+	s.tables.members[key] = db.ScratchOrganizationMember{
+		OrganizationID: arg.OrganizationID,
+		UserID:         arg.UserID,
+		Role:           arg.Role,
+		JoinedAt:       arg.Now,
+	}
+	return 1, nil
+}
+
+func (s *Store) GetOrganizationMember(ctx context.Context, arg db.GetOrganizationMemberParams) (db.ScratchOrganizationMember, error) {
+	defer s.lock()()
+
+	member, ok := s.tables.members[memberKey{organizationID: arg.OrganizationID, userID: arg.UserID}]
+	if !ok {
+		return db.ScratchOrganizationMember{}, db.ErrNoRows
+	}
+	return member, nil
+}
+
+func (s *Store) ListOrganizationMembers(ctx context.Context, organizationID int32) ([]db.ListOrganizationMembersRow, error) {
+	defer s.lock()()
+
+	var members []db.ListOrganizationMembersRow
+	for key, member := range s.tables.members {
+		user := s.tables.users[key.userID]
+		if key.organizationID != organizationID || user.DeletedAt.Valid {
+			continue
+		}
+		members = append(members, db.ListOrganizationMembersRow{
+			UserID:   member.UserID,
+			Name:     user.Name,
+			Email:    user.Email,
+			Role:     member.Role,
+			JoinedAt: member.JoinedAt,
+		})
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if !members[i].JoinedAt.Equal(members[j].JoinedAt) {
+			return members[i].JoinedAt.Before(members[j].JoinedAt)
+		}
+		return members[i].UserID < members[j].UserID
+	})
+	return members, nil
+}
+
+func (s *Store) UpdateOrganizationMemberRole(ctx context.Context, arg db.UpdateOrganizationMemberRoleParams) (int64, error) {
+	defer s.lock()()
+
+	key := memberKey{organizationID: arg.OrganizationID, userID: arg.UserID}
+	member, ok := s.tables.members[key]
+	if !ok {
+		return 0, nil
+	}
+	member.Role = arg.Role
+	s.tables.members[key] = member
+	return 1, nil
+}
+
+func (s *Store) RemoveOrganizationMember(ctx context.Context, arg db.RemoveOrganizationMemberParams) (int64, error) {
+	defer s.lock()()
+
+	key := memberKey{organizationID: arg.OrganizationID, userID: arg.UserID}
+	if _, ok := s.tables.members[key]; !ok {
+		return 0, nil
+	}
+	delete(s.tables.members, key)
+	return 1, nil
+}
+
+func (s *Store) CountOrganizationOwners(ctx context.Context, organizationID int32) (int64, error) {
+	defer s.lock()()
+
+	var n int64
+	for key, member := range s.tables.members {
+		if key.organizationID == organizationID && member.Role == "owner" {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *Store) CreateInvitation(ctx context.Context, arg db.CreateInvitationParams) (db.ScratchInvitation, error) {
+	defer s.lock()()
+
+	s.tables.invitationID++
+	if err := s.tables.referenceOrganization("invitation", "invitation_organization_id_fkey", arg.OrganizationID); err != nil {
+		return db.ScratchInvitation{}, err
+	}
+	if arg.InvitedBy.Valid {
+		if err := s.tables.referenceUser("invitation", "invitation_invited_by_fkey", arg.InvitedBy.Int32); err != nil {
+			return db.ScratchInvitation{}, err
+		}
+	}
+	for _, other := range s.tables.invitations {
+		if other.CodeHash == arg.CodeHash {
+			return db.ScratchInvitation{}, constraintError(db.UniqueViolation, "invitation", "invitation_code_hash_key",
+				`duplicate key value violates unique constraint "invitation_code_hash_key"`)
+		}
+	}
+	invitation := db.ScratchInvitation{
+		ID:             s.tables.invitationID,
+		OrganizationID: arg.OrganizationID,
+		Email:          arg.Email,
+		Role:           arg.Role,
+		CodeHash:       arg.CodeHash,
+		InvitedBy:      arg.InvitedBy,
+		CreatedAt:      arg.Now,
+		ExpiresAt:      arg.ExpiresAt,
+	}
+	s.tables.invitations[invitation.ID] = invitation
+	return invitation, nil
+}
+
+func (s *Store) GetInvitationByCode(ctx context.Context, codeHash string) (db.ScratchInvitation, error) {
+	defer s.lock()()
+
+	for _, invitation := range s.tables.invitations {
+		if invitation.CodeHash == codeHash {
+			return invitation, nil
+		}
+	}
+	return db.ScratchInvitation{}, db.ErrNoRows
+}
+
+func (s *Store) GetInvitation(ctx context.Context, arg db.GetInvitationParams) (db.ScratchInvitation, error) {
+	defer s.lock()()
+
+	invitation, ok := s.tables.invitations[arg.ID]
+	if !ok || invitation.OrganizationID != arg.OrganizationID {
+		return db.ScratchInvitation{}, db.ErrNoRows
+	}
+	return invitation, nil
+}
+
+func (s *Store) ListOrganizationInvitations(ctx context.Context, organizationID int32) ([]db.ListOrganizationInvitationsRow, error) {
+	defer s.lock()()
+
+	var invitations []db.ListOrganizationInvitationsRow
+	for _, invitation := range s.tables.invitations {
+		if invitation.OrganizationID != organizationID {
+			continue
+		}
+		invitations = append(invitations, db.ListOrganizationInvitationsRow{
+			ID:           invitation.ID,
+			Email:        invitation.Email,
+			Role:         invitation.Role,
+			InvitedBy:    invitation.InvitedBy,
+			InviterName:  s.tables.userName(invitation.InvitedBy),
+			AcceptedBy:   invitation.AcceptedBy,
+			AccepterName: s.tables.userName(invitation.AcceptedBy),
+			CreatedAt:    invitation.CreatedAt,
+			ExpiresAt:    invitation.ExpiresAt,
+			AcceptedAt:   invitation.AcceptedAt,
+			RevokedAt:    invitation.RevokedAt,
+		})
+	}
+	sort.Slice(invitations, func(i, j int) bool {
+		if !invitations[i].CreatedAt.Equal(invitations[j].CreatedAt) {
+			return invitations[i].CreatedAt.After(invitations[j].CreatedAt)
+		}
+		return invitations[i].ID > invitations[j].ID
+	})
+	return invitations, nil
+}
+
+// AcceptInvitation accepts the invitation once, before it expires or is
+// revoked.
+func (s *Store) AcceptInvitation(ctx context.Context, arg db.AcceptInvitationParams) (int64, error) {
+	defer s.lock()()
+
+	invitation, ok := s.tables.invitations[arg.ID]
+	if !ok || invitation.AcceptedAt.Valid || invitation.RevokedAt.Valid || !invitation.ExpiresAt.After(arg.Now) {
+		return 0, nil
+	}
+	if arg.AcceptedBy.Valid {
+		if err := s.tables.referenceUser("invitation", "invitation_accepted_by_fkey", arg.AcceptedBy.Int32); err != nil {
+			return 0, err
+		}
+	}
+	invitation.AcceptedAt = sql.NullTime{Time: arg.Now, Valid: true}
+	invitation.AcceptedBy = arg.AcceptedBy
+	s.tables.invitations[arg.ID] = invitation
+	return 1, nil
+}
+
+func (s *Store) RevokeInvitation(ctx context.Context, arg db.RevokeInvitationParams) (int64, error) {
+	defer s.lock()()
+
+	invitation, ok := s.tables.invitations[arg.ID]
+	if !ok || invitation.OrganizationID != arg.OrganizationID || invitation.AcceptedAt.Valid || invitation.RevokedAt.Valid {
+		return 0, nil
+	}
+	invitation.RevokedAt = sql.NullTime{Time: arg.Now, Valid: true}
+	s.tables.invitations[arg.ID] = invitation
+	return 1, nil
+}
+
+func (s *Store) ListUserInvitations(ctx context.Context, userID int32) ([]db.ListUserInvitationsRow, error) {
+	defer s.lock()()
+
+	user, ok := s.tables.users[userID]
+	if !ok {
+		return nil, nil
+	}
+	var invitations []db.ListUserInvitationsRow
+	for _, invitation := range s.tables.invitations {
+		if !strings.EqualFold(invitation.Email, user.Email) {
+			continue
+		}
+		invitations = append(invitations, db.ListUserInvitationsRow{
+			ID:           invitation.ID,
+			Organization: s.tables.organizations[invitation.OrganizationID].Name,
+			Role:         invitation.Role,
+			CreatedAt:    invitation.CreatedAt,
+			ExpiresAt:    invitation.ExpiresAt,
+			AcceptedAt:   invitation.AcceptedAt,
+			RevokedAt:    invitation.RevokedAt,
+		})
+	}
+	sort.Slice(invitations, func(i, j int) bool { return invitations[i].ID < invitations[j].ID })
+	return invitations, nil
+}
+
+func (s *Store) DeleteUserMemberships(ctx context.Context, userID int32) (int64, error) {
+	defer s.lock()()
+
+	var n int64
+	for key := range s.tables.members {
+		if key.userID == userID {
+			delete(s.tables.members, key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *Store) DeleteUserInvitations(ctx context.Context, userID int32) (int64, error) {
+	defer s.lock()()
+
+	user, ok := s.tables.users[userID]
+	if !ok {
+		return 0, nil
+	}
+	var n int64
+	for id, invitation := range s.tables.invitations {
+		if strings.EqualFold(invitation.Email, user.Email) {
+			delete(s.tables.invitations, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// userName is the name of the user with the id, or null like the outer join of
+// a user that is gone.
+func (t *tables) userName(id sql.NullInt32) sql.NullString {
+	user, ok := t.users[id.Int32]
+	if !id.Valid || !ok {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: user.Name, Valid: true}
+}
+
+func (t *tables) referenceOrganization(table, constraint string, id int32) error {
+	if _, ok := t.organizations[id]; !ok {
+		return constraintError(db.ForeignKeyViolation, table, constraint,
+			`insert or update on table "`+table+`" violates foreign key constraint "`+constraint+`"`)
+	}
+	return nil
+}
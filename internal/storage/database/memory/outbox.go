@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	db "scratch/internal/storage/database"
+)
+
+func (s *Store) InsertOutboxEvent(ctx context.Context, arg db.InsertOutboxEventParams) error {
+	defer s.lock()()
+
+	s.tables.outboxID++
+	s.tables.outbox[s.tables.outboxID] = db.ScratchOutbox{
+		ID:            s.tables.outboxID,
+		AggregateType: arg.AggregateType,
+		AggregateID:   arg.AggregateID,
+		EventType:     arg.EventType,
+		Payload:       cloneJSON(arg.Payload),
+		OccurredAt:    arg.OccurredAt,
+		NextAttemptAt: arg.OccurredAt,
+	}
+	return nil
+}
+
+// ClaimOutboxEvents claims only the oldest pending event of every aggregate,
+// so the events of an aggregate are published in order.
+func (s *Store) ClaimOutboxEvents(ctx context.Context, arg db.ClaimOutboxEventsParams) ([]db.ClaimOutboxEventsRow, error) {
+	defer s.lock()()
+
+	type aggregate struct{ typ, id string }
+	oldest := make(map[aggregate]db.ScratchOutbox)
+	for _, event := range s.tables.outbox {
+		if event.PublishedAt.Valid || event.DeadAt.Valid {
+			continue
+		}
+		key := aggregate{typ: event.AggregateType, id: event.AggregateID}
+		if o, ok := oldest[key]; !ok || event.ID < o.ID {
+			oldest[key] = event
+		}
+	}
+
+	var rows []db.ClaimOutboxEventsRow
+	for _, event := range oldest {
+		if event.NextAttemptAt.After(arg.Now) {
+			continue
+		}
+		rows = append(rows, db.ClaimOutboxEventsRow{
+			ID:            event.ID,
+			AggregateType: event.AggregateType,
+			AggregateID:   event.AggregateID,
+			EventType:     event.EventType,
+			Payload:       event.Payload,
+			OccurredAt:    event.OccurredAt,
+			Attempts:      event.Attempts,
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].ID < rows[j].ID })
+	if len(rows) > int(arg.BatchSize) {
+		rows = rows[:arg.BatchSize]
+	}
+	for _, row := range rows {
+		event := s.tables.outbox[row.ID]
+		event.NextAttemptAt = arg.LockedUntil
+		s.tables.outbox[row.ID] = event
+	}
+	return rows, nil
+}
+
+func (s *Store) MarkOutboxEventPublished(ctx context.Context, arg db.MarkOutboxEventPublishedParams) error {
+	defer s.lock()()
+
+	event, ok := s.tables.outbox[arg.ID]
+	if !ok {
+		return nil
+	}
+	event.PublishedAt = sql.NullTime{Time: arg.Now, Valid: true}
+	event.Attempts++
+	event.LastError = sql.NullString{}
+	s.tables.outbox[event.ID] = event
+	return nil
+}
+
+func (s *Store) MarkOutboxEventFailed(ctx context.Context, arg db.MarkOutboxEventFailedParams) error {
+	defer s.lock()()
+
+	event, ok := s.tables.outbox[arg.ID]
+	if !ok || event.PublishedAt.Valid {
+		return nil
+	}
+	event.Attempts++
+	event.NextAttemptAt = arg.NextAttemptAt
+	event.LastError = sql.NullString{String: arg.LastError, Valid: true}
+	s.tables.outbox[event.ID] = event
+	return nil
+}
+
+func (s *Store) KillOutboxEvent(ctx context.Context, arg db.KillOutboxEventParams) error {
+	defer s.lock()()
+
+	event, ok := s.tables.outbox[arg.ID]
+	if !ok || event.PublishedAt.Valid {
+		return nil
+	}
+	event.Attempts++
+	event.DeadAt = sql.NullTime{Time: arg.Now, Valid: true}
+	event.LastError = sql.NullString{String: arg.LastError, Valid: true}
+	s.tables.outbox[event.ID] = event
+	return nil
+}
+
+func (s *Store) DeletePublishedOutboxEvents(ctx context.Context, before time.Time) (int64, error) {
+	defer s.lock()()
+
+	var n int64
+	for id, event := range s.tables.outbox {
+		if event.PublishedAt.Valid && event.PublishedAt.Time.Before(before) {
+			delete(s.tables.outbox, id)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,217 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	db "scratch/internal/storage/database"
+)
+
+func (s *Store) ListUserSessions(ctx context.Context, userID int32) ([]db.ListUserSessionsRow, error) {
+	defer s.lock()()
+
+	var sessions []db.ListUserSessionsRow
+	for _, session := range s.tables.sessions {
+		if session.UserID != userID {
+			continue
+		}
+		sessions = append(sessions, db.ListUserSessionsRow{
+			ID:         session.ID,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			UserAgent:  session.UserAgent,
+			Ip:         session.Ip,
+			DeviceName: session.DeviceName,
+		})
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ID < sessions[j].ID })
+	return sessions, nil
+}
+
+func (s *Store) ListUserRoleGrants(ctx context.Context, userID int32) ([]db.ScratchUserRole, error) {
+	defer s.lock()()
+
+	var roles []db.ScratchUserRole
+	for key, role := range s.tables.roles {
+		if key.userID == userID {
+			roles = append(roles, role)
+		}
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Role < roles[j].Role })
+	return roles, nil
+}
+
+func (s *Store) ListUserDevices(ctx context.Context, userID int32) ([]db.ScratchUserDevice, error) {
+	defer s.lock()()
+
+	var devices []db.ScratchUserDevice
+	for key, device := range s.tables.devices {
+		if key.userID == userID {
+			devices = append(devices, device)
+		}
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		if !devices[i].FirstSeenAt.Equal(devices[j].FirstSeenAt) {
+			return devices[i].FirstSeenAt.Before(devices[j].FirstSeenAt)
+		}
+		return devices[i].Fingerprint < devices[j].Fingerprint
+	})
+	return devices, nil
+}
+
+func (s *Store) ListUserEvents(ctx context.Context, aggregateID string) ([]db.ListUserEventsRow, error) {
+	defer s.lock()()
+
+	var events []db.ListUserEventsRow
+	for _, event := range s.tables.outbox {
+		if event.AggregateType != "user" || event.AggregateID != aggregateID {
+			continue
+		}
+		events = append(events, db.ListUserEventsRow{
+			ID:         event.ID,
+			EventType:  event.EventType,
+			Payload:    cloneJSON(event.Payload),
+			OccurredAt: event.OccurredAt,
+		})
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return events, nil
+}
+
+func (s *Store) ListUsersDeletedBefore(ctx context.Context, deletedBefore time.Time) ([]int32, error) {
+	defer s.lock()()
+
+	var ids []int32
+	for id, user := range s.tables.users {
+		if before(user.DeletedAt, deletedBefore) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// EraseUser deletes the user with the rows referencing it, as the foreign
+// keys cascade.
+func (s *Store) EraseUser(ctx context.Context, id int32) (int64, error) {
+	defer s.lock()()
+
+	if _, ok := s.tables.users[id]; !ok {
+		return 0, nil
+	}
+	s.tables.deleteUser(id)
+	return 1, nil
+}
+
+func (s *Store) DeleteUserRoles(ctx context.Context, userID int32) (int64, error) {
+	defer s.lock()()
+
+	var n int64
+	for key := range s.tables.roles {
+		if key.userID == userID {
+			delete(s.tables.roles, key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *Store) DeleteUserDevices(ctx context.Context, userID int32) (int64, error) {
+	defer s.lock()()
+
+	var n int64
+	for key := range s.tables.devices {
+		if key.userID == userID {
+			delete(s.tables.devices, key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *Store) DeleteUserEvents(ctx context.Context, aggregateID string) (int64, error) {
+	defer s.lock()()
+
+	var n int64
+	for id, event := range s.tables.outbox {
+		if event.AggregateType == "user" && event.AggregateID == aggregateID {
+			delete(s.tables.outbox, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// RequestDataExport starts a new export of the user, unless one is being
+// built.
+func (s *Store) RequestDataExport(ctx context.Context, arg db.RequestDataExportParams) (int64, error) {
+	defer s.lock()()
+
+	if err := s.tables.referenceUser("data_export", "data_export_user_id_fkey", arg.UserID); err != nil {
+		return 0, err
+	}
+	if export, ok := s.tables.dataExports[arg.UserID]; ok && !export.ReadyAt.Valid {
+		return 0, nil
+	}
+	s.tables.dataExports[arg.UserID] = db.ScratchDataExport{UserID: arg.UserID, RequestedAt: arg.Now}
+	return 1, nil
+}
+
+func (s *Store) GetDataExport(ctx context.Context, userID int32) (db.GetDataExportRow, error) {
+	defer s.lock()()
+
+	export, ok := s.tables.dataExports[userID]
+	if !ok {
+		return db.GetDataExportRow{}, db.ErrNoRows
+	}
+	return db.GetDataExportRow{UserID: export.UserID, RequestedAt: export.RequestedAt, ReadyAt: export.ReadyAt}, nil
+}
+
+func (s *Store) GetDataExportArchive(ctx context.Context, arg db.GetDataExportArchiveParams) ([]byte, error) {
+	defer s.lock()()
+
+	export, ok := s.tables.dataExports[arg.UserID]
+	if !ok || !after(export.ReadyAt, arg.ReadyAfter) {
+		return nil, db.ErrNoRows
+	}
+	return cloneBytes(export.Archive), nil
+}
+
+func (s *Store) SaveDataExport(ctx context.Context, arg db.SaveDataExportParams) (int64, error) {
+	defer s.lock()()
+
+	export, ok := s.tables.dataExports[arg.UserID]
+	if !ok || export.ReadyAt.Valid {
+		return 0, nil
+	}
+	export.Archive = cloneBytes(arg.Archive)
+	export.ReadyAt = sql.NullTime{Time: arg.Now, Valid: true}
+	s.tables.dataExports[arg.UserID] = export
+	return 1, nil
+}
+
+func (s *Store) DeleteDataExport(ctx context.Context, userID int32) (int64, error) {
+	defer s.lock()()
+
+	if _, ok := s.tables.dataExports[userID]; !ok {
+		return 0, nil
+	}
+	delete(s.tables.dataExports, userID)
+	return 1, nil
+}
+
+func (s *Store) DeleteExpiredDataExports(ctx context.Context, before time.Time) (int64, error) {
+	defer s.lock()()
+
+	var n int64
+	for id, export := range s.tables.dataExports {
+		if export.ReadyAt.Valid && export.ReadyAt.Time.Before(before) {
+			delete(s.tables.dataExports, id)
+			n++
+		}
+	}
+	return n, nil
+}
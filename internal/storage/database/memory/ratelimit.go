@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	db "scratch/internal/storage/database"
+)
+
+func (s *Store) EnsureRateLimit(ctx context.Context, arg db.EnsureRateLimitParams) error {
+	defer s.lock()()
+
+	if _, ok := s.tables.rateLimits[arg.Key]; !ok {
+		s.tables.rateLimits[arg.Key] = arg.Tat
+	}
+	return nil
+}
+
+func (s *Store) LockRateLimit(ctx context.Context, key string) (time.Time, error) {
+	defer s.lock()()
+
+	tat, ok := s.tables.rateLimits[key]
+	if !ok {
+		return time.Time{}, db.ErrNoRows
+	}
+	return tat, nil
+}
+
+func (s *Store) UpdateRateLimit(ctx context.Context, arg db.UpdateRateLimitParams) error {
+	defer s.lock()()
+
+	if _, ok := s.tables.rateLimits[arg.Key]; ok {
+		s.tables.rateLimits[arg.Key] = arg.Tat
+	}
+	return nil
+}
+
+func (s *Store) DeleteExpiredRateLimits(ctx context.Context, tat time.Time) (int64, error) {
+	defer s.lock()()
+
+	var n int64
+	for key, t := range s.tables.rateLimits {
+		if t.Before(tat) {
+			delete(s.tables.rateLimits, key)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,174 @@
+// Package memory keeps the tables of the application in the process, for fast
+// tests and for running a demo without postgres. Store implements the queries
+// with the semantics they have in postgres, down to the constraint violations,
+// and the data is gone when the process exits.
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	db "scratch/internal/storage/database"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	_ db.Querier    = (*Store)(nil)
+	_ db.Transactor = (*Store)(nil)
+)
+
+// Store is an in-memory db.Querier. Its queries and transactions are
+// serialized by a single lock, so a transaction never sees a concurrent
+// change and never has to be retried.
+type Store struct {
+	// mu is nil in the view of a transaction, which already holds the lock
+	mu     *sync.Mutex
+	tables *tables
+}
+
+func New() *Store {
+	return &Store{mu: &sync.Mutex{}, tables: newTables()}
+}
+
+// InTx calls fn with a copy of the tables, which replaces them when fn returns
+// nil and is dropped otherwise.
+func (s *Store) InTx(ctx context.Context, fn func(q db.Querier) error) error {
+	defer s.lock()()
+
+	tx := s.tables.clone()
+	if err := fn(&Store{tables: tx}); err != nil {
+		return err
+	}
+	*s.tables = *tx
+	return nil
+}
+
+// lock locks the store, unless it is the view of a transaction, and returns
+// the function unlocking it.
+func (s *Store) lock() func() {
+	if s.mu == nil {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+func (s *Store) MigrationMessage(ctx context.Context) (string, error) {
+	return "successful migration", nil
+}
+
+type deviceKey struct {
+	userID      int32
+	fingerprint string
+}
+
+type roleKey struct {
+	userID int32
+	role   string
+}
+
+type memberKey struct {
+	organizationID int32
+	userID         int32
+}
+
+type idempotencyKey struct {
+	key       string
+	operation string
+}
+
+type tables struct {
+	users           map[int32]db.ScratchUser
+	sessions        map[int32]db.ScratchSession
+	devices         map[deviceKey]db.ScratchUserDevice
+	roles           map[roleKey]db.ScratchUserRole
+	idempotencyKeys map[idempotencyKey]db.ScratchIdempotencyKey
+	jobs            map[int64]db.ScratchJob
+	mailSent        map[string]time.Time
+	outbox          map[int64]db.ScratchOutbox
+	rateLimits      map[string]time.Time
+	dataExports     map[int32]db.ScratchDataExport
+	organizations   map[int32]db.ScratchOrganization
+	members         map[memberKey]db.ScratchOrganizationMember
+	invitations     map[int32]db.ScratchInvitation
+
+	// the last values of the serial columns
+	userID         int32
+	sessionID      int32
+	jobID          int64
+	outboxID       int64
+	organizationID int32
+	invitationID   int32
+}
+
+func newTables() *tables {
+	return &tables{
+		users:           make(map[int32]db.ScratchUser),
+		sessions:        make(map[int32]db.ScratchSession),
+		devices:         make(map[deviceKey]db.ScratchUserDevice),
+		roles:           make(map[roleKey]db.ScratchUserRole),
+		idempotencyKeys: make(map[idempotencyKey]db.ScratchIdempotencyKey),
+		jobs:            make(map[int64]db.ScratchJob),
+		mailSent:        make(map[string]time.Time),
+		outbox:          make(map[int64]db.ScratchOutbox),
+		rateLimits:      make(map[string]time.Time),
+		dataExports:     make(map[int32]db.ScratchDataExport),
+		organizations:   make(map[int32]db.ScratchOrganization),
+		members:         make(map[memberKey]db.ScratchOrganizationMember),
+		invitations:     make(map[int32]db.ScratchInvitation),
+	}
+}
+
+// clone copies the tables. Rows are values whose byte slices are replaced and
+// never modified, so copying the maps is enough.
+func (t *tables) clone() *tables {
+	c := *t
+	c.users = cloneMap(t.users)
+	c.sessions = cloneMap(t.sessions)
+	c.devices = cloneMap(t.devices)
+	c.roles = cloneMap(t.roles)
+	c.idempotencyKeys = cloneMap(t.idempotencyKeys)
+	c.jobs = cloneMap(t.jobs)
+	c.mailSent = cloneMap(t.mailSent)
+	c.outbox = cloneMap(t.outbox)
+	c.rateLimits = cloneMap(t.rateLimits)
+	c.dataExports = cloneMap(t.dataExports)
+	c.organizations = cloneMap(t.organizations)
+	c.members = cloneMap(t.members)
+	c.invitations = cloneMap(t.invitations)
+	return &c
+}
+
+func cloneMap[K comparable, V any](m map[K]V) map[K]V {
+	c := make(map[K]V, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// cloneBytes copies b, so the caller can reuse the slice it passed to a query.
+func cloneBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+func cloneJSON(b json.RawMessage) json.RawMessage {
+	return json.RawMessage(cloneBytes(b))
+}
+
+func constraintError(code, table, constraint, message string) error {
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           code,
+		Message:        message,
+		SchemaName:     "scratch",
+		TableName:      table,
+		ConstraintName: constraint,
+	}
+}
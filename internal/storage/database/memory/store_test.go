@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	db "scratch/internal/storage/database"
+	"scratch/internal/storage/database/dbtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	dbtest.TestQuerier(t, func(t *testing.T) (db.Querier, db.Transactor) {
+		s := New()
+		return s, s
+	})
+}
+
+func TestStore_InTxSerialized(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	require.NoError(t, s.EnsureRateLimit(ctx, db.EnsureRateLimitParams{Key: "counter"}))
+
+	// every transaction reads and writes the counter, none of the increments
+	// may get lost
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := s.InTx(ctx, func(q db.Querier) error {
+				tat, err := q.LockRateLimit(ctx, "counter")
+				if err != nil {
+					return err
+				}
+				return q.UpdateRateLimit(ctx, db.UpdateRateLimitParams{Key: "counter", Tat: tat.Add(1)})
+			})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	tat, err := s.LockRateLimit(ctx, "counter")
+	require.NoError(t, err)
+	assert.Equal(t, time.Time{}.Add(50), tat)
+}
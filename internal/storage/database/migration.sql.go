@@ -16,7 +16,7 @@ FROM initial_migration
 `
 
 func (q *Queries) MigrationMessage(ctx context.Context) (string, error) {
-	row := q.db.QueryRowContext(ctx, migrationMessage)
+	row := q.db.QueryRow(ctx, migrationMessage)
 	var message string
 	err := row.Scan(&message)
 	return message, err
@@ -8,6 +8,7 @@ import (
 	context "context"
 	reflect "reflect"
 	db "scratch/internal/storage/database"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -35,6 +36,81 @@ func (m *MockQuerier) EXPECT() *MockQuerierMockRecorder {
 	return m.recorder
 }
 
+// AcceptInvitation mocks base method.
+func (m *MockQuerier) AcceptInvitation(ctx context.Context, arg db.AcceptInvitationParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptInvitation", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcceptInvitation indicates an expected call of AcceptInvitation.
+func (mr *MockQuerierMockRecorder) AcceptInvitation(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptInvitation", reflect.TypeOf((*MockQuerier)(nil).AcceptInvitation), ctx, arg)
+}
+
+// AddOrganizationMember mocks base method.
+func (m *MockQuerier) AddOrganizationMember(ctx context.Context, arg db.AddOrganizationMemberParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddOrganizationMember", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddOrganizationMember indicates an expected call of AddOrganizationMember.
+func (mr *MockQuerierMockRecorder) AddOrganizationMember(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddOrganizationMember", reflect.TypeOf((*MockQuerier)(nil).AddOrganizationMember), ctx, arg)
+}
+
+// ClaimJobs mocks base method.
+func (m *MockQuerier) ClaimJobs(ctx context.Context, arg db.ClaimJobsParams) ([]db.ClaimJobsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimJobs", ctx, arg)
+	ret0, _ := ret[0].([]db.ClaimJobsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimJobs indicates an expected call of ClaimJobs.
+func (mr *MockQuerierMockRecorder) ClaimJobs(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimJobs", reflect.TypeOf((*MockQuerier)(nil).ClaimJobs), ctx, arg)
+}
+
+// ClaimMailMessage mocks base method.
+func (m *MockQuerier) ClaimMailMessage(ctx context.Context, arg db.ClaimMailMessageParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimMailMessage", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimMailMessage indicates an expected call of ClaimMailMessage.
+func (mr *MockQuerierMockRecorder) ClaimMailMessage(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimMailMessage", reflect.TypeOf((*MockQuerier)(nil).ClaimMailMessage), ctx, arg)
+}
+
+// ClaimOutboxEvents mocks base method.
+func (m *MockQuerier) ClaimOutboxEvents(ctx context.Context, arg db.ClaimOutboxEventsParams) ([]db.ClaimOutboxEventsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimOutboxEvents", ctx, arg)
+	ret0, _ := ret[0].([]db.ClaimOutboxEventsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimOutboxEvents indicates an expected call of ClaimOutboxEvents.
+func (mr *MockQuerierMockRecorder) ClaimOutboxEvents(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimOutboxEvents", reflect.TypeOf((*MockQuerier)(nil).ClaimOutboxEvents), ctx, arg)
+}
+
 // CleanUserTable mocks base method.
 func (m *MockQuerier) CleanUserTable(ctx context.Context) error {
 	m.ctrl.T.Helper()
@@ -49,12 +125,118 @@ func (mr *MockQuerierMockRecorder) CleanUserTable(ctx interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CleanUserTable", reflect.TypeOf((*MockQuerier)(nil).CleanUserTable), ctx)
 }
 
+// CompleteIdempotencyKey mocks base method.
+func (m *MockQuerier) CompleteIdempotencyKey(ctx context.Context, arg db.CompleteIdempotencyKeyParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteIdempotencyKey", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteIdempotencyKey indicates an expected call of CompleteIdempotencyKey.
+func (mr *MockQuerierMockRecorder) CompleteIdempotencyKey(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteIdempotencyKey", reflect.TypeOf((*MockQuerier)(nil).CompleteIdempotencyKey), ctx, arg)
+}
+
+// CompleteJob mocks base method.
+func (m *MockQuerier) CompleteJob(ctx context.Context, arg db.CompleteJobParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteJob", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteJob indicates an expected call of CompleteJob.
+func (mr *MockQuerierMockRecorder) CompleteJob(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteJob", reflect.TypeOf((*MockQuerier)(nil).CompleteJob), ctx, arg)
+}
+
+// CountOrganizationOwners mocks base method.
+func (m *MockQuerier) CountOrganizationOwners(ctx context.Context, organizationID int32) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountOrganizationOwners", ctx, organizationID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountOrganizationOwners indicates an expected call of CountOrganizationOwners.
+func (mr *MockQuerierMockRecorder) CountOrganizationOwners(ctx, organizationID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountOrganizationOwners", reflect.TypeOf((*MockQuerier)(nil).CountOrganizationOwners), ctx, organizationID)
+}
+
+// CountUserDevices mocks base method.
+func (m *MockQuerier) CountUserDevices(ctx context.Context, userID int32) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUserDevices", ctx, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUserDevices indicates an expected call of CountUserDevices.
+func (mr *MockQuerierMockRecorder) CountUserDevices(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUserDevices", reflect.TypeOf((*MockQuerier)(nil).CountUserDevices), ctx, userID)
+}
+
+// CreateBrowserSession mocks base method.
+func (m *MockQuerier) CreateBrowserSession(ctx context.Context, arg db.CreateBrowserSessionParams) (int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBrowserSession", ctx, arg)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateBrowserSession indicates an expected call of CreateBrowserSession.
+func (mr *MockQuerierMockRecorder) CreateBrowserSession(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBrowserSession", reflect.TypeOf((*MockQuerier)(nil).CreateBrowserSession), ctx, arg)
+}
+
+// CreateInvitation mocks base method.
+func (m *MockQuerier) CreateInvitation(ctx context.Context, arg db.CreateInvitationParams) (db.ScratchInvitation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInvitation", ctx, arg)
+	ret0, _ := ret[0].(db.ScratchInvitation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateInvitation indicates an expected call of CreateInvitation.
+func (mr *MockQuerierMockRecorder) CreateInvitation(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInvitation", reflect.TypeOf((*MockQuerier)(nil).CreateInvitation), ctx, arg)
+}
+
+// CreateOrganization mocks base method.
+func (m *MockQuerier) CreateOrganization(ctx context.Context, arg db.CreateOrganizationParams) (db.ScratchOrganization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrganization", ctx, arg)
+	ret0, _ := ret[0].(db.ScratchOrganization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrganization indicates an expected call of CreateOrganization.
+func (mr *MockQuerierMockRecorder) CreateOrganization(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrganization", reflect.TypeOf((*MockQuerier)(nil).CreateOrganization), ctx, arg)
+}
+
 // CreateSession mocks base method.
-func (m *MockQuerier) CreateSession(ctx context.Context, arg db.CreateSessionParams) error {
+func (m *MockQuerier) CreateSession(ctx context.Context, arg db.CreateSessionParams) (int32, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateSession", ctx, arg)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // CreateSession indicates an expected call of CreateSession.
@@ -78,47 +260,1117 @@ func (mr *MockQuerierMockRecorder) CreateUser(ctx, arg interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockQuerier)(nil).CreateUser), ctx, arg)
 }
 
-// GetSession mocks base method.
-func (m *MockQuerier) GetSession(ctx context.Context, arg db.GetSessionParams) (db.ScratchSession, error) {
+// DeleteAllSessions mocks base method.
+func (m *MockQuerier) DeleteAllSessions(ctx context.Context) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetSession", ctx, arg)
-	ret0, _ := ret[0].(db.ScratchSession)
+	ret := m.ctrl.Call(m, "DeleteAllSessions", ctx)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetSession indicates an expected call of GetSession.
-func (mr *MockQuerierMockRecorder) GetSession(ctx, arg interface{}) *gomock.Call {
+// DeleteAllSessions indicates an expected call of DeleteAllSessions.
+func (mr *MockQuerierMockRecorder) DeleteAllSessions(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockQuerier)(nil).GetSession), ctx, arg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAllSessions", reflect.TypeOf((*MockQuerier)(nil).DeleteAllSessions), ctx)
 }
 
-// GetUserByEmail mocks base method.
-func (m *MockQuerier) GetUserByEmail(ctx context.Context, email string) (db.ScratchUser, error) {
+// DeleteBrowserSession mocks base method.
+func (m *MockQuerier) DeleteBrowserSession(ctx context.Context, tokenHash string) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserByEmail", ctx, email)
-	ret0, _ := ret[0].(db.ScratchUser)
+	ret := m.ctrl.Call(m, "DeleteBrowserSession", ctx, tokenHash)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserByEmail indicates an expected call of GetUserByEmail.
-func (mr *MockQuerierMockRecorder) GetUserByEmail(ctx, email interface{}) *gomock.Call {
+// DeleteBrowserSession indicates an expected call of DeleteBrowserSession.
+func (mr *MockQuerierMockRecorder) DeleteBrowserSession(ctx, tokenHash interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockQuerier)(nil).GetUserByEmail), ctx, email)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBrowserSession", reflect.TypeOf((*MockQuerier)(nil).DeleteBrowserSession), ctx, tokenHash)
 }
 
-// MigrationMessage mocks base method.
-func (m *MockQuerier) MigrationMessage(ctx context.Context) (string, error) {
+// DeleteDataExport mocks base method.
+func (m *MockQuerier) DeleteDataExport(ctx context.Context, userID int32) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "MigrationMessage", ctx)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "DeleteDataExport", ctx, userID)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// MigrationMessage indicates an expected call of MigrationMessage.
-func (mr *MockQuerierMockRecorder) MigrationMessage(ctx interface{}) *gomock.Call {
+// DeleteDataExport indicates an expected call of DeleteDataExport.
+func (mr *MockQuerierMockRecorder) DeleteDataExport(ctx, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrationMessage", reflect.TypeOf((*MockQuerier)(nil).MigrationMessage), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDataExport", reflect.TypeOf((*MockQuerier)(nil).DeleteDataExport), ctx, userID)
+}
+
+// DeleteExpiredDataExports mocks base method.
+func (m *MockQuerier) DeleteExpiredDataExports(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredDataExports", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredDataExports indicates an expected call of DeleteExpiredDataExports.
+func (mr *MockQuerierMockRecorder) DeleteExpiredDataExports(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredDataExports", reflect.TypeOf((*MockQuerier)(nil).DeleteExpiredDataExports), ctx, before)
+}
+
+// DeleteExpiredIdempotencyKeys mocks base method.
+func (m *MockQuerier) DeleteExpiredIdempotencyKeys(ctx context.Context, expiresAt time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredIdempotencyKeys", ctx, expiresAt)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredIdempotencyKeys indicates an expected call of DeleteExpiredIdempotencyKeys.
+func (mr *MockQuerierMockRecorder) DeleteExpiredIdempotencyKeys(ctx, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredIdempotencyKeys", reflect.TypeOf((*MockQuerier)(nil).DeleteExpiredIdempotencyKeys), ctx, expiresAt)
+}
+
+// DeleteExpiredRateLimits mocks base method.
+func (m *MockQuerier) DeleteExpiredRateLimits(ctx context.Context, tat time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredRateLimits", ctx, tat)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredRateLimits indicates an expected call of DeleteExpiredRateLimits.
+func (mr *MockQuerierMockRecorder) DeleteExpiredRateLimits(ctx, tat interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredRateLimits", reflect.TypeOf((*MockQuerier)(nil).DeleteExpiredRateLimits), ctx, tat)
+}
+
+// DeleteExpiredSessions mocks base method.
+func (m *MockQuerier) DeleteExpiredSessions(ctx context.Context, now time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredSessions", ctx, now)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredSessions indicates an expected call of DeleteExpiredSessions.
+func (mr *MockQuerierMockRecorder) DeleteExpiredSessions(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredSessions", reflect.TypeOf((*MockQuerier)(nil).DeleteExpiredSessions), ctx, now)
+}
+
+// DeleteFinishedJobs mocks base method.
+func (m *MockQuerier) DeleteFinishedJobs(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFinishedJobs", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteFinishedJobs indicates an expected call of DeleteFinishedJobs.
+func (mr *MockQuerierMockRecorder) DeleteFinishedJobs(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFinishedJobs", reflect.TypeOf((*MockQuerier)(nil).DeleteFinishedJobs), ctx, before)
+}
+
+// DeleteIdempotencyKey mocks base method.
+func (m *MockQuerier) DeleteIdempotencyKey(ctx context.Context, arg db.DeleteIdempotencyKeyParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdempotencyKey", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIdempotencyKey indicates an expected call of DeleteIdempotencyKey.
+func (mr *MockQuerierMockRecorder) DeleteIdempotencyKey(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdempotencyKey", reflect.TypeOf((*MockQuerier)(nil).DeleteIdempotencyKey), ctx, arg)
+}
+
+// DeleteOtherUserSessions mocks base method.
+func (m *MockQuerier) DeleteOtherUserSessions(ctx context.Context, arg db.DeleteOtherUserSessionsParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOtherUserSessions", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteOtherUserSessions indicates an expected call of DeleteOtherUserSessions.
+func (mr *MockQuerierMockRecorder) DeleteOtherUserSessions(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOtherUserSessions", reflect.TypeOf((*MockQuerier)(nil).DeleteOtherUserSessions), ctx, arg)
+}
+
+// DeletePublishedOutboxEvents mocks base method.
+func (m *MockQuerier) DeletePublishedOutboxEvents(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePublishedOutboxEvents", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeletePublishedOutboxEvents indicates an expected call of DeletePublishedOutboxEvents.
+func (mr *MockQuerierMockRecorder) DeletePublishedOutboxEvents(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePublishedOutboxEvents", reflect.TypeOf((*MockQuerier)(nil).DeletePublishedOutboxEvents), ctx, before)
+}
+
+// DeleteSentMailBefore mocks base method.
+func (m *MockQuerier) DeleteSentMailBefore(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSentMailBefore", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteSentMailBefore indicates an expected call of DeleteSentMailBefore.
+func (mr *MockQuerierMockRecorder) DeleteSentMailBefore(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSentMailBefore", reflect.TypeOf((*MockQuerier)(nil).DeleteSentMailBefore), ctx, before)
+}
+
+// DeleteUser mocks base method.
+func (m *MockQuerier) DeleteUser(ctx context.Context, arg db.DeleteUserParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockQuerierMockRecorder) DeleteUser(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockQuerier)(nil).DeleteUser), ctx, arg)
+}
+
+// DeleteUserDevices mocks base method.
+func (m *MockQuerier) DeleteUserDevices(ctx context.Context, userID int32) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserDevices", ctx, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteUserDevices indicates an expected call of DeleteUserDevices.
+func (mr *MockQuerierMockRecorder) DeleteUserDevices(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserDevices", reflect.TypeOf((*MockQuerier)(nil).DeleteUserDevices), ctx, userID)
+}
+
+// DeleteUserEvents mocks base method.
+func (m *MockQuerier) DeleteUserEvents(ctx context.Context, aggregateID string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserEvents", ctx, aggregateID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteUserEvents indicates an expected call of DeleteUserEvents.
+func (mr *MockQuerierMockRecorder) DeleteUserEvents(ctx, aggregateID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserEvents", reflect.TypeOf((*MockQuerier)(nil).DeleteUserEvents), ctx, aggregateID)
+}
+
+// DeleteUserInvitations mocks base method.
+func (m *MockQuerier) DeleteUserInvitations(ctx context.Context, userID int32) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserInvitations", ctx, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteUserInvitations indicates an expected call of DeleteUserInvitations.
+func (mr *MockQuerierMockRecorder) DeleteUserInvitations(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserInvitations", reflect.TypeOf((*MockQuerier)(nil).DeleteUserInvitations), ctx, userID)
+}
+
+// DeleteUserMemberships mocks base method.
+func (m *MockQuerier) DeleteUserMemberships(ctx context.Context, userID int32) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserMemberships", ctx, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteUserMemberships indicates an expected call of DeleteUserMemberships.
+func (mr *MockQuerierMockRecorder) DeleteUserMemberships(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserMemberships", reflect.TypeOf((*MockQuerier)(nil).DeleteUserMemberships), ctx, userID)
+}
+
+// DeleteUserRoles mocks base method.
+func (m *MockQuerier) DeleteUserRoles(ctx context.Context, userID int32) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserRoles", ctx, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteUserRoles indicates an expected call of DeleteUserRoles.
+func (mr *MockQuerierMockRecorder) DeleteUserRoles(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserRoles", reflect.TypeOf((*MockQuerier)(nil).DeleteUserRoles), ctx, userID)
+}
+
+// DeleteUserSession mocks base method.
+func (m *MockQuerier) DeleteUserSession(ctx context.Context, arg db.DeleteUserSessionParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserSession", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteUserSession indicates an expected call of DeleteUserSession.
+func (mr *MockQuerierMockRecorder) DeleteUserSession(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserSession", reflect.TypeOf((*MockQuerier)(nil).DeleteUserSession), ctx, arg)
+}
+
+// DeleteUserSessions mocks base method.
+func (m *MockQuerier) DeleteUserSessions(ctx context.Context, userID int32) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserSessions", ctx, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteUserSessions indicates an expected call of DeleteUserSessions.
+func (mr *MockQuerierMockRecorder) DeleteUserSessions(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserSessions", reflect.TypeOf((*MockQuerier)(nil).DeleteUserSessions), ctx, userID)
+}
+
+// DisableUser mocks base method.
+func (m *MockQuerier) DisableUser(ctx context.Context, email string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableUser", ctx, email)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DisableUser indicates an expected call of DisableUser.
+func (mr *MockQuerierMockRecorder) DisableUser(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableUser", reflect.TypeOf((*MockQuerier)(nil).DisableUser), ctx, email)
+}
+
+// EnqueueJob mocks base method.
+func (m *MockQuerier) EnqueueJob(ctx context.Context, arg db.EnqueueJobParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnqueueJob", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnqueueJob indicates an expected call of EnqueueJob.
+func (mr *MockQuerierMockRecorder) EnqueueJob(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueueJob", reflect.TypeOf((*MockQuerier)(nil).EnqueueJob), ctx, arg)
+}
+
+// EnsureRateLimit mocks base method.
+func (m *MockQuerier) EnsureRateLimit(ctx context.Context, arg db.EnsureRateLimitParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnsureRateLimit", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnsureRateLimit indicates an expected call of EnsureRateLimit.
+func (mr *MockQuerierMockRecorder) EnsureRateLimit(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnsureRateLimit", reflect.TypeOf((*MockQuerier)(nil).EnsureRateLimit), ctx, arg)
+}
+
+// EraseUser mocks base method.
+func (m *MockQuerier) EraseUser(ctx context.Context, id int32) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EraseUser", ctx, id)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EraseUser indicates an expected call of EraseUser.
+func (mr *MockQuerierMockRecorder) EraseUser(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EraseUser", reflect.TypeOf((*MockQuerier)(nil).EraseUser), ctx, id)
+}
+
+// GetBrowserSession mocks base method.
+func (m *MockQuerier) GetBrowserSession(ctx context.Context, arg db.GetBrowserSessionParams) (db.GetBrowserSessionRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBrowserSession", ctx, arg)
+	ret0, _ := ret[0].(db.GetBrowserSessionRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBrowserSession indicates an expected call of GetBrowserSession.
+func (mr *MockQuerierMockRecorder) GetBrowserSession(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBrowserSession", reflect.TypeOf((*MockQuerier)(nil).GetBrowserSession), ctx, arg)
+}
+
+// GetDataExport mocks base method.
+func (m *MockQuerier) GetDataExport(ctx context.Context, userID int32) (db.GetDataExportRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDataExport", ctx, userID)
+	ret0, _ := ret[0].(db.GetDataExportRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDataExport indicates an expected call of GetDataExport.
+func (mr *MockQuerierMockRecorder) GetDataExport(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDataExport", reflect.TypeOf((*MockQuerier)(nil).GetDataExport), ctx, userID)
+}
+
+// GetDataExportArchive mocks base method.
+func (m *MockQuerier) GetDataExportArchive(ctx context.Context, arg db.GetDataExportArchiveParams) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDataExportArchive", ctx, arg)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDataExportArchive indicates an expected call of GetDataExportArchive.
+func (mr *MockQuerierMockRecorder) GetDataExportArchive(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDataExportArchive", reflect.TypeOf((*MockQuerier)(nil).GetDataExportArchive), ctx, arg)
+}
+
+// GetDeletedUserByEmail mocks base method.
+func (m *MockQuerier) GetDeletedUserByEmail(ctx context.Context, email string) (db.ScratchUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedUserByEmail", ctx, email)
+	ret0, _ := ret[0].(db.ScratchUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletedUserByEmail indicates an expected call of GetDeletedUserByEmail.
+func (mr *MockQuerierMockRecorder) GetDeletedUserByEmail(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedUserByEmail", reflect.TypeOf((*MockQuerier)(nil).GetDeletedUserByEmail), ctx, email)
+}
+
+// GetIdempotencyKey mocks base method.
+func (m *MockQuerier) GetIdempotencyKey(ctx context.Context, arg db.GetIdempotencyKeyParams) (db.ScratchIdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdempotencyKey", ctx, arg)
+	ret0, _ := ret[0].(db.ScratchIdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdempotencyKey indicates an expected call of GetIdempotencyKey.
+func (mr *MockQuerierMockRecorder) GetIdempotencyKey(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdempotencyKey", reflect.TypeOf((*MockQuerier)(nil).GetIdempotencyKey), ctx, arg)
+}
+
+// GetInvitation mocks base method.
+func (m *MockQuerier) GetInvitation(ctx context.Context, arg db.GetInvitationParams) (db.ScratchInvitation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInvitation", ctx, arg)
+	ret0, _ := ret[0].(db.ScratchInvitation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInvitation indicates an expected call of GetInvitation.
+func (mr *MockQuerierMockRecorder) GetInvitation(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvitation", reflect.TypeOf((*MockQuerier)(nil).GetInvitation), ctx, arg)
+}
+
+// GetInvitationByCode mocks base method.
+func (m *MockQuerier) GetInvitationByCode(ctx context.Context, codeHash string) (db.ScratchInvitation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInvitationByCode", ctx, codeHash)
+	ret0, _ := ret[0].(db.ScratchInvitation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInvitationByCode indicates an expected call of GetInvitationByCode.
+func (mr *MockQuerierMockRecorder) GetInvitationByCode(ctx, codeHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvitationByCode", reflect.TypeOf((*MockQuerier)(nil).GetInvitationByCode), ctx, codeHash)
+}
+
+// GetOrganization mocks base method.
+func (m *MockQuerier) GetOrganization(ctx context.Context, id int32) (db.ScratchOrganization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrganization", ctx, id)
+	ret0, _ := ret[0].(db.ScratchOrganization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrganization indicates an expected call of GetOrganization.
+func (mr *MockQuerierMockRecorder) GetOrganization(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrganization", reflect.TypeOf((*MockQuerier)(nil).GetOrganization), ctx, id)
+}
+
+// GetOrganizationMember mocks base method.
+func (m *MockQuerier) GetOrganizationMember(ctx context.Context, arg db.GetOrganizationMemberParams) (db.ScratchOrganizationMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrganizationMember", ctx, arg)
+	ret0, _ := ret[0].(db.ScratchOrganizationMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrganizationMember indicates an expected call of GetOrganizationMember.
+func (mr *MockQuerierMockRecorder) GetOrganizationMember(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrganizationMember", reflect.TypeOf((*MockQuerier)(nil).GetOrganizationMember), ctx, arg)
+}
+
+// GetSessionByRefreshToken mocks base method.
+func (m *MockQuerier) GetSessionByRefreshToken(ctx context.Context, arg db.GetSessionByRefreshTokenParams) (db.GetSessionByRefreshTokenRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessionByRefreshToken", ctx, arg)
+	ret0, _ := ret[0].(db.GetSessionByRefreshTokenRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessionByRefreshToken indicates an expected call of GetSessionByRefreshToken.
+func (mr *MockQuerierMockRecorder) GetSessionByRefreshToken(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionByRefreshToken", reflect.TypeOf((*MockQuerier)(nil).GetSessionByRefreshToken), ctx, arg)
+}
+
+// GetUserByEmail mocks base method.
+func (m *MockQuerier) GetUserByEmail(ctx context.Context, email string) (db.ScratchUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByEmail", ctx, email)
+	ret0, _ := ret[0].(db.ScratchUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByEmail indicates an expected call of GetUserByEmail.
+func (mr *MockQuerierMockRecorder) GetUserByEmail(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockQuerier)(nil).GetUserByEmail), ctx, email)
+}
+
+// GetUserByID mocks base method.
+func (m *MockQuerier) GetUserByID(ctx context.Context, id int32) (db.ScratchUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", ctx, id)
+	ret0, _ := ret[0].(db.ScratchUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByID indicates an expected call of GetUserByID.
+func (mr *MockQuerierMockRecorder) GetUserByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockQuerier)(nil).GetUserByID), ctx, id)
+}
+
+// GrantUserRole mocks base method.
+func (m *MockQuerier) GrantUserRole(ctx context.Context, arg db.GrantUserRoleParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GrantUserRole", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GrantUserRole indicates an expected call of GrantUserRole.
+func (mr *MockQuerierMockRecorder) GrantUserRole(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GrantUserRole", reflect.TypeOf((*MockQuerier)(nil).GrantUserRole), ctx, arg)
+}
+
+// InsertIdempotencyKey mocks base method.
+func (m *MockQuerier) InsertIdempotencyKey(ctx context.Context, arg db.InsertIdempotencyKeyParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertIdempotencyKey", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertIdempotencyKey indicates an expected call of InsertIdempotencyKey.
+func (mr *MockQuerierMockRecorder) InsertIdempotencyKey(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertIdempotencyKey", reflect.TypeOf((*MockQuerier)(nil).InsertIdempotencyKey), ctx, arg)
+}
+
+// InsertOutboxEvent mocks base method.
+func (m *MockQuerier) InsertOutboxEvent(ctx context.Context, arg db.InsertOutboxEventParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertOutboxEvent", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertOutboxEvent indicates an expected call of InsertOutboxEvent.
+func (mr *MockQuerierMockRecorder) InsertOutboxEvent(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertOutboxEvent", reflect.TypeOf((*MockQuerier)(nil).InsertOutboxEvent), ctx, arg)
+}
+
+// KillJob mocks base method.
+func (m *MockQuerier) KillJob(ctx context.Context, arg db.KillJobParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KillJob", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// KillJob indicates an expected call of KillJob.
+func (mr *MockQuerierMockRecorder) KillJob(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KillJob", reflect.TypeOf((*MockQuerier)(nil).KillJob), ctx, arg)
+}
+
+// KillOutboxEvent mocks base method.
+func (m *MockQuerier) KillOutboxEvent(ctx context.Context, arg db.KillOutboxEventParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KillOutboxEvent", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// KillOutboxEvent indicates an expected call of KillOutboxEvent.
+func (mr *MockQuerierMockRecorder) KillOutboxEvent(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KillOutboxEvent", reflect.TypeOf((*MockQuerier)(nil).KillOutboxEvent), ctx, arg)
+}
+
+// KillStaleJobs mocks base method.
+func (m *MockQuerier) KillStaleJobs(ctx context.Context, arg db.KillStaleJobsParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KillStaleJobs", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// KillStaleJobs indicates an expected call of KillStaleJobs.
+func (mr *MockQuerierMockRecorder) KillStaleJobs(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KillStaleJobs", reflect.TypeOf((*MockQuerier)(nil).KillStaleJobs), ctx, arg)
+}
+
+// ListDeadJobs mocks base method.
+func (m *MockQuerier) ListDeadJobs(ctx context.Context) ([]db.ListDeadJobsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeadJobs", ctx)
+	ret0, _ := ret[0].([]db.ListDeadJobsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeadJobs indicates an expected call of ListDeadJobs.
+func (mr *MockQuerierMockRecorder) ListDeadJobs(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeadJobs", reflect.TypeOf((*MockQuerier)(nil).ListDeadJobs), ctx)
+}
+
+// ListOrganizationInvitations mocks base method.
+func (m *MockQuerier) ListOrganizationInvitations(ctx context.Context, organizationID int32) ([]db.ListOrganizationInvitationsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrganizationInvitations", ctx, organizationID)
+	ret0, _ := ret[0].([]db.ListOrganizationInvitationsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrganizationInvitations indicates an expected call of ListOrganizationInvitations.
+func (mr *MockQuerierMockRecorder) ListOrganizationInvitations(ctx, organizationID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrganizationInvitations", reflect.TypeOf((*MockQuerier)(nil).ListOrganizationInvitations), ctx, organizationID)
+}
+
+// ListOrganizationMembers mocks base method.
+func (m *MockQuerier) ListOrganizationMembers(ctx context.Context, organizationID int32) ([]db.ListOrganizationMembersRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrganizationMembers", ctx, organizationID)
+	ret0, _ := ret[0].([]db.ListOrganizationMembersRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrganizationMembers indicates an expected call of ListOrganizationMembers.
+func (mr *MockQuerierMockRecorder) ListOrganizationMembers(ctx, organizationID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrganizationMembers", reflect.TypeOf((*MockQuerier)(nil).ListOrganizationMembers), ctx, organizationID)
+}
+
+// ListSessions mocks base method.
+func (m *MockQuerier) ListSessions(ctx context.Context, arg db.ListSessionsParams) ([]db.ListSessionsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSessions", ctx, arg)
+	ret0, _ := ret[0].([]db.ListSessionsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSessions indicates an expected call of ListSessions.
+func (mr *MockQuerierMockRecorder) ListSessions(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSessions", reflect.TypeOf((*MockQuerier)(nil).ListSessions), ctx, arg)
+}
+
+// ListUserDevices mocks base method.
+func (m *MockQuerier) ListUserDevices(ctx context.Context, userID int32) ([]db.ScratchUserDevice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserDevices", ctx, userID)
+	ret0, _ := ret[0].([]db.ScratchUserDevice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserDevices indicates an expected call of ListUserDevices.
+func (mr *MockQuerierMockRecorder) ListUserDevices(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserDevices", reflect.TypeOf((*MockQuerier)(nil).ListUserDevices), ctx, userID)
+}
+
+// ListUserEvents mocks base method.
+func (m *MockQuerier) ListUserEvents(ctx context.Context, aggregateID string) ([]db.ListUserEventsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserEvents", ctx, aggregateID)
+	ret0, _ := ret[0].([]db.ListUserEventsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserEvents indicates an expected call of ListUserEvents.
+func (mr *MockQuerierMockRecorder) ListUserEvents(ctx, aggregateID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserEvents", reflect.TypeOf((*MockQuerier)(nil).ListUserEvents), ctx, aggregateID)
+}
+
+// ListUserInvitations mocks base method.
+func (m *MockQuerier) ListUserInvitations(ctx context.Context, userID int32) ([]db.ListUserInvitationsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserInvitations", ctx, userID)
+	ret0, _ := ret[0].([]db.ListUserInvitationsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserInvitations indicates an expected call of ListUserInvitations.
+func (mr *MockQuerierMockRecorder) ListUserInvitations(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserInvitations", reflect.TypeOf((*MockQuerier)(nil).ListUserInvitations), ctx, userID)
+}
+
+// ListUserOrganizations mocks base method.
+func (m *MockQuerier) ListUserOrganizations(ctx context.Context, userID int32) ([]db.ListUserOrganizationsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserOrganizations", ctx, userID)
+	ret0, _ := ret[0].([]db.ListUserOrganizationsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserOrganizations indicates an expected call of ListUserOrganizations.
+func (mr *MockQuerierMockRecorder) ListUserOrganizations(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserOrganizations", reflect.TypeOf((*MockQuerier)(nil).ListUserOrganizations), ctx, userID)
+}
+
+// ListUserRoleGrants mocks base method.
+func (m *MockQuerier) ListUserRoleGrants(ctx context.Context, userID int32) ([]db.ScratchUserRole, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserRoleGrants", ctx, userID)
+	ret0, _ := ret[0].([]db.ScratchUserRole)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserRoleGrants indicates an expected call of ListUserRoleGrants.
+func (mr *MockQuerierMockRecorder) ListUserRoleGrants(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserRoleGrants", reflect.TypeOf((*MockQuerier)(nil).ListUserRoleGrants), ctx, userID)
+}
+
+// ListUserRoles mocks base method.
+func (m *MockQuerier) ListUserRoles(ctx context.Context, userID int32) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserRoles", ctx, userID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserRoles indicates an expected call of ListUserRoles.
+func (mr *MockQuerierMockRecorder) ListUserRoles(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserRoles", reflect.TypeOf((*MockQuerier)(nil).ListUserRoles), ctx, userID)
+}
+
+// ListUserSessions mocks base method.
+func (m *MockQuerier) ListUserSessions(ctx context.Context, userID int32) ([]db.ListUserSessionsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserSessions", ctx, userID)
+	ret0, _ := ret[0].([]db.ListUserSessionsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserSessions indicates an expected call of ListUserSessions.
+func (mr *MockQuerierMockRecorder) ListUserSessions(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserSessions", reflect.TypeOf((*MockQuerier)(nil).ListUserSessions), ctx, userID)
+}
+
+// ListUsersDeletedBefore mocks base method.
+func (m *MockQuerier) ListUsersDeletedBefore(ctx context.Context, deletedBefore time.Time) ([]int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsersDeletedBefore", ctx, deletedBefore)
+	ret0, _ := ret[0].([]int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsersDeletedBefore indicates an expected call of ListUsersDeletedBefore.
+func (mr *MockQuerierMockRecorder) ListUsersDeletedBefore(ctx, deletedBefore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsersDeletedBefore", reflect.TypeOf((*MockQuerier)(nil).ListUsersDeletedBefore), ctx, deletedBefore)
+}
+
+// LockRateLimit mocks base method.
+func (m *MockQuerier) LockRateLimit(ctx context.Context, key string) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockRateLimit", ctx, key)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockRateLimit indicates an expected call of LockRateLimit.
+func (mr *MockQuerierMockRecorder) LockRateLimit(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockRateLimit", reflect.TypeOf((*MockQuerier)(nil).LockRateLimit), ctx, key)
+}
+
+// MarkOutboxEventFailed mocks base method.
+func (m *MockQuerier) MarkOutboxEventFailed(ctx context.Context, arg db.MarkOutboxEventFailedParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkOutboxEventFailed", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkOutboxEventFailed indicates an expected call of MarkOutboxEventFailed.
+func (mr *MockQuerierMockRecorder) MarkOutboxEventFailed(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkOutboxEventFailed", reflect.TypeOf((*MockQuerier)(nil).MarkOutboxEventFailed), ctx, arg)
+}
+
+// MarkOutboxEventPublished mocks base method.
+func (m *MockQuerier) MarkOutboxEventPublished(ctx context.Context, arg db.MarkOutboxEventPublishedParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkOutboxEventPublished", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkOutboxEventPublished indicates an expected call of MarkOutboxEventPublished.
+func (mr *MockQuerierMockRecorder) MarkOutboxEventPublished(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkOutboxEventPublished", reflect.TypeOf((*MockQuerier)(nil).MarkOutboxEventPublished), ctx, arg)
+}
+
+// MigrationMessage mocks base method.
+func (m *MockQuerier) MigrationMessage(ctx context.Context) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MigrationMessage", ctx)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MigrationMessage indicates an expected call of MigrationMessage.
+func (mr *MockQuerierMockRecorder) MigrationMessage(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrationMessage", reflect.TypeOf((*MockQuerier)(nil).MigrationMessage), ctx)
+}
+
+// RefreshSession mocks base method.
+func (m *MockQuerier) RefreshSession(ctx context.Context, arg db.RefreshSessionParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshSession", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshSession indicates an expected call of RefreshSession.
+func (mr *MockQuerierMockRecorder) RefreshSession(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshSession", reflect.TypeOf((*MockQuerier)(nil).RefreshSession), ctx, arg)
+}
+
+// ReleaseMailMessage mocks base method.
+func (m *MockQuerier) ReleaseMailMessage(ctx context.Context, messageID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseMailMessage", ctx, messageID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseMailMessage indicates an expected call of ReleaseMailMessage.
+func (mr *MockQuerierMockRecorder) ReleaseMailMessage(ctx, messageID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseMailMessage", reflect.TypeOf((*MockQuerier)(nil).ReleaseMailMessage), ctx, messageID)
+}
+
+// RememberDevice mocks base method.
+func (m *MockQuerier) RememberDevice(ctx context.Context, arg db.RememberDeviceParams) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RememberDevice", ctx, arg)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RememberDevice indicates an expected call of RememberDevice.
+func (mr *MockQuerierMockRecorder) RememberDevice(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RememberDevice", reflect.TypeOf((*MockQuerier)(nil).RememberDevice), ctx, arg)
+}
+
+// RemoveOrganizationMember mocks base method.
+func (m *MockQuerier) RemoveOrganizationMember(ctx context.Context, arg db.RemoveOrganizationMemberParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveOrganizationMember", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveOrganizationMember indicates an expected call of RemoveOrganizationMember.
+func (mr *MockQuerierMockRecorder) RemoveOrganizationMember(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveOrganizationMember", reflect.TypeOf((*MockQuerier)(nil).RemoveOrganizationMember), ctx, arg)
+}
+
+// RequestDataExport mocks base method.
+func (m *MockQuerier) RequestDataExport(ctx context.Context, arg db.RequestDataExportParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestDataExport", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequestDataExport indicates an expected call of RequestDataExport.
+func (mr *MockQuerierMockRecorder) RequestDataExport(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestDataExport", reflect.TypeOf((*MockQuerier)(nil).RequestDataExport), ctx, arg)
+}
+
+// RestoreUser mocks base method.
+func (m *MockQuerier) RestoreUser(ctx context.Context, arg db.RestoreUserParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUser", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreUser indicates an expected call of RestoreUser.
+func (mr *MockQuerierMockRecorder) RestoreUser(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUser", reflect.TypeOf((*MockQuerier)(nil).RestoreUser), ctx, arg)
+}
+
+// RetryJob mocks base method.
+func (m *MockQuerier) RetryJob(ctx context.Context, arg db.RetryJobParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryJob", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RetryJob indicates an expected call of RetryJob.
+func (mr *MockQuerierMockRecorder) RetryJob(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryJob", reflect.TypeOf((*MockQuerier)(nil).RetryJob), ctx, arg)
+}
+
+// ReviveJob mocks base method.
+func (m *MockQuerier) ReviveJob(ctx context.Context, arg db.ReviveJobParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReviveJob", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReviveJob indicates an expected call of ReviveJob.
+func (mr *MockQuerierMockRecorder) ReviveJob(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReviveJob", reflect.TypeOf((*MockQuerier)(nil).ReviveJob), ctx, arg)
+}
+
+// RevokeInvitation mocks base method.
+func (m *MockQuerier) RevokeInvitation(ctx context.Context, arg db.RevokeInvitationParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeInvitation", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeInvitation indicates an expected call of RevokeInvitation.
+func (mr *MockQuerierMockRecorder) RevokeInvitation(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeInvitation", reflect.TypeOf((*MockQuerier)(nil).RevokeInvitation), ctx, arg)
+}
+
+// SaveDataExport mocks base method.
+func (m *MockQuerier) SaveDataExport(ctx context.Context, arg db.SaveDataExportParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveDataExport", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveDataExport indicates an expected call of SaveDataExport.
+func (mr *MockQuerierMockRecorder) SaveDataExport(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveDataExport", reflect.TypeOf((*MockQuerier)(nil).SaveDataExport), ctx, arg)
+}
+
+// SetUserLocale mocks base method.
+func (m *MockQuerier) SetUserLocale(ctx context.Context, arg db.SetUserLocaleParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserLocale", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserLocale indicates an expected call of SetUserLocale.
+func (mr *MockQuerierMockRecorder) SetUserLocale(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserLocale", reflect.TypeOf((*MockQuerier)(nil).SetUserLocale), ctx, arg)
+}
+
+// TakeOverIdempotencyKey mocks base method.
+func (m *MockQuerier) TakeOverIdempotencyKey(ctx context.Context, arg db.TakeOverIdempotencyKeyParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeOverIdempotencyKey", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeOverIdempotencyKey indicates an expected call of TakeOverIdempotencyKey.
+func (mr *MockQuerierMockRecorder) TakeOverIdempotencyKey(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeOverIdempotencyKey", reflect.TypeOf((*MockQuerier)(nil).TakeOverIdempotencyKey), ctx, arg)
+}
+
+// TouchSession mocks base method.
+func (m *MockQuerier) TouchSession(ctx context.Context, arg db.TouchSessionParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchSession", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchSession indicates an expected call of TouchSession.
+func (mr *MockQuerierMockRecorder) TouchSession(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchSession", reflect.TypeOf((*MockQuerier)(nil).TouchSession), ctx, arg)
+}
+
+// UpdateOrganizationMemberRole mocks base method.
+func (m *MockQuerier) UpdateOrganizationMemberRole(ctx context.Context, arg db.UpdateOrganizationMemberRoleParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOrganizationMemberRole", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateOrganizationMemberRole indicates an expected call of UpdateOrganizationMemberRole.
+func (mr *MockQuerierMockRecorder) UpdateOrganizationMemberRole(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrganizationMemberRole", reflect.TypeOf((*MockQuerier)(nil).UpdateOrganizationMemberRole), ctx, arg)
+}
+
+// UpdateRateLimit mocks base method.
+func (m *MockQuerier) UpdateRateLimit(ctx context.Context, arg db.UpdateRateLimitParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRateLimit", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRateLimit indicates an expected call of UpdateRateLimit.
+func (mr *MockQuerierMockRecorder) UpdateRateLimit(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRateLimit", reflect.TypeOf((*MockQuerier)(nil).UpdateRateLimit), ctx, arg)
+}
+
+// UpdateUserPassword mocks base method.
+func (m *MockQuerier) UpdateUserPassword(ctx context.Context, arg db.UpdateUserPasswordParams) (int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserPassword", ctx, arg)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserPassword indicates an expected call of UpdateUserPassword.
+func (mr *MockQuerierMockRecorder) UpdateUserPassword(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserPassword", reflect.TypeOf((*MockQuerier)(nil).UpdateUserPassword), ctx, arg)
+}
+
+// UpdateUserProfile mocks base method.
+func (m *MockQuerier) UpdateUserProfile(ctx context.Context, arg db.UpdateUserProfileParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserProfile", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserProfile indicates an expected call of UpdateUserProfile.
+func (mr *MockQuerierMockRecorder) UpdateUserProfile(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserProfile", reflect.TypeOf((*MockQuerier)(nil).UpdateUserProfile), ctx, arg)
 }
@@ -4,22 +4,136 @@
 
 package db
 
-import ()
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
 
 type InitialMigration struct {
 	Message string
 }
 
+type ScratchDataExport struct {
+	UserID      int32
+	RequestedAt time.Time
+	ReadyAt     sql.NullTime
+	Archive     []byte
+}
+
+type ScratchIdempotencyKey struct {
+	Key            string
+	Operation      string
+	Fingerprint    string
+	LockedAt       time.Time
+	ExpiresAt      time.Time
+	StatusCode     sql.NullInt32
+	ResponseHeader []byte
+	ResponseBody   []byte
+}
+
+type ScratchInvitation struct {
+	ID             int32
+	OrganizationID int32
+	Email          string
+	Role           string
+	CodeHash       string
+	CreatedAt      time.Time
+	ExpiresAt      time.Time
+	AcceptedAt     sql.NullTime
+	InvitedBy      sql.NullInt32
+	AcceptedBy     sql.NullInt32
+	RevokedAt      sql.NullTime
+}
+
+type ScratchJob struct {
+	ID          int64
+	Kind        string
+	Payload     json.RawMessage
+	UniqueKey   sql.NullString
+	State       string
+	Attempts    int32
+	MaxAttempts int32
+	RunAt       time.Time
+	LockedAt    sql.NullTime
+	LockedBy    sql.NullString
+	LastError   sql.NullString
+	CreatedAt   time.Time
+	FinishedAt  sql.NullTime
+}
+
+type ScratchMailSent struct {
+	MessageID string
+	SentAt    time.Time
+}
+
+type ScratchOrganization struct {
+	ID        int32
+	Slug      string
+	Name      string
+	CreatedAt time.Time
+}
+
+type ScratchOrganizationMember struct {
+	OrganizationID int32
+	UserID         int32
+	Role           string
+	JoinedAt       time.Time
+}
+
+type ScratchOutbox struct {
+	ID            int64
+	AggregateType string
+	AggregateID   string
+	EventType     string
+	Payload       json.RawMessage
+	OccurredAt    time.Time
+	Attempts      int32
+	NextAttemptAt time.Time
+	LastError     sql.NullString
+	PublishedAt   sql.NullTime
+	DeadAt        sql.NullTime
+}
+
+type ScratchRateLimit struct {
+	Key string
+	Tat time.Time
+}
+
 type ScratchSession struct {
-	ID           int32
-	UserID       int32
-	RefreshToken string
-	LoginDate    string
+	ID               int32
+	UserID           int32
+	RefreshTokenHash sql.NullString
+	TokenHash        sql.NullString
+	CsrfToken        sql.NullString
+	ExpiresAt        sql.NullTime
+	CreatedAt        time.Time
+	LastSeenAt       time.Time
+	UserAgent        string
+	Ip               string
+	DeviceName       string
 }
 
 type ScratchUser struct {
-	ID       int32
-	Name     string
-	Email    string
-	Password string
+	ID         int32
+	Name       string
+	Email      string
+	Password   string
+	DisabledAt sql.NullTime
+	Locale     sql.NullString
+	DeletedAt  sql.NullTime
+}
+
+type ScratchUserDevice struct {
+	UserID      int32
+	Fingerprint string
+	Name        string
+	FirstSeenAt time.Time
+	LastSeenAt  time.Time
+}
+
+type ScratchUserRole struct {
+	UserID    int32
+	Role      string
+	GrantedAt time.Time
 }
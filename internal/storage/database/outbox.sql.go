@@ -0,0 +1,162 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.20.0
+// source: outbox.sql
+
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+const claimOutboxEvents = `-- name: ClaimOutboxEvents :many
+UPDATE scratch.outbox SET next_attempt_at = $1::timestamptz
+WHERE id IN (
+    SELECT o.id FROM scratch.outbox o
+    WHERE o.published_at IS NULL AND o.dead_at IS NULL AND o.next_attempt_at <= $2::timestamptz
+      AND NOT EXISTS (
+        SELECT 1 FROM scratch.outbox p
+        WHERE p.aggregate_type = o.aggregate_type AND p.aggregate_id = o.aggregate_id
+          AND p.published_at IS NULL AND p.dead_at IS NULL AND p.id < o.id
+      )
+    ORDER BY o.id
+    LIMIT $3
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, aggregate_type, aggregate_id, event_type, payload, occurred_at, attempts
+`
+
+type ClaimOutboxEventsParams struct {
+	LockedUntil time.Time
+	Now         time.Time
+	BatchSize   int32
+}
+
+type ClaimOutboxEventsRow struct {
+	ID            int64
+	AggregateType string
+	AggregateID   string
+	EventType     string
+	Payload       json.RawMessage
+	OccurredAt    time.Time
+	Attempts      int32
+}
+
+// Claims the due events until locked_until, the events are published outside
+// of the transaction. Only the oldest pending event of every aggregate is
+// due, so the events of an aggregate are published in order even by several
+// relays.
+func (q *Queries) ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) ([]ClaimOutboxEventsRow, error) {
+	rows, err := q.db.Query(ctx, claimOutboxEvents, arg.LockedUntil, arg.Now, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimOutboxEventsRow
+	for rows.Next() {
+		var i ClaimOutboxEventsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AggregateType,
+			&i.AggregateID,
+			&i.EventType,
+			&i.Payload,
+			&i.OccurredAt,
+			&i.Attempts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deletePublishedOutboxEvents = `-- name: DeletePublishedOutboxEvents :execrows
+DELETE FROM scratch.outbox WHERE published_at < $1::timestamptz
+`
+
+func (q *Queries) DeletePublishedOutboxEvents(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.db.Exec(ctx, deletePublishedOutboxEvents, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const insertOutboxEvent = `-- name: InsertOutboxEvent :exec
+INSERT INTO scratch.outbox (aggregate_type, aggregate_id, event_type, payload, occurred_at, next_attempt_at)
+VALUES ($1, $2, $3, $4, $5, $5)
+`
+
+type InsertOutboxEventParams struct {
+	AggregateType string
+	AggregateID   string
+	EventType     string
+	Payload       json.RawMessage
+	OccurredAt    time.Time
+}
+
+func (q *Queries) InsertOutboxEvent(ctx context.Context, arg InsertOutboxEventParams) error {
+	_, err := q.db.Exec(ctx, insertOutboxEvent,
+		arg.AggregateType,
+		arg.AggregateID,
+		arg.EventType,
+		arg.Payload,
+		arg.OccurredAt,
+	)
+	return err
+}
+
+const killOutboxEvent = `-- name: KillOutboxEvent :exec
+UPDATE scratch.outbox
+SET attempts = attempts + 1, dead_at = $1::timestamptz, last_error = $2::text
+WHERE id = $3 AND published_at IS NULL
+`
+
+type KillOutboxEventParams struct {
+	Now       time.Time
+	LastError string
+	ID        int64
+}
+
+func (q *Queries) KillOutboxEvent(ctx context.Context, arg KillOutboxEventParams) error {
+	_, err := q.db.Exec(ctx, killOutboxEvent, arg.Now, arg.LastError, arg.ID)
+	return err
+}
+
+const markOutboxEventFailed = `-- name: MarkOutboxEventFailed :exec
+UPDATE scratch.outbox
+SET attempts = attempts + 1, next_attempt_at = $1, last_error = $2::text
+WHERE id = $3 AND published_at IS NULL
+`
+
+type MarkOutboxEventFailedParams struct {
+	NextAttemptAt time.Time
+	LastError     string
+	ID            int64
+}
+
+func (q *Queries) MarkOutboxEventFailed(ctx context.Context, arg MarkOutboxEventFailedParams) error {
+	_, err := q.db.Exec(ctx, markOutboxEventFailed, arg.NextAttemptAt, arg.LastError, arg.ID)
+	return err
+}
+
+const markOutboxEventPublished = `-- name: MarkOutboxEventPublished :exec
+UPDATE scratch.outbox SET published_at = $1::timestamptz, attempts = attempts + 1, last_error = NULL
+WHERE id = $2 AND published_at IS NULL
+`
+
+type MarkOutboxEventPublishedParams struct {
+	Now time.Time
+	ID  int64
+}
+
+func (q *Queries) MarkOutboxEventPublished(ctx context.Context, arg MarkOutboxEventPublishedParams) error {
+	_, err := q.db.Exec(ctx, markOutboxEventPublished, arg.Now, arg.ID)
+	return err
+}
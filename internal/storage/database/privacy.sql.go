@@ -0,0 +1,385 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.20.0
+// source: privacy.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+const deleteDataExport = `-- name: DeleteDataExport :execrows
+DELETE FROM scratch.data_export WHERE user_id = $1
+`
+
+func (q *Queries) DeleteDataExport(ctx context.Context, userID int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteDataExport, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteExpiredDataExports = `-- name: DeleteExpiredDataExports :execrows
+DELETE FROM scratch.data_export WHERE ready_at < $1::timestamptz
+`
+
+func (q *Queries) DeleteExpiredDataExports(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredDataExports, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteUserDevices = `-- name: DeleteUserDevices :execrows
+DELETE FROM scratch.user_device WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserDevices(ctx context.Context, userID int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUserDevices, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteUserEvents = `-- name: DeleteUserEvents :execrows
+DELETE FROM scratch.outbox WHERE aggregate_type = 'user' AND aggregate_id = $1
+`
+
+func (q *Queries) DeleteUserEvents(ctx context.Context, aggregateID string) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUserEvents, aggregateID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteUserInvitations = `-- name: DeleteUserInvitations :execrows
+DELETE FROM scratch.invitation
+WHERE lower(email) = (SELECT lower(u.email) FROM scratch.user u WHERE u.id = $1)
+`
+
+func (q *Queries) DeleteUserInvitations(ctx context.Context, userID int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUserInvitations, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteUserMemberships = `-- name: DeleteUserMemberships :execrows
+DELETE FROM scratch.organization_member WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserMemberships(ctx context.Context, userID int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUserMemberships, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteUserRoles = `-- name: DeleteUserRoles :execrows
+DELETE FROM scratch.user_role WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserRoles(ctx context.Context, userID int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUserRoles, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const eraseUser = `-- name: EraseUser :execrows
+DELETE FROM scratch.user WHERE id = $1
+`
+
+func (q *Queries) EraseUser(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, eraseUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getDataExport = `-- name: GetDataExport :one
+SELECT user_id, requested_at, ready_at FROM scratch.data_export WHERE user_id = $1
+`
+
+type GetDataExportRow struct {
+	UserID      int32
+	RequestedAt time.Time
+	ReadyAt     sql.NullTime
+}
+
+func (q *Queries) GetDataExport(ctx context.Context, userID int32) (GetDataExportRow, error) {
+	row := q.db.QueryRow(ctx, getDataExport, userID)
+	var i GetDataExportRow
+	err := row.Scan(&i.UserID, &i.RequestedAt, &i.ReadyAt)
+	return i, err
+}
+
+const getDataExportArchive = `-- name: GetDataExportArchive :one
+SELECT archive FROM scratch.data_export WHERE user_id = $1 AND ready_at > $2::timestamptz
+`
+
+type GetDataExportArchiveParams struct {
+	UserID     int32
+	ReadyAfter time.Time
+}
+
+func (q *Queries) GetDataExportArchive(ctx context.Context, arg GetDataExportArchiveParams) ([]byte, error) {
+	row := q.db.QueryRow(ctx, getDataExportArchive, arg.UserID, arg.ReadyAfter)
+	var archive []byte
+	err := row.Scan(&archive)
+	return archive, err
+}
+
+const listUserDevices = `-- name: ListUserDevices :many
+SELECT user_id, fingerprint, name, first_seen_at, last_seen_at FROM scratch.user_device WHERE user_id = $1 ORDER BY first_seen_at, fingerprint
+`
+
+func (q *Queries) ListUserDevices(ctx context.Context, userID int32) ([]ScratchUserDevice, error) {
+	rows, err := q.db.Query(ctx, listUserDevices, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ScratchUserDevice
+	for rows.Next() {
+		var i ScratchUserDevice
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Fingerprint,
+			&i.Name,
+			&i.FirstSeenAt,
+			&i.LastSeenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserEvents = `-- name: ListUserEvents :many
+SELECT id, event_type, payload, occurred_at FROM scratch.outbox
+WHERE aggregate_type = 'user' AND aggregate_id = $1 ORDER BY id
+`
+
+type ListUserEventsRow struct {
+	ID         int64
+	EventType  string
+	Payload    json.RawMessage
+	OccurredAt time.Time
+}
+
+func (q *Queries) ListUserEvents(ctx context.Context, aggregateID string) ([]ListUserEventsRow, error) {
+	rows, err := q.db.Query(ctx, listUserEvents, aggregateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserEventsRow
+	for rows.Next() {
+		var i ListUserEventsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventType,
+			&i.Payload,
+			&i.OccurredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserInvitations = `-- name: ListUserInvitations :many
+SELECT i.id, o.name AS organization, i.role, i.created_at, i.expires_at, i.accepted_at, i.revoked_at
+FROM scratch.invitation i JOIN scratch.organization o ON o.id = i.organization_id
+WHERE lower(i.email) = (SELECT lower(u.email) FROM scratch.user u WHERE u.id = $1) ORDER BY i.id
+`
+
+type ListUserInvitationsRow struct {
+	ID           int32
+	Organization string
+	Role         string
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+	AcceptedAt   sql.NullTime
+	RevokedAt    sql.NullTime
+}
+
+// The invitations sent to the email of the user.
+func (q *Queries) ListUserInvitations(ctx context.Context, userID int32) ([]ListUserInvitationsRow, error) {
+	rows, err := q.db.Query(ctx, listUserInvitations, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserInvitationsRow
+	for rows.Next() {
+		var i ListUserInvitationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Organization,
+			&i.Role,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.AcceptedAt,
+			&i.RevokedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserRoleGrants = `-- name: ListUserRoleGrants :many
+SELECT user_id, role, granted_at FROM scratch.user_role WHERE user_id = $1 ORDER BY role
+`
+
+func (q *Queries) ListUserRoleGrants(ctx context.Context, userID int32) ([]ScratchUserRole, error) {
+	rows, err := q.db.Query(ctx, listUserRoleGrants, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ScratchUserRole
+	for rows.Next() {
+		var i ScratchUserRole
+		if err := rows.Scan(&i.UserID, &i.Role, &i.GrantedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserSessions = `-- name: ListUserSessions :many
+SELECT id, created_at, last_seen_at, expires_at, user_agent, ip, device_name
+FROM scratch.session WHERE user_id = $1 ORDER BY id
+`
+
+type ListUserSessionsRow struct {
+	ID         int32
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  sql.NullTime
+	UserAgent  string
+	Ip         string
+	DeviceName string
+}
+
+func (q *Queries) ListUserSessions(ctx context.Context, userID int32) ([]ListUserSessionsRow, error) {
+	rows, err := q.db.Query(ctx, listUserSessions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserSessionsRow
+	for rows.Next() {
+		var i ListUserSessionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.LastSeenAt,
+			&i.ExpiresAt,
+			&i.UserAgent,
+			&i.Ip,
+			&i.DeviceName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersDeletedBefore = `-- name: ListUsersDeletedBefore :many
+SELECT id FROM scratch.user WHERE deleted_at < $1::timestamptz ORDER BY id
+`
+
+func (q *Queries) ListUsersDeletedBefore(ctx context.Context, deletedBefore time.Time) ([]int32, error) {
+	rows, err := q.db.Query(ctx, listUsersDeletedBefore, deletedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const requestDataExport = `-- name: RequestDataExport :execrows
+INSERT INTO scratch.data_export (user_id, requested_at) VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET requested_at = EXCLUDED.requested_at, ready_at = NULL, archive = NULL
+WHERE scratch.data_export.ready_at IS NOT NULL
+`
+
+type RequestDataExportParams struct {
+	UserID int32
+	Now    time.Time
+}
+
+// An export being built is left alone, a finished one is replaced.
+func (q *Queries) RequestDataExport(ctx context.Context, arg RequestDataExportParams) (int64, error) {
+	result, err := q.db.Exec(ctx, requestDataExport, arg.UserID, arg.Now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const saveDataExport = `-- name: SaveDataExport :execrows
+UPDATE scratch.data_export SET archive = $1, ready_at = $2::timestamptz
+WHERE user_id = $3 AND ready_at IS NULL
+`
+
+type SaveDataExportParams struct {
+	Archive []byte
+	Now     time.Time
+	UserID  int32
+}
+
+func (q *Queries) SaveDataExport(ctx context.Context, arg SaveDataExportParams) (int64, error) {
+	result, err := q.db.Exec(ctx, saveDataExport, arg.Archive, arg.Now, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,62 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.20.0
+// source: ratelimit.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const deleteExpiredRateLimits = `-- name: DeleteExpiredRateLimits :execrows
+DELETE FROM scratch.rate_limit WHERE tat < $1
+`
+
+func (q *Queries) DeleteExpiredRateLimits(ctx context.Context, tat time.Time) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredRateLimits, tat)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const ensureRateLimit = `-- name: EnsureRateLimit :exec
+INSERT INTO scratch.rate_limit (key, tat) VALUES ($1, $2) ON CONFLICT (key) DO NOTHING
+`
+
+type EnsureRateLimitParams struct {
+	Key string
+	Tat time.Time
+}
+
+func (q *Queries) EnsureRateLimit(ctx context.Context, arg EnsureRateLimitParams) error {
+	_, err := q.db.Exec(ctx, ensureRateLimit, arg.Key, arg.Tat)
+	return err
+}
+
+const lockRateLimit = `-- name: LockRateLimit :one
+SELECT tat FROM scratch.rate_limit WHERE key = $1 FOR UPDATE
+`
+
+func (q *Queries) LockRateLimit(ctx context.Context, key string) (time.Time, error) {
+	row := q.db.QueryRow(ctx, lockRateLimit, key)
+	var tat time.Time
+	err := row.Scan(&tat)
+	return tat, err
+}
+
+const updateRateLimit = `-- name: UpdateRateLimit :exec
+UPDATE scratch.rate_limit SET tat = $2 WHERE key = $1
+`
+
+type UpdateRateLimitParams struct {
+	Key string
+	Tat time.Time
+}
+
+func (q *Queries) UpdateRateLimit(ctx context.Context, arg UpdateRateLimitParams) error {
+	_, err := q.db.Exec(ctx, updateRateLimit, arg.Key, arg.Tat)
+	return err
+}
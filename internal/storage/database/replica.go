@@ -0,0 +1,182 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// replicaQueries are the queries a Router may send to a replica. They only
+// read, and a result a little behind the primary does no harm.
+var replicaQueries = map[string]bool{
+	"GetUserByEmail":   true,
+	"GetUserByID":      true,
+	"ListUserRoles":    true,
+	"ListSessions":     true,
+	"CountUserDevices": true,
+}
+
+// lagQuery reports whether a replica streams from its primary and measures
+// how far it is behind. A replica whose WAL receiver is not streaming falls
+// behind unnoticed, as it has replayed everything it received. A replica that
+// replayed everything it received while streaming is not behind, however old
+// its last transaction, and a server that is not in recovery is a primary.
+// Only roles with pg_read_all_stats see the status of the WAL receiver.
+const lagQuery = `SELECT
+	NOT pg_is_in_recovery() OR EXISTS (SELECT FROM pg_stat_wal_receiver WHERE status = 'streaming'),
+	CASE
+		WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END::float8`
+
+type RouterOptions struct {
+	// MaxLag is how far behind the primary a replica may be and still serve
+	// reads.
+	MaxLag time.Duration
+	// CheckInterval is how often the health and lag of the replicas are
+	// checked.
+	CheckInterval time.Duration
+}
+
+// Router is a DBTX sending the queries of replicaQueries to a healthy replica,
+// in turns, and everything else to the primary. A replica serves reads once a
+// check found it reachable and within MaxLag, and the primary serves them
+// while none does.
+//
+// A context returned by TrackWrites reads from the primary once a query
+// through it wrote, so a request sees its own writes.
+type Router struct {
+	primary  DBTX
+	replicas []*replica
+	options  RouterOptions
+	log      slog.Logger
+	next     uint32
+}
+
+type replica struct {
+	name    string
+	db      DBTX
+	healthy atomic.Bool
+}
+
+// NewRouter returns a Router over the primary and the replicas, named by their
+// position in the logs. The replicas serve no reads until the first Check.
+func NewRouter(primary DBTX, replicas []DBTX, options RouterOptions, log slog.Logger) *Router {
+	r := &Router{primary: primary, options: options, log: log}
+	for i, db := range replicas {
+		r.replicas = append(r.replicas, &replica{name: fmt.Sprintf("replica %d", i+1), db: db})
+	}
+	return r
+}
+
+func (r *Router) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	markWrite(ctx)
+	return r.primary.Exec(ctx, sql, args...)
+}
+
+func (r *Router) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return r.route(ctx, sql).Query(ctx, sql, args...)
+}
+
+func (r *Router) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return r.route(ctx, sql).QueryRow(ctx, sql, args...)
+}
+
+// route returns where the query goes, a healthy replica when it only reads
+// and ctx has not written yet.
+func (r *Router) route(ctx context.Context, sql string) DBTX {
+	if !replicaQueries[queryName(sql)] {
+		markWrite(ctx)
+		return r.primary
+	}
+	if wrote(ctx) {
+		return r.primary
+	}
+	start := atomic.AddUint32(&r.next, 1)
+	for i := range r.replicas {
+		rep := r.replicas[(int(start)+i)%len(r.replicas)]
+		if rep.healthy.Load() {
+			return rep.db
+		}
+	}
+	return r.primary
+}
+
+// Check measures the lag of every replica and takes the unreachable ones,
+// those not streaming from the primary and those lagging more than MaxLag out
+// of rotation.
+func (r *Router) Check(ctx context.Context) {
+	for _, rep := range r.replicas {
+		var streaming bool
+		var seconds float64
+		err := rep.db.QueryRow(ctx, lagQuery).Scan(&streaming, &seconds)
+		lag := time.Duration(seconds * float64(time.Second))
+
+		healthy := err == nil && streaming && lag <= r.options.MaxLag
+		if healthy == rep.healthy.Swap(healthy) {
+			continue
+		}
+		switch {
+		case healthy:
+			r.log.Info("replica serves reads", "replica", rep.name, "lag", lag)
+		case err != nil:
+			r.log.Warn("replica unreachable, reading from the primary", "replica", rep.name, "err", err)
+		case !streaming:
+			r.log.Warn("replica does not stream from its primary, reading from the primary", "replica", rep.name)
+		default:
+			r.log.Warn("replica lags behind, reading from the primary", "replica", rep.name, "lag", lag)
+		}
+	}
+}
+
+// Run checks the replicas right away and then every CheckInterval until ctx
+// is done.
+func (r *Router) Run(ctx context.Context) {
+	r.Check(ctx)
+	ticker := time.NewTicker(r.options.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Check(ctx)
+		}
+	}
+}
+
+// queryName returns the name sqlc gives a query in its first line.
+func queryName(sql string) string {
+	const prefix = "-- name: "
+	if !strings.HasPrefix(sql, prefix) {
+		return ""
+	}
+	name, _, _ := strings.Cut(sql[len(prefix):], " ")
+	return name
+}
+
+type writesKey struct{}
+
+// TrackWrites returns a context remembering whether a query through it wrote,
+// in which reads go to the primary from then on.
+func TrackWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, writesKey{}, new(atomic.Bool))
+}
+
+func markWrite(ctx context.Context) {
+	if w, ok := ctx.Value(writesKey{}).(*atomic.Bool); ok {
+		w.Store(true)
+	}
+}
+
+func wrote(ctx context.Context) bool {
+	w, ok := ctx.Value(writesKey{}).(*atomic.Bool)
+	return ok && w.Load()
+}
@@ -0,0 +1,165 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDB records the queries it receives and reports lag as a replica.
+type fakeDB struct {
+	lag          time.Duration
+	disconnected bool
+	err          error
+	queries      []string
+}
+
+func (f *fakeDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	f.queries = append(f.queries, queryName(sql))
+	return pgconn.CommandTag{}, nil
+}
+
+func (f *fakeDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	f.queries = append(f.queries, queryName(sql))
+	return nil, errors.New("no rows here")
+}
+
+func (f *fakeDB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	if sql == lagQuery {
+		return fakeRow{lag: f.lag, streaming: !f.disconnected, err: f.err}
+	}
+	f.queries = append(f.queries, queryName(sql))
+	return fakeRow{err: ErrNoRows}
+}
+
+type fakeRow struct {
+	lag       time.Duration
+	streaming bool
+	err       error
+}
+
+func (r fakeRow) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	*dest[0].(*bool) = r.streaming
+	*dest[1].(*float64) = r.lag.Seconds()
+	return nil
+}
+
+func TestRouter(t *testing.T) {
+	tests := []struct {
+		name        string
+		replicas    []*fakeDB
+		query       func(ctx context.Context, q *Queries)
+		wantPrimary []string
+		wantReplica [][]string
+	}{
+		{
+			name:     "read from the replica",
+			replicas: []*fakeDB{{lag: 100 * time.Millisecond}},
+			query: func(ctx context.Context, q *Queries) {
+				_, _ = q.GetUserByEmail(ctx, "norbi@example.com")
+			},
+			wantReplica: [][]string{{"GetUserByEmail"}},
+		},
+		{
+			name:     "reads take turns",
+			replicas: []*fakeDB{{}, {}},
+			query: func(ctx context.Context, q *Queries) {
+				_, _ = q.GetUserByEmail(ctx, "norbi@example.com")
+				_, _ = q.GetUserByID(ctx, 1)
+			},
+			wantReplica: [][]string{{"GetUserByID"}, {"GetUserByEmail"}},
+		},
+		{
+			name:     "writes go to the primary",
+			replicas: []*fakeDB{{}},
+			query: func(ctx context.Context, q *Queries) {
+				_, _ = q.CreateUser(ctx, CreateUserParams{Email: "norbi@example.com"})
+				_ = q.CleanUserTable(ctx)
+			},
+			wantPrimary: []string{"CreateUser", "CleanUserTable"},
+			wantReplica: [][]string{nil},
+		},
+		{
+			name:     "reads after a write go to the primary",
+			replicas: []*fakeDB{{}},
+			query: func(ctx context.Context, q *Queries) {
+				_, _ = q.GetUserByEmail(ctx, "norbi@example.com")
+				_, _ = q.SetUserLocale(ctx, SetUserLocaleParams{Email: "norbi@example.com", Locale: sql.NullString{String: "hu", Valid: true}})
+				_, _ = q.GetUserByEmail(ctx, "norbi@example.com")
+			},
+			wantPrimary: []string{"SetUserLocale", "GetUserByEmail"},
+			wantReplica: [][]string{{"GetUserByEmail"}},
+		},
+		{
+			name:     "lagging replica",
+			replicas: []*fakeDB{{lag: 2 * time.Second}, {}},
+			query: func(ctx context.Context, q *Queries) {
+				_, _ = q.GetUserByEmail(ctx, "norbi@example.com")
+				_, _ = q.GetUserByID(ctx, 1)
+			},
+			wantReplica: [][]string{nil, {"GetUserByEmail", "GetUserByID"}},
+		},
+		{
+			name:     "disconnected replica",
+			replicas: []*fakeDB{{disconnected: true}, {}},
+			query: func(ctx context.Context, q *Queries) {
+				_, _ = q.GetUserByEmail(ctx, "norbi@example.com")
+				_, _ = q.GetUserByID(ctx, 1)
+			},
+			wantReplica: [][]string{nil, {"GetUserByEmail", "GetUserByID"}},
+		},
+		{
+			name:     "no healthy replica",
+			replicas: []*fakeDB{{err: errors.New("connection refused")}},
+			query: func(ctx context.Context, q *Queries) {
+				_, _ = q.GetUserByEmail(ctx, "norbi@example.com")
+			},
+			wantPrimary: []string{"GetUserByEmail"},
+			wantReplica: [][]string{nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := &fakeDB{}
+			var replicas []DBTX
+			for _, r := range tt.replicas {
+				replicas = append(replicas, r)
+			}
+			router := NewRouter(primary, replicas, RouterOptions{MaxLag: time.Second}, *slog.Default())
+			ctx := TrackWrites(context.Background())
+			router.Check(ctx)
+
+			tt.query(ctx, New(router))
+
+			assert.Equal(t, tt.wantPrimary, primary.queries)
+			for i, r := range tt.replicas {
+				assert.Equal(t, tt.wantReplica[i], r.queries, "replica %d", i+1)
+			}
+		})
+	}
+}
+
+func TestRouter_RecoveredReplica(t *testing.T) {
+	primary, replica := &fakeDB{}, &fakeDB{err: errors.New("connection refused")}
+	router := NewRouter(primary, []DBTX{replica}, RouterOptions{MaxLag: time.Second}, *slog.Default())
+	q := New(router)
+	ctx := context.Background()
+
+	_, _ = q.GetUserByEmail(ctx, "norbi@example.com")
+	assert.Equal(t, []string{"GetUserByEmail"}, primary.queries, "not checked yet")
+
+	replica.err = nil
+	router.Check(ctx)
+	_, _ = q.GetUserByEmail(ctx, "norbi@example.com")
+	assert.Equal(t, []string{"GetUserByEmail"}, replica.queries)
+}
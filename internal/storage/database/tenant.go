@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"strconv"
+)
+
+// tenantSetting is the setting the row level security policies compare the
+// organization_id of a row with.
+const tenantSetting = "app.tenant_id"
+
+// allTenants is the value of tenantSetting that shows the rows of every
+// organization.
+const allTenants = "*"
+
+type tenantKey struct{}
+
+// tenantScope is what WithTenant and WithAllTenants store in a context.
+type tenantScope struct {
+	organizationID int32
+	all            bool
+}
+
+// WithTenant returns ctx scoped to the organization: with
+// TxOptions.RowLevelSecurity the transactions started with it see the rows of
+// no other organization.
+func WithTenant(ctx context.Context, organizationID int32) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantScope{organizationID: organizationID})
+}
+
+// WithAllTenants returns ctx whose transactions see the rows of every
+// organization, for the work of a user across them, like accepting an
+// invitation before its organization is known. With
+// TxOptions.RowLevelSecurity a transaction started with neither this nor
+// WithTenant sees no row of a tenant table.
+func WithAllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantScope{all: true})
+}
+
+// TenantFrom returns the organization stored by WithTenant.
+func TenantFrom(ctx context.Context) (int32, bool) {
+	scope, ok := ctx.Value(tenantKey{}).(tenantScope)
+	if !ok || scope.all {
+		return 0, false
+	}
+	return scope.organizationID, true
+}
+
+// tenantSettingValue is the value of tenantSetting for a transaction started
+// with ctx, empty when it is not scoped, which the policies let see nothing.
+func tenantSettingValue(ctx context.Context) string {
+	scope, ok := ctx.Value(tenantKey{}).(tenantScope)
+	switch {
+	case !ok:
+		return ""
+	case scope.all:
+		return allTenants
+	default:
+		return strconv.Itoa(int(scope.organizationID))
+	}
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Transactor runs a unit of work in a database transaction.
+type Transactor interface {
+	// InTx calls fn with queries bound to a new transaction, which is
+	// committed when fn returns nil and rolled back otherwise. fn may be
+	// called again when the transaction is retried, so it must not have side
+	// effects outside of q.
+	InTx(ctx context.Context, fn func(q Querier) error) error
+}
+
+// TxOptions configures the transactions of a TxManager.
+type TxOptions struct {
+	// Isolation is the isolation level, the default one of the database when
+	// empty.
+	Isolation pgx.TxIsoLevel
+	// MaxRetries is how many times a transaction failing on a serialization
+	// failure or a deadlock is retried.
+	MaxRetries int
+	// RowLevelSecurity sets app.tenant_id in every transaction to the
+	// organization of its context, which the policies of the tenant tables
+	// restrict the rows to. See WithTenant and WithAllTenants, a transaction
+	// started with neither sees no row of them.
+	RowLevelSecurity bool
+}
+
+// TxManager is a Transactor over a connection pool.
+type TxManager struct {
+	db      *pgxpool.Pool
+	queries *Queries
+	options TxOptions
+}
+
+func NewTxManager(db *pgxpool.Pool, options TxOptions) *TxManager {
+	return &TxManager{db: db, queries: New(db), options: options}
+}
+
+func (t *TxManager) InTx(ctx context.Context, fn func(q Querier) error) error {
+	return retry(ctx, t.options.MaxRetries, func() error {
+		return t.inTx(ctx, fn)
+	})
+}
+
+func (t *TxManager) inTx(ctx context.Context, fn func(q Querier) error) error {
+	// the transaction runs on the primary and may write, like a Router query
+	markWrite(ctx)
+	tx, err := t.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: t.options.Isolation})
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	if t.options.RowLevelSecurity {
+		// is_local keeps the setting to the transaction, a pooled connection
+		// never carries it to the next one
+		if _, err := tx.Exec(ctx, "SELECT set_config($1, $2, true)", tenantSetting, tenantSettingValue(ctx)); err != nil {
+			_ = tx.Rollback(ctx)
+			return fmt.Errorf("set tenant: %w", err)
+		}
+	}
+	if err := fn(t.queries.WithTx(tx)); err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// retry calls fn until it does not fail on a serialization failure, at most
+// retries times more, waiting a little longer, with jitter, before each call.
+func retry(ctx context.Context, retries int, fn func() error) error {
+	wait := 10 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= retries || !IsSerializationFailure(err) {
+			return err
+		}
+		timer := time.NewTimer(wait/2 + time.Duration(rand.Int63n(int64(wait))))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w (not retried: %v)", err, ctx.Err())
+		case <-timer.C:
+		}
+		wait *= 2
+	}
+}
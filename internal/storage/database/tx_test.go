@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetry(t *testing.T) {
+	conflict := fmt.Errorf("commit transaction: %w", &pgconn.PgError{Code: "40001"})
+	tests := []struct {
+		name      string
+		retries   int
+		errs      []error
+		wantCalls int
+		wantErr   error
+	}{
+		{
+			name:      "success - first attempt",
+			retries:   3,
+			errs:      []error{nil},
+			wantCalls: 1,
+		},
+		{
+			name:      "success - after serialization failures",
+			retries:   3,
+			errs:      []error{conflict, &pgconn.PgError{Code: "40P01"}, nil},
+			wantCalls: 3,
+		},
+		{
+			name:      "fail - retries exhausted",
+			retries:   1,
+			errs:      []error{conflict, conflict, nil},
+			wantCalls: 2,
+			wantErr:   conflict,
+		},
+		{
+			name:      "fail - other errors are not retried",
+			retries:   3,
+			errs:      []error{&pgconn.PgError{Code: "23505"}, nil},
+			wantCalls: 1,
+			wantErr:   &pgconn.PgError{Code: "23505"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := retry(context.Background(), tt.retries, func() error {
+				calls++
+				return tt.errs[calls-1]
+			})
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantCalls, calls)
+		})
+	}
+}
+
+func TestRetry_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := retry(ctx, 3, func() error { return &pgconn.PgError{Code: "40001"} })
+	assert.True(t, IsSerializationFailure(err))
+	assert.Contains(t, err.Error(), "not retried: context canceled")
+}
+
+func TestIsUniqueViolation(t *testing.T) {
+	assert.True(t, IsUniqueViolation(fmt.Errorf("create user: %w", &pgconn.PgError{Code: "23505"})))
+	assert.True(t, IsUniqueViolation(&pgconn.PgError{Code: "23505"}))
+	assert.False(t, IsUniqueViolation(&pgconn.PgError{Code: "23503"}))
+	assert.False(t, IsUniqueViolation(errors.New("23505")))
+	assert.False(t, IsUniqueViolation(nil))
+}
+
+func TestTenantSettingValue(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "", tenantSettingValue(ctx))
+	assert.Equal(t, "42", tenantSettingValue(WithTenant(ctx, 42)))
+	assert.Equal(t, "*", tenantSettingValue(WithAllTenants(ctx)))
+	assert.Equal(t, "42", tenantSettingValue(WithTenant(WithAllTenants(ctx), 42)))
+
+	id, ok := TenantFrom(WithTenant(ctx, 42))
+	assert.True(t, ok)
+	assert.Equal(t, int32(42), id)
+
+	_, ok = TenantFrom(WithAllTenants(ctx))
+	assert.False(t, ok)
+}
@@ -0,0 +1,7 @@
+-- +goose Up
+-- 20231006202055_init.sql creates the scratch schema but leaves it behind
+-- when rolled back. It is applied in existing databases and stays as it
+-- shipped, so this migration, rolled back right after it, drops the schema.
+
+-- +goose Down
+DROP SCHEMA IF EXISTS scratch;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE scratch.user ADD COLUMN disabled_at timestamptz;
+
+CREATE TABLE scratch.user_role (
+    user_id integer NOT NULL,
+    role VARCHAR(64) NOT NULL,
+    granted_at timestamptz NOT NULL DEFAULT now(),
+
+    PRIMARY KEY (user_id, role),
+    CONSTRAINT fk_user_role_user FOREIGN KEY (user_id)
+    REFERENCES scratch.user (id)
+    ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS scratch.user_role;
+
+ALTER TABLE scratch.user DROP COLUMN IF EXISTS disabled_at;
+-- +goose StatementEnd
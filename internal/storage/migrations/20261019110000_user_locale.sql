@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- preferred locale of the user as a BCP 47 tag, NULL means negotiate from Accept-Language
+ALTER TABLE scratch.user ADD COLUMN locale VARCHAR(35);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE scratch.user DROP COLUMN IF EXISTS locale;
+-- +goose StatementEnd
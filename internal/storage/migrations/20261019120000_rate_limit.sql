@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- tat is the theoretical arrival time of the GCRA token bucket of key, the
+-- bucket is full again once it lies in the past and the row can be deleted
+CREATE TABLE scratch.rate_limit (
+    key VARCHAR(255) PRIMARY KEY,
+    tat timestamptz NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS scratch.rate_limit;
+-- +goose StatementEnd
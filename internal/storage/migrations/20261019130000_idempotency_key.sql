@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- status_code is NULL while the first request with the key is in flight, the
+-- row then acts as the lock that makes concurrent duplicates wait
+CREATE TABLE scratch.idempotency_key (
+    key VARCHAR(255) NOT NULL,
+    operation VARCHAR(255) NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL,
+    locked_at timestamptz NOT NULL,
+    expires_at timestamptz NOT NULL,
+    status_code integer,
+    response_header bytea,
+    response_body bytea,
+
+    PRIMARY KEY (key, operation)
+);
+
+CREATE INDEX idempotency_key_expires_at_idx ON scratch.idempotency_key (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS scratch.idempotency_key;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- browser sessions are identified by a cookie instead of a refresh token, only
+-- the sha256 of the cookie value is stored
+ALTER TABLE scratch.session ALTER COLUMN refresh_token DROP NOT NULL;
+ALTER TABLE scratch.session
+    ADD COLUMN token_hash VARCHAR(64) UNIQUE,
+    ADD COLUMN csrf_token VARCHAR(64),
+    ADD COLUMN expires_at timestamptz;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM scratch.session WHERE refresh_token IS NULL;
+ALTER TABLE scratch.session
+    DROP COLUMN IF EXISTS token_hash,
+    DROP COLUMN IF EXISTS csrf_token,
+    DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE scratch.session ALTER COLUMN refresh_token SET NOT NULL;
+-- +goose StatementEnd
@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+-- sessions remember the device they were started from, login_date is replaced
+-- by proper timestamps
+ALTER TABLE scratch.session
+    ADD COLUMN created_at timestamptz NOT NULL DEFAULT now(),
+    ADD COLUMN last_seen_at timestamptz NOT NULL DEFAULT now(),
+    ADD COLUMN user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    ADD COLUMN ip VARCHAR(45) NOT NULL DEFAULT '',
+    ADD COLUMN device_name VARCHAR(255) NOT NULL DEFAULT '';
+UPDATE scratch.session
+SET created_at = login_date::timestamptz, last_seen_at = login_date::timestamptz
+WHERE login_date ~ '^\d{4}-\d{2}-\d{2}T';
+ALTER TABLE scratch.session DROP COLUMN login_date;
+
+-- api clients hold a refresh token instead of a cookie, only its sha256 is stored
+CREATE UNIQUE INDEX session_refresh_token_key ON scratch.session (refresh_token);
+
+-- the devices every user logged in from, by the sha256 of their user agent
+CREATE TABLE scratch.user_device (
+    user_id integer NOT NULL REFERENCES scratch.user (id) ON DELETE CASCADE,
+    fingerprint VARCHAR(64) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    first_seen_at timestamptz NOT NULL,
+    last_seen_at timestamptz NOT NULL,
+    PRIMARY KEY (user_id, fingerprint)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS scratch.user_device;
+DROP INDEX IF EXISTS scratch.session_refresh_token_key;
+ALTER TABLE scratch.session ADD COLUMN login_date VARCHAR(255);
+UPDATE scratch.session SET login_date = to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"');
+ALTER TABLE scratch.session
+    ALTER COLUMN login_date SET NOT NULL,
+    DROP COLUMN created_at,
+    DROP COLUMN last_seen_at,
+    DROP COLUMN user_agent,
+    DROP COLUMN ip,
+    DROP COLUMN device_name;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- domain events are written here in the transaction of the change they
+-- describe, and relayed to the broker afterwards
+CREATE TABLE scratch.outbox (
+    id BIGSERIAL PRIMARY KEY,
+    aggregate_type VARCHAR(64) NOT NULL,
+    aggregate_id VARCHAR(64) NOT NULL,
+    event_type VARCHAR(128) NOT NULL,
+    payload jsonb NOT NULL,
+    occurred_at timestamptz NOT NULL,
+    attempts integer NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz NOT NULL,
+    last_error TEXT,
+    published_at timestamptz
+);
+CREATE INDEX outbox_pending_idx ON scratch.outbox (aggregate_type, aggregate_id, id) WHERE published_at IS NULL;
+CREATE INDEX outbox_published_idx ON scratch.outbox (published_at) WHERE published_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS scratch.outbox;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- background jobs, claimed by workers with FOR UPDATE SKIP LOCKED
+CREATE TABLE scratch.job (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(128) NOT NULL,
+    payload jsonb NOT NULL,
+    -- at most one job of a kind has the same key, until it is deleted
+    unique_key VARCHAR(255),
+    -- pending, running, done or dead
+    state VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts integer NOT NULL DEFAULT 0,
+    max_attempts integer NOT NULL,
+    run_at timestamptz NOT NULL,
+    locked_at timestamptz,
+    locked_by VARCHAR(255),
+    last_error TEXT,
+    created_at timestamptz NOT NULL,
+    finished_at timestamptz
+);
+CREATE UNIQUE INDEX job_unique_key ON scratch.job (kind, unique_key);
+CREATE INDEX job_due_idx ON scratch.job (run_at, id) WHERE state = 'pending';
+CREATE INDEX job_running_idx ON scratch.job (locked_at) WHERE state = 'running';
+CREATE INDEX job_finished_idx ON scratch.job (finished_at) WHERE state = 'done';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS scratch.job;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the ids of the messages sent, so a retried job does not send one twice
+CREATE TABLE scratch.mail_sent (
+    message_id VARCHAR(255) PRIMARY KEY,
+    sent_at timestamptz NOT NULL
+);
+CREATE INDEX mail_sent_sent_at_idx ON scratch.mail_sent (sent_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS scratch.mail_sent;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- emails differing only in case belong to the same user, the index makes
+-- concurrent registrations of one email fail instead of both succeeding.
+-- Existing duplicates can not be merged automatically, they are listed so
+-- they can be resolved before migrating again.
+DO $$
+DECLARE
+    duplicates text;
+BEGIN
+    SELECT string_agg(email, ', ' ORDER BY email) INTO duplicates
+    FROM (
+        SELECT lower(email) AS email FROM scratch.user
+        GROUP BY lower(email) HAVING count(*) > 1
+    ) d;
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'users share an email differing only in case: %', duplicates
+            USING HINT = 'rename or delete all but one user of each email, then migrate again';
+    END IF;
+END
+$$;
+CREATE UNIQUE INDEX user_email_key ON scratch.user (lower(email));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS scratch.user_email_key;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- deleted accounts stay restorable for a grace period, after which they are
+-- purged
+ALTER TABLE scratch.user ADD COLUMN deleted_at timestamptz;
+CREATE INDEX user_deleted_at_idx ON scratch.user (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- session.user_id is NOT NULL, so SET NULL made every delete of a user with
+-- sessions fail, the sessions go with their user instead
+ALTER TABLE scratch.session
+    DROP CONSTRAINT fk_session_user,
+    ADD CONSTRAINT fk_session_user FOREIGN KEY (user_id)
+    REFERENCES scratch.user (id)
+    ON DELETE CASCADE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE scratch.session
+    DROP CONSTRAINT fk_session_user,
+    ADD CONSTRAINT fk_session_user FOREIGN KEY (user_id)
+    REFERENCES scratch.user (id)
+    ON DELETE SET NULL;
+
+DROP INDEX IF EXISTS scratch.user_deleted_at_idx;
+ALTER TABLE scratch.user DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the archive of everything held about a user, built by the data_export job,
+-- archive and ready_at are NULL while it is being built
+CREATE TABLE scratch.data_export (
+    user_id integer PRIMARY KEY REFERENCES scratch.user (id) ON DELETE CASCADE,
+    requested_at timestamptz NOT NULL,
+    ready_at timestamptz,
+    archive bytea
+);
+CREATE INDEX data_export_ready_at_idx ON scratch.data_export (ready_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS scratch.data_export;
+-- +goose StatementEnd
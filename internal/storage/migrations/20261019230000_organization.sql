@@ -0,0 +1,60 @@
+-- +goose Up
+-- +goose StatementBegin
+-- organizations host the teams of a deployment, every row of a tenant carries
+-- its organization_id
+CREATE TABLE scratch.organization (
+    id SERIAL PRIMARY KEY,
+    slug VARCHAR(64) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    created_at timestamptz NOT NULL
+);
+
+-- role is owner, admin or member, every organization keeps an owner
+CREATE TABLE scratch.organization_member (
+    organization_id integer NOT NULL REFERENCES scratch.organization (id) ON DELETE CASCADE,
+    user_id integer NOT NULL REFERENCES scratch.user (id) ON DELETE CASCADE,
+    role VARCHAR(16) NOT NULL,
+    joined_at timestamptz NOT NULL,
+
+    PRIMARY KEY (organization_id, user_id)
+);
+CREATE INDEX organization_member_user_idx ON scratch.organization_member (user_id);
+
+-- only the sha256 of the invitation code is stored, accepted_at makes it
+-- single use
+CREATE TABLE scratch.invitation (
+    id SERIAL PRIMARY KEY,
+    organization_id integer NOT NULL REFERENCES scratch.organization (id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(16) NOT NULL,
+    code_hash VARCHAR(64) NOT NULL UNIQUE,
+    created_at timestamptz NOT NULL,
+    expires_at timestamptz NOT NULL,
+    accepted_at timestamptz
+);
+CREATE INDEX invitation_organization_idx ON scratch.invitation (organization_id);
+
+-- with database.row_level_security the transactions of a request set
+-- app.tenant_id to its organization, and see the rows of no other. Work
+-- across organizations, like accepting an invitation, sets it to '*'
+-- explicitly. Unset or empty, it shows no row, so a transaction that forgets
+-- to scope itself fails closed. Without row level security the connections
+-- set it to '*' when they open. Superusers and roles with BYPASSRLS are never
+-- restricted.
+ALTER TABLE scratch.organization_member ENABLE ROW LEVEL SECURITY;
+ALTER TABLE scratch.organization_member FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON scratch.organization_member
+    USING (current_setting('app.tenant_id', true) IN ('*', organization_id::text));
+
+ALTER TABLE scratch.invitation ENABLE ROW LEVEL SECURITY;
+ALTER TABLE scratch.invitation FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON scratch.invitation
+    USING (current_setting('app.tenant_id', true) IN ('*', organization_id::text));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS scratch.invitation;
+DROP TABLE IF EXISTS scratch.organization_member;
+DROP TABLE IF EXISTS scratch.organization;
+-- +goose StatementEnd
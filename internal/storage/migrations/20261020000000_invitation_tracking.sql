@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- invitations remember who sent them and who registered or joined with them,
+-- and are revoked rather than deleted so the record stays
+ALTER TABLE scratch.invitation
+    ADD COLUMN invited_by integer REFERENCES scratch.user (id) ON DELETE SET NULL,
+    ADD COLUMN accepted_by integer REFERENCES scratch.user (id) ON DELETE SET NULL,
+    ADD COLUMN revoked_at timestamptz;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE scratch.invitation
+    DROP COLUMN IF EXISTS revoked_at,
+    DROP COLUMN IF EXISTS accepted_by,
+    DROP COLUMN IF EXISTS invited_by;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- an event the broker refused max attempts times is dead, it is kept for
+-- inspection and no longer holds back the later events of its aggregate
+ALTER TABLE scratch.outbox ADD COLUMN dead_at timestamptz;
+DROP INDEX scratch.outbox_pending_idx;
+CREATE INDEX outbox_pending_idx ON scratch.outbox (aggregate_type, aggregate_id, id) WHERE published_at IS NULL AND dead_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX scratch.outbox_pending_idx;
+CREATE INDEX outbox_pending_idx ON scratch.outbox (aggregate_type, aggregate_id, id) WHERE published_at IS NULL;
+ALTER TABLE scratch.outbox DROP COLUMN dead_at;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the email of a deleted account is free to register again during the grace
+-- period, only the accounts not deleted share no email
+DROP INDEX scratch.user_email_key;
+CREATE UNIQUE INDEX user_email_key ON scratch.user (lower(email)) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- every account shares no email again, a deleted account whose email was
+-- registered again can only be purged or restored by hand, so they are listed
+DO $$
+DECLARE
+    duplicates text;
+BEGIN
+    SELECT string_agg(email, ', ' ORDER BY email) INTO duplicates
+    FROM (
+        SELECT lower(email) AS email FROM scratch.user
+        GROUP BY lower(email) HAVING count(*) > 1
+    ) d;
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'deleted users share an email with other users: %', duplicates
+            USING HINT = 'purge the deleted users of each email, then migrate down again';
+    END IF;
+END
+$$;
+DROP INDEX scratch.user_email_key;
+CREATE UNIQUE INDEX user_email_key ON scratch.user (lower(email));
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- sessions keep the sha256 of their refresh token, the ones started before it
+-- was hashed still hold the token and are hashed here
+UPDATE scratch.session SET refresh_token = encode(sha256(convert_to(refresh_token, 'UTF8')), 'hex')
+WHERE refresh_token IS NOT NULL AND refresh_token !~ '^[0-9a-f]{64}$';
+ALTER TABLE scratch.session RENAME COLUMN refresh_token TO refresh_token_hash;
+ALTER INDEX scratch.session_refresh_token_key RENAME TO session_refresh_token_hash_key;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- the hashes can not be turned back into tokens, they stay as they are
+ALTER INDEX scratch.session_refresh_token_hash_key RENAME TO session_refresh_token_key;
+ALTER TABLE scratch.session RENAME COLUMN refresh_token_hash TO refresh_token;
+-- +goose StatementEnd
@@ -0,0 +1,437 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+)
+
+// FS holds the migrations of every dialect.
+//
+//go:embed *.sql sqlite/*.sql
+var FS embed.FS
+
+// DefaultLockTimeout is how long Up, Down and To wait for another instance
+// migrating the same database, unless WithLockTimeout says otherwise.
+const DefaultLockTimeout = time.Minute
+
+// MaxVersion is above every migration, migrating to it applies them all.
+const MaxVersion int64 = math.MaxInt64
+
+// lockID keys the postgres advisory lock held while migrating. Any constant
+// works as long as every instance uses the same one.
+const lockID int64 = 7_340_214_083_133
+
+// legacyInitialVersion is the version 20230216143411_initial.sql was applied
+// under by the first release. One digit too many sorted it after every later
+// migration, so goose took those for missing ones and refused to apply them.
+const legacyInitialVersion int64 = 202302161434110
+
+// Dialect is a database the application runs on, with its own set of
+// migrations since the SQL of postgres and sqlite differs.
+type Dialect struct {
+	// Name is the goose dialect.
+	Name string
+	// Dir is the directory of the migrations inside FS.
+	Dir string
+
+	// advisoryLock serializes migrations run by several instances, sqlite
+	// needs none since its file belongs to a single process.
+	advisoryLock bool
+	lockTimeout  time.Duration
+	// fixHistory rewrites the migrations an earlier release recorded as
+	// applied to match the migrations of today, nil when nothing changed.
+	fixHistory func(applied []appliedMigration) ([]appliedMigration, bool)
+}
+
+var (
+	Postgres = Dialect{Name: "postgres", Dir: ".", advisoryLock: true, lockTimeout: DefaultLockTimeout, fixHistory: fixPostgresHistory}
+	SQLite   = Dialect{Name: "sqlite3", Dir: "sqlite", lockTimeout: DefaultLockTimeout}
+)
+
+// appliedMigration is a row of the goose version table.
+type appliedMigration struct {
+	Version   int64
+	AppliedAt sql.NullTime
+}
+
+// fixPostgresHistory renumbers the initial migration in databases migrated
+// by the first release, and records the scratch schema migration, whose up
+// migration does nothing, as applied wherever init is. The history is sorted
+// by version afterwards, since goose rolls migrations back in the order it
+// recorded them.
+func fixPostgresHistory(applied []appliedMigration) ([]appliedMigration, bool) {
+	const (
+		initialVersion int64 = 20230216143411
+		schemaVersion  int64 = 20231006202054
+		initVersion    int64 = 20231006202055
+	)
+	var changed bool
+	var init *appliedMigration
+	var schema bool
+	fixed := make([]appliedMigration, len(applied), len(applied)+1)
+	for i, m := range applied {
+		switch m.Version {
+		case legacyInitialVersion:
+			m.Version = initialVersion
+			changed = true
+		case schemaVersion:
+			schema = true
+		case initVersion:
+			init = &applied[i]
+		}
+		fixed[i] = m
+	}
+	if init != nil && !schema {
+		fixed = append(fixed, appliedMigration{Version: schemaVersion, AppliedAt: init.AppliedAt})
+		changed = true
+	}
+	if !changed {
+		return applied, false
+	}
+	sort.SliceStable(fixed, func(i, j int) bool {
+		return fixed[i].Version < fixed[j].Version
+	})
+	return fixed, true
+}
+
+// Migration is a migration of a dialect and whether the database has it
+// applied.
+type Migration struct {
+	Version   int64     `json:"version"`
+	Name      string    `json:"name"`
+	Applied   bool      `json:"applied"`
+	AppliedAt time.Time `json:"appliedAt"`
+}
+
+// Report is the state of the migrations of a database.
+type Report struct {
+	// Current is the version of the database, 0 before the first migration.
+	Current int64 `json:"current"`
+	// Migrations are all known migrations, oldest first.
+	Migrations []Migration `json:"migrations"`
+}
+
+// Pending returns the migrations Up applies.
+func (r Report) Pending() []Migration {
+	var pending []Migration
+	for _, m := range r.Migrations {
+		if !m.Applied {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
+
+// Previous returns the version Down rolls the database back to.
+func (r Report) Previous() int64 {
+	var previous int64
+	for _, m := range r.Migrations {
+		if m.Applied && m.Version < r.Current {
+			previous = m.Version
+		}
+	}
+	return previous
+}
+
+// Plan lists what migrating to version does: the pending migrations up to
+// version, oldest first, or the applied ones above it, newest first, when
+// Down is set.
+func (r Report) Plan(version int64) Plan {
+	var plan Plan
+	if version >= r.Current {
+		for _, m := range r.Migrations {
+			if !m.Applied && m.Version <= version {
+				plan.Migrations = append(plan.Migrations, m)
+			}
+		}
+		return plan
+	}
+
+	plan.Down = true
+	for i := len(r.Migrations) - 1; i >= 0; i-- {
+		if m := r.Migrations[i]; m.Applied && m.Version > version {
+			plan.Migrations = append(plan.Migrations, m)
+		}
+	}
+	return plan
+}
+
+// Plan is what a migration run would do, for a dry run.
+type Plan struct {
+	Down       bool
+	Migrations []Migration
+}
+
+// Write lists the migrations of the plan, one per line.
+func (p Plan) Write(w io.Writer) error {
+	if len(p.Migrations) == 0 {
+		_, err := fmt.Fprintln(w, "nothing to migrate")
+		return err
+	}
+	action := "apply"
+	if p.Down {
+		action = "roll back"
+	}
+	for _, m := range p.Migrations {
+		if _, err := fmt.Fprintf(w, "would %v %d %v\n", action, m.Version, m.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OpenDB returns a database/sql handle on the database of pool for goose,
+// which is the only user of database/sql. The caller closes it.
+func OpenDB(pool *pgxpool.Pool) *sql.DB {
+	database := stdlib.OpenDB(*pool.Config().ConnConfig)
+	// one connection runs the migrations, the other holds the advisory lock
+	database.SetMaxOpenConns(2)
+	return database
+}
+
+// WithLockTimeout returns a copy of d waiting for at most timeout for
+// another instance migrating the same database.
+func (d Dialect) WithLockTimeout(timeout time.Duration) Dialect {
+	d.lockTimeout = timeout
+	return d
+}
+
+// Setup points goose at the embedded migrations of d.
+func (d Dialect) Setup() error {
+	goose.SetBaseFS(FS)
+
+	if err := goose.SetDialect(d.Name); err != nil {
+		return fmt.Errorf("goose - set dialect: %w", err)
+	}
+	return nil
+}
+
+// lock holds the migration lock of the database until unlock is called, so
+// instances starting together do not run the same migrations.
+func (d Dialect) lock(ctx context.Context, database *sql.DB) (unlock func(), err error) {
+	if !d.advisoryLock {
+		return func() {}, nil
+	}
+	// the session level lock lives as long as the connection
+	conn, err := database.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get lock connection: %w", err)
+	}
+	lockCtx, cancel := context.WithTimeout(ctx, d.lockTimeout)
+	defer cancel()
+	if _, err := conn.ExecContext(lockCtx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		conn.Close()
+		if lockCtx.Err() != nil && ctx.Err() == nil {
+			return nil, fmt.Errorf("another instance is migrating the database, gave up after %v", d.lockTimeout)
+		}
+		return nil, fmt.Errorf("lock migrations: %w", err)
+	}
+	return func() {
+		// closing the connection releases the lock even if this fails
+		conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+		conn.Close()
+	}, nil
+}
+
+// applied returns the migrations the database has applied, in the order it
+// applied them.
+func (d Dialect) applied(ctx context.Context, database *sql.DB) ([]appliedMigration, error) {
+	if _, err := goose.EnsureDBVersionContext(ctx, database); err != nil {
+		return nil, fmt.Errorf("ensure version table: %w", err)
+	}
+	rows, err := database.QueryContext(ctx,
+		fmt.Sprintf("SELECT version_id, tstamp FROM %v WHERE is_applied AND version_id > 0 ORDER BY id", goose.TableName()))
+	if err != nil {
+		return nil, fmt.Errorf("list applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	var applied []appliedMigration
+	for rows.Next() {
+		var m appliedMigration
+		if err := rows.Scan(&m.Version, &m.AppliedAt); err != nil {
+			return nil, fmt.Errorf("scan applied migration: %w", err)
+		}
+		applied = append(applied, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list applied migrations: %w", err)
+	}
+	return applied, nil
+}
+
+// fix rewrites the version table of a database migrated by an earlier
+// release, see fixHistory. It runs under the migration lock.
+func (d Dialect) fix(ctx context.Context, database *sql.DB) error {
+	if d.fixHistory == nil {
+		return nil
+	}
+	applied, err := d.applied(ctx, database)
+	if err != nil {
+		return err
+	}
+	fixed, changed := d.fixHistory(applied)
+	if !changed {
+		return nil
+	}
+
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin history fix: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %v WHERE version_id > 0", goose.TableName())); err != nil {
+		return fmt.Errorf("clear history: %w", err)
+	}
+	insert := fmt.Sprintf("INSERT INTO %v (version_id, is_applied, tstamp) VALUES ($1, true, $2)", goose.TableName())
+	for _, m := range fixed {
+		if _, err := tx.ExecContext(ctx, insert, m.Version, m.AppliedAt); err != nil {
+			return fmt.Errorf("record migration %d: %w", m.Version, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit history fix: %w", err)
+	}
+	return nil
+}
+
+// Up applies every pending migration.
+func (d Dialect) Up(ctx context.Context, database *sql.DB) error {
+	if err := d.Setup(); err != nil {
+		return err
+	}
+	unlock, err := d.lock(ctx, database)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := d.fix(ctx, database); err != nil {
+		return err
+	}
+
+	if err := goose.UpContext(ctx, database, d.Dir); err != nil {
+		return fmt.Errorf("run up migrations: %w", err)
+	}
+	return nil
+}
+
+// Down rolls back the most recent migration.
+func (d Dialect) Down(ctx context.Context, database *sql.DB) error {
+	if err := d.Setup(); err != nil {
+		return err
+	}
+	unlock, err := d.lock(ctx, database)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := d.fix(ctx, database); err != nil {
+		return err
+	}
+
+	if err := goose.DownContext(ctx, database, d.Dir); err != nil {
+		return fmt.Errorf("run down migration: %w", err)
+	}
+	return nil
+}
+
+// To migrates up or down until the database is at version.
+func (d Dialect) To(ctx context.Context, database *sql.DB, version int64) error {
+	if err := d.Setup(); err != nil {
+		return err
+	}
+	unlock, err := d.lock(ctx, database)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := d.fix(ctx, database); err != nil {
+		return err
+	}
+
+	current, err := goose.GetDBVersionContext(ctx, database)
+	if err != nil {
+		return fmt.Errorf("get database version: %w", err)
+	}
+
+	if version >= current {
+		err = goose.UpToContext(ctx, database, d.Dir, version)
+	} else {
+		err = goose.DownToContext(ctx, database, d.Dir, version)
+	}
+	if err != nil {
+		return fmt.Errorf("migrate to version %d: %w", version, err)
+	}
+	return nil
+}
+
+// Report returns every known migration and whether it is applied.
+func (d Dialect) Report(ctx context.Context, database *sql.DB) (Report, error) {
+	if err := d.Setup(); err != nil {
+		return Report{}, err
+	}
+	known, err := goose.CollectMigrations(d.Dir, 0, goose.MaxVersion)
+	if err != nil {
+		return Report{}, fmt.Errorf("collect migrations: %w", err)
+	}
+	applied, err := d.applied(ctx, database)
+	if err != nil {
+		return Report{}, err
+	}
+	// a dry run reports what the database looks like once fixed, without
+	// fixing it
+	if d.fixHistory != nil {
+		applied, _ = d.fixHistory(applied)
+	}
+
+	var current int64
+	appliedAt := make(map[int64]time.Time)
+	for _, m := range applied {
+		current = m.Version
+		appliedAt[m.Version] = m.AppliedAt.Time.UTC()
+	}
+
+	report := Report{Current: current}
+	for _, m := range known {
+		at, ok := appliedAt[m.Version]
+		report.Migrations = append(report.Migrations, Migration{
+			Version:   m.Version,
+			Name:      path.Base(m.Source),
+			Applied:   ok,
+			AppliedAt: at,
+		})
+	}
+	return report, nil
+}
+
+// Status writes every known migration and whether it is applied.
+func (d Dialect) Status(ctx context.Context, database *sql.DB, w io.Writer) error {
+	report, err := d.Report(ctx, database)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tMIGRATION\tAPPLIED AT")
+	for _, m := range report.Migrations {
+		appliedAt := "pending"
+		if m.Applied {
+			appliedAt = m.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(tw, "%d\t%v\t%v\n", m.Version, m.Name, appliedAt)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,86 @@
+package migrations_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"scratch/internal/storage/migrations"
+	"scratch/internal/storage/migrations/migrationtest"
+	"scratch/internal/storage/sqlite"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLite_Reversible(t *testing.T) {
+	database, err := sqlite.Open(filepath.Join(t.TempDir(), "chatto.db"))
+	require.NoError(t, err)
+	defer database.Close()
+
+	migrationtest.TestReversible(t, migrations.SQLite, database, migrationtest.SQLiteSchema)
+}
+
+func TestReport(t *testing.T) {
+	ctx := context.Background()
+	database, err := sqlite.Open(filepath.Join(t.TempDir(), "chatto.db"))
+	require.NoError(t, err)
+	defer database.Close()
+
+	report, err := migrations.SQLite.Report(ctx, database)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(report.Migrations), 3)
+	assert.Zero(t, report.Current)
+	assert.Len(t, report.Pending(), len(report.Migrations))
+	first, second, last := report.Migrations[0], report.Migrations[1], report.Migrations[len(report.Migrations)-1]
+
+	require.NoError(t, migrations.SQLite.To(ctx, database, second.Version))
+	report, err = migrations.SQLite.Report(ctx, database)
+	require.NoError(t, err)
+	assert.Equal(t, second.Version, report.Current)
+	assert.Equal(t, first.Version, report.Previous())
+	assert.True(t, report.Migrations[0].Applied)
+	assert.False(t, report.Migrations[0].AppliedAt.IsZero())
+	assert.Len(t, report.Pending(), len(report.Migrations)-2)
+
+	up := report.Plan(last.Version)
+	assert.False(t, up.Down)
+	assert.Equal(t, report.Pending(), up.Migrations)
+
+	down := report.Plan(0)
+	assert.True(t, down.Down)
+	assert.Equal(t, []migrations.Migration{report.Migrations[1], report.Migrations[0]}, down.Migrations)
+
+	assert.Empty(t, report.Plan(second.Version).Migrations)
+
+	// a dry run changes nothing
+	var out strings.Builder
+	require.NoError(t, down.Write(&out))
+	assert.Equal(t, fmt.Sprintf("would roll back %d %v\nwould roll back %d %v\n",
+		second.Version, second.Name, first.Version, first.Name), out.String())
+	report, err = migrations.SQLite.Report(ctx, database)
+	require.NoError(t, err)
+	assert.Equal(t, second.Version, report.Current)
+}
+
+func TestSQLite_UserEmailLiveDown(t *testing.T) {
+	ctx := context.Background()
+	database, err := sqlite.Open(filepath.Join(t.TempDir(), "chatto.db"))
+	require.NoError(t, err)
+	defer database.Close()
+
+	require.NoError(t, migrations.SQLite.To(ctx, database, 20261020020000))
+	_, err = database.Exec(`INSERT INTO user (name, email, password, deleted_at) VALUES
+		('Ann', 'ann@example.com', 'secret', '2026-10-19 12:00:00'), ('Ann', 'Ann@example.com', 'secret', NULL)`)
+	require.NoError(t, err)
+
+	err = migrations.SQLite.To(ctx, database, 20261020010000)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "deleted users share an email with other users")
+
+	_, err = database.Exec(`DELETE FROM user WHERE deleted_at IS NOT NULL`)
+	require.NoError(t, err)
+	require.NoError(t, migrations.SQLite.To(ctx, database, 20261020010000))
+}
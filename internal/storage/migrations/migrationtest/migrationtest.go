@@ -0,0 +1,139 @@
+// Package migrationtest checks that the migrations of a dialect can be rolled
+// back, so that migrate down and migrate to are safe to run in production.
+package migrationtest
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"scratch/internal/storage/migrations"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// PostgresBaseline holds the migrations of the first release as it shipped
+// them, the initial one still under its old version.
+//
+//go:embed baseline/*.sql
+var PostgresBaseline embed.FS
+
+// Schema describes the schema of database as text, equal for equal schemas.
+type Schema func(t *testing.T, database *sql.DB) string
+
+// TestReversible applies every migration of dialect to the empty database one
+// at a time, and checks that rolling each back restores the schema it found
+// and that it applies again afterwards.
+func TestReversible(t *testing.T, dialect migrations.Dialect, database *sql.DB, schema Schema) {
+	ctx := context.Background()
+	report, err := dialect.Report(ctx, database)
+	require.NoError(t, err)
+	require.Empty(t, report.Current, "the database is not empty")
+
+	var previous int64
+	for _, m := range report.Migrations {
+		before := schema(t, database)
+
+		require.NoError(t, dialect.To(ctx, database, m.Version), "apply %v", m.Name)
+		require.NoError(t, dialect.To(ctx, database, previous), "roll back %v", m.Name)
+		assert.Equal(t, before, schema(t, database), "rolling back %v does not restore the schema", m.Name)
+		require.NoError(t, dialect.To(ctx, database, m.Version), "apply %v after rolling it back", m.Name)
+
+		previous = m.Version
+	}
+
+	// and all at once, the way migrate to 0 runs
+	require.NoError(t, dialect.To(ctx, database, 0))
+	report, err = dialect.Report(ctx, database)
+	require.NoError(t, err)
+	assert.Len(t, report.Pending(), len(report.Migrations))
+}
+
+// TestUpgrade migrates the empty database the way an earlier release did,
+// with the migrations in the baseline directory, and checks that dialect
+// brings it to the latest version and to the schema latest describes, and
+// rolls it back entirely.
+func TestUpgrade(t *testing.T, dialect migrations.Dialect, baseline fs.FS, database *sql.DB, latest string, schema Schema) {
+	ctx := context.Background()
+	empty := schema(t, database)
+	goose.SetBaseFS(baseline)
+	require.NoError(t, goose.SetDialect(dialect.Name))
+	require.NoError(t, goose.UpContext(ctx, database, "baseline"), "apply the baseline")
+
+	require.NoError(t, dialect.Up(ctx, database))
+	report, err := dialect.Report(ctx, database)
+	require.NoError(t, err)
+	assert.Empty(t, report.Pending())
+	assert.Equal(t, report.Migrations[len(report.Migrations)-1].Version, report.Current)
+	assert.Equal(t, latest, schema(t, database))
+
+	require.NoError(t, dialect.To(ctx, database, 0))
+	assert.Equal(t, empty, schema(t, database))
+}
+
+// PostgresSchema describes the schemas, tables, columns, constraints and
+// indexes outside the system schemas. Empty schemas are left out, since
+// rolling back init leaves the scratch schema for the migration before it
+// to drop.
+func PostgresSchema(t *testing.T, database *sql.DB) string {
+	return query(t, database, `
+		SELECT 'schema ' || n.nspname FROM pg_namespace n
+		WHERE n.nspname NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
+			AND n.nspname NOT LIKE 'pg_temp_%' AND n.nspname NOT LIKE 'pg_toast_temp_%'
+			AND (n.nspname = 'public' OR EXISTS (SELECT FROM pg_class c WHERE c.relnamespace = n.oid))
+		UNION ALL
+		SELECT 'column ' || table_schema || '.' || table_name || '.' || column_name || ' ' || data_type ||
+			' ' || is_nullable || ' ' || COALESCE(column_default, '')
+		FROM information_schema.columns
+		WHERE table_schema NOT IN ('pg_catalog', 'information_schema')
+		UNION ALL
+		SELECT 'constraint ' || n.nspname || '.' || c.conname || ' ' || pg_get_constraintdef(c.oid)
+		FROM pg_constraint c JOIN pg_namespace n ON n.oid = c.connamespace
+		WHERE n.nspname NOT IN ('pg_catalog', 'information_schema')
+		UNION ALL
+		SELECT 'index ' || indexdef FROM pg_indexes
+		WHERE schemaname NOT IN ('pg_catalog', 'information_schema')
+		ORDER BY 1`)
+}
+
+// SQLiteSchema describes the columns, keys, indexes and triggers of the file.
+// Tables are described column by column, since rebuilding a table to alter
+// it changes its CREATE statement but not its schema.
+func SQLiteSchema(t *testing.T, database *sql.DB) string {
+	return query(t, database, `
+		SELECT 'column ' || m.name || '.' || c.name || ' ' || c.type || ' ' || c."notnull" || ' ' ||
+			COALESCE(c.dflt_value, '') || ' ' || c.pk
+		FROM sqlite_master m JOIN pragma_table_info(m.name) c WHERE m.type = 'table'
+		UNION ALL
+		SELECT 'foreign key ' || m.name || '.' || f."from" || ' ' || f."table" || '.' || f."to" || ' ' || f.on_delete
+		FROM sqlite_master m JOIN pragma_foreign_key_list(m.name) f WHERE m.type = 'table'
+		UNION ALL
+		SELECT 'key ' || m.name || ' ' || l.origin || ' ' ||
+			(SELECT group_concat(i.name) FROM pragma_index_info(l.name) i)
+		FROM sqlite_master m JOIN pragma_index_list(m.name) l WHERE m.type = 'table' AND l.origin != 'c'
+		UNION ALL
+		SELECT type || ' ' || name || ' ' || sql FROM sqlite_master WHERE type IN ('index', 'trigger') AND sql IS NOT NULL
+		ORDER BY 1`)
+}
+
+func query(t *testing.T, database *sql.DB, query string) string {
+	t.Helper()
+	rows, err := database.Query(query)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var schema strings.Builder
+	for rows.Next() {
+		var line string
+		require.NoError(t, rows.Scan(&line))
+		fmt.Fprintln(&schema, line)
+	}
+	require.NoError(t, rows.Err())
+	return schema.String()
+}
@@ -0,0 +1,133 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the postgres schema up to 20261019190000 in a single file, times are stored
+-- as UTC text and compared as such
+CREATE TABLE initial_migration (
+    message TEXT NOT NULL
+);
+INSERT INTO initial_migration (message) VALUES ('successful migration');
+
+CREATE TABLE user (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    password TEXT NOT NULL,
+    disabled_at DATETIME,
+    locale TEXT
+);
+CREATE UNIQUE INDEX user_email_key ON user (lower(email));
+
+CREATE TABLE session (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    refresh_token TEXT,
+    token_hash TEXT UNIQUE,
+    csrf_token TEXT,
+    expires_at DATETIME,
+    created_at DATETIME NOT NULL,
+    last_seen_at DATETIME NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    device_name TEXT NOT NULL DEFAULT '',
+
+    CONSTRAINT fk_session_user FOREIGN KEY (user_id)
+    REFERENCES user (id)
+    ON DELETE SET NULL
+);
+CREATE UNIQUE INDEX session_refresh_token_key ON session (refresh_token);
+
+CREATE TABLE user_role (
+    user_id INTEGER NOT NULL,
+    role TEXT NOT NULL,
+    granted_at DATETIME NOT NULL,
+
+    PRIMARY KEY (user_id, role),
+    CONSTRAINT fk_user_role_user FOREIGN KEY (user_id)
+    REFERENCES user (id)
+    ON DELETE CASCADE
+);
+
+CREATE TABLE user_device (
+    user_id INTEGER NOT NULL REFERENCES user (id) ON DELETE CASCADE,
+    fingerprint TEXT NOT NULL,
+    name TEXT NOT NULL,
+    first_seen_at DATETIME NOT NULL,
+    last_seen_at DATETIME NOT NULL,
+
+    PRIMARY KEY (user_id, fingerprint)
+);
+
+CREATE TABLE rate_limit (
+    key TEXT PRIMARY KEY,
+    tat DATETIME NOT NULL
+);
+
+CREATE TABLE idempotency_key (
+    key TEXT NOT NULL,
+    operation TEXT NOT NULL,
+    fingerprint TEXT NOT NULL,
+    locked_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    status_code INTEGER,
+    response_header BLOB,
+    response_body BLOB,
+
+    PRIMARY KEY (key, operation)
+);
+CREATE INDEX idempotency_key_expires_at_idx ON idempotency_key (expires_at);
+
+CREATE TABLE outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    aggregate_type TEXT NOT NULL,
+    aggregate_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    occurred_at DATETIME NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL,
+    last_error TEXT,
+    published_at DATETIME
+);
+CREATE INDEX outbox_pending_idx ON outbox (aggregate_type, aggregate_id, id) WHERE published_at IS NULL;
+CREATE INDEX outbox_published_idx ON outbox (published_at) WHERE published_at IS NOT NULL;
+
+CREATE TABLE job (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    unique_key TEXT,
+    state TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    run_at DATETIME NOT NULL,
+    locked_at DATETIME,
+    locked_by TEXT,
+    last_error TEXT,
+    created_at DATETIME NOT NULL,
+    finished_at DATETIME
+);
+CREATE UNIQUE INDEX job_unique_key ON job (kind, unique_key);
+CREATE INDEX job_due_idx ON job (run_at, id) WHERE state = 'pending';
+CREATE INDEX job_running_idx ON job (locked_at) WHERE state = 'running';
+CREATE INDEX job_finished_idx ON job (finished_at) WHERE state = 'done';
+
+CREATE TABLE mail_sent (
+    message_id TEXT PRIMARY KEY,
+    sent_at DATETIME NOT NULL
+);
+CREATE INDEX mail_sent_sent_at_idx ON mail_sent (sent_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS mail_sent;
+DROP TABLE IF EXISTS job;
+DROP TABLE IF EXISTS outbox;
+DROP TABLE IF EXISTS idempotency_key;
+DROP TABLE IF EXISTS rate_limit;
+DROP TABLE IF EXISTS user_device;
+DROP TABLE IF EXISTS user_role;
+DROP TABLE IF EXISTS session;
+DROP TABLE IF EXISTS user;
+DROP TABLE IF EXISTS initial_migration;
+-- +goose StatementEnd
@@ -0,0 +1,59 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user ADD COLUMN deleted_at DATETIME;
+CREATE INDEX user_deleted_at_idx ON user (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- SQLite cannot change a constraint, the session table is rebuilt with its
+-- sessions going with their user
+CREATE TABLE session_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    refresh_token TEXT,
+    token_hash TEXT UNIQUE,
+    csrf_token TEXT,
+    expires_at DATETIME,
+    created_at DATETIME NOT NULL,
+    last_seen_at DATETIME NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    device_name TEXT NOT NULL DEFAULT '',
+
+    CONSTRAINT fk_session_user FOREIGN KEY (user_id)
+    REFERENCES user (id)
+    ON DELETE CASCADE
+);
+INSERT INTO session_new (id, user_id, refresh_token, token_hash, csrf_token, expires_at, created_at, last_seen_at, user_agent, ip, device_name)
+SELECT id, user_id, refresh_token, token_hash, csrf_token, expires_at, created_at, last_seen_at, user_agent, ip, device_name FROM session;
+DROP TABLE session;
+ALTER TABLE session_new RENAME TO session;
+CREATE UNIQUE INDEX session_refresh_token_key ON session (refresh_token);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE TABLE session_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    refresh_token TEXT,
+    token_hash TEXT UNIQUE,
+    csrf_token TEXT,
+    expires_at DATETIME,
+    created_at DATETIME NOT NULL,
+    last_seen_at DATETIME NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    device_name TEXT NOT NULL DEFAULT '',
+
+    CONSTRAINT fk_session_user FOREIGN KEY (user_id)
+    REFERENCES user (id)
+    ON DELETE SET NULL
+);
+INSERT INTO session_new (id, user_id, refresh_token, token_hash, csrf_token, expires_at, created_at, last_seen_at, user_agent, ip, device_name)
+SELECT id, user_id, refresh_token, token_hash, csrf_token, expires_at, created_at, last_seen_at, user_agent, ip, device_name FROM session;
+DROP TABLE session;
+ALTER TABLE session_new RENAME TO session;
+CREATE UNIQUE INDEX session_refresh_token_key ON session (refresh_token);
+
+DROP INDEX IF EXISTS user_deleted_at_idx;
+ALTER TABLE user DROP COLUMN deleted_at;
+-- +goose StatementEnd
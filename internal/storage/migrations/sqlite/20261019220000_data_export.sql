@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE data_export (
+    user_id INTEGER PRIMARY KEY REFERENCES user (id) ON DELETE CASCADE,
+    requested_at DATETIME NOT NULL,
+    ready_at DATETIME,
+    archive BLOB
+);
+CREATE INDEX data_export_ready_at_idx ON data_export (ready_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS data_export;
+-- +goose StatementEnd
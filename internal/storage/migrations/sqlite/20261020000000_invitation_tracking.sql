@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE invitation ADD COLUMN invited_by INTEGER REFERENCES user (id) ON DELETE SET NULL;
+ALTER TABLE invitation ADD COLUMN accepted_by INTEGER REFERENCES user (id) ON DELETE SET NULL;
+ALTER TABLE invitation ADD COLUMN revoked_at DATETIME;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE invitation DROP COLUMN revoked_at;
+ALTER TABLE invitation DROP COLUMN accepted_by;
+ALTER TABLE invitation DROP COLUMN invited_by;
+-- +goose StatementEnd
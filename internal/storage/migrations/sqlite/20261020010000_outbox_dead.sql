@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- an event the broker refused max attempts times is dead, it is kept for
+-- inspection and no longer holds back the later events of its aggregate
+ALTER TABLE outbox ADD COLUMN dead_at DATETIME;
+DROP INDEX outbox_pending_idx;
+CREATE INDEX outbox_pending_idx ON outbox (aggregate_type, aggregate_id, id) WHERE published_at IS NULL AND dead_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX outbox_pending_idx;
+CREATE INDEX outbox_pending_idx ON outbox (aggregate_type, aggregate_id, id) WHERE published_at IS NULL;
+ALTER TABLE outbox DROP COLUMN dead_at;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the email of a deleted account is free to register again during the grace
+-- period, only the accounts not deleted share no email
+DROP INDEX user_email_key;
+CREATE UNIQUE INDEX user_email_key ON user (lower(email)) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- every account shares no email again, a deleted account whose email was
+-- registered again can only be purged or restored by hand; sqlite raises
+-- errors in triggers only
+CREATE TEMP TABLE user_email_check (duplicates INTEGER NOT NULL);
+CREATE TEMP TRIGGER user_email_check BEFORE INSERT ON user_email_check WHEN NEW.duplicates > 0
+BEGIN
+    SELECT RAISE(ABORT, 'deleted users share an email with other users, purge them then migrate down again');
+END;
+INSERT INTO user_email_check
+SELECT count(*) FROM (SELECT lower(email) FROM user GROUP BY lower(email) HAVING count(*) > 1);
+DROP TABLE user_email_check;
+DROP INDEX user_email_key;
+CREATE UNIQUE INDEX user_email_key ON user (lower(email));
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- sessions keep the sha256 of their refresh token, which sqlite databases
+-- always did
+ALTER TABLE session RENAME COLUMN refresh_token TO refresh_token_hash;
+DROP INDEX session_refresh_token_key;
+CREATE UNIQUE INDEX session_refresh_token_hash_key ON session (refresh_token_hash);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX session_refresh_token_hash_key;
+ALTER TABLE session RENAME COLUMN refresh_token_hash TO refresh_token;
+CREATE UNIQUE INDEX session_refresh_token_key ON session (refresh_token);
+-- +goose StatementEnd
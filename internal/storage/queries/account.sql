@@ -1,16 +1,112 @@
 -- name: GetUserByEmail :one
-SELECT * FROM scratch.user WHERE email = $1;
+SELECT * FROM scratch.user WHERE lower(email) = lower(@email::text) AND deleted_at IS NULL;
+
+-- name: GetUserByID :one
+SELECT * FROM scratch.user WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: GetDeletedUserByEmail :one
+-- The email may be registered again after the deletion, the latest deleted
+-- account of it is the one to restore.
+SELECT * FROM scratch.user WHERE lower(email) = lower(@email::text) AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id DESC LIMIT 1;
+
+-- name: UpdateUserProfile :execrows
+UPDATE scratch.user SET name = $2, locale = $3 WHERE id = $1 AND deleted_at IS NULL;
 
 -- name: CreateUser :one
-INSERT INTO scratch.user (name, email, password)
-VALUES ($1, $2, $3)
+INSERT INTO scratch.user (name, email, password, locale)
+VALUES ($1, $2, $3, $4)
 RETURNING *;
 
--- name: CreateSession :exec
-INSERT INTO scratch.session (user_id, refresh_token, login_date) VALUES ($1, $2, $3);
+-- name: CreateSession :one
+INSERT INTO scratch.session (user_id, refresh_token_hash, created_at, last_seen_at, expires_at, user_agent, ip, device_name)
+VALUES (@user_id, @refresh_token_hash::varchar, @now, @now, @expires_at::timestamptz, @user_agent, @ip, @device_name)
+RETURNING id;
+
+-- name: GetSessionByRefreshToken :one
+SELECT s.id, s.user_id
+FROM scratch.session s
+JOIN scratch.user u ON u.id = s.user_id
+WHERE s.refresh_token_hash = @refresh_token_hash::varchar AND s.expires_at > @now::timestamptz
+    AND u.disabled_at IS NULL AND u.deleted_at IS NULL;
+
+-- name: RefreshSession :execrows
+UPDATE scratch.session
+SET refresh_token_hash = @new_refresh_token_hash::varchar, last_seen_at = @now, expires_at = @expires_at::timestamptz,
+    user_agent = COALESCE(NULLIF(@user_agent::varchar, ''), user_agent), ip = COALESCE(NULLIF(@ip::varchar, ''), ip)
+WHERE id = @id AND refresh_token_hash = @refresh_token_hash::varchar;
+
+-- name: CreateBrowserSession :one
+INSERT INTO scratch.session (user_id, token_hash, csrf_token, created_at, last_seen_at, expires_at, user_agent, ip, device_name)
+VALUES (@user_id, @token_hash::varchar, @csrf_token::varchar, @now, @now, @expires_at::timestamptz, @user_agent, @ip, @device_name)
+RETURNING id;
+
+-- name: GetBrowserSession :one
+SELECT s.id, s.user_id, s.csrf_token::varchar AS csrf_token, s.expires_at::timestamptz AS expires_at, s.last_seen_at
+FROM scratch.session s
+JOIN scratch.user u ON u.id = s.user_id
+WHERE s.token_hash = @token_hash::varchar AND s.expires_at > @now::timestamptz
+    AND u.disabled_at IS NULL AND u.deleted_at IS NULL;
+
+-- name: TouchSession :exec
+UPDATE scratch.session SET last_seen_at = @now, ip = COALESCE(NULLIF(@ip::varchar, ''), ip) WHERE id = @id;
+
+-- name: DeleteBrowserSession :execrows
+DELETE FROM scratch.session WHERE token_hash = @token_hash::varchar;
+
+-- name: ListSessions :many
+SELECT id, (token_hash IS NOT NULL)::boolean AS browser, COALESCE(token_hash, '')::varchar AS token_hash,
+    created_at, last_seen_at, expires_at::timestamptz AS expires_at, user_agent, ip, device_name
+FROM scratch.session
+WHERE user_id = @user_id AND expires_at > @now::timestamptz
+ORDER BY last_seen_at DESC, id DESC;
+
+-- name: DeleteUserSession :execrows
+DELETE FROM scratch.session WHERE id = $1 AND user_id = $2;
+
+-- name: RememberDevice :one
+INSERT INTO scratch.user_device (user_id, fingerprint, name, first_seen_at, last_seen_at)
+VALUES (@user_id, @fingerprint, @name, @now, @now)
+ON CONFLICT (user_id, fingerprint) DO UPDATE SET last_seen_at = EXCLUDED.last_seen_at
+RETURNING (xmax = 0)::boolean AS inserted;
+
+-- name: CountUserDevices :one
+SELECT count(*) FROM scratch.user_device WHERE user_id = $1;
+
+-- name: DisableUser :execrows
+UPDATE scratch.user SET disabled_at = COALESCE(disabled_at, now()) WHERE lower(email) = lower(@email::text) AND deleted_at IS NULL;
+
+-- name: UpdateUserPassword :one
+UPDATE scratch.user SET password = @password WHERE lower(email) = lower(@email::text) AND deleted_at IS NULL RETURNING id;
+
+-- name: SetUserLocale :execrows
+UPDATE scratch.user SET locale = @locale WHERE lower(email) = lower(@email::text) AND deleted_at IS NULL;
+
+-- name: DeleteUser :execrows
+UPDATE scratch.user SET deleted_at = @now::timestamptz WHERE id = @id AND deleted_at IS NULL;
+
+-- name: RestoreUser :execrows
+UPDATE scratch.user SET deleted_at = NULL WHERE id = @id AND deleted_at > @deleted_after::timestamptz;
+
+-- name: GrantUserRole :exec
+INSERT INTO scratch.user_role (user_id, role) VALUES ($1, $2) ON CONFLICT DO NOTHING;
+
+-- name: ListUserRoles :many
+SELECT role FROM scratch.user_role WHERE user_id = $1 ORDER BY role;
+
+-- name: DeleteExpiredSessions :execrows
+DELETE FROM scratch.session WHERE expires_at < @now::timestamptz;
+
+-- name: DeleteAllSessions :execrows
+DELETE FROM scratch.session;
+
+-- name: DeleteUserSessions :execrows
+DELETE FROM scratch.session WHERE user_id = $1;
 
--- name: GetSession :one
-SELECT * FROM scratch.session WHERE refresh_token = $1 AND user_id = $2;
+-- name: DeleteOtherUserSessions :execrows
+-- Deletes the sessions of the user but the browser session keep_hash, api
+-- sessions included.
+DELETE FROM scratch.session WHERE user_id = @user_id AND token_hash IS DISTINCT FROM @keep_hash::varchar;
 
 -- name: CleanUserTable :exec
 DELETE FROM scratch.user;
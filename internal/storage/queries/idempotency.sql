@@ -0,0 +1,30 @@
+-- name: InsertIdempotencyKey :execrows
+INSERT INTO scratch.idempotency_key (key, operation, fingerprint, locked_at, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (key, operation) DO NOTHING;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM scratch.idempotency_key WHERE key = $1 AND operation = $2;
+
+-- name: TakeOverIdempotencyKey :execrows
+UPDATE scratch.idempotency_key
+SET fingerprint = @fingerprint, locked_at = @now, expires_at = @expires_at,
+    status_code = NULL, response_header = NULL, response_body = NULL
+WHERE key = @key AND operation = @operation
+  AND (expires_at < @now OR (status_code IS NULL AND locked_at < @stale_before));
+
+-- name: CompleteIdempotencyKey :execrows
+-- The request holding the lock since locked_at stores its response, unless
+-- a retry took the key over meanwhile.
+UPDATE scratch.idempotency_key
+SET status_code = @status_code, response_header = @response_header, response_body = @response_body
+WHERE key = @key AND operation = @operation AND locked_at = @locked_at AND status_code IS NULL;
+
+-- name: DeleteIdempotencyKey :exec
+-- The request holding the lock since locked_at releases the key, unless a
+-- retry took it over meanwhile.
+DELETE FROM scratch.idempotency_key
+WHERE key = @key AND operation = @operation AND locked_at = @locked_at AND status_code IS NULL;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM scratch.idempotency_key WHERE expires_at < $1;
@@ -0,0 +1,53 @@
+-- name: EnqueueJob :execrows
+INSERT INTO scratch.job (kind, payload, unique_key, max_attempts, run_at, created_at)
+VALUES (@kind, @payload, sqlc.narg(unique_key), @max_attempts, @run_at, @now)
+ON CONFLICT (kind, unique_key) DO NOTHING;
+
+-- name: ClaimJobs :many
+-- Claims the due jobs, and the running ones whose worker is gone for longer
+-- than the lock timeout and which have attempts left, see KillStaleJobs.
+UPDATE scratch.job
+SET state = 'running', attempts = attempts + 1, locked_at = @now::timestamptz, locked_by = @worker::varchar
+WHERE id IN (
+    SELECT j.id FROM scratch.job j
+    WHERE (j.state = 'pending' AND j.run_at <= @now::timestamptz)
+       OR (j.state = 'running' AND j.locked_at < @stale_before::timestamptz AND j.attempts < j.max_attempts)
+    ORDER BY j.run_at, j.id
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, kind, payload, attempts, max_attempts;
+
+-- name: CompleteJob :execrows
+UPDATE scratch.job
+SET state = 'done', finished_at = @now::timestamptz, locked_at = NULL, locked_by = NULL, last_error = NULL
+WHERE id = @id AND state = 'running' AND attempts = @attempts;
+
+-- name: RetryJob :execrows
+UPDATE scratch.job
+SET state = 'pending', run_at = @run_at, locked_at = NULL, locked_by = NULL, last_error = @last_error::text
+WHERE id = @id AND state = 'running' AND attempts = @attempts;
+
+-- name: KillJob :execrows
+UPDATE scratch.job
+SET state = 'dead', finished_at = @now::timestamptz, locked_at = NULL, locked_by = NULL, last_error = @last_error::text
+WHERE id = @id AND state = 'running' AND attempts = @attempts;
+
+-- name: KillStaleJobs :execrows
+-- Kills the running jobs whose worker is gone on their last attempt.
+UPDATE scratch.job
+SET state = 'dead', finished_at = @now::timestamptz, locked_at = NULL, locked_by = NULL, last_error = @last_error::text
+WHERE state = 'running' AND locked_at < @stale_before::timestamptz AND attempts >= max_attempts;
+
+-- name: ListDeadJobs :many
+SELECT id, kind, payload, attempts, last_error, finished_at FROM scratch.job
+WHERE state = 'dead'
+ORDER BY finished_at DESC, id DESC;
+
+-- name: ReviveJob :execrows
+UPDATE scratch.job
+SET state = 'pending', attempts = 0, run_at = @now, finished_at = NULL
+WHERE id = @id AND state = 'dead';
+
+-- name: DeleteFinishedJobs :execrows
+DELETE FROM scratch.job WHERE state = 'done' AND finished_at < @before::timestamptz;
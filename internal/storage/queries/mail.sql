@@ -0,0 +1,9 @@
+-- name: ClaimMailMessage :execrows
+INSERT INTO scratch.mail_sent (message_id, sent_at) VALUES (@message_id, @now)
+ON CONFLICT (message_id) DO NOTHING;
+
+-- name: ReleaseMailMessage :exec
+DELETE FROM scratch.mail_sent WHERE message_id = @message_id;
+
+-- name: DeleteSentMailBefore :execrows
+DELETE FROM scratch.mail_sent WHERE sent_at < @before;
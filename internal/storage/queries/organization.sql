@@ -0,0 +1,63 @@
+-- name: CreateOrganization :one
+INSERT INTO scratch.organization (slug, name, created_at) VALUES (@slug, @name, @now)
+RETURNING *;
+
+-- name: GetOrganization :one
+SELECT * FROM scratch.organization WHERE id = $1;
+
+-- name: ListUserOrganizations :many
+SELECT o.id, o.slug, o.name, o.created_at, m.role, m.joined_at
+FROM scratch.organization o JOIN scratch.organization_member m ON m.organization_id = o.id
+WHERE m.user_id = $1 ORDER BY o.name, o.id;
+
+-- name: AddOrganizationMember :execrows
+INSERT INTO scratch.organization_member (organization_id, user_id, role, joined_at)
+VALUES (@organization_id, @user_id, @role, @now)
+ON CONFLICT (organization_id, user_id) DO NOTHING;
+
+-- name: GetOrganizationMember :one
+SELECT * FROM scratch.organization_member WHERE organization_id = @organization_id AND user_id = @user_id;
+
+-- name: ListOrganizationMembers :many
+SELECT m.user_id, u.name, u.email, m.role, m.joined_at
+FROM scratch.organization_member m JOIN scratch.user u ON u.id = m.user_id
+WHERE m.organization_id = $1 AND u.deleted_at IS NULL ORDER BY m.joined_at, m.user_id;
+
+-- name: UpdateOrganizationMemberRole :execrows
+UPDATE scratch.organization_member SET role = @role
+WHERE organization_id = @organization_id AND user_id = @user_id;
+
+-- name: RemoveOrganizationMember :execrows
+DELETE FROM scratch.organization_member WHERE organization_id = @organization_id AND user_id = @user_id;
+
+-- name: CountOrganizationOwners :one
+SELECT count(*) FROM scratch.organization_member WHERE organization_id = $1 AND role = 'owner';
+
+-- name: CreateInvitation :one
+INSERT INTO scratch.invitation (organization_id, email, role, code_hash, invited_by, created_at, expires_at)
+VALUES (@organization_id, @email, @role, @code_hash, @invited_by, @now, @expires_at)
+RETURNING *;
+
+-- name: GetInvitationByCode :one
+SELECT * FROM scratch.invitation WHERE code_hash = $1;
+
+-- name: GetInvitation :one
+SELECT * FROM scratch.invitation WHERE id = @id AND organization_id = @organization_id;
+
+-- name: ListOrganizationInvitations :many
+SELECT i.id, i.email, i.role, i.invited_by, inviter.name AS inviter_name,
+    i.accepted_by, accepter.name AS accepter_name,
+    i.created_at, i.expires_at, i.accepted_at, i.revoked_at
+FROM scratch.invitation i
+LEFT JOIN scratch.user inviter ON inviter.id = i.invited_by
+LEFT JOIN scratch.user accepter ON accepter.id = i.accepted_by
+WHERE i.organization_id = $1 ORDER BY i.created_at DESC, i.id DESC;
+
+-- name: AcceptInvitation :execrows
+-- An invitation is accepted once, before it expires or is revoked.
+UPDATE scratch.invitation SET accepted_at = @now::timestamptz, accepted_by = @accepted_by
+WHERE id = @id AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > @now::timestamptz;
+
+-- name: RevokeInvitation :execrows
+UPDATE scratch.invitation SET revoked_at = @now::timestamptz
+WHERE id = @id AND organization_id = @organization_id AND accepted_at IS NULL AND revoked_at IS NULL;
@@ -0,0 +1,40 @@
+-- name: InsertOutboxEvent :exec
+INSERT INTO scratch.outbox (aggregate_type, aggregate_id, event_type, payload, occurred_at, next_attempt_at)
+VALUES (@aggregate_type, @aggregate_id, @event_type, @payload, @occurred_at, @occurred_at);
+
+-- name: ClaimOutboxEvents :many
+-- Claims the due events until locked_until, the events are published outside
+-- of the transaction. Only the oldest pending event of every aggregate is
+-- due, so the events of an aggregate are published in order even by several
+-- relays.
+UPDATE scratch.outbox SET next_attempt_at = @locked_until::timestamptz
+WHERE id IN (
+    SELECT o.id FROM scratch.outbox o
+    WHERE o.published_at IS NULL AND o.dead_at IS NULL AND o.next_attempt_at <= @now::timestamptz
+      AND NOT EXISTS (
+        SELECT 1 FROM scratch.outbox p
+        WHERE p.aggregate_type = o.aggregate_type AND p.aggregate_id = o.aggregate_id
+          AND p.published_at IS NULL AND p.dead_at IS NULL AND p.id < o.id
+      )
+    ORDER BY o.id
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, aggregate_type, aggregate_id, event_type, payload, occurred_at, attempts;
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE scratch.outbox SET published_at = @now::timestamptz, attempts = attempts + 1, last_error = NULL
+WHERE id = @id AND published_at IS NULL;
+
+-- name: MarkOutboxEventFailed :exec
+UPDATE scratch.outbox
+SET attempts = attempts + 1, next_attempt_at = @next_attempt_at, last_error = @last_error::text
+WHERE id = @id AND published_at IS NULL;
+
+-- name: KillOutboxEvent :exec
+UPDATE scratch.outbox
+SET attempts = attempts + 1, dead_at = @now::timestamptz, last_error = @last_error::text
+WHERE id = @id AND published_at IS NULL;
+
+-- name: DeletePublishedOutboxEvents :execrows
+DELETE FROM scratch.outbox WHERE published_at < @before::timestamptz;
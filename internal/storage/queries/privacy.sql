@@ -0,0 +1,63 @@
+-- name: ListUserSessions :many
+SELECT id, created_at, last_seen_at, expires_at, user_agent, ip, device_name
+FROM scratch.session WHERE user_id = $1 ORDER BY id;
+
+-- name: ListUserRoleGrants :many
+SELECT * FROM scratch.user_role WHERE user_id = $1 ORDER BY role;
+
+-- name: ListUserDevices :many
+SELECT * FROM scratch.user_device WHERE user_id = $1 ORDER BY first_seen_at, fingerprint;
+
+-- name: ListUserEvents :many
+SELECT id, event_type, payload, occurred_at FROM scratch.outbox
+WHERE aggregate_type = 'user' AND aggregate_id = @aggregate_id ORDER BY id;
+
+-- name: ListUsersDeletedBefore :many
+SELECT id FROM scratch.user WHERE deleted_at < @deleted_before::timestamptz ORDER BY id;
+
+-- name: EraseUser :execrows
+DELETE FROM scratch.user WHERE id = $1;
+
+-- name: DeleteUserRoles :execrows
+DELETE FROM scratch.user_role WHERE user_id = $1;
+
+-- name: DeleteUserDevices :execrows
+DELETE FROM scratch.user_device WHERE user_id = $1;
+
+-- name: DeleteUserEvents :execrows
+DELETE FROM scratch.outbox WHERE aggregate_type = 'user' AND aggregate_id = @aggregate_id;
+
+-- name: RequestDataExport :execrows
+-- An export being built is left alone, a finished one is replaced.
+INSERT INTO scratch.data_export (user_id, requested_at) VALUES (@user_id, @now)
+ON CONFLICT (user_id) DO UPDATE SET requested_at = EXCLUDED.requested_at, ready_at = NULL, archive = NULL
+WHERE scratch.data_export.ready_at IS NOT NULL;
+
+-- name: GetDataExport :one
+SELECT user_id, requested_at, ready_at FROM scratch.data_export WHERE user_id = $1;
+
+-- name: GetDataExportArchive :one
+SELECT archive FROM scratch.data_export WHERE user_id = @user_id AND ready_at > @ready_after::timestamptz;
+
+-- name: SaveDataExport :execrows
+UPDATE scratch.data_export SET archive = @archive, ready_at = @now::timestamptz
+WHERE user_id = @user_id AND ready_at IS NULL;
+
+-- name: DeleteDataExport :execrows
+DELETE FROM scratch.data_export WHERE user_id = $1;
+
+-- name: DeleteExpiredDataExports :execrows
+DELETE FROM scratch.data_export WHERE ready_at < @before::timestamptz;
+
+-- name: ListUserInvitations :many
+-- The invitations sent to the email of the user.
+SELECT i.id, o.name AS organization, i.role, i.created_at, i.expires_at, i.accepted_at, i.revoked_at
+FROM scratch.invitation i JOIN scratch.organization o ON o.id = i.organization_id
+WHERE lower(i.email) = (SELECT lower(u.email) FROM scratch.user u WHERE u.id = @user_id) ORDER BY i.id;
+
+-- name: DeleteUserMemberships :execrows
+DELETE FROM scratch.organization_member WHERE user_id = $1;
+
+-- name: DeleteUserInvitations :execrows
+DELETE FROM scratch.invitation
+WHERE lower(email) = (SELECT lower(u.email) FROM scratch.user u WHERE u.id = @user_id);
@@ -0,0 +1,11 @@
+-- name: EnsureRateLimit :exec
+INSERT INTO scratch.rate_limit (key, tat) VALUES ($1, $2) ON CONFLICT (key) DO NOTHING;
+
+-- name: LockRateLimit :one
+SELECT tat FROM scratch.rate_limit WHERE key = $1 FOR UPDATE;
+
+-- name: UpdateRateLimit :exec
+UPDATE scratch.rate_limit SET tat = $2 WHERE key = $1;
+
+-- name: DeleteExpiredRateLimits :execrows
+DELETE FROM scratch.rate_limit WHERE tat < $1;
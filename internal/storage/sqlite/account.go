@@ -0,0 +1,244 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	db "scratch/internal/storage/database"
+	"scratch/internal/storage/sqlite/sqlitedb"
+)
+
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (db.ScratchUser, error) {
+	user, err := s.queries.GetUserByEmail(ctx, email)
+	return fromUser(user), translate(err)
+}
+
+func (s *Store) GetUserByID(ctx context.Context, id int32) (db.ScratchUser, error) {
+	user, err := s.queries.GetUserByID(ctx, int64(id))
+	return fromUser(user), translate(err)
+}
+
+func (s *Store) GetDeletedUserByEmail(ctx context.Context, email string) (db.ScratchUser, error) {
+	user, err := s.queries.GetDeletedUserByEmail(ctx, email)
+	return fromUser(user), translate(err)
+}
+
+func (s *Store) UpdateUserProfile(ctx context.Context, arg db.UpdateUserProfileParams) (int64, error) {
+	n, err := s.queries.UpdateUserProfile(ctx, sqlitedb.UpdateUserProfileParams{
+		ID:     int64(arg.ID),
+		Name:   arg.Name,
+		Locale: arg.Locale,
+	})
+	return n, translate(err)
+}
+
+func (s *Store) CreateUser(ctx context.Context, arg db.CreateUserParams) (db.ScratchUser, error) {
+	user, err := s.queries.CreateUser(ctx, sqlitedb.CreateUserParams(arg))
+	return fromUser(user), translate(err)
+}
+
+func (s *Store) DisableUser(ctx context.Context, email string) (int64, error) {
+	n, err := s.queries.DisableUser(ctx, sqlitedb.DisableUserParams{Email: email, Now: nullTime(time.Now())})
+	return n, translate(err)
+}
+
+func (s *Store) UpdateUserPassword(ctx context.Context, arg db.UpdateUserPasswordParams) (int32, error) {
+	id, err := s.queries.UpdateUserPassword(ctx, sqlitedb.UpdateUserPasswordParams{Email: arg.Email, Password: arg.Password})
+	return int32(id), translate(err)
+}
+
+func (s *Store) SetUserLocale(ctx context.Context, arg db.SetUserLocaleParams) (int64, error) {
+	n, err := s.queries.SetUserLocale(ctx, sqlitedb.SetUserLocaleParams{Email: arg.Email, Locale: arg.Locale})
+	return n, translate(err)
+}
+
+func (s *Store) CleanUserTable(ctx context.Context) error {
+	return translate(s.queries.CleanUserTable(ctx))
+}
+
+func (s *Store) DeleteUser(ctx context.Context, arg db.DeleteUserParams) (int64, error) {
+	n, err := s.queries.DeleteUser(ctx, sqlitedb.DeleteUserParams{Now: nullTime(arg.Now), ID: int64(arg.ID)})
+	return n, translate(err)
+}
+
+func (s *Store) RestoreUser(ctx context.Context, arg db.RestoreUserParams) (int64, error) {
+	n, err := s.queries.RestoreUser(ctx, sqlitedb.RestoreUserParams{ID: int64(arg.ID), DeletedAfter: nullTime(arg.DeletedAfter)})
+	return n, translate(err)
+}
+
+func (s *Store) GrantUserRole(ctx context.Context, arg db.GrantUserRoleParams) error {
+	return translate(s.queries.GrantUserRole(ctx, sqlitedb.GrantUserRoleParams{
+		UserID:    int64(arg.UserID),
+		Role:      arg.Role,
+		GrantedAt: utc(time.Now()),
+	}))
+}
+
+func (s *Store) ListUserRoles(ctx context.Context, userID int32) ([]string, error) {
+	roles, err := s.queries.ListUserRoles(ctx, int64(userID))
+	return roles, translate(err)
+}
+
+func (s *Store) CreateSession(ctx context.Context, arg db.CreateSessionParams) (int32, error) {
+	id, err := s.queries.CreateSession(ctx, sqlitedb.CreateSessionParams{
+		UserID:           int64(arg.UserID),
+		RefreshTokenHash: nullString(arg.RefreshTokenHash),
+		Now:              utc(arg.Now),
+		ExpiresAt:        nullTime(arg.ExpiresAt),
+		UserAgent:        arg.UserAgent,
+		Ip:               arg.Ip,
+		DeviceName:       arg.DeviceName,
+	})
+	return int32(id), translate(err)
+}
+
+func (s *Store) CreateBrowserSession(ctx context.Context, arg db.CreateBrowserSessionParams) (int32, error) {
+	id, err := s.queries.CreateBrowserSession(ctx, sqlitedb.CreateBrowserSessionParams{
+		UserID:     int64(arg.UserID),
+		TokenHash:  nullString(arg.TokenHash),
+		CsrfToken:  nullString(arg.CsrfToken),
+		Now:        utc(arg.Now),
+		ExpiresAt:  nullTime(arg.ExpiresAt),
+		UserAgent:  arg.UserAgent,
+		Ip:         arg.Ip,
+		DeviceName: arg.DeviceName,
+	})
+	return int32(id), translate(err)
+}
+
+func (s *Store) GetSessionByRefreshToken(ctx context.Context, arg db.GetSessionByRefreshTokenParams) (db.GetSessionByRefreshTokenRow, error) {
+	row, err := s.queries.GetSessionByRefreshToken(ctx, sqlitedb.GetSessionByRefreshTokenParams{
+		RefreshTokenHash: nullString(arg.RefreshTokenHash),
+		Now:              nullTime(arg.Now),
+	})
+	return db.GetSessionByRefreshTokenRow{ID: int32(row.ID), UserID: int32(row.UserID)}, translate(err)
+}
+
+func (s *Store) GetBrowserSession(ctx context.Context, arg db.GetBrowserSessionParams) (db.GetBrowserSessionRow, error) {
+	row, err := s.queries.GetBrowserSession(ctx, sqlitedb.GetBrowserSessionParams{
+		TokenHash: nullString(arg.TokenHash),
+		Now:       nullTime(arg.Now),
+	})
+	return db.GetBrowserSessionRow{
+		ID:         int32(row.ID),
+		UserID:     int32(row.UserID),
+		CsrfToken:  row.CsrfToken.String,
+		ExpiresAt:  row.ExpiresAt.Time,
+		LastSeenAt: row.LastSeenAt,
+	}, translate(err)
+}
+
+func (s *Store) RefreshSession(ctx context.Context, arg db.RefreshSessionParams) (int64, error) {
+	n, err := s.queries.RefreshSession(ctx, sqlitedb.RefreshSessionParams{
+		NewRefreshTokenHash: nullString(arg.NewRefreshTokenHash),
+		Now:                 utc(arg.Now),
+		ExpiresAt:           nullTime(arg.ExpiresAt),
+		UserAgent:           arg.UserAgent,
+		Ip:                  arg.Ip,
+		ID:                  int64(arg.ID),
+		RefreshTokenHash:    nullString(arg.RefreshTokenHash),
+	})
+	return n, translate(err)
+}
+
+func (s *Store) TouchSession(ctx context.Context, arg db.TouchSessionParams) error {
+	return translate(s.queries.TouchSession(ctx, sqlitedb.TouchSessionParams{
+		Now: utc(arg.Now),
+		Ip:  arg.Ip,
+		ID:  int64(arg.ID),
+	}))
+}
+
+func (s *Store) ListSessions(ctx context.Context, arg db.ListSessionsParams) ([]db.ListSessionsRow, error) {
+	rows, err := s.queries.ListSessions(ctx, sqlitedb.ListSessionsParams{UserID: int64(arg.UserID), Now: nullTime(arg.Now)})
+	if err != nil {
+		return nil, translate(err)
+	}
+	var sessions []db.ListSessionsRow
+	for _, row := range rows {
+		sessions = append(sessions, db.ListSessionsRow{
+			ID:         int32(row.ID),
+			Browser:    row.TokenHash.Valid,
+			TokenHash:  row.TokenHash.String,
+			CreatedAt:  row.CreatedAt,
+			LastSeenAt: row.LastSeenAt,
+			ExpiresAt:  row.ExpiresAt.Time,
+			UserAgent:  row.UserAgent,
+			Ip:         row.Ip,
+			DeviceName: row.DeviceName,
+		})
+	}
+	return sessions, nil
+}
+
+func (s *Store) DeleteBrowserSession(ctx context.Context, tokenHash string) (int64, error) {
+	n, err := s.queries.DeleteBrowserSession(ctx, nullString(tokenHash))
+	return n, translate(err)
+}
+
+func (s *Store) DeleteUserSession(ctx context.Context, arg db.DeleteUserSessionParams) (int64, error) {
+	n, err := s.queries.DeleteUserSession(ctx, sqlitedb.DeleteUserSessionParams{ID: int64(arg.ID), UserID: int64(arg.UserID)})
+	return n, translate(err)
+}
+
+func (s *Store) DeleteExpiredSessions(ctx context.Context, now time.Time) (int64, error) {
+	n, err := s.queries.DeleteExpiredSessions(ctx, nullTime(now))
+	return n, translate(err)
+}
+
+func (s *Store) DeleteAllSessions(ctx context.Context) (int64, error) {
+	n, err := s.queries.DeleteAllSessions(ctx)
+	return n, translate(err)
+}
+
+func (s *Store) DeleteUserSessions(ctx context.Context, userID int32) (int64, error) {
+	n, err := s.queries.DeleteUserSessions(ctx, int64(userID))
+	return n, translate(err)
+}
+
+func (s *Store) DeleteOtherUserSessions(ctx context.Context, arg db.DeleteOtherUserSessionsParams) (int64, error) {
+	n, err := s.queries.DeleteOtherUserSessions(ctx, sqlitedb.DeleteOtherUserSessionsParams{
+		UserID:   int64(arg.UserID),
+		KeepHash: nullString(arg.KeepHash),
+	})
+	return n, translate(err)
+}
+
+// RememberDevice inserts the device or touches the one already known, SQLite
+// cannot tell the two apart in a single upsert.
+func (s *Store) RememberDevice(ctx context.Context, arg db.RememberDeviceParams) (bool, error) {
+	n, err := s.queries.InsertDevice(ctx, sqlitedb.InsertDeviceParams{
+		UserID:      int64(arg.UserID),
+		Fingerprint: arg.Fingerprint,
+		Name:        arg.Name,
+		Now:         utc(arg.Now),
+	})
+	if err != nil {
+		return false, translate(err)
+	}
+	if n > 0 {
+		return true, nil
+	}
+	return false, translate(s.queries.TouchDevice(ctx, sqlitedb.TouchDeviceParams{
+		Now:         utc(arg.Now),
+		UserID:      int64(arg.UserID),
+		Fingerprint: arg.Fingerprint,
+	}))
+}
+
+func (s *Store) CountUserDevices(ctx context.Context, userID int32) (int64, error) {
+	n, err := s.queries.CountUserDevices(ctx, int64(userID))
+	return n, translate(err)
+}
+
+func fromUser(user sqlitedb.User) db.ScratchUser {
+	return db.ScratchUser{
+		ID:         int32(user.ID),
+		Name:       user.Name,
+		Email:      user.Email,
+		Password:   user.Password,
+		DisabledAt: user.DisabledAt,
+		Locale:     user.Locale,
+		DeletedAt:  user.DeletedAt,
+	}
+}
@@ -0,0 +1,75 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	db "scratch/internal/storage/database"
+	"scratch/internal/storage/sqlite/sqlitedb"
+)
+
+func (s *Store) InsertIdempotencyKey(ctx context.Context, arg db.InsertIdempotencyKeyParams) (int64, error) {
+	n, err := s.queries.InsertIdempotencyKey(ctx, sqlitedb.InsertIdempotencyKeyParams{
+		Key:         arg.Key,
+		Operation:   arg.Operation,
+		Fingerprint: arg.Fingerprint,
+		LockedAt:    utc(arg.LockedAt),
+		ExpiresAt:   utc(arg.ExpiresAt),
+	})
+	return n, translate(err)
+}
+
+func (s *Store) GetIdempotencyKey(ctx context.Context, arg db.GetIdempotencyKeyParams) (db.ScratchIdempotencyKey, error) {
+	row, err := s.queries.GetIdempotencyKey(ctx, sqlitedb.GetIdempotencyKeyParams(arg))
+	if err != nil {
+		return db.ScratchIdempotencyKey{}, translate(err)
+	}
+	return db.ScratchIdempotencyKey{
+		Key:            row.Key,
+		Operation:      row.Operation,
+		Fingerprint:    row.Fingerprint,
+		LockedAt:       row.LockedAt,
+		ExpiresAt:      row.ExpiresAt,
+		StatusCode:     sql.NullInt32{Int32: int32(row.StatusCode.Int64), Valid: row.StatusCode.Valid},
+		ResponseHeader: row.ResponseHeader,
+		ResponseBody:   row.ResponseBody,
+	}, nil
+}
+
+func (s *Store) TakeOverIdempotencyKey(ctx context.Context, arg db.TakeOverIdempotencyKeyParams) (int64, error) {
+	n, err := s.queries.TakeOverIdempotencyKey(ctx, sqlitedb.TakeOverIdempotencyKeyParams{
+		Fingerprint: arg.Fingerprint,
+		Now:         utc(arg.Now),
+		ExpiresAt:   utc(arg.ExpiresAt),
+		Key:         arg.Key,
+		Operation:   arg.Operation,
+		StaleBefore: utc(arg.StaleBefore),
+	})
+	return n, translate(err)
+}
+
+func (s *Store) CompleteIdempotencyKey(ctx context.Context, arg db.CompleteIdempotencyKeyParams) (int64, error) {
+	n, err := s.queries.CompleteIdempotencyKey(ctx, sqlitedb.CompleteIdempotencyKeyParams{
+		StatusCode:     sql.NullInt64{Int64: int64(arg.StatusCode.Int32), Valid: arg.StatusCode.Valid},
+		ResponseHeader: arg.ResponseHeader,
+		ResponseBody:   arg.ResponseBody,
+		Key:            arg.Key,
+		Operation:      arg.Operation,
+		LockedAt:       utc(arg.LockedAt),
+	})
+	return n, translate(err)
+}
+
+func (s *Store) DeleteIdempotencyKey(ctx context.Context, arg db.DeleteIdempotencyKeyParams) error {
+	return translate(s.queries.DeleteIdempotencyKey(ctx, sqlitedb.DeleteIdempotencyKeyParams{
+		Key:       arg.Key,
+		Operation: arg.Operation,
+		LockedAt:  utc(arg.LockedAt),
+	}))
+}
+
+func (s *Store) DeleteExpiredIdempotencyKeys(ctx context.Context, expiresAt time.Time) (int64, error) {
+	n, err := s.queries.DeleteExpiredIdempotencyKeys(ctx, utc(expiresAt))
+	return n, translate(err)
+}
@@ -0,0 +1,112 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	db "scratch/internal/storage/database"
+	"scratch/internal/storage/sqlite/sqlitedb"
+)
+
+func (s *Store) EnqueueJob(ctx context.Context, arg db.EnqueueJobParams) (int64, error) {
+	n, err := s.queries.EnqueueJob(ctx, sqlitedb.EnqueueJobParams{
+		Kind:        arg.Kind,
+		Payload:     string(arg.Payload),
+		UniqueKey:   arg.UniqueKey,
+		MaxAttempts: int64(arg.MaxAttempts),
+		RunAt:       utc(arg.RunAt),
+		Now:         utc(arg.Now),
+	})
+	return n, translate(err)
+}
+
+func (s *Store) ClaimJobs(ctx context.Context, arg db.ClaimJobsParams) ([]db.ClaimJobsRow, error) {
+	rows, err := s.queries.ClaimJobs(ctx, sqlitedb.ClaimJobsParams{
+		Now:         nullTime(arg.Now),
+		Worker:      nullString(arg.Worker),
+		StaleBefore: nullTime(arg.StaleBefore),
+		BatchSize:   int64(arg.BatchSize),
+	})
+	if err != nil {
+		return nil, translate(err)
+	}
+	var jobs []db.ClaimJobsRow
+	for _, row := range rows {
+		jobs = append(jobs, db.ClaimJobsRow{
+			ID:          row.ID,
+			Kind:        row.Kind,
+			Payload:     json.RawMessage(row.Payload),
+			Attempts:    int32(row.Attempts),
+			MaxAttempts: int32(row.MaxAttempts),
+		})
+	}
+	return jobs, nil
+}
+
+func (s *Store) CompleteJob(ctx context.Context, arg db.CompleteJobParams) (int64, error) {
+	n, err := s.queries.CompleteJob(ctx, sqlitedb.CompleteJobParams{
+		Now:      nullTime(arg.Now),
+		ID:       arg.ID,
+		Attempts: int64(arg.Attempts),
+	})
+	return n, translate(err)
+}
+
+func (s *Store) RetryJob(ctx context.Context, arg db.RetryJobParams) (int64, error) {
+	n, err := s.queries.RetryJob(ctx, sqlitedb.RetryJobParams{
+		RunAt:     utc(arg.RunAt),
+		LastError: nullString(arg.LastError),
+		ID:        arg.ID,
+		Attempts:  int64(arg.Attempts),
+	})
+	return n, translate(err)
+}
+
+func (s *Store) KillJob(ctx context.Context, arg db.KillJobParams) (int64, error) {
+	n, err := s.queries.KillJob(ctx, sqlitedb.KillJobParams{
+		Now:       nullTime(arg.Now),
+		LastError: nullString(arg.LastError),
+		ID:        arg.ID,
+		Attempts:  int64(arg.Attempts),
+	})
+	return n, translate(err)
+}
+
+func (s *Store) KillStaleJobs(ctx context.Context, arg db.KillStaleJobsParams) (int64, error) {
+	n, err := s.queries.KillStaleJobs(ctx, sqlitedb.KillStaleJobsParams{
+		Now:         nullTime(arg.Now),
+		LastError:   nullString(arg.LastError),
+		StaleBefore: nullTime(arg.StaleBefore),
+	})
+	return n, translate(err)
+}
+
+func (s *Store) ListDeadJobs(ctx context.Context) ([]db.ListDeadJobsRow, error) {
+	rows, err := s.queries.ListDeadJobs(ctx)
+	if err != nil {
+		return nil, translate(err)
+	}
+	var jobs []db.ListDeadJobsRow
+	for _, row := range rows {
+		jobs = append(jobs, db.ListDeadJobsRow{
+			ID:         row.ID,
+			Kind:       row.Kind,
+			Payload:    json.RawMessage(row.Payload),
+			Attempts:   int32(row.Attempts),
+			LastError:  row.LastError,
+			FinishedAt: row.FinishedAt,
+		})
+	}
+	return jobs, nil
+}
+
+func (s *Store) ReviveJob(ctx context.Context, arg db.ReviveJobParams) (int64, error) {
+	n, err := s.queries.ReviveJob(ctx, sqlitedb.ReviveJobParams{Now: utc(arg.Now), ID: arg.ID})
+	return n, translate(err)
+}
+
+func (s *Store) DeleteFinishedJobs(ctx context.Context, before time.Time) (int64, error) {
+	n, err := s.queries.DeleteFinishedJobs(ctx, nullTime(before))
+	return n, translate(err)
+}
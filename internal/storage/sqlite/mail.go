@@ -0,0 +1,23 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	db "scratch/internal/storage/database"
+	"scratch/internal/storage/sqlite/sqlitedb"
+)
+
+func (s *Store) ClaimMailMessage(ctx context.Context, arg db.ClaimMailMessageParams) (int64, error) {
+	n, err := s.queries.ClaimMailMessage(ctx, sqlitedb.ClaimMailMessageParams{MessageID: arg.MessageID, Now: utc(arg.Now)})
+	return n, translate(err)
+}
+
+func (s *Store) ReleaseMailMessage(ctx context.Context, messageID string) error {
+	return translate(s.queries.ReleaseMailMessage(ctx, messageID))
+}
+
+func (s *Store) DeleteSentMailBefore(ctx context.Context, before time.Time) (int64, error) {
+	n, err := s.queries.DeleteSentMailBefore(ctx, utc(before))
+	return n, translate(err)
+}
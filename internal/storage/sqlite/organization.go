@@ -0,0 +1,187 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	db "scratch/internal/storage/database"
+	"scratch/internal/storage/sqlite/sqlitedb"
+)
+
+func (s *Store) CreateOrganization(ctx context.Context, arg db.CreateOrganizationParams) (db.ScratchOrganization, error) {
+	row, err := s.queries.CreateOrganization(ctx, sqlitedb.CreateOrganizationParams{Slug: arg.Slug, Name: arg.Name, Now: utc(arg.Now)})
+	return organization(row), translate(err)
+}
+
+func (s *Store) GetOrganization(ctx context.Context, id int32) (db.ScratchOrganization, error) {
+	row, err := s.queries.GetOrganization(ctx, int64(id))
+	return organization(row), translate(err)
+}
+
+func (s *Store) ListUserOrganizations(ctx context.Context, userID int32) ([]db.ListUserOrganizationsRow, error) {
+	rows, err := s.queries.ListUserOrganizations(ctx, int64(userID))
+	if err != nil {
+		return nil, translate(err)
+	}
+	var organizations []db.ListUserOrganizationsRow
+	for _, row := range rows {
+		organizations = append(organizations, db.ListUserOrganizationsRow{
+			ID:        int32(row.ID),
+			Slug:      row.Slug,
+			Name:      row.Name,
+			CreatedAt: row.CreatedAt,
+			Role:      row.Role,
+			JoinedAt:  row.JoinedAt,
+		})
+	}
+	return organizations, nil
+}
+
+func (s *Store) AddOrganizationMember(ctx context.Context, arg db.AddOrganizationMemberParams) (int64, error) {
+	n, err := s.queries.AddOrganizationMember(ctx, sqlitedb.AddOrganizationMemberParams{
+		OrganizationID: int64(arg.OrganizationID),
+		UserID:         int64(arg.UserID),
+		Role:           arg.Role,
+		Now:            utc(arg.Now),
+	})
+	return n, translate(err)
+}
+
+func (s *Store) GetOrganizationMember(ctx context.Context, arg db.GetOrganizationMemberParams) (db.ScratchOrganizationMember, error) {
+	row, err := s.queries.GetOrganizationMember(ctx, sqlitedb.GetOrganizationMemberParams{
+		OrganizationID: int64(arg.OrganizationID),
+		UserID:         int64(arg.UserID),
+	})
+	return db.ScratchOrganizationMember{
+		OrganizationID: int32(row.OrganizationID),
+		UserID:         int32(row.UserID),
+		Role:           row.Role,
+		JoinedAt:       row.JoinedAt,
+	}, translate(err)
+}
+
+func (s *Store) ListOrganizationMembers(ctx context.Context, organizationID int32) ([]db.ListOrganizationMembersRow, error) {
+	rows, err := s.queries.ListOrganizationMembers(ctx, int64(organizationID))
+	if err != nil {
+		return nil, translate(err)
+	}
+	var members []db.ListOrganizationMembersRow
+	for _, row := range rows {
+		members = append(members, db.ListOrganizationMembersRow{
+			UserID:   int32(row.UserID),
+			Name:     row.Name,
+			Email:    row.Email,
+			Role:     row.Role,
+			JoinedAt: row.JoinedAt,
+		})
+	}
+	return members, nil
+}
+
+func (s *Store) UpdateOrganizationMemberRole(ctx context.Context, arg db.UpdateOrganizationMemberRoleParams) (int64, error) {
+	n, err := s.queries.UpdateOrganizationMemberRole(ctx, sqlitedb.UpdateOrganizationMemberRoleParams{
+		Role:           arg.Role,
+		OrganizationID: int64(arg.OrganizationID),
+		UserID:         int64(arg.UserID),
+	})
+	return n, translate(err)
+}
+
+func (s *Store) RemoveOrganizationMember(ctx context.Context, arg db.RemoveOrganizationMemberParams) (int64, error) {
+	n, err := s.queries.RemoveOrganizationMember(ctx, sqlitedb.RemoveOrganizationMemberParams{
+		OrganizationID: int64(arg.OrganizationID),
+		UserID:         int64(arg.UserID),
+	})
+	return n, translate(err)
+}
+
+func (s *Store) CountOrganizationOwners(ctx context.Context, organizationID int32) (int64, error) {
+	n, err := s.queries.CountOrganizationOwners(ctx, int64(organizationID))
+	return n, translate(err)
+}
+
+func (s *Store) CreateInvitation(ctx context.Context, arg db.CreateInvitationParams) (db.ScratchInvitation, error) {
+	row, err := s.queries.CreateInvitation(ctx, sqlitedb.CreateInvitationParams{
+		OrganizationID: int64(arg.OrganizationID),
+		Email:          arg.Email,
+		Role:           arg.Role,
+		CodeHash:       arg.CodeHash,
+		InvitedBy:      nullInt64(arg.InvitedBy),
+		Now:            utc(arg.Now),
+		ExpiresAt:      utc(arg.ExpiresAt),
+	})
+	return invitation(row), translate(err)
+}
+
+func (s *Store) GetInvitationByCode(ctx context.Context, codeHash string) (db.ScratchInvitation, error) {
+	row, err := s.queries.GetInvitationByCode(ctx, codeHash)
+	return invitation(row), translate(err)
+}
+
+func (s *Store) GetInvitation(ctx context.Context, arg db.GetInvitationParams) (db.ScratchInvitation, error) {
+	row, err := s.queries.GetInvitation(ctx, sqlitedb.GetInvitationParams{ID: int64(arg.ID), OrganizationID: int64(arg.OrganizationID)})
+	return invitation(row), translate(err)
+}
+
+func (s *Store) ListOrganizationInvitations(ctx context.Context, organizationID int32) ([]db.ListOrganizationInvitationsRow, error) {
+	rows, err := s.queries.ListOrganizationInvitations(ctx, int64(organizationID))
+	if err != nil {
+		return nil, translate(err)
+	}
+	var invitations []db.ListOrganizationInvitationsRow
+	for _, row := range rows {
+		invitations = append(invitations, db.ListOrganizationInvitationsRow{
+			ID:        int32(row.ID),
+			Email:     row.Email,
+			Role:      row.Role,
+			InvitedBy: nullInt32(row.InvitedBy),
+			// the names of users who are gone read as empty strings
+			InviterName:  sql.NullString{String: row.InviterName, Valid: row.InvitedBy.Valid},
+			AcceptedBy:   nullInt32(row.AcceptedBy),
+			AccepterName: sql.NullString{String: row.AccepterName, Valid: row.AcceptedBy.Valid},
+			CreatedAt:    row.CreatedAt,
+			ExpiresAt:    row.ExpiresAt,
+			AcceptedAt:   row.AcceptedAt,
+			RevokedAt:    row.RevokedAt,
+		})
+	}
+	return invitations, nil
+}
+
+func (s *Store) AcceptInvitation(ctx context.Context, arg db.AcceptInvitationParams) (int64, error) {
+	n, err := s.queries.AcceptInvitation(ctx, sqlitedb.AcceptInvitationParams{
+		Now:        nullTime(arg.Now),
+		AcceptedBy: nullInt64(arg.AcceptedBy),
+		ID:         int64(arg.ID),
+	})
+	return n, translate(err)
+}
+
+func (s *Store) RevokeInvitation(ctx context.Context, arg db.RevokeInvitationParams) (int64, error) {
+	n, err := s.queries.RevokeInvitation(ctx, sqlitedb.RevokeInvitationParams{
+		Now:            nullTime(arg.Now),
+		ID:             int64(arg.ID),
+		OrganizationID: int64(arg.OrganizationID),
+	})
+	return n, translate(err)
+}
+
+func organization(row sqlitedb.Organization) db.ScratchOrganization {
+	return db.ScratchOrganization{ID: int32(row.ID), Slug: row.Slug, Name: row.Name, CreatedAt: row.CreatedAt}
+}
+
+func invitation(row sqlitedb.Invitation) db.ScratchInvitation {
+	return db.ScratchInvitation{
+		ID:             int32(row.ID),
+		OrganizationID: int32(row.OrganizationID),
+		Email:          row.Email,
+		Role:           row.Role,
+		CodeHash:       row.CodeHash,
+		InvitedBy:      nullInt32(row.InvitedBy),
+		AcceptedBy:     nullInt32(row.AcceptedBy),
+		CreatedAt:      row.CreatedAt,
+		ExpiresAt:      row.ExpiresAt,
+		AcceptedAt:     row.AcceptedAt,
+		RevokedAt:      row.RevokedAt,
+	}
+}
@@ -0,0 +1,72 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	db "scratch/internal/storage/database"
+	"scratch/internal/storage/sqlite/sqlitedb"
+)
+
+func (s *Store) InsertOutboxEvent(ctx context.Context, arg db.InsertOutboxEventParams) error {
+	return translate(s.queries.InsertOutboxEvent(ctx, sqlitedb.InsertOutboxEventParams{
+		AggregateType: arg.AggregateType,
+		AggregateID:   arg.AggregateID,
+		EventType:     arg.EventType,
+		Payload:       string(arg.Payload),
+		OccurredAt:    utc(arg.OccurredAt),
+	}))
+}
+
+func (s *Store) ClaimOutboxEvents(ctx context.Context, arg db.ClaimOutboxEventsParams) ([]db.ClaimOutboxEventsRow, error) {
+	rows, err := s.queries.ClaimOutboxEvents(ctx, sqlitedb.ClaimOutboxEventsParams{
+		LockedUntil: utc(arg.LockedUntil),
+		Now:         utc(arg.Now),
+		BatchSize:   int64(arg.BatchSize),
+	})
+	if err != nil {
+		return nil, translate(err)
+	}
+	var events []db.ClaimOutboxEventsRow
+	for _, row := range rows {
+		events = append(events, db.ClaimOutboxEventsRow{
+			ID:            row.ID,
+			AggregateType: row.AggregateType,
+			AggregateID:   row.AggregateID,
+			EventType:     row.EventType,
+			Payload:       json.RawMessage(row.Payload),
+			OccurredAt:    row.OccurredAt,
+			Attempts:      int32(row.Attempts),
+		})
+	}
+	return events, nil
+}
+
+func (s *Store) MarkOutboxEventPublished(ctx context.Context, arg db.MarkOutboxEventPublishedParams) error {
+	return translate(s.queries.MarkOutboxEventPublished(ctx, sqlitedb.MarkOutboxEventPublishedParams{
+		Now: nullTime(arg.Now),
+		ID:  arg.ID,
+	}))
+}
+
+func (s *Store) MarkOutboxEventFailed(ctx context.Context, arg db.MarkOutboxEventFailedParams) error {
+	return translate(s.queries.MarkOutboxEventFailed(ctx, sqlitedb.MarkOutboxEventFailedParams{
+		NextAttemptAt: utc(arg.NextAttemptAt),
+		LastError:     nullString(arg.LastError),
+		ID:            arg.ID,
+	}))
+}
+
+func (s *Store) KillOutboxEvent(ctx context.Context, arg db.KillOutboxEventParams) error {
+	return translate(s.queries.KillOutboxEvent(ctx, sqlitedb.KillOutboxEventParams{
+		Now:       nullTime(arg.Now),
+		LastError: nullString(arg.LastError),
+		ID:        arg.ID,
+	}))
+}
+
+func (s *Store) DeletePublishedOutboxEvents(ctx context.Context, before time.Time) (int64, error) {
+	n, err := s.queries.DeletePublishedOutboxEvents(ctx, nullTime(before))
+	return n, translate(err)
+}
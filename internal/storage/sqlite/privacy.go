@@ -0,0 +1,180 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	db "scratch/internal/storage/database"
+	"scratch/internal/storage/sqlite/sqlitedb"
+)
+
+func (s *Store) ListUserSessions(ctx context.Context, userID int32) ([]db.ListUserSessionsRow, error) {
+	rows, err := s.queries.ListUserSessions(ctx, int64(userID))
+	if err != nil {
+		return nil, translate(err)
+	}
+	var sessions []db.ListUserSessionsRow
+	for _, row := range rows {
+		sessions = append(sessions, db.ListUserSessionsRow{
+			ID:         int32(row.ID),
+			CreatedAt:  row.CreatedAt,
+			LastSeenAt: row.LastSeenAt,
+			ExpiresAt:  row.ExpiresAt,
+			UserAgent:  row.UserAgent,
+			Ip:         row.Ip,
+			DeviceName: row.DeviceName,
+		})
+	}
+	return sessions, nil
+}
+
+func (s *Store) ListUserRoleGrants(ctx context.Context, userID int32) ([]db.ScratchUserRole, error) {
+	rows, err := s.queries.ListUserRoleGrants(ctx, int64(userID))
+	if err != nil {
+		return nil, translate(err)
+	}
+	var roles []db.ScratchUserRole
+	for _, row := range rows {
+		roles = append(roles, db.ScratchUserRole{UserID: int32(row.UserID), Role: row.Role, GrantedAt: row.GrantedAt})
+	}
+	return roles, nil
+}
+
+func (s *Store) ListUserDevices(ctx context.Context, userID int32) ([]db.ScratchUserDevice, error) {
+	rows, err := s.queries.ListUserDevices(ctx, int64(userID))
+	if err != nil {
+		return nil, translate(err)
+	}
+	var devices []db.ScratchUserDevice
+	for _, row := range rows {
+		devices = append(devices, db.ScratchUserDevice{
+			UserID:      int32(row.UserID),
+			Fingerprint: row.Fingerprint,
+			Name:        row.Name,
+			FirstSeenAt: row.FirstSeenAt,
+			LastSeenAt:  row.LastSeenAt,
+		})
+	}
+	return devices, nil
+}
+
+func (s *Store) ListUserEvents(ctx context.Context, aggregateID string) ([]db.ListUserEventsRow, error) {
+	rows, err := s.queries.ListUserEvents(ctx, aggregateID)
+	if err != nil {
+		return nil, translate(err)
+	}
+	var events []db.ListUserEventsRow
+	for _, row := range rows {
+		events = append(events, db.ListUserEventsRow{
+			ID:         row.ID,
+			EventType:  row.EventType,
+			Payload:    json.RawMessage(row.Payload),
+			OccurredAt: row.OccurredAt,
+		})
+	}
+	return events, nil
+}
+
+func (s *Store) ListUsersDeletedBefore(ctx context.Context, deletedBefore time.Time) ([]int32, error) {
+	rows, err := s.queries.ListUsersDeletedBefore(ctx, nullTime(deletedBefore))
+	if err != nil {
+		return nil, translate(err)
+	}
+	var ids []int32
+	for _, id := range rows {
+		ids = append(ids, int32(id))
+	}
+	return ids, nil
+}
+
+func (s *Store) EraseUser(ctx context.Context, id int32) (int64, error) {
+	n, err := s.queries.EraseUser(ctx, int64(id))
+	return n, translate(err)
+}
+
+func (s *Store) DeleteUserRoles(ctx context.Context, userID int32) (int64, error) {
+	n, err := s.queries.DeleteUserRoles(ctx, int64(userID))
+	return n, translate(err)
+}
+
+func (s *Store) DeleteUserDevices(ctx context.Context, userID int32) (int64, error) {
+	n, err := s.queries.DeleteUserDevices(ctx, int64(userID))
+	return n, translate(err)
+}
+
+func (s *Store) DeleteUserEvents(ctx context.Context, aggregateID string) (int64, error) {
+	n, err := s.queries.DeleteUserEvents(ctx, aggregateID)
+	return n, translate(err)
+}
+
+func (s *Store) RequestDataExport(ctx context.Context, arg db.RequestDataExportParams) (int64, error) {
+	n, err := s.queries.RequestDataExport(ctx, sqlitedb.RequestDataExportParams{UserID: int64(arg.UserID), Now: utc(arg.Now)})
+	return n, translate(err)
+}
+
+func (s *Store) GetDataExport(ctx context.Context, userID int32) (db.GetDataExportRow, error) {
+	row, err := s.queries.GetDataExport(ctx, int64(userID))
+	return db.GetDataExportRow{
+		UserID:      int32(row.UserID),
+		RequestedAt: row.RequestedAt,
+		ReadyAt:     row.ReadyAt,
+	}, translate(err)
+}
+
+func (s *Store) GetDataExportArchive(ctx context.Context, arg db.GetDataExportArchiveParams) ([]byte, error) {
+	archive, err := s.queries.GetDataExportArchive(ctx, sqlitedb.GetDataExportArchiveParams{
+		UserID:     int64(arg.UserID),
+		ReadyAfter: nullTime(arg.ReadyAfter),
+	})
+	return archive, translate(err)
+}
+
+func (s *Store) SaveDataExport(ctx context.Context, arg db.SaveDataExportParams) (int64, error) {
+	n, err := s.queries.SaveDataExport(ctx, sqlitedb.SaveDataExportParams{
+		Archive: arg.Archive,
+		Now:     nullTime(arg.Now),
+		UserID:  int64(arg.UserID),
+	})
+	return n, translate(err)
+}
+
+func (s *Store) DeleteDataExport(ctx context.Context, userID int32) (int64, error) {
+	n, err := s.queries.DeleteDataExport(ctx, int64(userID))
+	return n, translate(err)
+}
+
+func (s *Store) DeleteExpiredDataExports(ctx context.Context, before time.Time) (int64, error) {
+	n, err := s.queries.DeleteExpiredDataExports(ctx, nullTime(before))
+	return n, translate(err)
+}
+
+func (s *Store) ListUserInvitations(ctx context.Context, userID int32) ([]db.ListUserInvitationsRow, error) {
+	rows, err := s.queries.ListUserInvitations(ctx, int64(userID))
+	if err != nil {
+		return nil, translate(err)
+	}
+	var invitations []db.ListUserInvitationsRow
+	for _, row := range rows {
+		invitations = append(invitations, db.ListUserInvitationsRow{
+			ID:           int32(row.ID),
+			Organization: row.Organization,
+			Role:         row.Role,
+			CreatedAt:    row.CreatedAt,
+			ExpiresAt:    row.ExpiresAt,
+			AcceptedAt:   row.AcceptedAt,
+			RevokedAt:    row.RevokedAt,
+		})
+	}
+	return invitations, nil
+}
+
+func (s *Store) DeleteUserMemberships(ctx context.Context, userID int32) (int64, error) {
+	n, err := s.queries.DeleteUserMemberships(ctx, int64(userID))
+	return n, translate(err)
+}
+
+func (s *Store) DeleteUserInvitations(ctx context.Context, userID int32) (int64, error) {
+	n, err := s.queries.DeleteUserInvitations(ctx, int64(userID))
+	return n, translate(err)
+}
@@ -0,0 +1,113 @@
+-- name: GetUserByEmail :one
+SELECT * FROM user WHERE lower(email) = lower(sqlc.arg(email)) AND deleted_at IS NULL;
+
+-- name: GetUserByID :one
+SELECT * FROM user WHERE id = ? AND deleted_at IS NULL;
+
+-- name: GetDeletedUserByEmail :one
+-- The email may be registered again after the deletion, the latest deleted
+-- account of it is the one to restore.
+SELECT * FROM user WHERE lower(email) = lower(sqlc.arg(email)) AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id DESC LIMIT 1;
+
+-- name: UpdateUserProfile :execrows
+UPDATE user SET name = ?, locale = ? WHERE id = ? AND deleted_at IS NULL;
+
+-- name: CreateUser :one
+INSERT INTO user (name, email, password, locale)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: CreateSession :one
+INSERT INTO session (user_id, refresh_token_hash, created_at, last_seen_at, expires_at, user_agent, ip, device_name)
+VALUES (sqlc.arg(user_id), sqlc.arg(refresh_token_hash), sqlc.arg(now), sqlc.arg(now), sqlc.arg(expires_at), sqlc.arg(user_agent), sqlc.arg(ip), sqlc.arg(device_name))
+RETURNING id;
+
+-- name: GetSessionByRefreshToken :one
+SELECT s.id, s.user_id
+FROM session s
+JOIN user u ON u.id = s.user_id
+WHERE s.refresh_token_hash = sqlc.arg(refresh_token_hash) AND s.expires_at > sqlc.arg(now)
+    AND u.disabled_at IS NULL AND u.deleted_at IS NULL;
+
+-- name: RefreshSession :execrows
+UPDATE session
+SET refresh_token_hash = sqlc.arg(new_refresh_token_hash), last_seen_at = sqlc.arg(now), expires_at = sqlc.arg(expires_at),
+    user_agent = COALESCE(NULLIF(CAST(sqlc.arg(user_agent) AS TEXT), ''), user_agent), ip = COALESCE(NULLIF(CAST(sqlc.arg(ip) AS TEXT), ''), ip)
+WHERE id = sqlc.arg(id) AND refresh_token_hash = sqlc.arg(refresh_token_hash);
+
+-- name: CreateBrowserSession :one
+INSERT INTO session (user_id, token_hash, csrf_token, created_at, last_seen_at, expires_at, user_agent, ip, device_name)
+VALUES (sqlc.arg(user_id), sqlc.arg(token_hash), sqlc.arg(csrf_token), sqlc.arg(now), sqlc.arg(now), sqlc.arg(expires_at), sqlc.arg(user_agent), sqlc.arg(ip), sqlc.arg(device_name))
+RETURNING id;
+
+-- name: GetBrowserSession :one
+SELECT s.id, s.user_id, s.csrf_token, s.expires_at, s.last_seen_at
+FROM session s
+JOIN user u ON u.id = s.user_id
+WHERE s.token_hash = sqlc.arg(token_hash) AND s.expires_at > sqlc.arg(now)
+    AND u.disabled_at IS NULL AND u.deleted_at IS NULL;
+
+-- name: TouchSession :exec
+UPDATE session SET last_seen_at = sqlc.arg(now), ip = COALESCE(NULLIF(CAST(sqlc.arg(ip) AS TEXT), ''), ip) WHERE id = sqlc.arg(id);
+
+-- name: DeleteBrowserSession :execrows
+DELETE FROM session WHERE token_hash = ?;
+
+-- name: ListSessions :many
+SELECT id, token_hash, created_at, last_seen_at, expires_at, user_agent, ip, device_name
+FROM session
+WHERE user_id = sqlc.arg(user_id) AND expires_at > sqlc.arg(now)
+ORDER BY last_seen_at DESC, id DESC;
+
+-- name: DeleteUserSession :execrows
+DELETE FROM session WHERE id = ? AND user_id = ?;
+
+-- name: InsertDevice :execrows
+INSERT INTO user_device (user_id, fingerprint, name, first_seen_at, last_seen_at)
+VALUES (sqlc.arg(user_id), sqlc.arg(fingerprint), sqlc.arg(name), sqlc.arg(now), sqlc.arg(now))
+ON CONFLICT (user_id, fingerprint) DO NOTHING;
+
+-- name: TouchDevice :exec
+UPDATE user_device SET last_seen_at = sqlc.arg(now) WHERE user_id = sqlc.arg(user_id) AND fingerprint = sqlc.arg(fingerprint);
+
+-- name: CountUserDevices :one
+SELECT count(*) FROM user_device WHERE user_id = ?;
+
+-- name: DisableUser :execrows
+UPDATE user SET disabled_at = COALESCE(disabled_at, sqlc.arg(now)) WHERE lower(email) = lower(sqlc.arg(email)) AND deleted_at IS NULL;
+
+-- name: UpdateUserPassword :one
+UPDATE user SET password = sqlc.arg(password) WHERE lower(email) = lower(sqlc.arg(email)) AND deleted_at IS NULL RETURNING id;
+
+-- name: SetUserLocale :execrows
+UPDATE user SET locale = sqlc.arg(locale) WHERE lower(email) = lower(sqlc.arg(email)) AND deleted_at IS NULL;
+
+-- name: DeleteUser :execrows
+UPDATE user SET deleted_at = sqlc.arg(now) WHERE id = sqlc.arg(id) AND deleted_at IS NULL;
+
+-- name: RestoreUser :execrows
+UPDATE user SET deleted_at = NULL WHERE id = sqlc.arg(id) AND deleted_at > sqlc.arg(deleted_after);
+
+-- name: GrantUserRole :exec
+INSERT INTO user_role (user_id, role, granted_at) VALUES (?, ?, ?) ON CONFLICT DO NOTHING;
+
+-- name: ListUserRoles :many
+SELECT role FROM user_role WHERE user_id = ? ORDER BY role;
+
+-- name: DeleteExpiredSessions :execrows
+DELETE FROM session WHERE expires_at < sqlc.arg(now);
+
+-- name: DeleteAllSessions :execrows
+DELETE FROM session;
+
+-- name: DeleteUserSessions :execrows
+DELETE FROM session WHERE user_id = ?;
+
+-- name: DeleteOtherUserSessions :execrows
+-- Deletes the sessions of the user but the browser session keep_hash, api
+-- sessions included.
+DELETE FROM session WHERE user_id = sqlc.arg(user_id) AND (token_hash IS NULL OR token_hash <> sqlc.arg(keep_hash));
+
+-- name: CleanUserTable :exec
+DELETE FROM user;
@@ -0,0 +1,30 @@
+-- name: InsertIdempotencyKey :execrows
+INSERT INTO idempotency_key (key, operation, fingerprint, locked_at, expires_at)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (key, operation) DO NOTHING;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_key WHERE key = ? AND operation = ?;
+
+-- name: TakeOverIdempotencyKey :execrows
+UPDATE idempotency_key
+SET fingerprint = sqlc.arg(fingerprint), locked_at = sqlc.arg(now), expires_at = sqlc.arg(expires_at),
+    status_code = NULL, response_header = NULL, response_body = NULL
+WHERE key = sqlc.arg(key) AND operation = sqlc.arg(operation)
+  AND (expires_at < sqlc.arg(now) OR (status_code IS NULL AND locked_at < sqlc.arg(stale_before)));
+
+-- name: CompleteIdempotencyKey :execrows
+-- The request holding the lock since locked_at stores its response, unless
+-- a retry took the key over meanwhile.
+UPDATE idempotency_key
+SET status_code = sqlc.arg(status_code), response_header = sqlc.arg(response_header), response_body = sqlc.arg(response_body)
+WHERE key = sqlc.arg(key) AND operation = sqlc.arg(operation) AND locked_at = sqlc.arg(locked_at) AND status_code IS NULL;
+
+-- name: DeleteIdempotencyKey :exec
+-- The request holding the lock since locked_at releases the key, unless a
+-- retry took it over meanwhile.
+DELETE FROM idempotency_key
+WHERE key = sqlc.arg(key) AND operation = sqlc.arg(operation) AND locked_at = sqlc.arg(locked_at) AND status_code IS NULL;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_key WHERE expires_at < ?;
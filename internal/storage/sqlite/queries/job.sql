@@ -0,0 +1,53 @@
+-- name: EnqueueJob :execrows
+INSERT INTO job (kind, payload, unique_key, max_attempts, run_at, created_at)
+VALUES (sqlc.arg(kind), sqlc.arg(payload), sqlc.narg(unique_key), sqlc.arg(max_attempts), sqlc.arg(run_at), sqlc.arg(now))
+ON CONFLICT (kind, unique_key) DO NOTHING;
+
+-- name: ClaimJobs :many
+-- Claims the due jobs, and the running ones whose worker is gone for longer
+-- than the lock timeout and which have attempts left, see KillStaleJobs.
+-- Writers are serialized, so there is nothing to skip.
+UPDATE job
+SET state = 'running', attempts = attempts + 1, locked_at = sqlc.arg(now), locked_by = sqlc.arg(worker)
+WHERE id IN (
+    SELECT j.id FROM job j
+    WHERE (j.state = 'pending' AND j.run_at <= sqlc.arg(now))
+       OR (j.state = 'running' AND j.locked_at < sqlc.arg(stale_before) AND j.attempts < j.max_attempts)
+    ORDER BY j.run_at, j.id
+    LIMIT sqlc.arg(batch_size)
+)
+RETURNING id, kind, payload, attempts, max_attempts;
+
+-- name: CompleteJob :execrows
+UPDATE job
+SET state = 'done', finished_at = sqlc.arg(now), locked_at = NULL, locked_by = NULL, last_error = NULL
+WHERE id = sqlc.arg(id) AND state = 'running' AND attempts = sqlc.arg(attempts);
+
+-- name: RetryJob :execrows
+UPDATE job
+SET state = 'pending', run_at = sqlc.arg(run_at), locked_at = NULL, locked_by = NULL, last_error = sqlc.arg(last_error)
+WHERE id = sqlc.arg(id) AND state = 'running' AND attempts = sqlc.arg(attempts);
+
+-- name: KillJob :execrows
+UPDATE job
+SET state = 'dead', finished_at = sqlc.arg(now), locked_at = NULL, locked_by = NULL, last_error = sqlc.arg(last_error)
+WHERE id = sqlc.arg(id) AND state = 'running' AND attempts = sqlc.arg(attempts);
+
+-- name: KillStaleJobs :execrows
+-- Kills the running jobs whose worker is gone on their last attempt.
+UPDATE job
+SET state = 'dead', finished_at = sqlc.arg(now), locked_at = NULL, locked_by = NULL, last_error = sqlc.arg(last_error)
+WHERE state = 'running' AND locked_at < sqlc.arg(stale_before) AND attempts >= max_attempts;
+
+-- name: ListDeadJobs :many
+SELECT id, kind, payload, attempts, last_error, finished_at FROM job
+WHERE state = 'dead'
+ORDER BY finished_at DESC NULLS FIRST, id DESC;
+
+-- name: ReviveJob :execrows
+UPDATE job
+SET state = 'pending', attempts = 0, run_at = sqlc.arg(now), finished_at = NULL
+WHERE id = sqlc.arg(id) AND state = 'dead';
+
+-- name: DeleteFinishedJobs :execrows
+DELETE FROM job WHERE state = 'done' AND finished_at < sqlc.arg(before);
@@ -0,0 +1,9 @@
+-- name: ClaimMailMessage :execrows
+INSERT INTO mail_sent (message_id, sent_at) VALUES (sqlc.arg(message_id), sqlc.arg(now))
+ON CONFLICT (message_id) DO NOTHING;
+
+-- name: ReleaseMailMessage :exec
+DELETE FROM mail_sent WHERE message_id = sqlc.arg(message_id);
+
+-- name: DeleteSentMailBefore :execrows
+DELETE FROM mail_sent WHERE sent_at < sqlc.arg(before);
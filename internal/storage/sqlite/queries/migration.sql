@@ -0,0 +1,4 @@
+-- name: MigrationMessage :one
+SELECT message
+FROM initial_migration
+LIMIT 1;
@@ -0,0 +1,62 @@
+-- name: CreateOrganization :one
+INSERT INTO organization (slug, name, created_at) VALUES (sqlc.arg(slug), sqlc.arg(name), sqlc.arg(now))
+RETURNING *;
+
+-- name: GetOrganization :one
+SELECT * FROM organization WHERE id = ?;
+
+-- name: ListUserOrganizations :many
+SELECT o.id, o.slug, o.name, o.created_at, m.role, m.joined_at
+FROM organization o JOIN organization_member m ON m.organization_id = o.id
+WHERE m.user_id = ? ORDER BY o.name, o.id;
+
+-- name: AddOrganizationMember :execrows
+INSERT INTO organization_member (organization_id, user_id, role, joined_at)
+VALUES (sqlc.arg(organization_id), sqlc.arg(user_id), sqlc.arg(role), sqlc.arg(now))
+ON CONFLICT (organization_id, user_id) DO NOTHING;
+
+-- name: GetOrganizationMember :one
+SELECT * FROM organization_member WHERE organization_id = sqlc.arg(organization_id) AND user_id = sqlc.arg(user_id);
+
+-- name: ListOrganizationMembers :many
+SELECT m.user_id, u.name, u.email, m.role, m.joined_at
+FROM organization_member m JOIN user u ON u.id = m.user_id
+WHERE m.organization_id = ? AND u.deleted_at IS NULL ORDER BY m.joined_at, m.user_id;
+
+-- name: UpdateOrganizationMemberRole :execrows
+UPDATE organization_member SET role = sqlc.arg(role)
+WHERE organization_id = sqlc.arg(organization_id) AND user_id = sqlc.arg(user_id);
+
+-- name: RemoveOrganizationMember :execrows
+DELETE FROM organization_member WHERE organization_id = sqlc.arg(organization_id) AND user_id = sqlc.arg(user_id);
+
+-- name: CountOrganizationOwners :one
+SELECT count(*) FROM organization_member WHERE organization_id = ? AND role = 'owner';
+
+-- name: CreateInvitation :one
+INSERT INTO invitation (organization_id, email, role, code_hash, invited_by, created_at, expires_at)
+VALUES (sqlc.arg(organization_id), sqlc.arg(email), sqlc.arg(role), sqlc.arg(code_hash), sqlc.arg(invited_by), sqlc.arg(now), sqlc.arg(expires_at))
+RETURNING *;
+
+-- name: GetInvitationByCode :one
+SELECT * FROM invitation WHERE code_hash = ?;
+
+-- name: GetInvitation :one
+SELECT * FROM invitation WHERE id = sqlc.arg(id) AND organization_id = sqlc.arg(organization_id);
+
+-- name: ListOrganizationInvitations :many
+SELECT i.id, i.email, i.role, i.invited_by, COALESCE(inviter.name, '') AS inviter_name,
+    i.accepted_by, COALESCE(accepter.name, '') AS accepter_name,
+    i.created_at, i.expires_at, i.accepted_at, i.revoked_at
+FROM invitation i
+LEFT JOIN user inviter ON inviter.id = i.invited_by
+LEFT JOIN user accepter ON accepter.id = i.accepted_by
+WHERE i.organization_id = ? ORDER BY i.created_at DESC, i.id DESC;
+
+-- name: AcceptInvitation :execrows
+UPDATE invitation SET accepted_at = sqlc.arg(now), accepted_by = sqlc.arg(accepted_by)
+WHERE id = sqlc.arg(id) AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > sqlc.arg(now);
+
+-- name: RevokeInvitation :execrows
+UPDATE invitation SET revoked_at = sqlc.arg(now)
+WHERE id = sqlc.arg(id) AND organization_id = sqlc.arg(organization_id) AND accepted_at IS NULL AND revoked_at IS NULL;
@@ -0,0 +1,38 @@
+-- name: InsertOutboxEvent :exec
+INSERT INTO outbox (aggregate_type, aggregate_id, event_type, payload, occurred_at, next_attempt_at)
+VALUES (sqlc.arg(aggregate_type), sqlc.arg(aggregate_id), sqlc.arg(event_type), sqlc.arg(payload), sqlc.arg(occurred_at), sqlc.arg(occurred_at));
+
+-- name: ClaimOutboxEvents :many
+-- Claims the due events until locked_until, the events are published outside
+-- of the transaction. Only the oldest pending event of every aggregate is
+-- due, so the events of an aggregate are published in order.
+UPDATE outbox SET next_attempt_at = sqlc.arg(locked_until)
+WHERE id IN (
+    SELECT o.id FROM outbox o
+    WHERE o.published_at IS NULL AND o.dead_at IS NULL AND o.next_attempt_at <= sqlc.arg(now)
+      AND NOT EXISTS (
+        SELECT 1 FROM outbox p
+        WHERE p.aggregate_type = o.aggregate_type AND p.aggregate_id = o.aggregate_id
+          AND p.published_at IS NULL AND p.dead_at IS NULL AND p.id < o.id
+      )
+    ORDER BY o.id
+    LIMIT sqlc.arg(batch_size)
+)
+RETURNING id, aggregate_type, aggregate_id, event_type, payload, occurred_at, attempts;
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE outbox SET published_at = sqlc.arg(now), attempts = attempts + 1, last_error = NULL
+WHERE id = sqlc.arg(id) AND published_at IS NULL;
+
+-- name: MarkOutboxEventFailed :exec
+UPDATE outbox
+SET attempts = attempts + 1, next_attempt_at = sqlc.arg(next_attempt_at), last_error = sqlc.arg(last_error)
+WHERE id = sqlc.arg(id) AND published_at IS NULL;
+
+-- name: KillOutboxEvent :exec
+UPDATE outbox
+SET attempts = attempts + 1, dead_at = sqlc.arg(now), last_error = sqlc.arg(last_error)
+WHERE id = sqlc.arg(id) AND published_at IS NULL;
+
+-- name: DeletePublishedOutboxEvents :execrows
+DELETE FROM outbox WHERE published_at < sqlc.arg(before);
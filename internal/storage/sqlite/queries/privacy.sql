@@ -0,0 +1,61 @@
+-- name: ListUserSessions :many
+SELECT id, created_at, last_seen_at, expires_at, user_agent, ip, device_name
+FROM session WHERE user_id = ? ORDER BY id;
+
+-- name: ListUserRoleGrants :many
+SELECT * FROM user_role WHERE user_id = ? ORDER BY role;
+
+-- name: ListUserDevices :many
+SELECT * FROM user_device WHERE user_id = ? ORDER BY first_seen_at, fingerprint;
+
+-- name: ListUserEvents :many
+SELECT id, event_type, payload, occurred_at FROM outbox
+WHERE aggregate_type = 'user' AND aggregate_id = sqlc.arg(aggregate_id) ORDER BY id;
+
+-- name: ListUsersDeletedBefore :many
+SELECT id FROM user WHERE deleted_at < sqlc.arg(deleted_before) ORDER BY id;
+
+-- name: EraseUser :execrows
+DELETE FROM user WHERE id = ?;
+
+-- name: DeleteUserRoles :execrows
+DELETE FROM user_role WHERE user_id = ?;
+
+-- name: DeleteUserDevices :execrows
+DELETE FROM user_device WHERE user_id = ?;
+
+-- name: DeleteUserEvents :execrows
+DELETE FROM outbox WHERE aggregate_type = 'user' AND aggregate_id = sqlc.arg(aggregate_id);
+
+-- name: RequestDataExport :execrows
+INSERT INTO data_export (user_id, requested_at) VALUES (sqlc.arg(user_id), sqlc.arg(now))
+ON CONFLICT (user_id) DO UPDATE SET requested_at = excluded.requested_at, ready_at = NULL, archive = NULL
+WHERE data_export.ready_at IS NOT NULL;
+
+-- name: GetDataExport :one
+SELECT user_id, requested_at, ready_at FROM data_export WHERE user_id = ?;
+
+-- name: GetDataExportArchive :one
+SELECT archive FROM data_export WHERE user_id = sqlc.arg(user_id) AND ready_at > sqlc.arg(ready_after);
+
+-- name: SaveDataExport :execrows
+UPDATE data_export SET archive = sqlc.arg(archive), ready_at = sqlc.arg(now)
+WHERE user_id = sqlc.arg(user_id) AND ready_at IS NULL;
+
+-- name: DeleteDataExport :execrows
+DELETE FROM data_export WHERE user_id = ?;
+
+-- name: DeleteExpiredDataExports :execrows
+DELETE FROM data_export WHERE ready_at < sqlc.arg(before);
+
+-- name: ListUserInvitations :many
+SELECT i.id, o.name AS organization, i.role, i.created_at, i.expires_at, i.accepted_at, i.revoked_at
+FROM invitation i JOIN organization o ON o.id = i.organization_id
+WHERE lower(i.email) = (SELECT lower(u.email) FROM user u WHERE u.id = sqlc.arg(user_id)) ORDER BY i.id;
+
+-- name: DeleteUserMemberships :execrows
+DELETE FROM organization_member WHERE user_id = ?;
+
+-- name: DeleteUserInvitations :execrows
+DELETE FROM invitation
+WHERE lower(email) = (SELECT lower(u.email) FROM user u WHERE u.id = sqlc.arg(user_id));
@@ -0,0 +1,11 @@
+-- name: EnsureRateLimit :exec
+INSERT INTO rate_limit (key, tat) VALUES (?, ?) ON CONFLICT (key) DO NOTHING;
+
+-- name: LockRateLimit :one
+SELECT tat FROM rate_limit WHERE key = ?;
+
+-- name: UpdateRateLimit :exec
+UPDATE rate_limit SET tat = ? WHERE key = ?;
+
+-- name: DeleteExpiredRateLimits :execrows
+DELETE FROM rate_limit WHERE tat < ?;
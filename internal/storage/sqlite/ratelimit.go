@@ -0,0 +1,29 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	db "scratch/internal/storage/database"
+	"scratch/internal/storage/sqlite/sqlitedb"
+)
+
+func (s *Store) EnsureRateLimit(ctx context.Context, arg db.EnsureRateLimitParams) error {
+	return translate(s.queries.EnsureRateLimit(ctx, sqlitedb.EnsureRateLimitParams{Key: arg.Key, Tat: utc(arg.Tat)}))
+}
+
+// LockRateLimit reads the bucket, the transaction it runs in already holds
+// the only connection.
+func (s *Store) LockRateLimit(ctx context.Context, key string) (time.Time, error) {
+	tat, err := s.queries.LockRateLimit(ctx, key)
+	return tat, translate(err)
+}
+
+func (s *Store) UpdateRateLimit(ctx context.Context, arg db.UpdateRateLimitParams) error {
+	return translate(s.queries.UpdateRateLimit(ctx, sqlitedb.UpdateRateLimitParams{Key: arg.Key, Tat: utc(arg.Tat)}))
+}
+
+func (s *Store) DeleteExpiredRateLimits(ctx context.Context, tat time.Time) (int64, error) {
+	n, err := s.queries.DeleteExpiredRateLimits(ctx, utc(tat))
+	return n, translate(err)
+}
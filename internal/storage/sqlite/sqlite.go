@@ -0,0 +1,132 @@
+// Package sqlite runs the application on a single SQLite file, for self-hosting
+// without postgres. Store implements db.Querier over the queries generated
+// from queries/, with the semantics of the postgres ones, and translates the
+// errors of SQLite to theirs.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	db "scratch/internal/storage/database"
+	"scratch/internal/storage/sqlite/sqlitedb"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+var (
+	_ db.Querier    = (*Store)(nil)
+	_ db.Transactor = (*Store)(nil)
+)
+
+// Open opens the database file at path, creating it when missing. Times are
+// written in a format that sorts as text, and foreign keys are enforced.
+//
+// SQLite allows a single writer, so the handle has a single connection and
+// every query and transaction waits for its turn instead of failing as busy.
+func Open(path string) (*sql.DB, error) {
+	query := url.Values{}
+	query.Add("_pragma", "foreign_keys(1)")
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Add("_pragma", "busy_timeout(5000)")
+	query.Set("_time_format", "sqlite")
+
+	database, err := sql.Open("sqlite", "file:"+path+"?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite database: %w", err)
+	}
+	database.SetMaxOpenConns(1)
+	return database, nil
+}
+
+// Store is a db.Querier and db.Transactor over an SQLite database.
+type Store struct {
+	database *sql.DB
+	queries  *sqlitedb.Queries
+}
+
+func New(database *sql.DB) *Store {
+	return &Store{database: database, queries: sqlitedb.New(database)}
+}
+
+// InTx runs fn in a transaction. Transactions are serialized by the single
+// connection, so they are never retried.
+func (s *Store) InTx(ctx context.Context, fn func(q db.Querier) error) error {
+	tx, err := s.database.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	if err := fn(&Store{queries: s.queries.WithTx(tx)}); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", translate(err))
+	}
+	return nil
+}
+
+func (s *Store) MigrationMessage(ctx context.Context) (string, error) {
+	message, err := s.queries.MigrationMessage(ctx)
+	return message, translate(err)
+}
+
+// stateError gives an SQLite error the SQLSTATE code postgres reports for the
+// same problem.
+type stateError struct {
+	state string
+	err   *sqlite.Error
+}
+
+func (e *stateError) Error() string    { return e.err.Error() }
+func (e *stateError) Unwrap() error    { return e.err }
+func (e *stateError) SQLState() string { return e.state }
+
+// sqlStates maps the extended result codes of violated constraints.
+var sqlStates = map[int]string{
+	sqlite3.SQLITE_CONSTRAINT_NOTNULL:    db.NotNullViolation,
+	sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY: db.ForeignKeyViolation,
+	sqlite3.SQLITE_CONSTRAINT_UNIQUE:     db.UniqueViolation,
+	sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY: db.UniqueViolation,
+}
+
+// translate returns db.ErrNoRows for sql.ErrNoRows, and the violated
+// constraints as SQLStateErrors.
+func translate(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.ErrNoRows
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		if state, ok := sqlStates[sqliteErr.Code()]; ok {
+			return &stateError{state: state, err: sqliteErr}
+		}
+	}
+	return err
+}
+
+// utc converts t to UTC, since times are compared as text.
+func utc(t time.Time) time.Time {
+	return t.UTC()
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: true}
+}
+
+func nullInt64(i sql.NullInt32) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(i.Int32), Valid: i.Valid}
+}
+
+func nullInt32(i sql.NullInt64) sql.NullInt32 {
+	return sql.NullInt32{Int32: int32(i.Int64), Valid: i.Valid}
+}
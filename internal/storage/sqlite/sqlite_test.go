@@ -0,0 +1,42 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	db "scratch/internal/storage/database"
+	"scratch/internal/storage/database/dbtest"
+	"scratch/internal/storage/migrations"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	dbtest.TestQuerier(t, func(t *testing.T) (db.Querier, db.Transactor) {
+		database, err := Open(filepath.Join(t.TempDir(), "chatto.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { database.Close() })
+		require.NoError(t, migrations.SQLite.Up(context.Background(), database))
+
+		s := New(database)
+		return s, s
+	})
+}
+
+func TestStore_MigrateDown(t *testing.T) {
+	ctx := context.Background()
+	database, err := Open(filepath.Join(t.TempDir(), "chatto.db"))
+	require.NoError(t, err)
+	defer database.Close()
+
+	require.NoError(t, migrations.SQLite.Up(ctx, database))
+	message, err := New(database).MigrationMessage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "successful migration", message)
+
+	require.NoError(t, migrations.SQLite.To(ctx, database, 0))
+	_, err = New(database).MigrationMessage(ctx)
+	assert.Error(t, err, "dropped")
+}
@@ -0,0 +1,620 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.20.0
+// source: account.sql
+
+package sqlitedb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const cleanUserTable = `-- name: CleanUserTable :exec
+DELETE FROM user
+`
+
+func (q *Queries) CleanUserTable(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, cleanUserTable)
+	return err
+}
+
+const countUserDevices = `-- name: CountUserDevices :one
+SELECT count(*) FROM user_device WHERE user_id = ?
+`
+
+func (q *Queries) CountUserDevices(ctx context.Context, userID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUserDevices, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createBrowserSession = `-- name: CreateBrowserSession :one
+INSERT INTO session (user_id, token_hash, csrf_token, created_at, last_seen_at, expires_at, user_agent, ip, device_name)
+VALUES (?1, ?2, ?3, ?4, ?4, ?5, ?6, ?7, ?8)
+RETURNING id
+`
+
+type CreateBrowserSessionParams struct {
+	UserID     int64
+	TokenHash  sql.NullString
+	CsrfToken  sql.NullString
+	Now        time.Time
+	ExpiresAt  sql.NullTime
+	UserAgent  string
+	Ip         string
+	DeviceName string
+}
+
+func (q *Queries) CreateBrowserSession(ctx context.Context, arg CreateBrowserSessionParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, createBrowserSession,
+		arg.UserID,
+		arg.TokenHash,
+		arg.CsrfToken,
+		arg.Now,
+		arg.ExpiresAt,
+		arg.UserAgent,
+		arg.Ip,
+		arg.DeviceName,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createSession = `-- name: CreateSession :one
+INSERT INTO session (user_id, refresh_token_hash, created_at, last_seen_at, expires_at, user_agent, ip, device_name)
+VALUES (?1, ?2, ?3, ?3, ?4, ?5, ?6, ?7)
+RETURNING id
+`
+
+type CreateSessionParams struct {
+	UserID           int64
+	RefreshTokenHash sql.NullString
+	Now              time.Time
+	ExpiresAt        sql.NullTime
+	UserAgent        string
+	Ip               string
+	DeviceName       string
+}
+
+func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, createSession,
+		arg.UserID,
+		arg.RefreshTokenHash,
+		arg.Now,
+		arg.ExpiresAt,
+		arg.UserAgent,
+		arg.Ip,
+		arg.DeviceName,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createUser = `-- name: CreateUser :one
+INSERT INTO user (name, email, password, locale)
+VALUES (?, ?, ?, ?)
+RETURNING id, name, email, password, disabled_at, locale, deleted_at
+`
+
+type CreateUserParams struct {
+	Name     string
+	Email    string
+	Password string
+	Locale   sql.NullString
+}
+
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, createUser,
+		arg.Name,
+		arg.Email,
+		arg.Password,
+		arg.Locale,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.Password,
+		&i.DisabledAt,
+		&i.Locale,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const deleteAllSessions = `-- name: DeleteAllSessions :execrows
+DELETE FROM session
+`
+
+func (q *Queries) DeleteAllSessions(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteAllSessions)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteBrowserSession = `-- name: DeleteBrowserSession :execrows
+DELETE FROM session WHERE token_hash = ?
+`
+
+func (q *Queries) DeleteBrowserSession(ctx context.Context, tokenHash sql.NullString) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteBrowserSession, tokenHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteExpiredSessions = `-- name: DeleteExpiredSessions :execrows
+DELETE FROM session WHERE expires_at < ?1
+`
+
+func (q *Queries) DeleteExpiredSessions(ctx context.Context, now sql.NullTime) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredSessions, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteOtherUserSessions = `-- name: DeleteOtherUserSessions :execrows
+DELETE FROM session WHERE user_id = ?1 AND (token_hash IS NULL OR token_hash <> ?2)
+`
+
+type DeleteOtherUserSessionsParams struct {
+	UserID   int64
+	KeepHash sql.NullString
+}
+
+// Deletes the sessions of the user but the browser session keep_hash, api
+// sessions included.
+func (q *Queries) DeleteOtherUserSessions(ctx context.Context, arg DeleteOtherUserSessionsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOtherUserSessions, arg.UserID, arg.KeepHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUser = `-- name: DeleteUser :execrows
+UPDATE user SET deleted_at = ?1 WHERE id = ?2 AND deleted_at IS NULL
+`
+
+type DeleteUserParams struct {
+	Now sql.NullTime
+	ID  int64
+}
+
+func (q *Queries) DeleteUser(ctx context.Context, arg DeleteUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUser, arg.Now, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserSession = `-- name: DeleteUserSession :execrows
+DELETE FROM session WHERE id = ? AND user_id = ?
+`
+
+type DeleteUserSessionParams struct {
+	ID     int64
+	UserID int64
+}
+
+func (q *Queries) DeleteUserSession(ctx context.Context, arg DeleteUserSessionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserSession, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserSessions = `-- name: DeleteUserSessions :execrows
+DELETE FROM session WHERE user_id = ?
+`
+
+func (q *Queries) DeleteUserSessions(ctx context.Context, userID int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserSessions, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const disableUser = `-- name: DisableUser :execrows
+UPDATE user SET disabled_at = COALESCE(disabled_at, ?1) WHERE lower(email) = lower(?2) AND deleted_at IS NULL
+`
+
+type DisableUserParams struct {
+	Now   sql.NullTime
+	Email string
+}
+
+func (q *Queries) DisableUser(ctx context.Context, arg DisableUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, disableUser, arg.Now, arg.Email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getBrowserSession = `-- name: GetBrowserSession :one
+SELECT s.id, s.user_id, s.csrf_token, s.expires_at, s.last_seen_at
+FROM session s
+JOIN user u ON u.id = s.user_id
+WHERE s.token_hash = ?1 AND s.expires_at > ?2
+    AND u.disabled_at IS NULL AND u.deleted_at IS NULL
+`
+
+type GetBrowserSessionParams struct {
+	TokenHash sql.NullString
+	Now       sql.NullTime
+}
+
+type GetBrowserSessionRow struct {
+	ID         int64
+	UserID     int64
+	CsrfToken  sql.NullString
+	ExpiresAt  sql.NullTime
+	LastSeenAt time.Time
+}
+
+func (q *Queries) GetBrowserSession(ctx context.Context, arg GetBrowserSessionParams) (GetBrowserSessionRow, error) {
+	row := q.db.QueryRowContext(ctx, getBrowserSession, arg.TokenHash, arg.Now)
+	var i GetBrowserSessionRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CsrfToken,
+		&i.ExpiresAt,
+		&i.LastSeenAt,
+	)
+	return i, err
+}
+
+const getDeletedUserByEmail = `-- name: GetDeletedUserByEmail :one
+SELECT id, name, email, password, disabled_at, locale, deleted_at FROM user WHERE lower(email) = lower(?1) AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id DESC LIMIT 1
+`
+
+// The email may be registered again after the deletion, the latest deleted
+// account of it is the one to restore.
+func (q *Queries) GetDeletedUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getDeletedUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.Password,
+		&i.DisabledAt,
+		&i.Locale,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getSessionByRefreshToken = `-- name: GetSessionByRefreshToken :one
+SELECT s.id, s.user_id
+FROM session s
+JOIN user u ON u.id = s.user_id
+WHERE s.refresh_token_hash = ?1 AND s.expires_at > ?2
+    AND u.disabled_at IS NULL AND u.deleted_at IS NULL
+`
+
+type GetSessionByRefreshTokenParams struct {
+	RefreshTokenHash sql.NullString
+	Now              sql.NullTime
+}
+
+type GetSessionByRefreshTokenRow struct {
+	ID     int64
+	UserID int64
+}
+
+func (q *Queries) GetSessionByRefreshToken(ctx context.Context, arg GetSessionByRefreshTokenParams) (GetSessionByRefreshTokenRow, error) {
+	row := q.db.QueryRowContext(ctx, getSessionByRefreshToken, arg.RefreshTokenHash, arg.Now)
+	var i GetSessionByRefreshTokenRow
+	err := row.Scan(&i.ID, &i.UserID)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, name, email, password, disabled_at, locale, deleted_at FROM user WHERE lower(email) = lower(?1) AND deleted_at IS NULL
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.Password,
+		&i.DisabledAt,
+		&i.Locale,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, name, email, password, disabled_at, locale, deleted_at FROM user WHERE id = ? AND deleted_at IS NULL
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id int64) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.Password,
+		&i.DisabledAt,
+		&i.Locale,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const grantUserRole = `-- name: GrantUserRole :exec
+INSERT INTO user_role (user_id, role, granted_at) VALUES (?, ?, ?) ON CONFLICT DO NOTHING
+`
+
+type GrantUserRoleParams struct {
+	UserID    int64
+	Role      string
+	GrantedAt time.Time
+}
+
+func (q *Queries) GrantUserRole(ctx context.Context, arg GrantUserRoleParams) error {
+	_, err := q.db.ExecContext(ctx, grantUserRole, arg.UserID, arg.Role, arg.GrantedAt)
+	return err
+}
+
+const insertDevice = `-- name: InsertDevice :execrows
+INSERT INTO user_device (user_id, fingerprint, name, first_seen_at, last_seen_at)
+VALUES (?1, ?2, ?3, ?4, ?4)
+ON CONFLICT (user_id, fingerprint) DO NOTHING
+`
+
+type InsertDeviceParams struct {
+	UserID      int64
+	Fingerprint string
+	Name        string
+	Now         time.Time
+}
+
+func (q *Queries) InsertDevice(ctx context.Context, arg InsertDeviceParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, insertDevice,
+		arg.UserID,
+		arg.Fingerprint,
+		arg.Name,
+		arg.Now,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const listSessions = `-- name: ListSessions :many
+SELECT id, token_hash, created_at, last_seen_at, expires_at, user_agent, ip, device_name
+FROM session
+WHERE user_id = ?1 AND expires_at > ?2
+ORDER BY last_seen_at DESC, id DESC
+`
+
+type ListSessionsParams struct {
+	UserID int64
+	Now    sql.NullTime
+}
+
+type ListSessionsRow struct {
+	ID         int64
+	TokenHash  sql.NullString
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  sql.NullTime
+	UserAgent  string
+	Ip         string
+	DeviceName string
+}
+
+func (q *Queries) ListSessions(ctx context.Context, arg ListSessionsParams) ([]ListSessionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSessions, arg.UserID, arg.Now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSessionsRow
+	for rows.Next() {
+		var i ListSessionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TokenHash,
+			&i.CreatedAt,
+			&i.LastSeenAt,
+			&i.ExpiresAt,
+			&i.UserAgent,
+			&i.Ip,
+			&i.DeviceName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserRoles = `-- name: ListUserRoles :many
+SELECT role FROM user_role WHERE user_id = ? ORDER BY role
+`
+
+func (q *Queries) ListUserRoles(ctx context.Context, userID int64) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listUserRoles, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		items = append(items, role)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const refreshSession = `-- name: RefreshSession :execrows
+UPDATE session
+SET refresh_token_hash = ?1, last_seen_at = ?2, expires_at = ?3,
+    user_agent = COALESCE(NULLIF(CAST(?4 AS TEXT), ''), user_agent), ip = COALESCE(NULLIF(CAST(?5 AS TEXT), ''), ip)
+WHERE id = ?6 AND refresh_token_hash = ?7
+`
+
+type RefreshSessionParams struct {
+	NewRefreshTokenHash sql.NullString
+	Now                 time.Time
+	ExpiresAt           sql.NullTime
+	UserAgent           string
+	Ip                  string
+	ID                  int64
+	RefreshTokenHash    sql.NullString
+}
+
+func (q *Queries) RefreshSession(ctx context.Context, arg RefreshSessionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, refreshSession,
+		arg.NewRefreshTokenHash,
+		arg.Now,
+		arg.ExpiresAt,
+		arg.UserAgent,
+		arg.Ip,
+		arg.ID,
+		arg.RefreshTokenHash,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreUser = `-- name: RestoreUser :execrows
+UPDATE user SET deleted_at = NULL WHERE id = ?1 AND deleted_at > ?2
+`
+
+type RestoreUserParams struct {
+	ID           int64
+	DeletedAfter sql.NullTime
+}
+
+func (q *Queries) RestoreUser(ctx context.Context, arg RestoreUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restoreUser, arg.ID, arg.DeletedAfter)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setUserLocale = `-- name: SetUserLocale :execrows
+UPDATE user SET locale = ?1 WHERE lower(email) = lower(?2) AND deleted_at IS NULL
+`
+
+type SetUserLocaleParams struct {
+	Locale sql.NullString
+	Email  string
+}
+
+func (q *Queries) SetUserLocale(ctx context.Context, arg SetUserLocaleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setUserLocale, arg.Locale, arg.Email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const touchDevice = `-- name: TouchDevice :exec
+UPDATE user_device SET last_seen_at = ?1 WHERE user_id = ?2 AND fingerprint = ?3
+`
+
+type TouchDeviceParams struct {
+	Now         time.Time
+	UserID      int64
+	Fingerprint string
+}
+
+func (q *Queries) TouchDevice(ctx context.Context, arg TouchDeviceParams) error {
+	_, err := q.db.ExecContext(ctx, touchDevice, arg.Now, arg.UserID, arg.Fingerprint)
+	return err
+}
+
+const touchSession = `-- name: TouchSession :exec
+UPDATE session SET last_seen_at = ?1, ip = COALESCE(NULLIF(CAST(?2 AS TEXT), ''), ip) WHERE id = ?3
+`
+
+type TouchSessionParams struct {
+	Now time.Time
+	Ip  string
+	ID  int64
+}
+
+func (q *Queries) TouchSession(ctx context.Context, arg TouchSessionParams) error {
+	_, err := q.db.ExecContext(ctx, touchSession, arg.Now, arg.Ip, arg.ID)
+	return err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :one
+UPDATE user SET password = ?1 WHERE lower(email) = lower(?2) AND deleted_at IS NULL RETURNING id
+`
+
+type UpdateUserPasswordParams struct {
+	Password string
+	Email    string
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, updateUserPassword, arg.Password, arg.Email)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :execrows
+UPDATE user SET name = ?, locale = ? WHERE id = ? AND deleted_at IS NULL
+`
+
+type UpdateUserProfileParams struct {
+	Name   string
+	Locale sql.NullString
+	ID     int64
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateUserProfile, arg.Name, arg.Locale, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,159 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.20.0
+// source: idempotency.sql
+
+package sqlitedb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const completeIdempotencyKey = `-- name: CompleteIdempotencyKey :execrows
+UPDATE idempotency_key
+SET status_code = ?1, response_header = ?2, response_body = ?3
+WHERE key = ?4 AND operation = ?5 AND locked_at = ?6 AND status_code IS NULL
+`
+
+type CompleteIdempotencyKeyParams struct {
+	StatusCode     sql.NullInt64
+	ResponseHeader []byte
+	ResponseBody   []byte
+	Key            string
+	Operation      string
+	LockedAt       time.Time
+}
+
+// The request holding the lock since locked_at stores its response, unless
+// a retry took the key over meanwhile.
+func (q *Queries) CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, completeIdempotencyKey,
+		arg.StatusCode,
+		arg.ResponseHeader,
+		arg.ResponseBody,
+		arg.Key,
+		arg.Operation,
+		arg.LockedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteExpiredIdempotencyKeys = `-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_key WHERE expires_at < ?
+`
+
+func (q *Queries) DeleteExpiredIdempotencyKeys(ctx context.Context, expiresAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredIdempotencyKeys, expiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteIdempotencyKey = `-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_key
+WHERE key = ?1 AND operation = ?2 AND locked_at = ?3 AND status_code IS NULL
+`
+
+type DeleteIdempotencyKeyParams struct {
+	Key       string
+	Operation string
+	LockedAt  time.Time
+}
+
+// The request holding the lock since locked_at releases the key, unless a
+// retry took it over meanwhile.
+func (q *Queries) DeleteIdempotencyKey(ctx context.Context, arg DeleteIdempotencyKeyParams) error {
+	_, err := q.db.ExecContext(ctx, deleteIdempotencyKey, arg.Key, arg.Operation, arg.LockedAt)
+	return err
+}
+
+const getIdempotencyKey = `-- name: GetIdempotencyKey :one
+SELECT "key", operation, fingerprint, locked_at, expires_at, status_code, response_header, response_body FROM idempotency_key WHERE key = ? AND operation = ?
+`
+
+type GetIdempotencyKeyParams struct {
+	Key       string
+	Operation string
+}
+
+func (q *Queries) GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.db.QueryRowContext(ctx, getIdempotencyKey, arg.Key, arg.Operation)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.Key,
+		&i.Operation,
+		&i.Fingerprint,
+		&i.LockedAt,
+		&i.ExpiresAt,
+		&i.StatusCode,
+		&i.ResponseHeader,
+		&i.ResponseBody,
+	)
+	return i, err
+}
+
+const insertIdempotencyKey = `-- name: InsertIdempotencyKey :execrows
+INSERT INTO idempotency_key (key, operation, fingerprint, locked_at, expires_at)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (key, operation) DO NOTHING
+`
+
+type InsertIdempotencyKeyParams struct {
+	Key         string
+	Operation   string
+	Fingerprint string
+	LockedAt    time.Time
+	ExpiresAt   time.Time
+}
+
+func (q *Queries) InsertIdempotencyKey(ctx context.Context, arg InsertIdempotencyKeyParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, insertIdempotencyKey,
+		arg.Key,
+		arg.Operation,
+		arg.Fingerprint,
+		arg.LockedAt,
+		arg.ExpiresAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const takeOverIdempotencyKey = `-- name: TakeOverIdempotencyKey :execrows
+UPDATE idempotency_key
+SET fingerprint = ?1, locked_at = ?2, expires_at = ?3,
+    status_code = NULL, response_header = NULL, response_body = NULL
+WHERE key = ?4 AND operation = ?5
+  AND (expires_at < ?2 OR (status_code IS NULL AND locked_at < ?6))
+`
+
+type TakeOverIdempotencyKeyParams struct {
+	Fingerprint string
+	Now         time.Time
+	ExpiresAt   time.Time
+	Key         string
+	Operation   string
+	StaleBefore time.Time
+}
+
+func (q *Queries) TakeOverIdempotencyKey(ctx context.Context, arg TakeOverIdempotencyKeyParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, takeOverIdempotencyKey,
+		arg.Fingerprint,
+		arg.Now,
+		arg.ExpiresAt,
+		arg.Key,
+		arg.Operation,
+		arg.StaleBefore,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,276 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.20.0
+// source: job.sql
+
+package sqlitedb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const claimJobs = `-- name: ClaimJobs :many
+UPDATE job
+SET state = 'running', attempts = attempts + 1, locked_at = ?1, locked_by = ?2
+WHERE id IN (
+    SELECT j.id FROM job j
+    WHERE (j.state = 'pending' AND j.run_at <= ?1)
+       OR (j.state = 'running' AND j.locked_at < ?3 AND j.attempts < j.max_attempts)
+    ORDER BY j.run_at, j.id
+    LIMIT ?4
+)
+RETURNING id, kind, payload, attempts, max_attempts
+`
+
+type ClaimJobsParams struct {
+	Now         sql.NullTime
+	Worker      sql.NullString
+	StaleBefore sql.NullTime
+	BatchSize   int64
+}
+
+type ClaimJobsRow struct {
+	ID          int64
+	Kind        string
+	Payload     string
+	Attempts    int64
+	MaxAttempts int64
+}
+
+// Claims the due jobs, and the running ones whose worker is gone for longer
+// than the lock timeout and which have attempts left, see KillStaleJobs.
+// Writers are serialized, so there is nothing to skip.
+func (q *Queries) ClaimJobs(ctx context.Context, arg ClaimJobsParams) ([]ClaimJobsRow, error) {
+	rows, err := q.db.QueryContext(ctx, claimJobs,
+		arg.Now,
+		arg.Worker,
+		arg.StaleBefore,
+		arg.BatchSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimJobsRow
+	for rows.Next() {
+		var i ClaimJobsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.Payload,
+			&i.Attempts,
+			&i.MaxAttempts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const completeJob = `-- name: CompleteJob :execrows
+UPDATE job
+SET state = 'done', finished_at = ?1, locked_at = NULL, locked_by = NULL, last_error = NULL
+WHERE id = ?2 AND state = 'running' AND attempts = ?3
+`
+
+type CompleteJobParams struct {
+	Now      sql.NullTime
+	ID       int64
+	Attempts int64
+}
+
+func (q *Queries) CompleteJob(ctx context.Context, arg CompleteJobParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, completeJob, arg.Now, arg.ID, arg.Attempts)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteFinishedJobs = `-- name: DeleteFinishedJobs :execrows
+DELETE FROM job WHERE state = 'done' AND finished_at < ?1
+`
+
+func (q *Queries) DeleteFinishedJobs(ctx context.Context, before sql.NullTime) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFinishedJobs, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const enqueueJob = `-- name: EnqueueJob :execrows
+INSERT INTO job (kind, payload, unique_key, max_attempts, run_at, created_at)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6)
+ON CONFLICT (kind, unique_key) DO NOTHING
+`
+
+type EnqueueJobParams struct {
+	Kind        string
+	Payload     string
+	UniqueKey   sql.NullString
+	MaxAttempts int64
+	RunAt       time.Time
+	Now         time.Time
+}
+
+func (q *Queries) EnqueueJob(ctx context.Context, arg EnqueueJobParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, enqueueJob,
+		arg.Kind,
+		arg.Payload,
+		arg.UniqueKey,
+		arg.MaxAttempts,
+		arg.RunAt,
+		arg.Now,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const killJob = `-- name: KillJob :execrows
+UPDATE job
+SET state = 'dead', finished_at = ?1, locked_at = NULL, locked_by = NULL, last_error = ?2
+WHERE id = ?3 AND state = 'running' AND attempts = ?4
+`
+
+type KillJobParams struct {
+	Now       sql.NullTime
+	LastError sql.NullString
+	ID        int64
+	Attempts  int64
+}
+
+func (q *Queries) KillJob(ctx context.Context, arg KillJobParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, killJob,
+		arg.Now,
+		arg.LastError,
+		arg.ID,
+		arg.Attempts,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const killStaleJobs = `-- name: KillStaleJobs :execrows
+UPDATE job
+SET state = 'dead', finished_at = ?1, locked_at = NULL, locked_by = NULL, last_error = ?2
+WHERE state = 'running' AND locked_at < ?3 AND attempts >= max_attempts
+`
+
+type KillStaleJobsParams struct {
+	Now         sql.NullTime
+	LastError   sql.NullString
+	StaleBefore sql.NullTime
+}
+
+// Kills the running jobs whose worker is gone on their last attempt.
+func (q *Queries) KillStaleJobs(ctx context.Context, arg KillStaleJobsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, killStaleJobs, arg.Now, arg.LastError, arg.StaleBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const listDeadJobs = `-- name: ListDeadJobs :many
+SELECT id, kind, payload, attempts, last_error, finished_at FROM job
+WHERE state = 'dead'
+ORDER BY finished_at DESC NULLS FIRST, id DESC
+`
+
+type ListDeadJobsRow struct {
+	ID         int64
+	Kind       string
+	Payload    string
+	Attempts   int64
+	LastError  sql.NullString
+	FinishedAt sql.NullTime
+}
+
+func (q *Queries) ListDeadJobs(ctx context.Context) ([]ListDeadJobsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDeadJobs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDeadJobsRow
+	for rows.Next() {
+		var i ListDeadJobsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.Payload,
+			&i.Attempts,
+			&i.LastError,
+			&i.FinishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const retryJob = `-- name: RetryJob :execrows
+UPDATE job
+SET state = 'pending', run_at = ?1, locked_at = NULL, locked_by = NULL, last_error = ?2
+WHERE id = ?3 AND state = 'running' AND attempts = ?4
+`
+
+type RetryJobParams struct {
+	RunAt     time.Time
+	LastError sql.NullString
+	ID        int64
+	Attempts  int64
+}
+
+func (q *Queries) RetryJob(ctx context.Context, arg RetryJobParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, retryJob,
+		arg.RunAt,
+		arg.LastError,
+		arg.ID,
+		arg.Attempts,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const reviveJob = `-- name: ReviveJob :execrows
+UPDATE job
+SET state = 'pending', attempts = 0, run_at = ?1, finished_at = NULL
+WHERE id = ?2 AND state = 'dead'
+`
+
+type ReviveJobParams struct {
+	Now time.Time
+	ID  int64
+}
+
+func (q *Queries) ReviveJob(ctx context.Context, arg ReviveJobParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, reviveJob, arg.Now, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,50 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.20.0
+// source: mail.sql
+
+package sqlitedb
+
+import (
+	"context"
+	"time"
+)
+
+const claimMailMessage = `-- name: ClaimMailMessage :execrows
+INSERT INTO mail_sent (message_id, sent_at) VALUES (?1, ?2)
+ON CONFLICT (message_id) DO NOTHING
+`
+
+type ClaimMailMessageParams struct {
+	MessageID string
+	Now       time.Time
+}
+
+func (q *Queries) ClaimMailMessage(ctx context.Context, arg ClaimMailMessageParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, claimMailMessage, arg.MessageID, arg.Now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteSentMailBefore = `-- name: DeleteSentMailBefore :execrows
+DELETE FROM mail_sent WHERE sent_at < ?1
+`
+
+func (q *Queries) DeleteSentMailBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteSentMailBefore, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const releaseMailMessage = `-- name: ReleaseMailMessage :exec
+DELETE FROM mail_sent WHERE message_id = ?1
+`
+
+func (q *Queries) ReleaseMailMessage(ctx context.Context, messageID string) error {
+	_, err := q.db.ExecContext(ctx, releaseMailMessage, messageID)
+	return err
+}
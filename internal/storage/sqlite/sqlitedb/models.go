@@ -0,0 +1,138 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.20.0
+
+package sqlitedb
+
+import (
+	"database/sql"
+	"time"
+)
+
+type DataExport struct {
+	UserID      int64
+	RequestedAt time.Time
+	ReadyAt     sql.NullTime
+	Archive     []byte
+}
+
+type IdempotencyKey struct {
+	Key            string
+	Operation      string
+	Fingerprint    string
+	LockedAt       time.Time
+	ExpiresAt      time.Time
+	StatusCode     sql.NullInt64
+	ResponseHeader []byte
+	ResponseBody   []byte
+}
+
+type InitialMigration struct {
+	Message string
+}
+
+type Invitation struct {
+	ID             int64
+	OrganizationID int64
+	Email          string
+	Role           string
+	CodeHash       string
+	CreatedAt      time.Time
+	ExpiresAt      time.Time
+	AcceptedAt     sql.NullTime
+	InvitedBy      sql.NullInt64
+	AcceptedBy     sql.NullInt64
+	RevokedAt      sql.NullTime
+}
+
+type Job struct {
+	ID          int64
+	Kind        string
+	Payload     string
+	UniqueKey   sql.NullString
+	State       string
+	Attempts    int64
+	MaxAttempts int64
+	RunAt       time.Time
+	LockedAt    sql.NullTime
+	LockedBy    sql.NullString
+	LastError   sql.NullString
+	CreatedAt   time.Time
+	FinishedAt  sql.NullTime
+}
+
+type MailSent struct {
+	MessageID string
+	SentAt    time.Time
+}
+
+type Organization struct {
+	ID        int64
+	Slug      string
+	Name      string
+	CreatedAt time.Time
+}
+
+type OrganizationMember struct {
+	OrganizationID int64
+	UserID         int64
+	Role           string
+	JoinedAt       time.Time
+}
+
+type Outbox struct {
+	ID            int64
+	AggregateType string
+	AggregateID   string
+	EventType     string
+	Payload       string
+	OccurredAt    time.Time
+	Attempts      int64
+	NextAttemptAt time.Time
+	LastError     sql.NullString
+	PublishedAt   sql.NullTime
+	DeadAt        sql.NullTime
+}
+
+type RateLimit struct {
+	Key string
+	Tat time.Time
+}
+
+type Session struct {
+	ID               int64
+	UserID           int64
+	RefreshTokenHash sql.NullString
+	TokenHash        sql.NullString
+	CsrfToken        sql.NullString
+	ExpiresAt        sql.NullTime
+	CreatedAt        time.Time
+	LastSeenAt       time.Time
+	UserAgent        string
+	Ip               string
+	DeviceName       string
+}
+
+type User struct {
+	ID         int64
+	Name       string
+	Email      string
+	Password   string
+	DisabledAt sql.NullTime
+	Locale     sql.NullString
+	DeletedAt  sql.NullTime
+}
+
+type UserDevice struct {
+	UserID      int64
+	Fingerprint string
+	Name        string
+	FirstSeenAt time.Time
+	LastSeenAt  time.Time
+}
+
+type UserRole struct {
+	UserID    int64
+	Role      string
+	GrantedAt time.Time
+}
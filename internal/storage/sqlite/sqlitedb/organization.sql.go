@@ -0,0 +1,424 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.20.0
+// source: organization.sql
+
+package sqlitedb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const acceptInvitation = `-- name: AcceptInvitation :execrows
+UPDATE invitation SET accepted_at = ?1, accepted_by = ?2
+WHERE id = ?3 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?1
+`
+
+type AcceptInvitationParams struct {
+	Now        sql.NullTime
+	AcceptedBy sql.NullInt64
+	ID         int64
+}
+
+func (q *Queries) AcceptInvitation(ctx context.Context, arg AcceptInvitationParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, acceptInvitation, arg.Now, arg.AcceptedBy, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const addOrganizationMember = `-- name: AddOrganizationMember :execrows
+INSERT INTO organization_member (organization_id, user_id, role, joined_at)
+VALUES (?1, ?2, ?3, ?4)
+ON CONFLICT (organization_id, user_id) DO NOTHING
+`
+
+type AddOrganizationMemberParams struct {
+	OrganizationID int64
+	UserID         int64
+	Role           string
+	Now            time.Time
+}
+
+func (q *Queries) AddOrganizationMember(ctx context.Context, arg AddOrganizationMemberParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, addOrganizationMember,
+		arg.OrganizationID,
+		arg.UserID,
+		arg.Role,
+		arg.Now,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countOrganizationOwners = `-- name: CountOrganizationOwners :one
+SELECT count(*) FROM organization_member WHERE organization_id = ? AND role = 'owner'
+`
+
+func (q *Queries) CountOrganizationOwners(ctx context.Context, organizationID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countOrganizationOwners, organizationID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createInvitation = `-- name: CreateInvitation :one
+INSERT INTO invitation (organization_id, email, role, code_hash, invited_by, created_at, expires_at)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7)
+RETURNING id, organization_id, email, role, code_hash, created_at, expires_at, accepted_at, invited_by, accepted_by, revoked_at
+`
+
+type CreateInvitationParams struct {
+	OrganizationID int64
+	Email          string
+	Role           string
+	CodeHash       string
+	InvitedBy      sql.NullInt64
+	Now            time.Time
+	ExpiresAt      time.Time
+}
+
+func (q *Queries) CreateInvitation(ctx context.Context, arg CreateInvitationParams) (Invitation, error) {
+	row := q.db.QueryRowContext(ctx, createInvitation,
+		arg.OrganizationID,
+		arg.Email,
+		arg.Role,
+		arg.CodeHash,
+		arg.InvitedBy,
+		arg.Now,
+		arg.ExpiresAt,
+	)
+	var i Invitation
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Email,
+		&i.Role,
+		&i.CodeHash,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.AcceptedAt,
+		&i.InvitedBy,
+		&i.AcceptedBy,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const createOrganization = `-- name: CreateOrganization :one
+INSERT INTO organization (slug, name, created_at) VALUES (?1, ?2, ?3)
+RETURNING id, slug, name, created_at
+`
+
+type CreateOrganizationParams struct {
+	Slug string
+	Name string
+	Now  time.Time
+}
+
+func (q *Queries) CreateOrganization(ctx context.Context, arg CreateOrganizationParams) (Organization, error) {
+	row := q.db.QueryRowContext(ctx, createOrganization, arg.Slug, arg.Name, arg.Now)
+	var i Organization
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Name,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getInvitation = `-- name: GetInvitation :one
+SELECT id, organization_id, email, role, code_hash, created_at, expires_at, accepted_at, invited_by, accepted_by, revoked_at FROM invitation WHERE id = ?1 AND organization_id = ?2
+`
+
+type GetInvitationParams struct {
+	ID             int64
+	OrganizationID int64
+}
+
+func (q *Queries) GetInvitation(ctx context.Context, arg GetInvitationParams) (Invitation, error) {
+	row := q.db.QueryRowContext(ctx, getInvitation, arg.ID, arg.OrganizationID)
+	var i Invitation
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Email,
+		&i.Role,
+		&i.CodeHash,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.AcceptedAt,
+		&i.InvitedBy,
+		&i.AcceptedBy,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const getInvitationByCode = `-- name: GetInvitationByCode :one
+SELECT id, organization_id, email, role, code_hash, created_at, expires_at, accepted_at, invited_by, accepted_by, revoked_at FROM invitation WHERE code_hash = ?
+`
+
+func (q *Queries) GetInvitationByCode(ctx context.Context, codeHash string) (Invitation, error) {
+	row := q.db.QueryRowContext(ctx, getInvitationByCode, codeHash)
+	var i Invitation
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Email,
+		&i.Role,
+		&i.CodeHash,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.AcceptedAt,
+		&i.InvitedBy,
+		&i.AcceptedBy,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const getOrganization = `-- name: GetOrganization :one
+SELECT id, slug, name, created_at FROM organization WHERE id = ?
+`
+
+func (q *Queries) GetOrganization(ctx context.Context, id int64) (Organization, error) {
+	row := q.db.QueryRowContext(ctx, getOrganization, id)
+	var i Organization
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Name,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getOrganizationMember = `-- name: GetOrganizationMember :one
+SELECT organization_id, user_id, role, joined_at FROM organization_member WHERE organization_id = ?1 AND user_id = ?2
+`
+
+type GetOrganizationMemberParams struct {
+	OrganizationID int64
+	UserID         int64
+}
+
+func (q *Queries) GetOrganizationMember(ctx context.Context, arg GetOrganizationMemberParams) (OrganizationMember, error) {
+	row := q.db.QueryRowContext(ctx, getOrganizationMember, arg.OrganizationID, arg.UserID)
+	var i OrganizationMember
+	err := row.Scan(
+		&i.OrganizationID,
+		&i.UserID,
+		&i.Role,
+		&i.JoinedAt,
+	)
+	return i, err
+}
+
+const listOrganizationInvitations = `-- name: ListOrganizationInvitations :many
+SELECT i.id, i.email, i.role, i.invited_by, COALESCE(inviter.name, '') AS inviter_name,
+    i.accepted_by, COALESCE(accepter.name, '') AS accepter_name,
+    i.created_at, i.expires_at, i.accepted_at, i.revoked_at
+FROM invitation i
+LEFT JOIN user inviter ON inviter.id = i.invited_by
+LEFT JOIN user accepter ON accepter.id = i.accepted_by
+WHERE i.organization_id = ? ORDER BY i.created_at DESC, i.id DESC
+`
+
+type ListOrganizationInvitationsRow struct {
+	ID           int64
+	Email        string
+	Role         string
+	InvitedBy    sql.NullInt64
+	InviterName  string
+	AcceptedBy   sql.NullInt64
+	AccepterName string
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+	AcceptedAt   sql.NullTime
+	RevokedAt    sql.NullTime
+}
+
+func (q *Queries) ListOrganizationInvitations(ctx context.Context, organizationID int64) ([]ListOrganizationInvitationsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOrganizationInvitations, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOrganizationInvitationsRow
+	for rows.Next() {
+		var i ListOrganizationInvitationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.Role,
+			&i.InvitedBy,
+			&i.InviterName,
+			&i.AcceptedBy,
+			&i.AccepterName,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.AcceptedAt,
+			&i.RevokedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrganizationMembers = `-- name: ListOrganizationMembers :many
+SELECT m.user_id, u.name, u.email, m.role, m.joined_at
+FROM organization_member m JOIN user u ON u.id = m.user_id
+WHERE m.organization_id = ? AND u.deleted_at IS NULL ORDER BY m.joined_at, m.user_id
+`
+
+type ListOrganizationMembersRow struct {
+	UserID   int64
+	Name     string
+	Email    string
+	Role     string
+	JoinedAt time.Time
+}
+
+func (q *Queries) ListOrganizationMembers(ctx context.Context, organizationID int64) ([]ListOrganizationMembersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOrganizationMembers, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOrganizationMembersRow
+	for rows.Next() {
+		var i ListOrganizationMembersRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Name,
+			&i.Email,
+			&i.Role,
+			&i.JoinedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserOrganizations = `-- name: ListUserOrganizations :many
+SELECT o.id, o.slug, o.name, o.created_at, m.role, m.joined_at
+FROM organization o JOIN organization_member m ON m.organization_id = o.id
+WHERE m.user_id = ? ORDER BY o.name, o.id
+`
+
+type ListUserOrganizationsRow struct {
+	ID        int64
+	Slug      string
+	Name      string
+	CreatedAt time.Time
+	Role      string
+	JoinedAt  time.Time
+}
+
+func (q *Queries) ListUserOrganizations(ctx context.Context, userID int64) ([]ListUserOrganizationsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUserOrganizations, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserOrganizationsRow
+	for rows.Next() {
+		var i ListUserOrganizationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Slug,
+			&i.Name,
+			&i.CreatedAt,
+			&i.Role,
+			&i.JoinedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const removeOrganizationMember = `-- name: RemoveOrganizationMember :execrows
+DELETE FROM organization_member WHERE organization_id = ?1 AND user_id = ?2
+`
+
+type RemoveOrganizationMemberParams struct {
+	OrganizationID int64
+	UserID         int64
+}
+
+func (q *Queries) RemoveOrganizationMember(ctx context.Context, arg RemoveOrganizationMemberParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, removeOrganizationMember, arg.OrganizationID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeInvitation = `-- name: RevokeInvitation :execrows
+UPDATE invitation SET revoked_at = ?1
+WHERE id = ?2 AND organization_id = ?3 AND accepted_at IS NULL AND revoked_at IS NULL
+`
+
+type RevokeInvitationParams struct {
+	Now            sql.NullTime
+	ID             int64
+	OrganizationID int64
+}
+
+func (q *Queries) RevokeInvitation(ctx context.Context, arg RevokeInvitationParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeInvitation, arg.Now, arg.ID, arg.OrganizationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateOrganizationMemberRole = `-- name: UpdateOrganizationMemberRole :execrows
+UPDATE organization_member SET role = ?1
+WHERE organization_id = ?2 AND user_id = ?3
+`
+
+type UpdateOrganizationMemberRoleParams struct {
+	Role           string
+	OrganizationID int64
+	UserID         int64
+}
+
+func (q *Queries) UpdateOrganizationMemberRole(ctx context.Context, arg UpdateOrganizationMemberRoleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateOrganizationMemberRole, arg.Role, arg.OrganizationID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,163 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.20.0
+// source: outbox.sql
+
+package sqlitedb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const claimOutboxEvents = `-- name: ClaimOutboxEvents :many
+UPDATE outbox SET next_attempt_at = ?1
+WHERE id IN (
+    SELECT o.id FROM outbox o
+    WHERE o.published_at IS NULL AND o.dead_at IS NULL AND o.next_attempt_at <= ?2
+      AND NOT EXISTS (
+        SELECT 1 FROM outbox p
+        WHERE p.aggregate_type = o.aggregate_type AND p.aggregate_id = o.aggregate_id
+          AND p.published_at IS NULL AND p.dead_at IS NULL AND p.id < o.id
+      )
+    ORDER BY o.id
+    LIMIT ?3
+)
+RETURNING id, aggregate_type, aggregate_id, event_type, payload, occurred_at, attempts
+`
+
+type ClaimOutboxEventsParams struct {
+	LockedUntil time.Time
+	Now         time.Time
+	BatchSize   int64
+}
+
+type ClaimOutboxEventsRow struct {
+	ID            int64
+	AggregateType string
+	AggregateID   string
+	EventType     string
+	Payload       string
+	OccurredAt    time.Time
+	Attempts      int64
+}
+
+// Claims the due events until locked_until, the events are published outside
+// of the transaction. Only the oldest pending event of every aggregate is
+// due, so the events of an aggregate are published in order.
+func (q *Queries) ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) ([]ClaimOutboxEventsRow, error) {
+	rows, err := q.db.QueryContext(ctx, claimOutboxEvents, arg.LockedUntil, arg.Now, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimOutboxEventsRow
+	for rows.Next() {
+		var i ClaimOutboxEventsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AggregateType,
+			&i.AggregateID,
+			&i.EventType,
+			&i.Payload,
+			&i.OccurredAt,
+			&i.Attempts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deletePublishedOutboxEvents = `-- name: DeletePublishedOutboxEvents :execrows
+DELETE FROM outbox WHERE published_at < ?1
+`
+
+func (q *Queries) DeletePublishedOutboxEvents(ctx context.Context, before sql.NullTime) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePublishedOutboxEvents, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const insertOutboxEvent = `-- name: InsertOutboxEvent :exec
+INSERT INTO outbox (aggregate_type, aggregate_id, event_type, payload, occurred_at, next_attempt_at)
+VALUES (?1, ?2, ?3, ?4, ?5, ?5)
+`
+
+type InsertOutboxEventParams struct {
+	AggregateType string
+	AggregateID   string
+	EventType     string
+	Payload       string
+	OccurredAt    time.Time
+}
+
+func (q *Queries) InsertOutboxEvent(ctx context.Context, arg InsertOutboxEventParams) error {
+	_, err := q.db.ExecContext(ctx, insertOutboxEvent,
+		arg.AggregateType,
+		arg.AggregateID,
+		arg.EventType,
+		arg.Payload,
+		arg.OccurredAt,
+	)
+	return err
+}
+
+const killOutboxEvent = `-- name: KillOutboxEvent :exec
+UPDATE outbox
+SET attempts = attempts + 1, dead_at = ?1, last_error = ?2
+WHERE id = ?3 AND published_at IS NULL
+`
+
+type KillOutboxEventParams struct {
+	Now       sql.NullTime
+	LastError sql.NullString
+	ID        int64
+}
+
+func (q *Queries) KillOutboxEvent(ctx context.Context, arg KillOutboxEventParams) error {
+	_, err := q.db.ExecContext(ctx, killOutboxEvent, arg.Now, arg.LastError, arg.ID)
+	return err
+}
+
+const markOutboxEventFailed = `-- name: MarkOutboxEventFailed :exec
+UPDATE outbox
+SET attempts = attempts + 1, next_attempt_at = ?1, last_error = ?2
+WHERE id = ?3 AND published_at IS NULL
+`
+
+type MarkOutboxEventFailedParams struct {
+	NextAttemptAt time.Time
+	LastError     sql.NullString
+	ID            int64
+}
+
+func (q *Queries) MarkOutboxEventFailed(ctx context.Context, arg MarkOutboxEventFailedParams) error {
+	_, err := q.db.ExecContext(ctx, markOutboxEventFailed, arg.NextAttemptAt, arg.LastError, arg.ID)
+	return err
+}
+
+const markOutboxEventPublished = `-- name: MarkOutboxEventPublished :exec
+UPDATE outbox SET published_at = ?1, attempts = attempts + 1, last_error = NULL
+WHERE id = ?2 AND published_at IS NULL
+`
+
+type MarkOutboxEventPublishedParams struct {
+	Now sql.NullTime
+	ID  int64
+}
+
+func (q *Queries) MarkOutboxEventPublished(ctx context.Context, arg MarkOutboxEventPublishedParams) error {
+	_, err := q.db.ExecContext(ctx, markOutboxEventPublished, arg.Now, arg.ID)
+	return err
+}
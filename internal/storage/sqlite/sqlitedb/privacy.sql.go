@@ -0,0 +1,400 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.20.0
+// source: privacy.sql
+
+package sqlitedb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const deleteDataExport = `-- name: DeleteDataExport :execrows
+DELETE FROM data_export WHERE user_id = ?
+`
+
+func (q *Queries) DeleteDataExport(ctx context.Context, userID int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteDataExport, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteExpiredDataExports = `-- name: DeleteExpiredDataExports :execrows
+DELETE FROM data_export WHERE ready_at < ?1
+`
+
+func (q *Queries) DeleteExpiredDataExports(ctx context.Context, before sql.NullTime) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredDataExports, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserDevices = `-- name: DeleteUserDevices :execrows
+DELETE FROM user_device WHERE user_id = ?
+`
+
+func (q *Queries) DeleteUserDevices(ctx context.Context, userID int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserDevices, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserEvents = `-- name: DeleteUserEvents :execrows
+DELETE FROM outbox WHERE aggregate_type = 'user' AND aggregate_id = ?1
+`
+
+func (q *Queries) DeleteUserEvents(ctx context.Context, aggregateID string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserEvents, aggregateID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserInvitations = `-- name: DeleteUserInvitations :execrows
+DELETE FROM invitation
+WHERE lower(email) = (SELECT lower(u.email) FROM user u WHERE u.id = ?1)
+`
+
+func (q *Queries) DeleteUserInvitations(ctx context.Context, userID int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserInvitations, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserMemberships = `-- name: DeleteUserMemberships :execrows
+DELETE FROM organization_member WHERE user_id = ?
+`
+
+func (q *Queries) DeleteUserMemberships(ctx context.Context, userID int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserMemberships, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserRoles = `-- name: DeleteUserRoles :execrows
+DELETE FROM user_role WHERE user_id = ?
+`
+
+func (q *Queries) DeleteUserRoles(ctx context.Context, userID int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserRoles, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const eraseUser = `-- name: EraseUser :execrows
+DELETE FROM user WHERE id = ?
+`
+
+func (q *Queries) EraseUser(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, eraseUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getDataExport = `-- name: GetDataExport :one
+SELECT user_id, requested_at, ready_at FROM data_export WHERE user_id = ?
+`
+
+type GetDataExportRow struct {
+	UserID      int64
+	RequestedAt time.Time
+	ReadyAt     sql.NullTime
+}
+
+func (q *Queries) GetDataExport(ctx context.Context, userID int64) (GetDataExportRow, error) {
+	row := q.db.QueryRowContext(ctx, getDataExport, userID)
+	var i GetDataExportRow
+	err := row.Scan(&i.UserID, &i.RequestedAt, &i.ReadyAt)
+	return i, err
+}
+
+const getDataExportArchive = `-- name: GetDataExportArchive :one
+SELECT archive FROM data_export WHERE user_id = ?1 AND ready_at > ?2
+`
+
+type GetDataExportArchiveParams struct {
+	UserID     int64
+	ReadyAfter sql.NullTime
+}
+
+func (q *Queries) GetDataExportArchive(ctx context.Context, arg GetDataExportArchiveParams) ([]byte, error) {
+	row := q.db.QueryRowContext(ctx, getDataExportArchive, arg.UserID, arg.ReadyAfter)
+	var archive []byte
+	err := row.Scan(&archive)
+	return archive, err
+}
+
+const listUserDevices = `-- name: ListUserDevices :many
+SELECT user_id, fingerprint, name, first_seen_at, last_seen_at FROM user_device WHERE user_id = ? ORDER BY first_seen_at, fingerprint
+`
+
+func (q *Queries) ListUserDevices(ctx context.Context, userID int64) ([]UserDevice, error) {
+	rows, err := q.db.QueryContext(ctx, listUserDevices, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserDevice
+	for rows.Next() {
+		var i UserDevice
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Fingerprint,
+			&i.Name,
+			&i.FirstSeenAt,
+			&i.LastSeenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserEvents = `-- name: ListUserEvents :many
+SELECT id, event_type, payload, occurred_at FROM outbox
+WHERE aggregate_type = 'user' AND aggregate_id = ?1 ORDER BY id
+`
+
+type ListUserEventsRow struct {
+	ID         int64
+	EventType  string
+	Payload    string
+	OccurredAt time.Time
+}
+
+func (q *Queries) ListUserEvents(ctx context.Context, aggregateID string) ([]ListUserEventsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUserEvents, aggregateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserEventsRow
+	for rows.Next() {
+		var i ListUserEventsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventType,
+			&i.Payload,
+			&i.OccurredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserInvitations = `-- name: ListUserInvitations :many
+SELECT i.id, o.name AS organization, i.role, i.created_at, i.expires_at, i.accepted_at, i.revoked_at
+FROM invitation i JOIN organization o ON o.id = i.organization_id
+WHERE lower(i.email) = (SELECT lower(u.email) FROM user u WHERE u.id = ?1) ORDER BY i.id
+`
+
+type ListUserInvitationsRow struct {
+	ID           int64
+	Organization string
+	Role         string
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+	AcceptedAt   sql.NullTime
+	RevokedAt    sql.NullTime
+}
+
+func (q *Queries) ListUserInvitations(ctx context.Context, userID int64) ([]ListUserInvitationsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUserInvitations, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserInvitationsRow
+	for rows.Next() {
+		var i ListUserInvitationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Organization,
+			&i.Role,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.AcceptedAt,
+			&i.RevokedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserRoleGrants = `-- name: ListUserRoleGrants :many
+SELECT user_id, role, granted_at FROM user_role WHERE user_id = ? ORDER BY role
+`
+
+func (q *Queries) ListUserRoleGrants(ctx context.Context, userID int64) ([]UserRole, error) {
+	rows, err := q.db.QueryContext(ctx, listUserRoleGrants, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserRole
+	for rows.Next() {
+		var i UserRole
+		if err := rows.Scan(&i.UserID, &i.Role, &i.GrantedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserSessions = `-- name: ListUserSessions :many
+SELECT id, created_at, last_seen_at, expires_at, user_agent, ip, device_name
+FROM session WHERE user_id = ? ORDER BY id
+`
+
+type ListUserSessionsRow struct {
+	ID         int64
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  sql.NullTime
+	UserAgent  string
+	Ip         string
+	DeviceName string
+}
+
+func (q *Queries) ListUserSessions(ctx context.Context, userID int64) ([]ListUserSessionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUserSessions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserSessionsRow
+	for rows.Next() {
+		var i ListUserSessionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.LastSeenAt,
+			&i.ExpiresAt,
+			&i.UserAgent,
+			&i.Ip,
+			&i.DeviceName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersDeletedBefore = `-- name: ListUsersDeletedBefore :many
+SELECT id FROM user WHERE deleted_at < ?1 ORDER BY id
+`
+
+func (q *Queries) ListUsersDeletedBefore(ctx context.Context, deletedBefore sql.NullTime) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersDeletedBefore, deletedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const requestDataExport = `-- name: RequestDataExport :execrows
+INSERT INTO data_export (user_id, requested_at) VALUES (?1, ?2)
+ON CONFLICT (user_id) DO UPDATE SET requested_at = excluded.requested_at, ready_at = NULL, archive = NULL
+WHERE data_export.ready_at IS NOT NULL
+`
+
+type RequestDataExportParams struct {
+	UserID int64
+	Now    time.Time
+}
+
+func (q *Queries) RequestDataExport(ctx context.Context, arg RequestDataExportParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, requestDataExport, arg.UserID, arg.Now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const saveDataExport = `-- name: SaveDataExport :execrows
+UPDATE data_export SET archive = ?1, ready_at = ?2
+WHERE user_id = ?3 AND ready_at IS NULL
+`
+
+type SaveDataExportParams struct {
+	Archive []byte
+	Now     sql.NullTime
+	UserID  int64
+}
+
+func (q *Queries) SaveDataExport(ctx context.Context, arg SaveDataExportParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, saveDataExport, arg.Archive, arg.Now, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
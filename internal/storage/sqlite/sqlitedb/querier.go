@@ -0,0 +1,122 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.20.0
+
+package sqlitedb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type Querier interface {
+	AcceptInvitation(ctx context.Context, arg AcceptInvitationParams) (int64, error)
+	AddOrganizationMember(ctx context.Context, arg AddOrganizationMemberParams) (int64, error)
+	// Claims the due jobs, and the running ones whose worker is gone for longer
+	// than the lock timeout and which have attempts left, see KillStaleJobs.
+	// Writers are serialized, so there is nothing to skip.
+	ClaimJobs(ctx context.Context, arg ClaimJobsParams) ([]ClaimJobsRow, error)
+	ClaimMailMessage(ctx context.Context, arg ClaimMailMessageParams) (int64, error)
+	// Claims the due events until locked_until, the events are published outside
+	// of the transaction. Only the oldest pending event of every aggregate is
+	// due, so the events of an aggregate are published in order.
+	ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) ([]ClaimOutboxEventsRow, error)
+	CleanUserTable(ctx context.Context) error
+	// The request holding the lock since locked_at stores its response, unless
+	// a retry took the key over meanwhile.
+	CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) (int64, error)
+	CompleteJob(ctx context.Context, arg CompleteJobParams) (int64, error)
+	CountOrganizationOwners(ctx context.Context, organizationID int64) (int64, error)
+	CountUserDevices(ctx context.Context, userID int64) (int64, error)
+	CreateBrowserSession(ctx context.Context, arg CreateBrowserSessionParams) (int64, error)
+	CreateInvitation(ctx context.Context, arg CreateInvitationParams) (Invitation, error)
+	CreateOrganization(ctx context.Context, arg CreateOrganizationParams) (Organization, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (int64, error)
+	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	DeleteAllSessions(ctx context.Context) (int64, error)
+	DeleteBrowserSession(ctx context.Context, tokenHash sql.NullString) (int64, error)
+	DeleteDataExport(ctx context.Context, userID int64) (int64, error)
+	DeleteExpiredDataExports(ctx context.Context, before sql.NullTime) (int64, error)
+	DeleteExpiredIdempotencyKeys(ctx context.Context, expiresAt time.Time) (int64, error)
+	DeleteExpiredRateLimits(ctx context.Context, tat time.Time) (int64, error)
+	DeleteExpiredSessions(ctx context.Context, now sql.NullTime) (int64, error)
+	DeleteFinishedJobs(ctx context.Context, before sql.NullTime) (int64, error)
+	// The request holding the lock since locked_at releases the key, unless a
+	// retry took it over meanwhile.
+	DeleteIdempotencyKey(ctx context.Context, arg DeleteIdempotencyKeyParams) error
+	// Deletes the sessions of the user but the browser session keep_hash, api
+	// sessions included.
+	DeleteOtherUserSessions(ctx context.Context, arg DeleteOtherUserSessionsParams) (int64, error)
+	DeletePublishedOutboxEvents(ctx context.Context, before sql.NullTime) (int64, error)
+	DeleteSentMailBefore(ctx context.Context, before time.Time) (int64, error)
+	DeleteUser(ctx context.Context, arg DeleteUserParams) (int64, error)
+	DeleteUserDevices(ctx context.Context, userID int64) (int64, error)
+	DeleteUserEvents(ctx context.Context, aggregateID string) (int64, error)
+	DeleteUserInvitations(ctx context.Context, userID int64) (int64, error)
+	DeleteUserMemberships(ctx context.Context, userID int64) (int64, error)
+	DeleteUserRoles(ctx context.Context, userID int64) (int64, error)
+	DeleteUserSession(ctx context.Context, arg DeleteUserSessionParams) (int64, error)
+	DeleteUserSessions(ctx context.Context, userID int64) (int64, error)
+	DisableUser(ctx context.Context, arg DisableUserParams) (int64, error)
+	EnqueueJob(ctx context.Context, arg EnqueueJobParams) (int64, error)
+	EnsureRateLimit(ctx context.Context, arg EnsureRateLimitParams) error
+	EraseUser(ctx context.Context, id int64) (int64, error)
+	GetBrowserSession(ctx context.Context, arg GetBrowserSessionParams) (GetBrowserSessionRow, error)
+	GetDataExport(ctx context.Context, userID int64) (GetDataExportRow, error)
+	GetDataExportArchive(ctx context.Context, arg GetDataExportArchiveParams) ([]byte, error)
+	// The email may be registered again after the deletion, the latest deleted
+	// account of it is the one to restore.
+	GetDeletedUserByEmail(ctx context.Context, email string) (User, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	GetInvitation(ctx context.Context, arg GetInvitationParams) (Invitation, error)
+	GetInvitationByCode(ctx context.Context, codeHash string) (Invitation, error)
+	GetOrganization(ctx context.Context, id int64) (Organization, error)
+	GetOrganizationMember(ctx context.Context, arg GetOrganizationMemberParams) (OrganizationMember, error)
+	GetSessionByRefreshToken(ctx context.Context, arg GetSessionByRefreshTokenParams) (GetSessionByRefreshTokenRow, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetUserByID(ctx context.Context, id int64) (User, error)
+	GrantUserRole(ctx context.Context, arg GrantUserRoleParams) error
+	InsertDevice(ctx context.Context, arg InsertDeviceParams) (int64, error)
+	InsertIdempotencyKey(ctx context.Context, arg InsertIdempotencyKeyParams) (int64, error)
+	InsertOutboxEvent(ctx context.Context, arg InsertOutboxEventParams) error
+	KillJob(ctx context.Context, arg KillJobParams) (int64, error)
+	KillOutboxEvent(ctx context.Context, arg KillOutboxEventParams) error
+	// Kills the running jobs whose worker is gone on their last attempt.
+	KillStaleJobs(ctx context.Context, arg KillStaleJobsParams) (int64, error)
+	ListDeadJobs(ctx context.Context) ([]ListDeadJobsRow, error)
+	ListOrganizationInvitations(ctx context.Context, organizationID int64) ([]ListOrganizationInvitationsRow, error)
+	ListOrganizationMembers(ctx context.Context, organizationID int64) ([]ListOrganizationMembersRow, error)
+	ListSessions(ctx context.Context, arg ListSessionsParams) ([]ListSessionsRow, error)
+	ListUserDevices(ctx context.Context, userID int64) ([]UserDevice, error)
+	ListUserEvents(ctx context.Context, aggregateID string) ([]ListUserEventsRow, error)
+	ListUserInvitations(ctx context.Context, userID int64) ([]ListUserInvitationsRow, error)
+	ListUserOrganizations(ctx context.Context, userID int64) ([]ListUserOrganizationsRow, error)
+	ListUserRoleGrants(ctx context.Context, userID int64) ([]UserRole, error)
+	ListUserRoles(ctx context.Context, userID int64) ([]string, error)
+	ListUserSessions(ctx context.Context, userID int64) ([]ListUserSessionsRow, error)
+	ListUsersDeletedBefore(ctx context.Context, deletedBefore sql.NullTime) ([]int64, error)
+	LockRateLimit(ctx context.Context, key string) (time.Time, error)
+	MarkOutboxEventFailed(ctx context.Context, arg MarkOutboxEventFailedParams) error
+	MarkOutboxEventPublished(ctx context.Context, arg MarkOutboxEventPublishedParams) error
+	MigrationMessage(ctx context.Context) (string, error)
+	RefreshSession(ctx context.Context, arg RefreshSessionParams) (int64, error)
+	ReleaseMailMessage(ctx context.Context, messageID string) error
+	RemoveOrganizationMember(ctx context.Context, arg RemoveOrganizationMemberParams) (int64, error)
+	RequestDataExport(ctx context.Context, arg RequestDataExportParams) (int64, error)
+	RestoreUser(ctx context.Context, arg RestoreUserParams) (int64, error)
+	RetryJob(ctx context.Context, arg RetryJobParams) (int64, error)
+	ReviveJob(ctx context.Context, arg ReviveJobParams) (int64, error)
+	RevokeInvitation(ctx context.Context, arg RevokeInvitationParams) (int64, error)
+	SaveDataExport(ctx context.Context, arg SaveDataExportParams) (int64, error)
+	SetUserLocale(ctx context.Context, arg SetUserLocaleParams) (int64, error)
+	TakeOverIdempotencyKey(ctx context.Context, arg TakeOverIdempotencyKeyParams) (int64, error)
+	TouchDevice(ctx context.Context, arg TouchDeviceParams) error
+	TouchSession(ctx context.Context, arg TouchSessionParams) error
+	UpdateOrganizationMemberRole(ctx context.Context, arg UpdateOrganizationMemberRoleParams) (int64, error)
+	UpdateRateLimit(ctx context.Context, arg UpdateRateLimitParams) error
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (int64, error)
+	UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (int64, error)
+}
+
+var _ Querier = (*Queries)(nil)
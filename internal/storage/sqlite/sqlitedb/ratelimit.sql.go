@@ -0,0 +1,62 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.20.0
+// source: ratelimit.sql
+
+package sqlitedb
+
+import (
+	"context"
+	"time"
+)
+
+const deleteExpiredRateLimits = `-- name: DeleteExpiredRateLimits :execrows
+DELETE FROM rate_limit WHERE tat < ?
+`
+
+func (q *Queries) DeleteExpiredRateLimits(ctx context.Context, tat time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredRateLimits, tat)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const ensureRateLimit = `-- name: EnsureRateLimit :exec
+INSERT INTO rate_limit (key, tat) VALUES (?, ?) ON CONFLICT (key) DO NOTHING
+`
+
+type EnsureRateLimitParams struct {
+	Key string
+	Tat time.Time
+}
+
+func (q *Queries) EnsureRateLimit(ctx context.Context, arg EnsureRateLimitParams) error {
+	_, err := q.db.ExecContext(ctx, ensureRateLimit, arg.Key, arg.Tat)
+	return err
+}
+
+const lockRateLimit = `-- name: LockRateLimit :one
+SELECT tat FROM rate_limit WHERE key = ?
+`
+
+func (q *Queries) LockRateLimit(ctx context.Context, key string) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, lockRateLimit, key)
+	var tat time.Time
+	err := row.Scan(&tat)
+	return tat, err
+}
+
+const updateRateLimit = `-- name: UpdateRateLimit :exec
+UPDATE rate_limit SET tat = ? WHERE key = ?
+`
+
+type UpdateRateLimitParams struct {
+	Tat time.Time
+	Key string
+}
+
+func (q *Queries) UpdateRateLimit(ctx context.Context, arg UpdateRateLimitParams) error {
+	_, err := q.db.ExecContext(ctx, updateRateLimit, arg.Tat, arg.Key)
+	return err
+}
@@ -0,0 +1,258 @@
+package validation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"scratch/api"
+	"scratch/internal/problem"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/go-chi/chi/v5"
+)
+
+func init() {
+	openapi3.DefineStringFormat("email", openapi3.FormatOfStringForEmail)
+}
+
+type Options struct {
+	// ValidateResponses checks responses against the spec, meant for development and tests.
+	ValidateResponses bool
+	// FailOnInvalidResponse replaces an invalid response with a 500 instead of only logging it.
+	FailOnInvalidResponse bool
+}
+
+// Validator checks requests, and optionally responses, against the OpenAPI spec.
+// It is meant to run as one of the api.ChiServerOptions middlewares, where chi
+// has already matched the route, so the route pattern names the spec path.
+type Validator struct {
+	spec    *openapi3.T
+	options Options
+	log     slog.Logger
+}
+
+func New(spec *openapi3.T, options Options, log slog.Logger) *Validator {
+	return &Validator{spec: spec, options: options, log: log}
+}
+
+func (v *Validator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, ok := v.findRoute(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		defaultContentType(r, route.Operation)
+
+		requestInput := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				MultiError: true,
+				// authentication is the job of the auth middleware
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			},
+		}
+		if err := openapi3filter.ValidateRequest(r.Context(), requestInput); err != nil {
+			problem.Write(w, r, problem.Validation(fieldErrors(err)))
+			return
+		}
+
+		if !v.options.ValidateResponses {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &responseRecorder{header: make(http.Header), code: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		err := openapi3filter.ValidateResponse(r.Context(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: requestInput,
+			Status:                 rec.code,
+			Header:                 rec.header,
+			Body:                   io.NopCloser(bytes.NewReader(rec.body.Bytes())),
+			Options:                &openapi3filter.Options{MultiError: true, IncludeResponseStatus: true},
+		})
+		if err != nil {
+			v.log.Error("response does not match the api specification",
+				"method", r.Method, "path", route.Path, "status", rec.code, "err", err)
+			if v.options.FailOnInvalidResponse {
+				p := problem.New(problem.InvalidResponse, "response does not match the api specification")
+				p.Errors = fieldErrors(err)
+				problem.Write(w, r, p)
+				return
+			}
+		}
+		rec.flush(w)
+	})
+}
+
+// findRoute maps the chi route of r to the spec operation.
+func (v *Validator) findRoute(r *http.Request) (*routers.Route, map[string]string, bool) {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return nil, nil, false
+	}
+	pattern := rctx.RoutePattern()
+	pathItem := v.spec.Paths.Find(pattern)
+	if pathItem == nil {
+		return nil, nil, false
+	}
+	operation := pathItem.GetOperation(r.Method)
+	if operation == nil {
+		return nil, nil, false
+	}
+
+	pathParams := make(map[string]string, len(rctx.URLParams.Keys))
+	for i, key := range rctx.URLParams.Keys {
+		pathParams[key] = rctx.URLParams.Values[i]
+	}
+
+	return &routers.Route{
+		Spec:      v.spec,
+		Path:      pattern,
+		PathItem:  pathItem,
+		Method:    r.Method,
+		Operation: operation,
+	}, pathParams, true
+}
+
+// defaultContentType sets the Content-Type of a request that has none when the
+// operation accepts only one media type, so clients posting plain JSON without
+// the header keep working.
+func defaultContentType(r *http.Request, operation *openapi3.Operation) {
+	if r.Header.Get("Content-Type") != "" || operation.RequestBody == nil || operation.RequestBody.Value == nil {
+		return
+	}
+	content := operation.RequestBody.Value.Content
+	if len(content) != 1 {
+		return
+	}
+	for mediaType := range content {
+		r.Header.Set("Content-Type", mediaType)
+	}
+}
+
+// Properties checks values against the properties of the named component
+// schema of spec, so forms served outside the JSON api share its constraints.
+// Only the properties present in values are checked.
+func Properties(spec *openapi3.T, schema string, values map[string]any) ([]api.FieldError, error) {
+	ref, ok := spec.Components.Schemas[schema]
+	if !ok || ref.Value == nil {
+		return nil, fmt.Errorf("unknown schema %v", schema)
+	}
+
+	var details []api.FieldError
+	for name, value := range values {
+		property, ok := ref.Value.Properties[name]
+		if !ok || property.Value == nil {
+			return nil, fmt.Errorf("schema %v has no property %v", schema, name)
+		}
+		if err := property.Value.VisitJSON(value, openapi3.MultiErrors()); err != nil {
+			details = append(details, fieldErrorsOf(err, name)...)
+		}
+	}
+	sort.SliceStable(details, func(i, j int) bool { return details[i].Field < details[j].Field })
+	return details, nil
+}
+
+// fieldErrors flattens kin-openapi errors into one entry per invalid field.
+func fieldErrors(err error) []api.FieldError {
+	return fieldErrorsOf(err, "")
+}
+
+// fieldErrorsOf is fieldErrors for errors of a value nested at field.
+func fieldErrorsOf(err error, field string) []api.FieldError {
+	var details []api.FieldError
+	var walk func(err error, field string)
+	walk = func(err error, field string) {
+		switch e := err.(type) {
+		case openapi3.MultiError:
+			for _, inner := range e {
+				walk(inner, field)
+			}
+		case *openapi3filter.RequestError:
+			if e.Parameter != nil {
+				field = e.Parameter.In + "." + e.Parameter.Name
+			}
+			if e.Err == nil {
+				details = append(details, api.FieldError{Field: field, Message: e.Reason})
+				return
+			}
+			walk(e.Err, field)
+		case *openapi3filter.ResponseError:
+			if e.Err == nil {
+				details = append(details, api.FieldError{Field: field, Message: e.Reason})
+				return
+			}
+			walk(e.Err, field)
+		case *openapi3.SchemaError:
+			if pointer := e.JSONPointer(); len(pointer) > 0 {
+				field = joinField(field, strings.Join(pointer, "."))
+			}
+			details = append(details, api.FieldError{Field: field, Message: e.Reason})
+		case *openapi3filter.ParseError:
+			details = append(details, api.FieldError{Field: field, Message: e.Reason})
+		default:
+			if inner := errors.Unwrap(err); inner != nil {
+				walk(inner, field)
+				return
+			}
+			details = append(details, api.FieldError{Field: field, Message: err.Error()})
+		}
+	}
+	walk(err, field)
+
+	sort.SliceStable(details, func(i, j int) bool { return details[i].Field < details[j].Field })
+	return details
+}
+
+func joinField(prefix, field string) string {
+	if prefix == "" {
+		return field
+	}
+	return fmt.Sprintf("%v.%v", prefix, field)
+}
+
+// responseRecorder buffers a response so it can be validated before it is sent.
+type responseRecorder struct {
+	header      http.Header
+	code        int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+// WriteHeader keeps the first status like net/http does.
+func (r *responseRecorder) WriteHeader(code int) {
+	if r.wroteHeader {
+		return
+	}
+	r.code = code
+	r.wroteHeader = true
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.body.Write(b)
+}
+
+func (r *responseRecorder) flush(w http.ResponseWriter) {
+	for k, v := range r.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(r.code)
+	_, _ = w.Write(r.body.Bytes())
+}
@@ -0,0 +1,283 @@
+package validation
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"scratch/api"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubServer answers every operation with a fixed response.
+type stubServer struct {
+	called bool
+	code   int
+	body   any
+}
+
+func (s *stubServer) PostLogin(w http.ResponseWriter, r *http.Request) {
+	s.respond(w)
+}
+
+func (s *stubServer) PostRegister(w http.ResponseWriter, r *http.Request) {
+	s.respond(w)
+}
+
+func (s *stubServer) PostSession(w http.ResponseWriter, r *http.Request) {
+	s.respond(w)
+}
+
+func (s *stubServer) DeleteSession(w http.ResponseWriter, r *http.Request) {
+	s.respond(w)
+}
+
+func (s *stubServer) PostRefresh(w http.ResponseWriter, r *http.Request) {
+	s.respond(w)
+}
+
+func (s *stubServer) GetMeSessions(w http.ResponseWriter, r *http.Request) {
+	s.respond(w)
+}
+
+func (s *stubServer) DeleteMeSessionsId(w http.ResponseWriter, r *http.Request, id int) {
+	s.respond(w)
+}
+
+func (s *stubServer) PostMeDeletion(w http.ResponseWriter, r *http.Request) {
+	s.respond(w)
+}
+
+func (s *stubServer) PostRestore(w http.ResponseWriter, r *http.Request) {
+	s.respond(w)
+}
+
+func (s *stubServer) GetMeExport(w http.ResponseWriter, r *http.Request) {
+	s.respond(w)
+}
+
+func (s *stubServer) GetExportsUserId(w http.ResponseWriter, r *http.Request, userId int, params api.GetExportsUserIdParams) {
+	s.respond(w)
+}
+
+func (s *stubServer) PostOrganizations(w http.ResponseWriter, r *http.Request) {
+	s.respond(w)
+}
+
+func (s *stubServer) GetOrganizations(w http.ResponseWriter, r *http.Request) {
+	s.respond(w)
+}
+
+func (s *stubServer) GetOrganizationsIdMembers(w http.ResponseWriter, r *http.Request, id int) {
+	s.respond(w)
+}
+
+func (s *stubServer) PatchOrganizationsIdMembersUserId(w http.ResponseWriter, r *http.Request, id int, userId int) {
+	s.respond(w)
+}
+
+func (s *stubServer) DeleteOrganizationsIdMembersUserId(w http.ResponseWriter, r *http.Request, id int, userId int) {
+	s.respond(w)
+}
+
+func (s *stubServer) PostOrganizationsIdInvitations(w http.ResponseWriter, r *http.Request, id int) {
+	s.respond(w)
+}
+
+func (s *stubServer) GetOrganizationsIdInvitations(w http.ResponseWriter, r *http.Request, id int) {
+	s.respond(w)
+}
+
+func (s *stubServer) DeleteOrganizationsIdInvitationsInvitationId(w http.ResponseWriter, r *http.Request, id int, invitationId int) {
+	s.respond(w)
+}
+
+func (s *stubServer) PostOrganizationsIdToken(w http.ResponseWriter, r *http.Request, id int) {
+	s.respond(w)
+}
+
+func (s *stubServer) PostInvitationsAccept(w http.ResponseWriter, r *http.Request) {
+	s.respond(w)
+}
+
+func (s *stubServer) GetInvitationsCode(w http.ResponseWriter, r *http.Request, code string) {
+	s.respond(w)
+}
+
+func (s *stubServer) GetUserId(w http.ResponseWriter, r *http.Request, id int) {
+	s.respond(w)
+}
+
+func (s *stubServer) respond(w http.ResponseWriter) {
+	s.called = true
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(s.code)
+	_ = json.NewEncoder(w).Encode(s.body)
+}
+
+func newHandler(t *testing.T, stub *stubServer, options Options) http.Handler {
+	t.Helper()
+	spec, err := api.GetSwagger()
+	require.NoError(t, err)
+
+	return api.HandlerWithOptions(stub, api.ChiServerOptions{
+		BaseRouter:  chi.NewRouter(),
+		Middlewares: []api.MiddlewareFunc{New(spec, options, *slog.New(slog.NewTextHandler(io.Discard, nil))).Middleware},
+	})
+}
+
+func TestValidator_Middleware(t *testing.T) {
+	validLogin := api.LoginUserResponse{Token: "token", RefreshToken: "refresh"}
+
+	tests := []struct {
+		name        string
+		options     Options
+		method      string
+		path        string
+		contentType string
+		body        string
+		stub        stubServer
+		wantCode    int
+		wantCalled  bool
+		wantDetails []api.FieldError
+	}{
+		{
+			name:        "valid login is passed to the handler",
+			method:      http.MethodPost,
+			path:        "/login",
+			contentType: "application/json",
+			body:        `{"email":"john@example.com","password":"secret"}`,
+			stub:        stubServer{code: http.StatusOK, body: validLogin},
+			wantCode:    http.StatusOK,
+			wantCalled:  true,
+		},
+		{
+			name:       "missing content type defaults to json",
+			method:     http.MethodPost,
+			path:       "/login",
+			body:       `{"email":"john@example.com","password":"secret"}`,
+			stub:       stubServer{code: http.StatusOK, body: validLogin},
+			wantCode:   http.StatusOK,
+			wantCalled: true,
+		},
+		{
+			name:        "missing email",
+			method:      http.MethodPost,
+			path:        "/login",
+			contentType: "application/json",
+			body:        `{"password":"secret"}`,
+			wantCode:    http.StatusBadRequest,
+			wantDetails: []api.FieldError{{Field: "email", Message: `property "email" is missing`}},
+		},
+		{
+			name:        "invalid email and short password",
+			method:      http.MethodPost,
+			path:        "/register",
+			contentType: "application/json",
+			body:        `{"email":"john","name":"John","password":"short"}`,
+			wantCode:    http.StatusBadRequest,
+			wantDetails: []api.FieldError{
+				{Field: "email", Message: `string doesn't match the format "email" (regular expression "^[^@]+@[^@<>",\s]+$")`},
+				{Field: "password", Message: "minimum string length is 8"},
+			},
+		},
+		{
+			name:        "path parameter out of range",
+			method:      http.MethodGet,
+			path:        "/user/0",
+			wantCode:    http.StatusBadRequest,
+			wantDetails: []api.FieldError{{Field: "path.id", Message: "number must be at least 1"}},
+		},
+		{
+			name:        "owners are not invited",
+			method:      http.MethodPost,
+			path:        "/organizations/1/invitations",
+			contentType: "application/json",
+			body:        `{"email":"john@example.com","role":"owner"}`,
+			wantCode:    http.StatusBadRequest,
+			wantDetails: []api.FieldError{{Field: "role", Message: `value is not one of the allowed values ["admin","member"]`}},
+		},
+		{
+			name:        "invite codes are not empty",
+			method:      http.MethodPost,
+			path:        "/register",
+			contentType: "application/json",
+			body:        `{"email":"john@example.com","name":"John","password":"password","invite":""}`,
+			wantCode:    http.StatusBadRequest,
+			wantDetails: []api.FieldError{{Field: "invite", Message: "minimum string length is 1"}},
+		},
+		{
+			name:        "invalid response is only logged by default",
+			options:     Options{ValidateResponses: true},
+			method:      http.MethodPost,
+			path:        "/login",
+			contentType: "application/json",
+			body:        `{"email":"john@example.com","password":"secret"}`,
+			stub:        stubServer{code: http.StatusOK, body: map[string]string{"token": "token"}},
+			wantCode:    http.StatusOK,
+			wantCalled:  true,
+		},
+		{
+			name:        "invalid response fails when configured",
+			options:     Options{ValidateResponses: true, FailOnInvalidResponse: true},
+			method:      http.MethodPost,
+			path:        "/login",
+			contentType: "application/json",
+			body:        `{"email":"john@example.com","password":"secret"}`,
+			stub:        stubServer{code: http.StatusOK, body: map[string]string{"token": "token"}},
+			wantCode:    http.StatusInternalServerError,
+			wantCalled:  true,
+			wantDetails: []api.FieldError{{Field: "refreshToken", Message: `property "refreshToken" is missing`}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newHandler(t, &tt.stub, tt.options)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			assert.Equal(t, tt.wantCalled, tt.stub.called)
+			if tt.wantDetails == nil {
+				return
+			}
+			assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+			var res api.Problem
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&res))
+			require.NotNil(t, res.Errors)
+			assert.Equal(t, tt.wantDetails, *res.Errors)
+		})
+	}
+}
+
+func TestProperties(t *testing.T) {
+	spec, err := api.GetSwagger()
+	require.NoError(t, err)
+
+	details, err := Properties(spec, "RegisterUserRequest", map[string]any{"email": "joedoe@gmail.com", "password": "Test123!"})
+	require.NoError(t, err)
+	assert.Empty(t, details)
+
+	details, err = Properties(spec, "RegisterUserRequest", map[string]any{"name": "x", "password": "short"})
+	require.NoError(t, err)
+	require.Len(t, details, 2)
+	assert.Equal(t, "name", details[0].Field)
+	assert.Equal(t, "password", details[1].Field)
+
+	_, err = Properties(spec, "RegisterUserRequest", map[string]any{"nickname": "x"})
+	assert.Error(t, err)
+	_, err = Properties(spec, "Unknown", nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,275 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"scratch/api"
+	"scratch/internal/authorization/middlewares"
+	"scratch/internal/i18n"
+	"scratch/internal/services"
+	"scratch/internal/validation"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// signupPage shows the registration form, filled in from the invitation of
+// the invite link it was opened with.
+func (h *Handler) signupPage(w http.ResponseWriter, r *http.Request) {
+	data := page{Title: "web.signup.title", Locales: i18n.Default().Locales()}
+	if code := r.URL.Query().Get("invite"); code != "" {
+		preview, err := h.accounts.Invitation(r.Context(), code)
+		switch {
+		case errors.Is(err, services.InvitationNotFoundErr):
+			data.Errors = map[string]string{"form": h.t(r, "problem.invitation-not-found")}
+		case err != nil:
+			h.fail(w, r, err)
+			return
+		default:
+			data.Form = map[string]string{"email": preview.Email, "invite": code}
+			data.Invitation = &invitation{Organization: preview.Organization}
+			if preview.Inviter != nil {
+				data.Invitation.Inviter = *preview.Inviter
+			}
+		}
+	}
+	h.render(w, r, http.StatusOK, "signup", data)
+}
+
+func (h *Handler) signup(w http.ResponseWriter, r *http.Request) {
+	form := formValues(r, "email", "name", "locale", "invite")
+	data := page{Title: "web.signup.title", Form: form, Locales: i18n.Default().Locales()}
+
+	values := map[string]any{"email": form["email"], "name": form["name"], "password": r.PostFormValue("password")}
+	if form["locale"] != "" {
+		values["locale"] = form["locale"]
+	}
+	if form["invite"] != "" {
+		values["invite"] = form["invite"]
+	}
+	if data.Errors = h.validate(w, r, values); data.Errors == nil {
+		return
+	}
+	if len(data.Errors) > 0 {
+		h.render(w, r, http.StatusUnprocessableEntity, "signup", data)
+		return
+	}
+
+	request := api.RegisterUserRequest{Email: form["email"], Name: form["name"], Password: r.PostFormValue("password")}
+	if locale := form["locale"]; locale != "" {
+		request.Locale = &locale
+	}
+	if invite := form["invite"]; invite != "" {
+		request.Invite = &invite
+	}
+	if _, err := h.accounts.CreateUser(r.Context(), request); err != nil {
+		h.formError(w, r, "signup", data, err)
+		return
+	}
+	h.startSession(w, r, "signup", data, api.LoginUserRequest{Email: request.Email, Password: request.Password})
+}
+
+func (h *Handler) loginPage(w http.ResponseWriter, r *http.Request) {
+	h.render(w, r, http.StatusOK, "login", page{Title: "web.login.title"})
+}
+
+func (h *Handler) login(w http.ResponseWriter, r *http.Request) {
+	form := formValues(r, "email")
+	data := page{Title: "web.login.title", Form: form}
+	h.startSession(w, r, "login", data, api.LoginUserRequest{Email: form["email"], Password: r.PostFormValue("password")})
+}
+
+func (h *Handler) startSession(w http.ResponseWriter, r *http.Request, name string, data page, credentials api.LoginUserRequest) {
+	s, err := h.accounts.LoginBrowser(r.Context(), credentials, h.cookies.TTL)
+	if err != nil {
+		h.formError(w, r, name, data, err)
+		return
+	}
+	middlewares.SetSessionCookies(w, h.cookies, s.Token, s.CSRFToken, s.ExpiresAt)
+	redirect(w, r, Prefix+"/profile")
+}
+
+func (h *Handler) logout(w http.ResponseWriter, r *http.Request) {
+	s, _ := sessionFrom(r.Context())
+	if err := h.accounts.Logout(r.Context(), s.Token); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	middlewares.ClearSessionCookies(w, h.cookies)
+	redirect(w, r, Prefix+"/login")
+}
+
+func (h *Handler) profilePage(w http.ResponseWriter, r *http.Request) {
+	s, _ := sessionFrom(r.Context())
+	profile, err := h.accounts.Profile(r.Context(), s.UserID)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	data := page{
+		Title:   "web.profile.title",
+		Profile: profile,
+		Form:    map[string]string{"name": profile.Name, "locale": profile.Locale},
+		Locales: i18n.Default().Locales(),
+	}
+	switch r.URL.Query().Get("saved") {
+	case "profile":
+		data.Flash = "web.profile.saved"
+	case "password":
+		data.Flash = "web.password.changed"
+	}
+	h.render(w, r, http.StatusOK, "profile", data)
+}
+
+func (h *Handler) updateProfile(w http.ResponseWriter, r *http.Request) {
+	s, _ := sessionFrom(r.Context())
+	profile, err := h.accounts.Profile(r.Context(), s.UserID)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	form := formValues(r, "name", "locale")
+	data := page{Title: "web.profile.title", Profile: profile, Form: form, Locales: i18n.Default().Locales()}
+	values := map[string]any{"name": form["name"]}
+	if form["locale"] != "" {
+		values["locale"] = form["locale"]
+	}
+	if data.Errors = h.validate(w, r, values); data.Errors == nil {
+		return
+	}
+	if len(data.Errors) > 0 {
+		h.render(w, r, http.StatusUnprocessableEntity, "profile", data)
+		return
+	}
+
+	if err := h.accounts.UpdateProfile(r.Context(), s.UserID, form["name"], form["locale"]); err != nil {
+		h.formError(w, r, "profile", data, err)
+		return
+	}
+	redirect(w, r, Prefix+"/profile?saved=profile")
+}
+
+func (h *Handler) passwordPage(w http.ResponseWriter, r *http.Request) {
+	h.render(w, r, http.StatusOK, "password", page{Title: "web.password.title"})
+}
+
+func (h *Handler) changePassword(w http.ResponseWriter, r *http.Request) {
+	s, _ := sessionFrom(r.Context())
+	data := page{Title: "web.password.title"}
+
+	password := r.PostFormValue("new_password")
+	if data.Errors = h.validate(w, r, map[string]any{"password": password}); data.Errors == nil {
+		return
+	}
+	if message, ok := data.Errors["password"]; ok {
+		data.Errors = map[string]string{"new_password": message}
+		h.render(w, r, http.StatusUnprocessableEntity, "password", data)
+		return
+	}
+
+	if err := h.accounts.ChangePassword(r.Context(), s.UserID, r.PostFormValue("current_password"), password, s.Token); err != nil {
+		if errors.Is(err, services.IncorrectPasswordErr) {
+			data.Errors = map[string]string{"current_password": h.t(r, "problem.invalid-credentials")}
+			h.render(w, r, http.StatusUnprocessableEntity, "password", data)
+			return
+		}
+		h.fail(w, r, err)
+		return
+	}
+	redirect(w, r, Prefix+"/profile?saved=password")
+}
+
+func (h *Handler) sessionsPage(w http.ResponseWriter, r *http.Request) {
+	s, _ := sessionFrom(r.Context())
+	sessions, err := h.accounts.Sessions(r.Context(), s.UserID, s.Token)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	h.render(w, r, http.StatusOK, "sessions", page{Title: "web.sessions.title", Sessions: sessions})
+}
+
+func (h *Handler) revokeSession(w http.ResponseWriter, r *http.Request) {
+	s, _ := sessionFrom(r.Context())
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	err = h.accounts.RevokeSession(r.Context(), s.UserID, id)
+	if err != nil && !errors.Is(err, services.SessionNotFoundErr) {
+		h.fail(w, r, err)
+		return
+	}
+	// revoking the current session is a logout
+	if _, err := h.accounts.BrowserSession(r.Context(), s.Token); errors.Is(err, services.SessionNotFoundErr) {
+		middlewares.ClearSessionCookies(w, h.cookies)
+		redirect(w, r, Prefix+"/login")
+		return
+	}
+	redirect(w, r, Prefix+"/sessions")
+}
+
+// validate checks form values against the RegisterUserRequest schema of the
+// api. It returns the message of every invalid field, or nil after it
+// answered the request itself.
+func (h *Handler) validate(w http.ResponseWriter, r *http.Request, values map[string]any) map[string]string {
+	details, err := validation.Properties(h.spec, "RegisterUserRequest", values)
+	if err != nil {
+		h.fail(w, r, err)
+		return nil
+	}
+	errs := make(map[string]string)
+	for _, detail := range details {
+		if _, ok := errs[detail.Field]; !ok {
+			errs[detail.Field] = detail.Message
+		}
+	}
+	return errs
+}
+
+// formError shows the error of a service next to the field it is about, or
+// above the form.
+func (h *Handler) formError(w http.ResponseWriter, r *http.Request, name string, data page, err error) {
+	var field, key string
+	switch {
+	case errors.Is(err, services.UserExistErr):
+		field, key = "email", "problem.user-exists"
+	case errors.Is(err, services.UnsupportedLocaleErr):
+		field, key = "locale", "problem.unsupported-locale"
+	case errors.Is(err, services.UserNotFoundErr), errors.Is(err, services.IncorrectPasswordErr):
+		// both look the same, so the form does not tell which emails are registered
+		field, key = "form", "problem.invalid-credentials"
+	case errors.Is(err, services.UserDisabledErr):
+		field, key = "form", "problem.user-disabled"
+	case errors.Is(err, services.UserDeletedErr):
+		field, key = "form", "problem.user-deleted"
+	case errors.Is(err, services.InvitationNotFoundErr):
+		field, key = "form", "problem.invitation-not-found"
+	case errors.Is(err, services.InvitationRequiredErr):
+		field, key = "form", "problem.invitation-required"
+	default:
+		h.fail(w, r, err)
+		return
+	}
+	data.Errors = map[string]string{field: h.t(r, key)}
+	h.render(w, r, http.StatusUnprocessableEntity, name, data)
+}
+
+func (h *Handler) t(r *http.Request, key string) string {
+	return i18n.Default().T(i18n.LocaleFrom(r.Context()), key)
+}
+
+// formValues returns the trimmed values of the named form fields, to be
+// rendered back into the form. Passwords are read separately and never
+// rendered.
+func formValues(r *http.Request, names ...string) map[string]string {
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		values[name] = strings.TrimSpace(r.PostFormValue(name))
+	}
+	return values
+}
@@ -0,0 +1,299 @@
+// Package web serves the browser frontend: server rendered pages enhanced
+// with htmx. Every page is a plain html form first, so it works without
+// JavaScript; htmx, when loaded, only saves the full page reloads.
+package web
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"path"
+	"scratch/api"
+	"scratch/internal/authorization/middlewares"
+	"scratch/internal/i18n"
+	"scratch/internal/services"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+)
+
+// Prefix is the path the pages are served under.
+const Prefix = "/web"
+
+//go:embed templates/*.html
+var templates embed.FS
+
+//go:embed static/*
+var static embed.FS
+
+// Accounts is the part of services.AccountService the pages use.
+type Accounts interface {
+	CreateUser(ctx context.Context, model api.RegisterUserRequest) (int, error)
+	Invitation(ctx context.Context, code string) (api.InvitationPreview, error)
+	LoginBrowser(ctx context.Context, model api.LoginUserRequest, ttl time.Duration) (services.BrowserSession, error)
+	BrowserSession(ctx context.Context, token string) (services.BrowserSession, error)
+	Logout(ctx context.Context, token string) error
+	Profile(ctx context.Context, userID int) (services.Profile, error)
+	UpdateProfile(ctx context.Context, userID int, name, locale string) error
+	ChangePassword(ctx context.Context, userID int, current, password, keep string) error
+	Sessions(ctx context.Context, userID int, current string) ([]services.SessionInfo, error)
+	RevokeSession(ctx context.Context, userID, sessionID int) error
+}
+
+type Handler struct {
+	accounts Accounts
+	cookies  middlewares.CookieConfig
+	spec     *openapi3.T
+	pages    map[string]*template.Template
+	log      slog.Logger
+}
+
+// New parses the page templates. Form fields are checked against the schemas
+// of spec, so the pages accept exactly what the JSON api does.
+func New(accounts Accounts, cookies middlewares.CookieConfig, spec *openapi3.T, log slog.Logger) (*Handler, error) {
+	files, err := fs.Glob(templates, "templates/*.html")
+	if err != nil {
+		return nil, fmt.Errorf("list templates: %w", err)
+	}
+
+	h := &Handler{accounts: accounts, cookies: cookies, spec: spec, pages: make(map[string]*template.Template), log: log}
+	for _, file := range files {
+		name := strings.TrimSuffix(path.Base(file), ".html")
+		if name == "layout" {
+			continue
+		}
+		// the texts are rendered in the locale of each request, these
+		// functions only let the templates parse
+		// missing form values and errors render as empty strings
+		t, err := template.New(name).Option("missingkey=zero").
+			Funcs(i18n.Default().Funcs(i18n.DefaultLocale)).
+			Funcs(template.FuncMap{"field": field}).
+			ParseFS(templates, "templates/layout.html", file)
+		if err != nil {
+			return nil, fmt.Errorf("parse template %v: %w", name, err)
+		}
+		h.pages[name] = t
+	}
+	return h, nil
+}
+
+// Routes registers the pages on r, which is expected to be mounted at Prefix.
+func (h *Handler) Routes(r chi.Router) {
+	r.Handle("/static/*", http.StripPrefix(Prefix, http.FileServer(http.FS(static))))
+
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, Prefix+"/profile", http.StatusSeeOther)
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(h.requireLoginCSRF)
+		r.Get("/signup", h.signupPage)
+		r.Post("/signup", h.signup)
+		r.Get("/login", h.loginPage)
+		r.Post("/login", h.login)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.requireSession)
+		r.Post("/logout", h.logout)
+		r.Get("/profile", h.profilePage)
+		r.Post("/profile", h.updateProfile)
+		r.Get("/password", h.passwordPage)
+		r.Post("/password", h.changePassword)
+		r.Get("/sessions", h.sessionsPage)
+		r.Post("/sessions/{id}/revoke", h.revokeSession)
+	})
+}
+
+// page is the data every template is rendered with.
+type page struct {
+	// Title is a message key.
+	Title string
+	// Signed is set on the pages of a logged in user.
+	Signed bool
+	CSRF   string
+	Form   map[string]string
+	// Errors maps a form field, or "form" for the whole form, to its message.
+	Errors map[string]string
+	// Flash is a message key confirming the last action.
+	Flash    string
+	Profile  services.Profile
+	Locales  []string
+	Sessions []services.SessionInfo
+	// Invitation is the one the signup page registers with.
+	Invitation *invitation
+}
+
+// invitation is what the signup page shows of an invitation, Inviter is empty
+// once its account is gone.
+type invitation struct {
+	Organization string
+	Inviter      string
+}
+
+// input is rendered by the "field" template of the layout.
+type input struct {
+	Label        string
+	Type         string
+	Name         string
+	Value        string
+	Error        string
+	Autocomplete string
+}
+
+func field(label, typ, name, value, err, autocomplete string) input {
+	return input{Label: label, Type: typ, Name: name, Value: value, Error: err, Autocomplete: autocomplete}
+}
+
+func (h *Handler) render(w http.ResponseWriter, r *http.Request, status int, name string, data page) {
+	if data.Form == nil {
+		data.Form = make(map[string]string)
+	}
+	if s, ok := sessionFrom(r.Context()); ok {
+		data.Signed = true
+		data.CSRF = s.CSRFToken
+	} else if token, ok := r.Context().Value(loginCSRFKey{}).(string); ok {
+		data.CSRF = token
+	}
+
+	locale := i18n.LocaleFrom(r.Context())
+	if locale == "" {
+		locale = i18n.DefaultLocale
+	}
+	t, err := h.pages[name].Clone()
+	if err != nil {
+		h.fail(w, r, fmt.Errorf("clone template %v: %w", name, err))
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := t.Funcs(i18n.Default().Funcs(locale)).ExecuteTemplate(&buf, "layout", data); err != nil {
+		h.fail(w, r, fmt.Errorf("render template %v: %w", name, err))
+		return
+	}
+
+	// htmx does not swap in error responses, so the form with its errors
+	// goes out as a success to it
+	if status >= http.StatusBadRequest && status < http.StatusInternalServerError && isHTMX(r) {
+		status = http.StatusOK
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_, _ = buf.WriteTo(w)
+}
+
+func (h *Handler) fail(w http.ResponseWriter, r *http.Request, err error) {
+	h.log.Error("render page", "path", r.URL.Path, "err", err)
+	locale := i18n.LocaleFrom(r.Context())
+	http.Error(w, i18n.Default().T(locale, "problem.internal"), http.StatusInternalServerError)
+}
+
+// redirect ends a successful form submission. htmx follows HX-Redirect with a
+// full navigation, which also refreshes the navigation of the layout.
+func redirect(w http.ResponseWriter, r *http.Request, to string) {
+	if isHTMX(r) {
+		w.Header().Set("HX-Redirect", to)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	http.Redirect(w, r, to, http.StatusSeeOther)
+}
+
+func isHTMX(r *http.Request) bool {
+	return r.Header.Get("HX-Request") == "true"
+}
+
+type sessionKey struct{}
+
+func sessionFrom(ctx context.Context) (services.BrowserSession, bool) {
+	s, ok := ctx.Value(sessionKey{}).(services.BrowserSession)
+	return s, ok
+}
+
+// requireSession sends visitors without a live session to the login page and
+// checks the csrf token of every form they submit.
+func (h *Handler) requireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(h.cookies.Name)
+		if err != nil {
+			redirect(w, r, Prefix+"/login")
+			return
+		}
+		s, err := h.accounts.BrowserSession(r.Context(), cookie.Value)
+		if errors.Is(err, services.SessionNotFoundErr) {
+			middlewares.ClearSessionCookies(w, h.cookies)
+			redirect(w, r, Prefix+"/login")
+			return
+		}
+		if err != nil {
+			h.fail(w, r, err)
+			return
+		}
+
+		if r.Method == http.MethodPost && !validCSRFToken(r, s.CSRFToken) {
+			csrfFailed(w, r)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), sessionKey{}, s)))
+	})
+}
+
+type loginCSRFKey struct{}
+
+// requireLoginCSRF checks the csrf token of the forms that start a session.
+// Without one there is no session token to check them against, so the token
+// comes from a cookie of its own, issued with the pages. Otherwise any site
+// could log its visitors in to an account of its choosing.
+func (h *Handler) requireLoginCSRF(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := middlewares.LoginCSRFToken(r, h.cookies)
+		if r.Method == http.MethodPost {
+			if !validCSRFToken(r, token) {
+				csrfFailed(w, r)
+				return
+			}
+		} else if token == "" {
+			var err error
+			if token, err = randomToken(); err != nil {
+				h.fail(w, r, fmt.Errorf("generate csrf token: %w", err))
+				return
+			}
+			middlewares.SetLoginCSRFCookie(w, h.cookies, token)
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), loginCSRFKey{}, token)))
+	})
+}
+
+// validCSRFToken reports whether the form, or the header htmx may send
+// instead, echoes want.
+func validCSRFToken(r *http.Request, want string) bool {
+	token := r.PostFormValue("csrf_token")
+	if token == "" {
+		token = r.Header.Get(middlewares.CSRFHeader)
+	}
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(want)) == 1
+}
+
+func csrfFailed(w http.ResponseWriter, r *http.Request) {
+	locale := i18n.LocaleFrom(r.Context())
+	http.Error(w, i18n.Default().T(locale, "problem.csrf-failed"), http.StatusForbidden)
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,462 @@
+package web
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"scratch/api"
+	"scratch/internal/authorization/middlewares"
+	"scratch/internal/i18n"
+	"scratch/internal/services"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// accounts keeps one user, joedoe@gmail.com with password Test123!, and the
+// browser sessions by token. The invitation with code "code" invites
+// ann@gmail.com.
+type accounts struct {
+	profile  services.Profile
+	password string
+	sessions map[string]services.BrowserSession
+	created  []api.RegisterUserRequest
+}
+
+func newAccounts() *accounts {
+	return &accounts{
+		profile:  services.Profile{ID: 1, Name: "Joe", Email: "joedoe@gmail.com"},
+		password: "Test123!",
+		sessions: map[string]services.BrowserSession{"token": {UserID: 1, Token: "token", CSRFToken: "csrf"}},
+	}
+}
+
+func (a *accounts) CreateUser(ctx context.Context, model api.RegisterUserRequest) (int, error) {
+	if model.Email == a.profile.Email {
+		return 0, services.UserExistErr
+	}
+	if model.Invite != nil && *model.Invite != "code" {
+		return 0, services.InvitationNotFoundErr
+	}
+	a.created = append(a.created, model)
+	a.profile = services.Profile{ID: 2, Name: model.Name, Email: model.Email}
+	a.password = model.Password
+	return 2, nil
+}
+
+func (a *accounts) Invitation(ctx context.Context, code string) (api.InvitationPreview, error) {
+	if code != "code" {
+		return api.InvitationPreview{}, services.InvitationNotFoundErr
+	}
+	inviter := "Joe"
+	return api.InvitationPreview{Email: "ann@gmail.com", Organization: "Acme", Role: api.Member, Inviter: &inviter}, nil
+}
+
+func (a *accounts) LoginBrowser(ctx context.Context, model api.LoginUserRequest, ttl time.Duration) (services.BrowserSession, error) {
+	if model.Email != a.profile.Email || model.Password != a.password {
+		return services.BrowserSession{}, services.IncorrectPasswordErr
+	}
+	s := services.BrowserSession{UserID: a.profile.ID, Token: "new-token", CSRFToken: "new-csrf", ExpiresAt: time.Now().Add(ttl)}
+	a.sessions[s.Token] = s
+	return s, nil
+}
+
+func (a *accounts) BrowserSession(ctx context.Context, token string) (services.BrowserSession, error) {
+	if s, ok := a.sessions[token]; ok {
+		return s, nil
+	}
+	return services.BrowserSession{}, services.SessionNotFoundErr
+}
+
+func (a *accounts) Logout(ctx context.Context, token string) error {
+	delete(a.sessions, token)
+	return nil
+}
+
+func (a *accounts) Profile(ctx context.Context, userID int) (services.Profile, error) {
+	return a.profile, nil
+}
+
+func (a *accounts) UpdateProfile(ctx context.Context, userID int, name, locale string) error {
+	if locale == "tlh" {
+		return services.UnsupportedLocaleErr
+	}
+	a.profile.Name, a.profile.Locale = name, locale
+	return nil
+}
+
+func (a *accounts) ChangePassword(ctx context.Context, userID int, current, password, keep string) error {
+	if current != a.password {
+		return services.IncorrectPasswordErr
+	}
+	a.password = password
+	for token := range a.sessions {
+		if token != keep {
+			delete(a.sessions, token)
+		}
+	}
+	return nil
+}
+
+func (a *accounts) Sessions(ctx context.Context, userID int, current string) ([]services.SessionInfo, error) {
+	return []services.SessionInfo{
+		{ID: 1, Browser: true, DeviceName: "Firefox on Linux", IP: "192.0.2.1", Current: true},
+		{ID: 2, DeviceName: "Joe's phone", IP: "192.0.2.2"},
+	}, nil
+}
+
+func (a *accounts) RevokeSession(ctx context.Context, userID, sessionID int) error {
+	if sessionID == 1 {
+		delete(a.sessions, "token")
+	}
+	return nil
+}
+
+func newRouter(t *testing.T, a Accounts) http.Handler {
+	t.Helper()
+	spec, err := api.GetSwagger()
+	require.NoError(t, err)
+	h, err := New(a, middlewares.CookieConfig{Name: "session", TTL: time.Hour}, spec, *slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Use(i18n.Default().Middleware)
+	r.Route(Prefix, h.Routes)
+	return r
+}
+
+func post(path string, form url.Values, signed bool) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if signed {
+		r.AddCookie(&http.Cookie{Name: "session", Value: "token"})
+	} else {
+		r.AddCookie(&http.Cookie{Name: "session_login_csrf", Value: "login-csrf"})
+	}
+	return r
+}
+
+func get(path string, signed bool) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	if signed {
+		r.AddCookie(&http.Cookie{Name: "session", Value: "token"})
+	}
+	return r
+}
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		request func() *http.Request
+		verify  func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts)
+	}{
+		{
+			name:    "success - signup page",
+			request: func() *http.Request { return get("/web/signup", false) },
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), `<form method="post" action="/web/signup">`)
+				assert.Contains(t, rec.Body.String(), "Create an account")
+			},
+		},
+		{
+			name: "success - signup page in polish",
+			request: func() *http.Request {
+				r := get("/web/signup", false)
+				r.Header.Set("Accept-Language", "pl")
+				return r
+			},
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Contains(t, rec.Body.String(), `<html lang="pl">`)
+				assert.Contains(t, rec.Body.String(), "Załóż konto")
+			},
+		},
+		{
+			name:    "success - signup page filled in from the invite link",
+			request: func() *http.Request { return get("/web/signup?invite=code", false) },
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), "Joe invited you to join Acme.")
+				assert.Contains(t, rec.Body.String(), `<input type="hidden" name="invite" value="code">`)
+				assert.Contains(t, rec.Body.String(), `value="ann@gmail.com"`)
+			},
+		},
+		{
+			name:    "fail - signup page with an unknown invite",
+			request: func() *http.Request { return get("/web/signup?invite=other", false) },
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), "The invitation is invalid, expired, revoked or already accepted")
+				assert.NotContains(t, rec.Body.String(), `name="invite"`)
+			},
+		},
+		{
+			name: "success - signup with an invitation",
+			request: func() *http.Request {
+				return post("/web/signup", url.Values{"csrf_token": {"login-csrf"}, "email": {"ann@gmail.com"}, "name": {"Ann"}, "password": {"Secret123!"}, "invite": {"code"}}, false)
+			},
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusSeeOther, rec.Code)
+				require.Len(t, a.created, 1)
+				require.NotNil(t, a.created[0].Invite)
+				assert.Equal(t, "code", *a.created[0].Invite)
+			},
+		},
+		{
+			name: "fail - signup with a revoked invitation",
+			request: func() *http.Request {
+				return post("/web/signup", url.Values{"csrf_token": {"login-csrf"}, "email": {"ann@gmail.com"}, "name": {"Ann"}, "password": {"Secret123!"}, "invite": {"revoked"}}, false)
+			},
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+				assert.Contains(t, rec.Body.String(), "The invitation is invalid, expired, revoked or already accepted")
+				assert.Contains(t, rec.Body.String(), `<input type="hidden" name="invite" value="revoked">`)
+				assert.Empty(t, a.created)
+			},
+		},
+		{
+			name: "success - signup logs in",
+			request: func() *http.Request {
+				return post("/web/signup", url.Values{"csrf_token": {"login-csrf"}, "email": {"ann@gmail.com"}, "name": {"Ann"}, "password": {"Secret123!"}, "locale": {"pl"}}, false)
+			},
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusSeeOther, rec.Code)
+				assert.Equal(t, "/web/profile", rec.Header().Get("Location"))
+				require.Len(t, a.created, 1)
+				require.NotNil(t, a.created[0].Locale)
+				assert.Equal(t, "pl", *a.created[0].Locale)
+				assert.Contains(t, rec.Header().Get("Set-Cookie"), "session=new-token")
+			},
+		},
+		{
+			name: "fail - signup renders validation errors inline",
+			request: func() *http.Request {
+				return post("/web/signup", url.Values{"csrf_token": {"login-csrf"}, "email": {"not an email"}, "name": {"Ann"}, "password": {"short"}}, false)
+			},
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+				assert.Contains(t, rec.Body.String(), `value="not an email"`)
+				assert.Contains(t, rec.Body.String(), `<small class="error">string doesn&#39;t match the format &#34;email&#34;`)
+				assert.Contains(t, rec.Body.String(), `minimum string length is 8`)
+				assert.NotContains(t, rec.Body.String(), "short", "passwords are not rendered back")
+				assert.Empty(t, a.created)
+			},
+		},
+		{
+			name: "fail - signup with a registered email, htmx gets the form as a success",
+			request: func() *http.Request {
+				r := post("/web/signup", url.Values{"csrf_token": {"login-csrf"}, "email": {"joedoe@gmail.com"}, "name": {"Joe"}, "password": {"Test123!"}}, false)
+				r.Header.Set("HX-Request", "true")
+				return r
+			},
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), "A user with that email already exists")
+			},
+		},
+		{
+			name: "success - login with htmx redirects through HX-Redirect",
+			request: func() *http.Request {
+				r := post("/web/login", url.Values{"csrf_token": {"login-csrf"}, "email": {"joedoe@gmail.com"}, "password": {"Test123!"}}, false)
+				r.Header.Set("HX-Request", "true")
+				return r
+			},
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Equal(t, "/web/profile", rec.Header().Get("HX-Redirect"))
+				assert.Contains(t, rec.Header().Get("Set-Cookie"), "HttpOnly")
+			},
+		},
+		{
+			name: "fail - login with a wrong password",
+			request: func() *http.Request {
+				return post("/web/login", url.Values{"csrf_token": {"login-csrf"}, "email": {"joedoe@gmail.com"}, "password": {"wrong"}}, false)
+			},
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+				assert.Contains(t, rec.Body.String(), "Incorrect email or password")
+			},
+		},
+		{
+			name:    "success - login page issues the login csrf token",
+			request: func() *http.Request { return get("/web/login", false) },
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				cookies := rec.Result().Cookies()
+				require.Len(t, cookies, 1)
+				assert.Equal(t, "session_login_csrf", cookies[0].Name)
+				assert.True(t, cookies[0].HttpOnly)
+				assert.Contains(t, rec.Body.String(), `name="csrf_token" value="`+cookies[0].Value+`"`)
+			},
+		},
+		{
+			name: "success - login page keeps the login csrf token",
+			request: func() *http.Request {
+				r := get("/web/login", false)
+				r.AddCookie(&http.Cookie{Name: "session_login_csrf", Value: "login-csrf"})
+				return r
+			},
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Empty(t, rec.Header().Get("Set-Cookie"))
+				assert.Contains(t, rec.Body.String(), `name="csrf_token" value="login-csrf"`)
+			},
+		},
+		{
+			name: "fail - login without the csrf token",
+			request: func() *http.Request {
+				return post("/web/login", url.Values{"email": {"joedoe@gmail.com"}, "password": {"Test123!"}}, false)
+			},
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusForbidden, rec.Code)
+				assert.Empty(t, rec.Header().Get("Set-Cookie"))
+			},
+		},
+		{
+			name: "fail - login with a csrf token other than the cookie",
+			request: func() *http.Request {
+				return post("/web/login", url.Values{"csrf_token": {"forged"}, "email": {"joedoe@gmail.com"}, "password": {"Test123!"}}, false)
+			},
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusForbidden, rec.Code)
+				assert.Empty(t, rec.Header().Get("Set-Cookie"))
+			},
+		},
+		{
+			name: "fail - signup without the csrf token",
+			request: func() *http.Request {
+				return post("/web/signup", url.Values{"email": {"ann@gmail.com"}, "name": {"Ann"}, "password": {"Secret123!"}}, false)
+			},
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusForbidden, rec.Code)
+				assert.Empty(t, a.created)
+			},
+		},
+		{
+			name:    "fail - profile without a session redirects to login",
+			request: func() *http.Request { return get("/web/profile", false) },
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusSeeOther, rec.Code)
+				assert.Equal(t, "/web/login", rec.Header().Get("Location"))
+			},
+		},
+		{
+			name:    "success - profile page",
+			request: func() *http.Request { return get("/web/profile?saved=profile", true) },
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), "joedoe@gmail.com")
+				assert.Contains(t, rec.Body.String(), `name="csrf_token" value="csrf"`)
+				assert.Contains(t, rec.Body.String(), "Your profile was saved")
+			},
+		},
+		{
+			name: "success - update profile",
+			request: func() *http.Request {
+				return post("/web/profile", url.Values{"csrf_token": {"csrf"}, "name": {"Joseph"}, "locale": {"pl"}}, true)
+			},
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusSeeOther, rec.Code)
+				assert.Equal(t, "Joseph", a.profile.Name)
+				assert.Equal(t, "pl", a.profile.Locale)
+			},
+		},
+		{
+			name: "fail - update profile with an unsupported locale",
+			request: func() *http.Request {
+				return post("/web/profile", url.Values{"csrf_token": {"csrf"}, "name": {"Joseph"}, "locale": {"tlh"}}, true)
+			},
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+				assert.Contains(t, rec.Body.String(), "The locale is not supported")
+				assert.Equal(t, "Joe", a.profile.Name)
+			},
+		},
+		{
+			name: "fail - form without the csrf token",
+			request: func() *http.Request {
+				return post("/web/profile", url.Values{"name": {"Mallory"}}, true)
+			},
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusForbidden, rec.Code)
+				assert.Equal(t, "Joe", a.profile.Name)
+			},
+		},
+		{
+			name: "success - change password",
+			request: func() *http.Request {
+				return post("/web/password", url.Values{"csrf_token": {"csrf"}, "current_password": {"Test123!"}, "new_password": {"Better123!"}}, true)
+			},
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusSeeOther, rec.Code)
+				assert.Equal(t, "/web/profile?saved=password", rec.Header().Get("Location"))
+				assert.Equal(t, "Better123!", a.password)
+				assert.Contains(t, a.sessions, "token", "stays signed in")
+			},
+		},
+		{
+			name: "fail - change password with a wrong current one",
+			request: func() *http.Request {
+				return post("/web/password", url.Values{"csrf_token": {"csrf"}, "current_password": {"wrong"}, "new_password": {"Better123!"}}, true)
+			},
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+				assert.Contains(t, rec.Body.String(), "Incorrect email or password")
+				assert.Equal(t, "Test123!", a.password)
+			},
+		},
+		{
+			name:    "success - sessions page",
+			request: func() *http.Request { return get("/web/sessions", true) },
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), `action="/web/sessions/2/revoke"`)
+				assert.Contains(t, rec.Body.String(), "this browser")
+				assert.Contains(t, rec.Body.String(), "Joe&#39;s phone")
+				assert.Contains(t, rec.Body.String(), "192.0.2.2")
+			},
+		},
+		{
+			name: "success - revoking the current session logs out",
+			request: func() *http.Request {
+				return post("/web/sessions/1/revoke", url.Values{"csrf_token": {"csrf"}}, true)
+			},
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusSeeOther, rec.Code)
+				assert.Equal(t, "/web/login", rec.Header().Get("Location"))
+			},
+		},
+		{
+			name:    "success - logout",
+			request: func() *http.Request { return post("/web/logout", url.Values{"csrf_token": {"csrf"}}, true) },
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusSeeOther, rec.Code)
+				assert.Empty(t, a.sessions)
+				assert.Contains(t, rec.Header().Get("Set-Cookie"), "Max-Age=0")
+			},
+		},
+		{
+			name:    "success - static assets",
+			request: func() *http.Request { return get("/web/static/app.css", false) },
+			verify: func(t *testing.T, rec *httptest.ResponseRecorder, a *accounts) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Header().Get("Content-Type"), "text/css")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newAccounts()
+			rec := httptest.NewRecorder()
+			newRouter(t, a).ServeHTTP(rec, tt.request())
+			tt.verify(t, rec, a)
+		})
+	}
+}